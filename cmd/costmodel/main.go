@@ -6,6 +6,7 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"github.com/kubecost/cost-model/pkg/costmodel"
 	"github.com/kubecost/cost-model/pkg/errors"
+	"github.com/kubecost/cost-model/pkg/util/httputil"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/rs/cors"
 	"k8s.io/klog"
@@ -22,8 +23,8 @@ func main() {
 
 	rootMux := http.NewServeMux()
 	a.Router.GET("/healthz", Healthz)
-	rootMux.Handle("/", a.Router)
+	rootMux.Handle("/", a.WithAuth(a.Router))
 	rootMux.Handle("/metrics", promhttp.Handler())
-	handler := cors.AllowAll().Handler(rootMux)
+	handler := cors.AllowAll().Handler(httputil.RequestIDMiddleware(rootMux))
 	klog.Fatal(http.ListenAndServe(":9003", errors.PanicHandlerMiddleware(handler)))
 }
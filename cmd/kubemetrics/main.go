@@ -70,6 +70,10 @@ func main() {
 		EmitNamespaceAnnotations:      env.IsEmitNamespaceAnnotationsMetric(),
 		EmitPodAnnotations:            env.IsEmitPodAnnotationsMetric(),
 		EmitKubeStateMetrics:          true,
+		EmitIngressMetrics:            env.IsEmitIngressMetrics(),
+		EmitAdmissionWebhookMetrics:   env.IsEmitAdmissionWebhookMetrics(),
+		EmitStorageClassMetrics:       env.IsEmitStorageClassMetrics(),
+		LabelAllowlist:                env.GetNodeLabelAllowlist(),
 	})
 
 	rootMux := http.NewServeMux()
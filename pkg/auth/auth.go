@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//--------------------------------------------------------------------------
+//  Role
+//--------------------------------------------------------------------------
+
+// Role is the level of access granted to an authenticated client. Roles are
+// ordered, so that a role grants access to everything that lesser roles do.
+type Role int
+
+const (
+	RoleNone Role = iota
+	RoleReadOnly
+	RoleAdmin
+)
+
+// The string representation of Role
+func (r Role) String() string {
+	switch r {
+	case RoleReadOnly:
+		return "read-only"
+	case RoleAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+// ParseRole parses a Role from its string representation, ignoring case.
+func ParseRole(s string) (Role, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "read-only", "readonly", "read":
+		return RoleReadOnly, nil
+	case "admin":
+		return RoleAdmin, nil
+	default:
+		return RoleNone, fmt.Errorf("unknown role: %s", s)
+	}
+}
+
+//--------------------------------------------------------------------------
+//  Authenticator
+//--------------------------------------------------------------------------
+
+var (
+	// ErrMissingToken is returned when a request has no bearer token
+	ErrMissingToken = errors.New("missing bearer token")
+
+	// ErrInvalidToken is returned when a bearer token is not recognized or
+	// cannot be verified
+	ErrInvalidToken = errors.New("invalid bearer token")
+
+	// ErrExpiredToken is returned when a bearer token has expired
+	ErrExpiredToken = errors.New("expired bearer token")
+)
+
+// Authenticator validates bearer tokens.
+type Authenticator interface {
+	// Authenticate returns the Role granted by the given bearer token, or an
+	// error if the token is not valid.
+	Authenticate(token string) (Role, error)
+}
+
+// StaticTokenAuthenticator is an Authenticator which grants each of a fixed
+// set of tokens a Role.
+type StaticTokenAuthenticator struct {
+	tokens map[string]Role
+}
+
+// NewStaticTokenAuthenticator creates a new StaticTokenAuthenticator which
+// grants the given tokens their roles.
+func NewStaticTokenAuthenticator(tokens map[string]Role) *StaticTokenAuthenticator {
+	return &StaticTokenAuthenticator{
+		tokens: tokens,
+	}
+}
+
+// ParseStaticTokens parses tokens and their roles, formatted as a
+// comma-separated list of token:role pairs; e.g. "s3cr3t:admin,t0k3n:read-only"
+func ParseStaticTokens(s string) (map[string]Role, error) {
+	tokens := make(map[string]Role)
+
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		i := strings.LastIndex(pair, ":")
+		if i <= 0 {
+			return nil, fmt.Errorf("illegal token: expected <token>:<role>")
+		}
+
+		role, err := ParseRole(pair[i+1:])
+		if err != nil {
+			return nil, err
+		}
+
+		tokens[pair[:i]] = role
+	}
+
+	return tokens, nil
+}
+
+// Authenticate returns the Role granted to the given token.
+func (sta *StaticTokenAuthenticator) Authenticate(token string) (Role, error) {
+	if token == "" {
+		return RoleNone, ErrMissingToken
+	}
+
+	// Compare against every token in constant time, so that response times do
+	// not reveal how much of a token was guessed
+	granted, found := RoleNone, false
+	for t, role := range sta.tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			granted, found = role, true
+		}
+	}
+	if !found {
+		return RoleNone, ErrInvalidToken
+	}
+
+	return granted, nil
+}
+
+// ChainAuthenticator is an Authenticator which tries each of its
+// Authenticators in order, returning the first Role granted.
+type ChainAuthenticator []Authenticator
+
+// Authenticate returns the Role granted by the first Authenticator to accept
+// the given token. If none do, the most specific error is returned; e.g. an
+// expired JWT is reported as expired rather than as unknown to a static list.
+func (ca ChainAuthenticator) Authenticate(token string) (Role, error) {
+	if token == "" {
+		return RoleNone, ErrMissingToken
+	}
+
+	err := ErrInvalidToken
+	for _, authenticator := range ca {
+		role, e := authenticator.Authenticate(token)
+		if e == nil {
+			return role, nil
+		}
+		if e == ErrExpiredToken {
+			err = e
+		}
+	}
+
+	return RoleNone, err
+}
@@ -0,0 +1,280 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+const (
+	// DefaultRoleClaim is the JWT claim from which a token's role is read
+	DefaultRoleClaim string = "role"
+
+	// JWKSMinRefreshInterval is the minimum interval between fetches of the
+	// JWKS, which is refreshed when a token is signed by an unknown key
+	JWKSMinRefreshInterval time.Duration = time.Minute
+)
+
+// jwtHeader is the header of a JWT
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// jsonWebKey is an RSA public key in the JWK format
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+}
+
+// jsonWebKeySet is a set of keys in the JWKS format
+type jsonWebKeySet struct {
+	Keys []*jsonWebKey `json:"keys"`
+}
+
+// JWKSAuthenticator is an Authenticator which verifies RS256-signed JWTs using
+// the public keys published at a JWKS URL, and grants the role given by the
+// token's role claim. The claim may be a single role or a list of roles, of
+// which the greatest is granted. Tokens must have an expiry, and if Issuer or
+// Audience are set, tokens must have been issued by, or for, them.
+type JWKSAuthenticator struct {
+	URL       string
+	RoleClaim string
+	Issuer    string
+	Audience  string
+	Client    *http.Client
+
+	lock      sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	lastFetch time.Time
+	now       func() time.Time
+}
+
+// NewJWKSAuthenticator creates a new JWKSAuthenticator which fetches keys from
+// the given URL. If roleClaim is empty, DefaultRoleClaim is used.
+func NewJWKSAuthenticator(url, roleClaim string) *JWKSAuthenticator {
+	if roleClaim == "" {
+		roleClaim = DefaultRoleClaim
+	}
+
+	return &JWKSAuthenticator{
+		URL:       url,
+		RoleClaim: roleClaim,
+		Client:    &http.Client{Timeout: 30 * time.Second},
+		keys:      make(map[string]*rsa.PublicKey),
+		now:       time.Now,
+	}
+}
+
+// Authenticate verifies the given JWT and returns the Role it grants.
+func (ja *JWKSAuthenticator) Authenticate(token string) (Role, error) {
+	if token == "" {
+		return RoleNone, ErrMissingToken
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return RoleNone, ErrInvalidToken
+	}
+
+	header := &jwtHeader{}
+	if err := decodeJWTSegment(parts[0], header); err != nil {
+		return RoleNone, ErrInvalidToken
+	}
+	if header.Algorithm != "RS256" {
+		return RoleNone, ErrInvalidToken
+	}
+
+	key, ok := ja.key(header.KeyID)
+	if !ok {
+		return RoleNone, ErrInvalidToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return RoleNone, ErrInvalidToken
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return RoleNone, ErrInvalidToken
+	}
+
+	claims := map[string]interface{}{}
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return RoleNone, ErrInvalidToken
+	}
+
+	now := float64(ja.now().Unix())
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return RoleNone, ErrInvalidToken
+	}
+	if now >= exp {
+		return RoleNone, ErrExpiredToken
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now < nbf {
+		return RoleNone, ErrInvalidToken
+	}
+	if ja.Issuer != "" && claims["iss"] != ja.Issuer {
+		return RoleNone, ErrInvalidToken
+	}
+	if ja.Audience != "" && !hasAudience(claims["aud"], ja.Audience) {
+		return RoleNone, ErrInvalidToken
+	}
+
+	return roleFromClaim(claims[ja.RoleClaim]), nil
+}
+
+// hasAudience returns true if the given aud claim, which may be a single
+// audience or a list of audiences, contains the audience.
+func hasAudience(claim interface{}, audience string) bool {
+	switch c := claim.(type) {
+	case string:
+		return c == audience
+	case []interface{}:
+		for _, value := range c {
+			if s, ok := value.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// roleFromClaim returns the greatest Role in the given claim value, which may
+// be a single role or a list of roles. Unknown roles are ignored.
+func roleFromClaim(claim interface{}) Role {
+	var values []interface{}
+	switch c := claim.(type) {
+	case string:
+		values = []interface{}{c}
+	case []interface{}:
+		values = c
+	}
+
+	granted := RoleNone
+	for _, value := range values {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		if role, err := ParseRole(s); err == nil && role > granted {
+			granted = role
+		}
+	}
+
+	return granted
+}
+
+// decodeJWTSegment decodes the given base64url-encoded JSON segment of a JWT
+// into v.
+func decodeJWTSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// key returns the public key with the given ID, refreshing the keys from the
+// JWKS URL if the key is unknown and they have not been refreshed recently.
+// The keys are fetched without holding the lock, so that requests with known
+// keys are not delayed by the fetch.
+func (ja *JWKSAuthenticator) key(kid string) (*rsa.PublicKey, bool) {
+	ja.lock.RLock()
+	key, ok := ja.keys[kid]
+	stale := ja.now().Sub(ja.lastFetch) >= JWKSMinRefreshInterval
+	ja.lock.RUnlock()
+
+	if ok || !stale {
+		return key, ok
+	}
+
+	// Claim the refresh, unless another request has already done so while
+	// waiting for the lock
+	ja.lock.Lock()
+	if ja.now().Sub(ja.lastFetch) < JWKSMinRefreshInterval {
+		key, ok := ja.keys[kid]
+		ja.lock.Unlock()
+		return key, ok
+	}
+	ja.lastFetch = ja.now()
+	ja.lock.Unlock()
+
+	keys, err := ja.fetchKeys()
+	if err != nil {
+		log.Warningf("Failed to fetch JWKS: %s", err)
+		return nil, false
+	}
+
+	ja.lock.Lock()
+	ja.keys = keys
+	ja.lock.Unlock()
+
+	key, ok = keys[kid]
+	return key, ok
+}
+
+// fetchKeys fetches the RSA public keys published at the JWKS URL, by key ID.
+func (ja *JWKSAuthenticator) fetchKeys() (map[string]*rsa.PublicKey, error) {
+	resp, err := ja.Client.Get(ja.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s: %s", ja.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to query %s: status %d", ja.URL, resp.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from %s: %s", ja.URL, err)
+	}
+
+	jwks := &jsonWebKeySet{}
+	if err := json.Unmarshal(data, jwks); err != nil {
+		return nil, fmt.Errorf("failed to decode response from %s: %s", ja.URL, err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk == nil || jwk.KeyType != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			log.Warningf("Failed to decode modulus of JWK %s", jwk.KeyID)
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			log.Warningf("Failed to decode exponent of JWK %s", jwk.KeyID)
+			continue
+		}
+
+		keys[jwk.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+func signTestJWT(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	encode := func(v interface{}) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signed := encode(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid}) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newTestJWKSServer(t *testing.T, key *rsa.PrivateKey, kid string, fetches *int) *httptest.Server {
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": kid,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}},
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*fetches++
+		data, _ := json.Marshal(jwks)
+		w.Write(data)
+	}))
+}
+
+func TestJWKSAuthenticator(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	fetches := 0
+	server := newTestJWKSServer(t, key, "key-1", &fetches)
+	defer server.Close()
+
+	now := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ja := NewJWKSAuthenticator(server.URL, "")
+	ja.now = func() time.Time { return now }
+
+	valid := now.Add(time.Hour).Unix()
+	expired := now.Add(-time.Hour).Unix()
+
+	cases := []struct {
+		name     string
+		token    string
+		role     Role
+		expected error
+	}{
+		{"missing", "", RoleNone, ErrMissingToken},
+		{"malformed", "not.a-jwt", RoleNone, ErrInvalidToken},
+		{"read-only", signTestJWT(t, key, "key-1", map[string]interface{}{"exp": valid, "role": "read-only"}), RoleReadOnly, nil},
+		{"greatest of roles", signTestJWT(t, key, "key-1", map[string]interface{}{"exp": valid, "role": []string{"read-only", "admin"}}), RoleAdmin, nil},
+		{"no role", signTestJWT(t, key, "key-1", map[string]interface{}{"exp": valid}), RoleNone, nil},
+		{"expired", signTestJWT(t, key, "key-1", map[string]interface{}{"exp": expired, "role": "admin"}), RoleNone, ErrExpiredToken},
+		{"no expiry", signTestJWT(t, key, "key-1", map[string]interface{}{"role": "admin"}), RoleNone, ErrInvalidToken},
+		{"not yet valid", signTestJWT(t, key, "key-1", map[string]interface{}{"exp": valid, "nbf": valid, "role": "admin"}), RoleNone, ErrInvalidToken},
+		{"wrong key", signTestJWT(t, otherKey, "key-1", map[string]interface{}{"exp": valid, "role": "admin"}), RoleNone, ErrInvalidToken},
+		{"unknown key", signTestJWT(t, key, "key-2", map[string]interface{}{"exp": valid, "role": "admin"}), RoleNone, ErrInvalidToken},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			role, err := ja.Authenticate(c.token)
+			if err != c.expected {
+				t.Fatalf("expected error %v; got %v", c.expected, err)
+			}
+			if role != c.role {
+				t.Fatalf("expected role %s; got %s", c.role, role)
+			}
+		})
+	}
+
+	// The unknown key does not cause a refetch within the minimum interval
+	if fetches != 1 {
+		t.Fatalf("expected 1 JWKS fetch; got %d", fetches)
+	}
+}
+
+func TestJWKSAuthenticatorIssuerAudience(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	fetches := 0
+	server := newTestJWKSServer(t, key, "key-1", &fetches)
+	defer server.Close()
+
+	ja := NewJWKSAuthenticator(server.URL, "")
+	ja.Issuer = "https://issuer.example.com"
+	ja.Audience = "cost-model"
+
+	valid := time.Now().Add(time.Hour).Unix()
+
+	cases := []struct {
+		name   string
+		claims map[string]interface{}
+		role   Role
+	}{
+		{"matching", map[string]interface{}{"iss": "https://issuer.example.com", "aud": "cost-model"}, RoleAdmin},
+		{"audience in list", map[string]interface{}{"iss": "https://issuer.example.com", "aud": []string{"other", "cost-model"}}, RoleAdmin},
+		{"wrong issuer", map[string]interface{}{"iss": "https://other.example.com", "aud": "cost-model"}, RoleNone},
+		{"missing issuer", map[string]interface{}{"aud": "cost-model"}, RoleNone},
+		{"wrong audience", map[string]interface{}{"iss": "https://issuer.example.com", "aud": "other"}, RoleNone},
+		{"missing audience", map[string]interface{}{"iss": "https://issuer.example.com"}, RoleNone},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			c.claims["exp"] = valid
+			c.claims["role"] = "admin"
+
+			role, err := ja.Authenticate(signTestJWT(t, key, "key-1", c.claims))
+			if c.role == RoleNone && err != ErrInvalidToken {
+				t.Fatalf("expected error %v; got %v", ErrInvalidToken, err)
+			}
+			if role != c.role {
+				t.Fatalf("expected role %s; got %s", c.role, role)
+			}
+		})
+	}
+}
+
+func TestMiddlewareHandlerExpiredJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	fetches := 0
+	server := newTestJWKSServer(t, key, "key-1", &fetches)
+	defer server.Close()
+
+	// Static tokens are tried first, and an expired JWT is reported as such
+	h := NewMiddleware(ChainAuthenticator{
+		NewStaticTokenAuthenticator(map[string]Role{"admin-token": RoleAdmin}),
+		NewJWKSAuthenticator(server.URL, "groups"),
+	}).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	token := signTestJWT(t, key, "key-1", map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix(), "groups": "admin"})
+	w := serve(h, http.MethodGet, "/budgets", "Bearer "+token)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status %d; got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	resp := &errorResponse{}
+	if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil || resp.Message != ErrExpiredToken.Error() {
+		t.Fatalf("expected expired token error; got %s", w.Body.String())
+	}
+
+	token = signTestJWT(t, key, "key-1", map[string]interface{}{"exp": time.Now().Add(time.Minute).Unix(), "groups": "admin"})
+	if w := serve(h, http.MethodPut, "/budgets", "Bearer "+token); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d; got %d", http.StatusOK, w.Code)
+	}
+	if w := serve(h, http.MethodPut, "/budgets", "Bearer admin-token"); w.Code != http.StatusOK {
+		t.Fatalf("expected status %d; got %d", http.StatusOK, w.Code)
+	}
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+// errorResponse is the JSON body of an authentication or authorization
+// failure, matching the format of other API errors.
+type errorResponse struct {
+	Code    int    `json:"code"`
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// Middleware is HTTP middleware which authenticates each request's bearer
+// token, and authorizes the request if the token's Role is at least the Role
+// required by the request's method.
+type Middleware struct {
+	Authenticator Authenticator
+
+	// ExemptPaths are paths which do not require authentication; e.g. health
+	// checks
+	ExemptPaths []string
+
+	// AdminPaths are paths which require RoleAdmin for any method; e.g. reads
+	// of configuration which is not for read-only clients
+	AdminPaths []string
+
+	// ReadOnlyPaths are paths which require only RoleReadOnly for any method;
+	// e.g. queries which are POSTed for their request body, but do not mutate
+	// state
+	ReadOnlyPaths []string
+}
+
+// NewMiddleware creates a new Middleware using the given Authenticator, which
+// does not require authentication for the given paths.
+func NewMiddleware(authenticator Authenticator, exemptPaths ...string) *Middleware {
+	return &Middleware{
+		Authenticator: authenticator,
+		ExemptPaths:   exemptPaths,
+	}
+}
+
+// RequiredRole returns the Role required for requests with the given method.
+// Reads require RoleReadOnly, and anything which may mutate state, e.g.
+// pricing config updates and budget changes, requires RoleAdmin.
+func RequiredRole(method string) Role {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return RoleReadOnly
+	default:
+		return RoleAdmin
+	}
+}
+
+// Handler wraps the given handler, responding with 401 Unauthorized to
+// requests without a valid bearer token, and with 403 Forbidden to requests
+// whose token does not grant the required Role.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, path := range m.ExemptPaths {
+			if r.URL.Path == path {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+
+		role, err := m.Authenticator.Authenticate(bearerToken(r))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="cost-model"`)
+			writeError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		required := m.requiredRole(r)
+		if role < required {
+			log.Infof("Denied %s %s: role %s does not have %s access", r.Method, r.URL.Path, role, required)
+			writeError(w, http.StatusForbidden, "insufficient role: "+required.String()+" required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requiredRole returns the Role required for the given request, which is
+// RoleAdmin for AdminPaths, RoleReadOnly for ReadOnlyPaths, and otherwise
+// depends on its method.
+func (m *Middleware) requiredRole(r *http.Request) Role {
+	for _, path := range m.AdminPaths {
+		if r.URL.Path == path {
+			return RoleAdmin
+		}
+	}
+
+	for _, path := range m.ReadOnlyPaths {
+		if r.URL.Path == path {
+			return RoleReadOnly
+		}
+	}
+
+	return RequiredRole(r.Method)
+}
+
+// bearerToken returns the bearer token of the given request's Authorization
+// header, or an empty string if there is none.
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) < len("Bearer ") || !strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return ""
+	}
+
+	return strings.TrimSpace(header[len("Bearer "):])
+}
+
+// writeError writes a JSON error response with the given status code.
+func writeError(w http.ResponseWriter, code int, message string) {
+	body, _ := json.Marshal(&errorResponse{
+		Code:    code,
+		Status:  "error",
+		Message: message,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(body)
+}
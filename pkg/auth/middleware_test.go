@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+func newTestMiddleware() *Middleware {
+	m := NewMiddleware(NewStaticTokenAuthenticator(map[string]Role{
+		"reader-token": RoleReadOnly,
+		"admin-token":  RoleAdmin,
+	}), "/healthz")
+	m.AdminPaths = []string{"/config/dump"}
+	m.ReadOnlyPaths = []string{"/whatIf"}
+	return m
+}
+
+func serve(h http.Handler, method, path, authorization string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestMiddlewareHandler(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	h := newTestMiddleware().Handler(ok)
+
+	cases := []struct {
+		name          string
+		method        string
+		path          string
+		authorization string
+		expected      int
+	}{
+		{"missing token", http.MethodGet, "/allocation/compute", "", http.StatusUnauthorized},
+		{"not a bearer token", http.MethodGet, "/allocation/compute", "Basic cmVhZGVyOnRva2Vu", http.StatusUnauthorized},
+		{"unknown token", http.MethodGet, "/allocation/compute", "Bearer unknown-token", http.StatusUnauthorized},
+		{"reader can read", http.MethodGet, "/allocation/compute", "Bearer reader-token", http.StatusOK},
+		{"reader cannot update budgets", http.MethodPut, "/budgets", "Bearer reader-token", http.StatusForbidden},
+		{"reader cannot delete budgets", http.MethodDelete, "/budgets/1", "Bearer reader-token", http.StatusForbidden},
+		{"reader cannot refresh pricing", http.MethodPost, "/refreshPricing", "Bearer reader-token", http.StatusForbidden},
+		{"admin can read", http.MethodGet, "/budgets", "bearer admin-token", http.StatusOK},
+		{"admin can update budgets", http.MethodPut, "/budgets", "Bearer admin-token", http.StatusOK},
+		{"exempt path", http.MethodGet, "/healthz", "", http.StatusOK},
+		{"reader cannot read admin path", http.MethodGet, "/config/dump", "Bearer reader-token", http.StatusForbidden},
+		{"admin can read admin path", http.MethodGet, "/config/dump", "Bearer admin-token", http.StatusOK},
+		{"reader can post to read-only path", http.MethodPost, "/whatIf", "Bearer reader-token", http.StatusOK},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := serve(h, c.method, c.path, c.authorization)
+			if w.Code != c.expected {
+				t.Fatalf("expected status %d; got %d", c.expected, w.Code)
+			}
+		})
+	}
+}
+
+func TestMiddlewareHandlerErrorBody(t *testing.T) {
+	h := newTestMiddleware().Handler(http.NotFoundHandler())
+
+	for _, expected := range []int{http.StatusUnauthorized, http.StatusForbidden} {
+		authorization := ""
+		if expected == http.StatusForbidden {
+			authorization = "Bearer reader-token"
+		}
+
+		w := serve(h, http.MethodPut, "/budgets", authorization)
+		if w.Code != expected {
+			t.Fatalf("expected status %d; got %d", expected, w.Code)
+		}
+		if w.Header().Get("Content-Type") != "application/json" {
+			t.Fatalf("expected JSON content type; got %s", w.Header().Get("Content-Type"))
+		}
+
+		resp := &errorResponse{}
+		if err := json.Unmarshal(w.Body.Bytes(), resp); err != nil {
+			t.Fatalf("expected JSON error body; got %s", w.Body.String())
+		}
+		if resp.Code != expected || resp.Status != "error" || resp.Message == "" {
+			t.Fatalf("unexpected error body: %+v", resp)
+		}
+	}
+}
+
+func TestParseStaticTokens(t *testing.T) {
+	tokens, err := ParseStaticTokens("abc:admin, d:e:f:read-only,")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(tokens) != 2 || tokens["abc"] != RoleAdmin || tokens["d:e:f"] != RoleReadOnly {
+		t.Fatalf("unexpected tokens: %v", tokens)
+	}
+
+	if _, err := ParseStaticTokens("abc:superuser"); err == nil {
+		t.Fatalf("expected error for unknown role")
+	}
+	if _, err := ParseStaticTokens("abc"); err == nil {
+		t.Fatalf("expected error for token without role")
+	}
+}
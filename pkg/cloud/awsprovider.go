@@ -78,6 +78,13 @@ func (aws *AWS) PricingSourceStatus() map[string]*PricingSource {
 		rps.Available = true
 	}
 	sources[ReservedInstancePricingSource] = rps
+
+	if env.IsPricingOfflineEnabled() {
+		sps.Available, sps.Error = false, "disabled in offline mode"
+		rps.Available, rps.Error = false, "disabled in offline mode"
+		sources[StaticPricingSource] = staticPricingStatus(awsStaticPricingFile)
+	}
+
 	return sources
 
 }
@@ -433,14 +440,9 @@ func (aws *AWS) UpdateConfig(r io.Reader, updateType string) (*CustomPricing, er
 			}
 			for k, v := range a {
 				kUpper := strings.Title(k) // Just so we consistently supply / receive the same values, uppercase the first letter.
-				vstr, ok := v.(string)
-				if ok {
-					err := SetCustomPricingField(c, kUpper, vstr)
-					if err != nil {
-						return err
-					}
-				} else {
-					return fmt.Errorf("type error while updating config for %s", kUpper)
+				err := SetCustomPricingFieldValue(c, kUpper, v)
+				if err != nil {
+					return err
 				}
 			}
 		}
@@ -473,7 +475,7 @@ func (k *awsKey) ID() string {
 			return group
 		}
 	}
-	klog.V(3).Infof("Could not find instance ID in \"%s\"", k.ProviderID)
+	cloudLog.WithFields(log.Fields{"providerID": k.ProviderID}).Infof("Could not find instance ID in \"%s\"", k.ProviderID)
 	return ""
 }
 
@@ -498,7 +500,7 @@ func (k *awsKey) Features() string {
 func (aws *AWS) PVPricing(pvk PVKey) (*PV, error) {
 	pricing, ok := aws.Pricing[pvk.Features()]
 	if !ok {
-		klog.V(4).Infof("Persistent Volume pricing not found for %s: %s", pvk.GetStorageClass(), pvk.Features())
+		cloudLog.WithFields(log.Fields{"storageClass": pvk.GetStorageClass()}).Debugf("Persistent Volume pricing not found for %s: %s", pvk.GetStorageClass(), pvk.Features())
 		return &PV{}, nil
 	}
 	return pricing.PV, nil
@@ -552,7 +554,7 @@ func (key *awsPVKey) Features() string {
 	//}
 	class, ok := volTypes[storageClass]
 	if !ok {
-		klog.V(4).Infof("No voltype mapping for %s's storageClass: %s", key.Name, storageClass)
+		cloudLog.WithFields(log.Fields{"volume": key.Name, "storageClass": storageClass}).Debugf("No voltype mapping for %s's storageClass: %s", key.Name, storageClass)
 	}
 	return region + "," + class
 }
@@ -622,6 +624,22 @@ func (aws *AWS) getRegionPricing(nodeList []*v1.Node) (*http.Response, string, e
 	return resp, pricingURL, err
 }
 
+// getPricingCatalog returns the AWS Price List offer file of the nodes' region,
+// and its location, which in offline mode is the static price file rather
+// than the Pricing API.
+func (aws *AWS) getPricingCatalog(nodeList []*v1.Node, offline bool) (io.ReadCloser, string, error) {
+	if offline {
+		klog.V(2).Infof("Pricing offline mode enabled, loading static pricing file")
+		return openStaticPricing(awsStaticPricingFile)
+	}
+
+	resp, pricingURL, err := aws.getRegionPricing(nodeList)
+	if err != nil {
+		return nil, pricingURL, err
+	}
+	return resp.Body, pricingURL, nil
+}
+
 // DownloadPricingData fetches data from the AWS Pricing API
 func (aws *AWS) DownloadPricingData() error {
 	aws.DownloadPricingDataLock.Lock()
@@ -691,9 +709,12 @@ func (aws *AWS) DownloadPricingData() error {
 		pvkeys[key.Features()] = key
 	}
 
+	// In offline mode, prices are loaded from the static price file alone
+	offline := env.IsPricingOfflineEnabled()
+
 	// RIDataRunning establishes the existance of the goroutine. Since it's possible we
 	// run multiple downloads, we don't want to create multiple go routines if one already exists
-	if !aws.RIDataRunning && c.AthenaBucketName != "" {
+	if !offline && !aws.RIDataRunning && c.AthenaBucketName != "" {
 		err = aws.GetReservationDataFromAthena() // Block until one run has completed.
 		if err != nil {
 			klog.V(1).Infof("Failed to lookup reserved instance data: %s", err.Error())
@@ -713,7 +734,7 @@ func (aws *AWS) DownloadPricingData() error {
 			}()
 		}
 	}
-	if !aws.SavingsPlanDataRunning && c.AthenaBucketName != "" {
+	if !offline && !aws.SavingsPlanDataRunning && c.AthenaBucketName != "" {
 		err = aws.GetSavingsPlanDataFromAthena()
 		if err != nil {
 			klog.V(1).Infof("Failed to lookup savings plan data: %s", err.Error())
@@ -737,18 +758,20 @@ func (aws *AWS) DownloadPricingData() error {
 	aws.ValidPricingKeys = make(map[string]bool)
 	skusToKeys := make(map[string]string)
 
-	resp, pricingURL, err := aws.getRegionPricing(nodeList)
+	body, pricingURL, err := aws.getPricingCatalog(nodeList, offline)
 	if err != nil {
 		return err
 	}
-	dec := json.NewDecoder(resp.Body)
+	defer body.Close()
+
+	dec := json.NewDecoder(body)
 	for {
 		t, err := dec.Token()
 		if err == io.EOF {
 			klog.V(2).Infof("done loading \"%s\"\n", pricingURL)
 			break
 		} else if err != nil {
-			klog.V(2).Infof("error parsing response json %v", resp.Body)
+			klog.V(2).Infof("error parsing response json from \"%s\": %s", pricingURL, err)
 			break
 		}
 		if t == "products" {
@@ -885,6 +908,11 @@ func (aws *AWS) DownloadPricingData() error {
 	}
 	klog.V(2).Infof("Finished downloading \"%s\"", pricingURL)
 
+	// Spot data feeds are not available offline
+	if offline {
+		return nil
+	}
+
 	// Always run spot pricing refresh when performing download
 	aws.refreshSpotPricing(true)
 
@@ -953,9 +981,10 @@ func (aws *AWS) NetworkPricing() (*Network, error) {
 	}
 
 	return &Network{
-		ZoneNetworkEgressCost:     znec,
-		RegionNetworkEgressCost:   rnec,
-		InternetNetworkEgressCost: inec,
+		ZoneNetworkEgressCost:      znec,
+		RegionNetworkEgressCost:    rnec,
+		InternetNetworkEgressCost:  inec,
+		InternetNetworkEgressTiers: cpricing.InternetNetworkEgressTiers,
 	}, nil
 }
 
@@ -1014,12 +1043,12 @@ func (aws *AWS) createNode(terms *AWSProductTerms, usageType string, k Key) (*No
 
 	if spotInfo, ok := aws.spotPricing(k.ID()); ok {
 		var spotcost string
-		log.DedupedInfof(5, "Looking up spot data from feed for node %s", k.ID())
+		cloudLog.WithFields(log.Fields{"node": k.ID()}).DedupedInfof(5, "Looking up spot data from feed for node %s", k.ID())
 		arr := strings.Split(spotInfo.Charge, " ")
 		if len(arr) == 2 {
 			spotcost = arr[0]
 		} else {
-			klog.V(2).Infof("Spot data for node %s is missing", k.ID())
+			cloudLog.WithFields(log.Fields{"node": k.ID()}).RateLimitedWarningf("aws-spot-data-missing", "Spot data for node %s is missing", k.ID())
 		}
 		return &Node{
 			Cost:         spotcost,
@@ -1033,7 +1062,7 @@ func (aws *AWS) createNode(terms *AWSProductTerms, usageType string, k Key) (*No
 			UsageType:    PreemptibleType,
 		}, nil
 	} else if aws.isPreemptible(key) { // Preemptible but we don't have any data in the pricing report.
-		log.DedupedWarningf(5, "Node %s marked preemptible but we have no data in spot feed", k.ID())
+		cloudLog.WithFields(log.Fields{"node": k.ID()}).RateLimitedWarningf("aws-spot-feed-missing", "Node %s marked preemptible but we have no data in spot feed", k.ID())
 		return &Node{
 			VCPU:         terms.VCpu,
 			VCPUCost:     aws.BaseSpotCPUPrice,
@@ -1416,7 +1445,7 @@ func (a *AWS) GetAddresses() ([]byte, error) {
 
 	errors := []error{}
 	for err := range errorCh {
-		log.DedupedWarningf(5, "unable to get addresses: %s", err)
+		cloudLog.RateLimitedWarningf("aws-addresses", "unable to get addresses: %s", err)
 		errors = append(errors, err)
 	}
 
@@ -1520,7 +1549,7 @@ func (a *AWS) GetDisks() ([]byte, error) {
 
 	errors := []error{}
 	for err := range errorCh {
-		log.DedupedWarningf(5, "unable to get disks: %s", err)
+		cloudLog.RateLimitedWarningf("aws-disks", "unable to get disks: %s", err)
 		errors = append(errors, err)
 	}
 
@@ -1749,7 +1778,7 @@ func (a *AWS) GetSavingsPlanDataFromAthena() error {
 		}
 		klog.V(1).Infof("Found %d savings plan applied instances", len(a.SavingsPlanDataByInstanceID))
 		for k, r := range a.SavingsPlanDataByInstanceID {
-			log.DedupedInfof(5, "Savings Plan Instance Data found for node %s : %f at time %s", k, r.EffectiveCost, r.MostRecentDate)
+			cloudLog.WithFields(log.Fields{"node": k}).DedupedInfof(5, "Savings Plan Instance Data found for node %s : %f at time %s", k, r.EffectiveCost, r.MostRecentDate)
 		}
 		a.SavingsPlanDataLock.Unlock()
 		return true
@@ -1839,7 +1868,7 @@ func (a *AWS) GetReservationDataFromAthena() error {
 			}
 			klog.V(1).Infof("Found %d reserved instances", len(a.RIPricingByInstanceID))
 			for k, r := range a.RIPricingByInstanceID {
-				log.DedupedInfof(5, "Reserved Instance Data found for node %s : %f at time %s", k, r.EffectiveCost, r.MostRecentDate)
+				cloudLog.WithFields(log.Fields{"node": k}).DedupedInfof(5, "Reserved Instance Data found for node %s : %f at time %s", k, r.EffectiveCost, r.MostRecentDate)
 			}
 			a.RIDataLock.Unlock()
 		} else {
@@ -654,6 +654,16 @@ func (az *Azure) DownloadPricingData() error {
 		return err
 	}
 
+	// In offline mode, prices are loaded from the static price file alone
+	if env.IsPricingOfflineEnabled() {
+		meters, regions, err := loadAzureStaticPricing()
+		if err != nil {
+			return err
+		}
+		az.Pricing = az.priceMeters(meters, regions, config.CPU)
+		return nil
+	}
+
 	// Load the service provider keys
 	subscriptionID, clientID, clientSecret, tenantID := az.getAzureAuth(false, config)
 	config.AzureSubscriptionID = subscriptionID
@@ -706,7 +716,6 @@ func (az *Azure) DownloadPricingData() error {
 	if err != nil {
 		return err
 	}
-	allPrices := make(map[string]*AzurePricing)
 	regions, err := getRegions("compute", sClient, providersClient, config.AzureSubscriptionID)
 	if err != nil {
 		return err
@@ -716,9 +725,17 @@ func (az *Azure) DownloadPricingData() error {
 	if err != nil {
 		return err
 	}
-	baseCPUPrice := c.CPU
 
-	for _, v := range *result.Meters {
+	az.Pricing = az.priceMeters(*result.Meters, regions, c.CPU)
+	return nil
+}
+
+// priceMeters returns the prices of nodes and PVs, by key, from the meters of
+// a rate card, in the given regions of the subscription.
+func (az *Azure) priceMeters(meters []commerce.MeterInfo, regions map[string]string, baseCPUPrice string) map[string]*AzurePricing {
+	allPrices := make(map[string]*AzurePricing)
+
+	for _, v := range meters {
 		meterName := *v.MeterName
 		meterRegion := *v.MeterRegion
 		meterCategory := *v.MeterCategory
@@ -834,8 +851,34 @@ func (az *Azure) DownloadPricingData() error {
 		}
 	}
 
-	az.Pricing = allPrices
-	return nil
+	return allPrices
+}
+
+// azureStaticPricing is the static price file of Azure in offline mode, a
+// RateCard API response with the regions of the subscription
+type azureStaticPricing struct {
+	// Regions are the display names of the regions, by ID, e.g. "eastus": "East US"
+	Regions map[string]string    `json:"regions"`
+	Meters  []commerce.MeterInfo `json:"Meters"`
+}
+
+// loadAzureStaticPricing loads the meters and regions of the static price file
+func loadAzureStaticPricing() ([]commerce.MeterInfo, map[string]string, error) {
+	body, path, err := openStaticPricing(azureStaticPricingFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer body.Close()
+
+	klog.V(2).Infof("Pricing offline mode enabled, loading static pricing file %s", path)
+
+	sp := &azureStaticPricing{}
+	err = json.NewDecoder(body).Decode(sp)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse static pricing file %s: %s", path, err)
+	}
+
+	return sp.Meters, sp.Regions, nil
 }
 
 func (az *Azure) addPricing(features string, azurePricing *AzurePricing) {
@@ -878,7 +921,7 @@ func (az *Azure) NodePricing(key Key) (*Node, error) {
 
 		spotCost, err := getRetailPrice(region, instance, config.CurrencyCode, true)
 		if err != nil {
-			log.DedupedWarningf(5, "failed to retrieve spot retail pricing")
+			cloudLog.RateLimitedWarningf("azure-spot-retail-pricing", "failed to retrieve spot retail pricing")
 		} else {
 			gpu := ""
 			if azKey.isValidGPUNode() {
@@ -946,9 +989,10 @@ func (az *Azure) NetworkPricing() (*Network, error) {
 	}
 
 	return &Network{
-		ZoneNetworkEgressCost:     znec,
-		RegionNetworkEgressCost:   rnec,
-		InternetNetworkEgressCost: inec,
+		ZoneNetworkEgressCost:      znec,
+		RegionNetworkEgressCost:    rnec,
+		InternetNetworkEgressCost:  inec,
+		InternetNetworkEgressTiers: cpricing.InternetNetworkEgressTiers,
 	}, nil
 }
 
@@ -1068,14 +1112,9 @@ func (az *Azure) UpdateConfig(r io.Reader, updateType string) (*CustomPricing, e
 		}
 		for k, v := range a {
 			kUpper := strings.Title(k) // Just so we consistently supply / receive the same values, uppercase the first letter.
-			vstr, ok := v.(string)
-			if ok {
-				err := SetCustomPricingField(c, kUpper, vstr)
-				if err != nil {
-					return err
-				}
-			} else {
-				return fmt.Errorf("type error while updating config for %s", kUpper)
+			err := SetCustomPricingFieldValue(c, kUpper, v)
+			if err != nil {
+				return err
 			}
 		}
 
@@ -1300,7 +1339,11 @@ func (az *Azure) ServiceAccountStatus() *ServiceAccountStatus {
 }
 
 func (az *Azure) PricingSourceStatus() map[string]*PricingSource {
-	return make(map[string]*PricingSource)
+	sources := make(map[string]*PricingSource)
+	if env.IsPricingOfflineEnabled() {
+		sources[StaticPricingSource] = staticPricingStatus(azureStaticPricingFile)
+	}
+	return sources
 }
 
 func (*Azure) ClusterManagementPricing() (string, float64, error) {
@@ -1,8 +1,12 @@
 package cloud
 
 import (
+	"compress/gzip"
 	"fmt"
 	"io"
+	"net/http"
+	"os"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -10,17 +14,99 @@ import (
 
 	"github.com/kubecost/cost-model/pkg/clustercache"
 	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/util/atomicfile"
 	"github.com/kubecost/cost-model/pkg/util/json"
+	"github.com/kubecost/cost-model/pkg/util/watcher"
 
+	"github.com/prometheus/client_golang/prometheus"
 	v1 "k8s.io/api/core/v1"
 )
 
+// MergePatchUpdateType applies the update to the custom pricing config as a
+// JSON Merge Patch (RFC 7396) rather than as a set of key/value replacements.
+const MergePatchUpdateType = "mergepatch"
+
+// The models by which the network is billed. Per GB, egress is billed by the
+// GB transferred, whereas per hour, a fixed rate is billed for the bandwidth.
+const (
+	NetworkBillingModelPerGB   = "per_gb"
+	NetworkBillingModelPerHour = "per_hour"
+)
+
+// TimeOfDayPrice multiplies the CPU price of nodes from StartHour, inclusive,
+// to EndHour, exclusive. If EndHour is less than StartHour, the hours wrap
+// past midnight; e.g. 22 to 6.
+type TimeOfDayPrice struct {
+	StartHour     int     `json:"startHour"`
+	EndHour       int     `json:"endHour"`
+	CPUMultiplier float64 `json:"cpuMultiplier"`
+}
+
+// contains returns true if the hour is within the hours of the price
+func (tp TimeOfDayPrice) contains(hour int) bool {
+	if tp.StartHour <= tp.EndHour {
+		return hour >= tp.StartHour && hour < tp.EndHour
+	}
+	return hour >= tp.StartHour || hour < tp.EndHour
+}
+
+// validate returns an error if the hours are not between 0 and 24, or do not
+// span any time, or the multiplier is negative
+func (tp TimeOfDayPrice) validate() error {
+	if tp.StartHour < 0 || tp.StartHour > 23 || tp.EndHour < 0 || tp.EndHour > 24 {
+		return fmt.Errorf("hours %d to %d are not between 0 and 24", tp.StartHour, tp.EndHour)
+	}
+	if tp.StartHour == tp.EndHour {
+		return fmt.Errorf("hours %d to %d are empty", tp.StartHour, tp.EndHour)
+	}
+	if tp.CPUMultiplier < 0 {
+		return fmt.Errorf("CPU multiplier %f is negative", tp.CPUMultiplier)
+	}
+	return nil
+}
+
+// maxFallbackKeyLength is the length to which the keys labelling the pricing
+// fallback counter are truncated, to limit its cardinality
+const maxFallbackKeyLength = 128
+
+// customPricingFallbacks counts the keys priced at the default price because
+// they have no pricing, e.g. that of spot GPU nodes. Each key is counted once
+// each time the pricing is loaded, rather than each time a node is priced.
+var customPricingFallbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubecost_custom_provider_pricing_fallback_total",
+	Help: "kubecost_custom_provider_pricing_fallback_total Number of times a key without custom pricing was priced at the default price, once per pricing load",
+}, []string{"key"})
+
+// customPricingFallbacksInit registers the fallback counter once
+var customPricingFallbacksInit sync.Once
+
+// countPricingFallback increments the fallback counter for the given key
+func countPricingFallback(key string) {
+	customPricingFallbacksInit.Do(func() {
+		prometheus.MustRegister(customPricingFallbacks)
+	})
+
+	if len(key) > maxFallbackKeyLength {
+		key = key[:maxFallbackKeyLength]
+	}
+	customPricingFallbacks.WithLabelValues(key).Inc()
+}
+
 type NodePrice struct {
 	CPU string
 	RAM string
 	GPU string
 }
 
+// PricingSnapshot is the node pricing of a provider at a point in time, by
+// pricing key, which can be restored to a CustomProvider to replay historical
+// pricing. Snapshots are taken by pricing.TakeSnapshot.
+type PricingSnapshot struct {
+	Timestamp time.Time             `json:"timestamp"`
+	Prices    map[string]*NodePrice `json:"prices"`
+}
+
 type CustomProvider struct {
 	Clientset               clustercache.ClusterCache
 	Pricing                 map[string]*NodePrice
@@ -30,6 +116,47 @@ type CustomProvider struct {
 	GPULabelValue           string
 	DownloadPricingDataLock sync.RWMutex
 	Config                  *ProviderConfig
+
+	// NodePricingOverrideFunc, if set, is consulted by NodePricing before the
+	// configured pricing. If it returns true, the returned Node is used as-is,
+	// which allows node prices to be injected programmatically, e.g. from an
+	// external billing API.
+	NodePricingOverrideFunc func(key Key) (*Node, bool)
+
+	// BlendedRateFunc, if set, computes the rate of a mix of on-demand and spot
+	// capacity, at which NodePricing prices all nodes when BlendedPricingEnabled
+	// is set in the config. It is set by pricing.InstallBlendedPricing, as this
+	// package cannot import the pricing package.
+	BlendedRateFunc func(onDemandFraction float64, onDemandPrice, spotPrice float64) float64
+
+	// blendedPricing is true if BlendedPricingEnabled is set in the config, and
+	// onDemandFraction is the fraction of capacity which is on-demand
+	blendedPricing   bool
+	onDemandFraction float64
+
+	// timeOfDayPricing are the valid TimeOfDayPricing of the config, whose
+	// hours are in timeOfDayLocation
+	timeOfDayPricing  []TimeOfDayPrice
+	timeOfDayLocation *time.Location
+
+	// now returns the current time, with which time of day pricing is
+	// applied. If nil, time.Now is used.
+	now func() time.Time
+
+	// pricingFileWatcher reloads the pricing when the pricing file at
+	// pricingFileWatchPath changes
+	pricingFileWatcher   *watcher.Watcher
+	pricingFileWatchPath string
+
+	// externalPricingRefreshStop stops the reloading of the pricing every
+	// externalPricingRefreshInterval
+	externalPricingRefreshStop     chan struct{}
+	externalPricingRefreshInterval time.Duration
+
+	// pricingFallbacks are the keys priced at the default price since the
+	// pricing was last loaded, which have been counted
+	pricingFallbacks     map[string]bool
+	pricingFallbacksLock sync.Mutex
 }
 
 type customProviderKey struct {
@@ -40,8 +167,30 @@ type customProviderKey struct {
 	Labels         map[string]string
 }
 
-func (*CustomProvider) ClusterManagementPricing() (string, float64, error) {
-	return "", 0.0, nil
+// ClusterManagementPricing returns the configured management pricing label,
+// e.g. "rancher" or "openshift", and the hourly cost of distro licensing,
+// which is the configured price per node per hour times the number of nodes.
+func (cp *CustomProvider) ClusterManagementPricing() (string, float64, error) {
+	c, err := cp.GetConfig()
+	if err != nil {
+		return "", 0.0, err
+	}
+
+	if c.ManagementPricePerNodePerHour == "" {
+		return c.ManagementPricingLabel, 0.0, nil
+	}
+
+	price, err := strconv.ParseFloat(c.ManagementPricePerNodePerHour, 64)
+	if err != nil {
+		return c.ManagementPricingLabel, 0.0, fmt.Errorf("unable to parse management price per node per hour %s: %s", c.ManagementPricePerNodePerHour, err)
+	}
+
+	nodeCount := 0
+	if cp.Clientset != nil {
+		nodeCount = len(cp.Clientset.GetAllNodes())
+	}
+
+	return c.ManagementPricingLabel, price * float64(nodeCount), nil
 }
 
 func (*CustomProvider) GetLocalStorageQuery(window, offset time.Duration, rate bool, used bool) string {
@@ -72,18 +221,25 @@ func (cp *CustomProvider) UpdateConfig(r io.Reader, updateType string) (*CustomP
 		return nil, err
 	}
 
+	if updateType == MergePatchUpdateType {
+		c, err := cp.Config.Update(func(c *CustomPricing) error {
+			return mergePatchCustomPricing(c, a)
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		defer cp.DownloadPricingData()
+		return c, nil
+	}
+
 	// Update Config
 	c, err := cp.Config.Update(func(c *CustomPricing) error {
 		for k, v := range a {
 			kUpper := strings.Title(k) // Just so we consistently supply / receive the same values, uppercase the first letter.
-			vstr, ok := v.(string)
-			if ok {
-				err := SetCustomPricingField(c, kUpper, vstr)
-				if err != nil {
-					return err
-				}
-			} else {
-				return fmt.Errorf("type error while updating config for %s", kUpper)
+			err := SetCustomPricingFieldValue(c, kUpper, v)
+			if err != nil {
+				return err
 			}
 		}
 
@@ -98,6 +254,74 @@ func (cp *CustomProvider) UpdateConfig(r io.Reader, updateType string) (*CustomP
 	return c, nil
 }
 
+// mergePatchCustomPricing applies a JSON Merge Patch to the custom pricing config.
+// Patch keys are matched case-insensitively against the config's JSON keys; null
+// values clear the field and string values replace it, as do numbers of
+// nanoseconds for durations, and JSON values of the fields which are not strings,
+// e.g. time of day prices.
+func mergePatchCustomPricing(c *CustomPricing, patch map[string]interface{}) error {
+	keys := map[string]string{}
+	types := map[string]reflect.Type{}
+	t := reflect.TypeOf(*c)
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		keys[strings.ToLower(key)] = key
+		types[key] = t.Field(i).Type
+	}
+
+	normalized := map[string]interface{}{}
+	for k, v := range patch {
+		key, ok := keys[strings.ToLower(k)]
+		if !ok {
+			return fmt.Errorf("No such field: %s in obj", k)
+		}
+
+		switch value := v.(type) {
+		case nil:
+			normalized[key] = nil
+		case string:
+			normalized[key] = sanitizePolicy.Sanitize(value)
+		case float64:
+			if types[key] != reflect.TypeOf(JSONDuration(0)) {
+				return fmt.Errorf("type error while updating config for %s", k)
+			}
+			normalized[key] = int64(value)
+		case []interface{}, map[string]interface{}:
+			if types[key].Kind() == reflect.String || types[key] == reflect.TypeOf(JSONDuration(0)) {
+				return fmt.Errorf("type error while updating config for %s", k)
+			}
+			normalized[key] = value
+		default:
+			return fmt.Errorf("type error while updating config for %s", k)
+		}
+	}
+
+	cj, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	var doc interface{}
+	err = json.Unmarshal(cj, &doc)
+	if err != nil {
+		return err
+	}
+
+	merged, err := json.Marshal(json.MergePatch(doc, normalized))
+	if err != nil {
+		return err
+	}
+
+	result := CustomPricing{}
+	err = json.Unmarshal(merged, &result)
+	if err != nil {
+		return err
+	}
+
+	*c = result
+	return nil
+}
+
 func (cp *CustomProvider) ClusterInfo() (map[string]string, error) {
 	conf, err := cp.GetConfig()
 	if err != nil {
@@ -127,29 +351,152 @@ func (cp *CustomProvider) AllNodePricing() (interface{}, error) {
 	return cp.Pricing, nil
 }
 
+// defaultPricingKeys are the keys of the configured prices, at which nodes whose
+// keys have no pricing are priced
+var defaultPricingKeys = []string{"default", "default,spot", "default,gpu"}
+
+// Restore lays the node pricing of the snapshot over the configured default
+// prices, which remain in effect until the pricing data is next downloaded, e.g.
+// when the config changes. Default prices which the snapshot lacks, such as
+// those of a snapshot of cloud provider pricing by region and instance type,
+// are kept, so that nodes can still be priced.
+func (cp *CustomProvider) Restore(snapshot *PricingSnapshot) error {
+	if snapshot == nil || len(snapshot.Prices) == 0 {
+		return fmt.Errorf("pricing snapshot has no prices")
+	}
+
+	cp.DownloadPricingDataLock.Lock()
+	defer cp.DownloadPricingDataLock.Unlock()
+
+	pricing := make(map[string]*NodePrice, len(snapshot.Prices)+len(defaultPricingKeys))
+	for _, key := range defaultPricingKeys {
+		if price, ok := cp.Pricing[key]; ok {
+			pricing[key] = price
+		}
+	}
+	for key, price := range snapshot.Prices {
+		if price != nil {
+			p := *price
+			pricing[key] = &p
+		}
+	}
+
+	cp.Pricing = pricing
+	cp.resetPricingFallbacks()
+	cloudLog.Infof("Restored pricing snapshot of %s with %d prices", snapshot.Timestamp.Format(time.RFC3339), len(snapshot.Prices))
+
+	return nil
+}
+
 func (cp *CustomProvider) NodePricing(key Key) (*Node, error) {
+	if cp.NodePricingOverrideFunc != nil {
+		if node, ok := cp.NodePricingOverrideFunc(key); ok {
+			return node, nil
+		}
+	}
+
 	cp.DownloadPricingDataLock.RLock()
 	defer cp.DownloadPricingDataLock.RUnlock()
 
 	k := key.Features()
+	fallback := "default"
 	var gpuCount string
-	if _, ok := cp.Pricing[k]; !ok {
-		k = "default"
-	}
 	if key.GPUType() != "" {
+		fallback = "default,gpu"
 		k += ",gpu"    // TODO: support multiple custom gpu types.
 		gpuCount = "1" // TODO: support more than one gpu.
+	} else if cp.blendedPricing && cp.BlendedRateFunc != nil {
+		return cp.applyTimeOfDayPricing(cp.blendedNode()), nil
 	}
 
-	return &Node{
-		VCPUCost: cp.Pricing[k].CPU,
-		RAMCost:  cp.Pricing[k].RAM,
-		GPUCost:  cp.Pricing[k].GPU,
+	price, ok := cp.Pricing[k]
+	if !ok {
+		// e.g. spot GPU nodes, unless the pricing file prices "default,spot,gpu"
+		cp.countPricingFallback(k)
+		k = fallback
+		price, ok = cp.Pricing[k]
+	}
+	if !ok {
+		return nil, fmt.Errorf("no custom pricing for key %s", k)
+	}
+
+	return cp.applyTimeOfDayPricing(&Node{
+		VCPUCost: price.CPU,
+		RAMCost:  price.RAM,
+		GPUCost:  price.GPU,
 		GPU:      gpuCount,
-	}, nil
+	}), nil
+}
+
+// countPricingFallback counts the key as priced at the default price, unless
+// it has been counted since the pricing was last loaded
+func (cp *CustomProvider) countPricingFallback(key string) {
+	cp.pricingFallbacksLock.Lock()
+	defer cp.pricingFallbacksLock.Unlock()
+
+	if cp.pricingFallbacks[key] {
+		return
+	}
+	if cp.pricingFallbacks == nil {
+		cp.pricingFallbacks = map[string]bool{}
+	}
+	cp.pricingFallbacks[key] = true
+
+	countPricingFallback(key)
+}
+
+// resetPricingFallbacks counts keys priced at the default price again, once
+// the pricing is loaded
+func (cp *CustomProvider) resetPricingFallbacks() {
+	cp.pricingFallbacksLock.Lock()
+	defer cp.pricingFallbacksLock.Unlock()
+
+	cp.pricingFallbacks = nil
+}
+
+// applyTimeOfDayPricing multiplies the CPU price of the node by the multiplier
+// of the first time of day price whose hours contain the current hour, in the
+// configured timezone. The lock must be held.
+func (cp *CustomProvider) applyTimeOfDayPricing(node *Node) *Node {
+	if len(cp.timeOfDayPricing) == 0 {
+		return node
+	}
+
+	now := time.Now
+	if cp.now != nil {
+		now = cp.now
+	}
+	hour := now().In(cp.timeOfDayLocation).Hour()
+
+	for _, tp := range cp.timeOfDayPricing {
+		if !tp.contains(hour) {
+			continue
+		}
+
+		cpu, err := strconv.ParseFloat(node.VCPUCost, 64)
+		if err != nil {
+			return node
+		}
+		node.VCPUCost = strconv.FormatFloat(cpu*tp.CPUMultiplier, 'f', -1, 64)
+		return node
+	}
+
+	return node
 }
 
 func (cp *CustomProvider) DownloadPricingData() error {
+	p, err := cp.Config.GetCustomPricingData()
+	if err != nil {
+		return err
+	}
+
+	// The external pricing is loaded before the lock is taken, so that nodes
+	// can still be priced while the URL responds
+	var external map[string]*NodePrice
+	if p.ExternalPricingURL != "" {
+		external = loadExternalPricing(p)
+	}
+
 	cp.DownloadPricingDataLock.Lock()
 	defer cp.DownloadPricingDataLock.Unlock()
 
@@ -157,10 +504,6 @@ func (cp *CustomProvider) DownloadPricingData() error {
 		m := make(map[string]*NodePrice)
 		cp.Pricing = m
 	}
-	p, err := cp.Config.GetCustomPricingData()
-	if err != nil {
-		return err
-	}
 	cp.SpotLabel = p.SpotLabel
 	cp.SpotLabelValue = p.SpotLabelValue
 	cp.GPULabel = p.GpuLabel
@@ -178,6 +521,317 @@ func (cp *CustomProvider) DownloadPricingData() error {
 		RAM: p.RAM,
 		GPU: p.GPU,
 	}
+
+	if p.PricingFilePath != "" {
+		pricing, err := readPricingFile(p.PricingFilePath)
+		if err != nil {
+			cloudLog.WithFields(log.Fields{"path": p.PricingFilePath}).Warningf("Failed to load pricing file, using configured pricing: %s", err)
+		} else {
+			cp.mergePricing(pricing)
+		}
+	}
+	cp.watchPricingFile(p.PricingFilePath)
+
+	if p.ExternalPricingURL != "" {
+		cp.mergePricing(external)
+		cp.refreshExternalPricing(p.ExternalPricingRefreshInterval.Duration())
+	} else {
+		cp.refreshExternalPricing(0)
+	}
+
+	cp.blendedPricing = p.BlendedPricingEnabled == "true"
+	if cp.blendedPricing {
+		cp.onDemandFraction = cp.getOnDemandFraction(p)
+	}
+
+	cp.timeOfDayPricing, cp.timeOfDayLocation = getTimeOfDayPricing(p)
+
+	cp.resetPricingFallbacks()
+
+	return nil
+}
+
+// watchPricingFile watches the pricing file at path, unless it is already
+// watched, so that the pricing is reloaded when it changes; e.g. when the
+// ConfigMap it is mounted from is updated. Any previously watched file is no
+// longer watched, and an empty path watches nothing. The lock must be held.
+func (cp *CustomProvider) watchPricingFile(path string) {
+	if path == cp.pricingFileWatchPath {
+		return
+	}
+
+	if cp.pricingFileWatcher != nil {
+		cp.pricingFileWatcher.Close()
+		cp.pricingFileWatcher = nil
+	}
+	cp.pricingFileWatchPath = ""
+
+	if path == "" {
+		return
+	}
+
+	w, err := watcher.New(path, func() {
+		pricingLog := cloudLog.WithFields(log.Fields{"path": path})
+		pricingLog.Infof("Pricing file %s changed, reloading pricing", path)
+		if err := cp.DownloadPricingData(); err != nil {
+			pricingLog.Warningf("Failed to reload pricing: %s", err)
+		}
+	}, watcher.Options{})
+	if err != nil {
+		cloudLog.WithFields(log.Fields{"path": path}).Warningf("Failed to watch pricing file %s, changes will not be reloaded: %s", path, err)
+		return
+	}
+
+	cp.pricingFileWatcher = w
+	cp.pricingFileWatchPath = path
+}
+
+// refreshExternalPricing reloads the pricing, including the external pricing,
+// every interval, unless it is already doing so. Any previous refresh is
+// stopped, and a non-positive interval does not refresh. The lock must be held.
+func (cp *CustomProvider) refreshExternalPricing(interval time.Duration) {
+	if interval < 0 {
+		interval = 0
+	}
+	if interval == cp.externalPricingRefreshInterval {
+		return
+	}
+
+	if cp.externalPricingRefreshStop != nil {
+		close(cp.externalPricingRefreshStop)
+		cp.externalPricingRefreshStop = nil
+	}
+	cp.externalPricingRefreshInterval = interval
+
+	if interval == 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	cp.externalPricingRefreshStop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				cloudLog.Infof("Refreshing external pricing every %s", interval)
+				if err := cp.DownloadPricingData(); err != nil {
+					cloudLog.Warningf("Failed to refresh external pricing: %s", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// getTimeOfDayPricing returns the valid time of day prices of the config, and
+// the location of their hours, which is UTC if the timezone is not configured
+// or invalid
+func getTimeOfDayPricing(p *CustomPricing) ([]TimeOfDayPrice, *time.Location) {
+	loc := time.UTC
+	if p.TimeOfDayPricingTimezone != "" {
+		l, err := time.LoadLocation(p.TimeOfDayPricingTimezone)
+		if err != nil {
+			log.Warningf("Invalid timeOfDayPricingTimezone '%s', using UTC: %s", p.TimeOfDayPricingTimezone, err)
+		} else {
+			loc = l
+		}
+	}
+
+	var prices []TimeOfDayPrice
+	for _, tp := range p.TimeOfDayPricing {
+		if err := tp.validate(); err != nil {
+			log.Warningf("Ignoring invalid time of day price: %s", err)
+			continue
+		}
+		prices = append(prices, tp)
+	}
+
+	return prices, loc
+}
+
+// getOnDemandFraction returns the configured fraction of capacity which is
+// on-demand, or, if it is not configured, the fraction of the cluster's nodes
+// which are not spot, according to the spot label. A cluster without nodes is
+// treated as on-demand.
+func (cp *CustomProvider) getOnDemandFraction(p *CustomPricing) float64 {
+	if p.OnDemandFraction != "" {
+		fraction, err := strconv.ParseFloat(p.OnDemandFraction, 64)
+		if err == nil && fraction >= 0 && fraction <= 1 {
+			return fraction
+		}
+		log.Warningf("Invalid onDemandFraction '%s': expected a fraction between 0 and 1; using the fraction of on-demand nodes", p.OnDemandFraction)
+	}
+
+	if cp.Clientset == nil {
+		return 1.0
+	}
+
+	nodes := cp.Clientset.GetAllNodes()
+	if len(nodes) == 0 {
+		return 1.0
+	}
+
+	onDemand := 0
+	for _, node := range nodes {
+		if cp.SpotLabel == "" || node.Labels[cp.SpotLabel] != cp.SpotLabelValue {
+			onDemand++
+		}
+	}
+
+	return float64(onDemand) / float64(len(nodes))
+}
+
+// blendedNode returns a Node priced at the blended rate of the on-demand and
+// spot prices. The lock must be held.
+func (cp *CustomProvider) blendedNode() *Node {
+	onDemand, spot := cp.Pricing["default"], cp.Pricing["default,spot"]
+	if onDemand == nil {
+		onDemand = &NodePrice{}
+	}
+	if spot == nil {
+		spot = onDemand
+	}
+
+	blend := func(onDemandPrice, spotPrice string) string {
+		od, err := strconv.ParseFloat(onDemandPrice, 64)
+		if err != nil {
+			return onDemandPrice
+		}
+
+		// Without a spot price, capacity is priced on-demand
+		sp, err := strconv.ParseFloat(spotPrice, 64)
+		if err != nil {
+			sp = od
+		}
+
+		return strconv.FormatFloat(cp.BlendedRateFunc(cp.onDemandFraction, od, sp), 'f', -1, 64)
+	}
+
+	return &Node{
+		VCPUCost: blend(onDemand.CPU, spot.CPU),
+		RAMCost:  blend(onDemand.RAM, spot.RAM),
+		GPUCost:  onDemand.GPU,
+	}
+}
+
+// loadExternalPricing returns the node prices loaded from the external pricing URL,
+// and writes them to the pricing cache file, if there is one. If the URL is
+// unavailable, the prices of the cache file are returned instead, so that the prices
+// of the last successful load are used. If neither is available, nil is returned, and
+// the configured prices are used.
+func loadExternalPricing(p *CustomPricing) map[string]*NodePrice {
+	external, err := fetchExternalPricing(p.ExternalPricingURL)
+	if err != nil {
+		if p.PricingCachePath == "" {
+			log.Warningf("Failed to load external pricing, using configured pricing: %s", err)
+			return nil
+		}
+
+		cached, cacheErr := readPricingCache(p.PricingCachePath)
+		if cacheErr != nil {
+			log.Warningf("Failed to load external pricing, using configured pricing: %s; %s", err, cacheErr)
+			return nil
+		}
+
+		log.Warningf("Failed to load external pricing, using cached pricing from %s: %s", p.PricingCachePath, err)
+
+		return cached
+	}
+
+	if p.PricingCachePath != "" {
+		err := writePricingCache(p.PricingCachePath, external)
+		if err != nil {
+			log.Warningf("Failed to cache external pricing: %s", err)
+		}
+	}
+
+	return external
+}
+
+// mergePricing sets the node prices of the given key features
+func (cp *CustomProvider) mergePricing(pricing map[string]*NodePrice) {
+	for key, price := range pricing {
+		if price != nil {
+			cp.Pricing[key] = price
+		}
+	}
+}
+
+// fetchExternalPricing fetches node prices by key features from the given URL
+func fetchExternalPricing(url string) (map[string]*NodePrice, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching pricing from %s: %s", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching pricing from %s: status %d", url, resp.StatusCode)
+	}
+
+	pricing := map[string]*NodePrice{}
+	err = json.NewDecoder(resp.Body).Decode(&pricing)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding pricing from %s: %s", url, err)
+	}
+
+	return pricing, nil
+}
+
+// readPricingFile reads node prices by key features from the file at path, which
+// is decompressed if it ends in .gz, so that large pricing files are decoded as
+// they are read rather than read in whole first
+func readPricingFile(path string) (map[string]*NodePrice, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading pricing file %s: %s", path, err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("error decompressing pricing file %s: %s", path, err)
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	pricing := map[string]*NodePrice{}
+	err = json.NewDecoder(r).Decode(&pricing)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding pricing file %s: %s", path, err)
+	}
+
+	return pricing, nil
+}
+
+// readPricingCache reads node prices by key features from the cache file at path
+func readPricingCache(path string) (map[string]*NodePrice, error) {
+	pricing := map[string]*NodePrice{}
+	err := atomicfile.ReadJSON(path, &pricing)
+	if err != nil {
+		return nil, fmt.Errorf("error reading pricing cache %s: %s", path, err)
+	}
+
+	return pricing, nil
+}
+
+// writePricingCache writes node prices by key features to the cache file at path
+func writePricingCache(path string, pricing map[string]*NodePrice) error {
+	err := atomicfile.WriteJSON(path, pricing, 0644)
+	if err != nil {
+		return fmt.Errorf("error writing pricing cache %s: %s", path, err)
+	}
+
 	return nil
 }
 
@@ -217,7 +871,7 @@ func (cp *CustomProvider) NetworkPricing() (*Network, error) {
 	if err != nil {
 		return nil, err
 	}
-	znec, err := strconv.ParseFloat(cpricing.ZoneNetworkEgress, 64)
+	znec, err := zoneNetworkEgressCost(cpricing)
 	if err != nil {
 		return nil, err
 	}
@@ -231,12 +885,42 @@ func (cp *CustomProvider) NetworkPricing() (*Network, error) {
 	}
 
 	return &Network{
-		ZoneNetworkEgressCost:     znec,
-		RegionNetworkEgressCost:   rnec,
-		InternetNetworkEgressCost: inec,
+		ZoneNetworkEgressCost:      znec,
+		RegionNetworkEgressCost:    rnec,
+		InternetNetworkEgressCost:  inec,
+		InternetNetworkEgressTiers: cpricing.InternetNetworkEgressTiers,
 	}, nil
 }
 
+// zoneNetworkEgressCost returns the cost per GB of cross-zone egress. If the
+// network is billed per hour, the hourly rate of the link is spread across the
+// GB it can transfer in an hour at the full bandwidth.
+func zoneNetworkEgressCost(cpricing *CustomPricing) (float64, error) {
+	rate, err := strconv.ParseFloat(cpricing.ZoneNetworkEgress, 64)
+	if err != nil {
+		return 0, err
+	}
+
+	switch cpricing.NetworkBillingModel {
+	case "", NetworkBillingModelPerGB:
+		return rate, nil
+	case NetworkBillingModelPerHour:
+		gbps, err := strconv.ParseFloat(cpricing.NetworkBandwidthGbps, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid network bandwidth '%s': %s", cpricing.NetworkBandwidthGbps, err)
+		}
+		if gbps <= 0 {
+			return 0, fmt.Errorf("invalid network bandwidth '%s': must be positive", cpricing.NetworkBandwidthGbps)
+		}
+
+		// Gb per second to GB per hour
+		gbPerHour := gbps * 3600 / 8
+		return rate / gbPerHour, nil
+	default:
+		return 0, fmt.Errorf("unknown network billing model '%s'", cpricing.NetworkBillingModel)
+	}
+}
+
 func (cp *CustomProvider) LoadBalancerPricing() (*LoadBalancer, error) {
 	cpricing, err := cp.Config.GetCustomPricingData()
 	if err != nil {
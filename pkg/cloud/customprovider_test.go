@@ -0,0 +1,405 @@
+package cloud
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/util/json"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCustomProviderGzipPricingFile(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "custom-pricing")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(env.ConfigPathEnvVar, configDir+"/")
+	defer os.Unsetenv(env.ConfigPathEnvVar)
+
+	cp := &CustomProvider{Config: NewProviderConfig("custom.json")}
+	_, err = cp.Config.Update(func(c *CustomPricing) error {
+		c.CPU = "0.04"
+		c.RAM = "0.004"
+		c.SpotLabel = "spot"
+		c.SpotLabelValue = "true"
+		c.PricingFilePath = "testdata/custom-pricing/pricing.json.gz"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to update config: %s", err)
+	}
+
+	err = cp.DownloadPricingData()
+	if err != nil {
+		t.Fatalf("unexpected error downloading pricing: %s", err)
+	}
+
+	cases := map[string]struct {
+		labels map[string]string
+		cpu    string
+		ram    string
+	}{
+		"on-demand": {map[string]string{}, "0.05", "0.005"},
+		"spot":      {map[string]string{"spot": "true"}, "0.015", "0.0015"},
+	}
+
+	for name, c := range cases {
+		node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Labels: c.labels}}
+
+		n, err := cp.NodePricing(cp.GetKey(c.labels, node))
+		if err != nil {
+			t.Fatalf("%s: unexpected error pricing node: %s", name, err)
+		}
+		if n.VCPUCost != c.cpu || n.RAMCost != c.ram {
+			t.Errorf("%s: expected CPU and RAM prices %s and %s from the pricing file; got %s and %s", name, c.cpu, c.ram, n.VCPUCost, n.RAMCost)
+		}
+	}
+}
+
+func TestCustomProviderExternalPricingCache(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "custom-pricing")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(env.ConfigPathEnvVar, configDir+"/")
+	defer os.Unsetenv(env.ConfigPathEnvVar)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"default,spot": {"CPU": "0.02", "RAM": "0.002"}}`))
+	}))
+
+	cachePath := filepath.Join(configDir, "pricing-cache.json")
+	cp := &CustomProvider{Config: NewProviderConfig("custom.json")}
+	_, err = cp.Config.Update(func(c *CustomPricing) error {
+		c.CPU = "0.04"
+		c.RAM = "0.004"
+		c.ExternalPricingURL = server.URL
+		c.PricingCachePath = cachePath
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to update config: %s", err)
+	}
+
+	if err := cp.DownloadPricingData(); err != nil {
+		t.Fatalf("unexpected error downloading pricing: %s", err)
+	}
+
+	// Only the external prices are cached, not the configured ones
+	cached, err := readPricingCache(cachePath)
+	if err != nil {
+		t.Fatalf("unexpected error reading cache: %s", err)
+	}
+	if len(cached) != 1 || cached["default,spot"] == nil || cached["default,spot"].CPU != "0.02" {
+		t.Fatalf("expected only the external prices to be cached; got %v", cached)
+	}
+
+	// Once the URL is unavailable, the cached prices are used, without
+	// overriding the configured prices
+	server.Close()
+	_, err = cp.Config.Update(func(c *CustomPricing) error {
+		c.CPU = "0.05"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to update config: %s", err)
+	}
+
+	if err := cp.DownloadPricingData(); err != nil {
+		t.Fatalf("unexpected error downloading pricing: %s", err)
+	}
+	if cp.Pricing["default"].CPU != "0.05" {
+		t.Errorf("expected the configured CPU price 0.05; got %s", cp.Pricing["default"].CPU)
+	}
+	if cp.Pricing["default,spot"].CPU != "0.02" {
+		t.Errorf("expected the cached spot CPU price 0.02; got %s", cp.Pricing["default,spot"].CPU)
+	}
+}
+
+func TestReadPricingFileInvalidGzip(t *testing.T) {
+	f, err := ioutil.TempFile("", "pricing-*.json.gz")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %s", err)
+	}
+	defer os.Remove(f.Name())
+
+	f.WriteString(`{"default": {"CPU": "0.05"}}`)
+	f.Close()
+
+	if _, err := readPricingFile(f.Name()); err == nil {
+		t.Fatalf("expected error reading uncompressed file ending in .gz")
+	}
+}
+
+func TestCustomProviderTimeOfDayPricing(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "custom-pricing")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(env.ConfigPathEnvVar, configDir+"/")
+	defer os.Unsetenv(env.ConfigPathEnvVar)
+
+	cp := &CustomProvider{Config: NewProviderConfig("custom.json")}
+	_, err = cp.Config.Update(func(c *CustomPricing) error {
+		c.CPU = "0.04"
+		c.RAM = "0.004"
+		c.TimeOfDayPricingTimezone = "America/New_York"
+		c.TimeOfDayPricing = []TimeOfDayPrice{
+			{StartHour: 9, EndHour: 17, CPUMultiplier: 1.5},
+			{StartHour: 22, EndHour: 6, CPUMultiplier: 0.5},
+			{StartHour: 25, EndHour: 3, CPUMultiplier: 10},
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to update config: %s", err)
+	}
+
+	err = cp.DownloadPricingData()
+	if err != nil {
+		t.Fatalf("unexpected error downloading pricing: %s", err)
+	}
+
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %s", err)
+	}
+
+	cases := map[string]struct {
+		hour int
+		cpu  string
+	}{
+		"peak":                     {9, "0.06"},
+		"end of peak":              {17, "0.04"},
+		"off-peak before midnight": {23, "0.02"},
+		"off-peak after midnight":  {2, "0.02"},
+		"unconfigured":             {7, "0.04"},
+	}
+
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{}}}
+	for name, c := range cases {
+		cp.now = func() time.Time {
+			return time.Date(2026, 6, 1, c.hour, 30, 0, 0, ny).UTC()
+		}
+
+		n, err := cp.NodePricing(cp.GetKey(map[string]string{}, node))
+		if err != nil {
+			t.Fatalf("%s: unexpected error pricing node: %s", name, err)
+		}
+		if n.VCPUCost != c.cpu || n.RAMCost != "0.004" {
+			t.Errorf("%s: expected CPU and RAM prices %s and 0.004; got %s and %s", name, c.cpu, n.VCPUCost, n.RAMCost)
+		}
+	}
+}
+
+func TestCustomProviderPricingFallbackMetric(t *testing.T) {
+	// The default prices, as configured by DownloadPricingData
+	cp := &CustomProvider{
+		Pricing: map[string]*NodePrice{
+			"default":      {CPU: "0.04", RAM: "0.004"},
+			"default,spot": {CPU: "0.01", RAM: "0.001"},
+			"default,gpu":  {CPU: "0.04", RAM: "0.004", GPU: "0.9"},
+		},
+		SpotLabel:      "spot",
+		SpotLabelValue: "true",
+		GPULabel:       "gpu",
+		GPULabelValue:  "true",
+	}
+
+	priced := customPricingFallbacks.WithLabelValues("default,spot")
+	fallback := customPricingFallbacks.WithLabelValues("default,spot,gpu")
+	pricedBefore, fallbackBefore := testutil.ToFloat64(priced), testutil.ToFloat64(fallback)
+
+	spotGPU := map[string]string{"spot": "true", "gpu": "true"}
+	node := &v1.Node{ObjectMeta: metav1.ObjectMeta{Labels: spotGPU}}
+	for i := 0; i < 2; i++ {
+		n, err := cp.NodePricing(cp.GetKey(spotGPU, node))
+		if err != nil {
+			t.Fatalf("unexpected error pricing node: %s", err)
+		}
+		if n.GPUCost != "0.9" {
+			t.Fatalf("expected the default GPU price 0.9; got %s", n.GPUCost)
+		}
+	}
+
+	if _, err := cp.NodePricing(cp.GetKey(map[string]string{"spot": "true"}, node)); err != nil {
+		t.Fatalf("unexpected error pricing node: %s", err)
+	}
+
+	// The key is counted once, however many nodes are priced
+	if delta := testutil.ToFloat64(fallback) - fallbackBefore; delta != 1 {
+		t.Fatalf("expected 1 fallback for the spot GPU key; got %f", delta)
+	}
+	if delta := testutil.ToFloat64(priced) - pricedBefore; delta != 0 {
+		t.Fatalf("expected no fallbacks for the priced key; got %f", delta)
+	}
+
+	// It is counted again once the pricing is loaded
+	cp.resetPricingFallbacks()
+	if _, err := cp.NodePricing(cp.GetKey(spotGPU, node)); err != nil {
+		t.Fatalf("unexpected error pricing node: %s", err)
+	}
+	if delta := testutil.ToFloat64(fallback) - fallbackBefore; delta != 2 {
+		t.Fatalf("expected 2 fallbacks for the spot GPU key after reloading; got %f", delta)
+	}
+
+	countPricingFallback(strings.Repeat("k", 200))
+	if v := testutil.ToFloat64(customPricingFallbacks.WithLabelValues(strings.Repeat("k", maxFallbackKeyLength))); v != 1 {
+		t.Fatalf("expected the long key to be truncated to %d characters; got %f", maxFallbackKeyLength, v)
+	}
+}
+
+func TestJSONDurationUnmarshal(t *testing.T) {
+	cases := map[string]time.Duration{
+		`{"externalPricingRefreshInterval": "1h30m"}`:       90 * time.Minute,
+		`{"externalPricingRefreshInterval": 5400000000000}`: 90 * time.Minute,
+		`{"externalPricingRefreshInterval": "60000000000"}`: time.Minute,
+		`{"externalPricingRefreshInterval": ""}`:            0,
+		`{"externalPricingRefreshInterval": null}`:          0,
+		`{}`: 0,
+	}
+
+	for data, expected := range cases {
+		cp := &CustomPricing{}
+		if err := json.Unmarshal([]byte(data), cp); err != nil {
+			t.Fatalf("unexpected error unmarshalling %s: %s", data, err)
+		}
+		if cp.ExternalPricingRefreshInterval.Duration() != expected {
+			t.Errorf("expected %s from %s; got %s", expected, data, cp.ExternalPricingRefreshInterval.Duration())
+		}
+	}
+
+	cp := &CustomPricing{}
+	if err := json.Unmarshal([]byte(`{"externalPricingRefreshInterval": "soon"}`), cp); err == nil {
+		t.Errorf("expected an error unmarshalling an invalid duration")
+	}
+
+	// Durations are marshalled as strings, which round trip
+	cp.ExternalPricingRefreshInterval = JSONDuration(90 * time.Minute)
+	data, err := json.Marshal(cp)
+	if err != nil {
+		t.Fatalf("unexpected error marshalling: %s", err)
+	}
+	if !strings.Contains(string(data), `"externalPricingRefreshInterval":"1h30m0s"`) {
+		t.Errorf("expected the duration to be marshalled as a string; got %s", data)
+	}
+
+	roundTrip := &CustomPricing{}
+	if err := json.Unmarshal(data, roundTrip); err != nil || roundTrip.ExternalPricingRefreshInterval != cp.ExternalPricingRefreshInterval {
+		t.Errorf("expected the duration to round trip; got %s (%v)", roundTrip.ExternalPricingRefreshInterval.Duration(), err)
+	}
+}
+
+func TestUpdateCustomPricingDuration(t *testing.T) {
+	cp := &CustomPricing{}
+	if err := SetCustomPricingField(cp, "ExternalPricingRefreshInterval", "6h"); err != nil {
+		t.Fatalf("unexpected error setting duration: %s", err)
+	}
+	if cp.ExternalPricingRefreshInterval.Duration() != 6*time.Hour {
+		t.Errorf("expected 6h; got %s", cp.ExternalPricingRefreshInterval.Duration())
+	}
+	if err := SetCustomPricingField(cp, "ExternalPricingRefreshInterval", "soon"); err == nil {
+		t.Errorf("expected an error setting an invalid duration")
+	}
+
+	err := mergePatchCustomPricing(cp, map[string]interface{}{"externalPricingRefreshInterval": float64(time.Minute)})
+	if err != nil {
+		t.Fatalf("unexpected error patching duration: %s", err)
+	}
+	if cp.ExternalPricingRefreshInterval.Duration() != time.Minute {
+		t.Errorf("expected 1m; got %s", cp.ExternalPricingRefreshInterval.Duration())
+	}
+
+	if err := mergePatchCustomPricing(cp, map[string]interface{}{"CPU": float64(1)}); err == nil {
+		t.Errorf("expected an error patching a string field with a number")
+	}
+}
+
+func TestUpdateConfigTimeOfDayPricingRoundTrip(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "custom-pricing")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(env.ConfigPathEnvVar, configDir+"/")
+	defer os.Unsetenv(env.ConfigPathEnvVar)
+
+	peak := TimeOfDayPrice{StartHour: 9, EndHour: 17, CPUMultiplier: 1.5}
+
+	cp := &CustomProvider{Config: NewProviderConfig("custom.json")}
+	_, err = cp.Config.Update(func(c *CustomPricing) error {
+		c.CPU = "0.04"
+		c.ExternalPricingRefreshInterval = JSONDuration(6 * time.Hour)
+		c.TimeOfDayPricing = []TimeOfDayPrice{peak}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to update config: %s", err)
+	}
+
+	config, err := cp.GetConfig()
+	if err != nil {
+		t.Fatalf("unexpected error getting config: %s", err)
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("unexpected error encoding config: %s", err)
+	}
+
+	// The config returned by the API can be sent back unchanged
+	for _, updateType := range []string{"", MergePatchUpdateType} {
+		updated, err := cp.UpdateConfig(bytes.NewReader(data), updateType)
+		if err != nil {
+			t.Fatalf("update type '%s': unexpected error sending back the config: %s", updateType, err)
+		}
+		if len(updated.TimeOfDayPricing) != 1 || updated.TimeOfDayPricing[0] != peak {
+			t.Errorf("update type '%s': expected time of day pricing to be unchanged; got %v", updateType, updated.TimeOfDayPricing)
+		}
+		if updated.CPU != "0.04" || updated.ExternalPricingRefreshInterval.Duration() != 6*time.Hour {
+			t.Errorf("update type '%s': expected other fields to be unchanged; got %s and %s", updateType, updated.CPU, updated.ExternalPricingRefreshInterval.Duration())
+		}
+	}
+
+	// Time of day pricing can be set and cleared through the API
+	updated, err := cp.UpdateConfig(strings.NewReader(`{"timeOfDayPricing":[{"startHour":22,"endHour":6,"cpuMultiplier":0.5}]}`), "")
+	if err != nil {
+		t.Fatalf("unexpected error setting time of day pricing: %s", err)
+	}
+	if len(updated.TimeOfDayPricing) != 1 || updated.TimeOfDayPricing[0].CPUMultiplier != 0.5 {
+		t.Errorf("expected time of day pricing to be set; got %v", updated.TimeOfDayPricing)
+	}
+
+	updated, err = cp.UpdateConfig(strings.NewReader(`{"timeOfDayPricing":null}`), MergePatchUpdateType)
+	if err != nil {
+		t.Fatalf("unexpected error clearing time of day pricing: %s", err)
+	}
+	if len(updated.TimeOfDayPricing) != 0 {
+		t.Errorf("expected time of day pricing to be cleared; got %v", updated.TimeOfDayPricing)
+	}
+
+	// String fields still reject other types
+	if _, err := cp.UpdateConfig(strings.NewReader(`{"CPU":1}`), ""); err == nil {
+		t.Errorf("expected an error setting a string field to a number")
+	}
+	if _, err := cp.UpdateConfig(strings.NewReader(`{"CPU":[1]}`), MergePatchUpdateType); err == nil {
+		t.Errorf("expected an error patching a string field with a list")
+	}
+}
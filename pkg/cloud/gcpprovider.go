@@ -17,6 +17,7 @@ import (
 	"github.com/kubecost/cost-model/pkg/env"
 	"github.com/kubecost/cost-model/pkg/log"
 	"github.com/kubecost/cost-model/pkg/util"
+	"github.com/kubecost/cost-model/pkg/util/atomicfile"
 	"github.com/kubecost/cost-model/pkg/util/fileutil"
 	"github.com/kubecost/cost-model/pkg/util/json"
 	"github.com/kubecost/cost-model/pkg/util/timeutil"
@@ -220,7 +221,7 @@ func (*GCP) loadGCPAuthSecret() {
 		return
 	}
 
-	err = ioutil.WriteFile(keyPath, result, 0644)
+	err = atomicfile.WriteFile(keyPath, result, 0644)
 	if err != nil {
 		klog.V(4).Infof("[Warning] Failed to copy auth secret to %s: %s", keyPath, err.Error())
 	}
@@ -251,7 +252,7 @@ func (gcp *GCP) UpdateConfig(r io.Reader, updateType string) (*CustomPricing, er
 				path := env.GetConfigPathWithDefault("/models/")
 
 				keyPath := path + "key.json"
-				err = ioutil.WriteFile(keyPath, j, 0644)
+				err = atomicfile.WriteFile(keyPath, j, 0644)
 				if err != nil {
 					return err
 				}
@@ -278,14 +279,9 @@ func (gcp *GCP) UpdateConfig(r io.Reader, updateType string) (*CustomPricing, er
 			}
 			for k, v := range a {
 				kUpper := strings.Title(k) // Just so we consistently supply / receive the same values, uppercase the first letter.
-				vstr, ok := v.(string)
-				if ok {
-					err := SetCustomPricingField(c, kUpper, vstr)
-					if err != nil {
-						return err
-					}
-				} else {
-					return fmt.Errorf("type error while updating config for %s", kUpper)
+				err := SetCustomPricingFieldValue(c, kUpper, v)
+				if err != nil {
+					return err
 				}
 			}
 		}
@@ -991,13 +987,23 @@ func (gcp *GCP) DownloadPricingData() error {
 	for _, pv := range pvList {
 		params, ok := storageClassMap[pv.Spec.StorageClassName]
 		if !ok {
-			log.DedupedWarningf(5, "Unable to find params for storageClassName %s", pv.Name)
+			cloudLog.RateLimitedWarningf("gcp-storage-class-params", "Unable to find params for storageClassName %s", pv.Name)
 			continue
 		}
 		key := gcp.GetPVKey(pv, params, "")
 		pvkeys[key.Features()] = key
 	}
 
+	// In offline mode, prices are loaded from the static price file alone
+	if env.IsPricingOfflineEnabled() {
+		pricing, err := gcp.parseStaticPricing(inputkeys, pvkeys)
+		if err != nil {
+			return err
+		}
+		gcp.Pricing = pricing
+		return nil
+	}
+
 	reserved, err := gcp.getReservedInstances()
 	if err != nil {
 		klog.V(1).Infof("Failed to lookup reserved instance data: %s", err.Error())
@@ -1018,6 +1024,24 @@ func (gcp *GCP) DownloadPricingData() error {
 	return nil
 }
 
+// parseStaticPricing parses the static price file, a single page of the Cloud
+// Billing Catalog API's list of SKUs, in place of the API.
+func (gcp *GCP) parseStaticPricing(inputKeys map[string]Key, pvKeys map[string]PVKey) (map[string]*GCPPricing, error) {
+	body, path, err := openStaticPricing(gcpStaticPricingFile)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	klog.V(2).Infof("Pricing offline mode enabled, loading static pricing file %s", path)
+	pricing, _, err := gcp.parsePage(body, inputKeys, pvKeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse static pricing file %s: %s", path, err)
+	}
+
+	return pricing, nil
+}
+
 func (gcp *GCP) PVPricing(pvk PVKey) (*PV, error) {
 	gcp.DownloadPricingDataLock.RLock()
 	defer gcp.DownloadPricingDataLock.RUnlock()
@@ -1049,9 +1073,10 @@ func (gcp *GCP) NetworkPricing() (*Network, error) {
 	}
 
 	return &Network{
-		ZoneNetworkEgressCost:     znec,
-		RegionNetworkEgressCost:   rnec,
-		InternetNetworkEgressCost: inec,
+		ZoneNetworkEgressCost:      znec,
+		RegionNetworkEgressCost:    rnec,
+		InternetNetworkEgressCost:  inec,
+		InternetNetworkEgressTiers: cpricing.InternetNetworkEgressTiers,
 	}, nil
 }
 
@@ -1447,7 +1472,11 @@ func (gcp *GCP) ServiceAccountStatus() *ServiceAccountStatus {
 }
 
 func (gcp *GCP) PricingSourceStatus() map[string]*PricingSource {
-	return make(map[string]*PricingSource)
+	sources := make(map[string]*PricingSource)
+	if env.IsPricingOfflineEnabled() {
+		sources[StaticPricingSource] = staticPricingStatus(gcpStaticPricingFile)
+	}
+	return sources
 }
 
 func (gcp *GCP) CombinedDiscountForNode(instanceType string, isPreemptible bool, defaultDiscount, negotiatedDiscount float64) float64 {
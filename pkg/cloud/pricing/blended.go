@@ -0,0 +1,29 @@
+package pricing
+
+import (
+	"github.com/kubecost/cost-model/pkg/cloud"
+)
+
+// BlendedRate returns the rate of capacity of which onDemandFraction is
+// on-demand, at onDemandPrice, and the rest is spot, at spotPrice; e.g. a
+// node group which is 25% on-demand at $0.10/hr and 75% spot at $0.03/hr has
+// a blended rate of $0.0475/hr. The fraction is clamped to [0, 1].
+func BlendedRate(onDemandFraction float64, onDemandPrice, spotPrice float64) float64 {
+	if onDemandFraction < 0 {
+		onDemandFraction = 0
+	}
+	if onDemandFraction > 1 {
+		onDemandFraction = 1
+	}
+
+	return onDemandFraction*onDemandPrice + (1-onDemandFraction)*spotPrice
+}
+
+// InstallBlendedPricing sets BlendedRate as the blended rate of the provider,
+// if it is a CustomProvider, so that it prices nodes at a single blended rate
+// of on-demand and spot prices when BlendedPricingEnabled is set in its config.
+func InstallBlendedPricing(provider cloud.Provider) {
+	if cp, ok := provider.(*cloud.CustomProvider); ok {
+		cp.BlendedRateFunc = BlendedRate
+	}
+}
@@ -0,0 +1,84 @@
+package pricing
+
+import (
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/env"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestBlendedRate(t *testing.T) {
+	cases := map[string]struct {
+		fraction float64
+		expected float64
+	}{
+		"all on-demand":      {1, 0.10},
+		"all spot":           {0, 0.03},
+		"quarter on-demand":  {0.25, 0.25*0.10 + 0.75*0.03},
+		"fraction above one": {1.5, 0.10},
+		"negative fraction":  {-0.5, 0.03},
+	}
+
+	for name, c := range cases {
+		if rate := BlendedRate(c.fraction, 0.10, 0.03); math.Abs(rate-c.expected) > 1e-9 {
+			t.Errorf("%s: expected %f; got %f", name, c.expected, rate)
+		}
+	}
+}
+
+func TestInstallBlendedPricing(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "blended-pricing")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(env.ConfigPathEnvVar, configDir+"/")
+	defer os.Unsetenv(env.ConfigPathEnvVar)
+
+	cp := &cloud.CustomProvider{Config: cloud.NewProviderConfig("blended.json")}
+	_, err = cp.Config.Update(func(c *cloud.CustomPricing) error {
+		c.CPU = "0.04"
+		c.SpotCPU = "0.01"
+		c.RAM = "0.004"
+		c.SpotRAM = "0.001"
+		c.BlendedPricingEnabled = "true"
+		c.OnDemandFraction = "0.25"
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to update config: %s", err)
+	}
+
+	err = cp.DownloadPricingData()
+	if err != nil {
+		t.Fatalf("unexpected error downloading pricing: %s", err)
+	}
+
+	node := &v1.Node{}
+	key := cp.GetKey(map[string]string{}, node)
+
+	// Until installed, nodes are priced on-demand
+	n, err := cp.NodePricing(key)
+	if err != nil {
+		t.Fatalf("unexpected error pricing node: %s", err)
+	}
+	if n.VCPUCost != "0.04" {
+		t.Errorf("expected on-demand CPU price 0.04; got %s", n.VCPUCost)
+	}
+
+	InstallBlendedPricing(cp)
+
+	n, err = cp.NodePricing(key)
+	if err != nil {
+		t.Fatalf("unexpected error pricing node: %s", err)
+	}
+	if n.VCPUCost != "0.0175" || n.RAMCost != "0.00175" {
+		t.Errorf("expected blended CPU and RAM prices 0.0175 and 0.00175; got %s and %s", n.VCPUCost, n.RAMCost)
+	}
+}
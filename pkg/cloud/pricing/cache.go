@@ -0,0 +1,132 @@
+package pricing
+
+import (
+	"sync"
+	"time"
+)
+
+// cacheEntry is a cached value along with the time at which it expires. A zero
+// expiration never expires.
+type cacheEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// PricingCache is a thread-safe cache of pricing data, e.g. node or PV prices
+// keyed by a provider's pricing key. Entries expire TTL after they are set, and
+// expired entries are removed by a background goroutine until Stop is called.
+type PricingCache[K comparable, V any] struct {
+	// TTL is the duration for which each entry remains valid after it is set.
+	// A non-positive TTL means that entries never expire.
+	TTL time.Duration
+
+	lock     sync.RWMutex
+	entries  map[K]cacheEntry[V]
+	now      func() time.Time
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewPricingCache creates a new PricingCache whose entries expire after the
+// given TTL. If the TTL is positive, a background goroutine removes expired
+// entries every TTL until Stop is called.
+func NewPricingCache[K comparable, V any](ttl time.Duration) *PricingCache[K, V] {
+	pc := &PricingCache[K, V]{
+		TTL:     ttl,
+		entries: make(map[K]cacheEntry[V]),
+		now:     time.Now,
+		stop:    make(chan struct{}),
+	}
+
+	if ttl > 0 {
+		go pc.expireEvery(ttl)
+	}
+
+	return pc
+}
+
+// Get returns the value cached for the given key, and true if a value exists
+// and has not expired.
+func (pc *PricingCache[K, V]) Get(key K) (V, bool) {
+	pc.lock.RLock()
+	defer pc.lock.RUnlock()
+
+	entry, ok := pc.entries[key]
+	if !ok || pc.isExpired(entry) {
+		var zero V
+		return zero, false
+	}
+
+	return entry.value, true
+}
+
+// Set caches the given value for the given key, replacing any existing value.
+func (pc *PricingCache[K, V]) Set(key K, value V) {
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
+
+	entry := cacheEntry[V]{value: value}
+	if pc.TTL > 0 {
+		entry.expires = pc.now().Add(pc.TTL)
+	}
+
+	pc.entries[key] = entry
+}
+
+// Delete removes the value cached for the given key, if there is one.
+func (pc *PricingCache[K, V]) Delete(key K) {
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
+
+	delete(pc.entries, key)
+}
+
+// Len returns the number of entries in the cache, including any expired
+// entries which have not yet been removed.
+func (pc *PricingCache[K, V]) Len() int {
+	pc.lock.RLock()
+	defer pc.lock.RUnlock()
+
+	return len(pc.entries)
+}
+
+// Stop stops the background removal of expired entries. Expired entries are
+// still never returned by Get.
+func (pc *PricingCache[K, V]) Stop() {
+	pc.stopOnce.Do(func() {
+		close(pc.stop)
+	})
+}
+
+// isExpired returns true if the given entry has expired. Must be called while
+// holding the lock.
+func (pc *PricingCache[K, V]) isExpired(entry cacheEntry[V]) bool {
+	return !entry.expires.IsZero() && !pc.now().Before(entry.expires)
+}
+
+// removeExpired removes all expired entries from the cache.
+func (pc *PricingCache[K, V]) removeExpired() {
+	pc.lock.Lock()
+	defer pc.lock.Unlock()
+
+	for key, entry := range pc.entries {
+		if pc.isExpired(entry) {
+			delete(pc.entries, key)
+		}
+	}
+}
+
+// expireEvery removes expired entries on the given interval until stopped.
+func (pc *PricingCache[K, V]) expireEvery(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			pc.removeExpired()
+		case <-pc.stop:
+			return
+		}
+	}
+}
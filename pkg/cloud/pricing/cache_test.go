@@ -0,0 +1,94 @@
+package pricing
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPricingCache(t *testing.T) {
+	pc := NewPricingCache[string, float64](time.Hour)
+	defer pc.Stop()
+
+	now := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	pc.now = func() time.Time { return now }
+
+	if _, ok := pc.Get("m5.large"); ok {
+		t.Fatalf("expected empty cache to miss")
+	}
+
+	pc.Set("m5.large", 0.096)
+	pc.Set("m5.xlarge", 0.192)
+	if price, ok := pc.Get("m5.large"); !ok || price != 0.096 {
+		t.Fatalf("expected %f; got %f, %t", 0.096, price, ok)
+	}
+
+	pc.Delete("m5.large")
+	if _, ok := pc.Get("m5.large"); ok {
+		t.Fatalf("expected deleted entry to miss")
+	}
+
+	// Entries expire after the TTL, even before they are removed
+	now = now.Add(time.Hour)
+	if _, ok := pc.Get("m5.xlarge"); ok {
+		t.Fatalf("expected expired entry to miss")
+	}
+	if pc.Len() != 1 {
+		t.Fatalf("expected expired entry to remain until removed; got %d entries", pc.Len())
+	}
+
+	pc.removeExpired()
+	if pc.Len() != 0 {
+		t.Fatalf("expected expired entry to be removed; got %d entries", pc.Len())
+	}
+}
+
+func TestPricingCacheNoTTL(t *testing.T) {
+	pc := NewPricingCache[string, int](0)
+	defer pc.Stop()
+
+	pc.Set("key", 1)
+	pc.now = func() time.Time { return time.Now().Add(24 * 365 * time.Hour) }
+	if value, ok := pc.Get("key"); !ok || value != 1 {
+		t.Fatalf("expected entry without TTL not to expire; got %d, %t", value, ok)
+	}
+}
+
+func TestPricingCacheBackgroundExpiry(t *testing.T) {
+	pc := NewPricingCache[string, int](10 * time.Millisecond)
+	defer pc.Stop()
+
+	pc.Set("key", 1)
+
+	deadline := time.Now().Add(time.Second)
+	for pc.Len() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected expired entry to be removed in the background")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPricingCacheConcurrentAccess(t *testing.T) {
+	pc := NewPricingCache[int, int](time.Minute)
+	defer pc.Stop()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				pc.Set(j, i)
+				pc.Get(j)
+				if j%10 == 0 {
+					pc.Delete(j)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// Stop is idempotent
+	pc.Stop()
+}
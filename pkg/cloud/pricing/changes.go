@@ -0,0 +1,137 @@
+package pricing
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+)
+
+// DefaultPricingChangeThreshold is the percentage by which the hourly cost of
+// a node type must change for the change to be reported by a
+// PricingChangeDetector
+const DefaultPricingChangeThreshold = 5.0
+
+// The types of PricingChange
+const (
+	PricingChangeIncrease = "increase"
+	PricingChangeDecrease = "decrease"
+)
+
+// PricingChange is a change to the hourly cost of a node type between two
+// downloads of a provider's pricing data
+type PricingChange struct {
+	NodeType string  `json:"nodeType"`
+	Type     string  `json:"type"`
+	OldCost  float64 `json:"oldCost"`
+	NewCost  float64 `json:"newCost"`
+	// PercentChange is the change as a percentage of OldCost, which is
+	// negative for decreases, or 100 if OldCost is 0
+	PercentChange float64 `json:"percentChange"`
+}
+
+// String returns a human readable description of the change
+func (pc PricingChange) String() string {
+	return fmt.Sprintf("%s %s from %g to %g (%+.2f%%)", pc.NodeType, pc.Type, pc.OldCost, pc.NewCost, pc.PercentChange)
+}
+
+// PricingChangeDetector compares each snapshot of a provider's node pricing
+// with the previous one, to detect when the pricing data changes materially;
+// e.g. when a provider publishes new prices, or the discounts of the pricing
+// config change.
+type PricingChangeDetector struct {
+	// ThresholdPercent is the percentage of the old hourly cost by which a
+	// node type's cost must change to be reported
+	ThresholdPercent float64
+
+	// Tolerance is the absolute difference in hourly cost below which a cost
+	// is considered unchanged, so that floating point noise is ignored
+	Tolerance float64
+
+	lock     sync.Mutex
+	previous map[string]float64
+}
+
+// NewPricingChangeDetector creates a PricingChangeDetector which reports
+// changes of more than the given percentage. A negative threshold is treated
+// as 0.
+func NewPricingChangeDetector(thresholdPercent float64) *PricingChangeDetector {
+	if thresholdPercent < 0 {
+		thresholdPercent = 0
+	}
+
+	return &PricingChangeDetector{
+		ThresholdPercent: thresholdPercent,
+		Tolerance:        comparisonTolerance,
+	}
+}
+
+// Observe snapshots the node pricing of the provider, as returned by
+// AllNodePricing, and returns the changes from the previous snapshot, sorted
+// by node type. The first snapshot has no changes. Node types are named as by
+// ComparePricing, and node types which are added or removed are not changes.
+func (pcd *PricingChangeDetector) Observe(provider cloud.Provider) ([]PricingChange, error) {
+	pricing, err := provider.AllNodePricing()
+	if err != nil {
+		return nil, fmt.Errorf("error getting node pricing: %s", err)
+	}
+	costs, err := nodeTypeCosts(pricing)
+	if err != nil {
+		return nil, fmt.Errorf("error reading node pricing: %s", err)
+	}
+
+	pcd.lock.Lock()
+	defer pcd.lock.Unlock()
+
+	previous := pcd.previous
+	pcd.previous = costs
+	if previous == nil {
+		return nil, nil
+	}
+
+	return pcd.diff(previous, costs), nil
+}
+
+// diff returns the changes of the node types of both old and new costs whose
+// cost changes by more than the threshold, sorted by node type
+func (pcd *PricingChangeDetector) diff(oldCosts, newCosts map[string]float64) []PricingChange {
+	var changes []PricingChange
+
+	for nodeType, oldCost := range oldCosts {
+		newCost, ok := newCosts[nodeType]
+		if !ok {
+			continue
+		}
+
+		delta := newCost - oldCost
+		if math.Abs(delta) < pcd.Tolerance {
+			continue
+		}
+
+		change := PricingChange{
+			NodeType:      nodeType,
+			Type:          PricingChangeIncrease,
+			OldCost:       oldCost,
+			NewCost:       newCost,
+			PercentChange: 100,
+		}
+		if delta < 0 {
+			change.Type = PricingChangeDecrease
+		}
+		if oldCost != 0 {
+			change.PercentChange = delta / oldCost * 100
+		}
+
+		if math.Abs(change.PercentChange) > pcd.ThresholdPercent {
+			changes = append(changes, change)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].NodeType < changes[j].NodeType
+	})
+
+	return changes
+}
@@ -0,0 +1,67 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+)
+
+func TestPricingChangeDetector(t *testing.T) {
+	cp := &cloud.CustomProvider{Pricing: map[string]*cloud.NodePrice{
+		"default":      {CPU: "0.04", RAM: "0.004"},
+		"default,spot": {CPU: "0.01", RAM: "0.001"},
+	}}
+
+	detector := NewPricingChangeDetector(DefaultPricingChangeThreshold)
+
+	changes, err := detector.Observe(cp)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no changes on the first snapshot; got %v", changes)
+	}
+
+	// A 2.5% bump does not cross the threshold, and float noise is ignored
+	cp.Pricing["default"].CPU = "0.041"
+	cp.Pricing["default"].RAM = "0.0040000000001"
+	changes, err = detector.Observe(cp)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no changes below the threshold; got %v", changes)
+	}
+
+	// A 10% bump crosses the threshold, relative to the last snapshot, and
+	// a cut to the spot price is a decrease
+	cp.Pricing["default"].CPU = "0.0451"
+	cp.Pricing["default,spot"].CPU = "0.008"
+	cp.Pricing["added"] = &cloud.NodePrice{CPU: "1.0"}
+	changes, err = detector.Observe(cp)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []PricingChange{
+		{NodeType: "default,spot/cpu", Type: PricingChangeDecrease, OldCost: 0.01, NewCost: 0.008, PercentChange: -20},
+		{NodeType: "default/cpu", Type: PricingChangeIncrease, OldCost: 0.041, NewCost: 0.0451, PercentChange: 10},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("expected %d changes; got %v", len(expected), changes)
+	}
+	for i, e := range expected {
+		c := changes[i]
+		if c.NodeType != e.NodeType || c.Type != e.Type || c.OldCost != e.OldCost || c.NewCost != e.NewCost || math.Abs(c.PercentChange-e.PercentChange) > 1e-6 {
+			t.Errorf("change %d: expected %v; got %v", i, e, c)
+		}
+	}
+
+	// Without a threshold, any change beyond the tolerance is reported
+	detector = NewPricingChangeDetector(0)
+	changes = detector.diff(map[string]float64{"a": 1.0, "b": 1.0}, map[string]float64{"a": 1.0 + 1e-12, "b": 1.001})
+	if len(changes) != 1 || changes[0].NodeType != "b" {
+		t.Fatalf("expected only b to change; got %v", changes)
+	}
+}
@@ -0,0 +1,189 @@
+package pricing
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+)
+
+// comparisonTolerance is the difference in hourly cost below which a node
+// type's cost is considered unchanged
+const comparisonTolerance = 1e-9
+
+// PricingDelta is the hourly cost of a node type under two providers' pricing
+type PricingDelta struct {
+	NodeType string  `json:"nodeType"`
+	OldCost  float64 `json:"oldCost"`
+	NewCost  float64 `json:"newCost"`
+}
+
+// Delta returns the change in hourly cost from OldCost to NewCost
+func (pd PricingDelta) Delta() float64 {
+	return pd.NewCost - pd.OldCost
+}
+
+// PricingComparison classifies the node types priced by two providers by how
+// their hourly cost changes from the first to the second. Node types priced
+// by only one provider are listed as Added or Removed.
+type PricingComparison struct {
+	Increases []PricingDelta `json:"increases"`
+	Decreases []PricingDelta `json:"decreases"`
+	Unchanged []PricingDelta `json:"unchanged"`
+	Added     []string       `json:"added"`
+	Removed   []string       `json:"removed"`
+}
+
+// ComparePricing compares the node pricing of provider a, the old pricing, to
+// that of provider b, the new pricing, as returned by AllNodePricing; e.g. the
+// same provider before and after a change to its pricing config. Each list of
+// the comparison is sorted by node type.
+//
+// A node type is a key of the provider's pricing. Nodes priced with an hourly
+// cost are compared by that cost. Nodes priced by resource, e.g. by
+// CustomProvider, are compared by the rate of each resource, as the node types
+// "<key>/cpu", "<key>/ram", and "<key>/gpu".
+func ComparePricing(a, b cloud.Provider) (*PricingComparison, error) {
+	oldPricing, err := a.AllNodePricing()
+	if err != nil {
+		return nil, fmt.Errorf("error getting old node pricing: %s", err)
+	}
+	oldCosts, err := nodeTypeCosts(oldPricing)
+	if err != nil {
+		return nil, fmt.Errorf("error reading old node pricing: %s", err)
+	}
+
+	newPricing, err := b.AllNodePricing()
+	if err != nil {
+		return nil, fmt.Errorf("error getting new node pricing: %s", err)
+	}
+	newCosts, err := nodeTypeCosts(newPricing)
+	if err != nil {
+		return nil, fmt.Errorf("error reading new node pricing: %s", err)
+	}
+
+	comparison := &PricingComparison{
+		Increases: []PricingDelta{},
+		Decreases: []PricingDelta{},
+		Unchanged: []PricingDelta{},
+		Added:     []string{},
+		Removed:   []string{},
+	}
+
+	for nodeType, oldCost := range oldCosts {
+		newCost, ok := newCosts[nodeType]
+		if !ok {
+			comparison.Removed = append(comparison.Removed, nodeType)
+			continue
+		}
+
+		delta := PricingDelta{NodeType: nodeType, OldCost: oldCost, NewCost: newCost}
+		switch {
+		case math.Abs(delta.Delta()) < comparisonTolerance:
+			comparison.Unchanged = append(comparison.Unchanged, delta)
+		case delta.Delta() > 0:
+			comparison.Increases = append(comparison.Increases, delta)
+		default:
+			comparison.Decreases = append(comparison.Decreases, delta)
+		}
+	}
+
+	for nodeType := range newCosts {
+		if _, ok := oldCosts[nodeType]; !ok {
+			comparison.Added = append(comparison.Added, nodeType)
+		}
+	}
+
+	for _, deltas := range [][]PricingDelta{comparison.Increases, comparison.Decreases, comparison.Unchanged} {
+		sort.Slice(deltas, func(i, j int) bool {
+			return deltas[i].NodeType < deltas[j].NodeType
+		})
+	}
+	sort.Strings(comparison.Added)
+	sort.Strings(comparison.Removed)
+
+	return comparison, nil
+}
+
+// nodeTypeCosts returns the hourly cost of each node type of the pricing
+// returned by a provider's AllNodePricing. Prices which are not set, or cannot
+// be parsed, are omitted.
+func nodeTypeCosts(pricing interface{}) (map[string]float64, error) {
+	costs := map[string]float64{}
+
+	switch p := pricing.(type) {
+	case map[string]*cloud.NodePrice:
+		for key, np := range p {
+			if np != nil {
+				addResourceCosts(costs, key, np.CPU, np.RAM, np.GPU)
+			}
+		}
+	case map[string]*cloud.GCPPricing:
+		for key, gp := range p {
+			if gp != nil {
+				addNodeCosts(costs, key, gp.Node)
+			}
+		}
+	case map[string]*cloud.AzurePricing:
+		for key, ap := range p {
+			if ap != nil {
+				addNodeCosts(costs, key, ap.Node)
+			}
+		}
+	case map[string]*cloud.AWSProductTerms:
+		for key, terms := range p {
+			if cost, ok := AWSOnDemandCost(terms); ok {
+				costs[key] = cost
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported node pricing type %T", pricing)
+	}
+
+	return costs, nil
+}
+
+// addNodeCosts adds the hourly cost of the node, if set, and otherwise the
+// rates of its resources
+func addNodeCosts(costs map[string]float64, key string, node *cloud.Node) {
+	if node == nil {
+		return
+	}
+
+	if cost, err := strconv.ParseFloat(node.Cost, 64); err == nil {
+		costs[key] = cost
+		return
+	}
+
+	addResourceCosts(costs, key, node.VCPUCost, node.RAMCost, node.GPUCost)
+}
+
+// addResourceCosts adds the rate of each resource of the node type which is set
+func addResourceCosts(costs map[string]float64, key, cpu, ram, gpu string) {
+	for resource, rate := range map[string]string{"cpu": cpu, "ram": ram, "gpu": gpu} {
+		if cost, err := strconv.ParseFloat(rate, 64); err == nil {
+			costs[key+"/"+resource] = cost
+		}
+	}
+}
+
+// AWSOnDemandCost returns the on-demand hourly cost of the AWS product, in USD,
+// or CNY in China regions
+func AWSOnDemandCost(terms *cloud.AWSProductTerms) (float64, bool) {
+	if terms == nil || terms.OnDemand == nil {
+		return 0, false
+	}
+
+	if rc, ok := terms.OnDemand.PriceDimensions[terms.Sku+cloud.OnDemandRateCode+cloud.HourlyRateCode]; ok && rc != nil {
+		cost, err := strconv.ParseFloat(rc.PricePerUnit.USD, 64)
+		return cost, err == nil
+	}
+	if rc, ok := terms.OnDemand.PriceDimensions[terms.Sku+cloud.OnDemandRateCodeCn+cloud.HourlyRateCodeCn]; ok && rc != nil {
+		cost, err := strconv.ParseFloat(rc.PricePerUnit.CNY, 64)
+		return cost, err == nil
+	}
+
+	return 0, false
+}
@@ -0,0 +1,105 @@
+package pricing
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+)
+
+func TestComparePricing(t *testing.T) {
+	before := &cloud.CustomProvider{Pricing: map[string]*cloud.NodePrice{
+		"default":      {CPU: "0.04", RAM: "0.004", GPU: "0.95"},
+		"default,spot": {CPU: "0.01", RAM: "0.001"},
+		"removed":      {CPU: "0.02"},
+	}}
+	after := &cloud.CustomProvider{Pricing: map[string]*cloud.NodePrice{
+		"default":      {CPU: "0.05", RAM: "0.004", GPU: "0.90"},
+		"default,spot": {CPU: "0.01", RAM: "0.001"},
+		"added":        {CPU: "0.03"},
+	}}
+
+	comparison, err := ComparePricing(before, after)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]string{
+		"increases": "[{default/cpu 0.04 0.05}]",
+		"decreases": "[{default/gpu 0.95 0.9}]",
+		"unchanged": "[{default,spot/cpu 0.01 0.01} {default,spot/ram 0.001 0.001} {default/ram 0.004 0.004}]",
+		"added":     "[added/cpu]",
+		"removed":   "[removed/cpu]",
+	}
+	actual := map[string]string{
+		"increases": fmt.Sprint(comparison.Increases),
+		"decreases": fmt.Sprint(comparison.Decreases),
+		"unchanged": fmt.Sprint(comparison.Unchanged),
+		"added":     fmt.Sprint(comparison.Added),
+		"removed":   fmt.Sprint(comparison.Removed),
+	}
+	for list := range expected {
+		if actual[list] != expected[list] {
+			t.Errorf("%s: expected %s; got %s", list, expected[list], actual[list])
+		}
+	}
+
+	if d := comparison.Increases[0].Delta(); d < 0.0099 || d > 0.0101 {
+		t.Errorf("expected delta of 0.01; got %f", d)
+	}
+}
+
+func TestComparePricingNodeCosts(t *testing.T) {
+	before := &cloud.Azure{Pricing: map[string]*cloud.AzurePricing{
+		"eastus,Standard_D2s_v3": {Node: &cloud.Node{Cost: "0.096"}},
+		"eastus,Standard_D4s_v3": {Node: &cloud.Node{VCPUCost: "0.024", RAMCost: "0.003"}},
+		"eastus,disk":            {PV: &cloud.PV{Cost: "0.1"}},
+	}}
+	after := &cloud.Azure{Pricing: map[string]*cloud.AzurePricing{
+		"eastus,Standard_D2s_v3": {Node: &cloud.Node{Cost: "0.090"}},
+		"eastus,Standard_D4s_v3": {Node: &cloud.Node{VCPUCost: "0.024", RAMCost: "0.003"}},
+	}}
+
+	comparison, err := ComparePricing(before, after)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(comparison.Decreases) != 1 || comparison.Decreases[0].NodeType != "eastus,Standard_D2s_v3" {
+		t.Errorf("expected the hourly cost of Standard_D2s_v3 to decrease; got %v", comparison.Decreases)
+	}
+	if len(comparison.Unchanged) != 2 || len(comparison.Increases) != 0 {
+		t.Errorf("expected the resource rates of Standard_D4s_v3 to be unchanged; got %v", comparison.Unchanged)
+	}
+	if len(comparison.Added) != 0 || len(comparison.Removed) != 0 {
+		t.Errorf("expected PV pricing to be ignored; got added %v, removed %v", comparison.Added, comparison.Removed)
+	}
+}
+
+func TestComparePricingAWS(t *testing.T) {
+	terms := func(sku, usd string) *cloud.AWSProductTerms {
+		return &cloud.AWSProductTerms{
+			Sku: sku,
+			OnDemand: &cloud.AWSOfferTerm{
+				Sku: sku,
+				PriceDimensions: map[string]*cloud.AWSRateCode{
+					sku + cloud.OnDemandRateCode + cloud.HourlyRateCode: {
+						Unit:         "Hrs",
+						PricePerUnit: cloud.AWSCurrencyCode{USD: usd},
+					},
+				},
+			},
+		}
+	}
+
+	before := &cloud.AWS{Pricing: map[string]*cloud.AWSProductTerms{"us-east-1,m5.large": terms("ABC", "0.096")}}
+	after := &cloud.AWS{Pricing: map[string]*cloud.AWSProductTerms{"us-east-1,m5.large": terms("ABC", "0.100")}}
+
+	comparison, err := ComparePricing(before, after)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(comparison.Increases) != 1 || comparison.Increases[0].OldCost != 0.096 || comparison.Increases[0].NewCost != 0.1 {
+		t.Fatalf("expected the on-demand cost of m5.large to increase; got %v", comparison.Increases)
+	}
+}
@@ -0,0 +1,93 @@
+package pricing
+
+import (
+	"math"
+	"sort"
+)
+
+// DefaultReconciliationThreshold is the percentage by which computed costs
+// may differ from the bill before they are reported by a BillReconciler
+const DefaultReconciliationThreshold = 5.0
+
+// ReconciliationDelta is the billed and computed cost of a node or PV type
+// whose difference exceeds the threshold of a BillReconciler
+type ReconciliationDelta struct {
+	Key      string  `json:"key"`
+	Actual   float64 `json:"actual"`
+	Computed float64 `json:"computed"`
+	// Difference is Computed less Actual, so is negative if the cost model
+	// underestimates the bill
+	Difference float64 `json:"difference"`
+	// PercentDifference is the absolute Difference as a percentage of Actual,
+	// or 100 if nothing was billed
+	PercentDifference float64 `json:"percentDifference"`
+}
+
+// BillReconciler compares the costs of a cloud bill with those computed by
+// the cost model, to find where the estimates diverge from the bill.
+type BillReconciler struct {
+	// ThresholdPercent is the percentage of the billed cost by which the
+	// computed cost must differ to be reported
+	ThresholdPercent float64
+}
+
+// NewBillReconciler creates a BillReconciler which reports costs differing
+// by more than the given percentage. A negative threshold is treated as 0.
+func NewBillReconciler(thresholdPercent float64) *BillReconciler {
+	if thresholdPercent < 0 {
+		thresholdPercent = 0
+	}
+
+	return &BillReconciler{
+		ThresholdPercent: thresholdPercent,
+	}
+}
+
+// Reconcile compares the actual, billed costs with the computed costs, each
+// keyed by node or PV type, and returns the deltas of the keys whose costs
+// differ by more than the threshold, sorted by the size of the difference. A
+// key missing from either map has a cost of 0 there, so costs which are only
+// billed, or only computed, are always reported.
+func (br *BillReconciler) Reconcile(actual map[string]float64, computed map[string]float64) []ReconciliationDelta {
+	keys := map[string]bool{}
+	for key := range actual {
+		keys[key] = true
+	}
+	for key := range computed {
+		keys[key] = true
+	}
+
+	deltas := []ReconciliationDelta{}
+	for key := range keys {
+		delta := ReconciliationDelta{
+			Key:        key,
+			Actual:     actual[key],
+			Computed:   computed[key],
+			Difference: computed[key] - actual[key],
+		}
+
+		if math.Abs(delta.Difference) < comparisonTolerance {
+			continue
+		}
+
+		if delta.Actual == 0 {
+			delta.PercentDifference = 100
+		} else {
+			delta.PercentDifference = math.Abs(delta.Difference/delta.Actual) * 100
+		}
+
+		if delta.PercentDifference > br.ThresholdPercent {
+			deltas = append(deltas, delta)
+		}
+	}
+
+	sort.Slice(deltas, func(i, j int) bool {
+		di, dj := math.Abs(deltas[i].Difference), math.Abs(deltas[j].Difference)
+		if di != dj {
+			return di > dj
+		}
+		return deltas[i].Key < deltas[j].Key
+	})
+
+	return deltas
+}
@@ -0,0 +1,58 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBillReconcilerReconcile(t *testing.T) {
+	actual := map[string]float64{
+		"m5.large":     100.0,
+		"m5.xlarge":    200.0,
+		"c5.large":     50.0,
+		"gp2":          10.0,
+		"billed-only":  5.0,
+		"free-in-both": 0.0,
+	}
+	computed := map[string]float64{
+		// Within 5% of the bill
+		"m5.large": 104.0,
+		// 10% under the bill
+		"m5.xlarge": 180.0,
+		// 20% over the bill
+		"c5.large":      60.0,
+		"gp2":           10.0,
+		"computed-only": 2.0,
+		"free-in-both":  0.0,
+	}
+
+	deltas := NewBillReconciler(DefaultReconciliationThreshold).Reconcile(actual, computed)
+
+	expected := []ReconciliationDelta{
+		{Key: "m5.xlarge", Actual: 200.0, Computed: 180.0, Difference: -20.0, PercentDifference: 10.0},
+		{Key: "c5.large", Actual: 50.0, Computed: 60.0, Difference: 10.0, PercentDifference: 20.0},
+		{Key: "billed-only", Actual: 5.0, Computed: 0.0, Difference: -5.0, PercentDifference: 100.0},
+		{Key: "computed-only", Actual: 0.0, Computed: 2.0, Difference: 2.0, PercentDifference: 100.0},
+	}
+
+	if len(deltas) != len(expected) {
+		t.Fatalf("expected %d deltas; got %d: %v", len(expected), len(deltas), deltas)
+	}
+	for i, e := range expected {
+		d := deltas[i]
+		if d.Key != e.Key || d.Actual != e.Actual || d.Computed != e.Computed ||
+			math.Abs(d.Difference-e.Difference) > 1e-9 || math.Abs(d.PercentDifference-e.PercentDifference) > 1e-9 {
+			t.Errorf("delta %d: expected %+v; got %+v", i, e, d)
+		}
+	}
+
+	// Raising the threshold excludes the smaller differences
+	deltas = NewBillReconciler(15).Reconcile(actual, computed)
+	if len(deltas) != 3 || deltas[0].Key != "c5.large" {
+		t.Fatalf("expected 3 deltas led by c5.large with a threshold of 15%%; got %v", deltas)
+	}
+
+	if deltas := NewBillReconciler(0).Reconcile(nil, nil); deltas == nil || len(deltas) != 0 {
+		t.Fatalf("expected no deltas reconciling nothing; got %v", deltas)
+	}
+}
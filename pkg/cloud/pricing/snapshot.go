@@ -0,0 +1,68 @@
+package pricing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+)
+
+// PricingSnapshot is the node pricing of a provider at a point in time, by
+// pricing key. It is defined in the cloud package, which cannot import this
+// one, so that a CustomProvider can Restore it.
+type PricingSnapshot = cloud.PricingSnapshot
+
+// TakeSnapshot captures the node pricing of the provider, as returned by
+// AllNodePricing, as the prices of CPU, RAM, and GPU of each pricing key.
+// Pricing which is only available as the cost of a whole node, such as AWS
+// on-demand terms, cannot be captured.
+func TakeSnapshot(provider cloud.Provider) (*PricingSnapshot, error) {
+	pricing, err := provider.AllNodePricing()
+	if err != nil {
+		return nil, fmt.Errorf("error getting node pricing: %s", err)
+	}
+
+	snapshot := &PricingSnapshot{
+		Timestamp: time.Now().UTC(),
+		Prices:    map[string]*cloud.NodePrice{},
+	}
+
+	switch p := pricing.(type) {
+	case map[string]*cloud.NodePrice:
+		for key, np := range p {
+			if np != nil {
+				price := *np
+				snapshot.Prices[key] = &price
+			}
+		}
+	case map[string]*cloud.GCPPricing:
+		for key, gp := range p {
+			if gp != nil {
+				addNodePrice(snapshot.Prices, key, gp.Node)
+			}
+		}
+	case map[string]*cloud.AzurePricing:
+		for key, ap := range p {
+			if ap != nil {
+				addNodePrice(snapshot.Prices, key, ap.Node)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("snapshots of node pricing type %T are not supported", pricing)
+	}
+
+	return snapshot, nil
+}
+
+// addNodePrice adds the rates of the node's resources, if any are set
+func addNodePrice(prices map[string]*cloud.NodePrice, key string, node *cloud.Node) {
+	if node == nil || (node.VCPUCost == "" && node.RAMCost == "" && node.GPUCost == "") {
+		return
+	}
+
+	prices[key] = &cloud.NodePrice{
+		CPU: node.VCPUCost,
+		RAM: node.RAMCost,
+		GPU: node.GPUCost,
+	}
+}
@@ -0,0 +1,107 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestTakeSnapshotAndRestore(t *testing.T) {
+	cp := &cloud.CustomProvider{Pricing: map[string]*cloud.NodePrice{
+		"default":      {CPU: "0.04", RAM: "0.004"},
+		"default,spot": {CPU: "0.01", RAM: "0.001"},
+	}}
+
+	snapshot, err := TakeSnapshot(cp)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if snapshot.Timestamp.IsZero() || len(snapshot.Prices) != 2 || snapshot.Prices["default"].CPU != "0.04" {
+		t.Fatalf("unexpected snapshot: %+v", snapshot)
+	}
+
+	// The snapshot is unaffected by later changes to the pricing
+	cp.Pricing["default"].CPU = "0.05"
+	cp.Pricing["added"] = &cloud.NodePrice{CPU: "1.0"}
+	if snapshot.Prices["default"].CPU != "0.04" {
+		t.Fatalf("expected the snapshot to be a copy of the pricing; got %s", snapshot.Prices["default"].CPU)
+	}
+
+	if err := cp.Restore(snapshot); err != nil {
+		t.Fatalf("unexpected error restoring: %s", err)
+	}
+	if len(cp.Pricing) != 2 || cp.Pricing["default"].CPU != "0.04" || cp.Pricing["added"] != nil {
+		t.Fatalf("expected the snapshot's pricing to be restored; got %v", cp.Pricing)
+	}
+
+	// Nor is the snapshot affected by changes to the restored pricing
+	cp.Pricing["default,spot"].CPU = "0.02"
+	if snapshot.Prices["default,spot"].CPU != "0.01" {
+		t.Fatalf("expected the restored pricing to be a copy of the snapshot")
+	}
+
+	if err := cp.Restore(&PricingSnapshot{}); err == nil {
+		t.Errorf("expected an error restoring an empty snapshot")
+	}
+}
+
+func TestTakeSnapshotProviders(t *testing.T) {
+	gcp := &cloud.GCP{Pricing: map[string]*cloud.GCPPricing{
+		"n1-standard-1": {Node: &cloud.Node{VCPUCost: "0.03", RAMCost: "0.004"}},
+		"total-only":    {Node: &cloud.Node{Cost: "0.1"}},
+	}}
+
+	snapshot, err := TakeSnapshot(gcp)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(snapshot.Prices) != 1 || snapshot.Prices["n1-standard-1"].CPU != "0.03" || snapshot.Prices["n1-standard-1"].RAM != "0.004" {
+		t.Fatalf("expected the resource rates of the priced node type; got %v", snapshot.Prices)
+	}
+
+	if _, err := TakeSnapshot(&cloud.AWS{}); err == nil {
+		t.Errorf("expected an error snapshotting AWS on-demand terms")
+	}
+}
+
+func TestRestoreProviderSnapshot(t *testing.T) {
+	gcp := &cloud.GCP{Pricing: map[string]*cloud.GCPPricing{
+		"us-central1,n1-standard-1": {Node: &cloud.Node{VCPUCost: "0.03", RAMCost: "0.004"}},
+	}}
+	snapshot, err := TakeSnapshot(gcp)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	cp := &cloud.CustomProvider{Pricing: map[string]*cloud.NodePrice{
+		"default":      {CPU: "0.04", RAM: "0.004"},
+		"default,spot": {CPU: "0.01", RAM: "0.001"},
+	}}
+	if err := cp.Restore(snapshot); err != nil {
+		t.Fatalf("unexpected error restoring: %s", err)
+	}
+	if cp.Pricing["us-central1,n1-standard-1"] == nil {
+		t.Fatalf("expected the snapshot's pricing to be restored; got %v", cp.Pricing)
+	}
+
+	// Nodes are still priced at the configured default prices, which the
+	// snapshot lacks
+	node, err := cp.NodePricing(cp.GetKey(map[string]string{}, &v1.Node{}))
+	if err != nil {
+		t.Fatalf("unexpected error pricing node: %s", err)
+	}
+	if node.VCPUCost != "0.04" || node.RAMCost != "0.004" {
+		t.Fatalf("expected the default prices; got %s and %s", node.VCPUCost, node.RAMCost)
+	}
+
+	// Without configured default prices, nodes cannot be priced
+	unconfigured := &cloud.CustomProvider{}
+	if err := unconfigured.Restore(snapshot); err != nil {
+		t.Fatalf("unexpected error restoring: %s", err)
+	}
+	if _, err := unconfigured.NodePricing(unconfigured.GetKey(map[string]string{}, &v1.Node{})); err == nil {
+		t.Errorf("expected an error pricing a node without default prices")
+	}
+}
@@ -0,0 +1,137 @@
+package pricing
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PricingTier is the price of usage up to a cumulative amount, beyond the
+// amount of the previous tier. An UpToGB of zero is unbounded, and may only be
+// the last tier.
+type PricingTier struct {
+	UpToGB     float64 `json:"upToGB"`
+	PricePerGB float64 `json:"pricePerGB"`
+}
+
+// TieredPricing prices usage in tiers, e.g. a free tier for the first 100 GB
+// of egress, then $0.09/GB up to 10 TB, and $0.085/GB beyond. Usage beyond
+// the last tier is priced at the last tier's price.
+type TieredPricing struct {
+	Tiers []PricingTier `json:"tiers"`
+}
+
+// ParseTieredPricing parses tiers of the form "upToGB:pricePerGB", separated
+// by commas, e.g. "100:0,10240:0.09,0:0.085". Tiers are sorted by UpToGB, with
+// an unbounded tier last.
+func ParseTieredPricing(s string) (*TieredPricing, error) {
+	tp := &TieredPricing{}
+
+	for _, t := range strings.Split(s, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" {
+			continue
+		}
+
+		split := strings.Split(t, ":")
+		if len(split) != 2 {
+			return nil, fmt.Errorf("invalid tier %q: expected upToGB:pricePerGB", t)
+		}
+
+		upTo, err := strconv.ParseFloat(strings.TrimSpace(split[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tier %q: %s", t, err)
+		}
+		price, err := strconv.ParseFloat(strings.TrimSpace(split[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tier %q: %s", t, err)
+		}
+
+		tp.Tiers = append(tp.Tiers, PricingTier{UpToGB: upTo, PricePerGB: price})
+	}
+
+	sort.SliceStable(tp.Tiers, func(i, j int) bool {
+		if tp.Tiers[i].UpToGB == 0 {
+			return false
+		}
+		if tp.Tiers[j].UpToGB == 0 {
+			return true
+		}
+		return tp.Tiers[i].UpToGB < tp.Tiers[j].UpToGB
+	})
+
+	err := tp.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return tp, nil
+}
+
+// Validate returns an error if there are no tiers, if any bound or price is
+// negative, or if an unbounded tier is not last. Tiers must be in ascending
+// order of UpToGB.
+func (tp *TieredPricing) Validate() error {
+	if tp == nil || len(tp.Tiers) == 0 {
+		return fmt.Errorf("no pricing tiers")
+	}
+
+	prev := 0.0
+	for i, tier := range tp.Tiers {
+		if tier.UpToGB < 0 {
+			return fmt.Errorf("tier %d: negative bound: %f", i, tier.UpToGB)
+		}
+		if tier.PricePerGB < 0 {
+			return fmt.Errorf("tier %d: negative price: %f", i, tier.PricePerGB)
+		}
+		if tier.UpToGB == 0 {
+			if i != len(tp.Tiers)-1 {
+				return fmt.Errorf("tier %d: only the last tier may be unbounded", i)
+			}
+			continue
+		}
+		if tier.UpToGB <= prev {
+			return fmt.Errorf("tier %d: bound %f is not greater than the previous bound %f", i, tier.UpToGB, prev)
+		}
+		prev = tier.UpToGB
+	}
+
+	return nil
+}
+
+// Compute returns the cost of the given usage, in GB, filling each tier in
+// turn. Usage beyond the last tier is priced at the last tier's price.
+func (tp *TieredPricing) Compute(usageGB float64) float64 {
+	if tp == nil || len(tp.Tiers) == 0 || usageGB <= 0 {
+		return 0
+	}
+
+	cost := 0.0
+	priced := 0.0
+	for _, tier := range tp.Tiers {
+		if tier.UpToGB == 0 || usageGB <= tier.UpToGB {
+			return cost + (usageGB-priced)*tier.PricePerGB
+		}
+
+		cost += (tier.UpToGB - priced) * tier.PricePerGB
+		priced = tier.UpToGB
+	}
+
+	last := tp.Tiers[len(tp.Tiers)-1]
+	return cost + (usageGB-priced)*last.PricePerGB
+}
+
+// EffectiveRate returns the average price per GB of the given usage, by which
+// a share of the usage, e.g. a single pod's, may be priced. Zero usage is
+// priced at the first tier's price.
+func (tp *TieredPricing) EffectiveRate(usageGB float64) float64 {
+	if tp == nil || len(tp.Tiers) == 0 {
+		return 0
+	}
+	if usageGB <= 0 {
+		return tp.Tiers[0].PricePerGB
+	}
+
+	return tp.Compute(usageGB) / usageGB
+}
@@ -0,0 +1,84 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTieredPricing_Compute(t *testing.T) {
+	tp := &TieredPricing{Tiers: []PricingTier{
+		{UpToGB: 100, PricePerGB: 0},
+		{UpToGB: 10240, PricePerGB: 0.09},
+		{UpToGB: 0, PricePerGB: 0.085},
+	}}
+
+	cases := map[string]struct {
+		usage    float64
+		expected float64
+	}{
+		"no usage":             {0, 0},
+		"within free tier":     {50, 0},
+		"at free tier bound":   {100, 0},
+		"within second tier":   {1100, 1000 * 0.09},
+		"within unbounded":     {11240, 10140*0.09 + 1000*0.085},
+		"negative usage":       {-10, 0},
+		"second tier boundary": {10240, 10140 * 0.09},
+	}
+
+	for name, c := range cases {
+		if cost := tp.Compute(c.usage); math.Abs(cost-c.expected) > 1e-9 {
+			t.Errorf("%s: expected %f; got %f", name, c.expected, cost)
+		}
+	}
+
+	// Usage beyond bounded tiers is priced at the last tier's price
+	bounded := &TieredPricing{Tiers: []PricingTier{
+		{UpToGB: 10, PricePerGB: 0},
+		{UpToGB: 20, PricePerGB: 0.1},
+	}}
+	if cost := bounded.Compute(30); math.Abs(cost-2) > 1e-9 {
+		t.Errorf("expected usage beyond the last tier to cost 2; got %f", cost)
+	}
+
+	if rate := tp.EffectiveRate(1100); math.Abs(rate-90.0/1100) > 1e-9 {
+		t.Errorf("expected effective rate %f; got %f", 90.0/1100, rate)
+	}
+	if rate := tp.EffectiveRate(0); rate != 0 {
+		t.Errorf("expected effective rate of no usage to be the first tier's price; got %f", rate)
+	}
+}
+
+func TestParseTieredPricing(t *testing.T) {
+	tp, err := ParseTieredPricing(" 0:0.085, 100:0 ,10240:0.09")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []PricingTier{
+		{UpToGB: 100, PricePerGB: 0},
+		{UpToGB: 10240, PricePerGB: 0.09},
+		{UpToGB: 0, PricePerGB: 0.085},
+	}
+	if len(tp.Tiers) != len(expected) {
+		t.Fatalf("expected %d tiers; got %v", len(expected), tp.Tiers)
+	}
+	for i := range expected {
+		if tp.Tiers[i] != expected[i] {
+			t.Errorf("expected tier %d to be %v; got %v", i, expected[i], tp.Tiers[i])
+		}
+	}
+
+	invalid := []string{
+		"",
+		"100",
+		"100:free",
+		"100:-0.1",
+		"0:0.1,0:0.2",
+		"100:0,100:0.09",
+	}
+	for _, s := range invalid {
+		if _, err := ParseTieredPricing(s); err == nil {
+			t.Errorf("expected %q to be invalid", s)
+		}
+	}
+}
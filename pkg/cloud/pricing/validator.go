@@ -0,0 +1,282 @@
+package pricing
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// The checks performed by a PricingValidator, by which ValidationErrors are
+// categorized
+const (
+	ConfigCheck         = "config"
+	NodePricingCheck    = "nodePricing"
+	PVPricingCheck      = "pvPricing"
+	NetworkPricingCheck = "networkPricing"
+)
+
+// ValidationError is a problem with a provider's pricing configuration, found
+// by one of a PricingValidator's checks.
+type ValidationError struct {
+	Check   string `json:"check"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error returns the check, field, and message of the ValidationError
+func (ve ValidationError) Error() string {
+	if ve.Field == "" {
+		return fmt.Sprintf("%s: %s", ve.Check, ve.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", ve.Check, ve.Field, ve.Message)
+}
+
+// PricingValidator checks the correctness of a provider's pricing
+// configuration by exercising each of its pricing methods, so that errors are
+// discovered up front, e.g. at startup or on config update, rather than while
+// computing costs.
+type PricingValidator struct {
+	// Node is the node whose key is priced. If nil, a synthetic node with
+	// standard instance type and region labels is used.
+	Node *v1.Node
+
+	// PersistentVolume is the volume whose key is priced. If nil, a synthetic
+	// volume is used.
+	PersistentVolume *v1.PersistentVolume
+
+	// StorageClassParameters are the parameters of the volume's storage class
+	StorageClassParameters map[string]string
+
+	// Region is the default region of the volume
+	Region string
+}
+
+// NewPricingValidator creates a new PricingValidator which prices synthetic
+// node and volume keys.
+func NewPricingValidator() *PricingValidator {
+	return &PricingValidator{
+		StorageClassParameters: map[string]string{},
+	}
+}
+
+// Validate exercises the given provider's config and node, PV, and network
+// pricing, returning every error found. An empty slice means that the
+// provider's pricing is valid.
+func (pv *PricingValidator) Validate(p cloud.Provider) []ValidationError {
+	errs := []ValidationError{}
+
+	errs = append(errs, pv.validateConfig(p)...)
+	errs = append(errs, pv.validateNodePricing(p)...)
+	errs = append(errs, pv.validatePVPricing(p)...)
+	errs = append(errs, pv.validateNetworkPricing(p)...)
+
+	return errs
+}
+
+// validateConfig checks that the provider's config loads, and that each of its
+// prices is a non-negative number.
+func (pv *PricingValidator) validateConfig(p cloud.Provider) []ValidationError {
+	config, err := p.GetConfig()
+	if err != nil {
+		return []ValidationError{{Check: ConfigCheck, Message: fmt.Sprintf("failed to load config: %s", err)}}
+	}
+	if config == nil {
+		return []ValidationError{{Check: ConfigCheck, Message: "config is empty"}}
+	}
+
+	prices := []struct {
+		field string
+		value string
+	}{
+		{"CPU", config.CPU},
+		{"spotCPU", config.SpotCPU},
+		{"RAM", config.RAM},
+		{"spotRAM", config.SpotRAM},
+		{"GPU", config.GPU},
+		{"spotGPU", config.SpotGPU},
+		{"storage", config.Storage},
+		{"zoneNetworkEgress", config.ZoneNetworkEgress},
+		{"regionNetworkEgress", config.RegionNetworkEgress},
+		{"internetNetworkEgress", config.InternetNetworkEgress},
+		{"firstFiveForwardingRulesCost", config.FirstFiveForwardingRulesCost},
+		{"additionalForwardingRuleCost", config.AdditionalForwardingRuleCost},
+		{"LBIngressDataCost", config.LBIngressDataCost},
+	}
+
+	var errs []ValidationError
+	for _, price := range prices {
+		if err := validatePrice(price.value); err != nil {
+			errs = append(errs, ValidationError{Check: ConfigCheck, Field: price.field, Message: err.Error()})
+		}
+	}
+
+	if config.OnDemandFraction != "" {
+		fraction, err := strconv.ParseFloat(config.OnDemandFraction, 64)
+		if err != nil || fraction < 0 || fraction > 1 {
+			errs = append(errs, ValidationError{Check: ConfigCheck, Field: "onDemandFraction", Message: fmt.Sprintf("'%s' is not a fraction between 0 and 1", config.OnDemandFraction)})
+		}
+	}
+
+	return errs
+}
+
+// validateNodePricing checks that the provider prices a node key, and that
+// the node's prices are non-negative numbers.
+func (pv *PricingValidator) validateNodePricing(p cloud.Provider) []ValidationError {
+	node := pv.Node
+	if node == nil {
+		node = syntheticNode()
+	}
+
+	key := p.GetKey(node.Labels, node)
+	if key == nil {
+		return []ValidationError{{Check: NodePricingCheck, Message: "no pricing key for node"}}
+	}
+
+	n, err := p.NodePricing(key)
+	if err != nil {
+		return []ValidationError{{Check: NodePricingCheck, Message: fmt.Sprintf("failed to price node key %s: %s", key.ID(), err)}}
+	}
+	if n == nil {
+		return []ValidationError{{Check: NodePricingCheck, Message: fmt.Sprintf("no pricing for node key %s", key.ID())}}
+	}
+
+	if n.Cost == "" && n.VCPUCost == "" && n.RAMCost == "" {
+		return []ValidationError{{Check: NodePricingCheck, Message: fmt.Sprintf("node key %s has neither a total nor a CPU or RAM price", key.ID())}}
+	}
+
+	prices := []struct {
+		field string
+		value string
+	}{
+		{"hourlyCost", n.Cost},
+		{"CPUHourlyCost", n.VCPUCost},
+		{"RAMGBHourlyCost", n.RAMCost},
+		{"gpuCost", n.GPUCost},
+	}
+
+	var errs []ValidationError
+	for _, price := range prices {
+		if err := validatePrice(price.value); err != nil {
+			errs = append(errs, ValidationError{Check: NodePricingCheck, Field: price.field, Message: err.Error()})
+		}
+	}
+
+	return errs
+}
+
+// validatePVPricing checks that the provider prices a volume key, and that the
+// volume's price is a non-negative number.
+func (pv *PricingValidator) validatePVPricing(p cloud.Provider) []ValidationError {
+	vol := pv.PersistentVolume
+	if vol == nil {
+		vol = syntheticPersistentVolume()
+	}
+
+	key := p.GetPVKey(vol, pv.StorageClassParameters, pv.Region)
+	if key == nil {
+		return []ValidationError{{Check: PVPricingCheck, Message: "no pricing key for persistent volume"}}
+	}
+
+	v, err := p.PVPricing(key)
+	if err != nil {
+		return []ValidationError{{Check: PVPricingCheck, Message: fmt.Sprintf("failed to price persistent volume key %s: %s", key.ID(), err)}}
+	}
+	if v == nil {
+		return []ValidationError{{Check: PVPricingCheck, Message: fmt.Sprintf("no pricing for persistent volume key %s", key.ID())}}
+	}
+
+	if err := validatePrice(v.Cost); err != nil {
+		return []ValidationError{{Check: PVPricingCheck, Field: "hourlyCost", Message: err.Error()}}
+	}
+
+	return nil
+}
+
+// validateNetworkPricing checks that the provider prices network egress, and
+// that each price is non-negative.
+func (pv *PricingValidator) validateNetworkPricing(p cloud.Provider) []ValidationError {
+	n, err := p.NetworkPricing()
+	if err != nil {
+		return []ValidationError{{Check: NetworkPricingCheck, Message: fmt.Sprintf("failed to price network: %s", err)}}
+	}
+	if n == nil {
+		return []ValidationError{{Check: NetworkPricingCheck, Message: "no network pricing"}}
+	}
+
+	prices := []struct {
+		field string
+		value float64
+	}{
+		{"zoneNetworkEgress", n.ZoneNetworkEgressCost},
+		{"regionNetworkEgress", n.RegionNetworkEgressCost},
+		{"internetNetworkEgress", n.InternetNetworkEgressCost},
+	}
+
+	var errs []ValidationError
+	for _, price := range prices {
+		if price.value < 0 {
+			errs = append(errs, ValidationError{Check: NetworkPricingCheck, Field: price.field, Message: fmt.Sprintf("negative price: %f", price.value)})
+		}
+	}
+
+	if n.InternetNetworkEgressTiers != "" {
+		if _, err := ParseTieredPricing(n.InternetNetworkEgressTiers); err != nil {
+			errs = append(errs, ValidationError{Check: NetworkPricingCheck, Field: "internetNetworkEgressTiers", Message: err.Error()})
+		}
+	}
+
+	return errs
+}
+
+// validatePrice returns an error if the given price is set, but is not a
+// non-negative number. Unset prices are valid, because providers fall back
+// to defaults for them.
+func validatePrice(price string) error {
+	if price == "" {
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(price, 64)
+	if err != nil {
+		return fmt.Errorf("invalid price: %q", price)
+	}
+	if f < 0 {
+		return fmt.Errorf("negative price: %s", price)
+	}
+
+	return nil
+}
+
+// syntheticNode returns a node with the standard labels used to build pricing
+// keys, but no provider ID, so that providers price it from their config or
+// downloaded pricing rather than from a specific instance.
+func syntheticNode() *v1.Node {
+	labels := map[string]string{
+		v1.LabelInstanceType:               "synthetic",
+		"node.kubernetes.io/instance-type": "synthetic",
+		v1.LabelTopologyRegion:             "synthetic",
+		v1.LabelOSStable:                   "linux",
+	}
+
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "pricing-validator",
+			Labels: labels,
+		},
+	}
+}
+
+// syntheticPersistentVolume returns a volume with no storage class or cloud
+// volume source, which providers price at their default storage price.
+func syntheticPersistentVolume() *v1.PersistentVolume {
+	return &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "pricing-validator",
+		},
+	}
+}
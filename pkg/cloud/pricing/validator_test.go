@@ -0,0 +1,249 @@
+package pricing
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+type testKey struct{ id string }
+
+func (k *testKey) ID() string              { return k.id }
+func (k *testKey) Features() string        { return k.id }
+func (k *testKey) GPUType() string         { return "" }
+func (k *testKey) GetStorageClass() string { return "" }
+
+// testProvider is a cloud.Provider whose pricing methods return fixed results.
+// Methods not used by the PricingValidator are not implemented.
+type testProvider struct {
+	cloud.Provider
+
+	config     *cloud.CustomPricing
+	configErr  error
+	node       *cloud.Node
+	nodeErr    error
+	pv         *cloud.PV
+	pvErr      error
+	network    *cloud.Network
+	networkErr error
+}
+
+func (tp *testProvider) GetConfig() (*cloud.CustomPricing, error) {
+	return tp.config, tp.configErr
+}
+
+func (tp *testProvider) GetKey(labels map[string]string, n *v1.Node) cloud.Key {
+	return &testKey{id: labels[v1.LabelInstanceType]}
+}
+
+func (tp *testProvider) NodePricing(key cloud.Key) (*cloud.Node, error) {
+	return tp.node, tp.nodeErr
+}
+
+func (tp *testProvider) GetPVKey(pv *v1.PersistentVolume, parameters map[string]string, defaultRegion string) cloud.PVKey {
+	return &testKey{id: pv.Name}
+}
+
+func (tp *testProvider) PVPricing(key cloud.PVKey) (*cloud.PV, error) {
+	return tp.pv, tp.pvErr
+}
+
+func (tp *testProvider) NetworkPricing() (*cloud.Network, error) {
+	return tp.network, tp.networkErr
+}
+
+func newValidTestProvider() *testProvider {
+	return &testProvider{
+		config: &cloud.CustomPricing{
+			CPU:     "0.031611",
+			SpotCPU: "0.006655",
+			RAM:     "0.004237",
+			SpotRAM: "0.000892",
+			GPU:     "0.95",
+			Storage: "0.00005479452",
+		},
+		node: &cloud.Node{
+			VCPUCost: "0.031611",
+			RAMCost:  "0.004237",
+		},
+		pv: &cloud.PV{
+			Cost: "0.00005479452",
+		},
+		network: &cloud.Network{
+			ZoneNetworkEgressCost:     0.01,
+			RegionNetworkEgressCost:   0.01,
+			InternetNetworkEgressCost: 0.12,
+		},
+	}
+}
+
+func TestPricingValidator_Validate(t *testing.T) {
+	cases := []struct {
+		name     string
+		modify   func(tp *testProvider)
+		expected []ValidationError
+	}{
+		{
+			name:     "valid",
+			modify:   func(tp *testProvider) {},
+			expected: nil,
+		},
+		{
+			name: "config error",
+			modify: func(tp *testProvider) {
+				tp.configErr = fmt.Errorf("unreadable")
+			},
+			expected: []ValidationError{
+				{Check: ConfigCheck, Message: "failed to load config: unreadable"},
+			},
+		},
+		{
+			name: "invalid config prices",
+			modify: func(tp *testProvider) {
+				tp.config.CPU = "cheap"
+				tp.config.RAM = "-1"
+			},
+			expected: []ValidationError{
+				{Check: ConfigCheck, Field: "CPU", Message: `invalid price: "cheap"`},
+				{Check: ConfigCheck, Field: "RAM", Message: "negative price: -1"},
+			},
+		},
+		{
+			name: "invalid on-demand fraction",
+			modify: func(tp *testProvider) {
+				tp.config.OnDemandFraction = "1.5"
+			},
+			expected: []ValidationError{
+				{Check: ConfigCheck, Field: "onDemandFraction", Message: "'1.5' is not a fraction between 0 and 1"},
+			},
+		},
+		{
+			name: "node pricing error",
+			modify: func(tp *testProvider) {
+				tp.node = nil
+				tp.nodeErr = fmt.Errorf("not found")
+			},
+			expected: []ValidationError{
+				{Check: NodePricingCheck, Message: "failed to price node key synthetic: not found"},
+			},
+		},
+		{
+			name: "node has no prices",
+			modify: func(tp *testProvider) {
+				tp.node = &cloud.Node{}
+			},
+			expected: []ValidationError{
+				{Check: NodePricingCheck, Message: "node key synthetic has neither a total nor a CPU or RAM price"},
+			},
+		},
+		{
+			name: "negative PV price",
+			modify: func(tp *testProvider) {
+				tp.pv.Cost = "-0.1"
+			},
+			expected: []ValidationError{
+				{Check: PVPricingCheck, Field: "hourlyCost", Message: "negative price: -0.1"},
+			},
+		},
+		{
+			name: "negative network price",
+			modify: func(tp *testProvider) {
+				tp.network.InternetNetworkEgressCost = -0.12
+			},
+			expected: []ValidationError{
+				{Check: NetworkPricingCheck, Field: "internetNetworkEgress", Message: "negative price: -0.120000"},
+			},
+		},
+		{
+			name: "invalid network egress tiers",
+			modify: func(tp *testProvider) {
+				tp.network.InternetNetworkEgressTiers = "100:0,100:0.09"
+			},
+			expected: []ValidationError{
+				{Check: NetworkPricingCheck, Field: "internetNetworkEgressTiers", Message: "tier 1: bound 100.000000 is not greater than the previous bound 100.000000"},
+			},
+		},
+		{
+			name: "every check fails",
+			modify: func(tp *testProvider) {
+				tp.config = nil
+				tp.node = nil
+				tp.pv = nil
+				tp.network = nil
+			},
+			expected: []ValidationError{
+				{Check: ConfigCheck, Message: "config is empty"},
+				{Check: NodePricingCheck, Message: "no pricing for node key synthetic"},
+				{Check: PVPricingCheck, Message: "no pricing for persistent volume key pricing-validator"},
+				{Check: NetworkPricingCheck, Message: "no network pricing"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			tp := newValidTestProvider()
+			c.modify(tp)
+
+			errs := NewPricingValidator().Validate(tp)
+			if len(errs) != len(c.expected) {
+				t.Fatalf("expected %d errors; got %d: %v", len(c.expected), len(errs), errs)
+			}
+			for i := range errs {
+				if errs[i] != c.expected[i] {
+					t.Errorf("expected error %q; got %q", c.expected[i], errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestPricingValidator_Node(t *testing.T) {
+	tp := newValidTestProvider()
+
+	var priced cloud.Key
+	validator := NewPricingValidator()
+	validator.Node = &v1.Node{}
+	validator.Node.Labels = map[string]string{v1.LabelInstanceType: "m5.large"}
+
+	keyed := &keyRecordingProvider{testProvider: tp, key: &priced}
+	if errs := validator.Validate(keyed); len(errs) != 0 {
+		t.Fatalf("expected no errors; got %v", errs)
+	}
+	if priced == nil || priced.ID() != "m5.large" {
+		t.Fatalf("expected the given node to be priced; got %v", priced)
+	}
+}
+
+// keyRecordingProvider records the key of the node it prices
+type keyRecordingProvider struct {
+	*testProvider
+	key *cloud.Key
+}
+
+func (krp *keyRecordingProvider) NodePricing(key cloud.Key) (*cloud.Node, error) {
+	*krp.key = key
+	return krp.testProvider.NodePricing(key)
+}
+
+func TestValidatePrice(t *testing.T) {
+	cases := []struct {
+		price string
+		valid bool
+	}{
+		{"", true},
+		{"0", true},
+		{"0.031611", true},
+		{"-0.5", false},
+		{"$1", false},
+	}
+
+	for _, c := range cases {
+		if err := validatePrice(c.price); (err == nil) != c.valid {
+			t.Errorf("expected price %q valid: %t; got error %v", c.price, c.valid, err)
+		}
+	}
+}
@@ -21,6 +21,10 @@ import (
 	v1 "k8s.io/api/core/v1"
 )
 
+// cloudLog writes the logs of node and volume pricing, whose level can be set
+// with LOG_LEVEL_CLOUD
+var cloudLog = log.WithModule("cloud")
+
 const authSecretPath = "/var/secrets/service-key.json"
 const storageConfigSecretPath = "/var/azure-storage-config/azure-storage-config.json"
 const defaultShareTenancyCost = "true"
@@ -95,6 +99,10 @@ type Network struct {
 	ZoneNetworkEgressCost     float64
 	RegionNetworkEgressCost   float64
 	InternetNetworkEgressCost float64
+	// InternetNetworkEgressTiers, if set, prices internet egress in tiers of
+	// usage rather than at InternetNetworkEgressCost, as parsed by
+	// pricing.ParseTieredPricing, e.g. "100:0,10240:0.09,0:0.085"
+	InternetNetworkEgressTiers string
 }
 
 // PV is the interface by which the provider and cost model communicate PV prices.
@@ -132,56 +140,133 @@ type OutOfClusterAllocation struct {
 }
 
 type CustomPricing struct {
-	Provider                     string `json:"provider"`
-	Description                  string `json:"description"`
-	CPU                          string `json:"CPU"`
-	SpotCPU                      string `json:"spotCPU"`
-	RAM                          string `json:"RAM"`
-	SpotRAM                      string `json:"spotRAM"`
-	GPU                          string `json:"GPU"`
-	SpotGPU                      string `json:"spotGPU"`
-	Storage                      string `json:"storage"`
-	ZoneNetworkEgress            string `json:"zoneNetworkEgress"`
-	RegionNetworkEgress          string `json:"regionNetworkEgress"`
-	InternetNetworkEgress        string `json:"internetNetworkEgress"`
-	FirstFiveForwardingRulesCost string `json:"firstFiveForwardingRulesCost"`
-	AdditionalForwardingRuleCost string `json:"additionalForwardingRuleCost"`
-	LBIngressDataCost            string `json:"LBIngressDataCost"`
-	SpotLabel                    string `json:"spotLabel,omitempty"`
-	SpotLabelValue               string `json:"spotLabelValue,omitempty"`
-	GpuLabel                     string `json:"gpuLabel,omitempty"`
-	GpuLabelValue                string `json:"gpuLabelValue,omitempty"`
-	ServiceKeyName               string `json:"awsServiceKeyName,omitempty"`
-	ServiceKeySecret             string `json:"awsServiceKeySecret,omitempty"`
-	SpotDataRegion               string `json:"awsSpotDataRegion,omitempty"`
-	SpotDataBucket               string `json:"awsSpotDataBucket,omitempty"`
-	SpotDataPrefix               string `json:"awsSpotDataPrefix,omitempty"`
-	ProjectID                    string `json:"projectID,omitempty"`
-	AthenaProjectID              string `json:"athenaProjectID,omitempty"`
-	AthenaBucketName             string `json:"athenaBucketName"`
-	AthenaRegion                 string `json:"athenaRegion"`
-	AthenaDatabase               string `json:"athenaDatabase"`
-	AthenaTable                  string `json:"athenaTable"`
-	MasterPayerARN               string `json:"masterPayerARN"`
-	BillingDataDataset           string `json:"billingDataDataset,omitempty"`
-	CustomPricesEnabled          string `json:"customPricesEnabled"`
-	DefaultIdle                  string `json:"defaultIdle"`
-	AzureSubscriptionID          string `json:"azureSubscriptionID"`
-	AzureClientID                string `json:"azureClientID"`
-	AzureClientSecret            string `json:"azureClientSecret"`
-	AzureTenantID                string `json:"azureTenantID"`
-	AzureBillingRegion           string `json:"azureBillingRegion"`
-	CurrencyCode                 string `json:"currencyCode"`
-	Discount                     string `json:"discount"`
-	NegotiatedDiscount           string `json:"negotiatedDiscount"`
-	SharedOverhead               string `json:"sharedOverhead"`
-	ClusterName                  string `json:"clusterName"`
-	SharedNamespaces             string `json:"sharedNamespaces"`
-	SharedLabelNames             string `json:"sharedLabelNames"`
-	SharedLabelValues            string `json:"sharedLabelValues"`
-	ShareTenancyCosts            string `json:"shareTenancyCosts"` // TODO clean up configuration so we can use a type other that string (this should be a bool, but the app panics if it's not a string)
-	ReadOnly                     string `json:"readOnly"`
-	KubecostToken                string `json:"kubecostToken"`
+	Provider                      string `json:"provider"`
+	Description                   string `json:"description"`
+	CPU                           string `json:"CPU"`
+	SpotCPU                       string `json:"spotCPU"`
+	RAM                           string `json:"RAM"`
+	SpotRAM                       string `json:"spotRAM"`
+	GPU                           string `json:"GPU"`
+	SpotGPU                       string `json:"spotGPU"`
+	Storage                       string `json:"storage"`
+	ZoneNetworkEgress             string `json:"zoneNetworkEgress"`
+	RegionNetworkEgress           string `json:"regionNetworkEgress"`
+	InternetNetworkEgress         string `json:"internetNetworkEgress"`
+	InternetNetworkEgressTiers    string `json:"internetNetworkEgressTiers,omitempty"` // e.g. "100:0,10240:0.09,0:0.085", GB up to which each price per GB applies, 0 being unbounded
+	FirstFiveForwardingRulesCost  string `json:"firstFiveForwardingRulesCost"`
+	AdditionalForwardingRuleCost  string `json:"additionalForwardingRuleCost"`
+	LBIngressDataCost             string `json:"LBIngressDataCost"`
+	SpotLabel                     string `json:"spotLabel,omitempty"`
+	SpotLabelValue                string `json:"spotLabelValue,omitempty"`
+	GpuLabel                      string `json:"gpuLabel,omitempty"`
+	GpuLabelValue                 string `json:"gpuLabelValue,omitempty"`
+	ServiceKeyName                string `json:"awsServiceKeyName,omitempty"`
+	ServiceKeySecret              string `json:"awsServiceKeySecret,omitempty"`
+	SpotDataRegion                string `json:"awsSpotDataRegion,omitempty"`
+	SpotDataBucket                string `json:"awsSpotDataBucket,omitempty"`
+	SpotDataPrefix                string `json:"awsSpotDataPrefix,omitempty"`
+	ProjectID                     string `json:"projectID,omitempty"`
+	AthenaProjectID               string `json:"athenaProjectID,omitempty"`
+	AthenaBucketName              string `json:"athenaBucketName"`
+	AthenaRegion                  string `json:"athenaRegion"`
+	AthenaDatabase                string `json:"athenaDatabase"`
+	AthenaTable                   string `json:"athenaTable"`
+	MasterPayerARN                string `json:"masterPayerARN"`
+	BillingDataDataset            string `json:"billingDataDataset,omitempty"`
+	CustomPricesEnabled           string `json:"customPricesEnabled"`
+	DefaultIdle                   string `json:"defaultIdle"`
+	AzureSubscriptionID           string `json:"azureSubscriptionID"`
+	AzureClientID                 string `json:"azureClientID"`
+	AzureClientSecret             string `json:"azureClientSecret"`
+	AzureTenantID                 string `json:"azureTenantID"`
+	AzureBillingRegion            string `json:"azureBillingRegion"`
+	CurrencyCode                  string `json:"currencyCode"`
+	CurrencyRates                 string `json:"currencyRates,omitempty"`                 // e.g. "EUR:0.92,GBP:0.79", units of each currency per unit of CurrencyCode
+	CurrencyRatesURL              string `json:"currencyRatesURL,omitempty"`              // refreshes CurrencyRates from {"base": "USD", "rates": {"EUR": 0.92}}
+	ExternalCostsCurrencyCode     string `json:"externalCostsCurrencyCode,omitempty"`     // currency of out-of-cluster costs, if not CurrencyCode
+	ManagementPricePerNodePerHour string `json:"managementPricePerNodePerHour,omitempty"` // distro licensing fee, e.g. Rancher, OpenShift, or Tanzu
+	ManagementPricingLabel        string `json:"managementPricingLabel,omitempty"`
+	ExternalPricingURL            string `json:"externalPricingURL,omitempty"`    // node prices by key features, e.g. {"default": {"CPU": "0.03", "RAM": "0.004"}}
+	PricingCachePath              string `json:"pricingCachePath,omitempty"`      // caches the prices loaded from ExternalPricingURL, in case it becomes unavailable
+	PricingFilePath               string `json:"pricingFilePath,omitempty"`       // node prices by key features, as for ExternalPricingURL, read from a local file, which is gzip compressed if it ends in .gz, and reloaded when it changes
+	NetworkBillingModel           string `json:"networkBillingModel,omitempty"`   // "per_gb", the default, or "per_hour", in which ZoneNetworkEgress is the hourly rate of the link
+	NetworkBandwidthGbps          string `json:"networkBandwidthGbps,omitempty"`  // capacity of the link billed per hour
+	BlendedPricingEnabled         string `json:"blendedPricingEnabled,omitempty"` // "true" to price all nodes at a single blended rate of the on-demand and spot prices
+	OnDemandFraction              string `json:"onDemandFraction,omitempty"`      // fraction of capacity which is on-demand, e.g. "0.25"; defaults to the fraction of nodes without the spot label
+	Discount                      string `json:"discount"`
+	NegotiatedDiscount            string `json:"negotiatedDiscount"`
+	SharedOverhead                string `json:"sharedOverhead"`
+	ClusterName                   string `json:"clusterName"`
+	SharedNamespaces              string `json:"sharedNamespaces"`
+	SharedLabelNames              string `json:"sharedLabelNames"`
+	SharedLabelValues             string `json:"sharedLabelValues"`
+	ShareTenancyCosts             string `json:"shareTenancyCosts"` // TODO clean up configuration so we can use a type other that string (this should be a bool, but the app panics if it's not a string)
+	ReadOnly                      string `json:"readOnly"`
+	KubecostToken                 string `json:"kubecostToken"`
+
+	TimeOfDayPricing         []TimeOfDayPrice `json:"timeOfDayPricing,omitempty"`         // CPU price multipliers by hour of the day, e.g. for peak electricity rates on-prem
+	TimeOfDayPricingTimezone string           `json:"timeOfDayPricingTimezone,omitempty"` // IANA timezone of the hours of TimeOfDayPricing, e.g. "America/New_York"; defaults to UTC
+
+	ExternalPricingRefreshInterval JSONDuration `json:"externalPricingRefreshInterval,omitempty"` // how often prices are reloaded from ExternalPricingURL, e.g. "6h"; if unset, they are only loaded with the pricing data
+}
+
+// JSONDuration is a time.Duration which is unmarshalled from JSON either as an
+// integer number of nanoseconds, as time.Duration is, or as a string such as
+// "1h30m". It is marshalled as a string.
+type JSONDuration time.Duration
+
+// ParseJSONDuration parses a duration string, e.g. "1h30m", or an integer
+// number of nanoseconds. An empty string is zero.
+func ParseJSONDuration(s string) (JSONDuration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+
+	if ns, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return JSONDuration(ns), nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration '%s': expected nanoseconds or a duration such as 1h30m", s)
+	}
+	return JSONDuration(d), nil
+}
+
+// Duration returns the duration as a time.Duration
+func (d JSONDuration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+// MarshalJSON marshals the duration as a string, e.g. "1h30m0s"
+func (d JSONDuration) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.Duration().String())), nil
+}
+
+// UnmarshalJSON unmarshals the duration from an integer number of nanoseconds
+// or a string parsed by ParseJSONDuration. null leaves the duration unchanged.
+func (d *JSONDuration) UnmarshalJSON(data []byte) error {
+	s := strings.TrimSpace(string(data))
+	if s == "null" {
+		return nil
+	}
+
+	if strings.HasPrefix(s, `"`) {
+		unquoted, err := strconv.Unquote(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %s: %s", s, err)
+		}
+		s = unquoted
+	}
+
+	parsed, err := ParseJSONDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+	return nil
 }
 
 // GetSharedOverheadCostPerMonth parses and returns a float64 representation
@@ -221,6 +306,12 @@ type PricingSource struct {
 	Name      string `json:"name"`
 	Available bool   `json:"available"`
 	Error     string `json:"error"`
+	// Mode is StaticPricingMode if prices are loaded from a static price
+	// file, in which case Path is the file and Age is the time since it
+	// was modified
+	Mode string `json:"mode,omitempty"`
+	Path string `json:"path,omitempty"`
+	Age  string `json:"age,omitempty"`
 }
 
 type PricingType string
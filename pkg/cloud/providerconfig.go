@@ -2,13 +2,13 @@ package cloud
 
 import (
 	"fmt"
-	"io/ioutil"
 	"reflect"
 	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/util/atomicfile"
 	"github.com/kubecost/cost-model/pkg/util/fileutil"
 	"github.com/kubecost/cost-model/pkg/util/json"
 	"github.com/microcosm-cc/bluemonday"
@@ -58,12 +58,7 @@ func (pc *ProviderConfig) loadConfig(writeIfNotExists bool) (*CustomPricing, err
 
 		// Only write the file if flag enabled
 		if writeIfNotExists {
-			cj, err := json.Marshal(pc.customPricing)
-			if err != nil {
-				return pc.customPricing, err
-			}
-
-			err = ioutil.WriteFile(pc.configPath, cj, 0644)
+			err = atomicfile.WriteJSON(pc.configPath, pc.customPricing, 0644)
 			if err != nil {
 				klog.Infof("Could not write Custom Pricing file to path '%s'", pc.configPath)
 				return pc.customPricing, err
@@ -73,21 +68,16 @@ func (pc *ProviderConfig) loadConfig(writeIfNotExists bool) (*CustomPricing, err
 		return pc.customPricing, nil
 	}
 
-	// File Exists - Read all contents of file, unmarshal json
-	byteValue, err := ioutil.ReadFile(pc.configPath)
+	// File Exists - Read all contents of file, unmarshal json, falling back to the
+	// previous version if the file is corrupt
+	var customPricing CustomPricing
+	err = atomicfile.ReadJSON(pc.configPath, &customPricing)
 	if err != nil {
 		klog.Infof("Could not read Custom Pricing file at path %s", pc.configPath)
 		// If read fails, we don't want to cache default, assuming that the file is valid
 		return DefaultPricing(), err
 	}
 
-	var customPricing CustomPricing
-	err = json.Unmarshal(byteValue, &customPricing)
-	if err != nil {
-		klog.Infof("Could not decode Custom Pricing file at path %s", pc.configPath)
-		return DefaultPricing(), err
-	}
-
 	pc.customPricing = &customPricing
 	if pc.customPricing.SpotGPU == "" {
 		pc.customPricing.SpotGPU = DefaultPricing().SpotGPU // Migration for users without this value set by default.
@@ -128,12 +118,7 @@ func (pc *ProviderConfig) Update(updateFunc func(*CustomPricing) error) (*Custom
 	// Cache Update (possible the ptr already references the cached value)
 	pc.customPricing = c
 
-	cj, err := json.Marshal(c)
-	if err != nil {
-		return c, err
-	}
-	err = ioutil.WriteFile(pc.configPath, cj, 0644)
-
+	err = atomicfile.WriteJSON(pc.configPath, c, 0644)
 	if err != nil {
 		return c, err
 	}
@@ -200,8 +185,31 @@ func SetCustomPricingField(obj *CustomPricing, name string, value string) error
 	}
 
 	structFieldType := structFieldValue.Type()
-	value = sanitizePolicy.Sanitize(value)
-	val := reflect.ValueOf(value)
+
+	// Durations are set from their string form, e.g. "1h30m"
+	if structFieldType == reflect.TypeOf(JSONDuration(0)) {
+		d, err := ParseJSONDuration(sanitizePolicy.Sanitize(value))
+		if err != nil {
+			return err
+		}
+		structFieldValue.Set(reflect.ValueOf(d))
+		return nil
+	}
+
+	// Other fields which are not strings, e.g. time of day prices, are set from
+	// their JSON form, and cleared by an empty value
+	if structFieldType.Kind() != reflect.String {
+		fieldValue := reflect.New(structFieldType)
+		if value != "" {
+			if err := json.Unmarshal([]byte(value), fieldValue.Interface()); err != nil {
+				return fmt.Errorf("Provided value for %s is not valid: %s", name, err)
+			}
+		}
+		structFieldValue.Set(fieldValue.Elem())
+		return nil
+	}
+
+	val := reflect.ValueOf(sanitizePolicy.Sanitize(value))
 	if structFieldType != val.Type() {
 		return fmt.Errorf("Provided value type didn't match custom pricing field type")
 	}
@@ -210,6 +218,33 @@ func SetCustomPricingField(obj *CustomPricing, name string, value string) error
 	return nil
 }
 
+// SetCustomPricingFieldValue sets the field to a value decoded from a JSON config
+// update: strings are set as they are, and the values of fields which are not
+// strings, e.g. time of day prices, are set from their JSON form, so that the
+// config returned by the API can be sent back unchanged.
+func SetCustomPricingFieldValue(obj *CustomPricing, name string, value interface{}) error {
+	if s, ok := value.(string); ok {
+		return SetCustomPricingField(obj, name, s)
+	}
+
+	field, ok := reflect.TypeOf(*obj).FieldByName(name)
+	if !ok || field.Type.Kind() == reflect.String || field.Type == reflect.TypeOf(JSONDuration(0)) {
+		return fmt.Errorf("type error while updating config for %s", name)
+	}
+
+	// A null value clears the field
+	data := []byte{}
+	if value != nil {
+		var err error
+		data, err = json.Marshal(value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return SetCustomPricingField(obj, name, string(data))
+}
+
 // File exists has three different return cases that should be handled:
 //   1. File exists and is not a directory (true, nil)
 //   2. File does not exist (false, nil)
@@ -0,0 +1,79 @@
+package cloud
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/env"
+)
+
+// StaticPricingSource is the pricing source of a provider in offline mode, in
+// which prices are loaded from a static price file rather than downloaded.
+const StaticPricingSource = "Static Pricing"
+
+// StaticPricingMode is the mode of the static pricing source
+const StaticPricingMode = "offline/static"
+
+// In offline mode, enabled by PRICING_OFFLINE_ENABLED, each provider loads its
+// prices from <provider>.json in STATIC_PRICING_PATH, e.g. a mounted ConfigMap
+// or a directory baked into the image, in the format of the provider's pricing
+// API, which may be trimmed to the regions and instance types in use:
+//
+//   - aws.json: an AWS Price List offer file, as downloaded from
+//     https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/AmazonEC2/current/<region>/index.json
+//   - gcp.json: a page of the Cloud Billing Catalog API's list of Compute Engine
+//     SKUs, {"skus": [...]}
+//   - azure.json: a RateCard API response, {"Meters": [...]}, with the regions
+//     of the subscription, by ID, {"regions": {"eastus": "East US"}}
+//
+// Downloads which supplement these prices, e.g. spot data feeds and reserved
+// instances, are disabled in offline mode.
+const (
+	awsStaticPricingFile   = "aws.json"
+	gcpStaticPricingFile   = "gcp.json"
+	azureStaticPricingFile = "azure.json"
+)
+
+// staticPricingPath returns the path of the static price file of the given name
+func staticPricingPath(file string) string {
+	return filepath.Join(env.GetStaticPricingPath(), file)
+}
+
+// openStaticPricing opens the static price file of the given name, returning
+// the file and its path
+func openStaticPricing(file string) (io.ReadCloser, string, error) {
+	path := staticPricingPath(file)
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, path, fmt.Errorf("failed to open static pricing file: %s", err)
+	}
+
+	return f, path, nil
+}
+
+// staticPricingStatus returns the status of the static price file of the given
+// name, which is available if the file exists, with the file's age.
+func staticPricingStatus(file string) *PricingSource {
+	path := staticPricingPath(file)
+
+	source := &PricingSource{
+		Name: StaticPricingSource,
+		Mode: StaticPricingMode,
+		Path: path,
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		source.Error = err.Error()
+		return source
+	}
+
+	source.Available = true
+	source.Age = time.Since(info.ModTime()).Round(time.Second).String()
+
+	return source
+}
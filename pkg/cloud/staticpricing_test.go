@@ -0,0 +1,100 @@
+package cloud
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/kubecost/cost-model/pkg/clustercache"
+	"github.com/kubecost/cost-model/pkg/env"
+
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// staticPricingClusterCache is a cluster cache of the given nodes
+type staticPricingClusterCache struct {
+	clustercache.ClusterCache
+	nodes []*v1.Node
+}
+
+func (c *staticPricingClusterCache) GetAllNodes() []*v1.Node {
+	return c.nodes
+}
+
+func (c *staticPricingClusterCache) GetAllPersistentVolumes() []*v1.PersistentVolume {
+	return nil
+}
+
+func (c *staticPricingClusterCache) GetAllStorageClasses() []*storagev1.StorageClass {
+	return nil
+}
+
+func TestAWSStaticPricing(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "static-pricing")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(configDir)
+
+	os.Setenv(env.ConfigPathEnvVar, configDir+"/")
+	os.Setenv(env.PricingOfflineEnabledEnvVar, "true")
+	os.Setenv(env.StaticPricingPathEnvVar, "testdata/static-pricing")
+	defer os.Unsetenv(env.ConfigPathEnvVar)
+	defer os.Unsetenv(env.PricingOfflineEnabledEnvVar)
+	defer os.Unsetenv(env.StaticPricingPathEnvVar)
+
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node-1",
+			Labels: map[string]string{
+				v1.LabelTopologyRegion: "us-east-1",
+				v1.LabelInstanceType:   "m5.large",
+				v1.LabelOSStable:       "linux",
+			},
+		},
+	}
+
+	aws := &AWS{
+		Clientset: &staticPricingClusterCache{nodes: []*v1.Node{node}},
+		Config:    NewProviderConfig("aws.json"),
+	}
+
+	err = aws.DownloadPricingData()
+	if err != nil {
+		t.Fatalf("unexpected error loading static pricing: %s", err)
+	}
+
+	n, err := aws.NodePricing(aws.GetKey(node.Labels, node))
+	if err != nil {
+		t.Fatalf("unexpected error pricing node: %s", err)
+	}
+	if n.Cost != "0.0960000000" {
+		t.Errorf("expected static on-demand price 0.0960000000; got %s", n.Cost)
+	}
+	if n.VCPU != "2" || n.RAM != "8 GiB" {
+		t.Errorf("expected 2 vCPU and 8 GiB; got %s and %s", n.VCPU, n.RAM)
+	}
+
+	sources := aws.PricingSourceStatus()
+	static, ok := sources[StaticPricingSource]
+	if !ok {
+		t.Fatalf("expected %s source; got %v", StaticPricingSource, sources)
+	}
+	if !static.Available || static.Mode != StaticPricingMode || static.Age == "" {
+		t.Errorf("expected available static source with an age; got %+v", static)
+	}
+	if spot := sources[SpotPricingSource]; spot.Available {
+		t.Errorf("expected spot data feed to be unavailable offline")
+	}
+
+	// A missing static price file is an error, rather than a download
+	os.Setenv(env.StaticPricingPathEnvVar, configDir)
+	if err := aws.DownloadPricingData(); err == nil {
+		t.Errorf("expected missing static pricing file to be an error")
+	}
+	if static := aws.PricingSourceStatus()[StaticPricingSource]; static.Available || static.Error == "" {
+		t.Errorf("expected missing static source to be unavailable; got %+v", static)
+	}
+}
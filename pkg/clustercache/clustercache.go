@@ -10,6 +10,8 @@ import (
 	autoscaling "k8s.io/api/autoscaling/v2beta1"
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	stv1 "k8s.io/api/storage/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/client-go/kubernetes"
@@ -67,8 +69,19 @@ type ClusterCache interface {
 	// GetAllHorizontalPodAutoscalers() returns all cached horizontal pod autoscalers
 	GetAllHorizontalPodAutoscalers() []*autoscaling.HorizontalPodAutoscaler
 
+	// GetAllPodDisruptionBudgets returns all cached pod disruption budgets. Pod
+	// disruption budgets are only watched if the API server serves policy/v1beta1.
+	GetAllPodDisruptionBudgets() []*policyv1beta1.PodDisruptionBudget
+
+	// GetAllIngresses returns all cached ingresses. Ingresses are only watched
+	// when ingress metrics are enabled at startup.
+	GetAllIngresses() []*networkingv1.Ingress
+
 	// SetConfigMapUpdateFunc sets the configmap update function
 	SetConfigMapUpdateFunc(func(interface{}))
+
+	// HasSynced returns true once every watched resource has been cached
+	HasSynced() bool
 }
 
 // KubernetesClusterCache is the implementation of ClusterCache
@@ -89,6 +102,8 @@ type KubernetesClusterCache struct {
 	storageClassWatch      WatchController
 	jobsWatch              WatchController
 	hpaWatch               WatchController
+	pdbWatch               WatchController
+	ingressWatch           WatchController
 	stop                   chan struct{}
 }
 
@@ -97,12 +112,32 @@ func initializeCache(wc WatchController, wg *sync.WaitGroup, cancel chan struct{
 	wc.WarmUp(cancel)
 }
 
+// isResourceServed returns true if the API server serves the resource in the
+// given group version
+func isResourceServed(client kubernetes.Interface, groupVersion, resource string) bool {
+	resources, err := client.Discovery().ServerResourcesForGroupVersion(groupVersion)
+	if err != nil {
+		klog.V(3).Infof("Failed to discover resources for %s: %s", groupVersion, err)
+		return false
+	}
+
+	for _, r := range resources.APIResources {
+		if r.Name == resource {
+			return true
+		}
+	}
+
+	return false
+}
+
 func NewKubernetesClusterCache(client kubernetes.Interface) ClusterCache {
 	coreRestClient := client.CoreV1().RESTClient()
 	appsRestClient := client.AppsV1().RESTClient()
 	storageRestClient := client.StorageV1().RESTClient()
 	batchClient := client.BatchV1().RESTClient()
 	autoscalingClient := client.AutoscalingV2beta1().RESTClient()
+	policyClient := client.PolicyV1beta1().RESTClient()
+	networkingClient := client.NetworkingV1().RESTClient()
 
 	kubecostNamespace := env.GetKubecostNamespace()
 	klog.Infof("NAMESPACE: %s", kubecostNamespace)
@@ -125,6 +160,20 @@ func NewKubernetesClusterCache(client kubernetes.Interface) ClusterCache {
 		hpaWatch:               NewCachingWatcher(autoscalingClient, "horizontalpodautoscalers", &autoscaling.HorizontalPodAutoscaler{}, "", fields.Everything()),
 	}
 
+	// policy/v1beta1 was removed in Kubernetes 1.25, so only watch pod
+	// disruption budgets where it is still served
+	if isResourceServed(client, "policy/v1beta1", "poddisruptionbudgets") {
+		kcc.pdbWatch = NewCachingWatcher(policyClient, "poddisruptionbudgets", &policyv1beta1.PodDisruptionBudget{}, "", fields.Everything())
+	} else {
+		klog.Infof("policy/v1beta1 poddisruptionbudgets are not served; pod disruption budgets will not be cached")
+	}
+
+	// Ingresses are only used by the ingress metrics, so avoid watching them
+	// cluster-wide unless those metrics are enabled
+	if env.IsEmitIngressMetrics() {
+		kcc.ingressWatch = NewCachingWatcher(networkingClient, "ingresses", &networkingv1.Ingress{}, "", fields.Everything())
+	}
+
 	// Wait for each caching watcher to initialize
 	var wg sync.WaitGroup
 	wg.Add(14)
@@ -145,6 +194,15 @@ func NewKubernetesClusterCache(client kubernetes.Interface) ClusterCache {
 	go initializeCache(kcc.storageClassWatch, &wg, cancel)
 	go initializeCache(kcc.jobsWatch, &wg, cancel)
 	go initializeCache(kcc.hpaWatch, &wg, cancel)
+	if kcc.ingressWatch != nil {
+		wg.Add(1)
+		go initializeCache(kcc.ingressWatch, &wg, cancel)
+	}
+
+	// Pod disruption budgets are optional, so don't block startup on them
+	if kcc.pdbWatch != nil {
+		go kcc.pdbWatch.WarmUp(cancel)
+	}
 
 	wg.Wait()
 
@@ -171,6 +229,12 @@ func (kcc *KubernetesClusterCache) Run() {
 	go kcc.storageClassWatch.Run(1, stopCh)
 	go kcc.jobsWatch.Run(1, stopCh)
 	go kcc.hpaWatch.Run(1, stopCh)
+	if kcc.pdbWatch != nil {
+		go kcc.pdbWatch.Run(1, stopCh)
+	}
+	if kcc.ingressWatch != nil {
+		go kcc.ingressWatch.Run(1, stopCh)
+	}
 
 	kcc.stop = stopCh
 }
@@ -184,6 +248,41 @@ func (kcc *KubernetesClusterCache) Stop() {
 	kcc.stop = nil
 }
 
+// HasSynced returns true once every watched resource has been cached. Caches
+// which failed to sync while warming up continue to sync in the background.
+func (kcc *KubernetesClusterCache) HasSynced() bool {
+	watches := []WatchController{
+		kcc.namespaceWatch,
+		kcc.nodeWatch,
+		kcc.podWatch,
+		kcc.kubecostConfigMapWatch,
+		kcc.serviceWatch,
+		kcc.daemonsetsWatch,
+		kcc.deploymentsWatch,
+		kcc.statefulsetWatch,
+		kcc.replicasetWatch,
+		kcc.pvWatch,
+		kcc.pvcWatch,
+		kcc.storageClassWatch,
+		kcc.jobsWatch,
+		kcc.hpaWatch,
+	}
+	if kcc.pdbWatch != nil {
+		watches = append(watches, kcc.pdbWatch)
+	}
+	if kcc.ingressWatch != nil {
+		watches = append(watches, kcc.ingressWatch)
+	}
+
+	for _, watch := range watches {
+		if !watch.HasSynced() {
+			return false
+		}
+	}
+
+	return true
+}
+
 func (kcc *KubernetesClusterCache) GetClient() kubernetes.Interface {
 	return kcc.client
 }
@@ -305,6 +404,30 @@ func (kcc *KubernetesClusterCache) GetAllHorizontalPodAutoscalers() []*autoscali
 	return hpas
 }
 
+func (kcc *KubernetesClusterCache) GetAllPodDisruptionBudgets() []*policyv1beta1.PodDisruptionBudget {
+	var pdbs []*policyv1beta1.PodDisruptionBudget
+	if kcc.pdbWatch == nil {
+		return pdbs
+	}
+	items := kcc.pdbWatch.GetAll()
+	for _, pdb := range items {
+		pdbs = append(pdbs, pdb.(*policyv1beta1.PodDisruptionBudget))
+	}
+	return pdbs
+}
+
+func (kcc *KubernetesClusterCache) GetAllIngresses() []*networkingv1.Ingress {
+	var ingresses []*networkingv1.Ingress
+	if kcc.ingressWatch == nil {
+		return ingresses
+	}
+	items := kcc.ingressWatch.GetAll()
+	for _, ingress := range items {
+		ingresses = append(ingresses, ingress.(*networkingv1.Ingress))
+	}
+	return ingresses
+}
+
 func (kcc *KubernetesClusterCache) SetConfigMapUpdateFunc(f func(interface{})) {
 	kcc.kubecostConfigMapWatch.SetUpdateHandler(f)
 }
@@ -31,6 +31,9 @@ type WatchController interface {
 	// GetAll returns all of the resources
 	GetAll() []interface{}
 
+	// HasSynced returns true once the initial listing of resources has been cached
+	HasSynced() bool
+
 	// SetUpdateHandler sets a specific handler for adding/updating individual resources
 	SetUpdateHandler(WatchHandler) WatchController
 
@@ -187,6 +190,11 @@ func (c *CachingWatchController) WarmUp(cancelCh chan struct{}) {
 	}
 }
 
+// HasSynced returns true once the initial listing of resources has been cached
+func (c *CachingWatchController) HasSynced() bool {
+	return c.informer.HasSynced()
+}
+
 func (c *CachingWatchController) Run(threadiness int, stopCh chan struct{}) {
 	defer runtime.HandleCrash()
 
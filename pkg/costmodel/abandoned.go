@@ -0,0 +1,323 @@
+package costmodel
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/prom"
+	"github.com/kubecost/cost-model/pkg/util/httputil"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+)
+
+const (
+	// DefaultAbandonedCPUCoreUsageThreshold is the average CPU usage, in cores,
+	// below which a controller is considered idle.
+	DefaultAbandonedCPUCoreUsageThreshold = 0.005
+
+	// DefaultAbandonedNetworkReceiveThreshold is the average network receive
+	// rate, in bytes per second, below which a controller is considered to be
+	// serving no traffic.
+	DefaultAbandonedNetworkReceiveThreshold = 500.0
+
+	// DefaultAbandonedCPUCoreRequestFloor is the minimum average CPU request,
+	// in cores, for a controller to be worth flagging.
+	DefaultAbandonedCPUCoreRequestFloor = 0.01
+
+	// DefaultAbandonedRAMBytesRequestFloor is the minimum average RAM request,
+	// in bytes, for a controller to be worth flagging.
+	DefaultAbandonedRAMBytesRequestFloor = 64.0 * 1024.0 * 1024.0
+)
+
+// AbandonedWorkloadOptions configures the thresholds and exclusions used to
+// detect abandoned workloads.
+type AbandonedWorkloadOptions struct {
+	CPUCoreUsageThreshold   float64
+	NetworkReceiveThreshold float64
+	CPUCoreRequestFloor     float64
+	RAMBytesRequestFloor    float64
+	ExcludedNamespaces      []string
+	ExcludedLabels          map[string]string
+}
+
+// DefaultAbandonedWorkloadOptions returns AbandonedWorkloadOptions with
+// default values set.
+func DefaultAbandonedWorkloadOptions() *AbandonedWorkloadOptions {
+	return &AbandonedWorkloadOptions{
+		CPUCoreUsageThreshold:   DefaultAbandonedCPUCoreUsageThreshold,
+		NetworkReceiveThreshold: DefaultAbandonedNetworkReceiveThreshold,
+		CPUCoreRequestFloor:     DefaultAbandonedCPUCoreRequestFloor,
+		RAMBytesRequestFloor:    DefaultAbandonedRAMBytesRequestFloor,
+		ExcludedNamespaces:      []string{},
+		ExcludedLabels:          map[string]string{},
+	}
+}
+
+// isExcluded returns true if the given properties match one of the excluded
+// namespaces or labels.
+func (opts *AbandonedWorkloadOptions) isExcluded(props *kubecost.AllocationProperties) bool {
+	for _, ns := range opts.ExcludedNamespaces {
+		if props.Namespace == ns {
+			return true
+		}
+	}
+
+	for name, value := range opts.ExcludedLabels {
+		if v, ok := props.Labels[prom.SanitizeLabelName(name)]; ok && v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AbandonedWorkload describes a controller which holds resource requests but
+// has shown near-zero CPU usage and network traffic over a window.
+type AbandonedWorkload struct {
+	Cluster                      string  `json:"cluster"`
+	Namespace                    string  `json:"namespace"`
+	ControllerKind               string  `json:"controllerKind"`
+	Controller                   string  `json:"controller"`
+	Pods                         int     `json:"pods"`
+	CPUCoreUsageAverage          float64 `json:"cpuCoreUsageAverage"`
+	CPUCoreRequestAverage        float64 `json:"cpuCoreRequestAverage"`
+	RAMBytesRequestAverage       float64 `json:"ramByteRequestAverage"`
+	NetworkReceiveBytesPerSecond float64 `json:"networkReceiveBytesPerSecond"`
+	TotalCost                    float64 `json:"totalCost"`
+	MonthlySavings               float64 `json:"monthlySavings"`
+}
+
+// abandonedWorkloadKey identifies a controller across clusters
+type abandonedWorkloadKey struct {
+	Cluster        string
+	Namespace      string
+	ControllerKind string
+	Controller     string
+}
+
+// abandonedWorkloadAccumulator sums the minute-weighted usage of the
+// allocations belonging to a single controller.
+type abandonedWorkloadAccumulator struct {
+	pods                map[string]bool
+	cpuCoreUsageMinutes float64
+	cpuCoreReqMinutes   float64
+	ramBytesReqMinutes  float64
+	networkRecvBytes    float64
+	totalCost           float64
+}
+
+// FindAbandonedWorkloads scans the given AllocationSet for controllers whose
+// average CPU usage and network receive rate fall below the configured
+// thresholds while their requests exceed the configured floors. Results are
+// sorted by descending estimated monthly savings.
+func FindAbandonedWorkloads(as *kubecost.AllocationSet, opts *AbandonedWorkloadOptions) []*AbandonedWorkload {
+	results := []*AbandonedWorkload{}
+
+	if as == nil || as.IsEmpty() {
+		return results
+	}
+
+	if opts == nil {
+		opts = DefaultAbandonedWorkloadOptions()
+	}
+
+	windowMins := as.End().Sub(as.Start()).Minutes()
+	if windowMins <= 0 {
+		return results
+	}
+
+	accs := map[abandonedWorkloadKey]*abandonedWorkloadAccumulator{}
+
+	as.Each(func(name string, alloc *kubecost.Allocation) {
+		if alloc.IsIdle() || alloc.IsUnmounted() || alloc.IsExternal() || alloc.IsUnallocated() {
+			return
+		}
+
+		props := alloc.Properties
+		if props == nil || props.Controller == "" {
+			return
+		}
+
+		if opts.isExcluded(props) {
+			return
+		}
+
+		key := abandonedWorkloadKey{
+			Cluster:        props.Cluster,
+			Namespace:      props.Namespace,
+			ControllerKind: props.ControllerKind,
+			Controller:     props.Controller,
+		}
+
+		acc, ok := accs[key]
+		if !ok {
+			acc = &abandonedWorkloadAccumulator{pods: map[string]bool{}}
+			accs[key] = acc
+		}
+
+		mins := alloc.Minutes()
+		acc.pods[props.Pod] = true
+		acc.cpuCoreUsageMinutes += alloc.CPUCoreUsageAverage * mins
+		acc.cpuCoreReqMinutes += alloc.CPUCoreRequestAverage * mins
+		acc.ramBytesReqMinutes += alloc.RAMBytesRequestAverage * mins
+		acc.networkRecvBytes += alloc.NetworkReceiveBytes
+		acc.totalCost += alloc.TotalCost()
+	})
+
+	windowHours := windowMins / timeutil.MinsPerHour
+
+	for key, acc := range accs {
+		cpuUsage := acc.cpuCoreUsageMinutes / windowMins
+		cpuReq := acc.cpuCoreReqMinutes / windowMins
+		ramReq := acc.ramBytesReqMinutes / windowMins
+		netRecvRate := acc.networkRecvBytes / (windowMins * timeutil.SecsPerMin)
+
+		if cpuUsage >= opts.CPUCoreUsageThreshold || netRecvRate >= opts.NetworkReceiveThreshold {
+			continue
+		}
+
+		if cpuReq < opts.CPUCoreRequestFloor && ramReq < opts.RAMBytesRequestFloor {
+			continue
+		}
+
+		results = append(results, &AbandonedWorkload{
+			Cluster:                      key.Cluster,
+			Namespace:                    key.Namespace,
+			ControllerKind:               key.ControllerKind,
+			Controller:                   key.Controller,
+			Pods:                         len(acc.pods),
+			CPUCoreUsageAverage:          cpuUsage,
+			CPUCoreRequestAverage:        cpuReq,
+			RAMBytesRequestAverage:       ramReq,
+			NetworkReceiveBytesPerSecond: netRecvRate,
+			TotalCost:                    acc.totalCost,
+			MonthlySavings:               acc.totalCost / windowHours * timeutil.HoursPerMonth,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].MonthlySavings == results[j].MonthlySavings {
+			return results[i].Controller < results[j].Controller
+		}
+		return results[i].MonthlySavings > results[j].MonthlySavings
+	})
+
+	return results
+}
+
+// AbandonedWorkloadsResponse is a single page of abandoned workloads along
+// with the totals across all pages.
+type AbandonedWorkloadsResponse struct {
+	Workloads           []*AbandonedWorkload `json:"workloads"`
+	Page                int                  `json:"page"`
+	PageSize            int                  `json:"pageSize"`
+	TotalCount          int                  `json:"totalCount"`
+	TotalMonthlySavings float64              `json:"totalMonthlySavings"`
+}
+
+// paginateAbandonedWorkloads returns the page of workloads at the given
+// zero-based page index.
+func paginateAbandonedWorkloads(workloads []*AbandonedWorkload, page, pageSize int) *AbandonedWorkloadsResponse {
+	resp := &AbandonedWorkloadsResponse{
+		Workloads:  []*AbandonedWorkload{},
+		Page:       page,
+		PageSize:   pageSize,
+		TotalCount: len(workloads),
+	}
+
+	for _, w := range workloads {
+		resp.TotalMonthlySavings += w.MonthlySavings
+	}
+
+	// Pages past the last are empty. The page is compared before multiplying,
+	// so that large pages cannot overflow.
+	if len(workloads) == 0 || page > (len(workloads)-1)/pageSize {
+		return resp
+	}
+
+	start := page * pageSize
+	end := len(workloads)
+	if pageSize < end-start {
+		end = start + pageSize
+	}
+	resp.Workloads = workloads[start:end]
+
+	return resp
+}
+
+// parseLabelSelectors parses a list of "key=value" pairs into a map, keyed by
+// sanitized label name; e.g. app.kubernetes.io/part-of=platform
+func parseLabelSelectors(selectors []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, selector := range selectors {
+		kv := strings.SplitN(selector, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return nil, fmt.Errorf("illegal label selector: %s", selector)
+		}
+		labels[prom.SanitizeLabelName(strings.TrimSpace(kv[0]))] = strings.TrimSpace(kv[1])
+	}
+	return labels, nil
+}
+
+// AbandonedWorkloadsHandler returns a paginated list of controllers which
+// appear to be abandoned, along with the estimated monthly savings of
+// removing each.
+func (a *Accesses) AbandonedWorkloadsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	qp := httputil.NewQueryParams(r.URL.Query())
+
+	conversion, err := a.currencyConversion(qp)
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'currency' parameter: %s", err)))
+		return
+	}
+
+	window, err := kubecost.ParseWindowWithOffset(qp.Get("window", "7d"), env.GetParsedUTCOffset())
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'window' parameter: %s", err)))
+		return
+	}
+	if window.IsOpen() || window.IsNegative() {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'window' parameter: %s", window)))
+		return
+	}
+
+	excludedLabels, err := parseLabelSelectors(qp.GetList("excludeLabels", ","))
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'excludeLabels' parameter: %s", err)))
+		return
+	}
+
+	opts := DefaultAbandonedWorkloadOptions()
+	opts.CPUCoreUsageThreshold = qp.GetFloat64("cpuThreshold", opts.CPUCoreUsageThreshold)
+	opts.NetworkReceiveThreshold = qp.GetFloat64("networkThreshold", opts.NetworkReceiveThreshold)
+	opts.CPUCoreRequestFloor = qp.GetFloat64("cpuRequestFloor", opts.CPUCoreRequestFloor)
+	opts.RAMBytesRequestFloor = qp.GetFloat64("ramRequestFloor", opts.RAMBytesRequestFloor)
+	opts.ExcludedNamespaces = qp.GetList("excludeNamespaces", ",")
+	opts.ExcludedLabels = excludedLabels
+
+	page := qp.GetInt("page", 0)
+	pageSize := qp.GetInt("pageSize", 25)
+	if page < 0 || pageSize <= 0 {
+		WriteError(w, BadRequest("'page' must be non-negative and 'pageSize' must be positive"))
+		return
+	}
+
+	resolution := qp.GetDuration("resolution", env.GetETLResolution())
+
+	as, err := a.Model.ComputeAllocationWithContext(r.Context(), *window.Start(), *window.End(), resolution)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	workloads := FindAbandonedWorkloads(as, opts)
+
+	writeWithCurrency(w, paginateAbandonedWorkloads(workloads, page, pageSize), conversion)
+}
@@ -0,0 +1,169 @@
+package costmodel
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/kubecost"
+)
+
+func newAbandonedTestAllocation(start, end time.Time, namespace, controller, pod string, cpuUsage, cpuReq, netRecvBytes, cost float64) *kubecost.Allocation {
+	return &kubecost.Allocation{
+		Name:  fmt.Sprintf("cluster1/%s/%s/container", namespace, pod),
+		Start: start,
+		End:   end,
+		Properties: &kubecost.AllocationProperties{
+			Cluster:        "cluster1",
+			Namespace:      namespace,
+			ControllerKind: "deployment",
+			Controller:     controller,
+			Pod:            pod,
+			Container:      "container",
+			Labels:         map[string]string{"app": controller},
+		},
+		CPUCoreUsageAverage:    cpuUsage,
+		CPUCoreRequestAverage:  cpuReq,
+		RAMBytesRequestAverage: 128.0 * 1024.0 * 1024.0,
+		NetworkReceiveBytes:    netRecvBytes,
+		CPUCost:                cost,
+	}
+}
+
+func TestFindAbandonedWorkloads(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	secs := end.Sub(start).Seconds()
+
+	opts := DefaultAbandonedWorkloadOptions()
+	opts.ExcludedNamespaces = []string{"kube-system"}
+	opts.ExcludedLabels = map[string]string{"app": "platform"}
+
+	// network receive bytes just below and just above threshold
+	quietNet := (opts.NetworkReceiveThreshold - 1.0) * secs
+	busyNet := (opts.NetworkReceiveThreshold + 1.0) * secs
+
+	as := kubecost.NewAllocationSet(start, end,
+		// idle cpu, idle network, two pods => flagged
+		newAbandonedTestAllocation(start, end, "ns1", "abandoned", "abandoned-1", 0.001, 0.5, quietNet/2, 1.0),
+		newAbandonedTestAllocation(start, end, "ns1", "abandoned", "abandoned-2", 0.001, 0.5, quietNet/2, 1.0),
+		// cpu just above threshold => not flagged
+		newAbandonedTestAllocation(start, end, "ns1", "busycpu", "busycpu-1", opts.CPUCoreUsageThreshold+0.001, 0.5, 0.0, 1.0),
+		// network just above threshold => not flagged
+		newAbandonedTestAllocation(start, end, "ns1", "busynet", "busynet-1", 0.0, 0.5, busyNet, 1.0),
+		// idle, but excluded by namespace
+		newAbandonedTestAllocation(start, end, "kube-system", "system", "system-1", 0.0, 0.5, 0.0, 1.0),
+		// idle, but excluded by label
+		newAbandonedTestAllocation(start, end, "ns2", "platform", "platform-1", 0.0, 0.5, 0.0, 1.0),
+		// idle, cheaper => flagged, sorted after "abandoned"
+		newAbandonedTestAllocation(start, end, "ns2", "cheap", "cheap-1", 0.0, 0.5, 0.0, 0.5),
+	)
+
+	// idle, but requests below both floors => not flagged
+	tiny := newAbandonedTestAllocation(start, end, "ns2", "tiny", "tiny-1", 0.0, opts.CPUCoreRequestFloor/2, 0.0, 1.0)
+	tiny.RAMBytesRequestAverage = opts.RAMBytesRequestFloor / 2
+	as.Set(tiny)
+
+	workloads := FindAbandonedWorkloads(as, opts)
+	if len(workloads) != 2 {
+		t.Fatalf("expected 2 abandoned workloads; got %d", len(workloads))
+	}
+
+	if workloads[0].Controller != "abandoned" {
+		t.Fatalf("expected first workload to be \"abandoned\"; got \"%s\"", workloads[0].Controller)
+	}
+	if workloads[0].Pods != 2 {
+		t.Fatalf("expected 2 pods; got %d", workloads[0].Pods)
+	}
+	if workloads[0].CPUCoreRequestAverage != 1.0 {
+		t.Fatalf("expected CPU request average of 1.0; got %f", workloads[0].CPUCoreRequestAverage)
+	}
+
+	// 2.0 cost per day => 2.0 / 24.0 * 730.0 per month
+	expSavings := 2.0 / 24.0 * 730.0
+	if math.Abs(workloads[0].MonthlySavings-expSavings) > 0.0001 {
+		t.Fatalf("expected monthly savings of %f; got %f", expSavings, workloads[0].MonthlySavings)
+	}
+
+	if workloads[1].Controller != "cheap" {
+		t.Fatalf("expected second workload to be \"cheap\"; got \"%s\"", workloads[1].Controller)
+	}
+
+	// nil and empty sets should produce empty results
+	if ws := FindAbandonedWorkloads(nil, opts); len(ws) != 0 {
+		t.Fatalf("expected no workloads for nil set; got %d", len(ws))
+	}
+	if ws := FindAbandonedWorkloads(kubecost.NewAllocationSet(start, end), opts); len(ws) != 0 {
+		t.Fatalf("expected no workloads for empty set; got %d", len(ws))
+	}
+}
+
+func TestParseLabelSelectors(t *testing.T) {
+	labels, err := parseLabelSelectors([]string{"app.kubernetes.io/part-of=platform", " team = a=b "})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(labels) != 2 || labels["app_kubernetes_io_part_of"] != "platform" || labels["team"] != "a=b" {
+		t.Fatalf("expected sanitized label names with values split on the first '='; got %v", labels)
+	}
+
+	opts := DefaultAbandonedWorkloadOptions()
+	opts.ExcludedLabels = labels
+	props := &kubecost.AllocationProperties{Labels: map[string]string{"app_kubernetes_io_part_of": "platform"}}
+	if !opts.isExcluded(props) {
+		t.Fatalf("expected the allocation to be excluded by its sanitized label")
+	}
+
+	for _, selector := range []string{"app:platform", "=platform"} {
+		if _, err := parseLabelSelectors([]string{selector}); err == nil {
+			t.Errorf("expected an error parsing %q", selector)
+		}
+	}
+}
+
+func TestPaginateAbandonedWorkloads(t *testing.T) {
+	workloads := []*AbandonedWorkload{}
+	for i := 0; i < 5; i++ {
+		workloads = append(workloads, &AbandonedWorkload{
+			Controller:     fmt.Sprintf("controller-%d", i),
+			MonthlySavings: 10.0,
+		})
+	}
+
+	resp := paginateAbandonedWorkloads(workloads, 1, 2)
+	if len(resp.Workloads) != 2 || resp.Workloads[0].Controller != "controller-2" {
+		t.Fatalf("unexpected page contents: %+v", resp.Workloads)
+	}
+	if resp.TotalCount != 5 || resp.TotalMonthlySavings != 50.0 {
+		t.Fatalf("unexpected totals: count=%d savings=%f", resp.TotalCount, resp.TotalMonthlySavings)
+	}
+
+	resp = paginateAbandonedWorkloads(workloads, 2, 2)
+	if len(resp.Workloads) != 1 {
+		t.Fatalf("expected partial last page of 1; got %d", len(resp.Workloads))
+	}
+
+	resp = paginateAbandonedWorkloads(workloads, 3, 2)
+	if len(resp.Workloads) != 0 {
+		t.Fatalf("expected empty page past end; got %d", len(resp.Workloads))
+	}
+
+	// Pages and page sizes whose products overflow do not panic
+	resp = paginateAbandonedWorkloads(workloads, 4611686018427387904, 4)
+	if len(resp.Workloads) != 0 {
+		t.Fatalf("expected empty page past end; got %d", len(resp.Workloads))
+	}
+	resp = paginateAbandonedWorkloads(workloads, 0, math.MaxInt)
+	if len(resp.Workloads) != 5 {
+		t.Fatalf("expected all workloads on the first page; got %d", len(resp.Workloads))
+	}
+	resp = paginateAbandonedWorkloads(workloads, math.MaxInt, math.MaxInt)
+	if len(resp.Workloads) != 0 {
+		t.Fatalf("expected empty page past end; got %d", len(resp.Workloads))
+	}
+	resp = paginateAbandonedWorkloads(nil, 0, 2)
+	if len(resp.Workloads) != 0 {
+		t.Fatalf("expected empty page without workloads; got %d", len(resp.Workloads))
+	}
+}
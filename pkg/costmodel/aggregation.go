@@ -15,6 +15,7 @@ import (
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/costmodel/report"
 	"github.com/kubecost/cost-model/pkg/env"
 	"github.com/kubecost/cost-model/pkg/errors"
 	"github.com/kubecost/cost-model/pkg/kubecost"
@@ -2125,6 +2126,69 @@ func ParseAggregationProperties(qp httputil.QueryParams, key string) ([]string,
 	return aggregateBy, nil
 }
 
+// withContainerBreakdown returns the given aggregation properties with the
+// container property added, so that each aggregated allocation is broken down
+// by container. Unaggregated allocations are already per-container, so an
+// empty aggregation is returned unchanged.
+func withContainerBreakdown(aggregateBy []string) []string {
+	if len(aggregateBy) == 0 {
+		return aggregateBy
+	}
+
+	for _, agg := range aggregateBy {
+		if agg == kubecost.AllocationContainerProp {
+			return aggregateBy
+		}
+	}
+
+	return append(aggregateBy, kubecost.AllocationContainerProp)
+}
+
+// writeCostReport accumulates the unaggregated allocations of the range into
+// a cost report, converted to the display currency, if any, and writes it in
+// the given format.
+func (a *Accesses) writeCostReport(w http.ResponseWriter, r *http.Request, asr *kubecost.AllocationSetRange, conversion *CurrencyConversion, format string) {
+	as, err := asr.Accumulate()
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+	if as == nil {
+		WriteError(w, InternalServerError("no allocations to report"))
+		return
+	}
+
+	if conversion != nil {
+		conversion.ConvertAllocationSet(as)
+	}
+
+	costReport, err := report.NewCostReport(as)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	// Stream the report, gzipped if accepted. A rendering error is reported with
+	// an error status if nothing has been sent yet, or else truncates the report.
+	w.Header().Set("Content-Type", report.ContentType(format))
+	rs := json.NewResponseStream(w, json.StreamOptions{Gzip: httputil.AcceptsGzip(r)})
+
+	err = report.Render(report.NewRenderer(), rs, format, costReport)
+	if err != nil {
+		err = fmt.Errorf("error rendering cost report: %s", err)
+		if !rs.Abort(err) {
+			log.Errorf("Truncated cost report: %s", err)
+			rs.Close()
+		}
+		return
+	}
+
+	err = rs.Close()
+	if err != nil {
+		log.Warningf("Failed to write cost report: %s", err)
+	}
+}
+
 // ComputeAllocationHandler computes an AllocationSetRange from the CostModel.
 func (a *Accesses) ComputeAllocationHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	w.Header().Set("Content-Type", "application/json")
@@ -2144,8 +2208,14 @@ func (a *Accesses) ComputeAllocationHandler(w http.ResponseWriter, r *http.Reque
 	step := qp.GetDuration("step", window.Duration())
 
 	// Resolution is an optional parameter, defaulting to the configured ETL
-	// resolution.
+	// resolution. Finer resolutions, e.g. 1m, measure the minutes of
+	// short-lived pods more accurately, but are more expensive to query, and
+	// are coarsened if the window would require too many samples.
 	resolution := qp.GetDuration("resolution", env.GetETLResolution())
+	if qp.Get("resolution", "") != "" && (resolution < MinAllocationResolution || resolution > MaxAllocationResolution) {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'resolution' parameter: must be between %s and %s", MinAllocationResolution, MaxAllocationResolution)))
+		return
+	}
 
 	// Aggregation is a required comma-separated list of fields by which to
 	// aggregate results. Some fields allow a sub-field, which is distinguished
@@ -2156,28 +2226,109 @@ func (a *Accesses) ComputeAllocationHandler(w http.ResponseWriter, r *http.Reque
 		http.Error(w, fmt.Sprintf("Invalid 'aggregate' parameter: %s", err), http.StatusBadRequest)
 	}
 
+	// Breakdown is an optional parameter which, if set to "container", breaks
+	// each aggregated allocation down by container; e.g. to measure the cost
+	// of sidecars across namespaces: aggregate=namespace&breakdown=container
+	breakdown := qp.Get("breakdown", "")
+	if breakdown != "" {
+		if breakdown != kubecost.AllocationContainerProp {
+			WriteError(w, BadRequest(fmt.Sprintf("Invalid 'breakdown' parameter: %s", breakdown)))
+			return
+		}
+		aggregateBy = withContainerBreakdown(aggregateBy)
+	}
+
 	// Accumulate is an optional parameter, defaulting to false, which if true
 	// sums each Set in the Range, producing one Set.
 	accumulate := qp.GetBool("accumulate", false)
 
+	// IncludeExternal is an optional parameter, defaulting to false, which if
+	// true attributes out-of-cluster costs to allocations by matching the tag
+	// given by externalLabelKey to the label of the same name. Results must be
+	// aggregated by that label, e.g. aggregate=label:app&externalLabelKey=app
+	includeExternal := qp.GetBool("includeExternal", false)
+	externalLabelKey := qp.Get("externalLabelKey", "")
+	if includeExternal {
+		if externalLabelKey == "" {
+			WriteError(w, BadRequest("Parameter 'externalLabelKey' is required when 'includeExternal' is true"))
+			return
+		}
+
+		aggregatedByLabel := false
+		for _, agg := range aggregateBy {
+			if agg == "label:"+externalLabelKey {
+				aggregatedByLabel = true
+				break
+			}
+		}
+		if !aggregatedByLabel {
+			WriteError(w, BadRequest(fmt.Sprintf("Parameter 'aggregate' must include 'label:%s' when 'includeExternal' is true", externalLabelKey)))
+			return
+		}
+	}
+
+	// Offset, limit, and fields are optional parameters which, if any are
+	// given, return a page of each AllocationSet, sorted by total cost, with
+	// only the given fields of each allocation, along with the totals of the
+	// entire set. e.g. offset=100&limit=50&fields=totalCost,cpuEfficiency
+	pageOpts := &AllocationPageOptions{
+		Offset: qp.GetInt("offset", 0),
+		Limit:  qp.GetInt("limit", 0),
+		Fields: qp.GetList("fields", ","),
+	}
+	paginate := qp.Get("offset", "") != "" || qp.Get("limit", "") != "" || len(pageOpts.Fields) > 0
+	if pageOpts.Offset < 0 || pageOpts.Limit < 0 {
+		WriteError(w, BadRequest("Parameters 'offset' and 'limit' must be non-negative"))
+		return
+	}
+
+	// Currency is an optional parameter which, if set, converts costs to the
+	// given display currency; e.g. currency=EUR
+	conversion, err := a.currencyConversion(qp)
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'currency' parameter: %s", err)))
+		return
+	}
+
+	// Format is an optional parameter which, if set, returns a cost report of
+	// the window, i.e. the cost of each cluster, namespace, and workload, in
+	// the given format instead; e.g. format=csv
+	reportFormat := ""
+	if qp.Get("format", "") != "" {
+		reportFormat, err = report.ParseFormat(qp.Get("format", ""))
+		if err != nil {
+			WriteError(w, BadRequest(fmt.Sprintf("Invalid 'format' parameter: %s", err)))
+			return
+		}
+	}
+
 	// Query for AllocationSets in increments of the given step duration,
 	// appending each to the AllocationSetRange.
+	// The coverage of each set's data is returned alongside the range.
 	asr := kubecost.NewAllocationSetRange()
+	coverage := []*AllocationCoverage{}
 	stepStart := *window.Start()
 	for window.End().After(stepStart) {
 		stepEnd := stepStart.Add(step)
 		stepWindow := kubecost.NewWindow(&stepStart, &stepEnd)
 
-		as, err := a.Model.ComputeAllocation(*stepWindow.Start(), *stepWindow.End(), resolution)
+		as, c, err := a.Model.ComputeAllocationWithCoverage(r.Context(), *stepWindow.Start(), *stepWindow.End(), resolution)
 		if err != nil {
 			WriteError(w, InternalServerError(err.Error()))
 			return
 		}
 		asr.Append(as)
+		coverage = append(coverage, c)
 
 		stepStart = stepEnd
 	}
 
+	// Render a cost report of the unaggregated allocations, if requested
+	if reportFormat != "" {
+		a.writeCostReport(w, r, asr, conversion, reportFormat)
+		return
+	}
+
 	// Aggregate, if requested
 	if len(aggregateBy) > 0 {
 		err = asr.AggregateBy(aggregateBy, nil)
@@ -2197,7 +2348,60 @@ func (a *Accesses) ComputeAllocationHandler(w http.ResponseWriter, r *http.Reque
 		asr = kubecost.NewAllocationSetRange(as)
 	}
 
-	w.Write(WrapData(asr, nil))
+	// Attribute out-of-cluster costs, if requested
+	if includeExternal {
+		var externalErr error
+		asr.Each(func(i int, as *kubecost.AllocationSet) {
+			if externalErr != nil {
+				return
+			}
+
+			externals, err := a.externalAllocationsWithCache(as.Start(), as.End(), externalLabelKey)
+			if err != nil {
+				externalErr = fmt.Errorf("error querying external allocations: %s", err)
+				return
+			}
+
+			externalErr = applyExternalCosts(as, externalLabelKey, externals)
+		})
+		if externalErr != nil {
+			WriteError(w, InternalServerError(externalErr.Error()))
+			return
+		}
+	}
+
+	// Convert to the display currency, if requested, after attributing
+	// out-of-cluster costs, which are converted from their own currency
+	if conversion != nil {
+		asr.Each(func(i int, as *kubecost.AllocationSet) {
+			conversion.ConvertAllocationSet(as)
+		})
+	}
+
+	// Paginate, if requested, from the computed range so that the result is
+	// only computed once per request
+	if paginate {
+		pages := []*AllocationPage{}
+		var pageErr error
+		asr.Each(func(i int, as *kubecost.AllocationSet) {
+			if pageErr != nil {
+				return
+			}
+
+			var page *AllocationPage
+			page, pageErr = PaginateAllocationSet(as, pageOpts)
+			pages = append(pages, page)
+		})
+		if pageErr != nil {
+			WriteError(w, InternalServerError(pageErr.Error()))
+			return
+		}
+
+		w.Write(WrapDataWithCoverage(pages, conversion, coverage))
+		return
+	}
+
+	w.Write(WrapDataWithCoverage(asr, conversion, coverage))
 }
 
 // The below was transferred from a different package in order to maintain
@@ -1,6 +1,7 @@
 package costmodel
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"strconv"
@@ -19,47 +20,58 @@ import (
 )
 
 const (
-	queryFmtPods                  = `avg(kube_pod_container_status_running{}) by (pod, namespace, %s)[%s:%s]%s`
-	queryFmtRAMBytesAllocated     = `avg(avg_over_time(container_memory_allocation_bytes{container!="", container!="POD", node!=""}[%s]%s)) by (container, pod, namespace, node, %s, provider_id)`
-	queryFmtRAMRequests           = `avg(avg_over_time(kube_pod_container_resource_requests{resource="memory", unit="byte", container!="", container!="POD", node!=""}[%s]%s)) by (container, pod, namespace, node, %s)`
-	queryFmtRAMUsageAvg           = `avg(avg_over_time(container_memory_working_set_bytes{container!="", container_name!="POD", container!="POD"}[%s]%s)) by (container_name, container, pod_name, pod, namespace, instance, %s)`
-	queryFmtRAMUsageMax           = `max(max_over_time(container_memory_working_set_bytes{container!="", container_name!="POD", container!="POD"}[%s]%s)) by (container_name, container, pod_name, pod, namespace, instance, %s)`
-	queryFmtCPUCoresAllocated     = `avg(avg_over_time(container_cpu_allocation{container!="", container!="POD", node!=""}[%s]%s)) by (container, pod, namespace, node, %s)`
-	queryFmtCPURequests           = `avg(avg_over_time(kube_pod_container_resource_requests{resource="cpu", unit="core", container!="", container!="POD", node!=""}[%s]%s)) by (container, pod, namespace, node, %s)`
-	queryFmtCPUUsageAvg           = `avg(rate(container_cpu_usage_seconds_total{container!="", container_name!="POD", container!="POD"}[%s]%s)) by (container_name, container, pod_name, pod, namespace, instance, %s)`
-	queryFmtCPUUsageMax           = `max(rate(container_cpu_usage_seconds_total{container!="", container_name!="POD", container!="POD"}[%s]%s)) by (container_name, container, pod_name, pod, namespace, instance, %s)`
-	queryFmtGPUsRequested         = `avg(avg_over_time(kube_pod_container_resource_requests{resource="nvidia_com_gpu", container!="",container!="POD", node!=""}[%s]%s)) by (container, pod, namespace, node, %s)`
-	queryFmtGPUsAllocated         = `avg(avg_over_time(container_gpu_allocation{container!="", container!="POD", node!=""}[%s]%s)) by (container, pod, namespace, node, %s)`
-	queryFmtNodeCostPerCPUHr      = `avg(avg_over_time(node_cpu_hourly_cost[%s]%s)) by (node, %s, instance_type, provider_id)`
-	queryFmtNodeCostPerRAMGiBHr   = `avg(avg_over_time(node_ram_hourly_cost[%s]%s)) by (node, %s, instance_type, provider_id)`
-	queryFmtNodeCostPerGPUHr      = `avg(avg_over_time(node_gpu_hourly_cost[%s]%s)) by (node, %s, instance_type, provider_id)`
-	queryFmtNodeIsSpot            = `avg_over_time(kubecost_node_is_spot[%s]%s)`
-	queryFmtPVCInfo               = `avg(kube_persistentvolumeclaim_info{volumename != ""}) by (persistentvolumeclaim, storageclass, volumename, namespace, %s)[%s:%s]%s`
-	queryFmtPVBytes               = `avg(avg_over_time(kube_persistentvolume_capacity_bytes[%s]%s)) by (persistentvolume, %s)`
-	queryFmtPodPVCAllocation      = `avg(avg_over_time(pod_pvc_allocation[%s]%s)) by (persistentvolume, persistentvolumeclaim, pod, namespace, %s)`
-	queryFmtPVCBytesRequested     = `avg(avg_over_time(kube_persistentvolumeclaim_resource_requests_storage_bytes{}[%s]%s)) by (persistentvolumeclaim, namespace, %s)`
-	queryFmtPVCostPerGiBHour      = `avg(avg_over_time(pv_hourly_cost[%s]%s)) by (volumename, %s)`
-	queryFmtNetZoneGiB            = `sum(increase(kubecost_pod_network_egress_bytes_total{internet="false", sameZone="false", sameRegion="true"}[%s]%s)) by (pod_name, namespace, %s) / 1024 / 1024 / 1024`
-	queryFmtNetZoneCostPerGiB     = `avg(avg_over_time(kubecost_network_zone_egress_cost{}[%s]%s)) by (%s)`
-	queryFmtNetRegionGiB          = `sum(increase(kubecost_pod_network_egress_bytes_total{internet="false", sameZone="false", sameRegion="false"}[%s]%s)) by (pod_name, namespace, %s) / 1024 / 1024 / 1024`
-	queryFmtNetRegionCostPerGiB   = `avg(avg_over_time(kubecost_network_region_egress_cost{}[%s]%s)) by (%s)`
-	queryFmtNetInternetGiB        = `sum(increase(kubecost_pod_network_egress_bytes_total{internet="true"}[%s]%s)) by (pod_name, namespace, %s) / 1024 / 1024 / 1024`
-	queryFmtNetInternetCostPerGiB = `avg(avg_over_time(kubecost_network_internet_egress_cost{}[%s]%s)) by (%s)`
-	queryFmtNetReceiveBytes       = `sum(increase(container_network_receive_bytes_total{pod!="", container="POD"}[%s]%s)) by (pod_name, pod, namespace, %s)`
-	queryFmtNetTransferBytes      = `sum(increase(container_network_transmit_bytes_total{pod!="", container="POD"}[%s]%s)) by (pod_name, pod, namespace, %s)`
-	queryFmtNamespaceLabels       = `avg_over_time(kube_namespace_labels[%s]%s)`
-	queryFmtNamespaceAnnotations  = `avg_over_time(kube_namespace_annotations[%s]%s)`
-	queryFmtPodLabels             = `avg_over_time(kube_pod_labels[%s]%s)`
-	queryFmtPodAnnotations        = `avg_over_time(kube_pod_annotations[%s]%s)`
-	queryFmtServiceLabels         = `avg_over_time(service_selector_labels[%s]%s)`
-	queryFmtDeploymentLabels      = `avg_over_time(deployment_match_labels[%s]%s)`
-	queryFmtStatefulSetLabels     = `avg_over_time(statefulSet_match_labels[%s]%s)`
-	queryFmtDaemonSetLabels       = `sum(avg_over_time(kube_pod_owner{owner_kind="DaemonSet"}[%s]%s)) by (pod, owner_name, namespace, %s)`
-	queryFmtJobLabels             = `sum(avg_over_time(kube_pod_owner{owner_kind="Job"}[%s]%s)) by (pod, owner_name, namespace ,%s)`
-	queryFmtLBCostPerHr           = `avg(avg_over_time(kubecost_load_balancer_cost[%s]%s)) by (namespace, service_name, %s)`
-	queryFmtLBActiveMins          = `count(kubecost_load_balancer_cost) by (namespace, service_name, %s)[%s:%s]%s`
+	queryFmtPods                           = `avg(kube_pod_container_status_running{}) by (pod, namespace, %s)[%s:%s]%s`
+	queryFmtInitContainersRunning          = `avg(kube_pod_init_container_status_running{}) by (container, pod, namespace, %s)[%s:%s]%s`
+	queryFmtPodStartTime                   = `max(max_over_time(kube_pod_start_time{}[%s]%s)) by (pod, namespace, %s)`
+	queryFmtPodCompletionTime              = `max(max_over_time(kube_pod_completion_time{}[%s]%s)) by (pod, namespace, %s)`
+	queryFmtRAMBytesAllocated              = `avg(avg_over_time(container_memory_allocation_bytes{container!="", container!="POD", node!=""}[%s]%s)) by (container, pod, namespace, node, %s, provider_id)`
+	queryFmtRAMRequests                    = `avg(avg_over_time(kube_pod_container_resource_requests{resource="memory", unit="byte", container!="", container!="POD", node!=""}[%s]%s)) by (container, pod, namespace, node, %s)`
+	queryFmtRAMUsageAvg                    = `avg(avg_over_time(container_memory_working_set_bytes{container!="", container_name!="POD", container!="POD"}[%s]%s)) by (container_name, container, pod_name, pod, namespace, instance, %s)`
+	queryFmtRAMUsageMax                    = `max(max_over_time(container_memory_working_set_bytes{container!="", container_name!="POD", container!="POD"}[%s]%s)) by (container_name, container, pod_name, pod, namespace, instance, %s)`
+	queryFmtCPUCoresAllocated              = `avg(avg_over_time(container_cpu_allocation{container!="", container!="POD", node!=""}[%s]%s)) by (container, pod, namespace, node, %s)`
+	queryFmtCPURequests                    = `avg(avg_over_time(kube_pod_container_resource_requests{resource="cpu", unit="core", container!="", container!="POD", node!=""}[%s]%s)) by (container, pod, namespace, node, %s)`
+	queryFmtCPUUsageAvg                    = `avg(rate(container_cpu_usage_seconds_total{container!="", container_name!="POD", container!="POD"}[%s]%s)) by (container_name, container, pod_name, pod, namespace, instance, %s)`
+	queryFmtCPUUsageMax                    = `max(rate(container_cpu_usage_seconds_total{container!="", container_name!="POD", container!="POD"}[%s]%s)) by (container_name, container, pod_name, pod, namespace, instance, %s)`
+	queryFmtGPUsRequested                  = `avg(avg_over_time(kube_pod_container_resource_requests{resource="nvidia_com_gpu", container!="",container!="POD", node!=""}[%s]%s)) by (container, pod, namespace, node, %s)`
+	queryFmtGPUsAllocated                  = `avg(avg_over_time(container_gpu_allocation{container!="", container!="POD", node!=""}[%s]%s)) by (container, pod, namespace, node, %s)`
+	queryFmtNodeCostPerCPUHr               = `avg(avg_over_time(node_cpu_hourly_cost[%s]%s)) by (node, %s, instance_type, provider_id)`
+	queryFmtNodeCostPerRAMGiBHr            = `avg(avg_over_time(node_ram_hourly_cost[%s]%s)) by (node, %s, instance_type, provider_id)`
+	queryFmtNodeCostPerGPUHr               = `avg(avg_over_time(node_gpu_hourly_cost[%s]%s)) by (node, %s, instance_type, provider_id)`
+	queryFmtNodeIsSpot                     = `avg_over_time(kubecost_node_is_spot[%s]%s)`
+	queryFmtPVCInfo                        = `avg(kube_persistentvolumeclaim_info{volumename != ""}) by (persistentvolumeclaim, storageclass, volumename, namespace, %s)[%s:%s]%s`
+	queryFmtPVBytes                        = `avg(avg_over_time(kube_persistentvolume_capacity_bytes[%s]%s)) by (persistentvolume, %s)`
+	queryFmtPodPVCAllocation               = `avg(avg_over_time(pod_pvc_allocation[%s]%s)) by (persistentvolume, persistentvolumeclaim, pod, namespace, %s)`
+	queryFmtPVCBytesRequested              = `avg(avg_over_time(kube_persistentvolumeclaim_resource_requests_storage_bytes{}[%s]%s)) by (persistentvolumeclaim, namespace, %s)`
+	queryFmtPVCostPerGiBHour               = `avg(avg_over_time(pv_hourly_cost[%s]%s)) by (volumename, %s)`
+	queryFmtNetZoneGiB                     = `sum(increase(kubecost_pod_network_egress_bytes_total{internet="false", sameZone="false", sameRegion="true"}[%s]%s)) by (pod_name, namespace, %s) / 1024 / 1024 / 1024`
+	queryFmtNetZoneCostPerGiB              = `avg(avg_over_time(kubecost_network_zone_egress_cost{}[%s]%s)) by (%s)`
+	queryFmtNetRegionGiB                   = `sum(increase(kubecost_pod_network_egress_bytes_total{internet="false", sameZone="false", sameRegion="false"}[%s]%s)) by (pod_name, namespace, %s) / 1024 / 1024 / 1024`
+	queryFmtNetRegionCostPerGiB            = `avg(avg_over_time(kubecost_network_region_egress_cost{}[%s]%s)) by (%s)`
+	queryFmtNetInternetGiB                 = `sum(increase(kubecost_pod_network_egress_bytes_total{internet="true"}[%s]%s)) by (pod_name, namespace, %s) / 1024 / 1024 / 1024`
+	queryFmtNetInternetCostPerGiB          = `avg(avg_over_time(kubecost_network_internet_egress_cost{}[%s]%s)) by (%s)`
+	queryFmtNetReceiveBytes                = `sum(increase(container_network_receive_bytes_total{pod!="", container="POD"}[%s]%s)) by (pod_name, pod, namespace, %s)`
+	queryFmtNetTransferBytes               = `sum(increase(container_network_transmit_bytes_total{pod!="", container="POD"}[%s]%s)) by (pod_name, pod, namespace, %s)`
+	queryFmtNamespaceLabels                = `avg_over_time(kube_namespace_labels[%s]%s)`
+	queryFmtNamespaceAnnotations           = `avg_over_time(kube_namespace_annotations[%s]%s)`
+	queryFmtPodLabels                      = `avg_over_time(kube_pod_labels[%s]%s)`
+	queryFmtPodAnnotations                 = `avg_over_time(kube_pod_annotations[%s]%s)`
+	queryFmtServiceLabels                  = `avg_over_time(service_selector_labels[%s]%s)`
+	queryFmtDeploymentLabels               = `avg_over_time(deployment_match_labels[%s]%s)`
+	queryFmtStatefulSetLabels              = `avg_over_time(statefulSet_match_labels[%s]%s)`
+	queryFmtDaemonSetLabels                = `sum(avg_over_time(kube_pod_owner{owner_kind="DaemonSet"}[%s]%s)) by (pod, owner_name, namespace, %s)`
+	queryFmtJobLabels                      = `sum(avg_over_time(kube_pod_owner{owner_kind="Job"}[%s]%s)) by (pod, owner_name, namespace ,%s)`
+	queryFmtPodsWithReplicaSetOwner        = `sum(avg_over_time(kube_pod_owner{owner_kind="ReplicaSet"}[%s]%s)) by (pod, owner_name, namespace, %s)`
+	queryFmtReplicaSetsWithDeploymentOwner = `sum(avg_over_time(kube_replicaset_owner{owner_kind="Deployment"}[%s]%s)) by (replicaset, owner_name, namespace, %s)`
+	queryFmtPodsWithStatefulSetOwner       = `sum(avg_over_time(kube_pod_owner{owner_kind="StatefulSet"}[%s]%s)) by (pod, owner_name, namespace, %s)`
+	queryFmtJobsWithCronJobOwner           = `sum(avg_over_time(kube_job_owner{owner_kind="CronJob"}[%s]%s)) by (job_name, owner_name, namespace, %s)`
+	queryFmtLBCostPerHr                    = `avg(avg_over_time(kubecost_load_balancer_cost[%s]%s)) by (namespace, service_name, %s)`
+	queryFmtLBActiveMins                   = `count(kubecost_load_balancer_cost) by (namespace, service_name, %s)[%s:%s]%s`
 )
 
+// unownedControllerKind is the controller kind of allocations for pods which are
+// not owned by a supported controller.
+const unownedControllerKind = "unowned"
+
 // This is a bit of a hack to work around garbage data from cadvisor
 // Ideally you cap each pod to the max CPU on its node, but that involves a bit more complexity, as it it would need to be done when allocations joins with asset data.
 const MAX_CPU_CAP = 512
@@ -78,10 +90,77 @@ func (cm *CostModel) Name() string {
 	return "CostModel"
 }
 
+const (
+	// MinAllocationResolution is the finest resolution at which allocations
+	// are computed. Finer resolutions measure the minutes of short-lived pods
+	// more accurately, at the cost of more expensive queries.
+	MinAllocationResolution = time.Minute
+
+	// MaxAllocationResolution is the coarsest resolution at which allocations
+	// are computed.
+	MaxAllocationResolution = time.Hour
+
+	// maxAllocationQuerySamples is the maximum number of samples per series
+	// that allocation subqueries may request, which keeps fine resolutions
+	// over long windows below Prometheus' limit of 11,000 points per series.
+	maxAllocationQuerySamples = 11000
+)
+
+// boundAllocationResolution returns the given resolution, bounded to the
+// minimum and maximum allocation resolutions, and coarsened, if necessary, to
+// the nearest minute for which a query over the given duration does not
+// exceed the maximum number of samples.
+func boundAllocationResolution(duration, resolution time.Duration) time.Duration {
+	if resolution < MinAllocationResolution {
+		resolution = MinAllocationResolution
+	}
+	if resolution > MaxAllocationResolution {
+		resolution = MaxAllocationResolution
+	}
+
+	if duration/resolution > maxAllocationQuerySamples {
+		resolution = minQueryStep(duration)
+	}
+
+	return resolution
+}
+
+// minQueryStep returns the finest step, rounded up to the minute, at which a
+// range query over the given duration does not exceed the maximum number of
+// samples per series.
+func minQueryStep(duration time.Duration) time.Duration {
+	step := duration / maxAllocationQuerySamples
+	if step%time.Minute != 0 {
+		step = step.Truncate(time.Minute) + time.Minute
+	}
+	return step
+}
+
 // ComputeAllocation uses the CostModel instance to compute an AllocationSet
 // for the window defined by the given start and end times. The Allocations
-// returned are unaggregated (i.e. down to the container level).
+// returned are unaggregated (i.e. down to the container level). The resolution
+// is bounded by boundAllocationResolution.
 func (cm *CostModel) ComputeAllocation(start, end time.Time, resolution time.Duration) (*kubecost.AllocationSet, error) {
+	return cm.ComputeAllocationWithContext(context.Background(), start, end, resolution)
+}
+
+// ComputeAllocationWithContext is ComputeAllocation on behalf of the request
+// whose context is given, so that the logs of its queries and errors include
+// the request ID.
+func (cm *CostModel) ComputeAllocationWithContext(reqCtx context.Context, start, end time.Time, resolution time.Duration) (*kubecost.AllocationSet, error) {
+	allocSet, _, _, err := cm.computeAllocation(reqCtx, start, end, resolution)
+	return allocSet, err
+}
+
+// computeAllocation computes the AllocationSet of ComputeAllocationWithContext,
+// along with the pods from which it was built and the bounded resolution at
+// which it was computed.
+func (cm *CostModel) computeAllocation(reqCtx context.Context, start, end time.Time, resolution time.Duration) (*kubecost.AllocationSet, map[podKey]*Pod, time.Duration, error) {
+	if bounded := boundAllocationResolution(end.Sub(start), resolution); bounded != resolution {
+		log.DedupedInfof(5, "CostModel.ComputeAllocation: using resolution %s instead of %s for window of %s", bounded, resolution, end.Sub(start))
+		resolution = bounded
+	}
+
 	// 1. Build out Pod map from resolution-tuned, batched Pod start/end query
 	// 2. Run and apply the results of the remaining queries to
 	// 3. Build out AllocationSet from completed Pod map
@@ -109,7 +188,7 @@ func (cm *CostModel) ComputeAllocation(start, end time.Time, resolution time.Dur
 	clusterStart := map[string]time.Time{}
 	clusterEnd := map[string]time.Time{}
 
-	cm.buildPodMap(window, resolution, env.GetETLMaxBatchDuration(), podMap, clusterStart, clusterEnd)
+	cm.buildPodMap(reqCtx, window, resolution, env.GetETLMaxBatchDuration(), podMap, clusterStart, clusterEnd)
 
 	// (2) Run and apply remaining queries
 
@@ -118,13 +197,22 @@ func (cm *CostModel) ComputeAllocation(start, end time.Time, resolution time.Dur
 	durStr, offStr, err := window.DurationOffsetForPrometheus()
 	if err != nil {
 		// Negative duration, so return empty set
-		return allocSet, nil
+		return allocSet, podMap, resolution, nil
 	}
 
 	// Convert resolution duration to a query-ready string
 	resStr := timeutil.DurationString(resolution)
 
-	ctx := prom.NewNamedContext(cm.PrometheusClient, prom.AllocationContextName)
+	ctx := prom.NewNamedContext(cm.PrometheusClient, prom.AllocationContextName).WithContext(reqCtx)
+
+	queryInitContainersRunning := fmt.Sprintf(queryFmtInitContainersRunning, env.GetPromClusterLabel(), durStr, resStr, offStr)
+	resChInitContainersRunning := ctx.Query(queryInitContainersRunning)
+
+	queryPodStartTime := fmt.Sprintf(queryFmtPodStartTime, durStr, offStr, env.GetPromClusterLabel())
+	resChPodStartTime := ctx.Query(queryPodStartTime)
+
+	queryPodCompletionTime := fmt.Sprintf(queryFmtPodCompletionTime, durStr, offStr, env.GetPromClusterLabel())
+	resChPodCompletionTime := ctx.Query(queryPodCompletionTime)
 
 	queryRAMBytesAllocated := fmt.Sprintf(queryFmtRAMBytesAllocated, durStr, offStr, env.GetPromClusterLabel())
 	resChRAMBytesAllocated := ctx.Query(queryRAMBytesAllocated)
@@ -234,12 +322,28 @@ func (cm *CostModel) ComputeAllocation(start, end time.Time, resolution time.Dur
 	queryJobLabels := fmt.Sprintf(queryFmtJobLabels, durStr, offStr, env.GetPromClusterLabel())
 	resChJobLabels := ctx.Query(queryJobLabels)
 
+	queryPodsWithReplicaSetOwner := fmt.Sprintf(queryFmtPodsWithReplicaSetOwner, durStr, offStr, env.GetPromClusterLabel())
+	resChPodsWithReplicaSetOwner := ctx.Query(queryPodsWithReplicaSetOwner)
+
+	queryReplicaSetsWithDeploymentOwner := fmt.Sprintf(queryFmtReplicaSetsWithDeploymentOwner, durStr, offStr, env.GetPromClusterLabel())
+	resChReplicaSetsWithDeploymentOwner := ctx.Query(queryReplicaSetsWithDeploymentOwner)
+
+	queryPodsWithStatefulSetOwner := fmt.Sprintf(queryFmtPodsWithStatefulSetOwner, durStr, offStr, env.GetPromClusterLabel())
+	resChPodsWithStatefulSetOwner := ctx.Query(queryPodsWithStatefulSetOwner)
+
+	queryJobsWithCronJobOwner := fmt.Sprintf(queryFmtJobsWithCronJobOwner, durStr, offStr, env.GetPromClusterLabel())
+	resChJobsWithCronJobOwner := ctx.Query(queryJobsWithCronJobOwner)
+
 	queryLBCostPerHr := fmt.Sprintf(queryFmtLBCostPerHr, durStr, offStr, env.GetPromClusterLabel())
 	resChLBCostPerHr := ctx.Query(queryLBCostPerHr)
 
 	queryLBActiveMins := fmt.Sprintf(queryFmtLBActiveMins, env.GetPromClusterLabel(), durStr, resStr, offStr)
 	resChLBActiveMins := ctx.Query(queryLBActiveMins)
 
+	resInitContainersRunning, _ := resChInitContainersRunning.Await()
+	resPodStartTime, _ := resChPodStartTime.Await()
+	resPodCompletionTime, _ := resChPodCompletionTime.Await()
+
 	resCPUCoresAllocated, _ := resChCPUCoresAllocated.Await()
 	resCPURequests, _ := resChCPURequests.Await()
 	resCPUUsageAvg, _ := resChCPUUsageAvg.Await()
@@ -281,17 +385,31 @@ func (cm *CostModel) ComputeAllocation(start, end time.Time, resolution time.Dur
 	resStatefulSetLabels, _ := resChStatefulSetLabels.Await()
 	resDaemonSetLabels, _ := resChDaemonSetLabels.Await()
 	resJobLabels, _ := resChJobLabels.Await()
+	resPodsWithReplicaSetOwner, _ := resChPodsWithReplicaSetOwner.Await()
+	resReplicaSetsWithDeploymentOwner, _ := resChReplicaSetsWithDeploymentOwner.Await()
+	resPodsWithStatefulSetOwner, _ := resChPodsWithStatefulSetOwner.Await()
+	resJobsWithCronJobOwner, _ := resChJobsWithCronJobOwner.Await()
 	resLBCostPerHr, _ := resChLBCostPerHr.Await()
 	resLBActiveMins, _ := resChLBActiveMins.Await()
 
 	if ctx.HasErrors() {
 		for _, err := range ctx.Errors() {
-			log.Errorf("CostModel.ComputeAllocation: %s", err)
+			log.Ctx(reqCtx).Errorf("CostModel.ComputeAllocation: %s", err)
 		}
 
-		return allocSet, ctx.ErrorCollection()
+		return allocSet, podMap, resolution, ctx.ErrorCollection()
 	}
 
+	// Pods' minutes are reconstructed from samples, so refine them using the
+	// pods' actual start and completion times, where available, before any
+	// allocations are created from them.
+	applyPodTimestamps(window, resolution, podMap, resPodStartTime, resPodCompletionTime)
+
+	// Init containers only run for part of the pod's lifetime, so their
+	// allocations must be restricted to their own runtime before any resource
+	// totals are computed from minutes.
+	applyInitContainerRuntimes(window, resolution, podMap, resInitContainersRunning)
+
 	// We choose to apply allocation before requests in the cases of RAM and
 	// CPU so that we can assert that allocation should always be greater than
 	// or equal to request.
@@ -320,14 +438,23 @@ func (cm *CostModel) ComputeAllocation(start, end time.Time, resolution time.Dur
 	allocsByService := map[serviceKey][]*kubecost.Allocation{}
 	applyServicesToPods(podMap, podLabels, allocsByService, serviceLabels)
 
+	// Matching controller selectors against pod labels is only a fallback for
+	// when owner metrics are unavailable, so it is applied first and then
+	// overwritten by controllers resolved through owner references.
 	podDeploymentMap := labelsToPodControllerMap(podLabels, resToDeploymentLabels(resDeploymentLabels))
 	podStatefulSetMap := labelsToPodControllerMap(podLabels, resToStatefulSetLabels(resStatefulSetLabels))
-	podDaemonSetMap := resToPodDaemonSetMap(resDaemonSetLabels)
-	podJobMap := resToPodJobMap(resJobLabels)
 	applyControllersToPods(podMap, podDeploymentMap)
 	applyControllersToPods(podMap, podStatefulSetMap)
+
+	podDeploymentOwnerMap := resToPodDeploymentMap(resPodsWithReplicaSetOwner, resReplicaSetsWithDeploymentOwner)
+	podStatefulSetOwnerMap := resToPodStatefulSetMap(resPodsWithStatefulSetOwner)
+	podDaemonSetMap := resToPodDaemonSetMap(resDaemonSetLabels)
+	podJobMap := resToPodJobMap(resJobLabels, resToJobCronJobMap(resJobsWithCronJobOwner))
+	applyControllersToPods(podMap, podDeploymentOwnerMap)
+	applyControllersToPods(podMap, podStatefulSetOwnerMap)
 	applyControllersToPods(podMap, podDaemonSetMap)
 	applyControllersToPods(podMap, podJobMap)
+	applyUnownedToPods(podMap)
 
 	// TODO breakdown network costs?
 
@@ -435,17 +562,17 @@ func (cm *CostModel) ComputeAllocation(start, end time.Time, resolution time.Dur
 		}
 	}
 
-	return allocSet, nil
+	return allocSet, podMap, resolution, nil
 }
 
-func (cm *CostModel) buildPodMap(window kubecost.Window, resolution, maxBatchSize time.Duration, podMap map[podKey]*Pod, clusterStart, clusterEnd map[string]time.Time) error {
+func (cm *CostModel) buildPodMap(reqCtx context.Context, window kubecost.Window, resolution, maxBatchSize time.Duration, podMap map[podKey]*Pod, clusterStart, clusterEnd map[string]time.Time) error {
 	// Assumes that window is positive and closed
 	start, end := *window.Start(), *window.End()
 
 	// Convert resolution duration to a query-ready string
 	resStr := timeutil.DurationString(resolution)
 
-	ctx := prom.NewNamedContext(cm.PrometheusClient, prom.AllocationContextName)
+	ctx := prom.NewNamedContext(cm.PrometheusClient, prom.AllocationContextName).WithContext(reqCtx)
 
 	// Query for (start, end) by (pod, namespace, cluster) over the given
 	// window, using the given resolution, and if necessary in batches no
@@ -534,9 +661,24 @@ func applyPodResults(window kubecost.Window, resolution time.Duration, podMap ma
 		// already represents the end of the last minute.
 		var allocStart, allocEnd time.Time
 		startAdjustmentCoeff, endAdjustmentCoeff := 1.0, 1.0
+
+		// firstSample and lastSample are the timestamps of the first and last
+		// samples of the pod in the window, between which each resolution
+		// should have a sample, unless scraping of the pod had gaps.
+		var firstSample, lastSample time.Time
+		samples := 0
+
 		for _, datum := range res.Values {
 			t := time.Unix(int64(datum.Timestamp), 0)
 
+			if window.Contains(t) {
+				if firstSample.IsZero() {
+					firstSample = t
+				}
+				lastSample = t
+				samples++
+			}
+
 			if allocStart.IsZero() && datum.Value > 0 && window.Contains(t) {
 				// Set the start timestamp to the earliest non-zero timestamp
 				allocStart = t
@@ -616,6 +758,126 @@ func applyPodResults(window kubecost.Window, resolution time.Duration, podMap ma
 				Allocations: map[string]*kubecost.Allocation{},
 			}
 		}
+
+		podMap[key].Samples += samples
+		podMap[key].ExpectedSamples += int(lastSample.Sub(firstSample)/resolution) + 1
+	}
+}
+
+// applyPodTimestamps replaces the start and end of each pod, which are
+// reconstructed from samples and so are only accurate to within a resolution,
+// with the pod's actual start and completion times, where available. A
+// timestamp is only applied if it is within a resolution of the reconstructed
+// one, so that the timestamps of a different pod with the same name, e.g. a
+// recreated StatefulSet pod, are ignored.
+func applyPodTimestamps(window kubecost.Window, resolution time.Duration, podMap map[podKey]*Pod, resPodStartTime, resPodCompletionTime []*prom.QueryResult) {
+	withinResolution := func(t, reconstructed time.Time) bool {
+		diff := t.Sub(reconstructed)
+		return diff >= -resolution && diff <= resolution
+	}
+
+	podTimestamps := func(results []*prom.QueryResult) map[podKey]time.Time {
+		timestamps := map[podKey]time.Time{}
+		for _, res := range results {
+			if len(res.Values) == 0 || res.Values[0].Value <= 0 {
+				continue
+			}
+
+			key, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+			if err != nil {
+				log.DedupedWarningf(10, "CostModel.ComputeAllocation: pod timestamp result missing field: %s", err)
+				continue
+			}
+
+			timestamps[key] = time.Unix(int64(res.Values[0].Value), 0)
+		}
+		return timestamps
+	}
+
+	starts := podTimestamps(resPodStartTime)
+	ends := podTimestamps(resPodCompletionTime)
+
+	for key, pod := range podMap {
+		start, end := pod.Start, pod.End
+
+		if t, ok := starts[key]; ok && withinResolution(t, start) {
+			start = t
+		}
+		if t, ok := ends[key]; ok && withinResolution(t, end) {
+			end = t
+		}
+
+		if window.Start() != nil && start.Before(*window.Start()) {
+			start = *window.Start()
+		}
+		if window.End() != nil && end.After(*window.End()) {
+			end = *window.End()
+		}
+
+		if !end.After(start) {
+			continue
+		}
+
+		pod.Start = start
+		pod.End = end
+	}
+}
+
+// applyInitContainerRuntimes restricts the start and end of each init
+// container's Allocation to the time that the init container was running, so
+// that init containers are only attributed resources and costs for their own
+// runtime, rather than for the lifetime of their pod.
+func applyInitContainerRuntimes(window kubecost.Window, resolution time.Duration, podMap map[podKey]*Pod, resInitContainersRunning []*prom.QueryResult) {
+	for _, res := range resInitContainersRunning {
+		key, err := resultPodKey(res, env.GetPromClusterLabel(), "namespace")
+		if err != nil {
+			log.DedupedWarningf(10, "CostModel.ComputeAllocation: init container runtime result missing field: %s", err)
+			continue
+		}
+
+		pod, ok := podMap[key]
+		if !ok {
+			continue
+		}
+
+		container, err := res.GetString("container")
+		if err != nil {
+			log.DedupedWarningf(10, "CostModel.ComputeAllocation: init container runtime query result missing 'container': %s", key)
+			continue
+		}
+
+		// As with pods, the first running timestamp represents the end of the
+		// first resolution that the init container was running.
+		var runStart, runEnd time.Time
+		for _, datum := range res.Values {
+			t := time.Unix(int64(datum.Timestamp), 0)
+			if datum.Value <= 0 || !window.Contains(t) {
+				continue
+			}
+			if runStart.IsZero() {
+				runStart = t.Add(-resolution)
+			}
+			runEnd = t
+		}
+		if runStart.IsZero() {
+			continue
+		}
+
+		if runStart.Before(pod.Start) {
+			runStart = pod.Start
+		}
+		if runEnd.After(pod.End) {
+			runEnd = pod.End
+		}
+		if !runEnd.After(runStart) {
+			continue
+		}
+
+		if _, ok := pod.Allocations[container]; !ok {
+			pod.AppendContainer(container)
+		}
+		pod.Allocations[container].Start = runStart
+		pod.Allocations[container].End = runEnd
 	}
 }
 
@@ -1297,7 +1559,10 @@ func resToPodDaemonSetMap(resDaemonSetLabels []*prom.QueryResult) map[podKey]con
 	return daemonSetLabels
 }
 
-func resToPodJobMap(resJobLabels []*prom.QueryResult) map[podKey]controllerKey {
+// resToPodJobMap maps each pod to the Job which owns it or, if the Job is owned by
+// a CronJob, to the CronJob. If the Job's owner is unknown, Jobs named like those
+// generated by CronJobs are attributed to the CronJob.
+func resToPodJobMap(resJobLabels []*prom.QueryResult, jobCronJobMap map[controllerKey]controllerKey) map[podKey]controllerKey {
 	jobLabels := map[podKey]controllerKey{}
 
 	for _, res := range resJobLabels {
@@ -1306,10 +1571,12 @@ func resToPodJobMap(resJobLabels []*prom.QueryResult) map[podKey]controllerKey {
 			continue
 		}
 
-		// Convert the name of Jobs generated by CronJobs to the name of the
-		// CronJob by stripping the timestamp off the end.
-		match := isCron.FindStringSubmatch(controllerKey.Controller)
-		if match != nil {
+		if cronJobKey, ok := jobCronJobMap[controllerKey]; ok {
+			controllerKey = cronJobKey
+		} else if match := isCron.FindStringSubmatch(controllerKey.Controller); match != nil {
+			// Convert the name of Jobs generated by CronJobs to the name of the
+			// CronJob by stripping the timestamp off the end.
+			controllerKey.ControllerKind = "cronjob"
 			controllerKey.Controller = match[1]
 		}
 
@@ -1326,6 +1593,102 @@ func resToPodJobMap(resJobLabels []*prom.QueryResult) map[podKey]controllerKey {
 	return jobLabels
 }
 
+// resToJobCronJobMap maps each Job owned by a CronJob to the CronJob
+func resToJobCronJobMap(resJobsWithCronJobOwner []*prom.QueryResult) map[controllerKey]controllerKey {
+	jobCronJobMap := map[controllerKey]controllerKey{}
+
+	for _, res := range resJobsWithCronJobOwner {
+		cronJobKey, err := resultCronJobKey(res, env.GetPromClusterLabel(), "namespace", "owner_name")
+		if err != nil {
+			continue
+		}
+
+		job, err := res.GetString("job_name")
+		if err != nil {
+			log.Warningf("CostModel.ComputeAllocation: JobOwner result without job_name: %s", cronJobKey)
+			continue
+		}
+
+		jobKey := newControllerKey(cronJobKey.Cluster, cronJobKey.Namespace, "job", job)
+		jobCronJobMap[jobKey] = cronJobKey
+	}
+
+	return jobCronJobMap
+}
+
+// resToPodDeploymentMap resolves the Deployment owning each pod through the pod's
+// owning ReplicaSet. Resolving through owner references, rather than selectors or
+// names, attributes pods from every ReplicaSet of a Deployment correctly, e.g.
+// when an old and new ReplicaSet coexist during a rollout or rollback. Because
+// the owner metrics are averaged over the window, pods whose owners were deleted
+// during the window retain their last-known owner.
+func resToPodDeploymentMap(resPodsWithReplicaSetOwner, resReplicaSetsWithDeploymentOwner []*prom.QueryResult) map[podKey]controllerKey {
+	replicaSetDeploymentMap := map[controllerKey]controllerKey{}
+
+	for _, res := range resReplicaSetsWithDeploymentOwner {
+		deploymentKey, err := resultDeploymentKey(res, env.GetPromClusterLabel(), "namespace", "owner_name")
+		if err != nil {
+			continue
+		}
+
+		replicaSet, err := res.GetString("replicaset")
+		if err != nil {
+			log.Warningf("CostModel.ComputeAllocation: ReplicaSetOwner result without replicaset: %s", deploymentKey)
+			continue
+		}
+
+		replicaSetKey := newControllerKey(deploymentKey.Cluster, deploymentKey.Namespace, "replicaset", replicaSet)
+		replicaSetDeploymentMap[replicaSetKey] = deploymentKey
+	}
+
+	podDeploymentMap := map[podKey]controllerKey{}
+
+	for _, res := range resPodsWithReplicaSetOwner {
+		replicaSetKey, err := resultReplicaSetKey(res, env.GetPromClusterLabel(), "namespace", "owner_name")
+		if err != nil {
+			continue
+		}
+
+		// ReplicaSets which are not owned by a Deployment are not resolved
+		deploymentKey, ok := replicaSetDeploymentMap[replicaSetKey]
+		if !ok {
+			continue
+		}
+
+		pod, err := res.GetString("pod")
+		if err != nil {
+			log.Warningf("CostModel.ComputeAllocation: PodOwner result without pod: %s", replicaSetKey)
+			continue
+		}
+
+		podDeploymentMap[newPodKey(replicaSetKey.Cluster, replicaSetKey.Namespace, pod)] = deploymentKey
+	}
+
+	return podDeploymentMap
+}
+
+// resToPodStatefulSetMap maps each pod owned by a StatefulSet to the StatefulSet
+func resToPodStatefulSetMap(resPodsWithStatefulSetOwner []*prom.QueryResult) map[podKey]controllerKey {
+	podStatefulSetMap := map[podKey]controllerKey{}
+
+	for _, res := range resPodsWithStatefulSetOwner {
+		controllerKey, err := resultStatefulSetKey(res, env.GetPromClusterLabel(), "namespace", "owner_name")
+		if err != nil {
+			continue
+		}
+
+		pod, err := res.GetString("pod")
+		if err != nil {
+			log.Warningf("CostModel.ComputeAllocation: PodOwner result without pod: %s", controllerKey)
+			continue
+		}
+
+		podStatefulSetMap[newPodKey(controllerKey.Cluster, controllerKey.Namespace, pod)] = controllerKey
+	}
+
+	return podStatefulSetMap
+}
+
 func applyServicesToPods(podMap map[podKey]*Pod, podLabels map[podKey]map[string]string, allocsByService map[serviceKey][]*kubecost.Allocation, serviceLabels map[serviceKey]map[string]string) {
 	podServicesMap := map[podKey][]serviceKey{}
 
@@ -1380,6 +1743,18 @@ func applyControllersToPods(podMap map[podKey]*Pod, podControllerMap map[podKey]
 	}
 }
 
+// applyUnownedToPods marks the allocations of pods which could not be attributed
+// to a controller, so that they aggregate into an "unowned" controller kind.
+func applyUnownedToPods(podMap map[podKey]*Pod) {
+	for _, pod := range podMap {
+		for _, alloc := range pod.Allocations {
+			if alloc.Properties.ControllerKind == "" && alloc.Properties.Controller == "" {
+				alloc.Properties.ControllerKind = unownedControllerKind
+			}
+		}
+	}
+}
+
 func applyNodeCostPerCPUHr(nodeMap map[nodeKey]*NodePricing, resNodeCostPerCPUHr []*prom.QueryResult) {
 	for _, res := range resNodeCostPerCPUHr {
 		cluster, err := res.GetString(env.GetPromClusterLabel())
@@ -2040,6 +2415,12 @@ type Pod struct {
 	End         time.Time
 	Key         podKey
 	Allocations map[string]*kubecost.Allocation
+
+	// Samples is the number of samples of the pod's running status, and
+	// ExpectedSamples the number of resolutions between its first and last
+	// samples, which differ if scraping of the pod had gaps.
+	Samples         int
+	ExpectedSamples int
 }
 
 // AppendContainer adds an entry for the given container name to the Pod.
@@ -0,0 +1,344 @@
+package costmodel
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/prom"
+	"github.com/kubecost/cost-model/pkg/util"
+)
+
+func newOwnerResult(labels map[string]interface{}) *prom.QueryResult {
+	return &prom.QueryResult{
+		Metric: labels,
+		Values: []*util.Vector{{Timestamp: 0, Value: 1}},
+	}
+}
+
+func TestResToPodDeploymentMap(t *testing.T) {
+	cluster := env.GetClusterID()
+
+	// A rollback leaves the old and new ReplicaSets of a deployment coexisting
+	// within the window; the old ReplicaSet has since been deleted, but its
+	// owner metric remains within the window.
+	resPodsWithReplicaSetOwner := []*prom.QueryResult{
+		newOwnerResult(map[string]interface{}{"namespace": "web", "pod": "frontend-5d4f8-abcde", "owner_name": "frontend-5d4f8"}),
+		newOwnerResult(map[string]interface{}{"namespace": "web", "pod": "frontend-7c9b2-fghij", "owner_name": "frontend-7c9b2"}),
+		newOwnerResult(map[string]interface{}{"namespace": "web", "pod": "bare-xyz12", "owner_name": "bare"}),
+	}
+	resReplicaSetsWithDeploymentOwner := []*prom.QueryResult{
+		newOwnerResult(map[string]interface{}{"namespace": "web", "replicaset": "frontend-5d4f8", "owner_name": "frontend"}),
+		newOwnerResult(map[string]interface{}{"namespace": "web", "replicaset": "frontend-7c9b2", "owner_name": "frontend"}),
+	}
+
+	podDeploymentMap := resToPodDeploymentMap(resPodsWithReplicaSetOwner, resReplicaSetsWithDeploymentOwner)
+
+	expected := newControllerKey(cluster, "web", "deployment", "frontend")
+	for _, pod := range []string{"frontend-5d4f8-abcde", "frontend-7c9b2-fghij"} {
+		key := newPodKey(cluster, "web", pod)
+		if actual, ok := podDeploymentMap[key]; !ok || actual != expected {
+			t.Fatalf("expected pod %s to resolve to %s; got %s", pod, expected, actual)
+		}
+	}
+
+	// A ReplicaSet without a Deployment owner does not resolve
+	if key, ok := podDeploymentMap[newPodKey(cluster, "web", "bare-xyz12")]; ok {
+		t.Fatalf("expected pod of a bare ReplicaSet not to resolve; got %s", key)
+	}
+}
+
+func TestResToPodJobMap(t *testing.T) {
+	cluster := env.GetClusterID()
+
+	resJobLabels := []*prom.QueryResult{
+		newOwnerResult(map[string]interface{}{"namespace": "batch", "pod": "report-27015840-abcde", "owner_name": "report-27015840"}),
+		newOwnerResult(map[string]interface{}{"namespace": "batch", "pod": "backup-1612345678-fghij", "owner_name": "backup-1612345678"}),
+		newOwnerResult(map[string]interface{}{"namespace": "batch", "pod": "migrate-klmno", "owner_name": "migrate"}),
+	}
+	resJobsWithCronJobOwner := []*prom.QueryResult{
+		newOwnerResult(map[string]interface{}{"namespace": "batch", "job_name": "report-27015840", "owner_name": "report"}),
+	}
+
+	podJobMap := resToPodJobMap(resJobLabels, resToJobCronJobMap(resJobsWithCronJobOwner))
+
+	cases := map[string]controllerKey{
+		// resolved through the job's owner
+		"report-27015840-abcde": newControllerKey(cluster, "batch", "cronjob", "report"),
+		// resolved from the job's name when its owner is unknown
+		"backup-1612345678-fghij": newControllerKey(cluster, "batch", "cronjob", "backup"),
+		// a job without a cronjob
+		"migrate-klmno": newControllerKey(cluster, "batch", "job", "migrate"),
+	}
+
+	for pod, expected := range cases {
+		if actual := podJobMap[newPodKey(cluster, "batch", pod)]; actual != expected {
+			t.Fatalf("expected pod %s to resolve to %s; got %s", pod, expected, actual)
+		}
+	}
+}
+
+func TestApplyUnownedToPods(t *testing.T) {
+	owned := &kubecost.Allocation{Properties: &kubecost.AllocationProperties{ControllerKind: "deployment", Controller: "frontend"}}
+	unowned := &kubecost.Allocation{Properties: &kubecost.AllocationProperties{}}
+
+	podMap := map[podKey]*Pod{
+		newPodKey("cluster1", "web", "frontend-abc"): {Allocations: map[string]*kubecost.Allocation{"frontend": owned}},
+		newPodKey("cluster1", "web", "debug"):        {Allocations: map[string]*kubecost.Allocation{"debug": unowned}},
+	}
+
+	applyUnownedToPods(podMap)
+
+	if owned.Properties.ControllerKind != "deployment" {
+		t.Fatalf("expected owned allocation to keep controller kind deployment; got %s", owned.Properties.ControllerKind)
+	}
+	if unowned.Properties.ControllerKind != unownedControllerKind {
+		t.Fatalf("expected unowned allocation to have controller kind %s; got %s", unownedControllerKind, unowned.Properties.ControllerKind)
+	}
+}
+
+func TestApplyInitContainerRuntimes(t *testing.T) {
+	cluster := env.GetClusterID()
+	start := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	window := kubecost.NewWindow(&start, &end)
+
+	key := newPodKey(cluster, "web", "frontend-abcde")
+	podMap := map[podKey]*Pod{
+		key: {Window: window.Clone(), Start: start, End: end, Key: key, Allocations: map[string]*kubecost.Allocation{}},
+	}
+
+	// The init container runs for the first 5 minutes of the pod's hour
+	values := []*util.Vector{}
+	for i := 1; i <= 10; i++ {
+		value := 0.0
+		if i <= 5 {
+			value = 1.0
+		}
+		values = append(values, &util.Vector{Timestamp: float64(start.Add(time.Duration(i) * time.Minute).Unix()), Value: value})
+	}
+	resInitContainersRunning := []*prom.QueryResult{
+		{Metric: map[string]interface{}{"namespace": "web", "pod": "frontend-abcde", "container": "init-db"}, Values: values},
+	}
+
+	applyInitContainerRuntimes(window, time.Minute, podMap, resInitContainersRunning)
+
+	resCPUCoresAllocated := []*prom.QueryResult{
+		{Metric: map[string]interface{}{"namespace": "web", "pod": "frontend-abcde", "container": "init-db", "node": "node1"}, Values: []*util.Vector{{Value: 2.0}}},
+		{Metric: map[string]interface{}{"namespace": "web", "pod": "frontend-abcde", "container": "app", "node": "node1"}, Values: []*util.Vector{{Value: 1.0}}},
+	}
+	applyCPUCoresAllocated(podMap, resCPUCoresAllocated)
+
+	allocs := podMap[key].Allocations
+
+	// The init container is attributed 2.0 cores for 5 minutes only
+	if allocs["init-db"].Minutes() != 5.0 {
+		t.Fatalf("expected init container to run for 5 minutes; got %f", allocs["init-db"].Minutes())
+	}
+	if !util.IsApproximately(2.0*5.0/60.0, allocs["init-db"].CPUCoreHours) {
+		t.Fatalf("expected init container CPU core hours %f; got %f", 2.0*5.0/60.0, allocs["init-db"].CPUCoreHours)
+	}
+
+	// Regular containers are attributed for the pod's lifetime
+	if !util.IsApproximately(1.0, allocs["app"].CPUCoreHours) {
+		t.Fatalf("expected container CPU core hours %f; got %f", 1.0, allocs["app"].CPUCoreHours)
+	}
+}
+
+func TestWithContainerBreakdown(t *testing.T) {
+	start := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	newContainerAlloc := func(namespace, pod, container string, cpuCost, ramCost float64) *kubecost.Allocation {
+		return &kubecost.Allocation{
+			Name: fmt.Sprintf("cluster1/node1/%s/%s/%s", namespace, pod, container),
+			Properties: &kubecost.AllocationProperties{
+				Cluster:   "cluster1",
+				Node:      "node1",
+				Namespace: namespace,
+				Pod:       pod,
+				Container: container,
+			},
+			Window:  kubecost.NewWindow(&start, &end),
+			Start:   start,
+			End:     end,
+			CPUCost: cpuCost,
+			RAMCost: ramCost,
+		}
+	}
+
+	newSet := func() *kubecost.AllocationSet {
+		return kubecost.NewAllocationSet(start, end,
+			newContainerAlloc("web", "frontend-abcde", "app", 4.0, 2.0),
+			newContainerAlloc("web", "frontend-abcde", "istio-proxy", 1.0, 0.5),
+			newContainerAlloc("api", "backend-fghij", "app", 2.0, 1.0),
+		)
+	}
+
+	if aggBy := withContainerBreakdown(nil); len(aggBy) != 0 {
+		t.Fatalf("expected unaggregated breakdown to be unchanged; got %v", aggBy)
+	}
+	if aggBy := withContainerBreakdown([]string{"namespace", "container"}); len(aggBy) != 2 {
+		t.Fatalf("expected breakdown by container to be unchanged; got %v", aggBy)
+	}
+
+	podSet := newSet()
+	if err := podSet.AggregateBy([]string{"namespace", "pod"}, nil); err != nil {
+		t.Fatalf("unexpected error aggregating: %s", err)
+	}
+
+	containerSet := newSet()
+	if err := containerSet.AggregateBy(withContainerBreakdown([]string{"namespace", "pod"}), nil); err != nil {
+		t.Fatalf("unexpected error aggregating: %s", err)
+	}
+
+	if containerSet.Length() != 3 {
+		t.Fatalf("expected 3 containers; got %d", containerSet.Length())
+	}
+
+	// The pod's containers are split out, and sum to the pod's cost
+	app := containerSet.Get("web/frontend-abcde/app")
+	proxy := containerSet.Get("web/frontend-abcde/istio-proxy")
+	pod := podSet.Get("web/frontend-abcde")
+	if app == nil || proxy == nil || pod == nil {
+		t.Fatalf("expected allocations for pod and containers; got %v", containerSet.Map())
+	}
+	if !util.IsApproximately(6.0, app.TotalCost()) || !util.IsApproximately(1.5, proxy.TotalCost()) {
+		t.Fatalf("expected container costs 6.0 and 1.5; got %f and %f", app.TotalCost(), proxy.TotalCost())
+	}
+	if !util.IsApproximately(pod.TotalCost(), app.TotalCost()+proxy.TotalCost()) {
+		t.Fatalf("expected containers to sum to pod cost %f; got %f", pod.TotalCost(), app.TotalCost()+proxy.TotalCost())
+	}
+	if !util.IsApproximately(podSet.TotalCost(), containerSet.TotalCost()) {
+		t.Fatalf("expected breakdown total %f; got %f", podSet.TotalCost(), containerSet.TotalCost())
+	}
+}
+
+func TestBoundAllocationResolution(t *testing.T) {
+	cases := []struct {
+		name       string
+		duration   time.Duration
+		resolution time.Duration
+		expected   time.Duration
+	}{
+		{"within bounds", 24 * time.Hour, time.Minute, time.Minute},
+		{"below minimum", 24 * time.Hour, 10 * time.Second, time.Minute},
+		{"above maximum", 24 * time.Hour, 2 * time.Hour, time.Hour},
+		{"7d at 1m is under the sample cap", 7 * 24 * time.Hour, time.Minute, time.Minute},
+		{"30d at 1m exceeds the sample cap", 30 * 24 * time.Hour, time.Minute, 4 * time.Minute},
+		{"30d at 5m is under the sample cap", 30 * 24 * time.Hour, 5 * time.Minute, 5 * time.Minute},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if res := boundAllocationResolution(c.duration, c.resolution); res != c.expected {
+				t.Fatalf("expected resolution %s; got %s", c.expected, res)
+			}
+		})
+	}
+}
+
+// TestShortLivedPodResolution demonstrates the trade-off of allocation
+// resolution for short-lived pods: a batch pod which runs for 90 seconds is
+// attributed a full 5m at the default Thanos resolution, 2m at a 1m resolution,
+// and its actual 90 seconds when its start and completion times are available.
+// A long-lived pod's cost is unaffected by the resolution.
+func TestShortLivedPodResolution(t *testing.T) {
+	start := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	window := kubecost.NewWindow(&start, &end)
+
+	batchStart := time.Date(2021, time.January, 1, 12, 3, 30, 0, time.UTC)
+	batchEnd := time.Date(2021, time.January, 1, 12, 5, 0, 0, time.UTC)
+	webStart := time.Date(2021, time.January, 1, 6, 0, 0, 0, time.UTC)
+	webEnd := time.Date(2021, time.January, 1, 18, 0, 0, 0, time.UTC)
+
+	batchKey := newPodKey(env.GetClusterID(), "batch", "batch-1")
+	webKey := newPodKey(env.GetClusterID(), "web", "web-1")
+
+	// runningResult returns the samples of a pod's running status at the
+	// given resolution, as returned by the pods subquery
+	runningResult := func(namespace, pod string, podStart, podEnd time.Time, resolution time.Duration) *prom.QueryResult {
+		var values []*util.Vector
+		for ts := start.Add(resolution); !ts.After(end); ts = ts.Add(resolution) {
+			if ts.After(podStart) && !ts.After(podEnd) {
+				values = append(values, &util.Vector{Timestamp: float64(ts.Unix()), Value: 1.0})
+			}
+		}
+		return &prom.QueryResult{
+			Metric: map[string]interface{}{"namespace": namespace, "pod": pod},
+			Values: values,
+		}
+	}
+
+	timestampResult := func(namespace, pod string, ts time.Time) *prom.QueryResult {
+		return &prom.QueryResult{
+			Metric: map[string]interface{}{"namespace": namespace, "pod": pod},
+			Values: []*util.Vector{{Value: float64(ts.Unix())}},
+		}
+	}
+
+	// cpuCoreHours computes the CPU core-hours of each pod, each of which is
+	// allocated 1 core, at the given resolution
+	cpuCoreHours := func(resolution time.Duration, withTimestamps bool) (float64, float64) {
+		podMap := map[podKey]*Pod{}
+		resPods := []*prom.QueryResult{
+			runningResult("batch", "batch-1", batchStart, batchEnd, resolution),
+			runningResult("web", "web-1", webStart, webEnd, resolution),
+		}
+		applyPodResults(window, resolution, podMap, map[string]time.Time{}, map[string]time.Time{}, resPods)
+
+		if withTimestamps {
+			resStarts := []*prom.QueryResult{
+				timestampResult("batch", "batch-1", batchStart),
+				timestampResult("web", "web-1", webStart),
+			}
+			resEnds := []*prom.QueryResult{
+				timestampResult("batch", "batch-1", batchEnd),
+				timestampResult("web", "web-1", webEnd),
+			}
+			applyPodTimestamps(window, resolution, podMap, resStarts, resEnds)
+		}
+
+		applyCPUCoresAllocated(podMap, []*prom.QueryResult{
+			{Metric: map[string]interface{}{"namespace": "batch", "pod": "batch-1", "container": "job", "node": "node1"}, Values: []*util.Vector{{Value: 1.0}}},
+			{Metric: map[string]interface{}{"namespace": "web", "pod": "web-1", "container": "app", "node": "node1"}, Values: []*util.Vector{{Value: 1.0}}},
+		})
+
+		return podMap[batchKey].Allocations["job"].CPUCoreHours, podMap[webKey].Allocations["app"].CPUCoreHours
+	}
+
+	cases := []struct {
+		name           string
+		resolution     time.Duration
+		withTimestamps bool
+		batchMinutes   float64
+	}{
+		{"default resolution", 5 * time.Minute, false, 5.0},
+		{"1m resolution", time.Minute, false, 2.0},
+		{"default resolution with timestamps", 5 * time.Minute, true, 1.5},
+		{"1m resolution with timestamps", time.Minute, true, 1.5},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			batch, web := cpuCoreHours(c.resolution, c.withTimestamps)
+			if !util.IsApproximately(c.batchMinutes/60.0, batch) {
+				t.Fatalf("expected batch pod CPU core hours %f; got %f", c.batchMinutes/60.0, batch)
+			}
+			if !util.IsApproximately(12.0, web) {
+				t.Fatalf("expected web pod CPU core hours %f; got %f", 12.0, web)
+			}
+		})
+	}
+
+	// Relative to the default, a 1m resolution with timestamps reduces the
+	// batch pod's cost by the 3.5 minutes it was not running
+	defaultBatch, _ := cpuCoreHours(5*time.Minute, false)
+	fineBatch, _ := cpuCoreHours(time.Minute, true)
+	if !util.IsApproximately(3.5/60.0, defaultBatch-fineBatch) {
+		t.Fatalf("expected a difference of %f CPU core hours; got %f", 3.5/60.0, defaultBatch-fineBatch)
+	}
+}
@@ -0,0 +1,294 @@
+package costmodel
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/prom"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+)
+
+// The data sources whose coverage is reported by AllocationCoverage
+const (
+	AllocationCoverageContainerUsage = "containerUsage"
+	AllocationCoverageNodeCapacity   = "nodeCapacity"
+	AllocationCoverageNetwork        = "network"
+)
+
+const (
+	queryFmtCoverageContainerUsage = `count(container_cpu_usage_seconds_total{container!="", container!="POD"}) by (%s)[%s:%s]%s`
+	queryFmtCoverageNodeCapacity   = `count(kube_node_status_capacity_cpu_cores) by (%s)[%s:%s]%s`
+	queryFmtCoverageNetwork        = `count(container_network_receive_bytes_total{pod!="", container="POD"}) by (%s)[%s:%s]%s`
+)
+
+// allocationCoverageHistorySize is the number of windows whose coverage is
+// kept by an AllocationCoverageHistory
+const allocationCoverageHistorySize = 100
+
+// SourceCoverage is the fraction of the expected samples of a data source,
+// i.e. one per resolution per cluster, which are present in Prometheus.
+type SourceCoverage struct {
+	Source          string  `json:"source"`
+	Samples         int     `json:"samples"`
+	ExpectedSamples int     `json:"expectedSamples"`
+	Fraction        float64 `json:"fraction"`
+}
+
+// PodCoverage is the fraction of the expected samples of a pod's running
+// status, i.e. one per resolution between its first and last samples, which
+// are present in Prometheus, along with the names of its allocations.
+type PodCoverage struct {
+	Cluster         string   `json:"cluster"`
+	Namespace       string   `json:"namespace"`
+	Pod             string   `json:"pod"`
+	Samples         int      `json:"samples"`
+	ExpectedSamples int      `json:"expectedSamples"`
+	Fraction        float64  `json:"fraction"`
+	Allocations     []string `json:"allocations"`
+}
+
+// AllocationCoverage describes the data from which the allocations of a window
+// were computed: the coverage of each data source, and the pods whose samples
+// had gaps exceeding GapThreshold, i.e. whose allocations are likely to be
+// under-counted. Fraction is the lowest coverage of any source.
+type AllocationCoverage struct {
+	Window       kubecost.Window   `json:"window"`
+	Resolution   string            `json:"resolution"`
+	ComputedAt   time.Time         `json:"computedAt"`
+	Fraction     float64           `json:"fraction"`
+	Sources      []*SourceCoverage `json:"sources"`
+	GapThreshold float64           `json:"gapThreshold"`
+	PodsWithGaps []*PodCoverage    `json:"podsWithGaps"`
+}
+
+// ComputeAllocationWithCoverage is ComputeAllocationWithContext, along with
+// the coverage of the data from which the allocations were computed, which is
+// also recorded in the CostModel's coverage history.
+func (cm *CostModel) ComputeAllocationWithCoverage(reqCtx context.Context, start, end time.Time, resolution time.Duration) (*kubecost.AllocationSet, *AllocationCoverage, error) {
+	allocSet, podMap, resolution, err := cm.computeAllocation(reqCtx, start, end, resolution)
+	if err != nil {
+		return allocSet, nil, err
+	}
+
+	window := kubecost.NewWindow(&start, &end)
+
+	sources, err := cm.querySourceCoverage(reqCtx, window, resolution)
+	if err != nil {
+		return allocSet, nil, err
+	}
+
+	coverage := newAllocationCoverage(window, resolution, sources, podMap, env.GetAllocationCoverageGapThreshold())
+	cm.CoverageHistory.Record(coverage)
+
+	return allocSet, coverage, nil
+}
+
+// querySourceCoverage queries the samples of each data source over the window
+// at the given resolution, and returns the coverage of each.
+func (cm *CostModel) querySourceCoverage(reqCtx context.Context, window kubecost.Window, resolution time.Duration) ([]*SourceCoverage, error) {
+	durStr, offStr, err := window.DurationOffsetForPrometheus()
+	if err != nil {
+		return nil, nil
+	}
+	resStr := timeutil.DurationString(resolution)
+
+	ctx := prom.NewNamedContext(cm.PrometheusClient, prom.AllocationContextName).WithContext(reqCtx)
+
+	sources := []string{AllocationCoverageContainerUsage, AllocationCoverageNodeCapacity, AllocationCoverageNetwork}
+	queryFmts := []string{queryFmtCoverageContainerUsage, queryFmtCoverageNodeCapacity, queryFmtCoverageNetwork}
+
+	resChs := make([]prom.QueryResultsChan, len(sources))
+	for i, queryFmt := range queryFmts {
+		resChs[i] = ctx.Query(fmt.Sprintf(queryFmt, env.GetPromClusterLabel(), durStr, resStr, offStr))
+	}
+
+	results := make([][]*prom.QueryResult, len(sources))
+	for i, resCh := range resChs {
+		results[i], _ = resCh.Await()
+	}
+
+	if ctx.HasErrors() {
+		return nil, ctx.ErrorCollection()
+	}
+
+	// A cluster which is missing from a source entirely, but present in
+	// another, has no coverage of the former
+	clusters := map[string]bool{}
+	for _, res := range results {
+		for cluster := range samplesByCluster(window, res) {
+			clusters[cluster] = true
+		}
+	}
+
+	coverage := make([]*SourceCoverage, len(sources))
+	for i, source := range sources {
+		coverage[i] = sourceCoverage(source, window, resolution, results[i], clusters)
+	}
+
+	return coverage, nil
+}
+
+// samplesByCluster returns the number of distinct timestamps in the window at
+// which each cluster has a sample in the given results
+func samplesByCluster(window kubecost.Window, res []*prom.QueryResult) map[string]int {
+	timestamps := map[string]map[float64]bool{}
+	for _, r := range res {
+		cluster, err := r.GetString(env.GetPromClusterLabel())
+		if err != nil {
+			cluster = env.GetClusterID()
+		}
+		if _, ok := timestamps[cluster]; !ok {
+			timestamps[cluster] = map[float64]bool{}
+		}
+
+		for _, datum := range r.Values {
+			if window.Contains(time.Unix(int64(datum.Timestamp), 0)) {
+				timestamps[cluster][datum.Timestamp] = true
+			}
+		}
+	}
+
+	samples := make(map[string]int, len(timestamps))
+	for cluster, ts := range timestamps {
+		samples[cluster] = len(ts)
+	}
+	return samples
+}
+
+// sourceCoverage returns the coverage of a source, given its results, where
+// each of the given clusters is expected to have one sample per resolution of
+// the window. If no cluster is given, the local cluster is expected.
+func sourceCoverage(source string, window kubecost.Window, resolution time.Duration, res []*prom.QueryResult, clusters map[string]bool) *SourceCoverage {
+	steps := int(window.Duration() / resolution)
+	if steps < 1 {
+		steps = 1
+	}
+
+	numClusters := len(clusters)
+	if numClusters == 0 {
+		numClusters = 1
+	}
+
+	sc := &SourceCoverage{
+		Source:          source,
+		ExpectedSamples: steps * numClusters,
+	}
+	for _, samples := range samplesByCluster(window, res) {
+		if samples > steps {
+			samples = steps
+		}
+		sc.Samples += samples
+	}
+	sc.Fraction = float64(sc.Samples) / float64(sc.ExpectedSamples)
+
+	return sc
+}
+
+// newAllocationCoverage returns the coverage of the window given the coverage
+// of each source, and the pods from which allocations were computed, of which
+// those missing more than the given fraction of samples are reported.
+func newAllocationCoverage(window kubecost.Window, resolution time.Duration, sources []*SourceCoverage, podMap map[podKey]*Pod, gapThreshold float64) *AllocationCoverage {
+	coverage := &AllocationCoverage{
+		Window:       window.Clone(),
+		Resolution:   timeutil.DurationString(resolution),
+		ComputedAt:   time.Now().UTC(),
+		Fraction:     1.0,
+		Sources:      sources,
+		GapThreshold: gapThreshold,
+		PodsWithGaps: []*PodCoverage{},
+	}
+
+	if coverage.Sources == nil {
+		coverage.Sources = []*SourceCoverage{}
+	}
+	for _, sc := range coverage.Sources {
+		if sc.Fraction < coverage.Fraction {
+			coverage.Fraction = sc.Fraction
+		}
+	}
+
+	for key, pod := range podMap {
+		if pod.ExpectedSamples <= 0 {
+			continue
+		}
+
+		fraction := float64(pod.Samples) / float64(pod.ExpectedSamples)
+		if 1.0-fraction <= gapThreshold {
+			continue
+		}
+
+		pc := &PodCoverage{
+			Cluster:         key.Cluster,
+			Namespace:       key.Namespace,
+			Pod:             key.Pod,
+			Samples:         pod.Samples,
+			ExpectedSamples: pod.ExpectedSamples,
+			Fraction:        fraction,
+			Allocations:     []string{},
+		}
+		for _, alloc := range pod.Allocations {
+			pc.Allocations = append(pc.Allocations, alloc.Name)
+		}
+		sort.Strings(pc.Allocations)
+
+		coverage.PodsWithGaps = append(coverage.PodsWithGaps, pc)
+	}
+
+	sort.Slice(coverage.PodsWithGaps, func(i, j int) bool {
+		if coverage.PodsWithGaps[i].Fraction != coverage.PodsWithGaps[j].Fraction {
+			return coverage.PodsWithGaps[i].Fraction < coverage.PodsWithGaps[j].Fraction
+		}
+		return coverage.PodsWithGaps[i].Pod < coverage.PodsWithGaps[j].Pod
+	})
+
+	return coverage
+}
+
+// AllocationCoverageHistory keeps the coverage of the most recently computed
+// windows, for diagnostics. A nil history records nothing.
+type AllocationCoverageHistory struct {
+	lock    sync.Mutex
+	entries []*AllocationCoverage
+}
+
+// NewAllocationCoverageHistory creates an empty AllocationCoverageHistory
+func NewAllocationCoverageHistory() *AllocationCoverageHistory {
+	return &AllocationCoverageHistory{}
+}
+
+// Record adds the coverage of a window to the history, dropping the oldest
+// entry if the history is full.
+func (ach *AllocationCoverageHistory) Record(coverage *AllocationCoverage) {
+	if ach == nil || coverage == nil {
+		return
+	}
+
+	ach.lock.Lock()
+	defer ach.lock.Unlock()
+
+	ach.entries = append(ach.entries, coverage)
+	if len(ach.entries) > allocationCoverageHistorySize {
+		ach.entries = ach.entries[len(ach.entries)-allocationCoverageHistorySize:]
+	}
+}
+
+// Entries returns the recorded coverage, most recent first.
+func (ach *AllocationCoverageHistory) Entries() []*AllocationCoverage {
+	entries := []*AllocationCoverage{}
+	if ach == nil {
+		return entries
+	}
+
+	ach.lock.Lock()
+	defer ach.lock.Unlock()
+
+	for i := len(ach.entries) - 1; i >= 0; i-- {
+		entries = append(entries, ach.entries[i])
+	}
+	return entries
+}
@@ -0,0 +1,181 @@
+package costmodel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util"
+
+	prometheus "github.com/prometheus/client_golang/api"
+)
+
+var coverageTestStart = time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// coverageTestSeries returns a matrix series with the given labels, and a
+// sample at each of the given minutes after coverageTestStart
+func coverageTestSeries(labels string, minutes ...int) string {
+	values := []string{}
+	for _, m := range minutes {
+		values = append(values, fmt.Sprintf(`[%d,"1"]`, coverageTestStart.Add(time.Duration(m)*time.Minute).Unix()))
+	}
+	return fmt.Sprintf(`{"metric":{%s},"values":[%s]}`, labels, strings.Join(values, ","))
+}
+
+// newCoverageTestServer starts a fake Prometheus which answers queries of each
+// of the given metrics with the given matrix series, and any other query with
+// no results.
+func newCoverageTestServer(t *testing.T, series map[string][]string) (prometheus.Client, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query().Get("query")
+
+		result := []string{}
+		for metric, s := range series {
+			if strings.Contains(query, metric) {
+				result = s
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[%s]}}`, strings.Join(result, ","))
+	}))
+
+	client, err := prometheus.NewClient(prometheus.Config{Address: server.URL})
+	if err != nil {
+		server.Close()
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	return client, server.Close
+}
+
+func TestQuerySourceCoverage(t *testing.T) {
+	every5m := []int{5, 10, 15, 20, 25, 30, 35, 40, 45, 50, 55, 60}
+
+	client, closeFn := newCoverageTestServer(t, map[string][]string{
+		// cluster-two stopped reporting usage halfway through the window
+		"container_cpu_usage_seconds_total": {
+			coverageTestSeries(`"cluster_id":"cluster-one"`, every5m...),
+			coverageTestSeries(`"cluster_id":"cluster-two"`, every5m[:6]...),
+		},
+		"kube_node_status_capacity_cpu_cores": {
+			coverageTestSeries(`"cluster_id":"cluster-one"`, every5m...),
+			coverageTestSeries(`"cluster_id":"cluster-two"`, every5m...),
+		},
+		// cluster-two has no network metrics at all
+		"container_network_receive_bytes_total": {
+			coverageTestSeries(`"cluster_id":"cluster-one"`, every5m...),
+		},
+	})
+	defer closeFn()
+
+	cm := &CostModel{PrometheusClient: client}
+
+	end := coverageTestStart.Add(time.Hour)
+	window := kubecost.NewWindow(&coverageTestStart, &end)
+
+	sources, err := cm.querySourceCoverage(context.Background(), window, 5*time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]float64{
+		AllocationCoverageContainerUsage: 0.75,
+		AllocationCoverageNodeCapacity:   1.0,
+		AllocationCoverageNetwork:        0.5,
+	}
+	if len(sources) != len(expected) {
+		t.Fatalf("expected %d sources; got %d", len(expected), len(sources))
+	}
+	for _, sc := range sources {
+		if sc.ExpectedSamples != 24 {
+			t.Errorf("expected 24 samples of %s; got %d", sc.Source, sc.ExpectedSamples)
+		}
+		if !util.IsApproximately(expected[sc.Source], sc.Fraction) {
+			t.Errorf("expected coverage of %s to be %f; got %f", sc.Source, expected[sc.Source], sc.Fraction)
+		}
+	}
+
+	coverage := newAllocationCoverage(window, 5*time.Minute, sources, nil, 0.1)
+	if !util.IsApproximately(0.5, coverage.Fraction) {
+		t.Errorf("expected overall coverage to be the lowest of the sources; got %f", coverage.Fraction)
+	}
+}
+
+func TestAllocationCoveragePodsWithGaps(t *testing.T) {
+	client, closeFn := newCoverageTestServer(t, map[string][]string{
+		"kube_pod_container_status_running": {
+			coverageTestSeries(`"cluster_id":"cluster-one","namespace":"ns","pod":"steady"`, 5, 10, 15, 20, 25, 30, 35, 40, 45, 50, 55, 60),
+			// Scraping of this pod had a gap of 25 minutes
+			coverageTestSeries(`"cluster_id":"cluster-one","namespace":"ns","pod":"gappy"`, 5, 10, 15, 45, 50, 55, 60),
+			// A pod which started late in the window has no gaps
+			coverageTestSeries(`"cluster_id":"cluster-one","namespace":"ns","pod":"late"`, 50, 55, 60),
+		},
+	})
+	defer closeFn()
+
+	cm := &CostModel{PrometheusClient: client}
+
+	end := coverageTestStart.Add(time.Hour)
+	window := kubecost.NewWindow(&coverageTestStart, &end)
+
+	podMap := map[podKey]*Pod{}
+	err := cm.buildPodMap(context.Background(), window, 5*time.Minute, time.Hour, podMap, map[string]time.Time{}, map[string]time.Time{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(podMap) != 3 {
+		t.Fatalf("expected 3 pods; got %d", len(podMap))
+	}
+
+	gappy := podMap[newPodKey("cluster-one", "ns", "gappy")]
+	if gappy == nil || gappy.Samples != 7 || gappy.ExpectedSamples != 12 {
+		t.Fatalf("expected 7 of 12 samples of the gappy pod; got %+v", gappy)
+	}
+	gappy.AppendContainer("app")
+
+	coverage := newAllocationCoverage(window, 5*time.Minute, nil, podMap, 0.1)
+	if len(coverage.PodsWithGaps) != 1 {
+		t.Fatalf("expected 1 pod with gaps; got %d", len(coverage.PodsWithGaps))
+	}
+
+	pc := coverage.PodsWithGaps[0]
+	if pc.Pod != "gappy" || !util.IsApproximately(7.0/12.0, pc.Fraction) {
+		t.Errorf("expected gappy pod with coverage %f; got %s with %f", 7.0/12.0, pc.Pod, pc.Fraction)
+	}
+	if len(pc.Allocations) != 1 || pc.Allocations[0] != "cluster-one/ns/gappy/app" {
+		t.Errorf("expected allocations of the gappy pod to be marked; got %v", pc.Allocations)
+	}
+
+	// Raising the threshold above the gap reports no pods
+	coverage = newAllocationCoverage(window, 5*time.Minute, nil, podMap, 0.5)
+	if len(coverage.PodsWithGaps) != 0 {
+		t.Errorf("expected no pods with gaps above threshold 0.5; got %d", len(coverage.PodsWithGaps))
+	}
+}
+
+func TestAllocationCoverageHistory(t *testing.T) {
+	var nilHistory *AllocationCoverageHistory
+	nilHistory.Record(&AllocationCoverage{})
+	if len(nilHistory.Entries()) != 0 {
+		t.Fatalf("expected a nil history to record nothing")
+	}
+
+	history := NewAllocationCoverageHistory()
+	for i := 0; i <= allocationCoverageHistorySize; i++ {
+		history.Record(&AllocationCoverage{Fraction: float64(i)})
+	}
+
+	entries := history.Entries()
+	if len(entries) != allocationCoverageHistorySize {
+		t.Fatalf("expected %d entries; got %d", allocationCoverageHistorySize, len(entries))
+	}
+	if entries[0].Fraction != float64(allocationCoverageHistorySize) || entries[len(entries)-1].Fraction != 1.0 {
+		t.Errorf("expected entries most recent first, with the oldest dropped; got %f to %f", entries[0].Fraction, entries[len(entries)-1].Fraction)
+	}
+}
@@ -0,0 +1,321 @@
+package costmodel
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/errors"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// allocationCostOtherLabelValue is the label value of the series into which
+// allocations beyond the top N are summed.
+const allocationCostOtherLabelValue = "__other__"
+
+//--------------------------------------------------------------------------
+//  Allocation Cost Metrics Initialization
+//--------------------------------------------------------------------------
+
+// Only allow the metrics to be instantiated and registered once
+var allocationCostMetricsInit sync.Once
+
+var (
+	namespaceHourlyCostGv  *prometheus.GaugeVec
+	controllerHourlyCostGv *prometheus.GaugeVec
+	clusterHourlyCostG     prometheus.Gauge
+	clusterIdleHourlyCostG prometheus.Gauge
+	topKCostCollector      *metrics.TopKCollector
+)
+
+// newNamespaceHourlyCostGaugeVec creates the gauge of the hourly cost of each
+// namespace.
+func newNamespaceHourlyCostGaugeVec() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubecost_namespace_hourly_cost",
+		Help: "kubecost_namespace_hourly_cost Total cost of the namespace over the last full hour",
+	}, []string{"namespace"})
+}
+
+// newControllerHourlyCostGaugeVec creates the gauge of the hourly cost of each
+// controller.
+func newControllerHourlyCostGaugeVec() *prometheus.GaugeVec {
+	return prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubecost_controller_hourly_cost",
+		Help: "kubecost_controller_hourly_cost Total cost of the controller over the last full hour",
+	}, []string{"namespace", "controller_kind", "controller"})
+}
+
+// initAllocationCostMetrics uses a sync.Once to ensure that these metrics are
+// only created and registered once
+func initAllocationCostMetrics() {
+	allocationCostMetricsInit.Do(func() {
+		namespaceHourlyCostGv = newNamespaceHourlyCostGaugeVec()
+		controllerHourlyCostGv = newControllerHourlyCostGaugeVec()
+		clusterHourlyCostG = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "kubecost_cluster_hourly_cost",
+			Help: "kubecost_cluster_hourly_cost Total cost of the cluster over the last full hour",
+		})
+		clusterIdleHourlyCostG = prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "kubecost_cluster_idle_hourly_cost",
+			Help: "kubecost_cluster_idle_hourly_cost Unallocated node cost of the cluster over the last full hour",
+		})
+
+		topKCostCollector = metrics.NewTopKCollector(env.GetAllocationCostMetricsTopK())
+
+		prometheus.MustRegister(namespaceHourlyCostGv, controllerHourlyCostGv, clusterHourlyCostG, clusterIdleHourlyCostG, topKCostCollector)
+	})
+}
+
+//--------------------------------------------------------------------------
+//  AllocationCostRecorder
+//--------------------------------------------------------------------------
+
+// AllocationCostRecorder periodically computes the allocation of the last full
+// hour and records its costs as metrics, so that costs can be graphed and
+// alerted on directly from Prometheus. To protect against high cardinality,
+// each metric has at most TopN series, beyond which the least costly
+// allocations are summed into a single "__other__" series.
+type AllocationCostRecorder struct {
+	CloudProvider cloud.Provider
+	Model         *CostModel
+
+	// Interval is how often costs are recomputed
+	Interval time.Duration
+
+	// TopN is the maximum number of namespaces and of controllers recorded
+	TopN int
+
+	// RecordControllers determines whether costs are also recorded per
+	// controller
+	RecordControllers bool
+
+	// Metrics
+	NamespaceCostRecorder   *prometheus.GaugeVec
+	ControllerCostRecorder  *prometheus.GaugeVec
+	ClusterCostRecorder     prometheus.Gauge
+	ClusterIdleCostRecorder prometheus.Gauge
+
+	// TopK emits the costs of the most costly namespaces and workloads,
+	// regardless of RecordControllers, if set
+	TopK *metrics.TopKCollector
+
+	namespacesSeen  map[string]bool
+	controllersSeen map[string]bool
+
+	// Flow Control
+	recordingLock     *sync.Mutex
+	recordingStopping bool
+	recordingStop     chan bool
+}
+
+// NewAllocationCostRecorder creates a new AllocationCostRecorder, configured
+// by the environment. Use Start() to begin recording.
+func NewAllocationCostRecorder(provider cloud.Provider, model *CostModel) *AllocationCostRecorder {
+	// init will only actually execute once to register the custom gauges
+	initAllocationCostMetrics()
+
+	return &AllocationCostRecorder{
+		CloudProvider:           provider,
+		Model:                   model,
+		Interval:                env.GetAllocationCostMetricsInterval(),
+		TopN:                    env.GetAllocationCostMetricsTopN(),
+		RecordControllers:       env.IsAllocationCostMetricsControllersEnabled(),
+		NamespaceCostRecorder:   namespaceHourlyCostGv,
+		ControllerCostRecorder:  controllerHourlyCostGv,
+		ClusterCostRecorder:     clusterHourlyCostG,
+		ClusterIdleCostRecorder: clusterIdleHourlyCostG,
+		TopK:                    topKCostCollector,
+		namespacesSeen:          map[string]bool{},
+		controllersSeen:         map[string]bool{},
+		recordingLock:           new(sync.Mutex),
+	}
+}
+
+// IsRunning returns true if cost recording is running.
+func (acr *AllocationCostRecorder) IsRunning() bool {
+	acr.recordingLock.Lock()
+	defer acr.recordingLock.Unlock()
+
+	return acr.recordingStop != nil
+}
+
+// Start starts the go routine that records the costs of the last full hour on
+// the configured interval.
+func (acr *AllocationCostRecorder) Start() bool {
+	acr.recordingLock.Lock()
+	if acr.recordingStop != nil {
+		acr.recordingLock.Unlock()
+		log.Errorf("Attempted to start allocation cost recording when it's already running.")
+		return false
+	}
+	acr.recordingStop = make(chan bool, 1)
+	acr.recordingLock.Unlock()
+
+	go func() {
+		defer errors.HandlePanic()
+
+		for {
+			err := acr.recordLastHour()
+			if err != nil {
+				log.Warningf("AllocationCostRecorder: failed to record allocation costs: %s", err)
+			}
+
+			select {
+			case <-time.After(acr.Interval):
+			case <-acr.recordingStop:
+				acr.recordingLock.Lock()
+				acr.recordingStopping = false
+				acr.recordingStop = nil
+				acr.recordingLock.Unlock()
+				return
+			}
+		}
+	}()
+
+	return true
+}
+
+// Stop halts cost recording after the current recording is completed.
+func (acr *AllocationCostRecorder) Stop() {
+	acr.recordingLock.Lock()
+	defer acr.recordingLock.Unlock()
+
+	if !acr.recordingStopping && acr.recordingStop != nil {
+		acr.recordingStopping = true
+		close(acr.recordingStop)
+	}
+}
+
+// recordLastHour computes the allocation and node costs of the last full hour
+// and records them.
+func (acr *AllocationCostRecorder) recordLastHour() error {
+	end := time.Now().UTC().Truncate(time.Hour)
+	start := end.Add(-time.Hour)
+
+	as, err := acr.Model.ComputeAllocation(start, end, env.GetETLResolution())
+	if err != nil {
+		return err
+	}
+
+	nodes, err := acr.Model.pricedNodeUtilizations(acr.CloudProvider, start, end)
+	if err != nil {
+		return err
+	}
+
+	acr.record(as, ComputeNodeUtilization(as, nodes, nil))
+	return nil
+}
+
+// record records the costs of the given allocations, and the cluster's total
+// and idle costs, which include the idle costs of the given nodes. Series
+// which were previously recorded, but are not recorded again, are removed.
+func (acr *AllocationCostRecorder) record(as *kubecost.AllocationSet, nodes []*NodeUtilization) {
+	namespaceCosts := map[string]float64{}
+	controllerCosts := map[string]float64{}
+	workloadCosts := map[metrics.TopKWorkload]float64{}
+	totalCost := 0.0
+
+	as.Each(func(name string, alloc *kubecost.Allocation) {
+		cost := alloc.TotalCost()
+		totalCost += cost
+
+		namespace, controllerKind, controller := "", "", ""
+		if alloc.Properties != nil {
+			namespace = alloc.Properties.Namespace
+			controllerKind = alloc.Properties.ControllerKind
+			controller = alloc.Properties.Controller
+		}
+		if namespace == "" {
+			namespace = kubecost.UnallocatedSuffix
+		}
+		if controller == "" {
+			controller = kubecost.UnallocatedSuffix
+		}
+
+		namespaceCosts[namespace] += cost
+		workloadCosts[metrics.TopKWorkload{Namespace: namespace, ControllerKind: controllerKind, Controller: controller}] += cost
+		if acr.RecordControllers {
+			controllerCosts[getAllocationCostKey(namespace, controllerKind, controller)] += cost
+		}
+	})
+
+	idleCost := 0.0
+	for _, nu := range nodes {
+		idleCost += nu.IdleCost
+	}
+
+	otherNamespace := getAllocationCostKey(allocationCostOtherLabelValue)
+	recordAllocationCosts(acr.NamespaceCostRecorder, topNAllocationCosts(namespaceCosts, acr.TopN, otherNamespace), acr.namespacesSeen)
+
+	if acr.RecordControllers {
+		otherController := getAllocationCostKey(allocationCostOtherLabelValue, allocationCostOtherLabelValue, allocationCostOtherLabelValue)
+		recordAllocationCosts(acr.ControllerCostRecorder, topNAllocationCosts(controllerCosts, acr.TopN, otherController), acr.controllersSeen)
+	}
+
+	if acr.TopK != nil {
+		acr.TopK.Update(namespaceCosts, workloadCosts)
+	}
+
+	acr.ClusterCostRecorder.Set(totalCost + idleCost)
+	acr.ClusterIdleCostRecorder.Set(idleCost)
+}
+
+// getAllocationCostKey joins the given label values into a single key.
+func getAllocationCostKey(labelValues ...string) string {
+	return strings.Join(labelValues, ",")
+}
+
+// topNAllocationCosts returns the n most costly of the given costs, keyed by
+// label values, along with the sum of the remaining costs under the given
+// "other" key. Ties are broken by key so that the result is stable. A
+// non-positive n returns all costs.
+func topNAllocationCosts(costs map[string]float64, n int, otherKey string) map[string]float64 {
+	if n <= 0 || len(costs) <= n {
+		return costs
+	}
+
+	keys := make([]string, 0, len(costs))
+	for key := range costs {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if costs[keys[i]] != costs[keys[j]] {
+			return costs[keys[i]] > costs[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	result := make(map[string]float64, n+1)
+	for _, key := range keys[:n] {
+		result[key] = costs[key]
+	}
+	for _, key := range keys[n:] {
+		result[otherKey] += costs[key]
+	}
+
+	return result
+}
+
+// recordAllocationCosts sets the gauge of each of the given costs, and removes
+// the gauges of previously seen keys which are no longer present.
+func recordAllocationCosts(gv *prometheus.GaugeVec, costs map[string]float64, seen map[string]bool) {
+	for key, cost := range costs {
+		gv.WithLabelValues(strings.Split(key, ",")...).Set(cost)
+		seen[key] = true
+	}
+
+	for key := range seen {
+		if _, ok := costs[key]; !ok {
+			gv.DeleteLabelValues(strings.Split(key, ",")...)
+			delete(seen, key)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package costmodel
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/metrics"
+	"github.com/kubecost/cost-model/pkg/util"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func newTestAllocationCostRecorder(topN int) *AllocationCostRecorder {
+	return &AllocationCostRecorder{
+		TopN:                    topN,
+		RecordControllers:       true,
+		NamespaceCostRecorder:   newNamespaceHourlyCostGaugeVec(),
+		ControllerCostRecorder:  newControllerHourlyCostGaugeVec(),
+		ClusterCostRecorder:     prometheus.NewGauge(prometheus.GaugeOpts{Name: "cluster"}),
+		ClusterIdleCostRecorder: prometheus.NewGauge(prometheus.GaugeOpts{Name: "idle"}),
+		TopK:                    metrics.NewTopKCollector(topN),
+		namespacesSeen:          map[string]bool{},
+		controllersSeen:         map[string]bool{},
+	}
+}
+
+// collectGauges returns the value of each series of the given collector, keyed
+// by its comma-separated label values, ordered by label name.
+func collectGauges(t *testing.T, c prometheus.Collector) map[string]float64 {
+	ch := make(chan prometheus.Metric, 100)
+	c.Collect(ch)
+	close(ch)
+
+	result := map[string]float64{}
+	for metric := range ch {
+		m := &dto.Metric{}
+		if err := metric.Write(m); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		values := []string{}
+		for _, label := range m.GetLabel() {
+			values = append(values, label.GetValue())
+		}
+		result[strings.Join(values, ",")] = m.GetGauge().GetValue()
+	}
+	return result
+}
+
+func newAllocationCostTestSet(costs map[string]float64) *kubecost.AllocationSet {
+	start := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	as := kubecost.NewAllocationSet(start, end)
+	for namespace, cost := range costs {
+		as.Insert(&kubecost.Allocation{
+			Name: namespace + "/app",
+			Properties: &kubecost.AllocationProperties{
+				Namespace:      namespace,
+				ControllerKind: "deployment",
+				Controller:     "app",
+			},
+			Window:  kubecost.NewWindow(&start, &end),
+			Start:   start,
+			End:     end,
+			CPUCost: cost,
+		})
+	}
+	return as
+}
+
+func TestTopNAllocationCosts(t *testing.T) {
+	costs := map[string]float64{"a": 5.0, "b": 4.0, "c": 4.0, "d": 1.0, "e": 0.5}
+
+	result := topNAllocationCosts(costs, 2, "__other__")
+	if len(result) != 3 {
+		t.Fatalf("expected 2 series plus other; got %v", result)
+	}
+	// Ties are broken by key, so "b" is kept and "c" is collapsed
+	if result["a"] != 5.0 || result["b"] != 4.0 || !util.IsApproximately(5.5, result["__other__"]) {
+		t.Fatalf("unexpected top costs: %v", result)
+	}
+
+	if result := topNAllocationCosts(costs, 5, "__other__"); len(result) != 5 {
+		t.Fatalf("expected no collapse within the cap; got %v", result)
+	}
+}
+
+func TestAllocationCostRecorderRecord(t *testing.T) {
+	acr := newTestAllocationCostRecorder(2)
+
+	as := newAllocationCostTestSet(map[string]float64{"web": 3.0, "db": 2.0, "batch": 1.0, "cron": 0.5})
+	acr.record(as, []*NodeUtilization{{Node: "node1", IdleCost: 1.5}, {Node: "node2", IdleCost: 0.5}})
+
+	namespaces := collectGauges(t, acr.NamespaceCostRecorder)
+	if len(namespaces) != 3 || namespaces["web"] != 3.0 || namespaces["db"] != 2.0 || namespaces["__other__"] != 1.5 {
+		t.Fatalf("unexpected namespace costs: %v", namespaces)
+	}
+
+	controllers := collectGauges(t, acr.ControllerCostRecorder)
+	if len(controllers) != 3 || controllers["app,deployment,web"] != 3.0 || controllers["__other__,__other__,__other__"] != 1.5 {
+		t.Fatalf("unexpected controller costs: %v", controllers)
+	}
+
+	// The top-K collector emits only the top series, without "__other__"
+	topK := collectGauges(t, acr.TopK)
+	if len(topK) != 4 || topK["web"] != 3.0 || topK["db"] != 2.0 || topK["app,deployment,web"] != 3.0 || topK["app,deployment,db"] != 2.0 {
+		t.Fatalf("unexpected top-K costs: %v", topK)
+	}
+
+	cluster := collectGauges(t, acr.ClusterCostRecorder)[""]
+	idle := collectGauges(t, acr.ClusterIdleCostRecorder)[""]
+	if !util.IsApproximately(8.5, cluster) || !util.IsApproximately(2.0, idle) {
+		t.Fatalf("expected cluster cost 8.5 and idle cost 2.0; got %f and %f", cluster, idle)
+	}
+
+	// Recording again updates existing series and removes stale ones, rather
+	// than duplicating them
+	as = newAllocationCostTestSet(map[string]float64{"web": 4.0, "batch": 2.0})
+	acr.record(as, nil)
+
+	namespaces = collectGauges(t, acr.NamespaceCostRecorder)
+	if len(namespaces) != 2 || namespaces["web"] != 4.0 || namespaces["batch"] != 2.0 {
+		t.Fatalf("unexpected namespace costs after re-recording: %v", namespaces)
+	}
+	if len(acr.namespacesSeen) != 2 {
+		t.Fatalf("expected 2 seen namespaces; got %v", acr.namespacesSeen)
+	}
+
+	cluster = collectGauges(t, acr.ClusterCostRecorder)[""]
+	if !util.IsApproximately(6.0, cluster) {
+		t.Fatalf("expected cluster cost 6.0; got %f", cluster)
+	}
+}
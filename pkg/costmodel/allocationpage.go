@@ -0,0 +1,156 @@
+package costmodel
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+// AllocationPageOptions selects a page of allocations and the fields of each
+// allocation to return.
+type AllocationPageOptions struct {
+	// Offset is the number of allocations to skip.
+	Offset int
+
+	// Limit is the maximum number of allocations to return. Zero returns all
+	// allocations after the offset.
+	Limit int
+
+	// Fields are the JSON fields of each allocation to return. The name is
+	// always returned. Empty returns all fields.
+	Fields []string
+}
+
+// AllocationTotals are the total costs of all of the allocations in a set,
+// regardless of the page returned.
+type AllocationTotals struct {
+	CPUCost          float64 `json:"cpuCost"`
+	GPUCost          float64 `json:"gpuCost"`
+	RAMCost          float64 `json:"ramCost"`
+	PVCost           float64 `json:"pvCost"`
+	NetworkCost      float64 `json:"networkCost"`
+	LoadBalancerCost float64 `json:"loadBalancerCost"`
+	SharedCost       float64 `json:"sharedCost"`
+	ExternalCost     float64 `json:"externalCost"`
+	TotalCost        float64 `json:"totalCost"`
+}
+
+// add adds the costs of the given allocation to the totals.
+func (at *AllocationTotals) add(alloc *kubecost.Allocation) {
+	at.CPUCost += alloc.CPUTotalCost()
+	at.GPUCost += alloc.GPUTotalCost()
+	at.RAMCost += alloc.RAMTotalCost()
+	at.PVCost += alloc.PVTotalCost()
+	at.NetworkCost += alloc.NetworkTotalCost()
+	at.LoadBalancerCost += alloc.LBTotalCost()
+	at.SharedCost += alloc.SharedTotalCost()
+	at.ExternalCost += alloc.ExternalCost
+	at.TotalCost += alloc.TotalCost()
+}
+
+// AllocationPage is a page of the allocations of a single AllocationSet.
+// Allocations are sorted by total cost, descending, and then by name, so that
+// pages of the same computed set are consistent.
+type AllocationPage struct {
+	Window      kubecost.Window          `json:"window"`
+	Allocations []map[string]interface{} `json:"allocations"`
+	Offset      int                      `json:"offset"`
+	Limit       int                      `json:"limit"`
+	TotalCount  int                      `json:"totalCount"`
+	Totals      *AllocationTotals        `json:"totals"`
+}
+
+// sortedAllocations returns the allocations of the given set sorted by total
+// cost, descending, and then by name.
+func sortedAllocations(as *kubecost.AllocationSet) []*kubecost.Allocation {
+	allocs := []*kubecost.Allocation{}
+	as.Each(func(name string, alloc *kubecost.Allocation) {
+		allocs = append(allocs, alloc)
+	})
+
+	sort.Slice(allocs, func(i, j int) bool {
+		if allocs[i].TotalCost() != allocs[j].TotalCost() {
+			return allocs[i].TotalCost() > allocs[j].TotalCost()
+		}
+		return allocs[i].Name < allocs[j].Name
+	})
+
+	return allocs
+}
+
+// projectAllocation returns the given JSON fields of the allocation, along with
+// its name. If no fields are given, all fields are returned.
+func projectAllocation(alloc *kubecost.Allocation, fields []string) (map[string]interface{}, error) {
+	data, err := json.Marshal(alloc)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding allocation %s: %s", alloc.Name, err)
+	}
+
+	all := map[string]interface{}{}
+	err = json.Unmarshal(data, &all)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding allocation %s: %s", alloc.Name, err)
+	}
+
+	if len(fields) == 0 {
+		return all, nil
+	}
+
+	projected := map[string]interface{}{"name": all["name"]}
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			projected[field] = value
+		}
+	}
+
+	return projected, nil
+}
+
+// PaginateAllocationSet returns the page of the given AllocationSet selected
+// by the given options, along with the totals of the entire set.
+func PaginateAllocationSet(as *kubecost.AllocationSet, opts *AllocationPageOptions) (*AllocationPage, error) {
+	if opts == nil {
+		opts = &AllocationPageOptions{}
+	}
+	if opts.Offset < 0 || opts.Limit < 0 {
+		return nil, fmt.Errorf("offset and limit must be non-negative")
+	}
+
+	allocs := sortedAllocations(as)
+
+	page := &AllocationPage{
+		Window:      as.Window.Clone(),
+		Allocations: []map[string]interface{}{},
+		Offset:      opts.Offset,
+		Limit:       opts.Limit,
+		TotalCount:  len(allocs),
+		Totals:      &AllocationTotals{},
+	}
+
+	for _, alloc := range allocs {
+		page.Totals.add(alloc)
+	}
+
+	if opts.Offset >= len(allocs) {
+		return page, nil
+	}
+
+	// The limit is compared to the remaining allocations, rather than added to
+	// the offset, so that large limits cannot overflow
+	end := len(allocs)
+	if opts.Limit > 0 && opts.Limit < end-opts.Offset {
+		end = opts.Offset + opts.Limit
+	}
+
+	for _, alloc := range allocs[opts.Offset:end] {
+		projected, err := projectAllocation(alloc, opts.Fields)
+		if err != nil {
+			return nil, err
+		}
+		page.Allocations = append(page.Allocations, projected)
+	}
+
+	return page, nil
+}
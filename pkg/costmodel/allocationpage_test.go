@@ -0,0 +1,128 @@
+package costmodel
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util"
+)
+
+func newAllocationPageTestSet() *kubecost.AllocationSet {
+	start := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	as := kubecost.NewAllocationSet(start, end)
+	costs := map[string]float64{
+		"alpha":   3.0,
+		"bravo":   5.0,
+		"charlie": 1.0,
+		"delta":   3.0,
+		"echo":    4.0,
+	}
+	for name, cost := range costs {
+		as.Insert(&kubecost.Allocation{
+			Name:       name,
+			Properties: &kubecost.AllocationProperties{Namespace: name},
+			Window:     kubecost.NewWindow(&start, &end),
+			Start:      start,
+			End:        end,
+			CPUCost:    cost,
+			RAMCost:    1.0,
+		})
+	}
+
+	return as
+}
+
+func pageNames(page *AllocationPage) []string {
+	names := []string{}
+	for _, alloc := range page.Allocations {
+		names = append(names, alloc["name"].(string))
+	}
+	return names
+}
+
+func TestPaginateAllocationSet(t *testing.T) {
+	as := newAllocationPageTestSet()
+
+	// Sorted by total cost descending, then by name:
+	// bravo (6), echo (5), alpha (4), delta (4), charlie (2)
+	cases := []struct {
+		name     string
+		offset   int
+		limit    int
+		expected []string
+	}{
+		{"first page", 0, 2, []string{"bravo", "echo"}},
+		{"ties broken by name", 2, 2, []string{"alpha", "delta"}},
+		{"last partial page", 4, 2, []string{"charlie"}},
+		{"past the end", 6, 2, []string{}},
+		{"no limit", 1, 0, []string{"echo", "alpha", "delta", "charlie"}},
+		{"limit overflowing the offset", 1, math.MaxInt, []string{"echo", "alpha", "delta", "charlie"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			page, err := PaginateAllocationSet(as, &AllocationPageOptions{Offset: c.offset, Limit: c.limit})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			names := pageNames(page)
+			if len(names) != len(c.expected) {
+				t.Fatalf("expected %v; got %v", c.expected, names)
+			}
+			for i := range names {
+				if names[i] != c.expected[i] {
+					t.Fatalf("expected %v; got %v", c.expected, names)
+				}
+			}
+
+			// Totals always reflect the full set
+			if page.TotalCount != 5 {
+				t.Fatalf("expected total count 5; got %d", page.TotalCount)
+			}
+			if !util.IsApproximately(21.0, page.Totals.TotalCost) || !util.IsApproximately(16.0, page.Totals.CPUCost) {
+				t.Fatalf("expected totals 21.0 and 16.0; got %f and %f", page.Totals.TotalCost, page.Totals.CPUCost)
+			}
+		})
+	}
+
+	if _, err := PaginateAllocationSet(as, &AllocationPageOptions{Offset: -1}); err == nil {
+		t.Fatalf("expected error for negative offset")
+	}
+}
+
+func TestPaginateAllocationSetFields(t *testing.T) {
+	as := newAllocationPageTestSet()
+
+	page, err := PaginateAllocationSet(as, &AllocationPageOptions{Limit: 1, Fields: []string{"totalCost", "cpuCost", "unknownField"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(page.Allocations) != 1 {
+		t.Fatalf("expected 1 allocation; got %d", len(page.Allocations))
+	}
+
+	alloc := page.Allocations[0]
+	if len(alloc) != 3 {
+		t.Fatalf("expected name, totalCost, and cpuCost only; got %v", alloc)
+	}
+	if alloc["name"] != "bravo" {
+		t.Fatalf("expected bravo; got %v", alloc["name"])
+	}
+	if cost, ok := alloc["totalCost"].(float64); !ok || !util.IsApproximately(6.0, cost) {
+		t.Fatalf("expected totalCost 6.0; got %v", alloc["totalCost"])
+	}
+
+	// Without fields, every field is returned
+	page, err = PaginateAllocationSet(as, &AllocationPageOptions{Limit: 1})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := page.Allocations[0]["ramEfficiency"]; !ok {
+		t.Fatalf("expected all fields; got %v", page.Allocations[0])
+	}
+}
@@ -0,0 +1,383 @@
+package costmodel
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util"
+	"github.com/kubecost/cost-model/pkg/util/httputil"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+)
+
+// The types of assets
+const (
+	AssetTypeNode         = "node"
+	AssetTypeDisk         = "disk"
+	AssetTypeLoadBalancer = "loadbalancer"
+)
+
+// The properties by which assets can be aggregated
+const (
+	AssetAggregateType     = "type"
+	AssetAggregateProvider = "provider"
+	AssetAggregateCluster  = "cluster"
+)
+
+// Asset is a piece of the cluster's infrastructure, i.e. a node, a persistent
+// volume, or a load balancer, and its cost over a window.
+type Asset struct {
+	Type       string            `json:"type"`
+	Name       string            `json:"name"`
+	Cluster    string            `json:"cluster"`
+	Provider   string            `json:"provider"`
+	ProviderID string            `json:"providerID,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	// Owner hints at who owns the asset; e.g. the "namespace/name" of a
+	// volume's claim or of a load balancer's service
+	Owner      string  `json:"owner,omitempty"`
+	Minutes    float64 `json:"minutes"`
+	HourlyCost float64 `json:"hourlyCost"`
+	TotalCost  float64 `json:"totalCost"`
+	// Provenance is the pricing path which produced the hourly cost; e.g.
+	// "reserved" for a node priced at its reserved instance rates, or
+	// "defaultPrices" for a volume priced at the default storage price
+	Provenance cloud.PricingType `json:"provenance"`
+}
+
+// AssetTotal is the total cost of the assets which share the aggregated
+// properties. Only the aggregated properties are set.
+type AssetTotal struct {
+	Type       string  `json:"type,omitempty"`
+	Provider   string  `json:"provider,omitempty"`
+	Cluster    string  `json:"cluster,omitempty"`
+	Count      int     `json:"count"`
+	HourlyCost float64 `json:"hourlyCost"`
+	TotalCost  float64 `json:"totalCost"`
+}
+
+// AssetInventory is the response of the assets endpoint: the assets of the
+// window, or their totals if aggregated, and the total cost of all of them.
+type AssetInventory struct {
+	Window    string        `json:"window"`
+	Assets    []*Asset      `json:"assets,omitempty"`
+	Totals    []*AssetTotal `json:"totals,omitempty"`
+	TotalCost float64       `json:"totalCost"`
+}
+
+// newAsset creates an Asset of the cluster, charged at the given hourly cost
+// for the given number of minutes.
+func newAsset(assetType, name string, hourlyCost, minutes float64, provenance cloud.PricingType) *Asset {
+	return &Asset{
+		Type:       assetType,
+		Name:       name,
+		Cluster:    env.GetClusterID(),
+		Minutes:    minutes,
+		HourlyCost: hourlyCost,
+		TotalCost:  hourlyCost * minutes / timeutil.MinsPerHour,
+		Provenance: provenance,
+	}
+}
+
+// assetMinutes returns the number of minutes of the window for which an asset
+// created at the given time ran, or false if it did not run in the window.
+func assetMinutes(created, start, end time.Time) (float64, bool) {
+	if created.After(start) {
+		start = created
+	}
+	if !end.After(start) {
+		return 0, false
+	}
+	return end.Sub(start).Minutes(), true
+}
+
+// nodeProvenance returns the pricing path which produced the node's price,
+// after reserved instance pricing has been applied.
+func nodeProvenance(node *cloud.Node) cloud.PricingType {
+	switch {
+	case node.Reserved != nil:
+		return cloud.Reserved
+	case node.PricingType != "":
+		return node.PricingType
+	case node.IsSpot():
+		return cloud.Spot
+	case node.UsesBaseCPUPrice:
+		return cloud.DefaultPrices
+	}
+	return cloud.Api
+}
+
+// ComputeAssets returns the cluster's current nodes, persistent volumes, and
+// load balancers, priced by the provider and charged over the given window
+// from the later of its start and each asset's creation. Nodes are priced by
+// GetNodeCost, including reserved instance and spot adjustments, volumes by
+// GetPVCost, and load balancers by GetLBCost.
+func (cm *CostModel) ComputeAssets(cp cloud.Provider, window kubecost.Window) ([]*Asset, error) {
+	start, end := *window.Start(), *window.End()
+
+	provider := ""
+	if info, err := cp.ClusterInfo(); err == nil {
+		provider = info["provider"]
+	}
+
+	nodes, err := cm.nodeAssets(cp, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	disks, err := cm.diskAssets(cp, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	loadBalancers, err := cm.loadBalancerAssets(cp, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	assets := append(append(nodes, disks...), loadBalancers...)
+	for _, asset := range assets {
+		asset.Provider = provider
+	}
+
+	sort.Slice(assets, func(i, j int) bool {
+		if assets[i].TotalCost != assets[j].TotalCost {
+			return assets[i].TotalCost > assets[j].TotalCost
+		}
+		if assets[i].Type != assets[j].Type {
+			return assets[i].Type < assets[j].Type
+		}
+		return assets[i].Name < assets[j].Name
+	})
+
+	return assets, nil
+}
+
+// nodeAssets returns an Asset for each of the cluster's current nodes which
+// ran in the window.
+func (cm *CostModel) nodeAssets(cp cloud.Provider, start, end time.Time) ([]*Asset, error) {
+	pricing, err := cm.GetNodeCost(cp)
+	if err != nil {
+		return nil, err
+	}
+
+	var assets []*Asset
+	for _, n := range cm.Cache.GetAllNodes() {
+		node, ok := pricing[n.GetName()]
+		if !ok {
+			continue
+		}
+
+		minutes, ok := assetMinutes(n.GetCreationTimestamp().Time, start, end)
+		if !ok {
+			continue
+		}
+
+		hourlyCost := clusterSizingNodeType(node.InstanceType, node, 0).HourlyCost
+
+		asset := newAsset(AssetTypeNode, n.GetName(), hourlyCost, minutes, nodeProvenance(node))
+		asset.ProviderID = n.Spec.ProviderID
+		asset.Labels = n.GetLabels()
+		assets = append(assets, asset)
+	}
+
+	return assets, nil
+}
+
+// diskAssets returns an Asset for each of the cluster's current persistent
+// volumes which ran in the window, charged for its capacity.
+func (cm *CostModel) diskAssets(cp cloud.Provider, start, end time.Time) ([]*Asset, error) {
+	// Pull a region from the first node, as addPVData does
+	var defaultRegion string
+	if nodeList := cm.Cache.GetAllNodes(); len(nodeList) > 0 {
+		defaultRegion, _ = util.GetRegion(nodeList[0].Labels)
+	}
+
+	storageClassMap := map[string]map[string]string{}
+	for _, storageClass := range cm.Cache.GetAllStorageClasses() {
+		storageClassMap[storageClass.Name] = storageClass.Parameters
+	}
+
+	var assets []*Asset
+	for _, kpv := range cm.Cache.GetAllPersistentVolumes() {
+		minutes, ok := assetMinutes(kpv.GetCreationTimestamp().Time, start, end)
+		if !ok {
+			continue
+		}
+
+		region := defaultRegion
+		if r, ok := util.GetRegion(kpv.Labels); ok {
+			region = r
+		}
+
+		pv := &cloud.PV{
+			Class:      kpv.Spec.StorageClassName,
+			Region:     region,
+			Parameters: storageClassMap[kpv.Spec.StorageClassName],
+		}
+		provenance, err := getPVCost(pv, kpv, cp, region)
+		if err != nil {
+			return nil, err
+		}
+
+		// PV prices are per GiB-hour
+		var gib float64
+		if capacity, ok := kpv.Spec.Capacity[v1.ResourceStorage]; ok {
+			gib = float64(capacity.Value()) / 1024 / 1024 / 1024
+		}
+
+		asset := newAsset(AssetTypeDisk, kpv.Name, parseNodeFloat(pv.Cost)*gib, minutes, provenance)
+		asset.ProviderID = pv.ProviderID
+		asset.Labels = kpv.Labels
+		if claim := kpv.Spec.ClaimRef; claim != nil {
+			asset.Owner = claim.Namespace + "/" + claim.Name
+		}
+		assets = append(assets, asset)
+	}
+
+	return assets, nil
+}
+
+// loadBalancerAssets returns an Asset for each of the cluster's current
+// services of type LoadBalancer which ran in the window.
+func (cm *CostModel) loadBalancerAssets(cp cloud.Provider, start, end time.Time) ([]*Asset, error) {
+	pricing, err := cm.GetLBCost(cp)
+	if err != nil {
+		return nil, err
+	}
+
+	var assets []*Asset
+	for _, service := range cm.Cache.GetAllServices() {
+		lb, ok := pricing[service.Namespace+","+service.Name]
+		if !ok {
+			continue
+		}
+
+		minutes, ok := assetMinutes(service.GetCreationTimestamp().Time, start, end)
+		if !ok {
+			continue
+		}
+
+		asset := newAsset(AssetTypeLoadBalancer, service.Namespace+"/"+service.Name, lb.Cost, minutes, cloud.Api)
+		asset.Labels = service.Labels
+		asset.Owner = service.Namespace + "/" + service.Name
+		if len(lb.IngressIPAddresses) > 0 {
+			asset.ProviderID = strings.Join(lb.IngressIPAddresses, ",")
+		}
+		assets = append(assets, asset)
+	}
+
+	return assets, nil
+}
+
+// AggregateAssets totals the assets by the given properties, which must be
+// AssetAggregateType, AssetAggregateProvider, or AssetAggregateCluster. The
+// totals are sorted by total cost, descending.
+func AggregateAssets(assets []*Asset, aggregateBy []string) ([]*AssetTotal, error) {
+	for _, prop := range aggregateBy {
+		switch prop {
+		case AssetAggregateType, AssetAggregateProvider, AssetAggregateCluster:
+		default:
+			return nil, fmt.Errorf("cannot aggregate assets by '%s'", prop)
+		}
+	}
+
+	totals := map[string]*AssetTotal{}
+	for _, asset := range assets {
+		key := &AssetTotal{}
+		for _, prop := range aggregateBy {
+			switch prop {
+			case AssetAggregateType:
+				key.Type = asset.Type
+			case AssetAggregateProvider:
+				key.Provider = asset.Provider
+			case AssetAggregateCluster:
+				key.Cluster = asset.Cluster
+			}
+		}
+
+		k := key.Type + "/" + key.Provider + "/" + key.Cluster
+		total, ok := totals[k]
+		if !ok {
+			total = key
+			totals[k] = total
+		}
+
+		total.Count++
+		total.HourlyCost += asset.HourlyCost
+		total.TotalCost += asset.TotalCost
+	}
+
+	result := make([]*AssetTotal, 0, len(totals))
+	for _, total := range totals {
+		result = append(result, total)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalCost != result[j].TotalCost {
+			return result[i].TotalCost > result[j].TotalCost
+		}
+		ki := result[i].Type + "/" + result[i].Provider + "/" + result[i].Cluster
+		kj := result[j].Type + "/" + result[j].Provider + "/" + result[j].Cluster
+		return ki < kj
+	})
+
+	return result, nil
+}
+
+// AssetsHandler returns the cluster's nodes, persistent volumes, and load
+// balancers with their costs over the given window, or their totals if
+// aggregated; e.g. aggregate=type,provider
+func (a *Accesses) AssetsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	qp := httputil.NewQueryParams(r.URL.Query())
+
+	conversion, err := a.currencyConversion(qp)
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'currency' parameter: %s", err)))
+		return
+	}
+
+	window, err := kubecost.ParseWindowWithOffset(qp.Get("window", "1d"), env.GetParsedUTCOffset())
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'window' parameter: %s", err)))
+		return
+	}
+	if window.IsOpen() || window.IsNegative() {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'window' parameter: %s", window)))
+		return
+	}
+
+	assets, err := a.Model.ComputeAssets(a.CloudProvider, window)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	inventory := &AssetInventory{Window: window.String()}
+	for _, asset := range assets {
+		inventory.TotalCost += asset.TotalCost
+	}
+
+	aggregateBy := qp.GetList("aggregate", ",")
+	if len(aggregateBy) > 0 {
+		inventory.Totals, err = AggregateAssets(assets, aggregateBy)
+		if err != nil {
+			WriteError(w, BadRequest(fmt.Sprintf("Invalid 'aggregate' parameter: %s", err)))
+			return
+		}
+	} else {
+		inventory.Assets = assets
+	}
+
+	writeWithCurrency(w, inventory, conversion)
+}
@@ -0,0 +1,253 @@
+package costmodel
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	stv1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/clustercache"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util"
+)
+
+type assetsClusterCache struct {
+	clustercache.ClusterCache
+	nodes          []*v1.Node
+	pvs            []*v1.PersistentVolume
+	storageClasses []*stv1.StorageClass
+	services       []*v1.Service
+}
+
+func (c *assetsClusterCache) GetAllNodes() []*v1.Node                         { return c.nodes }
+func (c *assetsClusterCache) GetAllPersistentVolumes() []*v1.PersistentVolume { return c.pvs }
+func (c *assetsClusterCache) GetAllStorageClasses() []*stv1.StorageClass      { return c.storageClasses }
+func (c *assetsClusterCache) GetAllServices() []*v1.Service                   { return c.services }
+func (c *assetsClusterCache) GetAllDaemonSets() []*appsv1.DaemonSet           { return nil }
+
+type assetsKey struct{ name string }
+
+func (k *assetsKey) ID() string       { return k.name }
+func (k *assetsKey) Features() string { return k.name }
+func (k *assetsKey) GPUType() string  { return "" }
+
+type assetsPVKey struct{ class string }
+
+func (k *assetsPVKey) ID() string              { return "vol-" + k.class }
+func (k *assetsPVKey) Features() string        { return k.class }
+func (k *assetsPVKey) GetStorageClass() string { return k.class }
+
+// assetsProvider prices nodes by name, volumes by storage class, and load
+// balancers at a flat rate, and reserves the nodes in reserved
+type assetsProvider struct {
+	cloud.Provider
+	nodes    map[string]*cloud.Node
+	pvs      map[string]*cloud.PV
+	lb       *cloud.LoadBalancer
+	reserved []string
+}
+
+func (p *assetsProvider) GetConfig() (*cloud.CustomPricing, error) {
+	return &cloud.CustomPricing{CPU: "0.03", RAM: "0.004", Storage: "0.00005"}, nil
+}
+
+func (p *assetsProvider) ClusterInfo() (map[string]string, error) {
+	return map[string]string{"provider": "AWS"}, nil
+}
+
+func (p *assetsProvider) GetKey(labels map[string]string, n *v1.Node) cloud.Key {
+	return &assetsKey{name: n.Name}
+}
+
+func (p *assetsProvider) NodePricing(key cloud.Key) (*cloud.Node, error) {
+	return p.nodes[key.ID()], nil
+}
+
+func (p *assetsProvider) ApplyReservedInstancePricing(nodes map[string]*cloud.Node) {
+	for _, name := range p.reserved {
+		if node, ok := nodes[name]; ok {
+			node.Reserved = &cloud.ReservedInstanceData{}
+		}
+	}
+}
+
+func (p *assetsProvider) GetPVKey(pv *v1.PersistentVolume, parameters map[string]string, defaultRegion string) cloud.PVKey {
+	return &assetsPVKey{class: pv.Spec.StorageClassName}
+}
+
+func (p *assetsProvider) PVPricing(key cloud.PVKey) (*cloud.PV, error) {
+	return p.pvs[key.GetStorageClass()], nil
+}
+
+func (p *assetsProvider) LoadBalancerPricing() (*cloud.LoadBalancer, error) {
+	return p.lb, nil
+}
+
+func newAssetsTestNode(name string, created time.Time) *v1.Node {
+	return &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Labels:            map[string]string{"node.kubernetes.io/instance-type": "m5.large"},
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: v1.NodeSpec{ProviderID: "aws:///us-east-1a/i-" + name},
+		Status: v1.NodeStatus{
+			Capacity: v1.ResourceList{
+				v1.ResourceCPU:    resource.MustParse("2"),
+				v1.ResourceMemory: resource.MustParse("8Gi"),
+			},
+		},
+	}
+}
+
+func newAssetsTestPV(name, class, size string, created time.Time, claim *v1.ObjectReference) *v1.PersistentVolume {
+	return &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			CreationTimestamp: metav1.NewTime(created),
+		},
+		Spec: v1.PersistentVolumeSpec{
+			StorageClassName: class,
+			Capacity:         v1.ResourceList{v1.ResourceStorage: resource.MustParse(size)},
+			ClaimRef:         claim,
+		},
+	}
+}
+
+func TestComputeAssets(t *testing.T) {
+	start := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	before := start.Add(-time.Hour)
+
+	cache := &assetsClusterCache{
+		nodes: []*v1.Node{
+			newAssetsTestNode("node1", before),
+			// node2 was created halfway through the window
+			newAssetsTestNode("node2", start.Add(12*time.Hour)),
+			// node3 was created after the window
+			newAssetsTestNode("node3", end.Add(time.Hour)),
+		},
+		pvs: []*v1.PersistentVolume{
+			newAssetsTestPV("pv1", "ssd", "100Gi", before, &v1.ObjectReference{Namespace: "db", Name: "data"}),
+			newAssetsTestPV("pv2", "unpriced", "10Gi", before, nil),
+		},
+		storageClasses: []*stv1.StorageClass{
+			{ObjectMeta: metav1.ObjectMeta{Name: "ssd"}, Parameters: map[string]string{"type": "gp2"}},
+		},
+		services: []*v1.Service{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "frontend", CreationTimestamp: metav1.NewTime(before)},
+				Spec:       v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+				Status: v1.ServiceStatus{LoadBalancer: v1.LoadBalancerStatus{
+					Ingress: []v1.LoadBalancerIngress{{IP: "1.2.3.4"}},
+				}},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "web", Name: "internal", CreationTimestamp: metav1.NewTime(before)},
+				Spec:       v1.ServiceSpec{Type: v1.ServiceTypeClusterIP},
+			},
+		},
+	}
+
+	provider := &assetsProvider{
+		nodes: map[string]*cloud.Node{
+			"node1": {Cost: "1.0", UsageType: "spot"},
+			"node2": {VCPU: "2", VCPUCost: "0.1", RAMCost: "0.01"},
+			"node3": {Cost: "5.0"},
+		},
+		pvs: map[string]*cloud.PV{
+			"ssd": {Cost: "0.0002"},
+		},
+		lb:       &cloud.LoadBalancer{Cost: 0.025},
+		reserved: []string{"node2"},
+	}
+
+	cm := &CostModel{Cache: cache}
+
+	assets, err := cm.ComputeAssets(provider, kubecost.NewWindow(&start, &end))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := map[string]struct {
+		assetType  string
+		totalCost  float64
+		provenance cloud.PricingType
+		owner      string
+	}{
+		// 1.0/hr for 24 hours
+		"node1": {AssetTypeNode, 24.0, cloud.Spot, ""},
+		// 2*0.1 + 8*0.01 = 0.28/hr for 12 hours
+		"node2": {AssetTypeNode, 3.36, cloud.Reserved, ""},
+		// 100Gi at 0.0002/GiB-hr for 24 hours
+		"pv1": {AssetTypeDisk, 0.48, cloud.Api, "db/data"},
+		// 10Gi at the default 0.00005/GiB-hr for 24 hours
+		"pv2": {AssetTypeDisk, 0.012, cloud.DefaultPrices, ""},
+		// 0.025/hr for 24 hours
+		"web/frontend": {AssetTypeLoadBalancer, 0.6, cloud.Api, "web/frontend"},
+	}
+
+	if len(assets) != len(expected) {
+		t.Fatalf("expected %d assets; got %d", len(expected), len(assets))
+	}
+
+	total := 0.0
+	for _, asset := range assets {
+		e, ok := expected[asset.Name]
+		if !ok {
+			t.Fatalf("unexpected asset %s", asset.Name)
+		}
+		if asset.Type != e.assetType || asset.Provenance != e.provenance || asset.Owner != e.owner || asset.Provider != "AWS" {
+			t.Fatalf("unexpected asset %+v", asset)
+		}
+		if !util.IsApproximately(asset.TotalCost, e.totalCost) {
+			t.Fatalf("expected %s to cost %f; got %f", asset.Name, e.totalCost, asset.TotalCost)
+		}
+		total += asset.TotalCost
+	}
+
+	if assets[0].Name != "node1" {
+		t.Fatalf("expected assets to be sorted by cost; got %s first", assets[0].Name)
+	}
+
+	// The totals match the sum of the underlying prices
+	if !util.IsApproximately(total, 28.452) {
+		t.Fatalf("expected a total cost of 28.452; got %f", total)
+	}
+
+	totals, err := AggregateAssets(assets, []string{AssetAggregateType})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectedTotals := map[string][2]float64{
+		AssetTypeNode:         {2, 27.36},
+		AssetTypeDisk:         {2, 0.492},
+		AssetTypeLoadBalancer: {1, 0.6},
+	}
+	if len(totals) != len(expectedTotals) {
+		t.Fatalf("expected %d totals; got %d", len(expectedTotals), len(totals))
+	}
+	for _, at := range totals {
+		e := expectedTotals[at.Type]
+		if at.Count != int(e[0]) || !util.IsApproximately(at.TotalCost, e[1]) || at.Provider != "" {
+			t.Fatalf("expected %s total of %d assets costing %f; got %+v", at.Type, int(e[0]), e[1], at)
+		}
+	}
+
+	totals, err = AggregateAssets(assets, []string{AssetAggregateProvider, AssetAggregateCluster})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(totals) != 1 || totals[0].Provider != "AWS" || totals[0].Count != 5 || !util.IsApproximately(totals[0].TotalCost, total) {
+		t.Fatalf("expected a single AWS total of all assets; got %+v", totals)
+	}
+
+	if _, err := AggregateAssets(assets, []string{"namespace"}); err == nil {
+		t.Fatalf("expected an error aggregating by namespace")
+	}
+}
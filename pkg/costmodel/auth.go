@@ -0,0 +1,60 @@
+package costmodel
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/kubecost/cost-model/pkg/auth"
+	"github.com/kubecost/cost-model/pkg/env"
+)
+
+// authExemptPaths are the paths which never require authentication
+var authExemptPaths = []string{"/healthz", "/readyz"}
+
+// authAdminPaths are the paths which require the admin role, even to read
+var authAdminPaths = []string{"/config/dump", "/config/env"}
+
+// authReadOnlyPaths are the paths which require only the read-only role, even
+// to POST, because their requests do not mutate state
+var authReadOnlyPaths = []string{"/whatIf"}
+
+// newAuthMiddleware creates the authentication middleware configured by the
+// environment, which accepts static tokens, JWTs verified by a JWKS, or both.
+func newAuthMiddleware() (*auth.Middleware, error) {
+	var chain auth.ChainAuthenticator
+
+	if tokens := env.GetAuthStaticTokens(); tokens != "" {
+		parsed, err := auth.ParseStaticTokens(tokens)
+		if err != nil {
+			return nil, fmt.Errorf("invalid $%s: %s", env.AuthStaticTokensEnvVar, err)
+		}
+		chain = append(chain, auth.NewStaticTokenAuthenticator(parsed))
+	}
+
+	if url := env.GetAuthJWKSURL(); url != "" {
+		ja := auth.NewJWKSAuthenticator(url, env.GetAuthJWTRoleClaim())
+		ja.Issuer = env.GetAuthJWTIssuer()
+		ja.Audience = env.GetAuthJWTAudience()
+		chain = append(chain, ja)
+	}
+
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("$%s or $%s is required when $%s is true", env.AuthStaticTokensEnvVar, env.AuthJWKSURLEnvVar, env.AuthEnabledEnvVar)
+	}
+
+	m := auth.NewMiddleware(chain, authExemptPaths...)
+	m.AdminPaths = authAdminPaths
+	m.ReadOnlyPaths = authReadOnlyPaths
+
+	return m, nil
+}
+
+// WithAuth wraps the given handler in the authentication middleware, if
+// authentication is enabled. Otherwise, the handler is returned unchanged.
+func (a *Accesses) WithAuth(h http.Handler) http.Handler {
+	if a.AuthMiddleware == nil {
+		return h
+	}
+
+	return a.AuthMiddleware.Handler(h)
+}
@@ -0,0 +1,254 @@
+package budget
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/costmodel/clusters"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/log"
+)
+
+// RedactedURL replaces the URL of each notification target of the budgets sent in
+// alerts and returned by the API, since webhook URLs embed credentials
+const RedactedURL = "<redacted>"
+
+// DefaultThresholds are the fractions of a budget at which alerts are sent if
+// a budget does not define its own thresholds.
+var DefaultThresholds = []float64{0.8, 1.0}
+
+// Budget is a monthly spending limit for a cluster, or for a namespace within a
+// cluster. An empty ClusterID applies the budget to all clusters, and an empty
+// Namespace applies the budget to all namespaces. If Labels are set, only
+// workloads with all of the given labels count against the budget.
+type Budget struct {
+	ID            string               `json:"id"`
+	Name          string               `json:"name"`
+	ClusterID     string               `json:"clusterId"`
+	Namespace     string               `json:"namespace"`
+	Labels        map[string]string    `json:"labels,omitempty"`
+	Monthly       float64              `json:"monthly"`
+	Currency      string               `json:"currency"`
+	Thresholds    []float64            `json:"thresholds,omitempty"`
+	Notifications []NotificationTarget `json:"notifications,omitempty"`
+}
+
+// Validate returns an error if the budget cannot be evaluated
+func (b Budget) Validate() error {
+	if b.Monthly <= 0 {
+		return fmt.Errorf("monthly amount must be positive")
+	}
+
+	for _, t := range b.Thresholds {
+		if t <= 0 {
+			return fmt.Errorf("illegal threshold %f: thresholds must be positive", t)
+		}
+	}
+
+	for _, n := range b.Notifications {
+		if n.Type != NotificationTypeWebhook && n.Type != NotificationTypeSlack {
+			return fmt.Errorf("illegal notification type: %s", n.Type)
+		}
+		if n.URL == "" || n.URL == RedactedURL {
+			return fmt.Errorf("notification URL must be set")
+		}
+	}
+
+	return nil
+}
+
+// AlertThresholds returns the budget's thresholds in ascending order, or the
+// DefaultThresholds if none are set.
+func (b Budget) AlertThresholds() []float64 {
+	if len(b.Thresholds) == 0 {
+		return DefaultThresholds
+	}
+
+	thresholds := make([]float64, len(b.Thresholds))
+	copy(thresholds, b.Thresholds)
+	sort.Float64s(thresholds)
+
+	return thresholds
+}
+
+// Clone returns a deep copy of the budget
+func (b *Budget) Clone() *Budget {
+	if b == nil {
+		return nil
+	}
+
+	clone := *b
+
+	if b.Labels != nil {
+		clone.Labels = make(map[string]string, len(b.Labels))
+		for k, v := range b.Labels {
+			clone.Labels[k] = v
+		}
+	}
+
+	if b.Thresholds != nil {
+		clone.Thresholds = make([]float64, len(b.Thresholds))
+		copy(clone.Thresholds, b.Thresholds)
+	}
+
+	if b.Notifications != nil {
+		clone.Notifications = make([]NotificationTarget, len(b.Notifications))
+		copy(clone.Notifications, b.Notifications)
+	}
+
+	return &clone
+}
+
+// Scope returns the allocations which count against the budget
+func (b *Budget) Scope() *kubecost.AllocationScope {
+	return &kubecost.AllocationScope{
+		Cluster:   b.ClusterID,
+		Namespace: b.Namespace,
+		Labels:    b.Labels,
+	}
+}
+
+// Redacted returns a copy of the budget with the URL of each notification target
+// redacted
+func (b *Budget) Redacted() *Budget {
+	clone := b.Clone()
+	if clone == nil {
+		return nil
+	}
+
+	for i := range clone.Notifications {
+		clone.Notifications[i].URL = RedactedURL
+	}
+
+	return clone
+}
+
+// RestoreRedacted sets the URL of each redacted notification target to the URL of
+// the target of the same type at the same position of the existing budget, so that
+// a budget returned by the API can be written back unchanged.
+func (b *Budget) RestoreRedacted(existing *Budget) {
+	if existing == nil {
+		return
+	}
+
+	for i, n := range b.Notifications {
+		if n.URL != RedactedURL || i >= len(existing.Notifications) {
+			continue
+		}
+		if existing.Notifications[i].Type == n.Type {
+			b.Notifications[i].URL = existing.Notifications[i].URL
+		}
+	}
+}
+
+// String returns a human readable description of the budget's scope
+func (b Budget) String() string {
+	cluster := b.ClusterID
+	if cluster == "" {
+		cluster = "*"
+	}
+
+	namespace := b.Namespace
+	if namespace == "" {
+		namespace = "*"
+	}
+
+	return fmt.Sprintf("%s/%s", cluster, namespace)
+}
+
+// BudgetAlert reports the current-month spend against a Budget
+type BudgetAlert struct {
+	Budget   Budget  `json:"budget"`
+	Actual   float64 `json:"actual"`
+	Exceeded bool    `json:"exceeded"`
+}
+
+// BudgetChecker computes current-month spend from the allocation pipeline, and
+// compares it against a set of budgets.
+type BudgetChecker struct {
+	source     AllocationSource
+	provider   cloud.Provider
+	clusterMap clusters.ClusterMap
+}
+
+// NewBudgetChecker creates a new BudgetChecker which computes spend from the
+// allocations of the provided source.
+func NewBudgetChecker(source AllocationSource, provider cloud.Provider, clusterMap clusters.ClusterMap) *BudgetChecker {
+	return &BudgetChecker{
+		source:     source,
+		provider:   provider,
+		clusterMap: clusterMap,
+	}
+}
+
+// Check computes the current-month spend and returns a BudgetAlert for each budget
+// which has been exceeded.
+func (bc *BudgetChecker) Check(budgets []Budget) ([]BudgetAlert, error) {
+	as, err := bc.MonthToDate(time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	currency := "USD"
+	if bc.provider != nil {
+		if cfg, err := bc.provider.GetConfig(); err == nil && cfg.CurrencyCode != "" {
+			currency = cfg.CurrencyCode
+		}
+	}
+
+	checked := []Budget{}
+	for _, b := range budgets {
+		if b.Currency != "" && !strings.EqualFold(b.Currency, currency) {
+			log.Warningf("Budget %s: currency %s does not match pricing currency %s, skipping", b, b.Currency, currency)
+			continue
+		}
+
+		if b.ClusterID != "" && bc.clusterMap != nil && bc.clusterMap.InfoFor(b.ClusterID) == nil {
+			log.Warningf("Budget %s: unknown cluster %s", b, b.ClusterID)
+		}
+
+		checked = append(checked, b)
+	}
+
+	return exceededBudgets(checked, as), nil
+}
+
+// MonthToDate computes the allocations from the start of the month of now, in
+// UTC, until now.
+func (bc *BudgetChecker) MonthToDate(now time.Time) (*kubecost.AllocationSet, error) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if now.Sub(start) < time.Minute {
+		return kubecost.NewAllocationSet(start, now), nil
+	}
+
+	as, err := bc.source(start, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute allocations: %s", err)
+	}
+
+	return as, nil
+}
+
+// exceededBudgets returns a BudgetAlert for each budget whose spend exceeds its
+// monthly limit.
+func exceededBudgets(budgets []Budget, as *kubecost.AllocationSet) []BudgetAlert {
+	alerts := []BudgetAlert{}
+
+	for _, b := range budgets {
+		actual := budgetSpend(&b, as)
+
+		if actual > b.Monthly {
+			alerts = append(alerts, BudgetAlert{
+				Budget:   b,
+				Actual:   actual,
+				Exceeded: true,
+			})
+		}
+	}
+
+	return alerts
+}
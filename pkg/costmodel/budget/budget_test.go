@@ -0,0 +1,149 @@
+package budget
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/kubecost"
+)
+
+func TestExceededBudgets(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, time.March, 20, 0, 0, 0, 0, time.UTC)
+
+	// Each mock allocation has 1.0 each of GPU, RAM, PV, network, and load
+	// balancer cost in addition to its CPU cost
+	newAlloc := func(cluster, namespace string, cpuCost float64) *kubecost.Allocation {
+		alloc := kubecost.NewMockUnitAllocation(cluster+"/"+namespace+"/pod", start, end.Sub(start), &kubecost.AllocationProperties{
+			Cluster:   cluster,
+			Namespace: namespace,
+		})
+		alloc.CPUCost = cpuCost
+		return alloc
+	}
+
+	as := kubecost.NewAllocationSet(start, end,
+		newAlloc("cluster-a", "web", 595.0),
+		newAlloc("cluster-a", "batch", 295.0),
+		newAlloc("cluster-b", "web", 45.0),
+		newAlloc("cluster-b", "default", 20.0),
+	)
+
+	budgets := []Budget{
+		// namespace over budget
+		{ClusterID: "cluster-a", Namespace: "web", Monthly: 500.0},
+		// namespace under budget
+		{ClusterID: "cluster-a", Namespace: "batch", Monthly: 500.0},
+		// cluster over budget
+		{ClusterID: "cluster-a", Monthly: 800.0},
+		// cluster under budget
+		{ClusterID: "cluster-b", Monthly: 100.0},
+		// namespace across all clusters over budget
+		{Namespace: "web", Monthly: 600.0},
+		// spend exactly at budget is not exceeded
+		{ClusterID: "cluster-b", Namespace: "web", Monthly: 50.0},
+	}
+
+	alerts := exceededBudgets(budgets, as)
+	if len(alerts) != 3 {
+		t.Fatalf("expected 3 alerts; got %d: %+v", len(alerts), alerts)
+	}
+
+	expected := []struct {
+		budget string
+		actual float64
+	}{
+		{"cluster-a/web", 600.0},
+		{"cluster-a/*", 900.0},
+		{"*/web", 650.0},
+	}
+
+	for i, exp := range expected {
+		if alerts[i].Budget.String() != exp.budget {
+			t.Fatalf("expected alert %d for budget %s; got %s", i, exp.budget, alerts[i].Budget)
+		}
+		if alerts[i].Actual != exp.actual {
+			t.Fatalf("expected actual spend of %f for %s; got %f", exp.actual, exp.budget, alerts[i].Actual)
+		}
+		if !alerts[i].Exceeded {
+			t.Fatalf("expected %s to be exceeded", exp.budget)
+		}
+	}
+
+	if alerts := exceededBudgets(budgets, kubecost.NewAllocationSet(start, end)); len(alerts) != 0 {
+		t.Fatalf("expected no alerts without spend; got %d", len(alerts))
+	}
+}
+
+func TestBudgetCheckerMonthToDate(t *testing.T) {
+	var window kubecost.Window
+	bc := NewBudgetChecker(func(start, end time.Time) (*kubecost.AllocationSet, error) {
+		window = kubecost.NewWindow(&start, &end)
+		return kubecost.NewAllocationSet(start, end), nil
+	}, nil, nil)
+
+	now := time.Date(2021, time.March, 20, 12, 0, 0, 0, time.UTC)
+	if _, err := bc.MonthToDate(now); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !window.Start().Equal(time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)) || !window.End().Equal(now) {
+		t.Fatalf("expected allocations from the start of the month until now; got %s", window)
+	}
+}
+
+func TestBudgetRedacted(t *testing.T) {
+	b := &Budget{
+		ID:      "b",
+		Monthly: 100.0,
+		Notifications: []NotificationTarget{
+			{Type: NotificationTypeWebhook, URL: "https://example.com/hook?token=secret"},
+			{Type: NotificationTypeSlack, URL: "https://hooks.slack.com/services/secret"},
+		},
+	}
+
+	redacted := b.Redacted()
+	for _, n := range redacted.Notifications {
+		if n.URL != RedactedURL {
+			t.Fatalf("expected URL to be redacted; got %s", n.URL)
+		}
+	}
+	if b.Notifications[0].URL == RedactedURL {
+		t.Fatalf("expected the budget itself not to be redacted")
+	}
+	if err := redacted.Validate(); err == nil {
+		t.Fatalf("expected a redacted budget to be invalid")
+	}
+
+	// A budget read from the API, with a target added, is written back with the
+	// URLs of its existing targets
+	redacted.Notifications = append(redacted.Notifications, NotificationTarget{Type: NotificationTypeWebhook, URL: "https://example.com/new"})
+	redacted.RestoreRedacted(b)
+	if err := redacted.Validate(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for i, url := range []string{b.Notifications[0].URL, b.Notifications[1].URL, "https://example.com/new"} {
+		if redacted.Notifications[i].URL != url {
+			t.Errorf("expected URL %s; got %s", url, redacted.Notifications[i].URL)
+		}
+	}
+}
+
+func TestBudgetScopeLabels(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	alloc := kubecost.NewMockUnitAllocation("cluster-a/web/pod", start, 24*time.Hour, &kubecost.AllocationProperties{
+		Cluster:   "cluster-a",
+		Namespace: "web",
+		Labels:    map[string]string{"app_kubernetes_io_team": "x"},
+	})
+
+	b := &Budget{Labels: map[string]string{"app.kubernetes.io/team": "x"}, Monthly: 100.0}
+	if !b.Scope().Matches(alloc) {
+		t.Fatalf("expected the Kubernetes label name to match the sanitized label")
+	}
+
+	b.Labels["app.kubernetes.io/team"] = "y"
+	if b.Scope().Matches(alloc) {
+		t.Fatalf("expected a different label value not to match")
+	}
+}
@@ -0,0 +1,243 @@
+package budget
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/log"
+)
+
+// AllocationSource computes the allocations for the provided window
+type AllocationSource func(start, end time.Time) (*kubecost.AllocationSet, error)
+
+// BudgetStatus is the month-to-date consumption of a budget
+type BudgetStatus struct {
+	Budget            *Budget   `json:"budget"`
+	Month             string    `json:"month"`
+	Spend             float64   `json:"spend"`
+	Consumed          float64   `json:"consumed"`
+	ThresholdsCrossed []float64 `json:"thresholdsCrossed"`
+	EvaluatedAt       time.Time `json:"evaluatedAt"`
+}
+
+// Evaluator periodically computes the month-to-date spend of each budget and
+// sends alerts when a threshold is crossed. Each threshold is alerted at most
+// once per budget per month.
+type Evaluator struct {
+	// evalLock serializes evaluations, which compute spend and deliver alerts
+	// without holding lock, so that Status is not blocked while they run
+	evalLock *sync.Mutex
+	lock     *sync.Mutex
+	store    *BudgetStore
+	checker  *BudgetChecker
+	client   *http.Client
+	now      func() time.Time
+	alerted  map[string]map[float64]bool
+	statuses map[string]*BudgetStatus
+	stop     chan struct{}
+}
+
+// NewEvaluator creates a new Evaluator for the budgets in the provided store, whose
+// spend is computed by the provided checker
+func NewEvaluator(store *BudgetStore, checker *BudgetChecker) *Evaluator {
+	return &Evaluator{
+		evalLock: new(sync.Mutex),
+		lock:     new(sync.Mutex),
+		store:    store,
+		checker:  checker,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		now:      time.Now,
+		alerted:  make(map[string]map[float64]bool),
+		statuses: make(map[string]*BudgetStatus),
+	}
+}
+
+// Start evaluates budgets on the provided interval until Stop is called
+func (e *Evaluator) Start(interval time.Duration) {
+	e.lock.Lock()
+	if e.stop != nil {
+		e.lock.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	e.stop = stop
+	e.lock.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := e.Evaluate(); err != nil {
+				log.Errorf("Budget: evaluation failed: %s", err)
+			}
+
+			select {
+			case <-ticker.C:
+			case <-stop:
+				log.Infof("Budget evaluation stopped.")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops periodic evaluation
+func (e *Evaluator) Stop() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.stop == nil {
+		return
+	}
+
+	close(e.stop)
+	e.stop = nil
+}
+
+// Status returns the most recent status of each budget
+func (e *Evaluator) Status() []*BudgetStatus {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	statuses := []*BudgetStatus{}
+	for _, b := range e.store.GetAll() {
+		if status, ok := e.statuses[b.ID]; ok {
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses
+}
+
+// Evaluate computes the month-to-date spend of every budget, delivers alerts for
+// any newly crossed thresholds, and returns the alerts which were raised.
+func (e *Evaluator) Evaluate() ([]*ThresholdAlert, error) {
+	e.evalLock.Lock()
+	defer e.evalLock.Unlock()
+
+	budgets := e.store.GetAll()
+	if len(budgets) == 0 {
+		return []*ThresholdAlert{}, nil
+	}
+
+	now := e.now().UTC()
+	month := now.Format("2006-01")
+
+	as, err := e.checker.MonthToDate(now)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := []*ThresholdAlert{}
+
+	// The alerts and statuses returned carry redacted budgets, so the budgets
+	// whose targets are notified of each alert are kept alongside
+	alerted := []*Budget{}
+
+	e.lock.Lock()
+	for _, b := range budgets {
+		spend := budgetSpend(b, as)
+		consumed := spend / b.Monthly
+
+		status := &BudgetStatus{
+			Budget:            b.Redacted(),
+			Month:             month,
+			Spend:             spend,
+			Consumed:          consumed,
+			ThresholdsCrossed: []float64{},
+			EvaluatedAt:       now,
+		}
+		e.statuses[b.ID] = status
+
+		alertKey := b.ID + "/" + month
+		if _, ok := e.alerted[alertKey]; !ok {
+			e.alerted[alertKey] = make(map[float64]bool)
+		}
+
+		for _, threshold := range b.AlertThresholds() {
+			if consumed < threshold {
+				continue
+			}
+			status.ThresholdsCrossed = append(status.ThresholdsCrossed, threshold)
+
+			if e.alerted[alertKey][threshold] {
+				continue
+			}
+
+			alerted = append(alerted, b)
+			alerts = append(alerts, &ThresholdAlert{
+				Budget:    status.Budget,
+				Month:     month,
+				Threshold: threshold,
+				Spend:     spend,
+				Consumed:  consumed,
+				Time:      now,
+			})
+		}
+	}
+
+	// Forget alerts from previous months
+	for key := range e.alerted {
+		if len(key) < len(month) || key[len(key)-len(month):] != month {
+			delete(e.alerted, key)
+		}
+	}
+	e.lock.Unlock()
+
+	// Only mark a threshold as alerted once every target has been notified, so
+	// failed deliveries are retried on the next evaluation
+	for i, alert := range alerts {
+		if !e.notify(alerted[i], alert) {
+			continue
+		}
+
+		e.lock.Lock()
+		if thresholds, ok := e.alerted[alert.Budget.ID+"/"+month]; ok {
+			thresholds[alert.Threshold] = true
+		}
+		e.lock.Unlock()
+	}
+
+	return alerts, nil
+}
+
+// notify delivers the alert to each of the budget's notification targets,
+// returning true if every delivery succeeded.
+func (e *Evaluator) notify(b *Budget, alert *ThresholdAlert) bool {
+	ok := true
+
+	for _, target := range b.Notifications {
+		notifier, err := NewNotifier(e.client, target)
+		if err != nil {
+			log.Warningf("Budget %s: %s", b, err)
+			continue
+		}
+
+		if err := notifier.Notify(alert); err != nil {
+			log.Warningf("Budget %s: failed to deliver alert: %s", b, err)
+			ok = false
+		}
+	}
+
+	return ok
+}
+
+// budgetSpend returns the total cost of the allocations within the budget's scope
+func budgetSpend(b *Budget, as *kubecost.AllocationSet) float64 {
+	spend := 0.0
+	if as == nil {
+		return spend
+	}
+
+	scope := b.Scope()
+	as.Each(func(name string, alloc *kubecost.Allocation) {
+		if scope.Matches(alloc) {
+			spend += alloc.TotalCost()
+		}
+	})
+
+	return spend
+}
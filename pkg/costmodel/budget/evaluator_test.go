@@ -0,0 +1,256 @@
+package budget
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+func newTestStore(t *testing.T) *BudgetStore {
+	dir, err := ioutil.TempDir("", "budgets")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	store, err := NewBudgetStore(filepath.Join(dir, BudgetsFile))
+	if err != nil {
+		t.Fatalf("failed to create budget store: %s", err)
+	}
+
+	return store
+}
+
+func TestEvaluatorCrossesThresholds(t *testing.T) {
+	lock := new(sync.Mutex)
+	webhooks := []map[string]interface{}{}
+	slacks := []map[string]interface{}{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload := map[string]interface{}{}
+		data, _ := ioutil.ReadAll(r.Body)
+		if err := json.Unmarshal(data, &payload); err != nil {
+			t.Errorf("failed to decode notification: %s", err)
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		if r.URL.Path == "/slack" {
+			slacks = append(slacks, payload)
+		} else {
+			webhooks = append(webhooks, payload)
+		}
+	}))
+	defer server.Close()
+
+	store := newTestStore(t)
+	b, err := store.AddOrUpdate(&Budget{
+		Name:      "web",
+		ClusterID: "cluster-a",
+		Namespace: "web",
+		Monthly:   100.0,
+		Notifications: []NotificationTarget{
+			{Type: NotificationTypeWebhook, URL: server.URL + "/webhook"},
+			{Type: NotificationTypeSlack, URL: server.URL + "/slack"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to add budget: %s", err)
+	}
+
+	now := time.Date(2021, time.March, 20, 12, 0, 0, 0, time.UTC)
+
+	source := func(start, end time.Time) (*kubecost.AllocationSet, error) {
+		if !start.Equal(time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)) {
+			t.Fatalf("expected evaluation to start at the beginning of the month; got %s", start)
+		}
+
+		web := kubecost.NewMockUnitAllocation("cluster-a/web/pod", start, end.Sub(start), &kubecost.AllocationProperties{
+			Cluster:   "cluster-a",
+			Namespace: "web",
+		})
+		web.CPUCost = 120.0
+
+		other := kubecost.NewMockUnitAllocation("cluster-a/batch/pod", start, end.Sub(start), &kubecost.AllocationProperties{
+			Cluster:   "cluster-a",
+			Namespace: "batch",
+		})
+		other.CPUCost = 500.0
+
+		idle := kubecost.NewMockUnitAllocation("cluster-a/"+kubecost.IdleSuffix, start, end.Sub(start), &kubecost.AllocationProperties{
+			Cluster: "cluster-a",
+		})
+		idle.CPUCost = 1000.0
+
+		return kubecost.NewAllocationSet(start, end, web, other, idle), nil
+	}
+
+	e := NewEvaluator(store, NewBudgetChecker(source, nil, nil))
+	e.now = func() time.Time { return now }
+
+	// Spend jumps straight past both 80% and 100% in a single evaluation
+	alerts, err := e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("expected 2 alerts; got %d", len(alerts))
+	}
+	if alerts[0].Threshold != 0.8 || alerts[1].Threshold != 1.0 {
+		t.Fatalf("expected alerts at 0.8 and 1.0; got %f and %f", alerts[0].Threshold, alerts[1].Threshold)
+	}
+
+	// 120.0 CPU plus 1.0 each of GPU, RAM, PV, network, and load balancer
+	// cost; other namespaces and idle are excluded
+	if spend := alerts[0].Spend; spend != 125.0 {
+		t.Fatalf("expected spend of 125.0 for the web namespace; got %f", spend)
+	}
+
+	lock.Lock()
+	if len(webhooks) != 2 || len(slacks) != 2 {
+		t.Fatalf("expected 2 webhook and 2 slack notifications; got %d and %d", len(webhooks), len(slacks))
+	}
+	if _, ok := slacks[0]["text"]; !ok {
+		t.Fatalf("expected slack payload to contain text; got %v", slacks[0])
+	}
+	if webhooks[1]["threshold"] != 1.0 {
+		t.Fatalf("expected webhook payload threshold of 1.0; got %v", webhooks[1]["threshold"])
+	}
+	if payload, _ := json.Marshal(webhooks[1]); strings.Contains(string(payload), server.URL) {
+		t.Fatalf("expected notification URLs to be redacted from the webhook payload; got %s", payload)
+	}
+	lock.Unlock()
+
+	// Thresholds which have already been alerted are not alerted again
+	alerts, err = e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(alerts) != 0 {
+		t.Fatalf("expected no repeated alerts; got %d", len(alerts))
+	}
+
+	lock.Lock()
+	if len(webhooks) != 2 || len(slacks) != 2 {
+		t.Fatalf("expected no further notifications; got %d and %d", len(webhooks), len(slacks))
+	}
+	lock.Unlock()
+
+	statuses := e.Status()
+	if len(statuses) != 1 || statuses[0].Budget.ID != b.ID {
+		t.Fatalf("expected status for budget %s; got %v", b.ID, statuses)
+	}
+	if statuses[0].Budget.Notifications[0].URL != RedactedURL {
+		t.Fatalf("expected notification URLs to be redacted from the status; got %s", statuses[0].Budget.Notifications[0].URL)
+	}
+	if len(statuses[0].ThresholdsCrossed) != 2 {
+		t.Fatalf("expected 2 thresholds crossed; got %v", statuses[0].ThresholdsCrossed)
+	}
+
+	// A new month resets the alerts
+	now = time.Date(2021, time.April, 20, 12, 0, 0, 0, time.UTC)
+	e.checker.source = func(start, end time.Time) (*kubecost.AllocationSet, error) {
+		return source(time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC), end)
+	}
+	alerts, err = e.Evaluate()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("expected 2 alerts in a new month; got %d", len(alerts))
+	}
+}
+
+func TestEvaluatorStatusDuringEvaluation(t *testing.T) {
+	store := newTestStore(t)
+	if _, err := store.AddOrUpdate(&Budget{Name: "all", Monthly: 100.0}); err != nil {
+		t.Fatalf("failed to add budget: %s", err)
+	}
+
+	computing := make(chan struct{})
+	release := make(chan struct{})
+	source := func(start, end time.Time) (*kubecost.AllocationSet, error) {
+		close(computing)
+		<-release
+		return kubecost.NewAllocationSet(start, end), nil
+	}
+
+	e := NewEvaluator(store, NewBudgetChecker(source, nil, nil))
+	e.now = func() time.Time { return time.Date(2021, time.March, 20, 12, 0, 0, 0, time.UTC) }
+
+	done := make(chan error)
+	go func() {
+		_, err := e.Evaluate()
+		done <- err
+	}()
+
+	// Status does not wait for spend to be computed
+	<-computing
+	statusCh := make(chan []*BudgetStatus)
+	go func() { statusCh <- e.Status() }()
+	select {
+	case statuses := <-statusCh:
+		if len(statuses) != 0 {
+			t.Fatalf("expected no statuses before the first evaluation; got %d", len(statuses))
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected Status not to block on evaluation")
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if statuses := e.Status(); len(statuses) != 1 {
+		t.Fatalf("expected 1 status after evaluation; got %d", len(statuses))
+	}
+}
+
+func TestBudgetStore(t *testing.T) {
+	store := newTestStore(t)
+
+	if _, err := store.AddOrUpdate(&Budget{Name: "invalid"}); err == nil {
+		t.Fatalf("expected error adding budget without a monthly amount")
+	}
+
+	b, err := store.AddOrUpdate(&Budget{Name: "prod", ClusterID: "cluster-a", Monthly: 1000.0})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if b.ID == "" {
+		t.Fatalf("expected budget to be assigned an id")
+	}
+
+	b.Monthly = 2000.0
+	if _, err := store.AddOrUpdate(b); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Budgets are loaded from the backing file
+	reloaded, err := NewBudgetStore(store.path)
+	if err != nil {
+		t.Fatalf("failed to reload budget store: %s", err)
+	}
+	if all := reloaded.GetAll(); len(all) != 1 || all[0].Monthly != 2000.0 {
+		t.Fatalf("expected 1 budget with a monthly amount of 2000; got %v", all)
+	}
+
+	if err := store.Remove(b.ID); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if store.Get(b.ID) != nil {
+		t.Fatalf("expected budget to be removed")
+	}
+	if err := store.Remove(b.ID); err == nil {
+		t.Fatalf("expected error removing a missing budget")
+	}
+}
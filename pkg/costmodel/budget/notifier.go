@@ -0,0 +1,122 @@
+package budget
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+const (
+	// NotificationTypeWebhook posts the ThresholdAlert as JSON to a URL
+	NotificationTypeWebhook = "webhook"
+
+	// NotificationTypeSlack posts a Slack-compatible message payload to a URL
+	NotificationTypeSlack = "slack"
+)
+
+// NotificationTarget is a destination for a budget's alerts
+type NotificationTarget struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+// ThresholdAlert is sent when a budget's month-to-date spend crosses one of its
+// alert thresholds.
+type ThresholdAlert struct {
+	Budget    *Budget   `json:"budget"`
+	Month     string    `json:"month"`
+	Threshold float64   `json:"threshold"`
+	Spend     float64   `json:"spend"`
+	Consumed  float64   `json:"consumed"`
+	Time      time.Time `json:"time"`
+}
+
+// Message returns a human readable description of the alert
+func (ta *ThresholdAlert) Message() string {
+	name := ta.Budget.Name
+	if name == "" {
+		name = ta.Budget.String()
+	}
+
+	return fmt.Sprintf("Budget %s has reached %.0f%% of its %.2f %s monthly budget for %s (spend: %.2f, threshold: %.0f%%)",
+		name, ta.Consumed*100.0, ta.Budget.Monthly, ta.Budget.Currency, ta.Month, ta.Spend, ta.Threshold*100.0)
+}
+
+// Notifier delivers threshold alerts
+type Notifier interface {
+	Notify(alert *ThresholdAlert) error
+}
+
+// WebhookNotifier posts alerts as JSON to a generic webhook
+type WebhookNotifier struct {
+	Client *http.Client
+	URL    string
+}
+
+// Notify posts the alert to the webhook, with the budget's notification targets
+// redacted
+func (wn *WebhookNotifier) Notify(alert *ThresholdAlert) error {
+	redacted := *alert
+	redacted.Budget = alert.Budget.Redacted()
+
+	payload := struct {
+		*ThresholdAlert
+		Message string `json:"message"`
+	}{
+		ThresholdAlert: &redacted,
+		Message:        alert.Message(),
+	}
+
+	return postJSON(wn.Client, wn.URL, payload)
+}
+
+// SlackNotifier posts alerts using a Slack-compatible incoming webhook payload
+type SlackNotifier struct {
+	Client *http.Client
+	URL    string
+}
+
+// Notify posts the alert to the Slack webhook
+func (sn *SlackNotifier) Notify(alert *ThresholdAlert) error {
+	payload := map[string]string{
+		"text": alert.Message(),
+	}
+
+	return postJSON(sn.Client, sn.URL, payload)
+}
+
+// NewNotifier creates a Notifier for the provided target
+func NewNotifier(client *http.Client, target NotificationTarget) (Notifier, error) {
+	switch target.Type {
+	case NotificationTypeWebhook:
+		return &WebhookNotifier{Client: client, URL: target.URL}, nil
+	case NotificationTypeSlack:
+		return &SlackNotifier{Client: client, URL: target.URL}, nil
+	default:
+		return nil, fmt.Errorf("illegal notification type: %s", target.Type)
+	}
+}
+
+// postJSON posts the JSON encoding of payload to url, returning an error for any
+// non-2xx response.
+func postJSON(client *http.Client, url string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("notification to %s failed with status: %s", url, resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+package budget
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/util/atomicfile"
+	"github.com/kubecost/cost-model/pkg/util/fileutil"
+)
+
+// BudgetsFile is the name of the file, in the config path, budgets are stored in
+const BudgetsFile = "budgets.json"
+
+// BudgetStore persists budgets as JSON in the config storage.
+type BudgetStore struct {
+	lock    *sync.RWMutex
+	path    string
+	budgets map[string]*Budget
+}
+
+// NewBudgetStore creates a new BudgetStore backed by the file at the provided path,
+// loading any existing budgets.
+func NewBudgetStore(path string) (*BudgetStore, error) {
+	bs := &BudgetStore{
+		lock:    new(sync.RWMutex),
+		path:    path,
+		budgets: make(map[string]*Budget),
+	}
+
+	exists, err := fileutil.FileExists(path)
+	if err != nil {
+		return bs, err
+	}
+	if !exists {
+		return bs, nil
+	}
+
+	var budgets []*Budget
+	err = atomicfile.ReadJSON(path, &budgets)
+	if err != nil {
+		return bs, fmt.Errorf("failed to decode budgets file %s: %s", path, err)
+	}
+
+	for _, b := range budgets {
+		if b.ID == "" {
+			log.Warningf("BudgetStore: skipping budget without an id in %s", path)
+			continue
+		}
+		bs.budgets[b.ID] = b
+	}
+
+	return bs, nil
+}
+
+// GetAll returns copies of all budgets, sorted by name
+func (bs *BudgetStore) GetAll() []*Budget {
+	bs.lock.RLock()
+	defer bs.lock.RUnlock()
+
+	budgets := make([]*Budget, 0, len(bs.budgets))
+	for _, b := range bs.budgets {
+		budgets = append(budgets, b.Clone())
+	}
+
+	sort.SliceStable(budgets, func(i, j int) bool {
+		if budgets[i].Name == budgets[j].Name {
+			return budgets[i].ID < budgets[j].ID
+		}
+		return budgets[i].Name < budgets[j].Name
+	})
+
+	return budgets
+}
+
+// Get returns a copy of the budget with the provided id, or nil if it doesn't exist
+func (bs *BudgetStore) Get(id string) *Budget {
+	bs.lock.RLock()
+	defer bs.lock.RUnlock()
+
+	return bs.budgets[id].Clone()
+}
+
+// AddOrUpdate adds the budget, or replaces the existing budget with the same id. A
+// budget without an id is assigned one.
+func (bs *BudgetStore) AddOrUpdate(b *Budget) (*Budget, error) {
+	if b == nil {
+		return nil, fmt.Errorf("budget must not be nil")
+	}
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+
+	b = b.Clone()
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+
+	prev, hadPrev := bs.budgets[b.ID]
+	bs.budgets[b.ID] = b
+
+	if err := bs.save(); err != nil {
+		if hadPrev {
+			bs.budgets[b.ID] = prev
+		} else {
+			delete(bs.budgets, b.ID)
+		}
+		return nil, err
+	}
+
+	return b.Clone(), nil
+}
+
+// Remove deletes the budget with the provided id
+func (bs *BudgetStore) Remove(id string) error {
+	bs.lock.Lock()
+	defer bs.lock.Unlock()
+
+	prev, ok := bs.budgets[id]
+	if !ok {
+		return fmt.Errorf("budget not found: %s", id)
+	}
+
+	delete(bs.budgets, id)
+
+	if err := bs.save(); err != nil {
+		bs.budgets[id] = prev
+		return err
+	}
+
+	return nil
+}
+
+// save writes all budgets to the backing file. The caller must hold the lock.
+func (bs *BudgetStore) save() error {
+	budgets := make([]*Budget, 0, len(bs.budgets))
+	for _, b := range bs.budgets {
+		budgets = append(budgets, b)
+	}
+
+	return atomicfile.WriteJSON(bs.path, budgets, 0644)
+}
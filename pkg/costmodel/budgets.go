@@ -0,0 +1,125 @@
+package costmodel
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/cost-model/pkg/costmodel/budget"
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+// initBudgets loads budgets from the config path and starts evaluating them
+// against month-to-date spend on the configured interval.
+func (a *Accesses) initBudgets() {
+	storePath := path.Join(env.GetConfigPathWithDefault("/models/"), budget.BudgetsFile)
+
+	store, err := budget.NewBudgetStore(storePath)
+	if err != nil {
+		log.Errorf("Failed to load budgets from %s: %s", storePath, err)
+	}
+
+	source := func(start, end time.Time) (*kubecost.AllocationSet, error) {
+		return a.Model.ComputeAllocation(start, end, env.GetETLResolution())
+	}
+
+	a.BudgetStore = store
+	a.BudgetEvaluator = budget.NewEvaluator(store, budget.NewBudgetChecker(source, a.CloudProvider, a.ClusterMap))
+	a.BudgetEvaluator.Start(env.GetBudgetEvaluationInterval())
+}
+
+// GetBudgetsHandler returns all budgets
+func (a *Accesses) GetBudgetsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	budgets := []*budget.Budget{}
+	for _, b := range a.BudgetStore.GetAll() {
+		budgets = append(budgets, b.Redacted())
+	}
+
+	w.Write(WrapData(budgets, nil))
+}
+
+// GetBudgetHandler returns the budget with the id provided in the path
+func (a *Accesses) GetBudgetHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	b := a.BudgetStore.Get(ps.ByName("id"))
+	if b == nil {
+		WriteError(w, NotFound())
+		return
+	}
+
+	w.Write(WrapData(b.Redacted(), nil))
+}
+
+// PutBudgetHandler creates a budget, or updates the budget with the id provided
+// in the request body.
+func (a *Accesses) PutBudgetHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	b := &budget.Budget{}
+	err = json.Unmarshal(data, b)
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("invalid budget: %s", err)))
+		return
+	}
+
+	// Notification URLs are redacted from responses, so a budget which was read
+	// from the API keeps the URLs of its existing targets
+	if b.ID != "" {
+		b.RestoreRedacted(a.BudgetStore.Get(b.ID))
+	}
+
+	if err := b.Validate(); err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("invalid budget: %s", err)))
+		return
+	}
+
+	b, err = a.BudgetStore.AddOrUpdate(b)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	w.Write(WrapData(b.Redacted(), nil))
+}
+
+// DeleteBudgetHandler removes the budget with the id provided in the path
+func (a *Accesses) DeleteBudgetHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := ps.ByName("id")
+	if a.BudgetStore.Get(id) == nil {
+		WriteError(w, NotFound())
+		return
+	}
+
+	if err := a.BudgetStore.Remove(id); err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	w.Write(WrapData("success", nil))
+}
+
+// BudgetStatusHandler returns the month-to-date consumption of each budget as of
+// the most recent evaluation.
+func (a *Accesses) BudgetStatusHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	w.Write(WrapData(a.BudgetEvaluator.Status(), nil))
+}
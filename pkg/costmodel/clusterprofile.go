@@ -0,0 +1,119 @@
+package costmodel
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/log"
+)
+
+// The sources from which the cluster profile is resolved
+const (
+	ClusterProfileSourceProvider    = "provider"
+	ClusterProfileSourceEnvironment = "environment"
+	ClusterProfileSourceDefault     = "default"
+)
+
+// ClusterProfileReport describes the cluster profile resolved at startup, and
+// the defaults of settings which it changed.
+type ClusterProfileReport struct {
+	Profile string `json:"profile"`
+	Source  string `json:"source"`
+
+	// Defaults are the defaults of settings of the profile which took effect
+	Defaults map[string]string `json:"defaults"`
+
+	// Overridden are the settings whose profile defaults did not take effect,
+	// because they are explicitly set
+	Overridden []string `json:"overridden"`
+
+	Error string `json:"error,omitempty"`
+}
+
+// resolveClusterProfile returns the cluster profile and its source, from the
+// provider's cluster info if it has one, and otherwise from the environment.
+func resolveClusterProfile(provider cloud.Provider) (string, string) {
+	if provider != nil {
+		info, err := provider.ClusterInfo()
+		if err == nil {
+			if profile := info["clusterProfile"]; profile != "" {
+				return profile, ClusterProfileSourceProvider
+			}
+		}
+	}
+
+	if profile := env.Get(env.ClusterProfileEnvVar, ""); profile != "" {
+		return profile, ClusterProfileSourceEnvironment
+	}
+
+	return env.GetClusterProfile(), ClusterProfileSourceDefault
+}
+
+// applyClusterProfile resolves the cluster profile, and applies its defaults
+// of settings unless the profile is the default, so that clusters which have
+// not chosen a profile are unchanged. The profile is then reported in the
+// local cluster info.
+func applyClusterProfile(provider cloud.Provider) *ClusterProfileReport {
+	profile, source := resolveClusterProfile(provider)
+
+	report := &ClusterProfileReport{
+		Profile:    profile,
+		Source:     source,
+		Defaults:   map[string]string{},
+		Overridden: []string{},
+	}
+
+	if normalized, ok := env.NormalizeClusterProfile(profile); ok {
+		report.Profile = normalized
+	}
+	clusterProfile = report.Profile
+
+	if source == ClusterProfileSourceDefault {
+		return report
+	}
+
+	defaults, overridden, err := env.ApplyClusterProfile(profile)
+	if err != nil {
+		report.Error = err.Error()
+		return report
+	}
+	report.Defaults = defaults
+	report.Overridden = overridden
+
+	return report
+}
+
+// Log writes the effective profile, and the defaults which it changed, to the
+// log as a single entry.
+func (cpr *ClusterProfileReport) Log() {
+	if cpr.Error != "" {
+		log.Warningf("Cluster profile '%s' from %s not applied: %s", cpr.Profile, cpr.Source, cpr.Error)
+		return
+	}
+
+	keys := make([]string, 0, len(cpr.Defaults))
+	for key := range cpr.Defaults {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	changed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		changed = append(changed, key+"="+cpr.Defaults[key])
+	}
+
+	log.Infof("Cluster profile '%s' from %s: defaults applied: [%s]; explicitly set: [%s]", cpr.Profile, cpr.Source, strings.Join(changed, ", "), strings.Join(cpr.Overridden, ", "))
+}
+
+// GetClusterProfile returns the report of the cluster profile resolved at
+// startup.
+func (a *Accesses) GetClusterProfile(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	w.Write(WrapData(a.ClusterProfile, nil))
+}
@@ -0,0 +1,68 @@
+package costmodel
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/env"
+)
+
+type profileProvider struct {
+	cloud.Provider
+	profile string
+}
+
+func (p *profileProvider) ClusterInfo() (map[string]string, error) {
+	return map[string]string{"clusterProfile": p.profile}, nil
+}
+
+func TestApplyClusterProfile(t *testing.T) {
+	previous := clusterProfile
+	defer func() {
+		clusterProfile = previous
+		env.ApplyClusterProfile(env.ProductionClusterProfile)
+		os.Unsetenv(env.ClusterProfileEnvVar)
+	}()
+
+	cases := map[string]struct {
+		provider string
+		env      string
+		profile  string
+		source   string
+		applied  bool
+		err      bool
+	}{
+		"default":              {"", "", env.DevelopmentClusterProfile, ClusterProfileSourceDefault, false, false},
+		"environment":          {"", "dev", env.DevelopmentClusterProfile, ClusterProfileSourceEnvironment, true, false},
+		"provider over env":    {"production", "dev", env.ProductionClusterProfile, ClusterProfileSourceProvider, false, false},
+		"provider development": {"development", "", env.DevelopmentClusterProfile, ClusterProfileSourceProvider, true, false},
+		"unknown":              {"", "staging", "staging", ClusterProfileSourceEnvironment, false, true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			env.ApplyClusterProfile(env.ProductionClusterProfile)
+			if c.env == "" {
+				os.Unsetenv(env.ClusterProfileEnvVar)
+			} else {
+				os.Setenv(env.ClusterProfileEnvVar, c.env)
+			}
+
+			report := applyClusterProfile(&profileProvider{profile: c.provider})
+
+			if report.Profile != c.profile || report.Source != c.source {
+				t.Fatalf("expected profile '%s' from %s; got '%s' from %s", c.profile, c.source, report.Profile, report.Source)
+			}
+			if (report.Error != "") != c.err {
+				t.Fatalf("unexpected error state: '%s'", report.Error)
+			}
+			if (len(report.Defaults) > 0) != c.applied {
+				t.Fatalf("expected defaults applied to be %t; got %v", c.applied, report.Defaults)
+			}
+			if clusterProfile != c.profile {
+				t.Fatalf("expected cluster info to report profile '%s'; got '%s'", c.profile, clusterProfile)
+			}
+		})
+	}
+}
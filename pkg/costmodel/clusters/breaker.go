@@ -0,0 +1,95 @@
+package clusters
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errLoadCircuitOpen is returned by loads which fail fast because the circuit is open
+var errLoadCircuitOpen = errors.New("circuit open after consecutive failures to load cluster info")
+
+// loadBreaker is a counter based circuit breaker around loading cluster info from
+// Prometheus. It opens after threshold consecutive failures, after which loads fail fast
+// until cooldown elapses. A single attempt, without retries, is then allowed: its success
+// closes the circuit, and its failure keeps it open for another cooldown. A nil loadBreaker
+// is always closed.
+type loadBreaker struct {
+	lock      sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	now       func() time.Time
+}
+
+// newLoadBreaker creates a closed loadBreaker. A non-positive threshold never opens.
+func newLoadBreaker(threshold int, cooldown time.Duration) *loadBreaker {
+	return &loadBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// open returns true if the circuit is open. The lock must be held.
+func (lb *loadBreaker) open() bool {
+	return lb.threshold > 0 && lb.failures >= lb.threshold
+}
+
+// allow returns the number of attempts a load may make, and false if the load must fail
+// fast because the circuit is open.
+func (lb *loadBreaker) allow() (int, bool) {
+	if lb == nil {
+		return LoadRetries, true
+	}
+
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
+
+	if !lb.open() {
+		return LoadRetries, true
+	}
+	if lb.now().Sub(lb.openedAt) < lb.cooldown {
+		return 0, false
+	}
+	return 1, true
+}
+
+// success records a successful load, closing the circuit
+func (lb *loadBreaker) success() {
+	if lb == nil {
+		return
+	}
+
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
+
+	if lb.open() {
+		clustersLog.Infof("Loaded cluster info after %d consecutive failures; closing circuit", lb.failures)
+	}
+	lb.failures = 0
+	lb.openedAt = time.Time{}
+}
+
+// failure records a failed load, opening the circuit, or restarting its cooldown, once
+// the threshold of consecutive failures is reached
+func (lb *loadBreaker) failure() {
+	if lb == nil {
+		return
+	}
+
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
+
+	wasOpen := lb.open()
+	lb.failures++
+	if !lb.open() {
+		return
+	}
+
+	lb.openedAt = lb.now()
+	if !wasOpen {
+		clustersLog.Warningf("Failed to load cluster info %d consecutive times; failing fast for %s", lb.failures, lb.cooldown)
+	}
+}
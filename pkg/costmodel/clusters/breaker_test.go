@@ -0,0 +1,56 @@
+package clusters
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadBreaker(t *testing.T) {
+	now := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	lb := newLoadBreaker(3, time.Minute)
+	lb.now = func() time.Time { return now }
+
+	// Closed, loads retry
+	for i := 0; i < 3; i++ {
+		if attempts, ok := lb.allow(); !ok || attempts != LoadRetries {
+			t.Fatalf("expected %d attempts while closed; got %d, %t", LoadRetries, attempts, ok)
+		}
+		lb.failure()
+	}
+
+	// Open, loads fail fast until the cooldown elapses
+	if _, ok := lb.allow(); ok {
+		t.Fatalf("expected loads to fail fast after 3 failures")
+	}
+	now = now.Add(59 * time.Second)
+	if _, ok := lb.allow(); ok {
+		t.Fatalf("expected loads to fail fast before the cooldown elapses")
+	}
+
+	// Half open, a single attempt, whose failure restarts the cooldown
+	now = now.Add(time.Second)
+	if attempts, ok := lb.allow(); !ok || attempts != 1 {
+		t.Fatalf("expected a single attempt after the cooldown; got %d, %t", attempts, ok)
+	}
+	lb.failure()
+	if _, ok := lb.allow(); ok {
+		t.Fatalf("expected loads to fail fast after a failed attempt")
+	}
+
+	// Success closes the circuit
+	now = now.Add(time.Minute)
+	if _, ok := lb.allow(); !ok {
+		t.Fatalf("expected an attempt after the cooldown")
+	}
+	lb.success()
+	if attempts, ok := lb.allow(); !ok || attempts != LoadRetries {
+		t.Fatalf("expected %d attempts after success; got %d, %t", LoadRetries, attempts, ok)
+	}
+
+	// A nil breaker is always closed
+	var nilBreaker *loadBreaker
+	nilBreaker.failure()
+	if attempts, ok := nilBreaker.allow(); !ok || attempts != LoadRetries {
+		t.Errorf("expected a nil breaker to be closed; got %d, %t", attempts, ok)
+	}
+}
@@ -2,7 +2,10 @@ package clusters
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"net/http"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -16,17 +19,140 @@ import (
 	prometheus "github.com/prometheus/client_golang/api"
 )
 
+// clustersLog writes the logs of the cluster map, whose level can be set with
+// LOG_LEVEL_CLUSTERS
+var clustersLog = log.WithModule("clusters")
+
 const (
-	LoadRetries    int           = 6
-	LoadRetryDelay time.Duration = 10 * time.Second
+	LoadRetries       int           = 6
+	LoadRetryDelay    time.Duration = 10 * time.Second
+	LoadRetryMaxDelay time.Duration = 30 * time.Second
+
+	// LoadCircuitBreakerThreshold is the number of consecutive failed loads after which
+	// loads fail fast, without querying Prometheus, until LoadCircuitBreakerCooldown elapses
+	LoadCircuitBreakerThreshold int           = 3
+	LoadCircuitBreakerCooldown  time.Duration = 5 * time.Minute
+
+	// DefaultClusterInfoMetricName is the name of the metric cluster info is loaded from
+	DefaultClusterInfoMetricName string = "kubecost_cluster_info"
 )
 
+// The ClusterInfo fields which may be mapped to labels of the cluster info metric
+const (
+	ClusterInfoIDField          = "id"
+	ClusterInfoNameField        = "name"
+	ClusterInfoProfileField     = "profile"
+	ClusterInfoProviderField    = "provider"
+	ClusterInfoProvisionerField = "provisioner"
+	ClusterInfoRegionField      = "region"
+)
+
+// DefaultClusterInfoFieldMapping returns the labels of the cluster info metric
+// each ClusterInfo field is loaded from by default
+func DefaultClusterInfoFieldMapping() map[string]string {
+	return map[string]string{
+		ClusterInfoIDField:          "id",
+		ClusterInfoNameField:        "name",
+		ClusterInfoProfileField:     "clusterprofile",
+		ClusterInfoProviderField:    "provider",
+		ClusterInfoProvisionerField: "provisioner",
+		ClusterInfoRegionField:      "region",
+	}
+}
+
+// ClusterMapOpts contains the options used to configure a PrometheusClusterMap.
+type ClusterMapOpts struct {
+	// ClusterInfoMetricName is the name of the metric cluster info is loaded from. This
+	// allows multiple installations which rename the metric to share a single Prometheus.
+	ClusterInfoMetricName string
+
+	// HTTPSDEndpoint is the URL of an optional Prometheus HTTP SD endpoint listing clusters,
+	// which are merged with the clusters loaded from metrics. Clusters loaded from metrics
+	// take precedence.
+	HTTPSDEndpoint string
+
+	// IDNormalizeFn is an optional function which normalizes cluster IDs, e.g. to a common
+	// format across Prometheus setups which format them differently. When set, it is applied
+	// to each loaded cluster ID, and to the cluster IDs provided to lookups.
+	IDNormalizeFn func(string) string
+
+	// EtcdEndpoints are the URLs of optional etcd endpoints, e.g. "http://etcd-0.etcd:2379",
+	// in which the cluster map is persisted after each refresh, so that it can be loaded at
+	// startup if Prometheus is unavailable.
+	EtcdEndpoints []string
+
+	// EtcdPrefix is the prefix of the etcd keys of the cluster map, each of which is suffixed
+	// by a cluster ID. It defaults to DefaultEtcdPrefix.
+	EtcdPrefix string
+
+	// FieldMapping maps each ClusterInfo field, e.g. ClusterInfoIDField, to the label of the
+	// cluster info metric it is loaded from, for installations which rename the labels; e.g.
+	// {"id": "cluster_id"}. Fields which are not mapped are loaded from their default label.
+	FieldMapping map[string]string
+
+	// TLSConfig is an optional TLS configuration, e.g. with a client certificate for
+	// Prometheus deployments which require mutual TLS, used by the HTTP clients the cluster
+	// map builds to reach the HTTP SD and etcd endpoints. When nil, the default transport is
+	// used. The Prometheus client passed to NewClusterMap is configured separately, see
+	// prom.NewClientTLSConfig.
+	TLSConfig *tls.Config
+}
+
+// httpClient returns an HTTP client with the given timeout, which uses TLSConfig if set,
+// and otherwise the default transport
+func (opts *ClusterMapOpts) httpClient(timeout time.Duration) *http.Client {
+	client := &http.Client{Timeout: timeout}
+	if opts.TLSConfig != nil {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = opts.TLSConfig
+		client.Transport = transport
+	}
+	return client
+}
+
+// withDefaults returns a copy of the options, with defaults set for unset values. The
+// copy does not share FieldMapping or EtcdEndpoints with the options, so the caller's
+// options are never modified, and later changes to them do not affect a cluster map.
+func (opts *ClusterMapOpts) withDefaults() *ClusterMapOpts {
+	if opts == nil {
+		return DefaultClusterMapOpts()
+	}
+
+	o := *opts
+	if o.ClusterInfoMetricName == "" {
+		o.ClusterInfoMetricName = DefaultClusterInfoMetricName
+	}
+	if opts.FieldMapping != nil {
+		o.FieldMapping = make(map[string]string, len(opts.FieldMapping))
+		for field, label := range opts.FieldMapping {
+			o.FieldMapping[field] = label
+		}
+	}
+	if opts.EtcdEndpoints != nil {
+		o.EtcdEndpoints = append([]string{}, opts.EtcdEndpoints...)
+	}
+	return &o
+}
+
+// DefaultClusterMapOpts returns ClusterMapOpts with default values set
+func DefaultClusterMapOpts() *ClusterMapOpts {
+	return &ClusterMapOpts{
+		ClusterInfoMetricName: DefaultClusterInfoMetricName,
+		FieldMapping:          DefaultClusterInfoFieldMapping(),
+	}
+}
+
 type ClusterInfo struct {
 	ID          string `json:"id"`
 	Name        string `json:"name"`
 	Profile     string `json:"profile"`
 	Provider    string `json:"provider"`
 	Provisioner string `json:"provisioner"`
+	Region      string `json:"region"`
+
+	// LastSeen is the time of the last refresh which loaded the cluster, or
+	// the zero time if it has not been loaded by a refresh
+	LastSeen time.Time `json:"lastSeen"`
 }
 
 // Clone creates a copy of ClusterInfo and returns it
@@ -41,9 +167,41 @@ func (ci *ClusterInfo) Clone() *ClusterInfo {
 		Profile:     ci.Profile,
 		Provider:    ci.Provider,
 		Provisioner: ci.Provisioner,
+		Region:      ci.Region,
+		LastSeen:    ci.LastSeen,
 	}
 }
 
+// equalIgnoringLastSeen returns true if the entries are equal other than when
+// they were last seen, which changes with every refresh
+func equalIgnoringLastSeen(a, b *ClusterInfo) bool {
+	x, y := *a, *b
+	x.LastSeen, y.LastSeen = time.Time{}, time.Time{}
+	return x == y
+}
+
+// ClusterMapEvent describes the changes to a ClusterMap made by a refresh. Each slice
+// contains copies of the ClusterInfo entries, sorted by ID.
+type ClusterMapEvent struct {
+	// Added are the entries which were not in the map before the refresh
+	Added []*ClusterInfo `json:"added"`
+
+	// Removed are the entries which are no longer in the map after the refresh
+	Removed []*ClusterInfo `json:"removed"`
+
+	// Updated are the new values of entries which changed
+	Updated []*ClusterInfo `json:"updated"`
+}
+
+// IsEmpty returns true if the event describes no changes
+func (cme ClusterMapEvent) IsEmpty() bool {
+	return len(cme.Added) == 0 && len(cme.Removed) == 0 && len(cme.Updated) == 0
+}
+
+// CancelFunc deregisters a callback. It is safe to call more than once, and from
+// within the callback itself.
+type CancelFunc func()
+
 type ClusterMap interface {
 	// GetClusterIDs returns a slice containing all of the cluster identifiers.
 	GetClusterIDs() []string
@@ -55,7 +213,22 @@ type ClusterMap interface {
 	// doesn't exist
 	InfoFor(clusterID string) *ClusterInfo
 
-	// NameFor returns the name of the cluster provided the clusterID.
+	// Find returns copies of all ClusterInfo entries for which the provided predicate
+	// returns true. The predicate is called with a copy of each entry.
+	Find(predicate func(*ClusterInfo) bool) []*ClusterInfo
+
+	// SortedProviders returns the distinct, non-empty providers of all ClusterInfo entries
+	// in sorted order.
+	SortedProviders() []string
+
+	// ForEachConcurrent calls fn with a copy of each ClusterInfo entry, using up to concurrency
+	// concurrent calls. The entries are snapshotted before any calls are made, so fn does not
+	// block updates to the map. The first error returned by fn, or the context's error if it is
+	// done first, stops any remaining calls from starting and is returned.
+	ForEachConcurrent(ctx context.Context, fn func(id string, info *ClusterInfo) error, concurrency int) error
+
+	// NameFor returns the name of the cluster provided the clusterID. If the cluster has no
+	// assigned name, or is unknown, the clusterID is returned.
 	NameFor(clusterID string) string
 
 	// NameIDFor returns an identifier in the format "<clusterName>/<clusterID>" if the cluster has an
@@ -65,6 +238,24 @@ type ClusterMap interface {
 	// SplitNameID splits the nameID back into a separate id and name field
 	SplitNameID(nameID string) (id string, name string)
 
+	// LastRefresh returns the time of the last successful refresh of the map, or the zero
+	// time if it has never been refreshed.
+	LastRefresh() time.Time
+
+	// TTLFor returns the time until the entry for the provided clusterID is considered
+	// stale, which is one refresh interval after it was last seen, or 0 if it is already
+	// stale, has never been seen by a refresh, or doesn't exist.
+	TTLFor(clusterID string) time.Duration
+
+	// SetRefreshInterval changes how often the map is automatically refreshed, taking
+	// effect once any refresh in progress completes, without waiting for it.
+	SetRefreshInterval(refresh time.Duration)
+
+	// RegisterChangeCallback registers fn to be called synchronously after each refresh
+	// which changes the map, with the changes. The returned CancelFunc deregisters it,
+	// e.g. from within fn for a one-shot callback.
+	RegisterChangeCallback(fn func(event ClusterMapEvent)) CancelFunc
+
 	// StopRefresh stops the automatic internal map refresh
 	StopRefresh()
 }
@@ -81,11 +272,30 @@ type PrometheusClusterMap struct {
 	client       prometheus.Client
 	clusters     map[string]*ClusterInfo
 	localCluster LocalClusterInfoProvider
+	opts         *ClusterMapOpts
+	httpClient   *http.Client
+	store        *etcdClusterStore
+	lastRefresh  time.Time
+	maxAge       time.Duration
+	interval     chan time.Duration
 	stop         chan struct{}
+	breaker      *loadBreaker
+
+	callbackLock sync.Mutex
+	callbacks    []*changeCallback
+}
+
+// changeCallback is a callback registered by RegisterChangeCallback. It is a pointer so
+// that it can be identified to be deregistered.
+type changeCallback struct {
+	fn func(event ClusterMapEvent)
 }
 
-// NewClusterMap creates a new ClusterMap implementation using a prometheus or thanos client
-func NewClusterMap(client prometheus.Client, lcip LocalClusterInfoProvider, refresh time.Duration) ClusterMap {
+// NewClusterMap creates a new ClusterMap implementation using a prometheus or thanos client. If opts
+// is nil, DefaultClusterMapOpts() is used.
+func NewClusterMap(client prometheus.Client, lcip LocalClusterInfoProvider, refresh time.Duration, opts *ClusterMapOpts) ClusterMap {
+	opts = opts.withDefaults()
+
 	stop := make(chan struct{})
 
 	cm := &PrometheusClusterMap{
@@ -93,7 +303,16 @@ func NewClusterMap(client prometheus.Client, lcip LocalClusterInfoProvider, refr
 		client:       client,
 		clusters:     make(map[string]*ClusterInfo),
 		localCluster: lcip,
+		opts:         opts,
+		httpClient:   opts.httpClient(HTTPSDTimeout),
+		maxAge:       refresh,
+		interval:     make(chan time.Duration, 1),
 		stop:         stop,
+		breaker:      newLoadBreaker(LoadCircuitBreakerThreshold, LoadCircuitBreakerCooldown),
+	}
+
+	if len(opts.EtcdEndpoints) > 0 {
+		cm.store = newEtcdClusterStore(opts.httpClient(EtcdTimeout), opts.EtcdEndpoints, opts.EtcdPrefix)
 	}
 
 	// Run an updater to ensure cluster data stays relevant over time
@@ -107,8 +326,10 @@ func NewClusterMap(client prometheus.Client, lcip LocalClusterInfoProvider, refr
 			select {
 			case <-ticker.C:
 				cm.refreshClusters()
-			case <-cm.stop:
-				log.Infof("ClusterMap refresh stopped.")
+			case refresh := <-cm.interval:
+				ticker.Reset(refresh)
+			case <-stop:
+				clustersLog.Infof("ClusterMap refresh stopped.")
 				return
 			}
 		}
@@ -118,8 +339,29 @@ func NewClusterMap(client prometheus.Client, lcip LocalClusterInfoProvider, refr
 }
 
 // clusterInfoQuery returns the query string to load cluster info
-func clusterInfoQuery(offset string) string {
-	return fmt.Sprintf("kubecost_cluster_info%s", offset)
+func clusterInfoQuery(metricName, offset string) string {
+	return fmt.Sprintf("%s%s", metricName, offset)
+}
+
+// normalizeID returns the cluster ID normalized by the IDNormalizeFn option, if set
+func (pcm *PrometheusClusterMap) normalizeID(id string) string {
+	if pcm.opts == nil || pcm.opts.IDNormalizeFn == nil {
+		return id
+	}
+
+	return pcm.opts.IDNormalizeFn(id)
+}
+
+// fieldLabel returns the label of the cluster info metric the ClusterInfo field is loaded
+// from, as mapped by the FieldMapping option
+func (pcm *PrometheusClusterMap) fieldLabel(field string) string {
+	if pcm.opts != nil {
+		if label, ok := pcm.opts.FieldMapping[field]; ok && label != "" {
+			return label
+		}
+	}
+
+	return DefaultClusterInfoFieldMapping()[field]
 }
 
 // loadClusters loads all the cluster info to map
@@ -130,72 +372,130 @@ func (pcm *PrometheusClusterMap) loadClusters() (map[string]*ClusterInfo, error)
 	}
 
 	// Execute Query
-	tryQuery := func() (interface{}, error) {
+	tryQuery := func() ([]*prom.QueryResult, error) {
 		ctx := prom.NewNamedContext(pcm.client, prom.ClusterMapContextName)
-		r, _, e := ctx.QuerySync(clusterInfoQuery(offset))
+		r, _, e := ctx.QuerySync(clusterInfoQuery(pcm.opts.ClusterInfoMetricName, offset))
 		return r, e
 	}
 
-	// Retry on failure
-	result, err := retry.Retry(context.Background(), tryQuery, uint(LoadRetries), LoadRetryDelay)
+	// Fail fast while the circuit is open, and only attempt once to close it, so that an
+	// extended outage doesn't block each refresh on retries
+	attempts, ok := pcm.breaker.allow()
+	if !ok {
+		return nil, errLoadCircuitOpen
+	}
 
-	qr, ok := result.([]*prom.QueryResult)
-	if !ok || err != nil {
+	// Retry on failure, backing off so that an unavailable Prometheus isn't flooded
+	qr, err := retry.Do(context.Background(), tryQuery, retry.Options{
+		Attempts: uint(attempts),
+		Backoff: retry.Backoff{
+			Initial:    LoadRetryDelay,
+			Max:        LoadRetryMaxDelay,
+			Multiplier: 1.5,
+			Jitter:     0.2,
+		},
+		OnRetry: func(attempt uint, err error, delay time.Duration) {
+			clustersLog.WithFields(log.Fields{"attempt": attempt, "error": err}).Warningf("Failed to load cluster info (attempt %d of %d): %s; retrying in %s", attempt, attempts, err, delay.Round(time.Second))
+		},
+	})
+
+	if err != nil {
+		pcm.breaker.failure()
 		return nil, err
 	}
+	pcm.breaker.success()
 
-	clusters := make(map[string]*ClusterInfo)
+	clusters := pcm.clusterInfoFromResults(qr)
 
-	// Load the query results. Critical fields are id and name.
-	for _, result := range qr {
-		id, err := result.GetString("id")
+	// merge clusters listed by HTTP SD which were not loaded from metrics
+	if pcm.opts.HTTPSDEndpoint != "" {
+		sdClusters, err := loadHTTPSDClusters(pcm.httpClient, pcm.opts.HTTPSDEndpoint)
 		if err != nil {
-			log.Warningf("Failed to load 'id' field for ClusterInfo")
-			continue
+			clustersLog.WithFields(log.Fields{"endpoint": pcm.opts.HTTPSDEndpoint}).Warningf("Failed to load cluster info via HTTP SD: %s", err)
+		} else {
+			mergeClusters(clusters, pcm.normalizeClusters(sdClusters))
 		}
+	}
 
-		name, err := result.GetString("name")
+	// populate the local cluster if it doesn't exist
+	localID := pcm.normalizeID(env.GetClusterID())
+	if _, ok := clusters[localID]; !ok {
+		localInfo, err := pcm.getLocalClusterInfo()
 		if err != nil {
-			log.Warningf("Failed to load 'name' field for ClusterInfo")
-			continue
+			clustersLog.Warningf("Failed to load local cluster info: %s", err)
+		} else {
+			localInfo.ID = pcm.normalizeID(localInfo.ID)
+			clusters[localInfo.ID] = localInfo
 		}
+	}
+
+	return clusters, nil
+}
 
-		profile, err := result.GetString("clusterprofile")
+// clusterInfoFromResults loads ClusterInfo from the results of the cluster info query, keyed
+// by normalized ID, reading each field from the label given by the FieldMapping option.
+// Critical fields are id and name.
+func (pcm *PrometheusClusterMap) clusterInfoFromResults(qr []*prom.QueryResult) map[string]*ClusterInfo {
+	clusters := make(map[string]*ClusterInfo)
+
+	idLabel := pcm.fieldLabel(ClusterInfoIDField)
+	nameLabel := pcm.fieldLabel(ClusterInfoNameField)
+
+	// optional returns the value of the field, or "" if it is not set
+	optional := func(result *prom.QueryResult, field string) string {
+		value, err := result.GetString(pcm.fieldLabel(field))
 		if err != nil {
-			profile = ""
+			return ""
 		}
+		return value
+	}
 
-		provider, err := result.GetString("provider")
+	for _, result := range qr {
+		id, err := result.GetString(idLabel)
 		if err != nil {
-			provider = ""
+			clustersLog.WithFields(log.Fields{"label": idLabel}).RateLimitedWarningf("cluster-info-id", "Failed to load 'id' field for ClusterInfo from label '%s'", idLabel)
+			continue
 		}
+		id = pcm.normalizeID(id)
 
-		provisioner, err := result.GetString("provisioner")
+		name, err := result.GetString(nameLabel)
 		if err != nil {
-			provisioner = ""
+			clustersLog.WithFields(log.Fields{"label": nameLabel, "cluster": id}).RateLimitedWarningf("cluster-info-name", "Failed to load 'name' field for ClusterInfo from label '%s'", nameLabel)
+			continue
 		}
 
 		clusters[id] = &ClusterInfo{
 			ID:          id,
 			Name:        name,
-			Profile:     profile,
-			Provider:    provider,
-			Provisioner: provisioner,
+			Profile:     optional(result, ClusterInfoProfileField),
+			Provider:    optional(result, ClusterInfoProviderField),
+			Provisioner: optional(result, ClusterInfoProvisionerField),
+			Region:      optional(result, ClusterInfoRegionField),
 		}
 	}
 
-	// populate the local cluster if it doesn't exist
-	localID := env.GetClusterID()
-	if _, ok := clusters[localID]; !ok {
-		localInfo, err := pcm.getLocalClusterInfo()
-		if err != nil {
-			log.Warningf("Failed to load local cluster info: %s", err)
-		} else {
-			clusters[localInfo.ID] = localInfo
+	return clusters
+}
+
+// mergeClusters adds each of the secondary ClusterInfo entries to clusters, unless an entry
+// with the same ID already exists.
+func mergeClusters(clusters map[string]*ClusterInfo, secondary map[string]*ClusterInfo) {
+	for id, info := range secondary {
+		if _, ok := clusters[id]; !ok {
+			clusters[id] = info
 		}
 	}
+}
 
-	return clusters, nil
+// normalizeClusters returns the ClusterInfo entries keyed by their normalized IDs
+func (pcm *PrometheusClusterMap) normalizeClusters(clusters map[string]*ClusterInfo) map[string]*ClusterInfo {
+	normalized := make(map[string]*ClusterInfo, len(clusters))
+	for _, info := range clusters {
+		info.ID = pcm.normalizeID(info.ID)
+		normalized[info.ID] = info
+	}
+
+	return normalized
 }
 
 // getLocalClusterInfo returns the local cluster info in the event there does not exist a metric available.
@@ -219,6 +519,7 @@ func (pcm *PrometheusClusterMap) getLocalClusterInfo() (*ClusterInfo, error) {
 	var clusterProfile string
 	var provider string
 	var provisioner string
+	var region string
 
 	if cp, ok := info["clusterProfile"]; ok {
 		clusterProfile = cp
@@ -229,6 +530,9 @@ func (pcm *PrometheusClusterMap) getLocalClusterInfo() (*ClusterInfo, error) {
 	if pvsr, ok := info["provisioner"]; ok {
 		provisioner = pvsr
 	}
+	if rgn, ok := info["region"]; ok {
+		region = rgn
+	}
 
 	return &ClusterInfo{
 		ID:          id,
@@ -236,20 +540,168 @@ func (pcm *PrometheusClusterMap) getLocalClusterInfo() (*ClusterInfo, error) {
 		Profile:     clusterProfile,
 		Provider:    provider,
 		Provisioner: provisioner,
+		Region:      region,
 	}, nil
 }
 
-// refreshClusters loads the clusters and updates the internal map
+// refreshClusters loads the clusters and updates the internal map, persisting it to etcd
+// if configured. If the clusters fail to load before the map is first refreshed, the map
+// is restored from etcd.
 func (pcm *PrometheusClusterMap) refreshClusters() {
 	updated, err := pcm.loadClusters()
+	if err == errLoadCircuitOpen {
+		clustersLog.Debugf("Skipped loading cluster info: %s", err)
+		pcm.restoreClusters()
+		return
+	}
+	if err != nil {
+		clustersLog.Errorf("Failed to load cluster info via query: %s", err)
+		pcm.restoreClusters()
+		return
+	}
+
+	pcm.applyClusters(updated)
+
+	if pcm.store != nil {
+		err = pcm.store.Save(updated)
+		if err != nil {
+			clustersLog.Warningf("Failed to persist cluster info to etcd: %s", err)
+		}
+	}
+}
+
+// restoreClusters loads the map persisted in etcd, if configured, unless the map has
+// already been refreshed. The restored map does not count as a refresh, so that it is
+// still reported as stale.
+func (pcm *PrometheusClusterMap) restoreClusters() {
+	if pcm.store == nil || !pcm.LastRefresh().IsZero() {
+		return
+	}
+
+	restored, err := pcm.store.Load()
 	if err != nil {
-		log.Errorf("Failed to load cluster info via query after %d retries", LoadRetries)
+		clustersLog.Warningf("Failed to load cluster info from etcd: %s", err)
 		return
 	}
 
+	clustersLog.Infof("Restored %d clusters from etcd", len(restored))
+	pcm.setClusters(pcm.normalizeClusters(restored), false)
+}
+
+// applyClusters replaces the internal map with updated, and calls the change callbacks
+// if any entry changed.
+func (pcm *PrometheusClusterMap) applyClusters(updated map[string]*ClusterInfo) {
+	pcm.setClusters(updated, true)
+}
+
+// setClusters replaces the internal map with updated, recording a refresh if refreshed is
+// true, and calls the change callbacks if any entry changed.
+func (pcm *PrometheusClusterMap) setClusters(updated map[string]*ClusterInfo, refreshed bool) {
 	pcm.lock.Lock()
+	if refreshed {
+		pcm.lastRefresh = time.Now()
+		for _, info := range updated {
+			info.LastSeen = pcm.lastRefresh
+		}
+	}
+	event := diffClusters(pcm.clusters, updated)
 	pcm.clusters = updated
 	pcm.lock.Unlock()
+
+	if event.IsEmpty() {
+		return
+	}
+
+	// Callbacks are snapshotted, so that they may register or cancel callbacks
+	pcm.callbackLock.Lock()
+	callbacks := make([]*changeCallback, len(pcm.callbacks))
+	copy(callbacks, pcm.callbacks)
+	pcm.callbackLock.Unlock()
+
+	for _, cb := range callbacks {
+		cb.fn(event)
+	}
+}
+
+// diffClusters returns the changes from the previous to the updated ClusterInfo entries
+func diffClusters(previous, updated map[string]*ClusterInfo) ClusterMapEvent {
+	event := ClusterMapEvent{}
+
+	for id, info := range updated {
+		prev, ok := previous[id]
+		if !ok {
+			event.Added = append(event.Added, info.Clone())
+		} else if !equalIgnoringLastSeen(prev, info) {
+			event.Updated = append(event.Updated, info.Clone())
+		}
+	}
+	for id, info := range previous {
+		if _, ok := updated[id]; !ok {
+			event.Removed = append(event.Removed, info.Clone())
+		}
+	}
+
+	byID := func(infos []*ClusterInfo) {
+		sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	}
+	byID(event.Added)
+	byID(event.Removed)
+	byID(event.Updated)
+
+	return event
+}
+
+// RegisterChangeCallback registers fn to be called synchronously after each refresh
+// which changes the map, with the changes. The returned CancelFunc deregisters it,
+// e.g. from within fn for a one-shot callback.
+func (pcm *PrometheusClusterMap) RegisterChangeCallback(fn func(event ClusterMapEvent)) CancelFunc {
+	cb := &changeCallback{fn: fn}
+
+	pcm.callbackLock.Lock()
+	pcm.callbacks = append(pcm.callbacks, cb)
+	pcm.callbackLock.Unlock()
+
+	return func() {
+		pcm.callbackLock.Lock()
+		defer pcm.callbackLock.Unlock()
+
+		for i, registered := range pcm.callbacks {
+			if registered == cb {
+				pcm.callbacks = append(pcm.callbacks[:i:i], pcm.callbacks[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// LastRefresh returns the time of the last successful refresh of the map, or the zero
+// time if it has never been refreshed.
+func (pcm *PrometheusClusterMap) LastRefresh() time.Time {
+	pcm.lock.RLock()
+	defer pcm.lock.RUnlock()
+
+	return pcm.lastRefresh
+}
+
+// TTLFor returns the time until the entry for the provided clusterID is considered
+// stale, which is one refresh interval after it was last seen, or 0 if it is already
+// stale, has never been seen by a refresh, or doesn't exist.
+func (pcm *PrometheusClusterMap) TTLFor(clusterID string) time.Duration {
+	clusterID = pcm.normalizeID(clusterID)
+
+	pcm.lock.RLock()
+	defer pcm.lock.RUnlock()
+
+	info, ok := pcm.clusters[clusterID]
+	if !ok || info.LastSeen.IsZero() {
+		return 0
+	}
+
+	ttl := pcm.maxAge - time.Since(info.LastSeen)
+	if ttl < 0 {
+		return 0
+	}
+	return ttl
 }
 
 // GetClusterIDs returns a slice containing all of the cluster identifiers.
@@ -281,6 +733,8 @@ func (pcm *PrometheusClusterMap) AsMap() map[string]*ClusterInfo {
 // InfoFor returns the ClusterInfo entry for the provided clusterID or nil if it
 // doesn't exist
 func (pcm *PrometheusClusterMap) InfoFor(clusterID string) *ClusterInfo {
+	clusterID = pcm.normalizeID(clusterID)
+
 	pcm.lock.RLock()
 	defer pcm.lock.RUnlock()
 
@@ -291,21 +745,135 @@ func (pcm *PrometheusClusterMap) InfoFor(clusterID string) *ClusterInfo {
 	return nil
 }
 
-// NameFor returns the name of the cluster provided the clusterID.
+// Find returns copies of all ClusterInfo entries for which the provided predicate
+// returns true. The predicate is called with a copy of each entry, so that it
+// cannot modify the map.
+func (pcm *PrometheusClusterMap) Find(predicate func(*ClusterInfo) bool) []*ClusterInfo {
+	pcm.lock.RLock()
+	defer pcm.lock.RUnlock()
+
+	var found []*ClusterInfo
+	for _, info := range pcm.clusters {
+		if clone := info.Clone(); predicate(clone) {
+			found = append(found, clone)
+		}
+	}
+
+	return found
+}
+
+// SortedProviders returns the distinct, non-empty providers of all ClusterInfo entries
+// in sorted order.
+func (pcm *PrometheusClusterMap) SortedProviders() []string {
+	pcm.lock.RLock()
+	defer pcm.lock.RUnlock()
+
+	seen := make(map[string]bool)
+	providers := []string{}
+	for _, info := range pcm.clusters {
+		if info.Provider == "" || seen[info.Provider] {
+			continue
+		}
+		seen[info.Provider] = true
+		providers = append(providers, info.Provider)
+	}
+
+	sort.Strings(providers)
+	return providers
+}
+
+// ForEachConcurrent calls fn with a copy of each ClusterInfo entry, using up to concurrency
+// concurrent calls. The entries are snapshotted before any calls are made, so fn does not
+// block updates to the map. The first error returned by fn, or the context's error if it is
+// done first, stops any remaining calls from starting and is returned.
+func (pcm *PrometheusClusterMap) ForEachConcurrent(ctx context.Context, fn func(id string, info *ClusterInfo) error, concurrency int) error {
+	pcm.lock.RLock()
+	snapshot := make([]*ClusterInfo, 0, len(pcm.clusters))
+	for _, info := range pcm.clusters {
+		snapshot = append(snapshot, info.Clone())
+	}
+	pcm.lock.RUnlock()
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var errOnce sync.Once
+	var firstErr error
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	work := make(chan *ClusterInfo)
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+
+			for info := range work {
+				if err := fn(info.ID, info); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+	dispatched := 0
+feed:
+	for _, info := range snapshot {
+		if ctx.Err() != nil {
+			break
+		}
+
+		select {
+		case work <- info:
+			dispatched++
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	// An error from fn takes precedence over the parent context being done
+	if firstErr != nil {
+		return firstErr
+	}
+	if dispatched < len(snapshot) {
+		return ctx.Err()
+	}
+
+	return nil
+}
+
+// NameFor returns the name of the cluster provided the clusterID. If the cluster has no
+// assigned name, or is unknown, the clusterID is returned.
 func (pcm *PrometheusClusterMap) NameFor(clusterID string) string {
+	clusterID = pcm.normalizeID(clusterID)
+
 	pcm.lock.RLock()
 	defer pcm.lock.RUnlock()
 
-	if info, ok := pcm.clusters[clusterID]; ok {
+	if info, ok := pcm.clusters[clusterID]; ok && info.Name != "" {
 		return info.Name
 	}
 
-	return ""
+	return clusterID
 }
 
 // NameIDFor returns an identifier in the format "<clusterName>/<clusterID>" if the cluster has an
 // assigned name. Otherwise, just the clusterID is returned.
 func (pcm *PrometheusClusterMap) NameIDFor(clusterID string) string {
+	clusterID = pcm.normalizeID(clusterID)
+
 	pcm.lock.RLock()
 	defer pcm.lock.RUnlock()
 
@@ -333,8 +901,43 @@ func (pcm *PrometheusClusterMap) SplitNameID(nameID string) (id string, name str
 	return
 }
 
+// SetRefreshInterval changes how often the map is automatically refreshed, taking
+// effect once any refresh in progress completes. It does not wait for the refresh,
+// and has no effect once the refresh has been stopped.
+func (pcm *PrometheusClusterMap) SetRefreshInterval(refresh time.Duration) {
+	if refresh <= 0 {
+		return
+	}
+
+	pcm.lock.Lock()
+	defer pcm.lock.Unlock()
+
+	if pcm.stop == nil {
+		return
+	}
+	pcm.maxAge = refresh
+
+	// The interval is buffered, so replace any interval which the refresh
+	// goroutine has not yet received with the latest one
+	for {
+		select {
+		case pcm.interval <- refresh:
+			clustersLog.Infof("ClusterMap refresh interval set to %s", refresh)
+			return
+		default:
+			select {
+			case <-pcm.interval:
+			default:
+			}
+		}
+	}
+}
+
 // StopRefresh stops the automatic internal map refresh
 func (pcm *PrometheusClusterMap) StopRefresh() {
+	pcm.lock.Lock()
+	defer pcm.lock.Unlock()
+
 	if pcm.stop != nil {
 		close(pcm.stop)
 		pcm.stop = nil
@@ -0,0 +1,478 @@
+package clusters
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/prom"
+)
+
+func newTestClusterMap(infos ...*ClusterInfo) *PrometheusClusterMap {
+	clusters := make(map[string]*ClusterInfo)
+	for _, info := range infos {
+		clusters[info.ID] = info
+	}
+
+	return &PrometheusClusterMap{
+		lock:     new(sync.RWMutex),
+		clusters: clusters,
+	}
+}
+
+func foundIDs(infos []*ClusterInfo) []string {
+	var ids []string
+	for _, info := range infos {
+		ids = append(ids, info.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestClusterMapFind(t *testing.T) {
+	cm := newTestClusterMap(
+		&ClusterInfo{ID: "cluster-a", Name: "prod-east", Provider: "AWS", Region: "us-east-1"},
+		&ClusterInfo{ID: "cluster-b", Name: "prod-west", Provider: "GCP", Region: "us-west1"},
+		&ClusterInfo{ID: "cluster-c", Name: "dev-east", Provider: "AWS", Region: "us-east-2"},
+	)
+
+	cases := []struct {
+		name      string
+		predicate func(*ClusterInfo) bool
+		expected  []string
+	}{
+		{
+			name:      "by provider",
+			predicate: func(ci *ClusterInfo) bool { return ci.Provider == "AWS" },
+			expected:  []string{"cluster-a", "cluster-c"},
+		},
+		{
+			name:      "by name prefix",
+			predicate: func(ci *ClusterInfo) bool { return strings.HasPrefix(ci.Name, "prod-") },
+			expected:  []string{"cluster-a", "cluster-b"},
+		},
+		{
+			name:      "by region",
+			predicate: func(ci *ClusterInfo) bool { return ci.Region == "us-west1" },
+			expected:  []string{"cluster-b"},
+		},
+		{
+			name:      "no match",
+			predicate: func(ci *ClusterInfo) bool { return ci.Provider == "azure" },
+			expected:  nil,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ids := foundIDs(cm.Find(c.predicate))
+			if len(ids) != len(c.expected) {
+				t.Fatalf("expected %v; got %v", c.expected, ids)
+			}
+			for i := range ids {
+				if ids[i] != c.expected[i] {
+					t.Fatalf("expected %v; got %v", c.expected, ids)
+				}
+			}
+		})
+	}
+
+	// Results must be copies, not references to the internal entries
+	found := cm.Find(func(ci *ClusterInfo) bool { return ci.ID == "cluster-a" })
+	found[0].Name = "mutated"
+	if cm.NameFor("cluster-a") != "prod-east" {
+		t.Fatalf("expected Find to return copies; internal entry was mutated")
+	}
+
+	// Nor can the predicate mutate the internal entries
+	cm.Find(func(ci *ClusterInfo) bool {
+		ci.Name = "mutated"
+		return false
+	})
+	if cm.NameFor("cluster-a") != "prod-east" {
+		t.Fatalf("expected Find to call the predicate with copies; internal entry was mutated")
+	}
+}
+
+func TestClusterMapSortedProviders(t *testing.T) {
+	cm := newTestClusterMap(
+		&ClusterInfo{ID: "cluster-a", Provider: "GCP"},
+		&ClusterInfo{ID: "cluster-b", Provider: "AWS"},
+		&ClusterInfo{ID: "cluster-c", Provider: "GCP"},
+		&ClusterInfo{ID: "cluster-d", Provider: ""},
+		&ClusterInfo{ID: "cluster-e", Provider: "Azure"},
+	)
+
+	expected := []string{"AWS", "Azure", "GCP"}
+	providers := cm.SortedProviders()
+	if len(providers) != len(expected) {
+		t.Fatalf("expected %v; got %v", expected, providers)
+	}
+	for i := range providers {
+		if providers[i] != expected[i] {
+			t.Fatalf("expected %v; got %v", expected, providers)
+		}
+	}
+
+	if providers := newTestClusterMap().SortedProviders(); len(providers) != 0 {
+		t.Fatalf("expected no providers; got %v", providers)
+	}
+}
+
+func TestClusterMapNameFor(t *testing.T) {
+	cm := newTestClusterMap(
+		&ClusterInfo{ID: "cluster-a", Name: "prod-east"},
+		&ClusterInfo{ID: "cluster-b", Name: ""},
+	)
+
+	cases := []struct {
+		clusterID string
+		expected  string
+	}{
+		{"cluster-a", "prod-east"},
+		{"cluster-b", "cluster-b"},
+		{"cluster-unknown", "cluster-unknown"},
+	}
+
+	for _, c := range cases {
+		if name := cm.NameFor(c.clusterID); name != c.expected {
+			t.Fatalf("expected name \"%s\" for %s; got \"%s\"", c.expected, c.clusterID, name)
+		}
+	}
+}
+
+func TestClusterMapIDNormalizeFn(t *testing.T) {
+	// normalize AKS resource IDs to their trailing cluster name
+	normalize := func(id string) string {
+		return id[strings.LastIndex(id, "/")+1:]
+	}
+
+	cm := newTestClusterMap()
+	cm.opts = &ClusterMapOpts{IDNormalizeFn: normalize}
+	cm.clusters = cm.normalizeClusters(map[string]*ClusterInfo{
+		"/subscriptions/abc/clusters/prod": {ID: "/subscriptions/abc/clusters/prod", Name: "prod-east"},
+		"dev":                              {ID: "dev", Name: ""},
+	})
+
+	if _, ok := cm.clusters["prod"]; !ok {
+		t.Fatalf("expected cluster to be keyed by normalized ID \"prod\"; got %v", cm.GetClusterIDs())
+	}
+
+	info := cm.InfoFor("/subscriptions/abc/clusters/prod")
+	if info == nil || info.ID != "prod" {
+		t.Fatalf("expected info for normalized ID \"prod\"; got %v", info)
+	}
+	if cm.InfoFor("prod") == nil {
+		t.Errorf("expected info for already normalized ID \"prod\"")
+	}
+
+	if name := cm.NameFor("/subscriptions/abc/clusters/prod"); name != "prod-east" {
+		t.Errorf("expected name \"prod-east\"; got \"%s\"", name)
+	}
+	if nameID := cm.NameIDFor("/subscriptions/abc/clusters/prod"); nameID != "prod-east/prod" {
+		t.Errorf("expected name ID \"prod-east/prod\"; got \"%s\"", nameID)
+	}
+	if nameID := cm.NameIDFor("/subscriptions/abc/clusters/dev"); nameID != "dev" {
+		t.Errorf("expected name ID \"dev\"; got \"%s\"", nameID)
+	}
+}
+
+func TestClusterInfoQuery(t *testing.T) {
+	cases := []struct {
+		metricName string
+		offset     string
+		expected   string
+	}{
+		{DefaultClusterInfoMetricName, "", "kubecost_cluster_info"},
+		{DefaultClusterInfoMetricName, " offset 3h", "kubecost_cluster_info offset 3h"},
+		{"tenant_a_cluster_info", "", "tenant_a_cluster_info"},
+	}
+
+	for _, c := range cases {
+		if q := clusterInfoQuery(c.metricName, c.offset); q != c.expected {
+			t.Fatalf("expected query \"%s\"; got \"%s\"", c.expected, q)
+		}
+	}
+}
+
+func TestClusterInfoFieldMapping(t *testing.T) {
+	results := []*prom.QueryResult{
+		{Metric: map[string]interface{}{"cluster_id": "prod", "cluster_name": "Production", "provider": "GCP", "clusterprofile": "production"}},
+		{Metric: map[string]interface{}{"id": "dev", "name": "Development"}},
+	}
+
+	cases := map[string]struct {
+		mapping  map[string]string
+		expected []string
+	}{
+		"default": {
+			mapping:  nil,
+			expected: []string{"dev"},
+		},
+		"mapped": {
+			mapping:  map[string]string{ClusterInfoIDField: "cluster_id", ClusterInfoNameField: "cluster_name"},
+			expected: []string{"prod"},
+		},
+	}
+
+	for name, c := range cases {
+		cm := newTestClusterMap()
+		cm.opts = &ClusterMapOpts{FieldMapping: c.mapping}
+
+		clusters := cm.clusterInfoFromResults(results)
+
+		var ids []string
+		for id := range clusters {
+			ids = append(ids, id)
+		}
+		if fmt.Sprint(ids) != fmt.Sprint(c.expected) {
+			t.Fatalf("%s: expected clusters %v; got %v", name, c.expected, ids)
+		}
+	}
+
+	// Unmapped fields are loaded from their default labels
+	cm := newTestClusterMap()
+	cm.opts = &ClusterMapOpts{FieldMapping: map[string]string{ClusterInfoIDField: "cluster_id", ClusterInfoNameField: "cluster_name"}}
+	info := cm.clusterInfoFromResults(results)["prod"]
+	if info.Name != "Production" || info.Provider != "GCP" || info.Profile != "production" {
+		t.Fatalf("expected fields loaded from mapped and default labels; got %+v", info)
+	}
+}
+
+func TestClusterMapForEachConcurrent(t *testing.T) {
+	var infos []*ClusterInfo
+	for i := 0; i < 20; i++ {
+		infos = append(infos, &ClusterInfo{ID: fmt.Sprintf("cluster-%d", i), Name: fmt.Sprintf("name-%d", i)})
+	}
+	cm := newTestClusterMap(infos...)
+
+	// All entries are visited with no more than the requested concurrency, and
+	// the map remains writable while fn runs
+	var lock sync.Mutex
+	visited := map[string]bool{}
+	var running, maxRunning int32
+
+	err := cm.ForEachConcurrent(context.Background(), func(id string, info *ClusterInfo) error {
+		n := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			m := atomic.LoadInt32(&maxRunning)
+			if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+				break
+			}
+		}
+
+		cm.lock.Lock()
+		cm.lock.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		lock.Lock()
+		visited[id] = true
+		lock.Unlock()
+
+		info.Name = "mutated"
+		return nil
+	}, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(visited) != len(infos) {
+		t.Fatalf("expected %d clusters visited; got %d", len(infos), len(visited))
+	}
+	if maxRunning > 4 {
+		t.Fatalf("expected at most 4 concurrent calls; got %d", maxRunning)
+	}
+	if cm.NameFor("cluster-0") != "name-0" {
+		t.Fatalf("expected ForEachConcurrent to pass copies; internal entry was mutated")
+	}
+
+	// The first error stops remaining calls and is returned
+	failure := errors.New("failure")
+	var calls int32
+	err = cm.ForEachConcurrent(context.Background(), func(id string, info *ClusterInfo) error {
+		atomic.AddInt32(&calls, 1)
+		return failure
+	}, 1)
+	if err != failure {
+		t.Fatalf("expected error %s; got %v", failure, err)
+	}
+	if calls >= int32(len(infos)) {
+		t.Fatalf("expected an error to stop remaining calls; got %d calls", calls)
+	}
+
+	// A done context stops remaining calls
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = cm.ForEachConcurrent(ctx, func(id string, info *ClusterInfo) error {
+		return nil
+	}, 2)
+	if err != context.Canceled {
+		t.Fatalf("expected error %s; got %v", context.Canceled, err)
+	}
+}
+
+func TestClusterMapRegisterChangeCallback(t *testing.T) {
+	cm := newTestClusterMap(
+		&ClusterInfo{ID: "a", Name: "alpha"},
+		&ClusterInfo{ID: "b", Name: "beta"},
+	)
+
+	var events []ClusterMapEvent
+	cancel := cm.RegisterChangeCallback(func(event ClusterMapEvent) {
+		events = append(events, event)
+	})
+
+	// one-shot callback, which cancels itself
+	oneShot := 0
+	var cancelOneShot CancelFunc
+	cancelOneShot = cm.RegisterChangeCallback(func(event ClusterMapEvent) {
+		oneShot++
+		cancelOneShot()
+	})
+
+	cm.applyClusters(map[string]*ClusterInfo{
+		"b": {ID: "b", Name: "beta-renamed"},
+		"c": {ID: "c", Name: "gamma"},
+	})
+
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event; got %d", len(events))
+	}
+	event := events[0]
+	if ids := foundIDs(event.Added); fmt.Sprint(ids) != "[c]" {
+		t.Errorf("expected added [c]; got %v", ids)
+	}
+	if ids := foundIDs(event.Removed); fmt.Sprint(ids) != "[a]" {
+		t.Errorf("expected removed [a]; got %v", ids)
+	}
+	if len(event.Updated) != 1 || event.Updated[0].Name != "beta-renamed" {
+		t.Errorf("expected updated beta-renamed; got %v", event.Updated)
+	}
+
+	// a refresh without changes does not call callbacks
+	cm.applyClusters(map[string]*ClusterInfo{
+		"b": {ID: "b", Name: "beta-renamed"},
+		"c": {ID: "c", Name: "gamma"},
+	})
+	if len(events) != 1 {
+		t.Fatalf("expected no event for unchanged refresh; got %d events", len(events))
+	}
+
+	cancel()
+	cancel()
+
+	cm.applyClusters(map[string]*ClusterInfo{
+		"d": {ID: "d", Name: "delta"},
+	})
+	if len(events) != 1 {
+		t.Errorf("expected no event after cancel; got %d events", len(events))
+	}
+	if oneShot != 1 {
+		t.Errorf("expected one-shot callback to be called once; got %d", oneShot)
+	}
+}
+
+func TestClusterMapTTLFor(t *testing.T) {
+	cm := newTestClusterMap()
+	cm.maxAge = time.Hour
+
+	cm.applyClusters(map[string]*ClusterInfo{
+		"a": {ID: "a", Name: "alpha"},
+	})
+
+	if ttl := cm.TTLFor("a"); ttl <= 59*time.Minute || ttl > time.Hour {
+		t.Fatalf("expected a TTL of about 1h for a refreshed cluster; got %s", ttl)
+	}
+	if ttl := cm.TTLFor("unknown"); ttl != 0 {
+		t.Fatalf("expected a TTL of 0 for an unknown cluster; got %s", ttl)
+	}
+
+	// entries age from the last refresh which loaded them
+	cm.lock.Lock()
+	cm.clusters["a"].LastSeen = time.Now().Add(-45 * time.Minute)
+	cm.lock.Unlock()
+
+	if ttl := cm.TTLFor("a"); ttl <= 14*time.Minute || ttl > 15*time.Minute {
+		t.Fatalf("expected a TTL of about 15m; got %s", ttl)
+	}
+
+	cm.lock.Lock()
+	cm.clusters["a"].LastSeen = time.Now().Add(-2 * time.Hour)
+	cm.lock.Unlock()
+
+	if ttl := cm.TTLFor("a"); ttl != 0 {
+		t.Fatalf("expected a TTL of 0 for a stale cluster; got %s", ttl)
+	}
+}
+
+func TestClusterMapOptsWithDefaults(t *testing.T) {
+	opts := &ClusterMapOpts{
+		FieldMapping:  map[string]string{ClusterInfoIDField: "cluster_id"},
+		EtcdEndpoints: []string{"http://etcd-0.etcd:2379"},
+	}
+
+	o := opts.withDefaults()
+	if o.ClusterInfoMetricName != DefaultClusterInfoMetricName {
+		t.Fatalf("expected metric name %s; got %s", DefaultClusterInfoMetricName, o.ClusterInfoMetricName)
+	}
+	if o.FieldMapping[ClusterInfoIDField] != "cluster_id" || len(o.EtcdEndpoints) != 1 {
+		t.Fatalf("expected options to be copied; got %+v", o)
+	}
+
+	// the caller's options are not modified, and do not share state with the copy
+	if opts.ClusterInfoMetricName != "" {
+		t.Fatalf("expected caller's metric name to stay unset; got %s", opts.ClusterInfoMetricName)
+	}
+	opts.FieldMapping[ClusterInfoIDField] = "id"
+	opts.EtcdEndpoints[0] = "http://etcd-1.etcd:2379"
+	if o.FieldMapping[ClusterInfoIDField] != "cluster_id" || o.EtcdEndpoints[0] != "http://etcd-0.etcd:2379" {
+		t.Fatalf("expected copy to be unaffected by changes to the caller's options; got %+v", o)
+	}
+
+	var nilOpts *ClusterMapOpts
+	if o := nilOpts.withDefaults(); o.ClusterInfoMetricName != DefaultClusterInfoMetricName || o.FieldMapping == nil {
+		t.Fatalf("expected defaults for nil options; got %+v", o)
+	}
+}
+
+func TestClusterMapSetRefreshIntervalDoesNotBlock(t *testing.T) {
+	cm := newTestClusterMap()
+	cm.interval = make(chan time.Duration, 1)
+	cm.stop = make(chan struct{})
+
+	// Nothing receives the intervals, as if a refresh were in progress, so
+	// only the latest is kept
+	done := make(chan struct{})
+	go func() {
+		cm.SetRefreshInterval(time.Minute)
+		cm.SetRefreshInterval(2 * time.Minute)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("expected SetRefreshInterval not to block")
+	}
+
+	if refresh := <-cm.interval; refresh != 2*time.Minute {
+		t.Fatalf("expected the latest interval of %s; got %s", 2*time.Minute, refresh)
+	}
+	if cm.maxAge != 2*time.Minute {
+		t.Fatalf("expected max age of %s; got %s", 2*time.Minute, cm.maxAge)
+	}
+
+	cm.StopRefresh()
+	cm.StopRefresh()
+	cm.SetRefreshInterval(3 * time.Minute)
+	if len(cm.interval) != 0 || cm.maxAge != 2*time.Minute {
+		t.Fatalf("expected no effect once the refresh has been stopped")
+	}
+}
@@ -0,0 +1,227 @@
+package clusters
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+const (
+	// EtcdTimeout is the timeout of requests to an etcd endpoint
+	EtcdTimeout time.Duration = 10 * time.Second
+
+	// DefaultEtcdPrefix is the prefix of the keys of the cluster map in etcd
+	DefaultEtcdPrefix string = "/kubecost/clustermap/"
+)
+
+// etcdKeyValue is a key-value pair of the etcd v3 JSON gateway, in which keys
+// and values are base64 encoded:
+// https://etcd.io/docs/v3.5/dev-guide/api_grpc_gateway/
+type etcdKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+}
+
+// etcdRangeRequest requests the keys from Key up to RangeEnd, exclusive
+type etcdRangeRequest struct {
+	Key      string `json:"key"`
+	RangeEnd string `json:"range_end,omitempty"`
+	KeysOnly bool   `json:"keys_only,omitempty"`
+}
+
+// etcdRangeResponse is the response to an etcdRangeRequest
+type etcdRangeResponse struct {
+	Kvs []*etcdKeyValue `json:"kvs"`
+}
+
+// etcdClusterStore persists the cluster map in etcd as a JSON ClusterInfo per
+// cluster, keyed by the prefix and the cluster ID, so that the map is
+// available at startup if Prometheus is not. Requests are made to the etcd v3
+// JSON gateway of each endpoint in turn, until one succeeds.
+type etcdClusterStore struct {
+	client    *http.Client
+	endpoints []string
+	prefix    string
+}
+
+// newEtcdClusterStore creates an etcdClusterStore using the given endpoints,
+// e.g. "http://etcd-0.etcd:2379", and key prefix, which defaults to
+// DefaultEtcdPrefix.
+func newEtcdClusterStore(client *http.Client, endpoints []string, prefix string) *etcdClusterStore {
+	if prefix == "" {
+		prefix = DefaultEtcdPrefix
+	}
+
+	return &etcdClusterStore{
+		client:    client,
+		endpoints: endpoints,
+		prefix:    prefix,
+	}
+}
+
+// key returns the etcd key of the cluster with the given ID
+func (ecs *etcdClusterStore) key(id string) string {
+	return ecs.prefix + id
+}
+
+// prefixRange returns the range request of all keys with the store's prefix
+func (ecs *etcdClusterStore) prefixRange(keysOnly bool) *etcdRangeRequest {
+	return &etcdRangeRequest{
+		Key:      etcdEncode(ecs.prefix),
+		RangeEnd: etcdEncode(etcdPrefixEnd(ecs.prefix)),
+		KeysOnly: keysOnly,
+	}
+}
+
+// Save writes each cluster to its key, and deletes the keys of clusters which
+// are no longer in the map.
+func (ecs *etcdClusterStore) Save(clusters map[string]*ClusterInfo) error {
+	var stored etcdRangeResponse
+	err := ecs.post("/v3/kv/range", ecs.prefixRange(true), &stored)
+	if err != nil {
+		return err
+	}
+
+	for id, info := range clusters {
+		data, err := json.Marshal(info)
+		if err != nil {
+			return fmt.Errorf("failed to encode cluster %s: %s", id, err)
+		}
+
+		err = ecs.post("/v3/kv/put", &etcdKeyValue{Key: etcdEncode(ecs.key(id)), Value: etcdEncode(string(data))}, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, kv := range stored.Kvs {
+		key, err := etcdDecode(kv.Key)
+		if err != nil {
+			continue
+		}
+		if _, ok := clusters[strings.TrimPrefix(key, ecs.prefix)]; ok {
+			continue
+		}
+
+		err = ecs.post("/v3/kv/deleterange", &etcdRangeRequest{Key: kv.Key}, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Load reads the clusters stored under the prefix. Values which do not decode
+// are skipped.
+func (ecs *etcdClusterStore) Load() (map[string]*ClusterInfo, error) {
+	var resp etcdRangeResponse
+	err := ecs.post("/v3/kv/range", ecs.prefixRange(false), &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	clusters := make(map[string]*ClusterInfo)
+	for _, kv := range resp.Kvs {
+		value, err := etcdDecode(kv.Value)
+		if err != nil {
+			continue
+		}
+
+		var info ClusterInfo
+		err = json.Unmarshal([]byte(value), &info)
+		if err != nil || info.ID == "" {
+			continue
+		}
+		clusters[info.ID] = &info
+	}
+
+	return clusters, nil
+}
+
+// post sends the request to the given path of each endpoint in turn, decoding
+// the response of the first which succeeds into resp, if not nil.
+func (ecs *etcdClusterStore) post(path string, req interface{}, resp interface{}) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode etcd request: %s", err)
+	}
+
+	if len(ecs.endpoints) == 0 {
+		return fmt.Errorf("no etcd endpoints configured")
+	}
+
+	var errs []string
+	for _, endpoint := range ecs.endpoints {
+		data, err := ecs.postTo(strings.TrimSuffix(endpoint, "/")+path, body)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+
+		if resp != nil {
+			err = json.Unmarshal(data, resp)
+			if err != nil {
+				return fmt.Errorf("failed to decode etcd response from %s: %s", endpoint, err)
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("failed to reach etcd: %s", strings.Join(errs, "; "))
+}
+
+// postTo sends the body to the given URL, returning the body of the response.
+func (ecs *etcdClusterStore) postTo(url string, body []byte) ([]byte, error) {
+	resp, err := ecs.client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query etcd at %s: %s", url, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read etcd response from %s: %s", url, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to query etcd at %s: status %d", url, resp.StatusCode)
+	}
+
+	return data, nil
+}
+
+// etcdEncode encodes a key or value for the etcd v3 JSON gateway
+func etcdEncode(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// etcdDecode decodes a key or value from the etcd v3 JSON gateway
+func etcdDecode(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// etcdPrefixEnd returns the end of the range of keys with the given prefix,
+// which is the prefix with its last byte incremented.
+func etcdPrefixEnd(prefix string) string {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1])
+		}
+	}
+
+	// All keys
+	return "\x00"
+}
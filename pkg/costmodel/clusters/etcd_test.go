@@ -0,0 +1,174 @@
+package clusters
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+// fakeEtcd is an in-memory etcd v3 JSON gateway supporting put, range, and
+// deleterange
+type fakeEtcd struct {
+	lock sync.Mutex
+	kvs  map[string]string
+}
+
+func newFakeEtcd() *fakeEtcd {
+	return &fakeEtcd{kvs: map[string]string{}}
+}
+
+func (fe *fakeEtcd) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fe.lock.Lock()
+	defer fe.lock.Unlock()
+
+	body, _ := ioutil.ReadAll(r.Body)
+
+	switch r.URL.Path {
+	case "/v3/kv/put":
+		var kv etcdKeyValue
+		json.Unmarshal(body, &kv)
+		key, _ := etcdDecode(kv.Key)
+		value, _ := etcdDecode(kv.Value)
+		fe.kvs[key] = value
+		w.Write([]byte(`{}`))
+
+	case "/v3/kv/range":
+		var req etcdRangeRequest
+		json.Unmarshal(body, &req)
+		start, _ := etcdDecode(req.Key)
+		end, _ := etcdDecode(req.RangeEnd)
+
+		keys := []string{}
+		for key := range fe.kvs {
+			if key >= start && key < end {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		resp := etcdRangeResponse{}
+		for _, key := range keys {
+			kv := &etcdKeyValue{Key: etcdEncode(key)}
+			if !req.KeysOnly {
+				kv.Value = etcdEncode(fe.kvs[key])
+			}
+			resp.Kvs = append(resp.Kvs, kv)
+		}
+		data, _ := json.Marshal(resp)
+		w.Write(data)
+
+	case "/v3/kv/deleterange":
+		var req etcdRangeRequest
+		json.Unmarshal(body, &req)
+		key, _ := etcdDecode(req.Key)
+		delete(fe.kvs, key)
+		w.Write([]byte(`{}`))
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestEtcdClusterStore(t *testing.T) {
+	etcd := newFakeEtcd()
+	server := httptest.NewServer(etcd)
+	defer server.Close()
+
+	// An unavailable endpoint is skipped in favor of the next
+	unavailable := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer unavailable.Close()
+
+	store := newEtcdClusterStore(server.Client(), []string{unavailable.URL, server.URL + "/"}, "")
+
+	// Keys outside of the prefix are untouched
+	etcd.kvs["/other/cluster-z"] = "unrelated"
+
+	err := store.Save(map[string]*ClusterInfo{
+		"cluster-a": {ID: "cluster-a", Name: "prod-east", Provider: "AWS"},
+		"cluster-b": {ID: "cluster-b", Name: "prod-west", Provider: "GCP"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error saving: %s", err)
+	}
+	if _, ok := etcd.kvs[DefaultEtcdPrefix+"cluster-a"]; !ok {
+		t.Fatalf("expected a key per cluster; got %v", etcd.kvs)
+	}
+
+	// Clusters which are no longer in the map are deleted
+	err = store.Save(map[string]*ClusterInfo{
+		"cluster-a": {ID: "cluster-a", Name: "prod-east", Provider: "AWS"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error saving: %s", err)
+	}
+
+	clusters, err := store.Load()
+	if err != nil {
+		t.Fatalf("unexpected error loading: %s", err)
+	}
+	if len(clusters) != 1 || clusters["cluster-a"] == nil || clusters["cluster-a"].Name != "prod-east" {
+		t.Fatalf("expected only cluster-a; got %v", clusters)
+	}
+	if etcd.kvs["/other/cluster-z"] != "unrelated" {
+		t.Fatalf("expected keys outside of the prefix to be untouched")
+	}
+
+	failing := newEtcdClusterStore(unavailable.Client(), []string{unavailable.URL}, "")
+	if _, err := failing.Load(); err == nil {
+		t.Fatalf("expected error when no endpoint is available")
+	}
+}
+
+func TestEtcdPrefixEnd(t *testing.T) {
+	cases := map[string]string{
+		"/kubecost/clustermap/": "/kubecost/clustermap0",
+		"a\xff":                 "b",
+		"\xff":                  "\x00",
+	}
+	for prefix, expected := range cases {
+		if end := etcdPrefixEnd(prefix); end != expected {
+			t.Errorf("expected end of %q to be %q; got %q", prefix, expected, end)
+		}
+	}
+}
+
+func TestClusterMapRestoreClusters(t *testing.T) {
+	etcd := newFakeEtcd()
+	server := httptest.NewServer(etcd)
+	defer server.Close()
+
+	store := newEtcdClusterStore(server.Client(), []string{server.URL}, "/test/")
+	err := store.Save(map[string]*ClusterInfo{
+		"cluster-a": {ID: "cluster-a", Name: "prod-east"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error saving: %s", err)
+	}
+
+	cm := newTestClusterMap()
+	cm.store = store
+
+	cm.restoreClusters()
+	if cm.NameFor("cluster-a") != "prod-east" {
+		t.Fatalf("expected cluster-a to be restored from etcd; got %v", cm.AsMap())
+	}
+	if !cm.LastRefresh().IsZero() {
+		t.Fatalf("expected a restored map not to count as a refresh")
+	}
+
+	// Once refreshed, the map is not replaced by the persisted map
+	cm.applyClusters(map[string]*ClusterInfo{
+		"cluster-b": {ID: "cluster-b", Name: "prod-west"},
+	})
+	cm.restoreClusters()
+	if cm.InfoFor("cluster-a") != nil || cm.InfoFor("cluster-b") == nil {
+		t.Fatalf("expected the refreshed map to be kept; got %v", cm.AsMap())
+	}
+}
@@ -0,0 +1,94 @@
+package clusters
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+// HTTPSDTimeout is the timeout of requests to a Prometheus HTTP SD endpoint
+const HTTPSDTimeout time.Duration = 30 * time.Second
+
+// httpSDTargetGroup is a target group in the Prometheus HTTP SD format:
+// https://prometheus.io/docs/prometheus/latest/http_sd/
+type httpSDTargetGroup struct {
+	Targets []string          `json:"targets"`
+	Labels  map[string]string `json:"labels"`
+}
+
+// httpSDLabel returns the value of the first of the given labels which is set.
+func (tg *httpSDTargetGroup) httpSDLabel(names ...string) string {
+	for _, name := range names {
+		if value, ok := tg.Labels[name]; ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseHTTPSDClusters parses ClusterInfo entries from a Prometheus HTTP SD
+// response, in which each target group's labels describe one cluster. The
+// cluster ID is read from the "id" label or the configured Prometheus cluster
+// label, and groups without an ID are skipped. The name defaults to the ID.
+func parseHTTPSDClusters(data []byte) (map[string]*ClusterInfo, error) {
+	var groups []*httpSDTargetGroup
+	err := json.Unmarshal(data, &groups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode HTTP SD response: %s", err)
+	}
+
+	clusters := make(map[string]*ClusterInfo)
+	for _, group := range groups {
+		if group == nil {
+			continue
+		}
+
+		id := group.httpSDLabel("id", env.GetPromClusterLabel())
+		if id == "" {
+			clustersLog.WithFields(log.Fields{"targets": group.Targets}).RateLimitedWarningf("http-sd-id", "Failed to load 'id' label for HTTP SD targets %v", group.Targets)
+			continue
+		}
+
+		name := group.httpSDLabel("name", "cluster_name")
+		if name == "" {
+			name = id
+		}
+
+		clusters[id] = &ClusterInfo{
+			ID:          id,
+			Name:        name,
+			Profile:     group.httpSDLabel("clusterprofile"),
+			Provider:    group.httpSDLabel("provider"),
+			Provisioner: group.httpSDLabel("provisioner"),
+			Region:      group.httpSDLabel("region"),
+		}
+	}
+
+	return clusters, nil
+}
+
+// loadHTTPSDClusters fetches and parses the ClusterInfo entries listed by the
+// Prometheus HTTP SD endpoint at the given URL.
+func loadHTTPSDClusters(client *http.Client, endpoint string) (map[string]*ClusterInfo, error) {
+	resp, err := client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query HTTP SD endpoint %s: %s", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to query HTTP SD endpoint %s: status %d", endpoint, resp.StatusCode)
+	}
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read HTTP SD response from %s: %s", endpoint, err)
+	}
+
+	return parseHTTPSDClusters(data)
+}
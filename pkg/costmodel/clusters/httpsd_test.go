@@ -0,0 +1,126 @@
+package clusters
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testHTTPSDResponse = `[
+	{
+		"targets": ["prometheus.prod-east:9090"],
+		"labels": {"id": "cluster-a", "name": "prod-east", "provider": "AWS", "region": "us-east-1"}
+	},
+	{
+		"targets": ["prometheus.dev:9090"],
+		"labels": {"cluster_id": "cluster-d", "clusterprofile": "development"}
+	},
+	{
+		"targets": ["prometheus.unknown:9090"],
+		"labels": {"region": "us-west1"}
+	}
+]`
+
+func TestParseHTTPSDClusters(t *testing.T) {
+	clusters, err := parseHTTPSDClusters([]byte(testHTTPSDResponse))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The group without an id is skipped
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters; got %d", len(clusters))
+	}
+
+	a := clusters["cluster-a"]
+	if a == nil || a.Name != "prod-east" || a.Provider != "AWS" || a.Region != "us-east-1" {
+		t.Fatalf("unexpected cluster-a: %+v", a)
+	}
+
+	// The id falls back to the Prometheus cluster label, and the name to the id
+	d := clusters["cluster-d"]
+	if d == nil || d.Name != "cluster-d" || d.Profile != "development" {
+		t.Fatalf("unexpected cluster-d: %+v", d)
+	}
+
+	if _, err := parseHTTPSDClusters([]byte(`{"targets": []}`)); err == nil {
+		t.Fatalf("expected error for malformed response")
+	}
+}
+
+func TestLoadHTTPSDClusters(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sd" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, testHTTPSDResponse)
+	}))
+	defer server.Close()
+
+	clusters, err := loadHTTPSDClusters(server.Client(), server.URL+"/sd")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters; got %d", len(clusters))
+	}
+
+	if _, err := loadHTTPSDClusters(server.Client(), server.URL+"/missing"); err == nil {
+		t.Fatalf("expected error for non-200 response")
+	}
+}
+
+func TestLoadHTTPSDClustersMutualTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, testHTTPSDResponse)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	rootCAs := server.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs
+
+	// Without a client certificate, the handshake fails
+	opts := &ClusterMapOpts{TLSConfig: &tls.Config{RootCAs: rootCAs}}
+	if _, err := loadHTTPSDClusters(opts.httpClient(HTTPSDTimeout), server.URL); err == nil {
+		t.Fatalf("expected an error without a client certificate")
+	}
+
+	// The server accepts any client certificate, so presents its own
+	opts.TLSConfig.Certificates = server.TLS.Certificates
+	clusters, err := loadHTTPSDClusters(opts.httpClient(HTTPSDTimeout), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters; got %d", len(clusters))
+	}
+
+	// Without a TLS config, the default transport is used
+	if client := (&ClusterMapOpts{}).httpClient(HTTPSDTimeout); client.Transport != nil {
+		t.Fatalf("expected the default transport without a TLS config")
+	}
+}
+
+func TestMergeClusters(t *testing.T) {
+	clusters := map[string]*ClusterInfo{
+		"cluster-a": {ID: "cluster-a", Name: "from-metrics"},
+	}
+	mergeClusters(clusters, map[string]*ClusterInfo{
+		"cluster-a": {ID: "cluster-a", Name: "from-sd"},
+		"cluster-b": {ID: "cluster-b", Name: "prod-west"},
+	})
+
+	if len(clusters) != 2 {
+		t.Fatalf("expected 2 clusters; got %d", len(clusters))
+	}
+	if clusters["cluster-a"].Name != "from-metrics" {
+		t.Fatalf("expected clusters from metrics to take precedence; got %s", clusters["cluster-a"].Name)
+	}
+	if clusters["cluster-b"].Name != "prod-west" {
+		t.Fatalf("expected cluster-b to be merged; got %+v", clusters["cluster-b"])
+	}
+}
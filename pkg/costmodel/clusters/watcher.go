@@ -0,0 +1,153 @@
+package clusters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+)
+
+const (
+	// ClusterWatcherComponent is the source component of the events emitted by a ClusterWatcher
+	ClusterWatcherComponent = "kubecost-cluster-map"
+
+	// ClusterAddedReason is the reason of the Normal event emitted when a cluster is added
+	ClusterAddedReason = "ClusterAdded"
+
+	// ClusterRemovedReason is the reason of the Warning event emitted when a cluster is removed
+	ClusterRemovedReason = "ClusterRemoved"
+
+	// ClusterMapWatchBuffer is the number of events buffered for each watch. Events which
+	// arrive while the buffer is full are dropped.
+	ClusterMapWatchBuffer = 16
+)
+
+// ClusterWatcher emits Kubernetes Events when clusters are added to or removed from a
+// ClusterMap, so that Kubernetes-native tooling can watch for changes to the map.
+type ClusterWatcher struct {
+	recorder record.EventRecorder
+	object   runtime.Object
+	cancel   CancelFunc
+	once     sync.Once
+}
+
+// NewClusterWatcher subscribes to changes to the ClusterMap, and records an event on the
+// provided object, e.g. a reference to the kubecost namespace, through the default event
+// recorder of the broadcaster for each cluster added or removed. The broadcaster is
+// expected to already be recording to a sink.
+func NewClusterWatcher(clusterMap ClusterMap, broadcaster record.EventBroadcaster, object runtime.Object) *ClusterWatcher {
+	recorder := broadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: ClusterWatcherComponent})
+	return newClusterWatcher(clusterMap, recorder, object)
+}
+
+// newClusterWatcher subscribes a ClusterWatcher recording to the provided recorder
+func newClusterWatcher(clusterMap ClusterMap, recorder record.EventRecorder, object runtime.Object) *ClusterWatcher {
+	cw := &ClusterWatcher{
+		recorder: recorder,
+		object:   object,
+	}
+	cw.cancel = clusterMap.RegisterChangeCallback(cw.onChange)
+	return cw
+}
+
+// onChange records an event for each cluster added or removed by the change
+func (cw *ClusterWatcher) onChange(event ClusterMapEvent) {
+	for _, info := range event.Added {
+		cw.recorder.Event(cw.object, v1.EventTypeNormal, ClusterAddedReason, fmt.Sprintf("Cluster %s was added to the cluster map", clusterDescription(info)))
+	}
+	for _, info := range event.Removed {
+		cw.recorder.Event(cw.object, v1.EventTypeWarning, ClusterRemovedReason, fmt.Sprintf("Cluster %s was removed from the cluster map", clusterDescription(info)))
+	}
+}
+
+// Stop unsubscribes the ClusterWatcher from the ClusterMap. It is safe to call more than once.
+func (cw *ClusterWatcher) Stop() {
+	cw.once.Do(cw.cancel)
+}
+
+// clusterDescription returns "<name> (<id>)" for a named cluster, or its id otherwise
+func clusterDescription(info *ClusterInfo) string {
+	if info.Name == "" || info.Name == info.ID {
+		return info.ID
+	}
+	return fmt.Sprintf("%s (%s)", info.Name, info.ID)
+}
+
+// ClusterMapWatcher subscribes to changes to a ClusterMap on behalf of clients which want
+// to stay in sync with it, e.g. HTTP clients receiving server-sent events, rather than poll.
+type ClusterMapWatcher struct {
+	clusterMap ClusterMap
+}
+
+// NewClusterMapWatcher creates a ClusterMapWatcher of the ClusterMap
+func NewClusterMapWatcher(clusterMap ClusterMap) *ClusterMapWatcher {
+	return &ClusterMapWatcher{clusterMap: clusterMap}
+}
+
+// Watch returns a channel receiving an event for each refresh which changes the map, until
+// the context is done, when the channel is closed. If the map has been refreshed since the
+// provided time, e.g. the time a reconnecting client last received an event, the first event
+// lists the entries of the map as Added, so that the client can catch up; removals before
+// the watch began cannot be reported. A zero since sends no catch-up event.
+func (cmw *ClusterMapWatcher) Watch(ctx context.Context, since time.Time) (<-chan ClusterMapEvent, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ch := make(chan ClusterMapEvent, ClusterMapWatchBuffer)
+	var lock sync.Mutex
+	closed := false
+
+	// Callbacks are called synchronously by the refresh, so must not block on the client
+	send := func(event ClusterMapEvent) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if closed {
+			return
+		}
+		select {
+		case ch <- event:
+		default:
+			clustersLog.Warningf("Dropped cluster map event: watch buffer of %d is full", ClusterMapWatchBuffer)
+		}
+	}
+
+	cancel := cmw.clusterMap.RegisterChangeCallback(send)
+
+	if !since.IsZero() && cmw.clusterMap.LastRefresh().After(since) {
+		if event := catchUpEvent(cmw.clusterMap.AsMap()); !event.IsEmpty() {
+			send(event)
+		}
+	}
+
+	go func() {
+		<-ctx.Done()
+		cancel()
+
+		lock.Lock()
+		defer lock.Unlock()
+		closed = true
+		close(ch)
+	}()
+
+	return ch, nil
+}
+
+// catchUpEvent returns an event listing each of the entries as Added, sorted by ID
+func catchUpEvent(clusters map[string]*ClusterInfo) ClusterMapEvent {
+	event := ClusterMapEvent{}
+	for _, info := range clusters {
+		event.Added = append(event.Added, info)
+	}
+	sort.Slice(event.Added, func(i, j int) bool {
+		return event.Added[i].ID < event.Added[j].ID
+	})
+	return event
+}
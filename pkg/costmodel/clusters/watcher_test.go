@@ -0,0 +1,108 @@
+package clusters
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestClusterWatcher(t *testing.T) {
+	cm := newTestClusterMap(&ClusterInfo{ID: "cluster-a", Name: "prod-east"})
+
+	recorder := record.NewFakeRecorder(10)
+	cw := newClusterWatcher(cm, recorder, &v1.ObjectReference{Kind: "Namespace", Name: "kubecost"})
+
+	cm.applyClusters(map[string]*ClusterInfo{
+		"cluster-b": {ID: "cluster-b"},
+	})
+
+	expected := []string{
+		"Normal ClusterAdded Cluster cluster-b was added to the cluster map",
+		"Warning ClusterRemoved Cluster prod-east (cluster-a) was removed from the cluster map",
+	}
+	for _, e := range expected {
+		select {
+		case event := <-recorder.Events:
+			if event != e {
+				t.Fatalf("expected event %q; got %q", e, event)
+			}
+		default:
+			t.Fatalf("expected event %q; got none", e)
+		}
+	}
+
+	// Once stopped, no more events are emitted
+	cw.Stop()
+	cw.Stop()
+	cm.applyClusters(map[string]*ClusterInfo{})
+	if len(recorder.Events) != 0 {
+		t.Fatalf("expected no events after stopping; got %d", len(recorder.Events))
+	}
+}
+
+func TestClusterMapWatcher(t *testing.T) {
+	cm := newTestClusterMap()
+	cm.applyClusters(map[string]*ClusterInfo{
+		"cluster-a": {ID: "cluster-a"},
+		"cluster-b": {ID: "cluster-b"},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// A client which last synced before the refresh catches up with the current entries
+	events, err := NewClusterMapWatcher(cm).Watch(ctx, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	select {
+	case event := <-events:
+		if ids := foundIDs(event.Added); len(ids) != 2 || ids[0] != "cluster-a" || ids[1] != "cluster-b" {
+			t.Fatalf("expected catch-up event adding cluster-a and cluster-b; got %v", ids)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected a catch-up event")
+	}
+
+	cm.applyClusters(map[string]*ClusterInfo{
+		"cluster-a": {ID: "cluster-a"},
+	})
+
+	select {
+	case event := <-events:
+		if ids := foundIDs(event.Removed); len(ids) != 1 || ids[0] != "cluster-b" {
+			t.Fatalf("expected event removing cluster-b; got %v", ids)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected an event for the refresh")
+	}
+
+	// A client which is up to date gets no catch-up event
+	upToDate, err := NewClusterMapWatcher(cm).Watch(ctx, time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(upToDate) != 0 {
+		t.Fatalf("expected no catch-up event; got %d", len(upToDate))
+	}
+
+	// The channel is closed once the context is done, after which refreshes are not sent
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatalf("expected no more events")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the channel to be closed")
+	}
+	cm.applyClusters(map[string]*ClusterInfo{})
+
+	if _, err := NewClusterMapWatcher(cm).Watch(ctx, time.Time{}); err != context.Canceled {
+		t.Errorf("expected error %s watching with a done context; got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,555 @@
+package costmodel
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/cloud/pricing"
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util"
+	"github.com/kubecost/cost-model/pkg/util/httputil"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+)
+
+// DefaultClusterSizingMaxPods is the pod capacity assumed for node types which
+// do not report one. This matches the kubelet's default.
+const DefaultClusterSizingMaxPods = 110
+
+// ClusterSizingOptions configures cluster sizing recommendations.
+type ClusterSizingOptions struct {
+	// Headroom is the fraction of additional CPU, RAM, and pod capacity to
+	// provision beyond the observed demand, e.g. 0.2 for 20%.
+	Headroom float64
+
+	// MaxConfigurations is the maximum number of configurations to recommend.
+	MaxConfigurations int
+}
+
+// DefaultClusterSizingOptions returns ClusterSizingOptions with default values
+// set.
+func DefaultClusterSizingOptions() *ClusterSizingOptions {
+	return &ClusterSizingOptions{
+		Headroom:          0.2,
+		MaxConfigurations: 2,
+	}
+}
+
+// ClusterSizingNodeType is a node type available for purchase, along with its
+// capacity and price.
+type ClusterSizingNodeType struct {
+	Name       string  `json:"name"`
+	CPUCores   float64 `json:"cpuCores"`
+	RAMBytes   float64 `json:"ramBytes"`
+	GPUs       float64 `json:"gpus"`
+	MaxPods    int     `json:"maxPods"`
+	HourlyCost float64 `json:"hourlyCost"`
+}
+
+// ClusterSizingResources is a quantity of the resources a workload requires,
+// or a node provides.
+type ClusterSizingResources struct {
+	CPUCores float64 `json:"cpuCores"`
+	RAMBytes float64 `json:"ramBytes"`
+	GPUs     float64 `json:"gpus"`
+	Pods     float64 `json:"pods"`
+}
+
+// ClusterSizingDemand describes the resources a cluster must provide. GPU
+// workloads are sized separately because they must run on GPU nodes, and the
+// daemonset overhead is reserved on every node.
+type ClusterSizingDemand struct {
+	General           ClusterSizingResources `json:"general"`
+	GPU               ClusterSizingResources `json:"gpu"`
+	DaemonSetOverhead ClusterSizingResources `json:"daemonSetOverheadPerNode"`
+}
+
+// ClusterSizingPool is a number of nodes of a single type.
+type ClusterSizingPool struct {
+	NodeType    *ClusterSizingNodeType `json:"nodeType"`
+	Count       int                    `json:"count"`
+	MonthlyCost float64                `json:"monthlyCost"`
+}
+
+// ClusterSizingConfiguration is a set of node pools which can run the
+// cluster's workloads.
+type ClusterSizingConfiguration struct {
+	Pools          []*ClusterSizingPool `json:"pools"`
+	MonthlyCost    float64              `json:"monthlyCost"`
+	MonthlySavings float64              `json:"monthlySavings"`
+}
+
+// ClusterSizingRecommendation compares the current cost of a cluster with the
+// cost of the recommended configurations.
+type ClusterSizingRecommendation struct {
+	Demand             *ClusterSizingDemand          `json:"demand"`
+	Headroom           float64                       `json:"headroom"`
+	CurrentNodes       int                           `json:"currentNodes"`
+	CurrentMonthlyCost float64                       `json:"currentMonthlyCost"`
+	Configurations     []*ClusterSizingConfiguration `json:"configurations"`
+}
+
+// ComputeClusterSizingDemand computes the average concurrent resources
+// required by the workloads in the given AllocationSet. Each workload requires
+// the greater of its requests and its usage. Daemonsets are reported as a
+// per-node overhead rather than as demand.
+func ComputeClusterSizingDemand(as *kubecost.AllocationSet) *ClusterSizingDemand {
+	demand := &ClusterSizingDemand{}
+
+	if as == nil || as.IsEmpty() {
+		return demand
+	}
+
+	windowMins := as.End().Sub(as.Start()).Minutes()
+	if windowMins <= 0 {
+		return demand
+	}
+
+	allocs := []*kubecost.Allocation{}
+	as.Each(func(name string, alloc *kubecost.Allocation) {
+		if alloc.IsIdle() || alloc.IsUnmounted() || alloc.IsExternal() || alloc.IsUnallocated() {
+			return
+		}
+		if alloc.Properties == nil || alloc.Minutes() <= 0 {
+			return
+		}
+		allocs = append(allocs, alloc)
+	})
+
+	// A pod is a GPU workload if any of its containers uses a GPU
+	gpuPods := map[podKey]bool{}
+	for _, alloc := range allocs {
+		if alloc.GPUHours > 0 {
+			props := alloc.Properties
+			gpuPods[newPodKey(props.Cluster, props.Namespace, props.Pod)] = true
+		}
+	}
+
+	podMinutes := map[podKey]float64{}
+	dsRequests := map[controllerKey]*ClusterSizingResources{}
+	dsPodMinutes := map[controllerKey]map[podKey]float64{}
+
+	for _, alloc := range allocs {
+		props := alloc.Properties
+		pk := newPodKey(props.Cluster, props.Namespace, props.Pod)
+		mins := alloc.Minutes()
+
+		cpuMins := math.Max(alloc.CPUCoreRequestAverage, alloc.CPUCoreUsageAverage) * mins
+		ramMins := math.Max(alloc.RAMBytesRequestAverage, alloc.RAMBytesUsageAverage) * mins
+
+		if props.ControllerKind == "daemonset" {
+			ck := newControllerKey(props.Cluster, props.Namespace, props.ControllerKind, props.Controller)
+			if _, ok := dsRequests[ck]; !ok {
+				dsRequests[ck] = &ClusterSizingResources{}
+				dsPodMinutes[ck] = map[podKey]float64{}
+			}
+			dsRequests[ck].CPUCores += cpuMins
+			dsRequests[ck].RAMBytes += ramMins
+			dsPodMinutes[ck][pk] = math.Max(dsPodMinutes[ck][pk], mins)
+			continue
+		}
+
+		res := &demand.General
+		if gpuPods[pk] {
+			res = &demand.GPU
+		}
+
+		res.CPUCores += cpuMins / windowMins
+		res.RAMBytes += ramMins / windowMins
+		res.GPUs += alloc.GPUHours / (windowMins / timeutil.MinsPerHour)
+
+		podMinutes[pk] = math.Max(podMinutes[pk], mins)
+	}
+
+	for pk, mins := range podMinutes {
+		if gpuPods[pk] {
+			demand.GPU.Pods += mins / windowMins
+		} else {
+			demand.General.Pods += mins / windowMins
+		}
+	}
+
+	// Each daemonset runs one pod per node, so its overhead per node is the
+	// average requirement of one of its pods
+	for ck, req := range dsRequests {
+		totalPodMins := 0.0
+		for _, mins := range dsPodMinutes[ck] {
+			totalPodMins += mins
+		}
+		if totalPodMins <= 0 {
+			continue
+		}
+
+		demand.DaemonSetOverhead.CPUCores += req.CPUCores / totalPodMins
+		demand.DaemonSetOverhead.RAMBytes += req.RAMBytes / totalPodMins
+		demand.DaemonSetOverhead.Pods++
+	}
+
+	return demand
+}
+
+// nodesRequired returns the number of nodes of the given type needed to run
+// the given demand with headroom, after reserving the daemonset overhead on
+// each node. Returns false if the node type cannot run the demand.
+func nodesRequired(nt *ClusterSizingNodeType, demand, overhead ClusterSizingResources, headroom float64) (int, bool) {
+	if demand.CPUCores <= 0 && demand.RAMBytes <= 0 && demand.GPUs <= 0 && demand.Pods <= 0 {
+		return 0, true
+	}
+
+	maxPods := nt.MaxPods
+	if maxPods <= 0 {
+		maxPods = DefaultClusterSizingMaxPods
+	}
+
+	usableCPU := nt.CPUCores - overhead.CPUCores
+	usableRAM := nt.RAMBytes - overhead.RAMBytes
+	usablePods := float64(maxPods) - overhead.Pods
+	if usableCPU <= 0 || usableRAM <= 0 || usablePods <= 0 {
+		return 0, false
+	}
+
+	count := 1.0
+	count = math.Max(count, math.Ceil(demand.CPUCores*(1.0+headroom)/usableCPU))
+	count = math.Max(count, math.Ceil(demand.RAMBytes*(1.0+headroom)/usableRAM))
+	count = math.Max(count, math.Ceil(demand.Pods*(1.0+headroom)/usablePods))
+
+	if demand.GPUs > 0 {
+		if nt.GPUs <= 0 {
+			return 0, false
+		}
+		count = math.Max(count, math.Ceil(demand.GPUs/nt.GPUs))
+	}
+
+	return int(count), true
+}
+
+// sizePools returns a pool of each node type in the catalog which can run the
+// given demand, sorted by ascending monthly cost.
+func sizePools(catalog []*ClusterSizingNodeType, demand, overhead ClusterSizingResources, headroom float64) []*ClusterSizingPool {
+	pools := []*ClusterSizingPool{}
+
+	for _, nt := range catalog {
+		count, ok := nodesRequired(nt, demand, overhead, headroom)
+		if !ok {
+			continue
+		}
+
+		pools = append(pools, &ClusterSizingPool{
+			NodeType:    nt,
+			Count:       count,
+			MonthlyCost: float64(count) * nt.HourlyCost * timeutil.HoursPerMonth,
+		})
+	}
+
+	sort.SliceStable(pools, func(i, j int) bool {
+		if pools[i].MonthlyCost == pools[j].MonthlyCost {
+			return pools[i].NodeType.Name < pools[j].NodeType.Name
+		}
+		return pools[i].MonthlyCost < pools[j].MonthlyCost
+	})
+
+	return pools
+}
+
+// RecommendClusterSizing proposes the cheapest node configurations from the
+// given catalog which can run the given demand with headroom. GPU workloads
+// are placed on the cheapest GPU node type which fits them, and general
+// workloads on non-GPU node types where the catalog has any.
+func RecommendClusterSizing(demand *ClusterSizingDemand, catalog []*ClusterSizingNodeType, currentMonthlyCost float64, opts *ClusterSizingOptions) ([]*ClusterSizingConfiguration, error) {
+	if opts == nil {
+		opts = DefaultClusterSizingOptions()
+	}
+
+	if demand == nil {
+		demand = &ClusterSizingDemand{}
+	}
+
+	gpuTypes := []*ClusterSizingNodeType{}
+	generalTypes := []*ClusterSizingNodeType{}
+	for _, nt := range catalog {
+		if nt.GPUs > 0 {
+			gpuTypes = append(gpuTypes, nt)
+		} else {
+			generalTypes = append(generalTypes, nt)
+		}
+	}
+	if len(generalTypes) == 0 {
+		generalTypes = catalog
+	}
+
+	var gpuPool *ClusterSizingPool
+	if demand.GPU.GPUs > 0 {
+		gpuPools := sizePools(gpuTypes, demand.GPU, demand.DaemonSetOverhead, opts.Headroom)
+		if len(gpuPools) == 0 {
+			return nil, fmt.Errorf("no node type in the catalog satisfies the GPU requirements")
+		}
+		gpuPool = gpuPools[0]
+	}
+
+	generalPools := sizePools(generalTypes, demand.General, demand.DaemonSetOverhead, opts.Headroom)
+	if len(generalPools) == 0 {
+		return nil, fmt.Errorf("no node type in the catalog satisfies the requirements")
+	}
+
+	configs := []*ClusterSizingConfiguration{}
+	for _, pool := range generalPools {
+		if len(configs) >= opts.MaxConfigurations {
+			break
+		}
+
+		config := &ClusterSizingConfiguration{Pools: []*ClusterSizingPool{}}
+		if pool.Count > 0 {
+			config.Pools = append(config.Pools, pool)
+			config.MonthlyCost += pool.MonthlyCost
+		}
+		if gpuPool != nil {
+			config.Pools = append(config.Pools, gpuPool)
+			config.MonthlyCost += gpuPool.MonthlyCost
+		}
+		config.MonthlySavings = currentMonthlyCost - config.MonthlyCost
+
+		configs = append(configs, config)
+
+		// Without general demand every pool is empty, so there is only one
+		// distinct configuration
+		if pool.Count == 0 {
+			break
+		}
+	}
+
+	return configs, nil
+}
+
+// parseNodeFloat parses a numeric field of a cloud.Node, returning 0.0 if the
+// field is empty or invalid.
+func parseNodeFloat(s string) float64 {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) {
+		return 0.0
+	}
+	return f
+}
+
+// clusterSizingNodeType converts a priced cloud.Node into a node type.
+func clusterSizingNodeType(name string, node *cloud.Node, maxPods int) *ClusterSizingNodeType {
+	cpu := parseNodeFloat(node.VCPU)
+	ram := parseNodeFloat(node.RAMBytes)
+	gpus := parseNodeFloat(node.GPU)
+
+	hourlyCost := parseNodeFloat(node.Cost)
+	if hourlyCost == 0.0 {
+		hourlyCost = cpu*parseNodeFloat(node.VCPUCost) + (ram/1024/1024/1024)*parseNodeFloat(node.RAMCost) + gpus*parseNodeFloat(node.GPUCost)
+	}
+
+	return &ClusterSizingNodeType{
+		Name:       name,
+		CPUCores:   cpu,
+		RAMBytes:   ram,
+		GPUs:       gpus,
+		MaxPods:    maxPods,
+		HourlyCost: hourlyCost,
+	}
+}
+
+// parseAWSMemory parses the memory of an AWS product, e.g. "16 GiB" or
+// "1,952 GiB", into bytes, returning 0.0 if it is invalid.
+func parseAWSMemory(memory string) float64 {
+	gib := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(memory), "GiB"))
+	return parseNodeFloat(strings.ReplaceAll(gib, ",", "")) * 1024 * 1024 * 1024
+}
+
+// providerNodeTypes returns the on-demand node types in the given regions of
+// the provider's full node pricing, as returned by AllNodePricing. Only
+// pricing which includes the capacity of its node type can be sized, e.g. the
+// products of AWS, so entries without a capacity are omitted.
+func providerNodeTypes(allPricing interface{}, regions map[string]bool) []*ClusterSizingNodeType {
+	catalog := []*ClusterSizingNodeType{}
+
+	// Pricing keys are "region,instanceType,usageType" or, on AWS,
+	// "region,instanceType,operatingSystem", suffixed by ",preemptible" for
+	// spot pricing
+	keyFields := func(key string) (string, string, string, bool) {
+		fields := strings.Split(key, ",")
+		if len(fields) != 3 || !regions[fields[0]] {
+			return "", "", "", false
+		}
+		return fields[0], fields[1], fields[2], true
+	}
+
+	addNode := func(key string, node *cloud.Node) {
+		_, instanceType, usageType, ok := keyFields(key)
+		if !ok || node == nil || usageType != "ondemand" {
+			return
+		}
+
+		nt := clusterSizingNodeType(instanceType, node, 0)
+		if nt.CPUCores > 0 && nt.RAMBytes > 0 && nt.HourlyCost > 0 {
+			catalog = append(catalog, nt)
+		}
+	}
+
+	switch p := allPricing.(type) {
+	case map[string]*cloud.AWSProductTerms:
+		for key, terms := range p {
+			_, instanceType, os, ok := keyFields(key)
+			if !ok || terms == nil || os != "linux" {
+				continue
+			}
+
+			cost, ok := pricing.AWSOnDemandCost(terms)
+			if !ok || cost <= 0 {
+				continue
+			}
+
+			nt := &ClusterSizingNodeType{
+				Name:       instanceType,
+				CPUCores:   parseNodeFloat(terms.VCpu),
+				RAMBytes:   parseAWSMemory(terms.Memory),
+				GPUs:       parseNodeFloat(terms.GPU),
+				HourlyCost: cost,
+			}
+			if nt.CPUCores > 0 && nt.RAMBytes > 0 {
+				catalog = append(catalog, nt)
+			}
+		}
+	case map[string]*cloud.GCPPricing:
+		for key, gp := range p {
+			if gp != nil {
+				addNode(key, gp.Node)
+			}
+		}
+	case map[string]*cloud.AzurePricing:
+		for key, ap := range p {
+			if ap != nil {
+				addNode(key, ap.Node)
+			}
+		}
+	}
+
+	return catalog
+}
+
+// ComputeClusterSizing computes the cluster's demand over the given window and
+// recommends node configurations from the node types in the provider's
+// pricing in the cluster's regions, along with the node types currently in
+// the cluster.
+func (cm *CostModel) ComputeClusterSizing(cp cloud.Provider, window kubecost.Window, resolution time.Duration, opts *ClusterSizingOptions) (*ClusterSizingRecommendation, error) {
+	as, err := cm.ComputeAllocation(*window.Start(), *window.End(), resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := cm.GetNodeCost(cp)
+	if err != nil {
+		return nil, err
+	}
+
+	allPricing, err := cp.AllNodePricing()
+	if err != nil {
+		return nil, err
+	}
+
+	maxPods := map[string]int{}
+	regions := map[string]bool{}
+	for _, n := range cm.Cache.GetAllNodes() {
+		if pods, ok := n.Status.Allocatable["pods"]; ok {
+			maxPods[n.GetName()] = int(pods.Value())
+		}
+		if region, ok := util.GetRegion(n.Labels); ok && region != "" {
+			regions[region] = true
+		}
+	}
+
+	rec := &ClusterSizingRecommendation{
+		Demand:       ComputeClusterSizingDemand(as),
+		Headroom:     opts.Headroom,
+		CurrentNodes: len(nodes),
+	}
+
+	// The node types currently in the cluster come first in the catalog, as
+	// their prices reflect any discounts, and their pod capacity is known
+	catalog := []*ClusterSizingNodeType{}
+	seen := map[string]bool{}
+	for name, node := range nodes {
+		nt := clusterSizingNodeType(node.InstanceType, node, maxPods[name])
+		rec.CurrentMonthlyCost += nt.HourlyCost * timeutil.HoursPerMonth
+
+		if nt.Name == "" {
+			nt.Name = name
+		}
+		if seen[nt.Name] {
+			continue
+		}
+		seen[nt.Name] = true
+		catalog = append(catalog, nt)
+	}
+
+	for _, nt := range providerNodeTypes(allPricing, regions) {
+		if seen[nt.Name] {
+			continue
+		}
+		seen[nt.Name] = true
+		catalog = append(catalog, nt)
+	}
+
+	rec.Configurations, err = RecommendClusterSizing(rec.Demand, catalog, rec.CurrentMonthlyCost, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return rec, nil
+}
+
+// ClusterSizingHandler returns recommended node configurations for the
+// cluster, based on its workloads' requests and usage over the given window.
+func (a *Accesses) ClusterSizingHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	qp := httputil.NewQueryParams(r.URL.Query())
+
+	conversion, err := a.currencyConversion(qp)
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'currency' parameter: %s", err)))
+		return
+	}
+
+	window, err := kubecost.ParseWindowWithOffset(qp.Get("window", "7d"), env.GetParsedUTCOffset())
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'window' parameter: %s", err)))
+		return
+	}
+	if window.IsOpen() || window.IsNegative() {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'window' parameter: %s", window)))
+		return
+	}
+
+	opts := DefaultClusterSizingOptions()
+	opts.Headroom = qp.GetFloat64("headroom", opts.Headroom)
+	opts.MaxConfigurations = qp.GetInt("maxConfigurations", opts.MaxConfigurations)
+	if opts.Headroom < 0.0 {
+		WriteError(w, BadRequest("'headroom' must be non-negative"))
+		return
+	}
+	if opts.MaxConfigurations <= 0 {
+		WriteError(w, BadRequest("'maxConfigurations' must be positive"))
+		return
+	}
+
+	resolution := qp.GetDuration("resolution", env.GetETLResolution())
+
+	rec, err := a.Model.ComputeClusterSizing(a.CloudProvider, window, resolution, opts)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	writeWithCurrency(w, rec, conversion)
+}
@@ -0,0 +1,188 @@
+package costmodel
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+)
+
+const testGiB = 1024.0 * 1024.0 * 1024.0
+
+func newClusterSizingTestAllocation(start, end time.Time, kind, controller, pod string, cpuReq, cpuUsage, ramReq, gpus float64) *kubecost.Allocation {
+	return &kubecost.Allocation{
+		Name:  fmt.Sprintf("cluster1/ns1/%s/container", pod),
+		Start: start,
+		End:   end,
+		Properties: &kubecost.AllocationProperties{
+			Cluster:        "cluster1",
+			Namespace:      "ns1",
+			ControllerKind: kind,
+			Controller:     controller,
+			Pod:            pod,
+			Container:      "container",
+		},
+		CPUCoreRequestAverage:  cpuReq,
+		CPUCoreUsageAverage:    cpuUsage,
+		RAMBytesRequestAverage: ramReq,
+		GPUHours:               gpus * end.Sub(start).Hours(),
+	}
+}
+
+func TestComputeClusterSizingDemand(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	half := start.Add(12 * time.Hour)
+
+	as := kubecost.NewAllocationSet(start, end,
+		// usage above request => usage counts
+		newClusterSizingTestAllocation(start, end, "deployment", "web", "web-1", 1.0, 2.0, 4.0*testGiB, 0.0),
+		// half the window => half a pod
+		newClusterSizingTestAllocation(start, half, "deployment", "web", "web-2", 2.0, 0.0, 4.0*testGiB, 0.0),
+		newClusterSizingTestAllocation(start, end, "deployment", "train", "train-1", 4.0, 0.0, 16.0*testGiB, 1.0),
+		newClusterSizingTestAllocation(start, end, "daemonset", "agent", "agent-1", 0.5, 0.0, 1.0*testGiB, 0.0),
+		newClusterSizingTestAllocation(start, end, "daemonset", "agent", "agent-2", 0.5, 0.0, 1.0*testGiB, 0.0),
+	)
+
+	demand := ComputeClusterSizingDemand(as)
+
+	if demand.General.CPUCores != 3.0 {
+		t.Fatalf("expected general CPU of 3.0; got %f", demand.General.CPUCores)
+	}
+	if demand.General.RAMBytes != 6.0*testGiB {
+		t.Fatalf("expected general RAM of %f; got %f", 6.0*testGiB, demand.General.RAMBytes)
+	}
+	if demand.General.Pods != 1.5 {
+		t.Fatalf("expected 1.5 general pods; got %f", demand.General.Pods)
+	}
+	if demand.GPU.GPUs != 1.0 || demand.GPU.CPUCores != 4.0 || demand.GPU.Pods != 1.0 {
+		t.Fatalf("unexpected GPU demand: %+v", demand.GPU)
+	}
+	if demand.DaemonSetOverhead.CPUCores != 0.5 || demand.DaemonSetOverhead.RAMBytes != 1.0*testGiB || demand.DaemonSetOverhead.Pods != 1.0 {
+		t.Fatalf("unexpected daemonset overhead: %+v", demand.DaemonSetOverhead)
+	}
+}
+
+func TestRecommendClusterSizing(t *testing.T) {
+	small := &ClusterSizingNodeType{Name: "small", CPUCores: 2.0, RAMBytes: 8.0 * testGiB, MaxPods: 110, HourlyCost: 0.10}
+	large := &ClusterSizingNodeType{Name: "large", CPUCores: 8.0, RAMBytes: 32.0 * testGiB, MaxPods: 110, HourlyCost: 0.30}
+	dense := &ClusterSizingNodeType{Name: "dense", CPUCores: 8.0, RAMBytes: 32.0 * testGiB, MaxPods: 4, HourlyCost: 0.20}
+	gpu := &ClusterSizingNodeType{Name: "gpu", CPUCores: 8.0, RAMBytes: 32.0 * testGiB, GPUs: 1.0, MaxPods: 110, HourlyCost: 1.00}
+	catalog := []*ClusterSizingNodeType{small, large, dense, gpu}
+
+	demand := &ClusterSizingDemand{
+		General:           ClusterSizingResources{CPUCores: 10.0, RAMBytes: 20.0 * testGiB, Pods: 20.0},
+		GPU:               ClusterSizingResources{CPUCores: 4.0, RAMBytes: 8.0 * testGiB, GPUs: 2.0, Pods: 2.0},
+		DaemonSetOverhead: ClusterSizingResources{CPUCores: 0.5, RAMBytes: 1.0 * testGiB, Pods: 1.0},
+	}
+
+	opts := &ClusterSizingOptions{Headroom: 0.0, MaxConfigurations: 2}
+
+	current := 10.0 * 0.30 * timeutil.HoursPerMonth
+
+	configs, err := RecommendClusterSizing(demand, catalog, current, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("expected 2 configurations; got %d", len(configs))
+	}
+
+	// large: 7.5 usable cores => 2 nodes at 0.30 = 0.60/hr
+	// small: 1.5 usable cores => 7 nodes at 0.10 = 0.70/hr
+	// dense: 3 usable pods => 7 nodes at 0.20 = 1.40/hr
+	// gpu: 2 GPUs => 2 nodes at 1.00 = 2.00/hr
+	expected := []struct {
+		name  string
+		count int
+	}{
+		{"large", 2},
+		{"small", 7},
+	}
+
+	for i, exp := range expected {
+		config := configs[i]
+		if len(config.Pools) != 2 {
+			t.Fatalf("expected 2 pools; got %d", len(config.Pools))
+		}
+		if config.Pools[0].NodeType.Name != exp.name || config.Pools[0].Count != exp.count {
+			t.Fatalf("expected %d x %s; got %d x %s", exp.count, exp.name, config.Pools[0].Count, config.Pools[0].NodeType.Name)
+		}
+		if config.Pools[1].NodeType.Name != "gpu" || config.Pools[1].Count != 2 {
+			t.Fatalf("expected 2 x gpu; got %d x %s", config.Pools[1].Count, config.Pools[1].NodeType.Name)
+		}
+	}
+
+	expCost := (2.0*0.30 + 2.0*1.00) * timeutil.HoursPerMonth
+	if math.Abs(configs[0].MonthlyCost-expCost) > 0.0001 {
+		t.Fatalf("expected monthly cost of %f; got %f", expCost, configs[0].MonthlyCost)
+	}
+	if math.Abs(configs[0].MonthlySavings-(current-expCost)) > 0.0001 {
+		t.Fatalf("expected monthly savings of %f; got %f", current-expCost, configs[0].MonthlySavings)
+	}
+
+	// Headroom of 60% requires 16 cores => 3 large nodes
+	opts.Headroom = 0.6
+	configs, err = RecommendClusterSizing(demand, catalog, current, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if configs[0].Pools[0].NodeType.Name != "large" || configs[0].Pools[0].Count != 3 {
+		t.Fatalf("expected 3 x large; got %d x %s", configs[0].Pools[0].Count, configs[0].Pools[0].NodeType.Name)
+	}
+
+	// Without a GPU node type, GPU demand cannot be satisfied
+	_, err = RecommendClusterSizing(demand, []*ClusterSizingNodeType{small, large}, current, opts)
+	if err == nil {
+		t.Fatalf("expected error for catalog without GPU nodes")
+	}
+}
+
+func TestProviderNodeTypes(t *testing.T) {
+	terms := func(sku, vcpu, memory, usd string) *cloud.AWSProductTerms {
+		return &cloud.AWSProductTerms{
+			Sku:    sku,
+			VCpu:   vcpu,
+			Memory: memory,
+			OnDemand: &cloud.AWSOfferTerm{
+				Sku: sku,
+				PriceDimensions: map[string]*cloud.AWSRateCode{
+					sku + cloud.OnDemandRateCode + cloud.HourlyRateCode: {
+						Unit:         "Hrs",
+						PricePerUnit: cloud.AWSCurrencyCode{USD: usd},
+					},
+				},
+			},
+		}
+	}
+
+	large := terms("ABC", "2", "8 GiB", "0.096")
+	allPricing := map[string]*cloud.AWSProductTerms{
+		"us-east-1,m5.large,linux":             large,
+		"us-east-1,m5.large,linux,preemptible": large,
+		"us-east-1,x1.32xlarge,linux":          terms("DEF", "128", "1,952 GiB", "13.338"),
+		"us-east-1,m5.large,windows":           terms("GHI", "2", "8 GiB", "0.188"),
+		"us-west-2,m5.xlarge,linux":            terms("JKL", "4", "16 GiB", "0.192"),
+		"us-east-1,ebs.gp2":                    {Sku: "MNO", PV: &cloud.PV{}},
+	}
+
+	catalog := providerNodeTypes(allPricing, map[string]bool{"us-east-1": true})
+	if len(catalog) != 2 {
+		t.Fatalf("expected 2 node types; got %d", len(catalog))
+	}
+
+	byName := map[string]*ClusterSizingNodeType{}
+	for _, nt := range catalog {
+		byName[nt.Name] = nt
+	}
+	if nt := byName["m5.large"]; nt == nil || nt.CPUCores != 2.0 || nt.RAMBytes != 8.0*testGiB || nt.HourlyCost != 0.096 {
+		t.Fatalf("unexpected m5.large node type: %+v", nt)
+	}
+	if nt := byName["x1.32xlarge"]; nt == nil || nt.RAMBytes != 1952.0*testGiB {
+		t.Fatalf("unexpected x1.32xlarge node type: %+v", nt)
+	}
+}
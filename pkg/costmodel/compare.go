@@ -0,0 +1,272 @@
+package costmodel
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util/httputil"
+)
+
+const (
+	// AllocationComparisonChanged indicates that a key was present in both windows
+	AllocationComparisonChanged = "changed"
+
+	// AllocationComparisonNew indicates that a key was only present in the
+	// second window
+	AllocationComparisonNew = "new"
+
+	// AllocationComparisonRemoved indicates that a key was only present in the
+	// first window
+	AllocationComparisonRemoved = "removed"
+)
+
+// AllocationComparison is the change in cost of a single aggregated key from
+// one window to another. Where the allocations record resource hours, the
+// change in CPU, RAM, GPU, and PV cost is decomposed into the change due to
+// the price per hour and the change due to the number of hours used; the
+// remainder, e.g. network, load balancer, and shared costs, is Other.
+type AllocationComparison struct {
+	Name           string   `json:"name"`
+	Status         string   `json:"status"`
+	BeforeCost     float64  `json:"beforeCost"`
+	AfterCost      float64  `json:"afterCost"`
+	CostDelta      float64  `json:"costDelta"`
+	PercentChange  *float64 `json:"percentChange,omitempty"`
+	PriceCostDelta float64  `json:"priceCostDelta"`
+	UsageCostDelta float64  `json:"usageCostDelta"`
+	OtherCostDelta float64  `json:"otherCostDelta"`
+}
+
+// AllocationComparisonReport is the comparison of the aggregated allocations
+// of two windows. Comparisons are sorted by the magnitude of their change in
+// cost, descending, and may be limited to the largest; NewKeys and
+// RemovedKeys always list every key only present in one window.
+type AllocationComparisonReport struct {
+	Before      kubecost.Window         `json:"before"`
+	After       kubecost.Window         `json:"after"`
+	Aggregate   []string                `json:"aggregate"`
+	BeforeCost  float64                 `json:"beforeCost"`
+	AfterCost   float64                 `json:"afterCost"`
+	CostDelta   float64                 `json:"costDelta"`
+	Comparisons []*AllocationComparison `json:"comparisons"`
+	NewKeys     []string                `json:"newKeys"`
+	RemovedKeys []string                `json:"removedKeys"`
+}
+
+// CompareAllocationSets compares the allocations of two sets, which are
+// expected to be aggregated by the same properties, by name. If topN is
+// positive, only the topN largest changes are returned.
+func CompareAllocationSets(before, after *kubecost.AllocationSet, topN int) *AllocationComparisonReport {
+	report := &AllocationComparisonReport{
+		Before:      before.Window.Clone(),
+		After:       after.Window.Clone(),
+		Comparisons: []*AllocationComparison{},
+		NewKeys:     []string{},
+		RemovedKeys: []string{},
+	}
+
+	before.Each(func(name string, b *kubecost.Allocation) {
+		report.BeforeCost += b.TotalCost()
+
+		a := after.Get(name)
+		if a == nil {
+			report.RemovedKeys = append(report.RemovedKeys, name)
+			report.Comparisons = append(report.Comparisons, compareAllocations(name, AllocationComparisonRemoved, b, nil))
+			return
+		}
+		report.Comparisons = append(report.Comparisons, compareAllocations(name, AllocationComparisonChanged, b, a))
+	})
+
+	after.Each(func(name string, a *kubecost.Allocation) {
+		report.AfterCost += a.TotalCost()
+
+		if before.Get(name) == nil {
+			report.NewKeys = append(report.NewKeys, name)
+			report.Comparisons = append(report.Comparisons, compareAllocations(name, AllocationComparisonNew, nil, a))
+		}
+	})
+
+	report.CostDelta = report.AfterCost - report.BeforeCost
+
+	sort.Strings(report.NewKeys)
+	sort.Strings(report.RemovedKeys)
+	sort.SliceStable(report.Comparisons, func(i, j int) bool {
+		di, dj := math.Abs(report.Comparisons[i].CostDelta), math.Abs(report.Comparisons[j].CostDelta)
+		if di != dj {
+			return di > dj
+		}
+		return report.Comparisons[i].Name < report.Comparisons[j].Name
+	})
+
+	if topN > 0 && len(report.Comparisons) > topN {
+		report.Comparisons = report.Comparisons[:topN]
+	}
+
+	return report
+}
+
+// compareAllocations compares the allocation of a key in the first window to
+// that of the second. Either may be nil, if the key is absent from a window.
+func compareAllocations(name, status string, before, after *kubecost.Allocation) *AllocationComparison {
+	if before == nil {
+		before = &kubecost.Allocation{}
+	}
+	if after == nil {
+		after = &kubecost.Allocation{}
+	}
+
+	comp := &AllocationComparison{
+		Name:       name,
+		Status:     status,
+		BeforeCost: before.TotalCost(),
+		AfterCost:  after.TotalCost(),
+	}
+
+	comp.CostDelta = comp.AfterCost - comp.BeforeCost
+	if comp.BeforeCost != 0 {
+		pct := comp.CostDelta / comp.BeforeCost * 100.0
+		comp.PercentChange = &pct
+	}
+
+	resources := []struct {
+		beforeCost, beforeHours float64
+		afterCost, afterHours   float64
+	}{
+		{before.CPUTotalCost(), before.CPUCoreHours, after.CPUTotalCost(), after.CPUCoreHours},
+		{before.RAMTotalCost(), before.RAMByteHours, after.RAMTotalCost(), after.RAMByteHours},
+		{before.GPUTotalCost(), before.GPUHours, after.GPUTotalCost(), after.GPUHours},
+		{before.PVTotalCost(), before.PVByteHours(), after.PVTotalCost(), after.PVByteHours()},
+	}
+
+	for _, r := range resources {
+		price, usage := decomposeCostDelta(r.beforeCost, r.beforeHours, r.afterCost, r.afterHours)
+		comp.PriceCostDelta += price
+		comp.UsageCostDelta += usage
+	}
+	comp.OtherCostDelta = comp.CostDelta - comp.PriceCostDelta - comp.UsageCostDelta
+
+	return comp
+}
+
+// decomposeCostDelta splits the change in cost of a resource into the change
+// due to its price per hour and the change due to the hours used, such that
+// they sum to the change in cost. Usage is valued at the first window's price,
+// and the change in price at the second window's usage. If the resource was
+// not used in one window, its price in that window is taken to be the other's,
+// so that the whole change is due to usage. If it was used in neither window,
+// the change cannot be decomposed, and both are zero.
+func decomposeCostDelta(beforeCost, beforeHours, afterCost, afterHours float64) (float64, float64) {
+	if beforeHours <= 0 && afterHours <= 0 {
+		return 0.0, 0.0
+	}
+
+	var beforePrice, afterPrice float64
+	if beforeHours > 0 {
+		beforePrice = beforeCost / beforeHours
+	}
+	if afterHours > 0 {
+		afterPrice = afterCost / afterHours
+	}
+	if beforeHours <= 0 {
+		beforePrice = afterPrice
+	}
+	if afterHours <= 0 {
+		afterPrice = beforePrice
+	}
+
+	usage := (afterHours - beforeHours) * beforePrice
+	price := (afterPrice - beforePrice) * afterHours
+
+	return price, usage
+}
+
+// computeAggregatedAllocationSet computes the allocations of the window,
+// aggregated by the given properties.
+func (a *Accesses) computeAggregatedAllocationSet(window kubecost.Window, resolution time.Duration, aggregateBy []string) (*kubecost.AllocationSet, error) {
+	as, err := a.Model.ComputeAllocation(*window.Start(), *window.End(), resolution)
+	if err != nil {
+		return nil, fmt.Errorf("error computing allocations for %s: %s", window, err)
+	}
+
+	err = as.AggregateBy(aggregateBy, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error aggregating allocations for %s: %s", window, err)
+	}
+
+	return as, nil
+}
+
+// CompareAllocationsHandler compares the aggregated allocations of two
+// windows, e.g. last month to the month before, reporting the change in cost
+// of each key and how much of it is due to price versus usage.
+func (a *Accesses) CompareAllocationsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	qp := httputil.NewQueryParams(r.URL.Query())
+
+	conversion, err := a.currencyConversion(qp)
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'currency' parameter: %s", err)))
+		return
+	}
+
+	// Before and after are the required windows to compare; e.g.
+	// before=2021-01-01T00:00:00Z,2021-02-01T00:00:00Z&after=lastmonth
+	windows := map[string]kubecost.Window{}
+	for _, param := range []string{"before", "after"} {
+		window, err := kubecost.ParseWindowWithOffset(qp.Get(param, ""), env.GetParsedUTCOffset())
+		if err != nil {
+			WriteError(w, BadRequest(fmt.Sprintf("Invalid '%s' parameter: %s", param, err)))
+			return
+		}
+		if window.IsOpen() || window.IsNegative() {
+			WriteError(w, BadRequest(fmt.Sprintf("Invalid '%s' parameter: %s", param, window)))
+			return
+		}
+		windows[param] = window
+	}
+
+	aggregateBy, err := ParseAggregationProperties(qp, "aggregate")
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'aggregate' parameter: %s", err)))
+		return
+	}
+	if len(aggregateBy) == 0 {
+		WriteError(w, BadRequest("Parameter 'aggregate' is required"))
+		return
+	}
+
+	// TopN is an optional parameter which, if positive, limits the
+	// comparisons to the given number of largest changes in cost
+	topN := qp.GetInt("topN", 0)
+	if topN < 0 {
+		WriteError(w, BadRequest("Parameter 'topN' must be non-negative"))
+		return
+	}
+
+	resolution := qp.GetDuration("resolution", env.GetETLResolution())
+
+	before, err := a.computeAggregatedAllocationSet(windows["before"], resolution, aggregateBy)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	after, err := a.computeAggregatedAllocationSet(windows["after"], resolution, aggregateBy)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	report := CompareAllocationSets(before, after, topN)
+	report.Aggregate = aggregateBy
+
+	writeWithCurrency(w, report, conversion)
+}
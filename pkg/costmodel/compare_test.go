@@ -0,0 +1,154 @@
+package costmodel
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util"
+)
+
+// compareTestDelta is the precision to which comparisons are checked
+const compareTestDelta = 0.000001
+
+func newCompareTestAllocation(namespace string, cpuHours, cpuPrice, ramByteHours, ramPrice, networkCost float64) *kubecost.Allocation {
+	return &kubecost.Allocation{
+		Name: namespace,
+		Properties: &kubecost.AllocationProperties{
+			Namespace: namespace,
+		},
+		CPUCoreHours: cpuHours,
+		CPUCost:      cpuHours * cpuPrice,
+		RAMByteHours: ramByteHours,
+		RAMCost:      ramByteHours * ramPrice,
+		NetworkCost:  networkCost,
+	}
+}
+
+func TestCompareAllocationSets(t *testing.T) {
+	gib := 1024.0 * 1024.0 * 1024.0
+	cpuPrice := 0.03
+	ramPrice := 0.004 / gib
+
+	beforeStart := time.Date(2021, time.February, 1, 0, 0, 0, 0, time.UTC)
+	afterStart := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	afterEnd := time.Date(2021, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+	before := kubecost.NewAllocationSet(beforeStart, afterStart,
+		newCompareTestAllocation("scaled", 100.0, cpuPrice, 400.0*gib, ramPrice, 1.0),
+		newCompareTestAllocation("repriced", 100.0, cpuPrice, 400.0*gib, ramPrice, 0.0),
+		newCompareTestAllocation("steady", 10.0, cpuPrice, 40.0*gib, ramPrice, 0.0),
+		newCompareTestAllocation("removed", 50.0, cpuPrice, 0.0, ramPrice, 0.0),
+	)
+	after := kubecost.NewAllocationSet(afterStart, afterEnd,
+		// twice the replicas, and twice the network traffic
+		newCompareTestAllocation("scaled", 200.0, cpuPrice, 800.0*gib, ramPrice, 2.0),
+		// the same usage on nodes a third more expensive
+		newCompareTestAllocation("repriced", 100.0, cpuPrice*4.0/3.0, 400.0*gib, ramPrice*4.0/3.0, 0.0),
+		newCompareTestAllocation("steady", 10.0, cpuPrice, 40.0*gib, ramPrice, 0.0),
+		newCompareTestAllocation("added", 20.0, cpuPrice, 0.0, ramPrice, 0.0),
+	)
+
+	report := CompareAllocationSets(before, after, 0)
+
+	// scaled: 3.0 CPU + 1.6 RAM + 1.0 network => 5.6, then 11.2
+	// repriced: 4.6, then 6.1333
+	// steady: 0.46; removed: 1.5; added: 0.6
+	if !util.IsWithin(report.BeforeCost, 5.6+4.6+0.46+1.5, compareTestDelta) {
+		t.Errorf("expected before cost %f; got %f", 5.6+4.6+0.46+1.5, report.BeforeCost)
+	}
+	if !util.IsWithin(report.AfterCost, 11.2+4.6*4.0/3.0+0.46+0.6, compareTestDelta) {
+		t.Errorf("expected after cost %f; got %f", 11.2+4.6*4.0/3.0+0.46+0.6, report.AfterCost)
+	}
+	if !util.IsWithin(report.CostDelta, report.AfterCost-report.BeforeCost, compareTestDelta) {
+		t.Errorf("expected cost delta %f; got %f", report.AfterCost-report.BeforeCost, report.CostDelta)
+	}
+
+	if len(report.NewKeys) != 1 || report.NewKeys[0] != "added" {
+		t.Errorf("expected new keys [added]; got %v", report.NewKeys)
+	}
+	if len(report.RemovedKeys) != 1 || report.RemovedKeys[0] != "removed" {
+		t.Errorf("expected removed keys [removed]; got %v", report.RemovedKeys)
+	}
+
+	expected := []struct {
+		name    string
+		status  string
+		delta   float64
+		percent float64
+		price   float64
+		usage   float64
+		other   float64
+	}{
+		{"scaled", AllocationComparisonChanged, 5.6, 100.0, 0.0, 4.6, 1.0},
+		{"repriced", AllocationComparisonChanged, 4.6 / 3.0, 100.0 / 3.0, 4.6 / 3.0, 0.0, 0.0},
+		{"removed", AllocationComparisonRemoved, -1.5, -100.0, 0.0, -1.5, 0.0},
+		{"added", AllocationComparisonNew, 0.6, math.NaN(), 0.0, 0.6, 0.0},
+		{"steady", AllocationComparisonChanged, 0.0, 0.0, 0.0, 0.0, 0.0},
+	}
+
+	if len(report.Comparisons) != len(expected) {
+		t.Fatalf("expected %d comparisons; got %d", len(expected), len(report.Comparisons))
+	}
+
+	for i, exp := range expected {
+		comp := report.Comparisons[i]
+		if comp.Name != exp.name {
+			t.Fatalf("comparison %d: expected %s; got %s", i, exp.name, comp.Name)
+		}
+		if comp.Status != exp.status {
+			t.Errorf("%s: expected status %s; got %s", exp.name, exp.status, comp.Status)
+		}
+		if !util.IsWithin(comp.CostDelta, exp.delta, compareTestDelta) {
+			t.Errorf("%s: expected cost delta %f; got %f", exp.name, exp.delta, comp.CostDelta)
+		}
+		if math.IsNaN(exp.percent) {
+			if comp.PercentChange != nil {
+				t.Errorf("%s: expected no percent change; got %f", exp.name, *comp.PercentChange)
+			}
+		} else if comp.PercentChange == nil || !util.IsWithin(*comp.PercentChange, exp.percent, compareTestDelta) {
+			t.Errorf("%s: expected percent change %f; got %v", exp.name, exp.percent, comp.PercentChange)
+		}
+		if !util.IsWithin(comp.PriceCostDelta, exp.price, compareTestDelta) {
+			t.Errorf("%s: expected price cost delta %f; got %f", exp.name, exp.price, comp.PriceCostDelta)
+		}
+		if !util.IsWithin(comp.UsageCostDelta, exp.usage, compareTestDelta) {
+			t.Errorf("%s: expected usage cost delta %f; got %f", exp.name, exp.usage, comp.UsageCostDelta)
+		}
+		if !util.IsWithin(comp.OtherCostDelta, exp.other, compareTestDelta) {
+			t.Errorf("%s: expected other cost delta %f; got %f", exp.name, exp.other, comp.OtherCostDelta)
+		}
+	}
+
+	// TopN limits the comparisons, but not the new and removed keys
+	report = CompareAllocationSets(before, after, 2)
+	if len(report.Comparisons) != 2 || report.Comparisons[0].Name != "scaled" || report.Comparisons[1].Name != "repriced" {
+		t.Errorf("expected top 2 comparisons [scaled repriced]; got %d", len(report.Comparisons))
+	}
+	if len(report.NewKeys) != 1 || len(report.RemovedKeys) != 1 {
+		t.Errorf("expected topN not to limit new and removed keys; got %v, %v", report.NewKeys, report.RemovedKeys)
+	}
+}
+
+func TestDecomposeCostDelta(t *testing.T) {
+	cases := []struct {
+		name                                           string
+		beforeCost, beforeHours, afterCost, afterHours float64
+		price, usage                                   float64
+	}{
+		{"usage only", 10.0, 10.0, 20.0, 20.0, 0.0, 10.0},
+		{"price only", 10.0, 10.0, 20.0, 10.0, 10.0, 0.0},
+		{"both", 10.0, 10.0, 40.0, 20.0, 20.0, 10.0},
+		{"not used before", 0.0, 0.0, 5.0, 5.0, 0.0, 5.0},
+		{"not used after", 5.0, 5.0, 0.0, 0.0, 0.0, -5.0},
+		{"never used", 1.0, 0.0, 2.0, 0.0, 0.0, 0.0},
+	}
+
+	for _, c := range cases {
+		price, usage := decomposeCostDelta(c.beforeCost, c.beforeHours, c.afterCost, c.afterHours)
+		if !util.IsWithin(price, c.price, compareTestDelta) || !util.IsWithin(usage, c.usage, compareTestDelta) {
+			t.Errorf("%s: expected price %f and usage %f; got %f and %f", c.name, c.price, c.usage, price, usage)
+		}
+	}
+}
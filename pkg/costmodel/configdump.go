@@ -0,0 +1,286 @@
+package costmodel
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/thanos"
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+// configDumpRedacted replaces the value of each secret in the configuration dump
+const configDumpRedacted = "<redacted>"
+
+// secretFieldPattern matches the names of fields which hold secrets, e.g.
+// "DB_BEARER_TOKEN", "MC_BASIC_AUTH_PW", and "azureClientSecret", and of the
+// files and Secrets from which credentials are read
+var secretFieldPattern = regexp.MustCompile(`(?i)(secret|passw|token|bearer|credential|api_?key|access_?key|private_?key|service_?key|(^|_)pw($|_))`)
+
+// secretValuePattern matches values which could be keys or tokens: a single
+// run of the characters of base64, hex, and JWTs, of at least 20 characters
+var secretValuePattern = regexp.MustCompile(`^[A-Za-z0-9+/=_.\-]{20,}$`)
+
+// minSecretValueEntropy is the Shannon entropy, in bits per character, above
+// which a value matching secretValuePattern is treated as a secret. Random
+// base64 is around 4.5, while hex IDs, e.g. UUIDs, can't exceed 4.
+const minSecretValueEntropy = 4.0
+
+// ConfigDump is the effective configuration, with secrets redacted, for
+// support bundles.
+type ConfigDump struct {
+	// Config is the configuration, by section: settings, customPricing,
+	// featureFlags, metricOptions, prometheus, and provider
+	Config map[string]interface{} `json:"config"`
+
+	// Redacted are the paths of the fields whose values were redacted, sorted
+	Redacted []string `json:"redacted"`
+
+	Errors []string `json:"errors"`
+}
+
+// configDocument is the configuration before redaction
+type configDocument struct {
+	Settings      map[string]string    `json:"settings"`
+	CustomPricing *cloud.CustomPricing `json:"customPricing"`
+	FeatureFlags  map[string]bool      `json:"featureFlags"`
+	MetricOptions map[string]bool      `json:"metricOptions"`
+	Prometheus    *prometheusConfig    `json:"prometheus"`
+	Provider      map[string]string    `json:"provider"`
+}
+
+// prometheusConfig is the effective configuration of the Prometheus and
+// Thanos clients
+type prometheusConfig struct {
+	Address             string `json:"address"`
+	MaxQueryConcurrency int    `json:"maxQueryConcurrency"`
+	QueryLoggingFile    string `json:"queryLoggingFile"`
+	InsecureSkipVerify  bool   `json:"insecureSkipVerify"`
+	ClusterIDLabel      string `json:"clusterIDLabel"`
+	BasicAuthUsername   string `json:"basicAuthUsername"`
+	BasicAuthPassword   string `json:"basicAuthPassword"`
+	BearerToken         string `json:"bearerToken"`
+	ThanosEnabled       bool   `json:"thanosEnabled"`
+	ThanosQueryURL      string `json:"thanosQueryURL"`
+	ThanosOffset        string `json:"thanosOffset"`
+	ThanosMaxSourceRes  string `json:"thanosMaxSourceResolution"`
+}
+
+// DumpConfig returns the effective configuration, from the environment and the
+// provider, with secrets redacted: the values of fields whose names match
+// secretFieldPattern, values which look like keys or tokens, and the
+// passwords of URLs. The provider may be nil.
+func DumpConfig(provider cloud.Provider) *ConfigDump {
+	dump := &ConfigDump{
+		Redacted: []string{},
+		Errors:   []string{},
+	}
+
+	doc := &configDocument{
+		Settings:     map[string]string{},
+		FeatureFlags: map[string]bool{},
+		MetricOptions: map[string]bool{
+			"emitKubecostControllerMetrics": !env.IsKubecostMetricsPodEnabled(),
+			"emitKubeStateMetrics":          env.IsEmitKsmV1Metrics(),
+			"emitNamespaceAnnotations":      env.IsEmitNamespaceAnnotationsMetric(),
+			"emitPodAnnotations":            env.IsEmitPodAnnotationsMetric(),
+			"emitIngressMetrics":            env.IsEmitIngressMetrics(),
+		},
+		Prometheus: &prometheusConfig{
+			Address:             env.GetPrometheusServerEndpoint(),
+			MaxQueryConcurrency: env.GetMaxQueryConcurrency(),
+			QueryLoggingFile:    env.GetQueryLoggingFile(),
+			InsecureSkipVerify:  env.GetInsecureSkipVerify(),
+			ClusterIDLabel:      env.GetPromClusterLabel(),
+			BasicAuthUsername:   env.GetDBBasicAuthUsername(),
+			BasicAuthPassword:   env.GetDBBasicAuthUserPassword(),
+			BearerToken:         env.GetDBBearerToken(),
+			ThanosEnabled:       thanos.IsEnabled(),
+			ThanosQueryURL:      thanos.QueryURL(),
+			ThanosOffset:        thanos.Offset(),
+			ThanosMaxSourceRes:  thanos.MaxSourceResolution(),
+		},
+		Provider: map[string]string{},
+	}
+
+	for key := range env.GetSettings() {
+		if value := env.Get(key, ""); value != "" {
+			doc.Settings[key] = value
+		}
+	}
+
+	for _, flag := range env.GetFeatureFlags() {
+		doc.FeatureFlags[flag.Name] = flag.Enabled()
+	}
+
+	if provider != nil {
+		cp, err := provider.GetConfig()
+		if err != nil {
+			dump.Errors = append(dump.Errors, fmt.Sprintf("failed to get custom pricing: %s", err))
+		} else {
+			doc.CustomPricing = cp
+		}
+
+		info, err := provider.ClusterInfo()
+		if err != nil {
+			dump.Errors = append(dump.Errors, fmt.Sprintf("failed to get cluster info: %s", err))
+		} else {
+			doc.Provider = info
+		}
+	}
+
+	// Redact the document in its JSON form, so that every field, including
+	// those added later, is subject to redaction
+	data, err := json.Marshal(doc)
+	if err != nil {
+		dump.Errors = append(dump.Errors, fmt.Sprintf("failed to marshal configuration: %s", err))
+		return dump
+	}
+
+	var config map[string]interface{}
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		dump.Errors = append(dump.Errors, fmt.Sprintf("failed to unmarshal configuration: %s", err))
+		return dump
+	}
+
+	dump.Config = redactConfig("", config, &dump.Redacted).(map[string]interface{})
+	sort.Strings(dump.Redacted)
+
+	return dump
+}
+
+// redactConfig returns the given JSON value, at the given path, with secrets
+// redacted, appending the path of each redacted value to redacted.
+func redactConfig(path string, value interface{}, redacted *[]string) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+
+			if s, ok := field.(string); ok {
+				if r, ok := redactSetting(key, s); ok {
+					v[key] = r
+					*redacted = append(*redacted, fieldPath)
+				}
+				continue
+			}
+
+			v[key] = redactConfig(fieldPath, field, redacted)
+		}
+		return v
+
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = redactConfig(fmt.Sprintf("%s[%d]", path, i), elem, redacted)
+		}
+		return v
+
+	case string:
+		if r, ok := redactValue(v); ok {
+			*redacted = append(*redacted, path)
+			return r
+		}
+		return v
+
+	default:
+		return v
+	}
+}
+
+// redactSetting returns the value of the named setting or field with secrets
+// redacted, and true if it was redacted: the whole value if the name matches
+// secretFieldPattern, and otherwise as redacted by redactValue.
+func redactSetting(name, value string) (string, bool) {
+	if value != "" && secretFieldPattern.MatchString(name) {
+		return configDumpRedacted, true
+	}
+
+	return redactValue(value)
+}
+
+// redactValue returns the value with secrets redacted, and true if it was
+// redacted: values which look like keys or tokens are replaced, and the
+// passwords of URLs are redacted, keeping the rest of the URL, e.g. the host.
+func redactValue(value string) (string, bool) {
+	if isSecretValue(value) {
+		return configDumpRedacted, true
+	}
+
+	if strings.Contains(value, "://") {
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				return u.Redacted(), true
+			}
+		}
+	}
+
+	return value, false
+}
+
+// isSecretValue returns true if the value looks like a key or token: a long
+// run of base64 characters, with letters and digits, of high entropy.
+func isSecretValue(value string) bool {
+	if !secretValuePattern.MatchString(value) {
+		return false
+	}
+
+	if !strings.ContainsAny(value, "0123456789") || strings.IndexFunc(value, isLetter) < 0 {
+		return false
+	}
+
+	return shannonEntropy(value) >= minSecretValueEntropy
+}
+
+// isLetter returns true if the rune is an ASCII letter
+func isLetter(r rune) bool {
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// shannonEntropy returns the Shannon entropy of the value, in bits per character
+func shannonEntropy(value string) float64 {
+	counts := map[rune]float64{}
+	total := 0.0
+	for _, r := range value {
+		counts[r]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, count := range counts {
+		p := count / total
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// Log writes the dump to the log at debug level, as a single entry.
+func (cd *ConfigDump) Log() {
+	data, err := json.Marshal(cd)
+	if err != nil {
+		log.Warningf("Failed to marshal configuration dump: %s", err)
+		return
+	}
+
+	log.Debugf("Configuration: %s", data)
+}
+
+// GetConfigDump returns the effective configuration, with secrets redacted.
+// It requires the admin role when authentication is enabled.
+func (a *Accesses) GetConfigDump(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	w.Write(WrapData(DumpConfig(a.CloudProvider), nil))
+}
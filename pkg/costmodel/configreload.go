@@ -0,0 +1,238 @@
+package costmodel
+
+import (
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/metrics"
+	"github.com/kubecost/cost-model/pkg/prom"
+	"github.com/kubecost/cost-model/pkg/thanos"
+)
+
+// configReloadMetricInit registers the reload counter once
+var configReloadMetricInit sync.Once
+
+// EnvConfigReloader applies the data of the ConfigMap backing the environment
+// each time it changes, so that settings may be changed without a restart.
+type EnvConfigReloader struct {
+	lock       sync.RWMutex
+	name       string
+	data       map[string]string
+	lastReload time.Time
+	lastResult *env.ReloadResult
+}
+
+// EnvConfigSetting is the effective value of a setting of the ConfigMap
+// backing the environment.
+type EnvConfigSetting struct {
+	// Value is the value in effect
+	Value string `json:"value"`
+
+	// ConfiguredValue is the value in the ConfigMap, which differs from Value
+	// if the setting requires a restart to change
+	ConfiguredValue string `json:"configuredValue"`
+
+	RequiresRestart bool `json:"requiresRestart"`
+}
+
+// EnvConfigStatus is the state of the ConfigMap backing the environment, and
+// the effective value of each of its settings.
+type EnvConfigStatus struct {
+	ConfigMapName string                       `json:"configMapName"`
+	Reloads       int64                        `json:"reloads"`
+	LastReload    *time.Time                   `json:"lastReload,omitempty"`
+	LastResult    *env.ReloadResult            `json:"lastResult,omitempty"`
+	Settings      map[string]*EnvConfigSetting `json:"settings"`
+}
+
+// NewEnvConfigReloader creates a reloader for the named ConfigMap, and
+// registers the kubecost_config_reloads_total metric.
+func NewEnvConfigReloader(name string) *EnvConfigReloader {
+	configReloadMetricInit.Do(func() {
+		prometheus.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Name: "kubecost_config_reloads_total",
+			Help: "kubecost_config_reloads_total number of times the environment configuration has been reloaded",
+		}, func() float64 {
+			return float64(env.GetReloadCount())
+		}))
+	})
+
+	return &EnvConfigReloader{
+		name: name,
+		data: map[string]string{},
+	}
+}
+
+// WatchFunc applies the data of the ConfigMap, and is used as the WatchFunc
+// of its ConfigWatchers.
+func (ecr *EnvConfigReloader) WatchFunc(name string, data map[string]string) error {
+	if name != ecr.name {
+		return nil
+	}
+
+	result := env.Reload(data)
+
+	ecr.lock.Lock()
+	ecr.data = make(map[string]string, len(data))
+	for key, value := range data {
+		ecr.data[key] = value
+	}
+	ecr.lastReload = time.Now().UTC()
+	ecr.lastResult = result
+	ecr.lock.Unlock()
+
+	if len(result.Changed) > 0 {
+		log.Infof("Reloaded %s: changed %s", name, strings.Join(result.Changed, ", "))
+	}
+	for _, key := range result.RequiresRestart {
+		log.Warningf("Reloaded %s: %s changed, but requires a restart to take effect", name, key)
+	}
+
+	return nil
+}
+
+// Status returns the effective value of each setting in the ConfigMap. The
+// values of secret settings are redacted.
+func (ecr *EnvConfigReloader) Status() *EnvConfigStatus {
+	ecr.lock.RLock()
+	defer ecr.lock.RUnlock()
+
+	status := &EnvConfigStatus{
+		ConfigMapName: ecr.name,
+		Reloads:       env.GetReloadCount(),
+		LastResult:    ecr.lastResult,
+		Settings:      map[string]*EnvConfigSetting{},
+	}
+	if !ecr.lastReload.IsZero() {
+		lastReload := ecr.lastReload
+		status.LastReload = &lastReload
+	}
+
+	keys := make([]string, 0, len(ecr.data))
+	for key := range ecr.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		setting := &EnvConfigSetting{
+			Value:           os.Getenv(key),
+			ConfiguredValue: ecr.data[key],
+			RequiresRestart: env.RequiresRestart(key),
+		}
+		setting.Value = redactEnvSetting(key, setting.Value)
+		setting.ConfiguredValue = redactEnvSetting(key, setting.ConfiguredValue)
+		status.Settings[key] = setting
+	}
+
+	return status
+}
+
+// redactEnvSetting returns the value of the setting with secrets redacted as
+// in the configuration dump, e.g. URLs with passwords, and the whole value of
+// settings whose names look secret.
+func redactEnvSetting(key, value string) string {
+	if value != "" && isSecretSetting(key) {
+		return configDumpRedacted
+	}
+
+	redacted, _ := redactSetting(key, value)
+	return redacted
+}
+
+// isSecretSetting returns true if the value of the setting should not be
+// exposed, e.g. passwords, tokens, and keys.
+func isSecretSetting(key string) bool {
+	key = strings.ToUpper(key)
+	for _, s := range []string{"SECRET", "PASSWORD", "_PW", "TOKEN", "KEY"} {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// EnvConfigHandler returns the effective value of each setting of the
+// ConfigMap backing the environment.
+func (a *Accesses) EnvConfigHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if a.EnvConfigReloader == nil {
+		WriteError(w, BadRequest("Environment configuration reload is disabled; set "+env.EnvConfigMapNameEnvVar+" to enable it"))
+		return
+	}
+
+	w.Write(WrapData(a.EnvConfigReloader.Status(), nil))
+}
+
+// registerReloadListeners applies changes to the settings of the environment
+// which are cached at startup: the cluster map refresh interval, the optional
+// kubernetes metrics, the Prometheus and Thanos credentials, and the Thanos
+// query offset and resolution.
+func (a *Accesses) registerReloadListeners(defaultClusterMapRefresh time.Duration) {
+	env.RegisterReloadListener(env.ClusterMapRefreshIntervalMinutesEnvVar, func(key, value string) {
+		a.ClusterMap.SetRefreshInterval(env.GetClusterMapRefreshInterval(defaultClusterMapRefresh))
+	})
+
+	// Ingresses are only watched if ingress metrics are enabled at startup, so
+	// they cannot be toggled by a reload
+	emitIngressMetrics := env.IsEmitIngressMetrics()
+	updateKubeMetrics := func(key, value string) {
+		metrics.UpdateKubeMetricsOpts(&metrics.KubeMetricsOpts{
+			EmitNamespaceAnnotations: env.IsEmitNamespaceAnnotationsMetric(),
+			EmitPodAnnotations:       env.IsEmitPodAnnotationsMetric(),
+			EmitIngressMetrics:       emitIngressMetrics,
+		})
+	}
+	for _, flag := range []*env.FeatureFlag{env.NamespaceAnnotationsMetricFlag, env.PodAnnotationsMetricFlag} {
+		env.RegisterReloadListener(flag.EnvVar, updateKubeMetrics)
+		env.RegisterReloadListener(flag.LegacyEnvVar, updateKubeMetrics)
+	}
+
+	updateAuth := func(key, value string) {
+		if promCli, ok := a.PrometheusClient.(*prom.RateLimitedPrometheusClient); ok {
+			promCli.SetAuth(&prom.ClientAuth{
+				Username:    env.GetDBBasicAuthUsername(),
+				Password:    env.GetDBBasicAuthUserPassword(),
+				BearerToken: env.GetDBBearerToken(),
+			})
+		}
+	}
+	env.RegisterReloadListener(env.DBBasicAuthUsername, updateAuth)
+	env.RegisterReloadListener(env.DBBasicAuthPassword, updateAuth)
+	env.RegisterReloadListener(env.DBBearerToken, updateAuth)
+
+	updateThanosAuth := func(key, value string) {
+		if thanosCli, ok := a.ThanosClient.(*prom.RateLimitedPrometheusClient); ok {
+			thanosCli.SetAuth(&prom.ClientAuth{
+				Username:    env.GetMultiClusterBasicAuthUsername(),
+				Password:    env.GetMultiClusterBasicAuthPassword(),
+				BearerToken: env.GetMultiClusterBearerToken(),
+			})
+		}
+	}
+	env.RegisterReloadListener(env.MultiClusterBasicAuthUsername, updateThanosAuth)
+	env.RegisterReloadListener(env.MultiClusterBasicAuthPassword, updateThanosAuth)
+	env.RegisterReloadListener(env.MultiClusterBearerToken, updateThanosAuth)
+
+	env.RegisterReloadListener(env.ThanosOffsetEnvVar, func(key, value string) {
+		thanos.SetOffset(env.GetThanosOffset())
+	})
+	env.RegisterReloadListener(env.ThanosMaxSourceResEnvVar, func(key, value string) {
+		thanos.SetMaxSourceResolution(env.GetThanosMaxSourceResolution())
+	})
+
+	// The thanos settings are read when the package is initialized, so apply
+	// any overrides loaded since
+	thanos.SetOffset(env.GetThanosOffset())
+	thanos.SetMaxSourceResolution(env.GetThanosMaxSourceResolution())
+}
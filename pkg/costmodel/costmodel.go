@@ -53,6 +53,7 @@ type CostModel struct {
 	ScrapeInterval   time.Duration
 	PrometheusClient prometheus.Client
 	Provider         costAnalyzerCloud.Provider
+	CoverageHistory  *AllocationCoverageHistory
 	pricingMetadata  *costAnalyzerCloud.PricingMatchMetadata
 }
 
@@ -67,6 +68,7 @@ func NewCostModel(client prometheus.Client, provider costAnalyzerCloud.Provider,
 		Provider:         provider,
 		RequestGroup:     requestGroup,
 		ScrapeInterval:   scrapeInterval,
+		CoverageHistory:  NewAllocationCoverageHistory(),
 	}
 }
 
@@ -344,6 +346,7 @@ func (cm *CostModel) ComputeCostData(cli prometheusClient.Client, cp costAnalyze
 		klog.V(1).Infof("[Warning] Unable to get Network Cost Data: %s", err.Error())
 		networkUsageMap = make(map[string]*NetworkUsageData)
 	}
+	internetEgressGB := GetInternetEgressGB(networkUsageMap)
 
 	containerNameCost := make(map[string]*CostData)
 	containers := make(map[string]bool)
@@ -448,7 +451,7 @@ func (cm *CostModel) ComputeCostData(cli prometheusClient.Client, cp costAnalyze
 
 			var podNetCosts []*util.Vector
 			if usage, ok := networkUsageMap[ns+","+podName+","+clusterID]; ok {
-				netCosts, err := GetNetworkCost(usage, cp)
+				netCosts, err := GetNetworkCost(usage, cp, internetEgressGB)
 				if err != nil {
 					klog.V(4).Infof("Error pulling network costs: %s", err.Error())
 				} else {
@@ -898,23 +901,30 @@ func addPVData(cache clustercache.ClusterCache, pvClaimMapping map[string]*Persi
 }
 
 func GetPVCost(pv *costAnalyzerCloud.PV, kpv *v1.PersistentVolume, cp costAnalyzerCloud.Provider, defaultRegion string) error {
+	_, err := getPVCost(pv, kpv, cp, defaultRegion)
+	return err
+}
+
+// getPVCost sets the cost of the PV as GetPVCost does, returning the pricing path
+// which produced it: the provider's PVPricing, or the default storage price.
+func getPVCost(pv *costAnalyzerCloud.PV, kpv *v1.PersistentVolume, cp costAnalyzerCloud.Provider, defaultRegion string) (costAnalyzerCloud.PricingType, error) {
 	cfg, err := cp.GetConfig()
 	if err != nil {
-		return err
+		return "", err
 	}
 	key := cp.GetPVKey(kpv, pv.Parameters, defaultRegion)
 	pv.ProviderID = key.ID()
 	pvWithCost, err := cp.PVPricing(key)
 	if err != nil {
 		pv.Cost = cfg.Storage
-		return err
+		return costAnalyzerCloud.DefaultPrices, err
 	}
 	if pvWithCost == nil || pvWithCost.Cost == "" {
 		pv.Cost = cfg.Storage
-		return nil // set default cost
+		return costAnalyzerCloud.DefaultPrices, nil // set default cost
 	}
 	pv.Cost = pvWithCost.Cost
-	return nil
+	return costAnalyzerCloud.Api, nil
 }
 
 func (cm *CostModel) GetPricingSourceCounts() (*costAnalyzerCloud.PricingMatchMetadata, error) {
@@ -1805,6 +1815,7 @@ func (cm *CostModel) costDataRange(cli prometheusClient.Client, cp costAnalyzerC
 		klog.V(1).Infof("Unable to get Network Cost Data: %s", err.Error())
 		networkUsageMap = make(map[string]*NetworkUsageData)
 	}
+	internetEgressGB := GetInternetEgressGB(networkUsageMap)
 
 	containerNameCost := make(map[string]*CostData)
 	containers := make(map[string]bool)
@@ -2000,7 +2011,7 @@ func (cm *CostModel) costDataRange(cli prometheusClient.Client, cp costAnalyzerC
 		// correct data.
 		var podNetworkCosts []*util.Vector
 		if usage, ok := networkUsageMap[podKey]; ok {
-			netCosts, err := GetNetworkCost(usage, cp)
+			netCosts, err := GetNetworkCost(usage, cp, internetEgressGB)
 			if err != nil {
 				klog.V(3).Infof("Error pulling network costs: %s", err.Error())
 			} else {
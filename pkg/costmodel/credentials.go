@@ -0,0 +1,55 @@
+package costmodel
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/env"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// credentialRefreshInterval is how often credentials supplied by file or Secret are
+// read again, so that rotated credentials are applied
+const credentialRefreshInterval = time.Minute
+
+// newSecretReader returns an env.SecretReader which reads Secrets using the given client
+func newSecretReader(client kubernetes.Interface) env.SecretReader {
+	return func(namespace, name, key string) (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		secret, err := client.CoreV1().Secrets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+
+		data, ok := secret.Data[key]
+		if !ok {
+			return "", fmt.Errorf("secret %s/%s has no key '%s'", namespace, name, key)
+		}
+
+		return string(data), nil
+	}
+}
+
+// refreshCredentials reads credentials supplied by file or Secret again on an
+// interval, notifying the reload listeners of each which changed.
+func refreshCredentials(interval time.Duration) {
+	for range time.Tick(interval) {
+		env.RefreshCredentials()
+	}
+}
+
+// checkCredentials returns an error if any credential supplied by file or Secret
+// could not be read, so that misconfigured references are not silently empty.
+func checkCredentials(errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("failed to resolve credentials: %s", strings.Join(errs, "; "))
+}
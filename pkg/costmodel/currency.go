@@ -0,0 +1,335 @@
+package costmodel
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/cloud/pricing"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util/httputil"
+	"github.com/kubecost/cost-model/pkg/util/json"
+	"k8s.io/klog"
+)
+
+// defaultCurrencyCode is the currency of pricing data when none is configured.
+const defaultCurrencyCode = "USD"
+
+// currencyRatesTTL is the duration for which rates fetched from the configured
+// currency rates URL are cached.
+const currencyRatesTTL = time.Hour
+
+// CurrencyRates are exchange rates from a base currency, i.e. the number of
+// units of each currency per unit of the base currency.
+type CurrencyRates struct {
+	Base      string             `json:"base"`
+	Rates     map[string]float64 `json:"rates"`
+	Timestamp time.Time          `json:"-"`
+}
+
+// parseCurrencyRates parses rates from the given base currency, formatted as a
+// comma-separated list of currency:rate pairs; e.g. "EUR:0.92,GBP:0.79"
+func parseCurrencyRates(base, rates string) (*CurrencyRates, error) {
+	cr := &CurrencyRates{
+		Base:      strings.ToUpper(base),
+		Rates:     map[string]float64{},
+		Timestamp: time.Now().UTC(),
+	}
+
+	for _, pair := range strings.Split(rates, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		fields := strings.Split(pair, ":")
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("illegal currency rate: %s", pair)
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(fields[1]), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("illegal currency rate: %s", pair)
+		}
+
+		cr.Rates[strings.ToUpper(strings.TrimSpace(fields[0]))] = rate
+	}
+
+	return cr, nil
+}
+
+// Rate returns the number of units of the "to" currency per unit of the "from"
+// currency. Both currencies must be the base or have a rate.
+func (cr *CurrencyRates) Rate(from, to string) (float64, error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+	if from == to {
+		return 1.0, nil
+	}
+
+	rateOf := func(currency string) (float64, bool) {
+		if currency == cr.Base {
+			return 1.0, true
+		}
+		rate, ok := cr.Rates[currency]
+		return rate, ok && rate > 0
+	}
+
+	fromRate, ok := rateOf(from)
+	if !ok {
+		return 0.0, fmt.Errorf("no rate for currency %s", from)
+	}
+	toRate, ok := rateOf(to)
+	if !ok {
+		return 0.0, fmt.Errorf("no rate for currency %s", to)
+	}
+
+	return toRate / fromRate, nil
+}
+
+// CurrencyConversion describes the conversion of a response's costs to a
+// display currency. Costs are converted per component, by the currency in
+// which that component was priced: Rate applies to costs derived from pricing
+// data, and ExternalRate to out-of-cluster costs, which may be billed in a
+// different currency.
+type CurrencyConversion struct {
+	Currency     string    `json:"currency"`
+	Rate         float64   `json:"rate"`
+	ExternalRate float64   `json:"externalRate"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// ConvertAllocation converts the costs of the given allocation, in place.
+func (cc *CurrencyConversion) ConvertAllocation(alloc *kubecost.Allocation) {
+	if alloc == nil {
+		return
+	}
+
+	alloc.CPUCost *= cc.Rate
+	alloc.CPUCostAdjustment *= cc.Rate
+	alloc.GPUCost *= cc.Rate
+	alloc.GPUCostAdjustment *= cc.Rate
+	alloc.RAMCost *= cc.Rate
+	alloc.RAMCostAdjustment *= cc.Rate
+	alloc.NetworkCost *= cc.Rate
+	alloc.NetworkCostAdjustment *= cc.Rate
+	alloc.LoadBalancerCost *= cc.Rate
+	alloc.LoadBalancerCostAdjustment *= cc.Rate
+	alloc.PVCostAdjustment *= cc.Rate
+	alloc.SharedCost *= cc.Rate
+	alloc.ExternalCost *= cc.ExternalRate
+
+	for _, pv := range alloc.PVs {
+		pv.Cost *= cc.Rate
+	}
+}
+
+// ConvertAllocationSet converts the costs of each allocation in the given set,
+// in place.
+func (cc *CurrencyConversion) ConvertAllocationSet(as *kubecost.AllocationSet) {
+	as.Each(func(name string, alloc *kubecost.Allocation) {
+		cc.ConvertAllocation(alloc)
+	})
+}
+
+// ConvertMonetaryFields returns the JSON representation of the given data with
+// every numeric field whose name denotes a cost, a price, or a savings
+// converted by Rate. It is intended for responses derived entirely from
+// pricing data; e.g. savings recommendations.
+func (cc *CurrencyConversion) ConvertMonetaryFields(data interface{}) (interface{}, error) {
+	bs, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding data: %s", err)
+	}
+
+	var generic interface{}
+	err = json.Unmarshal(bs, &generic)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding data: %s", err)
+	}
+
+	return convertMonetaryFields(generic, cc.Rate), nil
+}
+
+// convertMonetaryFields recursively converts the monetary fields of the given
+// decoded JSON value by the given rate.
+func convertMonetaryFields(value interface{}, rate float64) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if f, ok := field.(float64); ok {
+				if isMonetaryField(key) {
+					v[key] = f * rate
+				}
+				continue
+			}
+			v[key] = convertMonetaryFields(field, rate)
+		}
+	case []interface{}:
+		for i, elem := range v {
+			v[i] = convertMonetaryFields(elem, rate)
+		}
+	}
+
+	return value
+}
+
+// isMonetaryField returns true if the given JSON field name denotes a cost, a
+// price, or a savings; e.g. "totalCost", "costPerCPUCoreHour", "monthlySavings"
+func isMonetaryField(name string) bool {
+	name = strings.ToLower(name)
+	return strings.Contains(name, "cost") || strings.Contains(name, "price") || strings.Contains(name, "savings")
+}
+
+// CurrencyConverter computes conversions from the currencies of pricing data
+// and out-of-cluster costs to a display currency, using the rates configured
+// in the custom pricing config. If a currency rates URL is configured, rates
+// are fetched from it and cached, falling back to the configured rates.
+type CurrencyConverter struct {
+	Provider cloud.Provider
+	Client   *http.Client
+	cache    *pricing.PricingCache[string, *CurrencyRates]
+}
+
+// NewCurrencyConverter creates a new CurrencyConverter for the given provider.
+func NewCurrencyConverter(provider cloud.Provider) *CurrencyConverter {
+	return &CurrencyConverter{
+		Provider: provider,
+		Client:   &http.Client{Timeout: 30 * time.Second},
+		cache:    pricing.NewPricingCache[string, *CurrencyRates](currencyRatesTTL),
+	}
+}
+
+// Conversion returns the conversion of costs to the given currency.
+func (cc *CurrencyConverter) Conversion(currency string) (*CurrencyConversion, error) {
+	if currency == "" {
+		return nil, fmt.Errorf("currency is required")
+	}
+
+	cfg, err := cc.Provider.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error loading pricing config: %s", err)
+	}
+
+	pricingCurrency := cfg.CurrencyCode
+	if pricingCurrency == "" {
+		pricingCurrency = defaultCurrencyCode
+	}
+	externalCurrency := cfg.ExternalCostsCurrencyCode
+	if externalCurrency == "" {
+		externalCurrency = pricingCurrency
+	}
+
+	rates, err := cc.rates(cfg, pricingCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	rate, err := rates.Rate(pricingCurrency, currency)
+	if err != nil {
+		return nil, err
+	}
+	externalRate, err := rates.Rate(externalCurrency, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CurrencyConversion{
+		Currency:     strings.ToUpper(currency),
+		Rate:         rate,
+		ExternalRate: externalRate,
+		Timestamp:    rates.Timestamp,
+	}, nil
+}
+
+// rates returns the rates fetched from the configured rates URL, if there is
+// one and it can be reached, and the configured rates otherwise.
+func (cc *CurrencyConverter) rates(cfg *cloud.CustomPricing, pricingCurrency string) (*CurrencyRates, error) {
+	if cfg.CurrencyRatesURL != "" {
+		if rates, ok := cc.cache.Get(cfg.CurrencyRatesURL); ok {
+			return rates, nil
+		}
+
+		rates, err := cc.fetchRates(cfg.CurrencyRatesURL)
+		if err == nil {
+			cc.cache.Set(cfg.CurrencyRatesURL, rates)
+			return rates, nil
+		}
+		klog.V(1).Infof("Failed to fetch currency rates, using configured rates: %s", err)
+	}
+
+	rates, err := parseCurrencyRates(pricingCurrency, cfg.CurrencyRates)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing configured currency rates: %s", err)
+	}
+
+	return rates, nil
+}
+
+// fetchRates fetches rates from the given URL.
+func (cc *CurrencyConverter) fetchRates(url string) (*CurrencyRates, error) {
+	resp, err := cc.Client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("error fetching currency rates from %s: %s", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error fetching currency rates from %s: status %d", url, resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading currency rates from %s: %s", url, err)
+	}
+
+	rates := &CurrencyRates{}
+	err = json.Unmarshal(body, rates)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding currency rates from %s: %s", url, err)
+	}
+	if rates.Base == "" {
+		return nil, fmt.Errorf("currency rates from %s have no base", url)
+	}
+
+	rates.Base = strings.ToUpper(rates.Base)
+	rates.Timestamp = time.Now().UTC()
+	normalized := make(map[string]float64, len(rates.Rates))
+	for currency, rate := range rates.Rates {
+		normalized[strings.ToUpper(currency)] = rate
+	}
+	rates.Rates = normalized
+
+	return rates, nil
+}
+
+// currencyConversion returns the conversion to the currency given by the
+// optional "currency" query parameter, or nil if none was given.
+func (a *Accesses) currencyConversion(qp httputil.QueryParams) (*CurrencyConversion, error) {
+	currency := qp.Get("currency", "")
+	if currency == "" {
+		return nil, nil
+	}
+
+	return a.CurrencyConverter.Conversion(currency)
+}
+
+// writeWithCurrency writes the given data, derived entirely from pricing data,
+// converting its monetary fields by the given conversion, if there is one.
+func writeWithCurrency(w http.ResponseWriter, data interface{}, conversion *CurrencyConversion) {
+	if conversion == nil {
+		w.Write(WrapData(data, nil))
+		return
+	}
+
+	converted, err := conversion.ConvertMonetaryFields(data)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	w.Write(WrapDataWithCurrency(converted, nil, conversion))
+}
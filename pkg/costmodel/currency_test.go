@@ -0,0 +1,192 @@
+package costmodel
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util"
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+type currencyConfigProvider struct {
+	cloud.Provider
+	config *cloud.CustomPricing
+}
+
+func (p *currencyConfigProvider) GetConfig() (*cloud.CustomPricing, error) {
+	return p.config, nil
+}
+
+func newCurrencyTestAlloc() *kubecost.Allocation {
+	start := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	return &kubecost.Allocation{
+		Name:         "alloc",
+		Properties:   &kubecost.AllocationProperties{},
+		Window:       kubecost.NewWindow(&start, &end),
+		Start:        start,
+		End:          end,
+		CPUCost:      4.0,
+		RAMCost:      2.0,
+		SharedCost:   1.0,
+		ExternalCost: 3.0,
+		PVs: kubecost.PVAllocations{
+			{Cluster: "cluster1", Name: "pv1"}: {ByteHours: 1.0, Cost: 2.0},
+		},
+	}
+}
+
+func TestCurrencyConverterConversion(t *testing.T) {
+	// External costs are billed in EUR, so they are not converted again
+	cc := NewCurrencyConverter(&currencyConfigProvider{config: &cloud.CustomPricing{
+		CurrencyCode:              "USD",
+		CurrencyRates:             "EUR:0.5, gbp:0.25",
+		ExternalCostsCurrencyCode: "EUR",
+	}})
+
+	conversion, err := cc.Conversion("eur")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if conversion.Currency != "EUR" || conversion.Rate != 0.5 || conversion.ExternalRate != 1.0 {
+		t.Fatalf("expected EUR at rates 0.5 and 1.0; got %+v", conversion)
+	}
+
+	alloc := newCurrencyTestAlloc()
+	conversion.ConvertAllocation(alloc)
+
+	// (4.0 + 2.0 + 1.0 + 2.0) * 0.5 + 3.0 = 7.5
+	if !util.IsApproximately(7.5, alloc.TotalCost()) {
+		t.Fatalf("expected total cost 7.5; got %f", alloc.TotalCost())
+	}
+	if !util.IsApproximately(3.0, alloc.ExternalCost) || !util.IsApproximately(1.0, alloc.PVCost()) {
+		t.Fatalf("expected external cost 3.0 and PV cost 1.0; got %f and %f", alloc.ExternalCost, alloc.PVCost())
+	}
+
+	// Cross rates are computed through the base currency
+	conversion, err = cc.Conversion("GBP")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if conversion.Rate != 0.25 || conversion.ExternalRate != 0.5 {
+		t.Fatalf("expected GBP at rates 0.25 and 0.5; got %+v", conversion)
+	}
+
+	if _, err := cc.Conversion("JPY"); err == nil {
+		t.Fatalf("expected error for currency without a rate")
+	}
+}
+
+func TestCurrencyConverterConversionSameCurrency(t *testing.T) {
+	cc := NewCurrencyConverter(&currencyConfigProvider{config: &cloud.CustomPricing{}})
+
+	conversion, err := cc.Conversion("USD")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if conversion.Rate != 1.0 || conversion.ExternalRate != 1.0 {
+		t.Fatalf("expected rates of 1.0; got %+v", conversion)
+	}
+
+	alloc := newCurrencyTestAlloc()
+	expected := alloc.TotalCost()
+	conversion.ConvertAllocation(alloc)
+	if alloc.TotalCost() != expected {
+		t.Fatalf("expected total cost %f to be unchanged; got %f", expected, alloc.TotalCost())
+	}
+}
+
+func TestCurrencyConverterRatesURL(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"base": "EUR", "rates": {"usd": 2.0, "GBP": 0.5}}`)
+	}))
+	defer server.Close()
+
+	cc := NewCurrencyConverter(&currencyConfigProvider{config: &cloud.CustomPricing{
+		CurrencyCode:     "USD",
+		CurrencyRates:    "GBP:0.1",
+		CurrencyRatesURL: server.URL,
+	}})
+
+	for i := 0; i < 2; i++ {
+		conversion, err := cc.Conversion("GBP")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !util.IsApproximately(0.25, conversion.Rate) {
+			t.Fatalf("expected fetched rate 0.25; got %f", conversion.Rate)
+		}
+		if conversion.Timestamp.IsZero() {
+			t.Fatalf("expected conversion timestamp")
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("expected fetched rates to be cached; got %d requests", requests)
+	}
+
+	// Configured rates are used if the URL cannot be reached
+	server.Close()
+	cc = NewCurrencyConverter(cc.Provider)
+	conversion, err := cc.Conversion("GBP")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !util.IsApproximately(0.1, conversion.Rate) {
+		t.Fatalf("expected configured rate 0.1; got %f", conversion.Rate)
+	}
+}
+
+func TestConvertMonetaryFields(t *testing.T) {
+	conversion := &CurrencyConversion{Currency: "EUR", Rate: 0.5, ExternalRate: 0.5}
+
+	data := []*NodeUtilization{{Node: "node1", CPUCores: 4.0, HourlyCost: 2.0, TotalCost: 48.0}}
+	converted, err := conversion.ConvertMonetaryFields(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	node := converted.([]interface{})[0].(map[string]interface{})
+	if node["hourlyCost"] != 1.0 || node["totalCost"] != 24.0 {
+		t.Fatalf("expected costs to be converted; got %v", node)
+	}
+	if node["cpuCores"] != 4.0 {
+		t.Fatalf("expected capacity not to be converted; got %v", node["cpuCores"])
+	}
+}
+
+func TestWrapDataWithCurrency(t *testing.T) {
+	timestamp := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	conversion := &CurrencyConversion{Currency: "EUR", Rate: 0.5, ExternalRate: 1.0, Timestamp: timestamp}
+
+	resp := map[string]interface{}{}
+	err := json.Unmarshal(WrapDataWithCurrency([]string{}, nil, conversion), &resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	currency, ok := resp["currency"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected currency annotation; got %v", resp)
+	}
+	if currency["currency"] != "EUR" || currency["rate"] != 0.5 || currency["externalRate"] != 1.0 || currency["timestamp"] != "2021-01-01T00:00:00Z" {
+		t.Fatalf("unexpected currency annotation: %v", currency)
+	}
+
+	// Without a conversion, the annotation is omitted
+	resp = map[string]interface{}{}
+	err = json.Unmarshal(WrapDataWithCurrency([]string{}, nil, nil), &resp)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, ok := resp["currency"]; ok {
+		t.Fatalf("expected no currency annotation; got %v", resp)
+	}
+}
@@ -0,0 +1,228 @@
+package costmodel
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+// envValidationPrefixes are the prefixes of environment variables which are
+// expected to be settings, and so are reported if they are not recognized.
+var envValidationPrefixes = []string{"KUBECOST_", "PROMETHEUS_"}
+
+// maxEnvSuggestionDistance is the maximum edit distance of a recognized
+// setting from an unknown variable for it to be suggested
+const maxEnvSuggestionDistance = 3
+
+// UnknownEnvSetting is an environment variable which looks like a setting,
+// but is not recognized, e.g. because it is misspelled.
+type UnknownEnvSetting struct {
+	Key        string `json:"key"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// InvalidEnvSetting is a recognized setting whose value does not parse, and
+// so is ignored in favor of the default. Values are omitted, because they may
+// be secret.
+type InvalidEnvSetting struct {
+	Key   string          `json:"key"`
+	Kind  env.SettingKind `json:"kind"`
+	Error string          `json:"error"`
+}
+
+// EnvValidationReport is the result of validating the environment.
+type EnvValidationReport struct {
+	Recognized []string             `json:"recognized"`
+	Unknown    []*UnknownEnvSetting `json:"unknown"`
+	Invalid    []*InvalidEnvSetting `json:"invalid"`
+}
+
+// HasIssues returns true if any setting is unknown or invalid
+func (evr *EnvValidationReport) HasIssues() bool {
+	return len(evr.Unknown) > 0 || len(evr.Invalid) > 0
+}
+
+// Log writes the report to the log as a single entry, which is a warning if
+// any setting is unknown or invalid.
+func (evr *EnvValidationReport) Log() {
+	data, err := json.Marshal(evr)
+	if err != nil {
+		log.Warningf("Failed to marshal environment validation report: %s", err)
+		return
+	}
+
+	if evr.HasIssues() {
+		log.Warningf("Environment validation found %d unknown and %d invalid settings: %s", len(evr.Unknown), len(evr.Invalid), data)
+		return
+	}
+
+	log.Infof("Environment validation found no issues: %s", data)
+}
+
+// ValidateEnvironment validates the environment, given as "key=value"
+// entries, e.g. from os.Environ(), against the recognized settings. Variables
+// with a setting prefix which are not recognized are reported as unknown,
+// with the nearest recognized setting as a suggestion, and recognized
+// settings whose values do not parse are reported as invalid. Empty values
+// are not validated, as they are treated as unset.
+func ValidateEnvironment(environ []string, settings map[string]env.SettingKind) *EnvValidationReport {
+	report := &EnvValidationReport{
+		Recognized: []string{},
+		Unknown:    []*UnknownEnvSetting{},
+		Invalid:    []*InvalidEnvSetting{},
+	}
+
+	for _, entry := range environ {
+		split := strings.SplitN(entry, "=", 2)
+		key := split[0]
+		value := ""
+		if len(split) == 2 {
+			value = split[1]
+		}
+
+		kind, ok := settings[key]
+		if !ok {
+			if hasEnvValidationPrefix(key) {
+				report.Unknown = append(report.Unknown, &UnknownEnvSetting{
+					Key:        key,
+					Suggestion: suggestEnvSetting(key, settings),
+				})
+			}
+			continue
+		}
+
+		report.Recognized = append(report.Recognized, key)
+
+		if value == "" {
+			continue
+		}
+		if err := validateEnvValue(kind, value); err != nil {
+			report.Invalid = append(report.Invalid, &InvalidEnvSetting{
+				Key:   key,
+				Kind:  kind,
+				Error: err.Error(),
+			})
+		}
+	}
+
+	sort.Strings(report.Recognized)
+	sort.Slice(report.Unknown, func(i, j int) bool { return report.Unknown[i].Key < report.Unknown[j].Key })
+	sort.Slice(report.Invalid, func(i, j int) bool { return report.Invalid[i].Key < report.Invalid[j].Key })
+
+	return report
+}
+
+// hasEnvValidationPrefix returns true if the key has a setting prefix
+func hasEnvValidationPrefix(key string) bool {
+	for _, prefix := range envValidationPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateEnvValue returns an error if the value is not of the given kind. The
+// error describes the expected kind, but not the value, which may be secret.
+func validateEnvValue(kind env.SettingKind, value string) error {
+	switch kind {
+	case env.BoolSetting:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("expected a boolean")
+		}
+	case env.IntSetting:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return fmt.Errorf("expected an integer")
+		}
+	case env.FloatSetting:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("expected a number")
+		}
+	case env.DurationSetting:
+		if _, err := env.ParseDuration(value, time.Second); err != nil {
+			return fmt.Errorf("expected a duration, e.g. \"10m\" or \"2h\"")
+		}
+	case env.URLSetting:
+		u, err := url.Parse(value)
+		if err != nil {
+			// the error includes the value, which may contain credentials
+			return fmt.Errorf("not a valid URL")
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("URL must be absolute, with a scheme and host")
+		}
+	}
+
+	return nil
+}
+
+// suggestEnvSetting returns the recognized setting nearest to the key by edit
+// distance, or an empty string if none is near enough. Ties are broken
+// alphabetically, so that suggestions are deterministic.
+func suggestEnvSetting(key string, settings map[string]env.SettingKind) string {
+	suggestion := ""
+	best := maxEnvSuggestionDistance + 1
+
+	for setting := range settings {
+		d := editDistance(key, setting)
+		if d < best || (d == best && setting < suggestion) {
+			suggestion = setting
+			best = d
+		}
+	}
+
+	if best > maxEnvSuggestionDistance {
+		return ""
+	}
+	return suggestion
+}
+
+// editDistance returns the Levenshtein distance between a and b, i.e. the
+// number of single character insertions, deletions, and substitutions which
+// transform one into the other.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(minInt(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// GetEnvValidation returns the report of the validation of the environment
+// at startup.
+func (a *Accesses) GetEnvValidation(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	w.Write(WrapData(a.EnvValidation, nil))
+}
@@ -0,0 +1,117 @@
+package costmodel
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/prom"
+	"github.com/patrickmn/go-cache"
+	"k8s.io/klog"
+)
+
+// externalDateFormat is the format of the inclusive start and end dates
+// expected by ExternalAllocations.
+const externalDateFormat = "2006-01-02"
+
+// externalAllocationsWithCache queries the cloud provider for out-of-cluster
+// costs over the given window, aggregated by the tag corresponding to the
+// given label key. Results are cached in the OutOfClusterCache, which is
+// shared with the outOfClusterCostsWithCache endpoint, because billing queries
+// (e.g. Athena or BigQuery) are slow and expensive. Out-of-cluster costs are
+// only available at daily granularity, so the window is rounded out to the
+// days it touches.
+func (a *Accesses) externalAllocationsWithCache(start, end time.Time, labelKey string) ([]*cloud.OutOfClusterAllocation, error) {
+	startDate := start.UTC().Format(externalDateFormat)
+	endDate := end.Add(-time.Nanosecond).UTC().Format(externalDateFormat)
+
+	aggregationKey, aggregation, filter := parseAggregations("", labelKey, "")
+
+	key := fmt.Sprintf(`%s:%s:%s:%s:%s`, startDate, endDate, aggregationKey, filter, "")
+	if value, found := a.OutOfClusterCache.Get(key); found {
+		if data, ok := value.([]*cloud.OutOfClusterAllocation); ok {
+			return data, nil
+		}
+		klog.Errorf("caching error: failed to type cast data: %s", key)
+	}
+
+	data, err := a.CloudProvider.ExternalAllocations(startDate, endDate, aggregation, filter, "", false)
+	if err != nil {
+		return nil, err
+	}
+	a.OutOfClusterCache.Set(key, data, cache.DefaultExpiration)
+
+	return data, nil
+}
+
+// applyExternalCosts attributes out-of-cluster costs, aggregated by the tag
+// corresponding to the given label key, to the allocations of the given set,
+// which must be aggregated by that label. Each external cost is added to the
+// ExternalCost of the allocation whose label value matches the tag value. The
+// costs of tag values without a matching allocation are collected into a
+// single external allocation, so that the total cost of the set includes them.
+func applyExternalCosts(as *kubecost.AllocationSet, labelKey string, externals []*cloud.OutOfClusterAllocation) error {
+	if as == nil {
+		return nil
+	}
+
+	labelName := prom.SanitizeLabelName(strings.TrimSpace(labelKey))
+
+	costsByValue := map[string]float64{}
+	for _, ooc := range externals {
+		if ooc == nil || ooc.Environment == "" {
+			continue
+		}
+		costsByValue[ooc.Environment] += ooc.Cost
+	}
+
+	matched := map[string]bool{}
+	as.Each(func(name string, alloc *kubecost.Allocation) {
+		value, ok := allocationLabelValue(name, labelName)
+		if !ok {
+			return
+		}
+
+		if cost, ok := costsByValue[value]; ok {
+			alloc.ExternalCost += cost
+			matched[value] = true
+		}
+	})
+
+	unmatchedCost := 0.0
+	for value, cost := range costsByValue {
+		if !matched[value] {
+			unmatchedCost += cost
+		}
+	}
+
+	if unmatchedCost == 0.0 {
+		return nil
+	}
+
+	start, end := as.Start(), as.End()
+	return as.Insert(&kubecost.Allocation{
+		Name:         kubecost.ExternalSuffix,
+		Properties:   &kubecost.AllocationProperties{},
+		Window:       kubecost.NewWindow(&start, &end),
+		Start:        start,
+		End:          end,
+		ExternalCost: unmatchedCost,
+	})
+}
+
+// allocationLabelValue returns the value of the given label from the name of
+// an allocation aggregated by that label, e.g. "web" from "app=web" or from
+// "namespace1/app=web".
+func allocationLabelValue(name, labelName string) (string, bool) {
+	prefix := labelName + "="
+	for _, segment := range strings.Split(name, "/") {
+		if strings.HasPrefix(segment, prefix) {
+			return strings.TrimPrefix(segment, prefix), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,136 @@
+package costmodel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util"
+	"github.com/patrickmn/go-cache"
+)
+
+type countingExternalProvider struct {
+	cloud.Provider
+	calls     int
+	externals []*cloud.OutOfClusterAllocation
+}
+
+func (p *countingExternalProvider) ExternalAllocations(start, end string, aggregators []string, filterType, filterValue string, crossCluster bool) ([]*cloud.OutOfClusterAllocation, error) {
+	p.calls++
+	return p.externals, nil
+}
+
+func newExternalTestSet(start, end time.Time, names ...string) *kubecost.AllocationSet {
+	as := kubecost.NewAllocationSet(start, end)
+	for _, name := range names {
+		as.Insert(&kubecost.Allocation{
+			Name:         name,
+			Properties:   &kubecost.AllocationProperties{},
+			Window:       kubecost.NewWindow(&start, &end),
+			Start:        start,
+			End:          end,
+			CPUCost:      1.0,
+			ExternalCost: 1.0,
+		})
+	}
+	return as
+}
+
+func TestApplyExternalCosts(t *testing.T) {
+	start := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	as := newExternalTestSet(start, end, "app=web", "app=api", kubecost.UnallocatedSuffix)
+
+	externals := []*cloud.OutOfClusterAllocation{
+		// two services tagged with the same value overlap a single allocation
+		{Aggregator: "kubernetes_app", Environment: "web", Service: "AmazonRDS", Cost: 10.0},
+		{Aggregator: "kubernetes_app", Environment: "web", Service: "AmazonS3", Cost: 5.0},
+		// orphan tag values have no allocation
+		{Aggregator: "kubernetes_app", Environment: "db", Service: "AmazonRDS", Cost: 7.0},
+		{Aggregator: "kubernetes_app", Environment: "cache", Service: "AmazonElastiCache", Cost: 3.0},
+		// untagged costs are ignored
+		{Aggregator: "kubernetes_app", Environment: "", Service: "AmazonEC2", Cost: 100.0},
+	}
+
+	err := applyExternalCosts(as, "app", externals)
+	if err != nil {
+		t.Fatalf("applyExternalCosts: unexpected error: %s", err)
+	}
+
+	cases := map[string]float64{
+		"app=web":                  16.0,
+		"app=api":                  1.0,
+		kubecost.UnallocatedSuffix: 1.0,
+		kubecost.ExternalSuffix:    10.0,
+	}
+	for name, expected := range cases {
+		alloc := as.Get(name)
+		if alloc == nil {
+			t.Fatalf("applyExternalCosts: expected allocation %s", name)
+		}
+		if !util.IsApproximately(expected, alloc.ExternalCost) {
+			t.Fatalf("applyExternalCosts: expected %s external cost %f; actual %f", name, expected, alloc.ExternalCost)
+		}
+	}
+
+	if !util.IsApproximately(31.0, as.TotalCost()) {
+		t.Fatalf("applyExternalCosts: expected total cost %f; actual %f", 31.0, as.TotalCost())
+	}
+}
+
+func TestAllocationLabelValue(t *testing.T) {
+	cases := []struct {
+		name     string
+		label    string
+		expected string
+		ok       bool
+	}{
+		{"app=web", "app", "web", true},
+		{"namespace1/app=web", "app", "web", true},
+		{"app_name=web", "app", "", false},
+		{kubecost.UnallocatedSuffix, "app", "", false},
+	}
+
+	for _, c := range cases {
+		value, ok := allocationLabelValue(c.name, c.label)
+		if value != c.expected || ok != c.ok {
+			t.Fatalf("allocationLabelValue(%s, %s): expected (%s, %t); actual (%s, %t)", c.name, c.label, c.expected, c.ok, value, ok)
+		}
+	}
+}
+
+func TestExternalAllocationsWithCache(t *testing.T) {
+	provider := &countingExternalProvider{
+		externals: []*cloud.OutOfClusterAllocation{{Environment: "web", Cost: 1.0}},
+	}
+	a := &Accesses{
+		CloudProvider:     provider,
+		OutOfClusterCache: cache.New(5*time.Minute, 10*time.Minute),
+	}
+
+	start := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	for i := 0; i < 3; i++ {
+		externals, err := a.externalAllocationsWithCache(start, end, "app")
+		if err != nil {
+			t.Fatalf("externalAllocationsWithCache: unexpected error: %s", err)
+		}
+		if len(externals) != 1 {
+			t.Fatalf("externalAllocationsWithCache: expected 1 result; actual %d", len(externals))
+		}
+	}
+	if provider.calls != 1 {
+		t.Fatalf("externalAllocationsWithCache: expected 1 provider call; actual %d", provider.calls)
+	}
+
+	// a different window is queried separately
+	if _, err := a.externalAllocationsWithCache(end, end.Add(24*time.Hour), "app"); err != nil {
+		t.Fatalf("externalAllocationsWithCache: unexpected error: %s", err)
+	}
+	if provider.calls != 2 {
+		t.Fatalf("externalAllocationsWithCache: expected 2 provider calls; actual %d", provider.calls)
+	}
+}
@@ -0,0 +1,72 @@
+package costmodel
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kubecost/cost-model/pkg/env"
+)
+
+// featureFlagMetricInit registers the feature flag collector once
+var featureFlagMetricInit sync.Once
+
+// featureFlagDesc describes the kubecost_feature_enabled metric
+var featureFlagDesc = prometheus.NewDesc(
+	"kubecost_feature_enabled",
+	"kubecost_feature_enabled 1 if the feature flag is enabled, 0 otherwise",
+	[]string{"flag"},
+	nil,
+)
+
+// FeatureFlagCollector is a prometheus collector which emits whether each registered
+// feature flag is enabled, so that the flags a pod is running with are visible.
+type FeatureFlagCollector struct{}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (ffc FeatureFlagCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- featureFlagDesc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (ffc FeatureFlagCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, flag := range env.GetFeatureFlags() {
+		value := 0.0
+		if flag.Enabled() {
+			value = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(featureFlagDesc, prometheus.GaugeValue, value, flag.Name)
+	}
+}
+
+// registerFeatureFlagMetric registers the kubecost_feature_enabled metric
+func registerFeatureFlagMetric() {
+	featureFlagMetricInit.Do(func() {
+		prometheus.MustRegister(FeatureFlagCollector{})
+	})
+}
+
+// FeatureFlagStatus is a registered feature flag and whether it is enabled.
+type FeatureFlagStatus struct {
+	*env.FeatureFlag
+	Enabled bool `json:"enabled"`
+}
+
+// GetFeatureFlags returns each registered feature flag, its description, and whether it
+// is enabled, sorted by name.
+func (a *Accesses) GetFeatureFlags(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	flags := []*FeatureFlagStatus{}
+	for _, flag := range env.GetFeatureFlags() {
+		flags = append(flags, &FeatureFlagStatus{
+			FeatureFlag: flag,
+			Enabled:     flag.Enabled(),
+		})
+	}
+
+	w.Write(WrapData(flags, nil))
+}
@@ -0,0 +1,39 @@
+package costmodel
+
+import (
+	"os"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/kubecost/cost-model/pkg/env"
+)
+
+func TestFeatureFlagCollector(t *testing.T) {
+	os.Setenv(env.EmitIngressMetricsEnvVar, "true")
+	defer os.Unsetenv(env.EmitIngressMetricsEnvVar)
+
+	ch := make(chan prometheus.Metric, 100)
+	FeatureFlagCollector{}.Collect(ch)
+	close(ch)
+
+	values := map[string]float64{}
+	for metric := range ch {
+		m := &dto.Metric{}
+		if err := metric.Write(m); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		values[m.GetLabel()[0].GetValue()] = m.GetGauge().GetValue()
+	}
+
+	if len(values) != len(env.GetFeatureFlags()) {
+		t.Fatalf("expected a metric per flag; got %v", values)
+	}
+	if values[env.IngressMetricsFlag.Name] != 1.0 {
+		t.Errorf("expected %s to be enabled; got %v", env.IngressMetricsFlag.Name, values)
+	}
+	if values[env.AllocationCostMetricsControllersFlag.Name] != 0.0 {
+		t.Errorf("expected %s to be disabled; got %v", env.AllocationCostMetricsControllersFlag.Name, values)
+	}
+}
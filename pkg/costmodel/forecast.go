@@ -0,0 +1,333 @@
+package costmodel
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/util/httputil"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+)
+
+const (
+	// ForecastModelRunRate projects the remaining days of the month at the
+	// average daily cost observed so far.
+	ForecastModelRunRate = "runrate"
+
+	// ForecastModelDayOfWeek projects each remaining day of the month at the
+	// average cost observed on the same day of the week, accounting for
+	// weekend dips.
+	ForecastModelDayOfWeek = "dayofweek"
+
+	// ForecastStatusOK indicates that a forecast was computed
+	ForecastStatusOK = "ok"
+
+	// ForecastStatusInsufficientData indicates that too few days of data were
+	// available to compute a meaningful forecast.
+	ForecastStatusInsufficientData = "insufficientData"
+)
+
+// ForecastOptions configures month-end cost forecasting
+type ForecastOptions struct {
+	// Model is the forecasting model: ForecastModelRunRate or ForecastModelDayOfWeek
+	Model string
+
+	// MinDays is the minimum number of days with data required to forecast
+	MinDays int
+
+	// ZScore determines the width of the confidence band, in standard deviations
+	ZScore float64
+}
+
+// DefaultForecastOptions returns the default ForecastOptions: a day-of-week model
+// requiring 5 days of data, with a 95% confidence band.
+func DefaultForecastOptions() *ForecastOptions {
+	return &ForecastOptions{
+		Model:   ForecastModelDayOfWeek,
+		MinDays: 5,
+		ZScore:  1.96,
+	}
+}
+
+// ForecastScope limits a forecast to the allocations of a cluster, namespace,
+// and/or set of labels. An empty scope includes all allocations.
+type ForecastScope = kubecost.AllocationScope
+
+// DailyCost is the cost of a single day. Historical days which had no data are
+// Interpolated from their neighbors; projected days carry a confidence band.
+type DailyCost struct {
+	Date         time.Time `json:"date"`
+	Cost         float64   `json:"cost"`
+	Lower        float64   `json:"lower,omitempty"`
+	Upper        float64   `json:"upper,omitempty"`
+	Interpolated bool      `json:"interpolated,omitempty"`
+}
+
+// CostForecast is the projected end-of-month cost of a scope
+type CostForecast struct {
+	Status      string         `json:"status"`
+	Model       string         `json:"model"`
+	Month       string         `json:"month"`
+	Scope       *ForecastScope `json:"scope,omitempty"`
+	DaysOfData  int            `json:"daysOfData"`
+	MonthToDate float64        `json:"monthToDate"`
+	Projected   float64        `json:"projected"`
+	Lower       float64        `json:"lower"`
+	Upper       float64        `json:"upper"`
+	History     []*DailyCost   `json:"history"`
+	Projection  []*DailyCost   `json:"projection"`
+}
+
+// ForecastMonthEnd projects the total cost of the month beginning at start. The
+// observed costs are keyed by the start of each day (UTC) and cover the days from
+// start up to, but not including, end. Days in that range without an observed
+// cost are interpolated. The days from end to the end of the month are projected
+// using the configured model.
+func ForecastMonthEnd(start, end time.Time, observed map[time.Time]float64, opts *ForecastOptions) (*CostForecast, error) {
+	if opts == nil {
+		opts = DefaultForecastOptions()
+	}
+	if opts.Model != ForecastModelRunRate && opts.Model != ForecastModelDayOfWeek {
+		return nil, fmt.Errorf("illegal forecast model: %s", opts.Model)
+	}
+
+	start = timeutil.StartOfDay(start, time.UTC)
+	end = timeutil.StartOfDay(end, time.UTC)
+	monthEnd := start.AddDate(0, 1, 0)
+	if end.Before(start) || end.After(monthEnd) {
+		return nil, fmt.Errorf("end %s must be within the month beginning %s", end, start)
+	}
+
+	forecast := &CostForecast{
+		Status:     ForecastStatusOK,
+		Model:      opts.Model,
+		Month:      start.Format("2006-01"),
+		History:    interpolateDailyCosts(start, end, observed),
+		Projection: []*DailyCost{},
+	}
+
+	costs := []float64{}
+	weekdayCosts := map[time.Weekday][]float64{}
+	for _, dc := range forecast.History {
+		forecast.MonthToDate += dc.Cost
+		if !dc.Interpolated {
+			costs = append(costs, dc.Cost)
+			weekdayCosts[dc.Date.Weekday()] = append(weekdayCosts[dc.Date.Weekday()], dc.Cost)
+		}
+	}
+	forecast.DaysOfData = len(costs)
+
+	if forecast.DaysOfData < opts.MinDays || forecast.DaysOfData == 0 {
+		forecast.Status = ForecastStatusInsufficientData
+		return forecast, nil
+	}
+
+	mean, stdDev := meanStdDev(costs)
+
+	// The expected cost of each day of the week. Days of the week which have
+	// not been observed fall back to the overall mean.
+	expected := map[time.Weekday]float64{}
+	for wd := time.Sunday; wd <= time.Saturday; wd++ {
+		expected[wd] = mean
+	}
+
+	if opts.Model == ForecastModelDayOfWeek {
+		sumSq := 0.0
+		for wd, wdCosts := range weekdayCosts {
+			wdMean, _ := meanStdDev(wdCosts)
+			expected[wd] = wdMean
+			for _, c := range wdCosts {
+				sumSq += (c - wdMean) * (c - wdMean)
+			}
+		}
+
+		// Use the residual deviation from each day-of-week mean, unless there
+		// are too few days to estimate it, in which case keep the overall one
+		if dof := len(costs) - len(weekdayCosts); dof > 0 {
+			stdDev = math.Sqrt(sumSq / float64(dof))
+		}
+	}
+
+	margin := opts.ZScore * stdDev
+	remaining := 0
+	projected := 0.0
+	for day := end; day.Before(monthEnd); day = day.AddDate(0, 0, 1) {
+		cost := expected[day.Weekday()]
+		forecast.Projection = append(forecast.Projection, &DailyCost{
+			Date:  day,
+			Cost:  cost,
+			Lower: math.Max(0.0, cost-margin),
+			Upper: cost + margin,
+		})
+		projected += cost
+		remaining++
+	}
+
+	// Assuming independent days, the deviation of the sum grows with the
+	// square root of the number of days projected
+	totalMargin := margin * math.Sqrt(float64(remaining))
+
+	forecast.Projected = forecast.MonthToDate + projected
+	forecast.Lower = math.Max(forecast.MonthToDate, forecast.Projected-totalMargin)
+	forecast.Upper = forecast.Projected + totalMargin
+
+	return forecast, nil
+}
+
+// interpolateDailyCosts returns a DailyCost for each day from start up to end.
+// Days without an observed cost are linearly interpolated between the nearest
+// observed days, or copied from the nearest observed day at either edge.
+func interpolateDailyCosts(start, end time.Time, observed map[time.Time]float64) []*DailyCost {
+	history := []*DailyCost{}
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		cost, ok := observed[day]
+		history = append(history, &DailyCost{
+			Date:         day,
+			Cost:         cost,
+			Interpolated: !ok,
+		})
+	}
+
+	for i, dc := range history {
+		if !dc.Interpolated {
+			continue
+		}
+
+		prev, next := -1, -1
+		for j := i - 1; j >= 0; j-- {
+			if !history[j].Interpolated {
+				prev = j
+				break
+			}
+		}
+		for j := i + 1; j < len(history); j++ {
+			if !history[j].Interpolated {
+				next = j
+				break
+			}
+		}
+
+		switch {
+		case prev >= 0 && next >= 0:
+			frac := float64(i-prev) / float64(next-prev)
+			dc.Cost = history[prev].Cost + frac*(history[next].Cost-history[prev].Cost)
+		case prev >= 0:
+			dc.Cost = history[prev].Cost
+		case next >= 0:
+			dc.Cost = history[next].Cost
+		}
+	}
+
+	return history
+}
+
+// meanStdDev returns the mean and sample standard deviation of the values
+func meanStdDev(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0.0, 0.0
+	}
+
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	if len(values) < 2 {
+		return mean, 0.0
+	}
+
+	sumSq := 0.0
+	for _, v := range values {
+		sumSq += (v - mean) * (v - mean)
+	}
+
+	return mean, math.Sqrt(sumSq / float64(len(values)-1))
+}
+
+// ComputeDailyScopeCosts computes the cost of the scope for each day from start
+// up to end, keyed by the start of the day. Days for which allocations could not
+// be computed are omitted.
+func (cm *CostModel) ComputeDailyScopeCosts(scope *ForecastScope, start, end time.Time, resolution time.Duration) map[time.Time]float64 {
+	costs := map[time.Time]float64{}
+
+	for day := timeutil.StartOfDay(start, time.UTC); day.Before(end); day = day.AddDate(0, 0, 1) {
+		as, err := cm.ComputeAllocation(day, day.AddDate(0, 0, 1), resolution)
+		if err != nil {
+			log.Warningf("Forecast: failed to compute allocations for %s: %s", day.Format("2006-01-02"), err)
+			continue
+		}
+		if as == nil || as.IsEmpty() {
+			continue
+		}
+
+		cost := 0.0
+		as.Each(func(name string, alloc *kubecost.Allocation) {
+			if scope.Matches(alloc) {
+				cost += alloc.TotalCost()
+			}
+		})
+		costs[day] = cost
+	}
+
+	return costs
+}
+
+// ForecastHandler projects the end-of-month cost of a cluster, namespace, and/or
+// label scope from the month-to-date daily costs.
+func (a *Accesses) ForecastHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	qp := httputil.NewQueryParams(r.URL.Query())
+
+	opts := DefaultForecastOptions()
+	opts.Model = qp.Get("model", opts.Model)
+	opts.MinDays = qp.GetInt("minDays", opts.MinDays)
+	opts.ZScore = qp.GetFloat64("zScore", opts.ZScore)
+
+	if opts.Model != ForecastModelRunRate && opts.Model != ForecastModelDayOfWeek {
+		WriteError(w, BadRequest(fmt.Sprintf("model must be one of: %s, %s", ForecastModelRunRate, ForecastModelDayOfWeek)))
+		return
+	}
+	if opts.MinDays < 1 {
+		WriteError(w, BadRequest("minDays must be positive"))
+		return
+	}
+
+	// labels are expected to be comma-separated and to take the form key=value
+	// e.g. app=cost-analyzer,app.kubernetes.io/part-of=finance
+	labels, err := parseLabelSelectors(qp.GetList("labels", ","))
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'labels' parameter: %s", err)))
+		return
+	}
+
+	scope := &ForecastScope{
+		Cluster:   qp.Get("cluster", ""),
+		Namespace: qp.Get("namespace", ""),
+		Labels:    labels,
+	}
+
+	now := time.Now().UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	// Only complete days contribute to the history; today is projected
+	end := timeutil.StartOfDay(now, time.UTC)
+
+	observed := a.Model.ComputeDailyScopeCosts(scope, start, end, env.GetETLResolution())
+
+	forecast, err := ForecastMonthEnd(start, end, observed, opts)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+	forecast.Scope = scope
+
+	w.Write(WrapData(forecast, nil))
+}
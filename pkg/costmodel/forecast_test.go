@@ -0,0 +1,169 @@
+package costmodel
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/kubecost"
+)
+
+// seasonalCosts returns a daily cost of 100 on weekdays and 40 on weekends for
+// each day from start up to end.
+func seasonalCosts(start, end time.Time) map[time.Time]float64 {
+	costs := map[time.Time]float64{}
+	for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			costs[day] = 40.0
+		} else {
+			costs[day] = 100.0
+		}
+	}
+	return costs
+}
+
+func TestForecastMonthEnd(t *testing.T) {
+	// March 1, 2021 is a Monday; observe two full weeks
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, time.March, 15, 0, 0, 0, 0, time.UTC)
+	observed := seasonalCosts(start, end)
+
+	// Month-to-date is 10 weekdays and 4 weekend days; the remaining 17 days
+	// are 13 weekdays and 4 weekend days
+	expMTD := 10.0*100.0 + 4.0*40.0
+
+	dow, err := ForecastMonthEnd(start, end, observed, &ForecastOptions{Model: ForecastModelDayOfWeek, MinDays: 5, ZScore: 1.96})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if dow.Status != ForecastStatusOK {
+		t.Fatalf("expected status %s; got %s", ForecastStatusOK, dow.Status)
+	}
+	if dow.MonthToDate != expMTD {
+		t.Fatalf("expected month-to-date of %f; got %f", expMTD, dow.MonthToDate)
+	}
+	if len(dow.History) != 14 || len(dow.Projection) != 17 {
+		t.Fatalf("expected 14 historical and 17 projected days; got %d and %d", len(dow.History), len(dow.Projection))
+	}
+
+	expDOW := expMTD + 13.0*100.0 + 4.0*40.0
+	if math.Abs(dow.Projected-expDOW) > 0.0001 {
+		t.Fatalf("expected day-of-week projection of %f; got %f", expDOW, dow.Projected)
+	}
+
+	// The seasonal pattern is fit exactly, so there is no uncertainty
+	if math.Abs(dow.Upper-dow.Lower) > 0.0001 {
+		t.Fatalf("expected an empty confidence band; got [%f, %f]", dow.Lower, dow.Upper)
+	}
+
+	// The run-rate model ignores the weekend dip
+	rr, err := ForecastMonthEnd(start, end, observed, &ForecastOptions{Model: ForecastModelRunRate, MinDays: 5, ZScore: 1.96})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expRR := expMTD * 31.0 / 14.0
+	if math.Abs(rr.Projected-expRR) > 0.0001 {
+		t.Fatalf("expected run-rate projection of %f; got %f", expRR, rr.Projected)
+	}
+	if !(rr.Lower < rr.Projected && rr.Projected < rr.Upper) {
+		t.Fatalf("expected projection %f to be within confidence band [%f, %f]", rr.Projected, rr.Lower, rr.Upper)
+	}
+	if rr.Lower < rr.MonthToDate {
+		t.Fatalf("expected lower bound %f to be at least month-to-date %f", rr.Lower, rr.MonthToDate)
+	}
+
+	// Missing days are interpolated and marked, but don't count as data
+	missing := time.Date(2021, time.March, 3, 0, 0, 0, 0, time.UTC)
+	delete(observed, missing)
+
+	interp, err := ForecastMonthEnd(start, end, observed, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if interp.DaysOfData != 13 {
+		t.Fatalf("expected 13 days of data; got %d", interp.DaysOfData)
+	}
+	for _, dc := range interp.History {
+		if dc.Date.Equal(missing) {
+			if !dc.Interpolated || dc.Cost != 100.0 {
+				t.Fatalf("expected %s to be interpolated at 100.0; got %+v", missing, dc)
+			}
+		} else if dc.Interpolated {
+			t.Fatalf("expected %s not to be interpolated", dc.Date)
+		}
+	}
+	if math.Abs(interp.Projected-expDOW) > 0.0001 {
+		t.Fatalf("expected projection of %f with interpolation; got %f", expDOW, interp.Projected)
+	}
+
+	if _, err := ForecastMonthEnd(start, end, observed, &ForecastOptions{Model: "linear"}); err == nil {
+		t.Fatalf("expected error for illegal model")
+	}
+}
+
+func TestForecastMonthEndInsufficientData(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2021, time.March, 4, 0, 0, 0, 0, time.UTC)
+
+	forecast, err := ForecastMonthEnd(start, end, seasonalCosts(start, end), DefaultForecastOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if forecast.Status != ForecastStatusInsufficientData {
+		t.Fatalf("expected status %s; got %s", ForecastStatusInsufficientData, forecast.Status)
+	}
+	if forecast.Projected != 0.0 || len(forecast.Projection) != 0 {
+		t.Fatalf("expected no projection; got %f over %d days", forecast.Projected, len(forecast.Projection))
+	}
+	if len(forecast.History) != 3 {
+		t.Fatalf("expected 3 historical days; got %d", len(forecast.History))
+	}
+}
+
+func TestInterpolateDailyCosts(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 5)
+
+	observed := map[time.Time]float64{
+		start.AddDate(0, 0, 1): 10.0,
+		start.AddDate(0, 0, 3): 30.0,
+	}
+
+	history := interpolateDailyCosts(start, end, observed)
+
+	expected := []float64{10.0, 10.0, 20.0, 30.0, 30.0}
+	for i, dc := range history {
+		if dc.Cost != expected[i] {
+			t.Fatalf("day %d: expected cost %f; got %f", i, expected[i], dc.Cost)
+		}
+		if _, ok := observed[dc.Date]; ok == dc.Interpolated {
+			t.Fatalf("day %d: expected interpolated to be %t", i, !ok)
+		}
+	}
+}
+
+func TestForecastScopeMatchesLabels(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	alloc := kubecost.NewMockUnitAllocation("cluster-a/web/pod", start, 24*time.Hour, &kubecost.AllocationProperties{
+		Cluster:   "cluster-a",
+		Namespace: "web",
+		Labels:    map[string]string{"app_kubernetes_io_part_of": "finance", "query": "a=b"},
+	})
+
+	labels, err := parseLabelSelectors([]string{"app.kubernetes.io/part-of=finance", "query=a=b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	scope := &ForecastScope{Labels: labels}
+	if !scope.Matches(alloc) {
+		t.Fatalf("expected labels with dots, slashes, and '=' in values to match; got %v", labels)
+	}
+
+	// Label names given to the scope directly are sanitized too
+	scope = &ForecastScope{Labels: map[string]string{"app.kubernetes.io/part-of": "finance"}}
+	if !scope.Matches(alloc) {
+		t.Fatalf("expected an unsanitized label name to match")
+	}
+}
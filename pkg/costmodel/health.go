@@ -0,0 +1,322 @@
+package costmodel
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/cloud/pricing"
+	"github.com/kubecost/cost-model/pkg/clustercache"
+	"github.com/kubecost/cost-model/pkg/costmodel/clusters"
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/prom"
+	"github.com/kubecost/cost-model/pkg/util/json"
+
+	prometheusClient "github.com/prometheus/client_golang/api"
+)
+
+// The names of the readiness checks, which may be excluded from readiness by
+// listing them in READINESS_EXCLUDED_CHECKS
+const (
+	PrometheusReadinessCheck   = "prometheus"
+	ClusterCacheReadinessCheck = "clusterCache"
+	PricingReadinessCheck      = "pricing"
+	ClusterMapReadinessCheck   = "clusterMap"
+	CredentialsReadinessCheck  = "credentials"
+)
+
+// The statuses of a readiness check
+const (
+	ReadinessCheckOK       = "ok"
+	ReadinessCheckFailed   = "failed"
+	ReadinessCheckExcluded = "excluded"
+)
+
+// readinessCheckTimeout is how long a readiness check may run before it is
+// considered failed
+const readinessCheckTimeout = 10 * time.Second
+
+// prometheusReadinessQuery is a query which Prometheus answers without reading
+// any series, so that checking connectivity is cheap
+const prometheusReadinessQuery = "vector(1)"
+
+// ReadinessCheck is a named check of a dependency, which returns an error if
+// the dependency is not ready.
+type ReadinessCheck struct {
+	Name  string
+	Check func() error
+}
+
+// ReadinessCheckResult is the outcome of a single ReadinessCheck.
+type ReadinessCheckResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ReadinessReport is the outcome of all readiness checks. The service is ready
+// if every check which was not excluded succeeded.
+type ReadinessReport struct {
+	Ready  bool                    `json:"ready"`
+	Checks []*ReadinessCheckResult `json:"checks"`
+}
+
+// runReadinessChecks runs the given checks concurrently, except those named in
+// excluded, and reports the result of each in the order given. Checks which do
+// not complete within the timeout fail.
+func runReadinessChecks(checks []*ReadinessCheck, excluded []string, timeout time.Duration) *ReadinessReport {
+	isExcluded := make(map[string]bool, len(excluded))
+	for _, name := range excluded {
+		isExcluded[strings.ToLower(name)] = true
+	}
+
+	report := &ReadinessReport{
+		Ready:  true,
+		Checks: make([]*ReadinessCheckResult, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		result := &ReadinessCheckResult{Name: check.Name}
+		report.Checks[i] = result
+
+		if isExcluded[strings.ToLower(check.Name)] {
+			result.Status = ReadinessCheckExcluded
+			continue
+		}
+
+		wg.Add(1)
+		go func(check *ReadinessCheck, result *ReadinessCheckResult) {
+			defer wg.Done()
+
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- check.Check()
+			}()
+
+			var err error
+			select {
+			case err = <-errCh:
+			case <-time.After(timeout):
+				err = fmt.Errorf("timed out after %s", timeout)
+			}
+
+			if err != nil {
+				result.Status = ReadinessCheckFailed
+				result.Error = err.Error()
+				return
+			}
+			result.Status = ReadinessCheckOK
+		}(check, result)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status == ReadinessCheckFailed {
+			report.Ready = false
+		}
+	}
+
+	return report
+}
+
+// checkPrometheus returns an error if the given Prometheus client cannot
+// answer a query.
+func checkPrometheus(cli prometheusClient.Client) error {
+	if cli == nil {
+		return fmt.Errorf("no Prometheus client")
+	}
+
+	ctx := prom.NewNamedContext(cli, prom.DiagnosticContextName)
+	_, _, err := ctx.QuerySync(prometheusReadinessQuery)
+	return err
+}
+
+// checkClusterCache returns an error if the given cluster cache has not yet
+// cached every watched resource.
+func checkClusterCache(cache clustercache.ClusterCache) error {
+	if cache == nil {
+		return fmt.Errorf("no cluster cache")
+	}
+
+	if !cache.HasSynced() {
+		return fmt.Errorf("cluster cache has not synced")
+	}
+
+	return nil
+}
+
+// checkPricing returns an error if the most recent pricing download failed, or
+// if the provider reports pricing sources and none of them are available.
+func checkPricing(provider cloud.Provider, status *pricingDownloadStatus) error {
+	if provider == nil {
+		return fmt.Errorf("no cloud provider")
+	}
+
+	attempted, err := status.get()
+	if !attempted {
+		return fmt.Errorf("pricing data has not been downloaded")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download pricing data: %s", err)
+	}
+
+	sources := provider.PricingSourceStatus()
+	if len(sources) == 0 {
+		return nil
+	}
+
+	var errs []string
+	for name, source := range sources {
+		if source.Available {
+			return nil
+		}
+		errs = append(errs, fmt.Sprintf("%s: %s", name, source.Error))
+	}
+
+	return fmt.Errorf("no pricing sources available: %s", strings.Join(errs, ", "))
+}
+
+// checkClusterMap returns an error if the given cluster map is empty and has
+// not been refreshed within the tolerance.
+func checkClusterMap(clusterMap clusters.ClusterMap, tolerance time.Duration, now time.Time) error {
+	if clusterMap == nil {
+		return fmt.Errorf("no cluster map")
+	}
+
+	if len(clusterMap.GetClusterIDs()) > 0 {
+		return nil
+	}
+
+	lastRefresh := clusterMap.LastRefresh()
+	if lastRefresh.IsZero() {
+		return fmt.Errorf("cluster map is empty and has never been refreshed")
+	}
+	if since := now.Sub(lastRefresh); since > tolerance {
+		return fmt.Errorf("cluster map is empty and was last refreshed %s ago", since.Round(time.Second))
+	}
+
+	return nil
+}
+
+// pricingDownloadStatus records the outcome of the most recent attempt to
+// download pricing data.
+type pricingDownloadStatus struct {
+	lock      sync.RWMutex
+	attempted bool
+	err       error
+}
+
+// set records the outcome of a pricing download
+func (pds *pricingDownloadStatus) set(err error) {
+	pds.lock.Lock()
+	defer pds.lock.Unlock()
+
+	pds.attempted = true
+	pds.err = err
+}
+
+// get returns whether pricing data has been downloaded, and the error of the
+// most recent download, if any
+func (pds *pricingDownloadStatus) get() (bool, error) {
+	pds.lock.RLock()
+	defer pds.lock.RUnlock()
+
+	return pds.attempted, pds.err
+}
+
+// downloadPricingData downloads the cloud provider's pricing data, recording
+// the outcome for the pricing readiness check, and emitting any material
+// changes to it. Concurrent downloads share the outcome of the one in flight.
+func (a *Accesses) downloadPricingData() error {
+	return a.pricingRefresh.do(func() error {
+		err := a.CloudProvider.DownloadPricingData()
+		a.pricingDownload.set(err)
+		if err == nil && a.PricingChanges != nil {
+			a.PricingChanges.Observe(a.CloudProvider)
+		}
+		return err
+	})
+}
+
+// validatePricing logs each problem with the cloud provider's pricing
+// configuration, pricing one of the cluster's nodes if there are any.
+func (a *Accesses) validatePricing() {
+	validator := pricing.NewPricingValidator()
+	if a.Model != nil && a.Model.Cache != nil {
+		if nodes := a.Model.Cache.GetAllNodes(); len(nodes) > 0 {
+			validator.Node = nodes[0]
+		}
+	}
+
+	for _, err := range validator.Validate(a.CloudProvider) {
+		log.Warningf("Invalid pricing configuration: %s", err)
+	}
+}
+
+// readinessChecks returns the checks of each dependency which must be
+// available to serve requests.
+func (a *Accesses) readinessChecks() []*ReadinessCheck {
+	var cache clustercache.ClusterCache
+	if a.Model != nil {
+		cache = a.Model.Cache
+	}
+
+	return []*ReadinessCheck{
+		{
+			Name:  PrometheusReadinessCheck,
+			Check: func() error { return checkPrometheus(a.PrometheusClient) },
+		},
+		{
+			Name:  ClusterCacheReadinessCheck,
+			Check: func() error { return checkClusterCache(cache) },
+		},
+		{
+			Name:  PricingReadinessCheck,
+			Check: func() error { return checkPricing(a.CloudProvider, &a.pricingDownload) },
+		},
+		{
+			Name: ClusterMapReadinessCheck,
+			Check: func() error {
+				return checkClusterMap(a.ClusterMap, env.GetReadinessClusterMapTolerance(), time.Now())
+			},
+		},
+		{
+			Name:  CredentialsReadinessCheck,
+			Check: func() error { return checkCredentials(env.GetCredentialErrors()) },
+		},
+	}
+}
+
+// ReadinessHandler reports whether each dependency is ready to serve
+// requests, responding with 503 Service Unavailable if any are not. Checks
+// listed in READINESS_EXCLUDED_CHECKS are skipped.
+func (a *Accesses) ReadinessHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	report := runReadinessChecks(a.readinessChecks(), env.GetReadinessExcludedChecks(), readinessCheckTimeout)
+
+	code := http.StatusOK
+	if !report.Ready {
+		code = http.StatusServiceUnavailable
+		for _, result := range report.Checks {
+			if result.Status == ReadinessCheckFailed {
+				log.Warningf("Readiness check %s failed: %s", result.Name, result.Error)
+			}
+		}
+	}
+
+	body, err := json.Marshal(report)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	w.WriteHeader(code)
+	w.Write(body)
+}
@@ -0,0 +1,299 @@
+package costmodel
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/clustercache"
+	"github.com/kubecost/cost-model/pkg/costmodel/clusters"
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/util/json"
+
+	prometheusClient "github.com/prometheus/client_golang/api"
+)
+
+type readinessClusterCache struct {
+	clustercache.ClusterCache
+	synced bool
+}
+
+func (c *readinessClusterCache) HasSynced() bool {
+	return c.synced
+}
+
+type readinessClusterMap struct {
+	clusters.ClusterMap
+	ids         []string
+	lastRefresh time.Time
+}
+
+func (cm *readinessClusterMap) GetClusterIDs() []string {
+	return cm.ids
+}
+
+func (cm *readinessClusterMap) LastRefresh() time.Time {
+	return cm.lastRefresh
+}
+
+type readinessProvider struct {
+	cloud.Provider
+	sources map[string]*cloud.PricingSource
+}
+
+func (p *readinessProvider) PricingSourceStatus() map[string]*cloud.PricingSource {
+	return p.sources
+}
+
+// newReadinessTestPrometheus starts a server which answers queries with a
+// single sample, or with 503 Service Unavailable if down is true.
+func newReadinessTestPrometheus(t *testing.T, down bool) (prometheusClient.Client, func()) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1609459200,"1"]}]}}`))
+	}))
+
+	cli, err := prometheusClient.NewClient(prometheusClient.Config{Address: server.URL})
+	if err != nil {
+		server.Close()
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	return cli, server.Close
+}
+
+// newReadinessTestAccesses returns Accesses whose dependencies are all ready.
+func newReadinessTestAccesses(t *testing.T) (*Accesses, func()) {
+	cli, closeFn := newReadinessTestPrometheus(t, false)
+
+	a := &Accesses{
+		PrometheusClient: cli,
+		Model:            &CostModel{Cache: &readinessClusterCache{synced: true}},
+		CloudProvider:    &readinessProvider{},
+		ClusterMap:       &readinessClusterMap{ids: []string{"cluster-one"}, lastRefresh: time.Now()},
+	}
+	a.pricingDownload.set(nil)
+
+	return a, closeFn
+}
+
+func getReadiness(t *testing.T, a *Accesses) (int, *ReadinessReport) {
+	w := httptest.NewRecorder()
+	a.ReadinessHandler(w, httptest.NewRequest(http.MethodGet, "/readyz", nil), nil)
+
+	report := &ReadinessReport{}
+	if err := json.Unmarshal(w.Body.Bytes(), report); err != nil {
+		t.Fatalf("unexpected error decoding readiness: %s", err)
+	}
+
+	return w.Code, report
+}
+
+func TestReadinessHandler(t *testing.T) {
+	cases := map[string]struct {
+		breakDependency func(t *testing.T, a *Accesses) func()
+		failed          string
+	}{
+		"all ready": {
+			breakDependency: func(t *testing.T, a *Accesses) func() { return func() {} },
+		},
+		"prometheus down": {
+			breakDependency: func(t *testing.T, a *Accesses) func() {
+				cli, closeFn := newReadinessTestPrometheus(t, true)
+				a.PrometheusClient = cli
+				return closeFn
+			},
+			failed: PrometheusReadinessCheck,
+		},
+		"cluster cache not synced": {
+			breakDependency: func(t *testing.T, a *Accesses) func() {
+				a.Model.Cache = &readinessClusterCache{synced: false}
+				return func() {}
+			},
+			failed: ClusterCacheReadinessCheck,
+		},
+		"pricing never downloaded": {
+			breakDependency: func(t *testing.T, a *Accesses) func() {
+				a.pricingDownload.attempted = false
+				return func() {}
+			},
+			failed: PricingReadinessCheck,
+		},
+		"pricing download failed": {
+			breakDependency: func(t *testing.T, a *Accesses) func() {
+				a.pricingDownload.set(fmt.Errorf("connection refused"))
+				return func() {}
+			},
+			failed: PricingReadinessCheck,
+		},
+		"no pricing sources available": {
+			breakDependency: func(t *testing.T, a *Accesses) func() {
+				a.CloudProvider = &readinessProvider{sources: map[string]*cloud.PricingSource{
+					cloud.SpotPricingSource: {Name: cloud.SpotPricingSource, Error: "access denied"},
+				}}
+				return func() {}
+			},
+			failed: PricingReadinessCheck,
+		},
+		"cluster map empty and stale": {
+			breakDependency: func(t *testing.T, a *Accesses) func() {
+				a.ClusterMap = &readinessClusterMap{lastRefresh: time.Now().Add(-24 * time.Hour)}
+				return func() {}
+			},
+			failed: ClusterMapReadinessCheck,
+		},
+		"cluster map never refreshed": {
+			breakDependency: func(t *testing.T, a *Accesses) func() {
+				a.ClusterMap = &readinessClusterMap{}
+				return func() {}
+			},
+			failed: ClusterMapReadinessCheck,
+		},
+		"credential file missing": {
+			breakDependency: func(t *testing.T, a *Accesses) func() {
+				os.Setenv(env.DBBearerToken+env.CredentialFileSuffix, "/nonexistent/bearer-token")
+				env.RefreshCredentials()
+				return func() {
+					os.Unsetenv(env.DBBearerToken + env.CredentialFileSuffix)
+					env.RefreshCredentials()
+				}
+			},
+			failed: CredentialsReadinessCheck,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			a, closeFn := newReadinessTestAccesses(t)
+			defer closeFn()
+
+			restore := tc.breakDependency(t, a)
+			defer restore()
+
+			code, report := getReadiness(t, a)
+
+			expectedCode := http.StatusOK
+			if tc.failed != "" {
+				expectedCode = http.StatusServiceUnavailable
+			}
+			if code != expectedCode {
+				t.Errorf("expected status %d; got %d", expectedCode, code)
+			}
+			if report.Ready != (tc.failed == "") {
+				t.Errorf("expected ready %t; got %t", tc.failed == "", report.Ready)
+			}
+
+			if len(report.Checks) != 5 {
+				t.Fatalf("expected 5 checks; got %d", len(report.Checks))
+			}
+			for _, result := range report.Checks {
+				if result.Name == tc.failed {
+					if result.Status != ReadinessCheckFailed || result.Error == "" {
+						t.Errorf("expected %s to fail with an error; got %s %q", result.Name, result.Status, result.Error)
+					}
+				} else if result.Status != ReadinessCheckOK {
+					t.Errorf("expected %s to be ok; got %s %q", result.Name, result.Status, result.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestReadinessHandler_ExcludedChecks(t *testing.T) {
+	os.Setenv(env.ReadinessExcludedChecksEnvVar, "prometheus, clusterMap")
+	defer os.Unsetenv(env.ReadinessExcludedChecksEnvVar)
+
+	a, closeFn := newReadinessTestAccesses(t)
+	defer closeFn()
+
+	cli, closeDown := newReadinessTestPrometheus(t, true)
+	defer closeDown()
+	a.PrometheusClient = cli
+	a.ClusterMap = &readinessClusterMap{}
+
+	code, report := getReadiness(t, a)
+	if code != http.StatusOK {
+		t.Errorf("expected status %d; got %d", http.StatusOK, code)
+	}
+
+	expected := map[string]string{
+		PrometheusReadinessCheck:   ReadinessCheckExcluded,
+		ClusterCacheReadinessCheck: ReadinessCheckOK,
+		PricingReadinessCheck:      ReadinessCheckOK,
+		ClusterMapReadinessCheck:   ReadinessCheckExcluded,
+		CredentialsReadinessCheck:  ReadinessCheckOK,
+	}
+	for _, result := range report.Checks {
+		if result.Status != expected[result.Name] {
+			t.Errorf("expected %s to be %s; got %s", result.Name, expected[result.Name], result.Status)
+		}
+	}
+}
+
+func TestCheckClusterMap(t *testing.T) {
+	now := time.Date(2021, time.January, 1, 12, 0, 0, 0, time.UTC)
+	tolerance := 15 * time.Minute
+
+	cases := map[string]struct {
+		clusterMap clusters.ClusterMap
+		ready      bool
+	}{
+		"non-empty": {
+			clusterMap: &readinessClusterMap{ids: []string{"cluster-one"}},
+			ready:      true,
+		},
+		"empty within tolerance": {
+			clusterMap: &readinessClusterMap{lastRefresh: now.Add(-10 * time.Minute)},
+			ready:      true,
+		},
+		"empty beyond tolerance": {
+			clusterMap: &readinessClusterMap{lastRefresh: now.Add(-20 * time.Minute)},
+			ready:      false,
+		},
+		"nil": {
+			clusterMap: nil,
+			ready:      false,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := checkClusterMap(tc.clusterMap, tolerance, now)
+			if tc.ready && err != nil {
+				t.Errorf("expected ready; got error: %s", err)
+			}
+			if !tc.ready && err == nil {
+				t.Errorf("expected error; got ready")
+			}
+		})
+	}
+}
+
+func TestRunReadinessChecks_Timeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	checks := []*ReadinessCheck{
+		{Name: "slow", Check: func() error { <-block; return nil }},
+		{Name: "fast", Check: func() error { return nil }},
+	}
+
+	report := runReadinessChecks(checks, nil, 10*time.Millisecond)
+	if report.Ready {
+		t.Errorf("expected not ready")
+	}
+	if report.Checks[0].Status != ReadinessCheckFailed {
+		t.Errorf("expected slow check to fail; got %s", report.Checks[0].Status)
+	}
+	if report.Checks[1].Status != ReadinessCheckOK {
+		t.Errorf("expected fast check to be ok; got %s", report.Checks[1].Status)
+	}
+}
@@ -252,6 +252,10 @@ type CostModelMetricsEmitter struct {
 	NetworkRegionEgressRecorder   prometheus.Gauge
 	NetworkInternetEgressRecorder prometheus.Gauge
 
+	// NodePrices are set to the resource prices of each node as they are
+	// recorded, and price the kubecost controller cost estimate metrics
+	NodePrices *metrics.NodePrices
+
 	// Flow Control
 	recordingLock     *sync.Mutex
 	recordingStopping bool
@@ -263,6 +267,8 @@ func NewCostModelMetricsEmitter(promClient promclient.Client, clusterCache clust
 	// init will only actually execute once to register the custom gauges
 	initCostModelMetrics(clusterCache, provider)
 
+	nodePrices := metrics.NewNodePrices()
+
 	// if the metrics pod is not enabled, we want to emit those metrics from this pod.
 	// NOTE: This is not optimal, as we calculate costs based on run times for other containers.
 	// NOTE: The metrics for run times should be emitted separate from cost-model
@@ -272,6 +278,11 @@ func NewCostModelMetricsEmitter(promClient promclient.Client, clusterCache clust
 			EmitNamespaceAnnotations:      env.IsEmitNamespaceAnnotationsMetric(),
 			EmitPodAnnotations:            env.IsEmitPodAnnotationsMetric(),
 			EmitKubeStateMetrics:          env.IsEmitKsmV1Metrics(),
+			EmitIngressMetrics:            env.IsEmitIngressMetrics(),
+			EmitAdmissionWebhookMetrics:   env.IsEmitAdmissionWebhookMetrics(),
+			EmitStorageClassMetrics:       env.IsEmitStorageClassMetrics(),
+			LabelAllowlist:                env.GetNodeLabelAllowlist(),
+			NodePrices:                    nodePrices,
 		})
 	}
 
@@ -296,6 +307,7 @@ func NewCostModelMetricsEmitter(promClient promclient.Client, clusterCache clust
 		NetworkInternetEgressRecorder: networkInternetEgressCostG,
 		ClusterManagementCostRecorder: clusterManagementCostGv,
 		LBCostRecorder:                lbCostGv,
+		NodePrices:                    nodePrices,
 		recordingLock:                 new(sync.Mutex),
 		recordingStopping:             false,
 		recordingStop:                 nil,
@@ -410,6 +422,7 @@ func (cmme *CostModelMetricsEmitter) Start() bool {
 			if err != nil {
 				log.Warningf("Metric emission: error getting Node cost: %s", err)
 			}
+			nodePrices := make(map[string]metrics.NodeResourcePrices, len(nodes))
 			for nodeName, node := range nodes {
 				// Emit costs, guarding against NaN inputs for custom pricing.
 				cpuCost, _ := strconv.ParseFloat(node.VCPUCost, 64)
@@ -462,7 +475,18 @@ func (cmme *CostModelMetricsEmitter) Start() bool {
 				}
 				labelKey := getKeyFromLabelStrings(nodeName, nodeName, nodeType, nodeRegion, node.ProviderID)
 				nodeSeen[labelKey] = true
+
+				nodePrices[nodeName] = metrics.NodeResourcePrices{CPU: cpuCost, RAM: ramCost}
+			}
+
+			// Pods which are not scheduled on a priced node are priced at the
+			// configured prices
+			var defaultNodePrices metrics.NodeResourcePrices
+			if cfg != nil {
+				defaultNodePrices.CPU, _ = strconv.ParseFloat(cfg.CPU, 64)
+				defaultNodePrices.RAM, _ = strconv.ParseFloat(cfg.RAM, 64)
 			}
+			cmme.NodePrices.Set(nodePrices, defaultNodePrices)
 
 			// TODO: Pass CloudProvider into CostModel on instantiation so this isn't so awkward
 			loadBalancers, err := cmme.Model.GetLBCost(cmme.CloudProvider)
@@ -1,7 +1,10 @@
 package costmodel
 
 import (
+	"fmt"
+
 	costAnalyzerCloud "github.com/kubecost/cost-model/pkg/cloud"
+	cloudpricing "github.com/kubecost/cost-model/pkg/cloud/pricing"
 	"github.com/kubecost/cost-model/pkg/env"
 	"github.com/kubecost/cost-model/pkg/log"
 	"github.com/kubecost/cost-model/pkg/prom"
@@ -93,8 +96,23 @@ func GetNetworkUsageData(zr []*prom.QueryResult, rr []*prom.QueryResult, ir []*p
 	return usageData, nil
 }
 
+// GetInternetEgressGB returns the total internet egress, in GB, of all of the
+// given usage data, by which tiered internet egress pricing is applied.
+func GetInternetEgressGB(usageData map[string]*NetworkUsageData) float64 {
+	total := 0.0
+	for _, usage := range usageData {
+		for _, v := range usage.NetworkInternetEgress {
+			total += v.Value
+		}
+	}
+	return total
+}
+
 // GetNetworkCost computes the actual cost for NetworkUsageData based on data provided by the Provider.
-func GetNetworkCost(usage *NetworkUsageData, cloud costAnalyzerCloud.Provider) ([]*util.Vector, error) {
+// If internet egress is priced in tiers, each GB is priced at the effective rate of the total internet
+// egress of the cluster, internetEgressGB, so that the free and discounted tiers are shared across pods
+// rather than applied to each.
+func GetNetworkCost(usage *NetworkUsageData, cloud costAnalyzerCloud.Provider, internetEgressGB float64) ([]*util.Vector, error) {
 	var results []*util.Vector
 
 	pricing, err := cloud.NetworkPricing()
@@ -105,6 +123,14 @@ func GetNetworkCost(usage *NetworkUsageData, cloud costAnalyzerCloud.Provider) (
 	regionCost := pricing.RegionNetworkEgressCost
 	internetCost := pricing.InternetNetworkEgressCost
 
+	if pricing.InternetNetworkEgressTiers != "" {
+		tiers, err := cloudpricing.ParseTieredPricing(pricing.InternetNetworkEgressTiers)
+		if err != nil {
+			return nil, fmt.Errorf("invalid internet egress tiers: %s", err)
+		}
+		internetCost = tiers.EffectiveRate(internetEgressGB)
+	}
+
 	zlen := len(usage.NetworkZoneEgress)
 	rlen := len(usage.NetworkRegionEgress)
 	ilen := len(usage.NetworkInternetEgress)
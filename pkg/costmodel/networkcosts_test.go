@@ -0,0 +1,61 @@
+package costmodel
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/util"
+)
+
+type networkProvider struct {
+	cloud.Provider
+	network *cloud.Network
+}
+
+func (p *networkProvider) NetworkPricing() (*cloud.Network, error) {
+	return p.network, nil
+}
+
+func TestGetNetworkCost_TieredInternetEgress(t *testing.T) {
+	usageData := map[string]*NetworkUsageData{
+		"a": {NetworkInternetEgress: []*util.Vector{{Value: 60, Timestamp: 1}}},
+		"b": {NetworkInternetEgress: []*util.Vector{{Value: 140, Timestamp: 1}}},
+	}
+	total := GetInternetEgressGB(usageData)
+	if total != 200 {
+		t.Fatalf("expected 200GB of internet egress; got %f", total)
+	}
+
+	provider := &networkProvider{network: &cloud.Network{
+		InternetNetworkEgressCost:  0.12,
+		InternetNetworkEgressTiers: "100:0,0:0.1",
+	}}
+
+	// The first 100GB are free, so the cluster's 200GB cost $10, shared
+	// across pods at an effective rate of $0.05/GB
+	for key, expected := range map[string]float64{"a": 3, "b": 7} {
+		costs, err := GetNetworkCost(usageData[key], provider, total)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if len(costs) != 1 || math.Abs(costs[0].Value-expected) > 1e-9 {
+			t.Fatalf("expected cost of %s to be %f; got %v", key, expected, costs)
+		}
+	}
+
+	// Without tiers, the flat rate applies
+	provider.network.InternetNetworkEgressTiers = ""
+	costs, err := GetNetworkCost(usageData["a"], provider, total)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(costs) != 1 || math.Abs(costs[0].Value-7.2) > 1e-9 {
+		t.Fatalf("expected flat cost of 7.2; got %v", costs)
+	}
+
+	provider.network.InternetNetworkEgressTiers = "100:free"
+	if _, err := GetNetworkCost(usageData["a"], provider, total); err == nil {
+		t.Fatalf("expected invalid tiers to be an error")
+	}
+}
@@ -0,0 +1,448 @@
+package costmodel
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util"
+	"github.com/kubecost/cost-model/pkg/util/httputil"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+)
+
+// hoursPerWeek is the number of hours of a NodeSchedule
+const hoursPerWeek = 7 * 24
+
+// scheduleDays are the names of the days of a NodeSchedule spec, indexed by
+// time.Weekday
+var scheduleDays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// NodeSchedule is the weekly schedule of hours in which nodes run, in a
+// timezone. Outside of these hours, the nodes are scaled to zero.
+type NodeSchedule struct {
+	spec     string
+	location *time.Location
+	on       [7][24]bool
+}
+
+// ParseNodeSchedule parses a weekly schedule of the hours in which nodes run,
+// as semicolon-separated entries of days and hours; e.g.
+// "mon-fri 8-18; sat 10-14" runs from 08:00 to 18:00 on weekdays, and from
+// 10:00 to 14:00 on Saturdays. Days are named by their first three letters, as
+// ranges, or "*" for every day. Hours are a range whose end is exclusive, or
+// "*" for the whole day. The hours are in the given location.
+func ParseNodeSchedule(spec string, location *time.Location) (*NodeSchedule, error) {
+	if location == nil {
+		location = time.UTC
+	}
+
+	s := &NodeSchedule{
+		spec:     spec,
+		location: location,
+	}
+
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		fields := strings.Fields(entry)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid schedule entry '%s': expected days and hours, e.g. 'mon-fri 8-18'", entry)
+		}
+
+		days, err := parseScheduleDays(fields[0])
+		if err != nil {
+			return nil, err
+		}
+
+		startHour, endHour, err := parseScheduleHours(fields[1])
+		if err != nil {
+			return nil, err
+		}
+
+		for _, day := range days {
+			for hour := startHour; hour < endHour; hour++ {
+				s.on[day][hour] = true
+			}
+		}
+	}
+
+	if s.OnHoursPerWeek() == 0 {
+		return nil, fmt.Errorf("schedule '%s' has no hours", spec)
+	}
+
+	return s, nil
+}
+
+// parseScheduleDays parses the days of a schedule entry, e.g. "mon-fri"
+func parseScheduleDays(s string) ([]time.Weekday, error) {
+	if s == "*" {
+		return []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}, nil
+	}
+
+	var days []time.Weekday
+	for _, part := range strings.Split(s, ",") {
+		from, to, isRange := strings.Cut(strings.ToLower(part), "-")
+		if !isRange {
+			to = from
+		}
+
+		start, ok := scheduleDay(from)
+		if !ok {
+			return nil, fmt.Errorf("invalid schedule day '%s'", from)
+		}
+		end, ok := scheduleDay(to)
+		if !ok {
+			return nil, fmt.Errorf("invalid schedule day '%s'", to)
+		}
+
+		// Ranges wrap around the week, e.g. "sat-sun"
+		for day := start; ; day = (day + 1) % 7 {
+			days = append(days, day)
+			if day == end {
+				break
+			}
+		}
+	}
+
+	return days, nil
+}
+
+// scheduleDay returns the day named by its first three letters
+func scheduleDay(name string) (time.Weekday, bool) {
+	for i, day := range scheduleDays {
+		if name == day {
+			return time.Weekday(i), true
+		}
+	}
+	return time.Sunday, false
+}
+
+// parseScheduleHours parses the hours of a schedule entry, e.g. "8-18", as a
+// start and exclusive end
+func parseScheduleHours(s string) (int, int, error) {
+	if s == "*" {
+		return 0, 24, nil
+	}
+
+	from, to, ok := strings.Cut(s, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid schedule hours '%s': expected a range, e.g. '8-18'", s)
+	}
+
+	start, err := strconv.Atoi(from)
+	if err != nil || start < 0 || start > 23 {
+		return 0, 0, fmt.Errorf("invalid schedule hour '%s'", from)
+	}
+	end, err := strconv.Atoi(to)
+	if err != nil || end <= start || end > 24 {
+		return 0, 0, fmt.Errorf("invalid schedule hour '%s': must be after %d and at most 24", to, start)
+	}
+
+	return start, end, nil
+}
+
+// String returns the spec from which the schedule was parsed
+func (s *NodeSchedule) String() string {
+	return s.spec
+}
+
+// IsOn returns true if the nodes run at the given time
+func (s *NodeSchedule) IsOn(t time.Time) bool {
+	t = t.In(s.location)
+	return s.on[t.Weekday()][t.Hour()]
+}
+
+// OnHoursPerWeek returns the number of hours per week in which the nodes run
+func (s *NodeSchedule) OnHoursPerWeek() int {
+	hours := 0
+	for _, day := range s.on {
+		for _, on := range day {
+			if on {
+				hours++
+			}
+		}
+	}
+	return hours
+}
+
+// OffDuration returns how much of the interval [start, end) falls outside of
+// the schedule
+func (s *NodeSchedule) OffDuration(start, end time.Time) time.Duration {
+	var off time.Duration
+
+	for t := start; t.Before(end); {
+		// The schedule changes at most on the hour, in its location
+		next := t.In(s.location).Truncate(time.Hour).Add(time.Hour)
+		if next.After(end) {
+			next = end
+		}
+
+		if !s.IsOn(t) {
+			off += next.Sub(t)
+		}
+		t = next
+	}
+
+	return off
+}
+
+// ScheduleSavingsNode is a node which would be run on a schedule
+type ScheduleSavingsNode struct {
+	Node       string  `json:"node"`
+	Pool       string  `json:"pool"`
+	HourlyCost float64 `json:"hourlyCost"`
+}
+
+// DisruptedWorkload is a workload which ran on the nodes outside of the
+// schedule, so would be disrupted by scaling the nodes to zero
+type DisruptedWorkload struct {
+	Namespace      string   `json:"namespace"`
+	ControllerKind string   `json:"controllerKind,omitempty"`
+	Controller     string   `json:"controller,omitempty"`
+	Pods           []string `json:"pods"`
+	// OffHours is the number of pod-hours the workload ran outside of the
+	// schedule, and OffHoursCost their cost
+	OffHours     float64 `json:"offHours"`
+	OffHoursCost float64 `json:"offHoursCost"`
+}
+
+// ScheduleSavings estimates the savings of running the nodes of pools on a
+// schedule rather than always, and lists the workloads which would be
+// disrupted because they ran outside of the schedule.
+type ScheduleSavings struct {
+	Schedule             string                 `json:"schedule"`
+	Timezone             string                 `json:"timezone"`
+	Pools                []string               `json:"pools"`
+	OnHoursPerWeek       int                    `json:"onHoursPerWeek"`
+	Nodes                []*ScheduleSavingsNode `json:"nodes"`
+	AlwaysOnMonthlyCost  float64                `json:"alwaysOnMonthlyCost"`
+	ScheduledMonthlyCost float64                `json:"scheduledMonthlyCost"`
+	MonthlySavings       float64                `json:"monthlySavings"`
+	DisruptedWorkloads   []*DisruptedWorkload   `json:"disruptedWorkloads"`
+}
+
+// EstimateScheduleSavings estimates the savings of running the given nodes on
+// the schedule, and finds the workloads of the allocations which ran on the
+// nodes outside of the schedule. Daemonsets, which run on every node, are not
+// disrupted by removing nodes, so are not listed.
+func EstimateScheduleSavings(schedule *NodeSchedule, pools []string, nodes []*ScheduleSavingsNode, asr *kubecost.AllocationSetRange) *ScheduleSavings {
+	onFraction := float64(schedule.OnHoursPerWeek()) / hoursPerWeek
+
+	savings := &ScheduleSavings{
+		Schedule:           schedule.String(),
+		Timezone:           schedule.location.String(),
+		Pools:              pools,
+		OnHoursPerWeek:     schedule.OnHoursPerWeek(),
+		Nodes:              nodes,
+		DisruptedWorkloads: []*DisruptedWorkload{},
+	}
+
+	scheduled := map[string]bool{}
+	for _, node := range nodes {
+		scheduled[node.Node] = true
+		savings.AlwaysOnMonthlyCost += node.HourlyCost * timeutil.HoursPerMonth
+	}
+	savings.ScheduledMonthlyCost = savings.AlwaysOnMonthlyCost * onFraction
+	savings.MonthlySavings = savings.AlwaysOnMonthlyCost - savings.ScheduledMonthlyCost
+
+	workloads := map[string]*DisruptedWorkload{}
+	pods := map[string]map[string]bool{}
+
+	if asr != nil {
+		asr.Each(func(_ int, as *kubecost.AllocationSet) {
+			as.Each(func(_ string, alloc *kubecost.Allocation) {
+				if alloc.IsIdle() || alloc.IsUnmounted() || alloc.IsExternal() || alloc.IsUnallocated() {
+					return
+				}
+				props := alloc.Properties
+				if props == nil || !scheduled[props.Node] || props.ControllerKind == "daemonset" {
+					return
+				}
+
+				off := schedule.OffDuration(alloc.Start, alloc.End)
+				if off <= 0 {
+					return
+				}
+
+				// Pods without a controller are their own workload
+				controllerKind, controller := props.ControllerKind, props.Controller
+				if controller == "" {
+					controllerKind, controller = "pod", props.Pod
+				}
+
+				key := props.Namespace + "/" + controllerKind + "/" + controller
+				workload, ok := workloads[key]
+				if !ok {
+					workload = &DisruptedWorkload{
+						Namespace:      props.Namespace,
+						ControllerKind: controllerKind,
+						Controller:     controller,
+					}
+					workloads[key] = workload
+					pods[key] = map[string]bool{}
+				}
+
+				if !pods[key][props.Pod] {
+					pods[key][props.Pod] = true
+					workload.Pods = append(workload.Pods, props.Pod)
+				}
+
+				workload.OffHours += off.Hours()
+				if duration := alloc.End.Sub(alloc.Start); duration > 0 {
+					workload.OffHoursCost += alloc.TotalCost() * float64(off) / float64(duration)
+				}
+			})
+		})
+	}
+
+	for _, workload := range workloads {
+		sort.Strings(workload.Pods)
+		savings.DisruptedWorkloads = append(savings.DisruptedWorkloads, workload)
+	}
+
+	sort.Slice(savings.DisruptedWorkloads, func(i, j int) bool {
+		wi, wj := savings.DisruptedWorkloads[i], savings.DisruptedWorkloads[j]
+		if wi.OffHours != wj.OffHours {
+			return wi.OffHours > wj.OffHours
+		}
+		return wi.Namespace+"/"+wi.Controller < wj.Namespace+"/"+wj.Controller
+	})
+
+	return savings
+}
+
+// scheduleSavingsNodes returns the cluster's current nodes in the given pools,
+// as named by util.GetNodePool, priced by the provider.
+func (cm *CostModel) scheduleSavingsNodes(cp cloud.Provider, pools []string) ([]*ScheduleSavingsNode, error) {
+	pricing, err := cm.GetNodeCost(cp)
+	if err != nil {
+		return nil, err
+	}
+
+	inPools := map[string]bool{}
+	for _, pool := range pools {
+		inPools[pool] = true
+	}
+
+	var nodes []*ScheduleSavingsNode
+	for _, n := range cm.Cache.GetAllNodes() {
+		pool, ok := util.GetNodePool(n.GetLabels())
+		if !ok || !inPools[pool] {
+			continue
+		}
+
+		node, ok := pricing[n.GetName()]
+		if !ok {
+			continue
+		}
+
+		nodes = append(nodes, &ScheduleSavingsNode{
+			Node:       n.GetName(),
+			Pool:       pool,
+			HourlyCost: clusterSizingNodeType(node.InstanceType, node, 0).HourlyCost,
+		})
+	}
+
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Node < nodes[j].Node
+	})
+
+	return nodes, nil
+}
+
+// ComputeScheduleSavings estimates the savings of running the nodes of the
+// given pools on the schedule, finding the workloads which would be disrupted
+// from the allocations of the window, computed in steps of the given duration.
+func (cm *CostModel) ComputeScheduleSavings(cp cloud.Provider, schedule *NodeSchedule, pools []string, window kubecost.Window, step, resolution time.Duration) (*ScheduleSavings, error) {
+	nodes, err := cm.scheduleSavingsNodes(cp, pools)
+	if err != nil {
+		return nil, err
+	}
+
+	asr := kubecost.NewAllocationSetRange()
+	for start := *window.Start(); window.End().After(start); start = start.Add(step) {
+		end := start.Add(step)
+		if end.After(*window.End()) {
+			end = *window.End()
+		}
+
+		as, err := cm.ComputeAllocation(start, end, resolution)
+		if err != nil {
+			return nil, err
+		}
+		asr.Append(as)
+	}
+
+	return EstimateScheduleSavings(schedule, pools, nodes, asr), nil
+}
+
+// ScheduleSavingsHandler estimates the savings of scaling the nodes of the
+// given pools to zero outside of a weekly schedule, and lists the workloads
+// which ran outside of the schedule over the window, so would be disrupted;
+// e.g. pools=dev&schedule=mon-fri 8-18&timezone=America/New_York
+func (a *Accesses) ScheduleSavingsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	qp := httputil.NewQueryParams(r.URL.Query())
+
+	conversion, err := a.currencyConversion(qp)
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'currency' parameter: %s", err)))
+		return
+	}
+
+	pools := qp.GetList("pools", ",")
+	if len(pools) == 0 {
+		WriteError(w, BadRequest("Missing 'pools' parameter"))
+		return
+	}
+
+	location, err := time.LoadLocation(qp.Get("timezone", "UTC"))
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'timezone' parameter: %s", err)))
+		return
+	}
+
+	schedule, err := ParseNodeSchedule(qp.Get("schedule", "mon-fri 8-18"), location)
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'schedule' parameter: %s", err)))
+		return
+	}
+
+	window, err := kubecost.ParseWindowWithOffset(qp.Get("window", "7d"), env.GetParsedUTCOffset())
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'window' parameter: %s", err)))
+		return
+	}
+	if window.IsOpen() || window.IsNegative() {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'window' parameter: %s", window)))
+		return
+	}
+
+	step := qp.GetDuration("step", 24*time.Hour)
+	if step <= 0 {
+		WriteError(w, BadRequest("Parameter 'step' must be positive"))
+		return
+	}
+
+	resolution := qp.GetDuration("resolution", env.GetETLResolution())
+
+	savings, err := a.Model.ComputeScheduleSavings(a.CloudProvider, schedule, pools, window, step, resolution)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	writeWithCurrency(w, savings, conversion)
+}
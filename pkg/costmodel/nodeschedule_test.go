@@ -0,0 +1,161 @@
+package costmodel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util"
+)
+
+func newScheduleTestAlloc(start, end time.Time, node, namespace, controllerKind, controller, pod string, cost float64) *kubecost.Allocation {
+	return &kubecost.Allocation{
+		Name: node + "/" + namespace + "/" + pod,
+		Properties: &kubecost.AllocationProperties{
+			Node:           node,
+			Namespace:      namespace,
+			ControllerKind: controllerKind,
+			Controller:     controller,
+			Pod:            pod,
+		},
+		Window:  kubecost.NewWindow(&start, &end),
+		Start:   start,
+		End:     end,
+		CPUCost: cost,
+	}
+}
+
+func TestParseNodeSchedule(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no timezone data: %s", err)
+	}
+
+	s, err := ParseNodeSchedule("mon-fri 8-18; sat 10-14", newYork)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if s.OnHoursPerWeek() != 54 {
+		t.Fatalf("expected 54 hours per week; got %d", s.OnHoursPerWeek())
+	}
+
+	// Monday 2021-01-04 09:00 in New York is 14:00 UTC
+	if !s.IsOn(time.Date(2021, time.January, 4, 14, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the schedule to be on at 09:00 Monday in New York")
+	}
+	if s.IsOn(time.Date(2021, time.January, 4, 9, 0, 0, 0, time.UTC)) {
+		t.Fatalf("expected the schedule to be off at 04:00 Monday in New York")
+	}
+
+	// Ranges of days wrap around the week
+	s, err = ParseNodeSchedule("sat-sun *", time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if s.OnHoursPerWeek() != 48 {
+		t.Fatalf("expected 48 hours per week; got %d", s.OnHoursPerWeek())
+	}
+
+	for _, spec := range []string{"", "mon-fri", "mon-fri 18-8", "mon-fri 8-25", "someday 8-18", "mon-fri eight-18"} {
+		if _, err := ParseNodeSchedule(spec, time.UTC); err == nil {
+			t.Fatalf("expected an error parsing '%s'", spec)
+		}
+	}
+}
+
+func TestNodeScheduleOffDuration(t *testing.T) {
+	s, err := ParseNodeSchedule("* 8-18", time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	day := time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		start, end time.Time
+		expected   time.Duration
+	}{
+		{day, day.Add(24 * time.Hour), 14 * time.Hour},
+		{day.Add(9 * time.Hour), day.Add(17 * time.Hour), 0},
+		{day.Add(17*time.Hour + 30*time.Minute), day.Add(19*time.Hour + 15*time.Minute), 75 * time.Minute},
+		{day.Add(time.Hour), day.Add(3 * time.Hour), 2 * time.Hour},
+	}
+
+	for _, c := range cases {
+		if off := s.OffDuration(c.start, c.end); off != c.expected {
+			t.Fatalf("expected %s off from %s to %s; got %s", c.expected, c.start, c.end, off)
+		}
+	}
+}
+
+func TestEstimateScheduleSavings(t *testing.T) {
+	schedule, err := ParseNodeSchedule("mon-fri 8-18", time.UTC)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// Monday 2021-01-04
+	day := time.Date(2021, time.January, 4, 0, 0, 0, 0, time.UTC)
+	at := func(hour int) time.Time { return day.Add(time.Duration(hour) * time.Hour) }
+
+	nodes := []*ScheduleSavingsNode{
+		{Node: "dev-1", Pool: "dev", HourlyCost: 1.0},
+		{Node: "dev-2", Pool: "dev", HourlyCost: 1.0},
+		{Node: "batch-1", Pool: "batch", HourlyCost: 2.0},
+	}
+
+	as := kubecost.NewAllocationSet(day, at(24),
+		// The dev pool only runs workloads during the day
+		newScheduleTestAlloc(at(9), at(17), "dev-1", "web", "deployment", "frontend", "frontend-abcde", 8.0),
+		newScheduleTestAlloc(at(10), at(12), "dev-2", "web", "deployment", "frontend", "frontend-fghij", 2.0),
+		// Daemonsets run on every node, at all hours
+		newScheduleTestAlloc(day, at(24), "dev-1", "monitoring", "daemonset", "node-exporter", "node-exporter-abcde", 2.0),
+		// The batch pool runs a nightly job from 01:00 to 03:00, and a
+		// report from 17:00 to 19:00
+		newScheduleTestAlloc(at(1), at(3), "batch-1", "etl", "job", "nightly", "nightly-abcde", 9.0),
+		newScheduleTestAlloc(at(17), at(19), "batch-1", "etl", "", "", "report", 7.0),
+		// Nodes not in the pools are not considered
+		newScheduleTestAlloc(at(1), at(3), "prod-1", "web", "deployment", "api", "api-abcde", 2.0),
+	)
+
+	asr := kubecost.NewAllocationSetRange(as)
+
+	dev := EstimateScheduleSavings(schedule, []string{"dev"}, nodes[:2], asr)
+
+	// 2 nodes at 1.0/hr, run for 50 of 168 hours per week
+	if !util.IsApproximately(dev.AlwaysOnMonthlyCost, 1460.0) {
+		t.Fatalf("expected an always-on monthly cost of 1460.0; got %f", dev.AlwaysOnMonthlyCost)
+	}
+	if !util.IsApproximately(dev.ScheduledMonthlyCost, 1460.0*50.0/168.0) {
+		t.Fatalf("expected a scheduled monthly cost of %f; got %f", 1460.0*50.0/168.0, dev.ScheduledMonthlyCost)
+	}
+	if !util.IsApproximately(dev.MonthlySavings, dev.AlwaysOnMonthlyCost-dev.ScheduledMonthlyCost) {
+		t.Fatalf("expected savings of the difference in cost; got %f", dev.MonthlySavings)
+	}
+	if len(dev.DisruptedWorkloads) != 0 {
+		t.Fatalf("expected no disrupted workloads in the dev pool; got %d", len(dev.DisruptedWorkloads))
+	}
+
+	batch := EstimateScheduleSavings(schedule, []string{"batch"}, nodes[2:], asr)
+	if len(batch.DisruptedWorkloads) != 2 {
+		t.Fatalf("expected 2 disrupted workloads in the batch pool; got %d", len(batch.DisruptedWorkloads))
+	}
+
+	nightly := batch.DisruptedWorkloads[0]
+	if nightly.ControllerKind != "job" || nightly.Controller != "nightly" || len(nightly.Pods) != 1 {
+		t.Fatalf("expected the nightly job to be disrupted most; got %+v", nightly)
+	}
+	// The job runs entirely outside of the schedule
+	if !util.IsApproximately(nightly.OffHours, 2.0) || !util.IsApproximately(nightly.OffHoursCost, 9.0) {
+		t.Fatalf("expected the nightly job to run 2.0 hours costing 9.0 off schedule; got %f costing %f", nightly.OffHours, nightly.OffHoursCost)
+	}
+
+	report := batch.DisruptedWorkloads[1]
+	if report.ControllerKind != "pod" || report.Controller != "report" {
+		t.Fatalf("expected the bare report pod to be its own workload; got %+v", report)
+	}
+	// Half of the report runs after 18:00
+	if !util.IsApproximately(report.OffHours, 1.0) || !util.IsApproximately(report.OffHoursCost, 3.5) {
+		t.Fatalf("expected the report to run 1.0 hours costing 3.5 off schedule; got %f costing %f", report.OffHours, report.OffHoursCost)
+	}
+}
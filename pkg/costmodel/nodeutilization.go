@@ -0,0 +1,238 @@
+package costmodel
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util/httputil"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+)
+
+// NodeUtilizationOptions configures which workloads are classified as system
+// workloads when breaking down node costs.
+type NodeUtilizationOptions struct {
+	// SystemNamespaces are the namespaces whose pods are system workloads.
+	SystemNamespaces []string
+
+	// DaemonSetsAsSystem, if true, classifies all daemonset pods as system
+	// workloads, regardless of namespace.
+	DaemonSetsAsSystem bool
+}
+
+// DefaultNodeUtilizationOptions returns NodeUtilizationOptions with default
+// values set.
+func DefaultNodeUtilizationOptions() *NodeUtilizationOptions {
+	return &NodeUtilizationOptions{
+		SystemNamespaces:   []string{"kube-system"},
+		DaemonSetsAsSystem: true,
+	}
+}
+
+// isSystem returns true if the allocation with the given properties is a
+// system workload.
+func (opts *NodeUtilizationOptions) isSystem(props *kubecost.AllocationProperties) bool {
+	if opts.DaemonSetsAsSystem && props.ControllerKind == "daemonset" {
+		return true
+	}
+
+	for _, ns := range opts.SystemNamespaces {
+		if props.Namespace == ns {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NodeUtilization is the cost of a node over a window, broken down into the
+// cost allocated to workloads, the cost allocated to system workloads, and the
+// cost of the node's idle capacity.
+type NodeUtilization struct {
+	Node         string  `json:"node"`
+	InstanceType string  `json:"instanceType"`
+	Region       string  `json:"region"`
+	Spot         bool    `json:"spot"`
+	CPUCores     float64 `json:"cpuCores"`
+	RAMBytes     float64 `json:"ramBytes"`
+	GPUs         float64 `json:"gpus"`
+	Minutes      float64 `json:"minutes"`
+	HourlyCost   float64 `json:"hourlyCost"`
+	TotalCost    float64 `json:"totalCost"`
+	WorkloadCost float64 `json:"workloadCost"`
+	SystemCost   float64 `json:"systemCost"`
+	IdleCost     float64 `json:"idleCost"`
+}
+
+// newNodeUtilization creates a NodeUtilization for a node priced by the
+// provider, which ran for the given number of minutes.
+func newNodeUtilization(name string, node *cloud.Node, minutes float64) *NodeUtilization {
+	nt := clusterSizingNodeType(node.InstanceType, node, 0)
+
+	return &NodeUtilization{
+		Node:         name,
+		InstanceType: node.InstanceType,
+		Region:       node.Region,
+		Spot:         node.IsSpot(),
+		CPUCores:     nt.CPUCores,
+		RAMBytes:     nt.RAMBytes,
+		GPUs:         nt.GPUs,
+		Minutes:      minutes,
+		HourlyCost:   nt.HourlyCost,
+		TotalCost:    nt.HourlyCost * minutes / timeutil.MinsPerHour,
+	}
+}
+
+// ComputeNodeUtilization breaks down the cost of each of the given nodes into
+// workload, system, and idle costs, using the CPU, GPU, and RAM costs of the
+// allocations in the given AllocationSet which ran on each node. The idle cost
+// is the remainder of the node's cost, so that the breakdown always sums to the
+// node's total cost. If a node's allocated costs exceed its total cost, then
+// the workload and system costs are scaled down proportionally and the idle
+// cost is zero. Allocations on nodes which are not given are ignored.
+func ComputeNodeUtilization(as *kubecost.AllocationSet, nodes map[string]*NodeUtilization, opts *NodeUtilizationOptions) []*NodeUtilization {
+	if opts == nil {
+		opts = DefaultNodeUtilizationOptions()
+	}
+
+	as.Each(func(name string, alloc *kubecost.Allocation) {
+		if alloc.IsIdle() || alloc.IsUnmounted() || alloc.IsExternal() || alloc.IsUnallocated() {
+			return
+		}
+		if alloc.Properties == nil {
+			return
+		}
+
+		nu, ok := nodes[alloc.Properties.Node]
+		if !ok {
+			return
+		}
+
+		cost := alloc.CPUTotalCost() + alloc.GPUTotalCost() + alloc.RAMTotalCost()
+		if opts.isSystem(alloc.Properties) {
+			nu.SystemCost += cost
+		} else {
+			nu.WorkloadCost += cost
+		}
+	})
+
+	result := make([]*NodeUtilization, 0, len(nodes))
+	for _, nu := range nodes {
+		allocated := nu.WorkloadCost + nu.SystemCost
+		if allocated > nu.TotalCost {
+			if allocated > 0 {
+				nu.WorkloadCost *= nu.TotalCost / allocated
+				nu.SystemCost *= nu.TotalCost / allocated
+			}
+			nu.IdleCost = 0.0
+		} else {
+			nu.IdleCost = nu.TotalCost - allocated
+		}
+
+		result = append(result, nu)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalCost != result[j].TotalCost {
+			return result[i].TotalCost > result[j].TotalCost
+		}
+		return result[i].Node < result[j].Node
+	})
+
+	return result
+}
+
+// ComputeNodeUtilization computes the cost breakdown of each of the cluster's
+// current nodes over the given window. Each node is priced by the provider and
+// charged from the later of the start of the window and the node's creation.
+func (cm *CostModel) ComputeNodeUtilization(cp cloud.Provider, window kubecost.Window, resolution time.Duration, opts *NodeUtilizationOptions) ([]*NodeUtilization, error) {
+	start, end := *window.Start(), *window.End()
+
+	as, err := cm.ComputeAllocation(start, end, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := cm.pricedNodeUtilizations(cp, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	return ComputeNodeUtilization(as, nodes, opts), nil
+}
+
+// pricedNodeUtilizations returns an empty NodeUtilization for each of the
+// cluster's current nodes, priced by the provider over the given window.
+func (cm *CostModel) pricedNodeUtilizations(cp cloud.Provider, start, end time.Time) (map[string]*NodeUtilization, error) {
+	pricing, err := cm.GetNodeCost(cp)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := map[string]*NodeUtilization{}
+	for _, n := range cm.Cache.GetAllNodes() {
+		node, ok := pricing[n.GetName()]
+		if !ok {
+			continue
+		}
+
+		nodeStart := start
+		if created := n.GetCreationTimestamp().Time; created.After(nodeStart) {
+			nodeStart = created
+		}
+		if !end.After(nodeStart) {
+			continue
+		}
+
+		nodes[n.GetName()] = newNodeUtilization(n.GetName(), node, end.Sub(nodeStart).Minutes())
+	}
+
+	return nodes, nil
+}
+
+// NodeUtilizationHandler returns the cost of each node over the given window,
+// broken down into workload, system, and idle costs.
+func (a *Accesses) NodeUtilizationHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	qp := httputil.NewQueryParams(r.URL.Query())
+
+	conversion, err := a.currencyConversion(qp)
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'currency' parameter: %s", err)))
+		return
+	}
+
+	window, err := kubecost.ParseWindowWithOffset(qp.Get("window", "1d"), env.GetParsedUTCOffset())
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'window' parameter: %s", err)))
+		return
+	}
+	if window.IsOpen() || window.IsNegative() {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'window' parameter: %s", window)))
+		return
+	}
+
+	opts := DefaultNodeUtilizationOptions()
+	opts.SystemNamespaces = qp.GetList("systemNamespaces", ",")
+	if len(opts.SystemNamespaces) == 0 {
+		opts.SystemNamespaces = DefaultNodeUtilizationOptions().SystemNamespaces
+	}
+	opts.DaemonSetsAsSystem = qp.GetBool("daemonSetsAsSystem", opts.DaemonSetsAsSystem)
+
+	resolution := qp.GetDuration("resolution", env.GetETLResolution())
+
+	nodes, err := a.Model.ComputeNodeUtilization(a.CloudProvider, window, resolution, opts)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	writeWithCurrency(w, nodes, conversion)
+}
@@ -0,0 +1,107 @@
+package costmodel
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util"
+)
+
+func newNodeUtilizationTestAlloc(start, end time.Time, node, namespace, controllerKind, pod string, cpuCost, ramCost float64) *kubecost.Allocation {
+	return &kubecost.Allocation{
+		Name: node + "/" + namespace + "/" + pod,
+		Properties: &kubecost.AllocationProperties{
+			Node:           node,
+			Namespace:      namespace,
+			ControllerKind: controllerKind,
+			Pod:            pod,
+		},
+		Window:      kubecost.NewWindow(&start, &end),
+		Start:       start,
+		End:         end,
+		CPUCost:     cpuCost,
+		RAMCost:     ramCost,
+		NetworkCost: 5.0,
+	}
+}
+
+func TestComputeNodeUtilization(t *testing.T) {
+	start := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	as := kubecost.NewAllocationSet(start, end,
+		newNodeUtilizationTestAlloc(start, end, "node1", "monitoring", "daemonset", "node-exporter-abcde", 2.0, 1.0),
+		newNodeUtilizationTestAlloc(start, end, "node1", "web", "deployment", "frontend-abcde", 8.0, 4.0),
+		newNodeUtilizationTestAlloc(start, end, "node2", "kube-system", "deployment", "coredns-abcde", 1.0, 1.0),
+		newNodeUtilizationTestAlloc(start, end, "node2", "web", "deployment", "frontend-fghij", 1.0, 1.0),
+		newNodeUtilizationTestAlloc(start, end, "node3", "web", "deployment", "frontend-klmno", 1.0, 1.0),
+	)
+
+	nodes := map[string]*NodeUtilization{
+		"node1": newNodeUtilization("node1", &cloud.Node{Cost: "1.0", VCPU: "4", RAMBytes: "17179869184", InstanceType: "m5.xlarge", UsageType: "spot"}, 24*60),
+		// node2 was created halfway through the window, and is over-allocated
+		"node2": newNodeUtilization("node2", &cloud.Node{Cost: "0.25", VCPU: "2", RAMBytes: "8589934592", InstanceType: "m5.large"}, 12*60),
+	}
+
+	result := ComputeNodeUtilization(as, nodes, DefaultNodeUtilizationOptions())
+	if len(result) != 2 {
+		t.Fatalf("ComputeNodeUtilization: expected 2 nodes; actual %d", len(result))
+	}
+
+	// node1 costs 1.0*24 = 24.0, of which the daemonset is system (3.0), the
+	// deployment is a workload (12.0), and the rest is idle (9.0). Network
+	// costs are not node costs.
+	node1 := result[0]
+	if node1.Node != "node1" || !node1.Spot || node1.InstanceType != "m5.xlarge" || node1.CPUCores != 4.0 {
+		t.Fatalf("ComputeNodeUtilization: unexpected node %+v", node1)
+	}
+	cases := map[string][2]float64{
+		"totalCost":    {24.0, node1.TotalCost},
+		"systemCost":   {3.0, node1.SystemCost},
+		"workloadCost": {12.0, node1.WorkloadCost},
+		"idleCost":     {9.0, node1.IdleCost},
+	}
+	for field, c := range cases {
+		if !util.IsApproximately(c[0], c[1]) {
+			t.Fatalf("ComputeNodeUtilization: expected node1 %s %f; actual %f", field, c[0], c[1])
+		}
+	}
+
+	// node2 costs 0.25*12 = 3.0, but 4.0 is allocated, split evenly between
+	// system (kube-system) and workload, so both are scaled down to 1.5.
+	node2 := result[1]
+	if node2.Node != "node2" || node2.Spot {
+		t.Fatalf("ComputeNodeUtilization: unexpected node %+v", node2)
+	}
+	cases = map[string][2]float64{
+		"totalCost":    {3.0, node2.TotalCost},
+		"systemCost":   {1.5, node2.SystemCost},
+		"workloadCost": {1.5, node2.WorkloadCost},
+		"idleCost":     {0.0, node2.IdleCost},
+	}
+	for field, c := range cases {
+		if !util.IsApproximately(c[0], c[1]) {
+			t.Fatalf("ComputeNodeUtilization: expected node2 %s %f; actual %f", field, c[0], c[1])
+		}
+	}
+
+	// Breakdowns sum to the node's total cost
+	for _, nu := range result {
+		if !util.IsApproximately(nu.TotalCost, nu.WorkloadCost+nu.SystemCost+nu.IdleCost) {
+			t.Fatalf("ComputeNodeUtilization: expected %s breakdown to sum to %f", nu.Node, nu.TotalCost)
+		}
+	}
+}
+
+func TestNodeUtilizationOptionsIsSystem(t *testing.T) {
+	opts := &NodeUtilizationOptions{SystemNamespaces: []string{"kube-system"}, DaemonSetsAsSystem: false}
+
+	if opts.isSystem(&kubecost.AllocationProperties{Namespace: "monitoring", ControllerKind: "daemonset"}) {
+		t.Fatalf("isSystem: expected daemonset outside of system namespaces not to be system")
+	}
+	if !opts.isSystem(&kubecost.AllocationProperties{Namespace: "kube-system", ControllerKind: "deployment"}) {
+		t.Fatalf("isSystem: expected kube-system deployment to be system")
+	}
+}
@@ -0,0 +1,131 @@
+package costmodel
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/cloud/pricing"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+// pricingChangeWebhookTimeout is the timeout of posting pricing changes to the
+// webhook
+const pricingChangeWebhookTimeout = 10 * time.Second
+
+// pricingChangesTotal counts the pricing changes detected, by type
+var pricingChangesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubecost_pricing_changes_total",
+	Help: "kubecost_pricing_changes_total Number of node types whose hourly cost changed by more than the threshold between downloads of pricing data, by type",
+}, []string{"type"})
+
+// pricingChangesMetricInit registers the pricing changes counter once
+var pricingChangesMetricInit sync.Once
+
+// PricingChangeEvent is emitted when the pricing data of the provider changes
+// materially between downloads
+type PricingChangeEvent struct {
+	Provider         string                  `json:"provider"`
+	Time             time.Time               `json:"time"`
+	ThresholdPercent float64                 `json:"thresholdPercent"`
+	Changes          []pricing.PricingChange `json:"changes"`
+}
+
+// Message returns a human readable summary of the event
+func (pce *PricingChangeEvent) Message() string {
+	return fmt.Sprintf("Pricing data of %s changed by more than %.2f%% for %d node types", pce.Provider, pce.ThresholdPercent, len(pce.Changes))
+}
+
+// PricingChangeNotifier detects material changes to the pricing data of the
+// provider each time it is downloaded, and emits each as a log entry, an
+// increment of kubecost_pricing_changes_total, and, if a webhook URL is set,
+// a POST of the event to the webhook.
+type PricingChangeNotifier struct {
+	Detector   *pricing.PricingChangeDetector
+	WebhookURL string
+	Client     *http.Client
+}
+
+// NewPricingChangeNotifier creates a PricingChangeNotifier which reports
+// changes of more than the given percentage, and registers the
+// kubecost_pricing_changes_total metric.
+func NewPricingChangeNotifier(thresholdPercent float64, webhookURL string) *PricingChangeNotifier {
+	pricingChangesMetricInit.Do(func() {
+		prometheus.MustRegister(pricingChangesTotal)
+	})
+
+	return &PricingChangeNotifier{
+		Detector:   pricing.NewPricingChangeDetector(thresholdPercent),
+		WebhookURL: webhookURL,
+		Client:     &http.Client{Timeout: pricingChangeWebhookTimeout},
+	}
+}
+
+// Observe compares the provider's pricing data with that of the previous
+// observation, emitting an event if it changed materially. It returns the
+// event, or nil if there were no changes.
+func (pcn *PricingChangeNotifier) Observe(provider cloud.Provider) *PricingChangeEvent {
+	changes, err := pcn.Detector.Observe(provider)
+	if err != nil {
+		log.Warningf("Failed to detect pricing changes: %s", err)
+		return nil
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+
+	event := &PricingChangeEvent{
+		Provider:         pricingProviderName(provider),
+		Time:             time.Now().UTC(),
+		ThresholdPercent: pcn.Detector.ThresholdPercent,
+		Changes:          changes,
+	}
+
+	log.WithFields(log.Fields{"provider": event.Provider, "changes": len(changes)}).Infof("%s", event.Message())
+	for _, change := range changes {
+		log.Infof("Pricing change: %s", change)
+		pricingChangesTotal.WithLabelValues(change.Type).Inc()
+	}
+
+	if pcn.WebhookURL != "" {
+		if err := pcn.post(event); err != nil {
+			log.Warningf("Failed to post pricing changes to webhook: %s", err)
+		}
+	}
+
+	return event
+}
+
+// post posts the event, with its message, as JSON to the webhook
+func (pcn *PricingChangeNotifier) post(event *PricingChangeEvent) error {
+	payload := struct {
+		*PricingChangeEvent
+		Message string `json:"message"`
+	}{
+		PricingChangeEvent: event,
+		Message:            event.Message(),
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := pcn.Client.Post(pcn.WebhookURL, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status: %s", resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package costmodel
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/cloud/pricing"
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+func TestPricingChangeNotifier(t *testing.T) {
+	var posted []*PricingChangeEvent
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("failed to read webhook body: %s", err)
+		}
+		event := &PricingChangeEvent{}
+		if err := json.Unmarshal(body, event); err != nil {
+			t.Errorf("failed to decode webhook body: %s", err)
+		}
+		posted = append(posted, event)
+	}))
+	defer server.Close()
+
+	cp := &cloud.CustomProvider{Pricing: map[string]*cloud.NodePrice{
+		"default": {CPU: "0.04", RAM: "0.004"},
+	}}
+
+	notifier := NewPricingChangeNotifier(pricing.DefaultPricingChangeThreshold, server.URL)
+	increases := pricingChangesTotal.WithLabelValues(pricing.PricingChangeIncrease)
+	before := testutil.ToFloat64(increases)
+
+	if event := notifier.Observe(cp); event != nil {
+		t.Fatalf("expected no event for the first download; got %+v", event)
+	}
+
+	// A 1% bump does not cross the threshold
+	cp.Pricing["default"].CPU = "0.0404"
+	if event := notifier.Observe(cp); event != nil {
+		t.Fatalf("expected no event below the threshold; got %+v", event)
+	}
+
+	// A 25% bump does
+	cp.Pricing["default"].CPU = "0.0505"
+	event := notifier.Observe(cp)
+	if event == nil || event.Provider != "custom" || len(event.Changes) != 1 || event.Changes[0].NodeType != "default/cpu" {
+		t.Fatalf("expected an event for the CPU price; got %+v", event)
+	}
+
+	if len(posted) != 1 || len(posted[0].Changes) != 1 || posted[0].Changes[0].Type != pricing.PricingChangeIncrease {
+		t.Fatalf("expected the event to be posted to the webhook once; got %+v", posted)
+	}
+	if delta := testutil.ToFloat64(increases) - before; delta != 1 {
+		t.Fatalf("expected 1 increase to be counted; got %f", delta)
+	}
+}
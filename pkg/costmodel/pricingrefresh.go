@@ -0,0 +1,108 @@
+package costmodel
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/log"
+)
+
+// pricingRefresher ensures that only one download of pricing data runs at a
+// time. A refresh requested while another is in flight waits for it, and
+// shares its outcome, rather than starting another.
+type pricingRefresher struct {
+	lock     sync.Mutex
+	inflight *pricingRefresh
+}
+
+// pricingRefresh is a download of pricing data, whose err is set before done
+// is closed
+type pricingRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+// do runs download, unless a download is already in flight, in which case it
+// waits for that download instead. It returns the error of the download run.
+func (pr *pricingRefresher) do(download func() error) error {
+	pr.lock.Lock()
+	if refresh := pr.inflight; refresh != nil {
+		pr.lock.Unlock()
+		<-refresh.done
+		return refresh.err
+	}
+	refresh := &pricingRefresh{done: make(chan struct{})}
+	pr.inflight = refresh
+	pr.lock.Unlock()
+
+	refresh.err = download()
+
+	pr.lock.Lock()
+	pr.inflight = nil
+	pr.lock.Unlock()
+	close(refresh.done)
+
+	return refresh.err
+}
+
+// pricingProviderName returns the name of the provider by which its pricing
+// refresh interval is set, one of env.PricingProviders
+func pricingProviderName(provider cloud.Provider) string {
+	switch provider.(type) {
+	case *cloud.AWS:
+		return "aws"
+	case *cloud.Azure:
+		return "azure"
+	case *cloud.GCP:
+		return "gcp"
+	case *cloud.CSVProvider:
+		return "csv"
+	default:
+		return "custom"
+	}
+}
+
+// refreshPricingData downloads the cloud provider's pricing data on the
+// provider's refresh interval, which is read again before each wait so that
+// changes to it are applied.
+func (a *Accesses) refreshPricingData() {
+	for {
+		interval := env.GetPricingRefreshInterval(pricingProviderName(a.CloudProvider))
+		time.Sleep(interval)
+
+		err := a.downloadPricingData()
+		if err != nil {
+			log.Warningf("Failed to refresh pricing data: %s", err)
+		}
+	}
+}
+
+// PricingRefreshResult is the outcome of an on-demand refresh of pricing data
+type PricingRefreshResult struct {
+	Provider string                          `json:"provider"`
+	Sources  map[string]*cloud.PricingSource `json:"sources"`
+}
+
+// RefreshPricing downloads the active provider's pricing data immediately,
+// returning the status of each of its pricing sources. If a refresh is already
+// in progress, its outcome is returned instead.
+func (a *Accesses) RefreshPricing(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	err := a.downloadPricingData()
+	if err != nil {
+		log.Warningf("Failed to refresh pricing data on request of %s: %s", requestActor(r), err)
+	}
+
+	result := &PricingRefreshResult{
+		Provider: pricingProviderName(a.CloudProvider),
+		Sources:  a.CloudProvider.PricingSourceStatus(),
+	}
+
+	w.Write(WrapData(result, err))
+}
@@ -0,0 +1,153 @@
+package costmodel
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+// refreshingProvider counts the downloads of pricing data, each of which
+// blocks until release is closed
+type refreshingProvider struct {
+	readinessProvider
+	downloads int32
+	started   chan struct{}
+	release   chan struct{}
+	err       error
+}
+
+func (p *refreshingProvider) DownloadPricingData() error {
+	atomic.AddInt32(&p.downloads, 1)
+	p.started <- struct{}{}
+	<-p.release
+	return p.err
+}
+
+func TestGetPricingRefreshInterval(t *testing.T) {
+	defer os.Unsetenv(env.PricingRefreshIntervalMinutesEnvVar)
+	defer os.Unsetenv(env.PricingRefreshIntervalMinutesEnvVar + "_AWS")
+
+	if interval := env.GetPricingRefreshInterval("aws"); interval != 24*time.Hour {
+		t.Fatalf("expected default of 24h; got %s", interval)
+	}
+
+	os.Setenv(env.PricingRefreshIntervalMinutesEnvVar, "120")
+	if interval := env.GetPricingRefreshInterval("aws"); interval != 2*time.Hour {
+		t.Fatalf("expected interval of 2h; got %s", interval)
+	}
+
+	os.Setenv(env.PricingRefreshIntervalMinutesEnvVar+"_AWS", "30")
+	if interval := env.GetPricingRefreshInterval("aws"); interval != 30*time.Minute {
+		t.Fatalf("expected provider override of 30m; got %s", interval)
+	}
+	if interval := env.GetPricingRefreshInterval("gcp"); interval != 2*time.Hour {
+		t.Fatalf("expected other providers to use interval of 2h; got %s", interval)
+	}
+
+	os.Setenv(env.PricingRefreshIntervalMinutesEnvVar+"_AWS", "-5")
+	if interval := env.GetPricingRefreshInterval("aws"); interval != 2*time.Hour {
+		t.Fatalf("expected invalid provider override to fall back to 2h; got %s", interval)
+	}
+
+	if name := pricingProviderName(&cloud.AWS{}); name != "aws" {
+		t.Fatalf("expected provider name 'aws'; got '%s'", name)
+	}
+	if name := pricingProviderName(&readinessProvider{}); name != "custom" {
+		t.Fatalf("expected provider name 'custom'; got '%s'", name)
+	}
+}
+
+func TestDownloadPricingDataSingleFlight(t *testing.T) {
+	provider := &refreshingProvider{
+		started: make(chan struct{}, 10),
+		release: make(chan struct{}),
+		err:     fmt.Errorf("rate limited"),
+	}
+	a := &Accesses{CloudProvider: provider}
+
+	// A scheduled refresh is in flight when manual refreshes are requested
+	errs := make(chan error, 4)
+	go func() { errs <- a.downloadPricingData() }()
+	<-provider.started
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- a.downloadPricingData()
+		}()
+	}
+
+	// Allow the manual refreshes to start waiting
+	time.Sleep(50 * time.Millisecond)
+	close(provider.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err == nil || err.Error() != "rate limited" {
+			t.Fatalf("expected each refresh to share the error of the one in flight; got %v", err)
+		}
+	}
+	if downloads := atomic.LoadInt32(&provider.downloads); downloads != 1 {
+		t.Fatalf("expected 1 download; got %d", downloads)
+	}
+	if attempted, err := a.pricingDownload.get(); !attempted || err == nil {
+		t.Fatalf("expected failed download to be recorded")
+	}
+
+	// Once complete, a subsequent refresh downloads again
+	provider.err = nil
+	err := a.downloadPricingData()
+	<-provider.started
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if downloads := atomic.LoadInt32(&provider.downloads); downloads != 2 {
+		t.Fatalf("expected 2 downloads; got %d", downloads)
+	}
+}
+
+func TestRefreshPricingHandler(t *testing.T) {
+	provider := &refreshingProvider{
+		readinessProvider: readinessProvider{sources: map[string]*cloud.PricingSource{
+			cloud.SpotPricingSource: {Name: cloud.SpotPricingSource, Available: true},
+		}},
+		started: make(chan struct{}, 1),
+		release: make(chan struct{}),
+	}
+	close(provider.release)
+	a := &Accesses{CloudProvider: provider}
+
+	w := httptest.NewRecorder()
+	a.RefreshPricing(w, httptest.NewRequest(http.MethodPost, "/pricing/refresh", nil), nil)
+
+	resp := &struct {
+		Code int                   `json:"code"`
+		Data *PricingRefreshResult `json:"data"`
+	}{}
+	err := json.Unmarshal(w.Body.Bytes(), resp)
+	if err != nil {
+		t.Fatalf("failed to decode response: %s", err)
+	}
+
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected code %d; got %d", http.StatusOK, resp.Code)
+	}
+	if resp.Data == nil || resp.Data.Provider != "custom" {
+		t.Fatalf("expected result for provider 'custom'; got %+v", resp.Data)
+	}
+	if source, ok := resp.Data.Sources[cloud.SpotPricingSource]; !ok || !source.Available {
+		t.Fatalf("expected available %s source; got %v", cloud.SpotPricingSource, resp.Data.Sources)
+	}
+}
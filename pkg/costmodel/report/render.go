@@ -0,0 +1,223 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+const (
+	// FormatJSON renders a CostReport as JSON
+	FormatJSON = "json"
+
+	// FormatCSV renders a CostReport as CSV, with a row per line item
+	FormatCSV = "csv"
+
+	// FormatHTML renders a CostReport as an HTML document, with a table per
+	// section
+	FormatHTML = "html"
+)
+
+// Renderer renders a CostReport in each of the supported formats.
+type Renderer interface {
+	RenderJSON(w io.Writer, report *CostReport) error
+	RenderCSV(w io.Writer, report *CostReport) error
+	RenderHTML(w io.Writer, report *CostReport) error
+}
+
+// ParseFormat returns the format of the given name, which is case-insensitive,
+// or an error if the format is not supported. An empty name is FormatJSON.
+func ParseFormat(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatCSV:
+		return FormatCSV, nil
+	case FormatHTML:
+		return FormatHTML, nil
+	}
+
+	return "", fmt.Errorf("unsupported format '%s': expected one of %s, %s, %s", name, FormatJSON, FormatCSV, FormatHTML)
+}
+
+// ContentType returns the HTTP Content-Type of the format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	}
+
+	return "application/json"
+}
+
+// Render renders the report in the given format using the renderer
+func Render(renderer Renderer, w io.Writer, format string, report *CostReport) error {
+	switch format {
+	case FormatJSON:
+		return renderer.RenderJSON(w, report)
+	case FormatCSV:
+		return renderer.RenderCSV(w, report)
+	case FormatHTML:
+		return renderer.RenderHTML(w, report)
+	}
+
+	return fmt.Errorf("unsupported format '%s'", format)
+}
+
+// section is a titled list of line items of a CostReport
+type section struct {
+	Title string
+	Items []CostLineItem
+}
+
+// sections returns the line items of the report by section, in order
+func sections(report *CostReport) []section {
+	return []section{
+		{"cluster", report.Clusters},
+		{"namespace", report.Namespaces},
+		{"workload", report.Workloads},
+	}
+}
+
+// csvHeader is the header row of a CostReport rendered as CSV
+var csvHeader = []string{
+	"Section", "Name", "CPUCost", "GPUCost", "RAMCost", "PVCost", "NetworkCost",
+	"LoadBalancerCost", "SharedCost", "ExternalCost", "TotalCost",
+}
+
+// DefaultRenderer is the standard implementation of Renderer
+type DefaultRenderer struct{}
+
+// NewRenderer returns the standard Renderer
+func NewRenderer() Renderer {
+	return DefaultRenderer{}
+}
+
+// RenderJSON renders the report as a JSON object, streaming the line items of
+// each section rather than marshaling the report in one call.
+func (dr DefaultRenderer) RenderJSON(w io.Writer, report *CostReport) error {
+	period, err := json.Marshal(report.Period)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, `{"period":%s`, period)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range sections(report) {
+		_, err = fmt.Fprintf(w, `,"%ss":`, s.Title)
+		if err != nil {
+			return err
+		}
+
+		// A nil section is encoded as null, as it would be by json.Marshal
+		if s.Items == nil {
+			_, err = io.WriteString(w, "null")
+		} else {
+			err = json.StreamArray(w, func(encode func(item interface{}) error) error {
+				for _, item := range s.Items {
+					if err := encode(item); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = io.WriteString(w, "}")
+	return err
+}
+
+// RenderCSV renders the report as CSV, with a header row and a row per line
+// item, in section order. The section of each row is its first column.
+func (dr DefaultRenderer) RenderCSV(w io.Writer, report *CostReport) error {
+	cw := csv.NewWriter(w)
+
+	err := cw.Write(csvHeader)
+	if err != nil {
+		return err
+	}
+
+	formatCost := func(cost float64) string {
+		return strconv.FormatFloat(cost, 'f', -1, 64)
+	}
+
+	for _, s := range sections(report) {
+		for _, item := range s.Items {
+			err := cw.Write([]string{
+				s.Title,
+				item.Name,
+				formatCost(item.CPUCost),
+				formatCost(item.GPUCost),
+				formatCost(item.RAMCost),
+				formatCost(item.PVCost),
+				formatCost(item.NetworkCost),
+				formatCost(item.LoadBalancerCost),
+				formatCost(item.SharedCost),
+				formatCost(item.ExternalCost),
+				formatCost(item.TotalCost),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// htmlTemplate renders a CostReport as an HTML document. Names are escaped by
+// html/template.
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"cost": func(cost float64) string { return fmt.Sprintf("%.2f", cost) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Cost Report {{.Period}}</title>
+</head>
+<body>
+<h1>Cost Report</h1>
+<p>{{.Period}}</p>
+{{range .Sections}}<h2>{{.Title}}</h2>
+<table>
+<tr><th>Name</th><th>CPU</th><th>GPU</th><th>RAM</th><th>PV</th><th>Network</th><th>Load Balancer</th><th>Shared</th><th>External</th><th>Total</th></tr>
+{{range .Items}}<tr><td>{{.Name}}</td><td>{{cost .CPUCost}}</td><td>{{cost .GPUCost}}</td><td>{{cost .RAMCost}}</td><td>{{cost .PVCost}}</td><td>{{cost .NetworkCost}}</td><td>{{cost .LoadBalancerCost}}</td><td>{{cost .SharedCost}}</td><td>{{cost .ExternalCost}}</td><td>{{cost .TotalCost}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// RenderHTML renders the report as an HTML document, with a table per
+// section
+func (dr DefaultRenderer) RenderHTML(w io.Writer, report *CostReport) error {
+	titled := []section{}
+	for _, s := range sections(report) {
+		titled = append(titled, section{
+			Title: strings.Title(s.Title) + "s",
+			Items: s.Items,
+		})
+	}
+
+	return htmlTemplate.Execute(w, struct {
+		Period   string
+		Sections []section
+	}{
+		Period:   report.Period.String(),
+		Sections: titled,
+	})
+}
@@ -0,0 +1,106 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kubecost/cost-model/pkg/kubecost"
+)
+
+// CostLineItem is the cost of a single cluster, namespace, or workload over
+// the period of a CostReport.
+type CostLineItem struct {
+	Name             string  `json:"name"`
+	CPUCost          float64 `json:"cpuCost"`
+	GPUCost          float64 `json:"gpuCost"`
+	RAMCost          float64 `json:"ramCost"`
+	PVCost           float64 `json:"pvCost"`
+	NetworkCost      float64 `json:"networkCost"`
+	LoadBalancerCost float64 `json:"loadBalancerCost"`
+	SharedCost       float64 `json:"sharedCost"`
+	ExternalCost     float64 `json:"externalCost"`
+	TotalCost        float64 `json:"totalCost"`
+}
+
+// CostReport is the cost of each cluster, namespace, and workload over a
+// period. The line items of each are sorted by total cost, descending.
+// Workloads are named by cluster, namespace, and controller; e.g.
+// "cluster-one/kubecost/deployment:cost-analyzer".
+type CostReport struct {
+	Period     kubecost.Window `json:"period"`
+	Clusters   []CostLineItem  `json:"clusters"`
+	Namespaces []CostLineItem  `json:"namespaces"`
+	Workloads  []CostLineItem  `json:"workloads"`
+}
+
+// workloadAggregation are the properties by which workloads are aggregated
+var workloadAggregation = []string{
+	kubecost.AllocationClusterProp,
+	kubecost.AllocationNamespaceProp,
+	kubecost.AllocationControllerProp,
+}
+
+// NewCostReport creates a CostReport from the allocations of the set, which
+// must not be aggregated. The set itself is not modified.
+func NewCostReport(as *kubecost.AllocationSet) (*CostReport, error) {
+	if as == nil {
+		return nil, fmt.Errorf("cannot create cost report from nil allocation set")
+	}
+
+	clusters, err := newCostLineItems(as, []string{kubecost.AllocationClusterProp})
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces, err := newCostLineItems(as, []string{kubecost.AllocationNamespaceProp})
+	if err != nil {
+		return nil, err
+	}
+
+	workloads, err := newCostLineItems(as, workloadAggregation)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CostReport{
+		Period:     as.Window.Clone(),
+		Clusters:   clusters,
+		Namespaces: namespaces,
+		Workloads:  workloads,
+	}, nil
+}
+
+// newCostLineItems aggregates a copy of the set by the given properties and
+// returns a line item per aggregated allocation, sorted by total cost.
+func newCostLineItems(as *kubecost.AllocationSet, aggregateBy []string) ([]CostLineItem, error) {
+	agg := as.Clone()
+	err := agg.AggregateBy(aggregateBy, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error aggregating by %v: %s", aggregateBy, err)
+	}
+
+	items := []CostLineItem{}
+	agg.Each(func(name string, alloc *kubecost.Allocation) {
+		items = append(items, CostLineItem{
+			Name:             name,
+			CPUCost:          alloc.CPUTotalCost(),
+			GPUCost:          alloc.GPUTotalCost(),
+			RAMCost:          alloc.RAMTotalCost(),
+			PVCost:           alloc.PVTotalCost(),
+			NetworkCost:      alloc.NetworkTotalCost(),
+			LoadBalancerCost: alloc.LBTotalCost(),
+			SharedCost:       alloc.SharedTotalCost(),
+			ExternalCost:     alloc.ExternalCost,
+			TotalCost:        alloc.TotalCost(),
+		})
+	})
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].TotalCost != items[j].TotalCost {
+			return items[i].TotalCost > items[j].TotalCost
+		}
+		return items[i].Name < items[j].Name
+	})
+
+	return items, nil
+}
@@ -0,0 +1,166 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+func newTestAllocationSet() *kubecost.AllocationSet {
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	newAlloc := func(cluster, namespace, controller, pod string) *kubecost.Allocation {
+		return kubecost.NewMockUnitAllocation(cluster+"/"+namespace+"/"+pod+"/app", start, 24*time.Hour, &kubecost.AllocationProperties{
+			Cluster:        cluster,
+			Namespace:      namespace,
+			ControllerKind: "deployment",
+			Controller:     controller,
+			Pod:            pod,
+			Container:      "app",
+		})
+	}
+
+	return kubecost.NewAllocationSet(start, start.Add(24*time.Hour),
+		newAlloc("cluster-one", "web", "frontend", "frontend-1"),
+		newAlloc("cluster-one", "web", "frontend", "frontend-2"),
+		newAlloc("cluster-one", "<batch>", "etl", "etl-1"),
+		newAlloc("cluster-two", "web", "frontend", "frontend-3"),
+	)
+}
+
+func TestNewCostReport(t *testing.T) {
+	as := newTestAllocationSet()
+	unit := as.Clone().Get("cluster-one/web/frontend-1/app").TotalCost()
+
+	report, err := NewCostReport(as)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// The set is not aggregated by the report
+	if as.Length() != 4 {
+		t.Errorf("expected set to be unmodified; got %d allocations", as.Length())
+	}
+
+	cases := []struct {
+		section  string
+		items    []CostLineItem
+		expected []CostLineItem
+	}{
+		{"clusters", report.Clusters, []CostLineItem{
+			{Name: "cluster-one", TotalCost: 3 * unit},
+			{Name: "cluster-two", TotalCost: unit},
+		}},
+		{"namespaces", report.Namespaces, []CostLineItem{
+			{Name: "web", TotalCost: 3 * unit},
+			{Name: "<batch>", TotalCost: unit},
+		}},
+		{"workloads", report.Workloads, []CostLineItem{
+			{Name: "cluster-one/web/deployment:frontend", TotalCost: 2 * unit},
+			{Name: "cluster-one/<batch>/deployment:etl", TotalCost: unit},
+			{Name: "cluster-two/web/deployment:frontend", TotalCost: unit},
+		}},
+	}
+
+	for _, c := range cases {
+		if len(c.items) != len(c.expected) {
+			t.Fatalf("%s: expected %d line items; got %d", c.section, len(c.expected), len(c.items))
+		}
+		for i, exp := range c.expected {
+			if c.items[i].Name != exp.Name || c.items[i].TotalCost != exp.TotalCost {
+				t.Errorf("%s: expected line item %d to be %s (%f); got %s (%f)", c.section, i, exp.Name, exp.TotalCost, c.items[i].Name, c.items[i].TotalCost)
+			}
+		}
+	}
+
+	if _, err := NewCostReport(nil); err == nil {
+		t.Errorf("expected error for nil allocation set")
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	cases := map[string]string{
+		"":     FormatJSON,
+		"json": FormatJSON,
+		"CSV":  FormatCSV,
+		"html": FormatHTML,
+	}
+
+	for name, expected := range cases {
+		format, err := ParseFormat(name)
+		if err != nil || format != expected {
+			t.Errorf("%s: expected %s; got %s, %v", name, expected, format, err)
+		}
+	}
+
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Errorf("expected error for unsupported format")
+	}
+}
+
+func TestDefaultRenderer(t *testing.T) {
+	report, err := NewCostReport(newTestAllocationSet())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	renderer := NewRenderer()
+
+	// CSV has a header row and a row per line item
+	var buf bytes.Buffer
+	err = Render(renderer, &buf, FormatCSV, report)
+	if err != nil {
+		t.Fatalf("unexpected error rendering CSV: %s", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("unexpected error reading CSV: %s", err)
+	}
+	if len(rows) != 1+2+2+3 {
+		t.Fatalf("expected %d CSV rows; got %d", 1+2+2+3, len(rows))
+	}
+	if rows[0][0] != "Section" || rows[1][0] != "cluster" || rows[1][1] != "cluster-one" || rows[7][0] != "workload" {
+		t.Errorf("unexpected CSV rows: %v", rows)
+	}
+
+	// HTML escapes names
+	buf.Reset()
+	err = Render(renderer, &buf, FormatHTML, report)
+	if err != nil {
+		t.Fatalf("unexpected error rendering HTML: %s", err)
+	}
+	html := buf.String()
+	if !strings.Contains(html, "&lt;batch&gt;") || strings.Contains(html, "<batch>") {
+		t.Errorf("expected HTML to escape names")
+	}
+	if !strings.Contains(html, "<h2>Workloads</h2>") {
+		t.Errorf("expected HTML to have a workloads section")
+	}
+
+	buf.Reset()
+	err = Render(renderer, &buf, FormatJSON, report)
+	if err != nil {
+		t.Fatalf("unexpected error rendering JSON: %s", err)
+	}
+	if !strings.Contains(buf.String(), `"workloads":[`) {
+		t.Errorf("expected JSON to have workloads; got %s", buf.String())
+	}
+
+	// The streamed JSON matches the report marshaled in one call
+	marshaled, err := json.Marshal(report)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling report: %s", err)
+	}
+	if buf.String() != string(marshaled) {
+		t.Errorf("expected streamed JSON to match marshaled JSON:\n%s\n%s", buf.String(), marshaled)
+	}
+
+	if err := Render(renderer, &buf, "xml", report); err == nil {
+		t.Errorf("expected error for unsupported format")
+	}
+}
@@ -0,0 +1,497 @@
+package costmodel
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/prom"
+	"github.com/kubecost/cost-model/pkg/util/httputil"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+
+	autoscaling "k8s.io/api/autoscaling/v2beta1"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	queryFmtContainerCPUUsageSeries = `sum(rate(container_cpu_usage_seconds_total{container!="", container_name!="POD", container!="POD"}[%s])) by (container, pod, namespace, %s)`
+	queryFmtContainerRAMUsageSeries = `sum(container_memory_working_set_bytes{container!="", container_name!="POD", container!="POD"}) by (container, pod, namespace, %s)`
+	queryFmtContainerOOMKills       = `max(max_over_time(kube_pod_container_status_terminated_reason{reason="OOMKilled"}[%s]%s)) by (container, pod, namespace, %s)`
+)
+
+// RequestSizingOptions configures the percentile targets and headroom used to
+// compute container request recommendations.
+type RequestSizingOptions struct {
+	TargetCPUPercentile float64
+	TargetRAMPercentile float64
+	CPUHeadroom         float64
+	RAMHeadroom         float64
+	MinCPUCores         float64
+	MinRAMBytes         float64
+}
+
+// DefaultRequestSizingOptions returns RequestSizingOptions targeting p98 usage
+// with 15% headroom.
+func DefaultRequestSizingOptions() *RequestSizingOptions {
+	return &RequestSizingOptions{
+		TargetCPUPercentile: 0.98,
+		TargetRAMPercentile: 0.98,
+		CPUHeadroom:         0.15,
+		RAMHeadroom:         0.15,
+		MinCPUCores:         0.01,
+		MinRAMBytes:         16.0 * 1024.0 * 1024.0,
+	}
+}
+
+// ContainerUsageProfile summarizes the observed usage distribution of a
+// container spec over all of its controller's pods.
+type ContainerUsageProfile struct {
+	CPUSamples              int     `json:"cpuSamples"`
+	CPUCoreUsageAverage     float64 `json:"cpuCoreUsageAverage"`
+	CPUCoreUsageMax         float64 `json:"cpuCoreUsageMax"`
+	CPUCoreUsagePercentile  float64 `json:"cpuCoreUsagePercentile"`
+	RAMSamples              int     `json:"ramSamples"`
+	RAMBytesUsageAverage    float64 `json:"ramByteUsageAverage"`
+	RAMBytesUsageMax        float64 `json:"ramByteUsageMax"`
+	RAMBytesUsagePercentile float64 `json:"ramByteUsagePercentile"`
+	CostPerCPUCoreHour      float64 `json:"costPerCPUCoreHour"`
+	CostPerRAMGiBHour       float64 `json:"costPerRAMGiBHour"`
+	AverageReplicas         float64 `json:"averageReplicas"`
+}
+
+// RequestSizingRecommendation is a recommended set of requests for a single
+// container spec of a controller.
+type RequestSizingRecommendation struct {
+	Cluster                    string                 `json:"cluster"`
+	Namespace                  string                 `json:"namespace"`
+	ControllerKind             string                 `json:"controllerKind"`
+	Controller                 string                 `json:"controller"`
+	Container                  string                 `json:"container"`
+	Pods                       int                    `json:"pods"`
+	CurrentCPUCoreRequest      float64                `json:"currentCPUCoreRequest"`
+	RecommendedCPUCoreRequest  float64                `json:"recommendedCPUCoreRequest"`
+	CurrentRAMBytesRequest     float64                `json:"currentRAMByteRequest"`
+	RecommendedRAMBytesRequest float64                `json:"recommendedRAMByteRequest"`
+	CurrentMonthlyCost         float64                `json:"currentMonthlyCost"`
+	RecommendedMonthlyCost     float64                `json:"recommendedMonthlyCost"`
+	MonthlySavings             float64                `json:"monthlySavings"`
+	OOMKilled                  bool                   `json:"oomKilled"`
+	HPAs                       []string               `json:"hpas,omitempty"`
+	Warnings                   []string               `json:"warnings,omitempty"`
+	Profile                    *ContainerUsageProfile `json:"profile"`
+}
+
+// containerSpecKey identifies a container spec shared by all pods of a
+// controller.
+type containerSpecKey struct {
+	controllerKey
+	Container string
+}
+
+// containerSpecUsage collects the usage samples and allocation data for a
+// single container spec.
+type containerSpecUsage struct {
+	pods          map[string]bool
+	cpuSamples    []float64
+	ramSamples    []float64
+	allocMinutes  float64
+	cpuReqMinutes float64
+	ramReqMinutes float64
+	cpuCost       float64
+	cpuCoreHours  float64
+	ramCost       float64
+	ramByteHours  float64
+	oomKilled     bool
+}
+
+// Percentile returns the p-th percentile (0.0 <= p <= 1.0) of the given values
+// using linear interpolation between the closest ranks. The input is not
+// modified. Returns 0.0 for an empty input.
+func Percentile(values []float64, p float64) float64 {
+	if len(values) == 0 {
+		return 0.0
+	}
+
+	sorted := make([]float64, len(values))
+	copy(sorted, values)
+	sort.Float64s(sorted)
+
+	if p <= 0.0 {
+		return sorted[0]
+	}
+	if p >= 1.0 {
+		return sorted[len(sorted)-1]
+	}
+
+	rank := p * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+
+	frac := rank - float64(lower)
+	return sorted[lower] + frac*(sorted[upper]-sorted[lower])
+}
+
+// recommendRequest applies headroom to the given percentile usage, honoring
+// the given minimum.
+func recommendRequest(percentileUsage, headroom, minimum float64) float64 {
+	return math.Max(percentileUsage*(1.0+headroom), minimum)
+}
+
+// controllerSpecFor returns the controller key for the given allocation
+// properties. Pods without a controller are treated as their own controller.
+func controllerSpecFor(props *kubecost.AllocationProperties) controllerKey {
+	if props.Controller == "" {
+		return newControllerKey(props.Cluster, props.Namespace, "pod", props.Pod)
+	}
+	return newControllerKey(props.Cluster, props.Namespace, props.ControllerKind, props.Controller)
+}
+
+// ComputeRequestSizingRecommendations computes request recommendations per
+// container spec from the given AllocationSet, the CPU and RAM usage samples
+// keyed by container, the set of containers observed to be OOMKilled, and the
+// HPAs in the cluster.
+func ComputeRequestSizingRecommendations(as *kubecost.AllocationSet, cpuSamples, ramSamples map[containerKey][]float64, oomKilled map[containerKey]bool, hpas []*autoscaling.HorizontalPodAutoscaler, opts *RequestSizingOptions) []*RequestSizingRecommendation {
+	recs := []*RequestSizingRecommendation{}
+
+	if as == nil || as.IsEmpty() {
+		return recs
+	}
+
+	if opts == nil {
+		opts = DefaultRequestSizingOptions()
+	}
+
+	windowMins := as.End().Sub(as.Start()).Minutes()
+	if windowMins <= 0 {
+		return recs
+	}
+
+	specs := map[containerSpecKey]*containerSpecUsage{}
+
+	as.Each(func(name string, alloc *kubecost.Allocation) {
+		if alloc.IsIdle() || alloc.IsUnmounted() || alloc.IsExternal() || alloc.IsUnallocated() {
+			return
+		}
+
+		props := alloc.Properties
+		if props == nil || props.Container == "" || props.Pod == "" {
+			return
+		}
+
+		key := containerSpecKey{
+			controllerKey: controllerSpecFor(props),
+			Container:     props.Container,
+		}
+
+		spec, ok := specs[key]
+		if !ok {
+			spec = &containerSpecUsage{pods: map[string]bool{}}
+			specs[key] = spec
+		}
+
+		ck := newContainerKey(props.Cluster, props.Namespace, props.Pod, props.Container)
+
+		mins := alloc.Minutes()
+		spec.pods[props.Pod] = true
+		spec.cpuSamples = append(spec.cpuSamples, cpuSamples[ck]...)
+		spec.ramSamples = append(spec.ramSamples, ramSamples[ck]...)
+		spec.allocMinutes += mins
+		spec.cpuReqMinutes += alloc.CPUCoreRequestAverage * mins
+		spec.ramReqMinutes += alloc.RAMBytesRequestAverage * mins
+		spec.cpuCost += alloc.CPUCost
+		spec.cpuCoreHours += alloc.CPUCoreHours
+		spec.ramCost += alloc.RAMCost
+		spec.ramByteHours += alloc.RAMByteHours
+		if oomKilled[ck] {
+			spec.oomKilled = true
+		}
+	})
+
+	for key, spec := range specs {
+		if spec.allocMinutes <= 0 {
+			continue
+		}
+
+		profile := &ContainerUsageProfile{
+			CPUSamples:      len(spec.cpuSamples),
+			RAMSamples:      len(spec.ramSamples),
+			AverageReplicas: spec.allocMinutes / windowMins,
+		}
+
+		if len(spec.cpuSamples) > 0 {
+			profile.CPUCoreUsageAverage = sampleAverage(spec.cpuSamples)
+			profile.CPUCoreUsageMax = sampleMax(spec.cpuSamples)
+			profile.CPUCoreUsagePercentile = Percentile(spec.cpuSamples, opts.TargetCPUPercentile)
+		}
+		if len(spec.ramSamples) > 0 {
+			profile.RAMBytesUsageAverage = sampleAverage(spec.ramSamples)
+			profile.RAMBytesUsageMax = sampleMax(spec.ramSamples)
+			profile.RAMBytesUsagePercentile = Percentile(spec.ramSamples, opts.TargetRAMPercentile)
+		}
+
+		// Use the effective prices of the nodes the pods actually ran on
+		if spec.cpuCoreHours > 0 {
+			profile.CostPerCPUCoreHour = spec.cpuCost / spec.cpuCoreHours
+		}
+		if spec.ramByteHours > 0 {
+			profile.CostPerRAMGiBHour = spec.ramCost / (spec.ramByteHours / 1024.0 / 1024.0 / 1024.0)
+		}
+
+		rec := &RequestSizingRecommendation{
+			Cluster:                key.Cluster,
+			Namespace:              key.Namespace,
+			ControllerKind:         key.ControllerKind,
+			Controller:             key.Controller,
+			Container:              key.Container,
+			Pods:                   len(spec.pods),
+			CurrentCPUCoreRequest:  spec.cpuReqMinutes / spec.allocMinutes,
+			CurrentRAMBytesRequest: spec.ramReqMinutes / spec.allocMinutes,
+			OOMKilled:              spec.oomKilled,
+			Profile:                profile,
+		}
+
+		rec.RecommendedCPUCoreRequest = recommendRequest(profile.CPUCoreUsagePercentile, opts.CPUHeadroom, opts.MinCPUCores)
+		rec.RecommendedRAMBytesRequest = recommendRequest(profile.RAMBytesUsagePercentile, opts.RAMHeadroom, opts.MinRAMBytes)
+
+		if len(spec.cpuSamples) == 0 {
+			rec.RecommendedCPUCoreRequest = rec.CurrentCPUCoreRequest
+			rec.Warnings = append(rec.Warnings, "no CPU usage samples; keeping current CPU request")
+		}
+		if len(spec.ramSamples) == 0 {
+			rec.RecommendedRAMBytesRequest = rec.CurrentRAMBytesRequest
+			rec.Warnings = append(rec.Warnings, "no RAM usage samples; keeping current RAM request")
+		}
+
+		// An OOMKilled container was constrained by its memory limit, so its
+		// observed usage understates its need. Never recommend lowering RAM.
+		if rec.OOMKilled {
+			rec.RecommendedRAMBytesRequest = math.Max(rec.RecommendedRAMBytesRequest, rec.CurrentRAMBytesRequest)
+			rec.Warnings = append(rec.Warnings, "container was OOMKilled; RAM request will not be lowered")
+		}
+
+		for _, hpa := range hpas {
+			if hpaResource, ok := hpaTargetsResource(hpa, key.controllerKey); ok {
+				rec.HPAs = append(rec.HPAs, hpa.Name)
+				rec.Warnings = append(rec.Warnings, fmt.Sprintf("HPA %s scales on %s utilization relative to requests; changing requests changes scaling behavior", hpa.Name, hpaResource))
+			}
+		}
+
+		rec.CurrentMonthlyCost = requestsMonthlyCost(rec.CurrentCPUCoreRequest, rec.CurrentRAMBytesRequest, profile)
+		rec.RecommendedMonthlyCost = requestsMonthlyCost(rec.RecommendedCPUCoreRequest, rec.RecommendedRAMBytesRequest, profile)
+		rec.MonthlySavings = rec.CurrentMonthlyCost - rec.RecommendedMonthlyCost
+
+		recs = append(recs, rec)
+	}
+
+	sort.SliceStable(recs, func(i, j int) bool {
+		if recs[i].MonthlySavings == recs[j].MonthlySavings {
+			return recs[i].Controller+"/"+recs[i].Container < recs[j].Controller+"/"+recs[j].Container
+		}
+		return recs[i].MonthlySavings > recs[j].MonthlySavings
+	})
+
+	return recs
+}
+
+// requestsMonthlyCost returns the monthly cost of running the given requests
+// at the profile's prices and average replica count.
+func requestsMonthlyCost(cpuCores, ramBytes float64, profile *ContainerUsageProfile) float64 {
+	hourly := cpuCores*profile.CostPerCPUCoreHour + (ramBytes/1024.0/1024.0/1024.0)*profile.CostPerRAMGiBHour
+	return hourly * profile.AverageReplicas * timeutil.HoursPerMonth
+}
+
+// hpaTargetsResource returns the name of the resource the HPA scales on if the
+// HPA targets the given controller and scales on a request-relative resource
+// utilization.
+func hpaTargetsResource(hpa *autoscaling.HorizontalPodAutoscaler, key controllerKey) (string, bool) {
+	if hpa == nil || hpa.Namespace != key.Namespace {
+		return "", false
+	}
+
+	ref := hpa.Spec.ScaleTargetRef
+	if !strings.EqualFold(ref.Kind, key.ControllerKind) || ref.Name != key.Controller {
+		return "", false
+	}
+
+	for _, metric := range hpa.Spec.Metrics {
+		if metric.Resource == nil || metric.Resource.TargetAverageUtilization == nil {
+			continue
+		}
+		if metric.Resource.Name == v1.ResourceCPU || metric.Resource.Name == v1.ResourceMemory {
+			return string(metric.Resource.Name), true
+		}
+	}
+
+	return "", false
+}
+
+func sampleAverage(values []float64) float64 {
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	return total / float64(len(values))
+}
+
+func sampleMax(values []float64) float64 {
+	max := 0.0
+	for _, v := range values {
+		max = math.Max(max, v)
+	}
+	return max
+}
+
+// resToContainerSamples converts range query results into usage samples keyed
+// by container.
+func resToContainerSamples(results []*prom.QueryResult) map[containerKey][]float64 {
+	samples := map[containerKey][]float64{}
+
+	for _, res := range results {
+		key, err := resultContainerKey(res, env.GetPromClusterLabel(), "namespace", "pod", "container")
+		if err != nil {
+			log.DedupedWarningf(10, "RequestSizing: usage result missing field: %s", err)
+			continue
+		}
+
+		for _, v := range res.Values {
+			samples[key] = append(samples[key], v.Value)
+		}
+	}
+
+	return samples
+}
+
+// resToOOMKilledContainers returns the set of containers terminated with an
+// OOMKilled reason.
+func resToOOMKilledContainers(results []*prom.QueryResult) map[containerKey]bool {
+	oomKilled := map[containerKey]bool{}
+
+	for _, res := range results {
+		key, err := resultContainerKey(res, env.GetPromClusterLabel(), "namespace", "pod", "container")
+		if err != nil {
+			log.DedupedWarningf(10, "RequestSizing: OOMKilled result missing field: %s", err)
+			continue
+		}
+
+		if len(res.Values) > 0 && res.Values[0].Value > 0 {
+			oomKilled[key] = true
+		}
+	}
+
+	return oomKilled
+}
+
+// ComputeRequestSizing queries the usage distributions for the given window
+// and computes container request recommendations.
+func (cm *CostModel) ComputeRequestSizing(window kubecost.Window, step, resolution time.Duration, opts *RequestSizingOptions) ([]*RequestSizingRecommendation, error) {
+	start, end := *window.Start(), *window.End()
+
+	as, err := cm.ComputeAllocation(start, end, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	durStr, offStr, err := window.DurationOffsetForPrometheus()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := prom.NewNamedContext(cm.PrometheusClient, prom.AllocationContextName)
+
+	queryCPU := fmt.Sprintf(queryFmtContainerCPUUsageSeries, timeutil.DurationString(step), env.GetPromClusterLabel())
+	resChCPU := ctx.QueryRange(queryCPU, start, end, step)
+
+	queryRAM := fmt.Sprintf(queryFmtContainerRAMUsageSeries, env.GetPromClusterLabel())
+	resChRAM := ctx.QueryRange(queryRAM, start, end, step)
+
+	queryOOM := fmt.Sprintf(queryFmtContainerOOMKills, durStr, offStr, env.GetPromClusterLabel())
+	resChOOM := ctx.Query(queryOOM)
+
+	resCPU, _ := resChCPU.Await()
+	resRAM, _ := resChRAM.Await()
+	resOOM, _ := resChOOM.Await()
+
+	if ctx.HasErrors() {
+		for _, err := range ctx.Errors() {
+			log.Errorf("CostModel.ComputeRequestSizing: %s", err)
+		}
+		return nil, ctx.ErrorCollection()
+	}
+
+	var hpas []*autoscaling.HorizontalPodAutoscaler
+	if cm.Cache != nil {
+		hpas = cm.Cache.GetAllHorizontalPodAutoscalers()
+	}
+
+	return ComputeRequestSizingRecommendations(as, resToContainerSamples(resCPU), resToContainerSamples(resRAM), resToOOMKilledContainers(resOOM), hpas, opts), nil
+}
+
+// RequestSizingHandler returns container request recommendations for the
+// given window and percentile targets.
+func (a *Accesses) RequestSizingHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	qp := httputil.NewQueryParams(r.URL.Query())
+
+	conversion, err := a.currencyConversion(qp)
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'currency' parameter: %s", err)))
+		return
+	}
+
+	window, err := kubecost.ParseWindowWithOffset(qp.Get("window", "7d"), env.GetParsedUTCOffset())
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'window' parameter: %s", err)))
+		return
+	}
+	if window.IsOpen() || window.IsNegative() {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'window' parameter: %s", window)))
+		return
+	}
+
+	opts := DefaultRequestSizingOptions()
+	opts.TargetCPUPercentile = qp.GetFloat64("targetCPUPercentile", opts.TargetCPUPercentile)
+	opts.TargetRAMPercentile = qp.GetFloat64("targetRAMPercentile", opts.TargetRAMPercentile)
+	opts.CPUHeadroom = qp.GetFloat64("cpuHeadroom", opts.CPUHeadroom)
+	opts.RAMHeadroom = qp.GetFloat64("ramHeadroom", opts.RAMHeadroom)
+
+	if opts.TargetCPUPercentile < 0.0 || opts.TargetCPUPercentile > 1.0 || opts.TargetRAMPercentile < 0.0 || opts.TargetRAMPercentile > 1.0 {
+		WriteError(w, BadRequest("percentile targets must be between 0.0 and 1.0"))
+		return
+	}
+	if opts.CPUHeadroom < 0.0 || opts.RAMHeadroom < 0.0 {
+		WriteError(w, BadRequest("headroom must be non-negative"))
+		return
+	}
+
+	step := qp.GetDuration("step", 5*time.Minute)
+	if step <= 0 {
+		WriteError(w, BadRequest("Parameter 'step' must be positive"))
+		return
+	}
+	if window.Duration()/step > maxAllocationQuerySamples {
+		WriteError(w, BadRequest(fmt.Sprintf("Parameter 'step' of %s exceeds the limit of %d samples per series over the window of %s; use a step of at least %s", step, maxAllocationQuerySamples, window.Duration(), minQueryStep(window.Duration()))))
+		return
+	}
+
+	resolution := qp.GetDuration("resolution", env.GetETLResolution())
+
+	recs, err := a.Model.ComputeRequestSizing(window, step, resolution, opts)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	writeWithCurrency(w, recs, conversion)
+}
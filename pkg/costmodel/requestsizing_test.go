@@ -0,0 +1,222 @@
+package costmodel
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+
+	autoscaling "k8s.io/api/autoscaling/v2beta1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPercentile(t *testing.T) {
+	oneToHundred := []float64{}
+	for i := 100; i >= 1; i-- {
+		oneToHundred = append(oneToHundred, float64(i))
+	}
+
+	cases := []struct {
+		name     string
+		values   []float64
+		p        float64
+		expected float64
+	}{
+		{"empty", []float64{}, 0.5, 0.0},
+		{"single", []float64{4.0}, 0.98, 4.0},
+		{"min", oneToHundred, 0.0, 1.0},
+		{"max", oneToHundred, 1.0, 100.0},
+		{"median even", []float64{1.0, 2.0, 3.0, 4.0}, 0.5, 2.5},
+		{"median odd", []float64{3.0, 1.0, 2.0}, 0.5, 2.0},
+		{"p98", oneToHundred, 0.98, 98.02},
+		{"p90", oneToHundred, 0.90, 90.1},
+		{"p50 of constant", []float64{0.2, 0.2, 0.2, 0.2}, 0.5, 0.2},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			actual := Percentile(c.values, c.p)
+			if math.Abs(actual-c.expected) > 0.0001 {
+				t.Fatalf("expected %f; got %f", c.expected, actual)
+			}
+		})
+	}
+
+	// Input must not be reordered
+	if oneToHundred[0] != 100.0 {
+		t.Fatalf("expected Percentile not to modify its input")
+	}
+}
+
+func newRequestSizingTestAllocation(start, end time.Time, controller, pod string, cpuReq, ramReq float64) *kubecost.Allocation {
+	hours := end.Sub(start).Hours()
+	cpuCoreHours := cpuReq * hours
+	ramByteHours := ramReq * hours
+
+	return &kubecost.Allocation{
+		Name:  fmt.Sprintf("cluster1/ns1/%s/container", pod),
+		Start: start,
+		End:   end,
+		Properties: &kubecost.AllocationProperties{
+			Cluster:        "cluster1",
+			Namespace:      "ns1",
+			ControllerKind: "deployment",
+			Controller:     controller,
+			Pod:            pod,
+			Container:      "container",
+		},
+		CPUCoreRequestAverage:  cpuReq,
+		CPUCoreHours:           cpuCoreHours,
+		CPUCost:                cpuCoreHours * 0.04,
+		RAMBytesRequestAverage: ramReq,
+		RAMByteHours:           ramByteHours,
+		RAMCost:                ramByteHours / 1024.0 / 1024.0 / 1024.0 * 0.005,
+	}
+}
+
+func TestComputeRequestSizingRecommendations(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	gib := 1024.0 * 1024.0 * 1024.0
+
+	opts := DefaultRequestSizingOptions()
+	opts.TargetCPUPercentile = 0.98
+	opts.TargetRAMPercentile = 0.98
+	opts.CPUHeadroom = 0.10
+	opts.RAMHeadroom = 0.10
+
+	as := kubecost.NewAllocationSet(start, end,
+		newRequestSizingTestAllocation(start, end, "web", "web-1", 1.0, 2.0*gib),
+		newRequestSizingTestAllocation(start, end, "web", "web-2", 1.0, 2.0*gib),
+		newRequestSizingTestAllocation(start, end, "oom", "oom-1", 1.0, 1.0*gib),
+		newRequestSizingTestAllocation(start, end, "scaled", "scaled-1", 1.0, 1.0*gib),
+	)
+
+	cpuSamples := map[containerKey][]float64{}
+	ramSamples := map[containerKey][]float64{}
+
+	// Split a uniform 0.01..1.00 CPU and 0.01..1.00 GiB RAM distribution
+	// across the two web pods; pooled, the p98 is 0.9802 cores and GiB
+	web1 := newContainerKey("cluster1", "ns1", "web-1", "container")
+	web2 := newContainerKey("cluster1", "ns1", "web-2", "container")
+	for i := 1; i <= 100; i++ {
+		key := web1
+		if i%2 == 0 {
+			key = web2
+		}
+		cpuSamples[key] = append(cpuSamples[key], float64(i)/100.0)
+		ramSamples[key] = append(ramSamples[key], float64(i)/100.0*gib)
+	}
+
+	oom := newContainerKey("cluster1", "ns1", "oom-1", "container")
+	cpuSamples[oom] = []float64{0.1, 0.1, 0.1}
+	ramSamples[oom] = []float64{0.5 * gib, 0.5 * gib, 0.5 * gib}
+
+	scaled := newContainerKey("cluster1", "ns1", "scaled-1", "container")
+	cpuSamples[scaled] = []float64{0.5, 0.5}
+	ramSamples[scaled] = []float64{0.5 * gib, 0.5 * gib}
+
+	oomKilled := map[containerKey]bool{oom: true}
+
+	utilization := int32(80)
+	hpas := []*autoscaling.HorizontalPodAutoscaler{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "scaled-hpa", Namespace: "ns1"},
+			Spec: autoscaling.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscaling.CrossVersionObjectReference{Kind: "Deployment", Name: "scaled"},
+				Metrics: []autoscaling.MetricSpec{
+					{
+						Type: autoscaling.ResourceMetricSourceType,
+						Resource: &autoscaling.ResourceMetricSource{
+							Name:                     v1.ResourceCPU,
+							TargetAverageUtilization: &utilization,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	recs := ComputeRequestSizingRecommendations(as, cpuSamples, ramSamples, oomKilled, hpas, opts)
+	if len(recs) != 3 {
+		t.Fatalf("expected 3 recommendations; got %d", len(recs))
+	}
+
+	byController := map[string]*RequestSizingRecommendation{}
+	for _, rec := range recs {
+		byController[rec.Controller] = rec
+	}
+
+	web := byController["web"]
+	if web == nil {
+		t.Fatalf("expected recommendation for \"web\"")
+	}
+	if web.Pods != 2 || web.Profile.CPUSamples != 100 {
+		t.Fatalf("expected 2 pods and 100 samples; got %d pods and %d samples", web.Pods, web.Profile.CPUSamples)
+	}
+
+	expCPU := 0.9802 * 1.10
+	if math.Abs(web.RecommendedCPUCoreRequest-expCPU) > 0.0001 {
+		t.Fatalf("expected recommended CPU of %f; got %f", expCPU, web.RecommendedCPUCoreRequest)
+	}
+	expRAM := 0.9802 * 1.10 * gib
+	if math.Abs(web.RecommendedRAMBytesRequest-expRAM) > 1.0 {
+		t.Fatalf("expected recommended RAM of %f; got %f", expRAM, web.RecommendedRAMBytesRequest)
+	}
+
+	// Two replicas at 1 core and 2 GiB each, priced at 0.04/core-hr and
+	// 0.005/GiB-hr
+	expCurrentCost := 2.0 * (1.0*0.04 + 2.0*0.005) * timeutil.HoursPerMonth
+	if math.Abs(web.CurrentMonthlyCost-expCurrentCost) > 0.0001 {
+		t.Fatalf("expected current monthly cost of %f; got %f", expCurrentCost, web.CurrentMonthlyCost)
+	}
+	expRecCost := 2.0 * (expCPU*0.04 + 0.9802*1.10*0.005) * timeutil.HoursPerMonth
+	if math.Abs(web.RecommendedMonthlyCost-expRecCost) > 0.0001 {
+		t.Fatalf("expected recommended monthly cost of %f; got %f", expRecCost, web.RecommendedMonthlyCost)
+	}
+	if math.Abs(web.MonthlySavings-(expCurrentCost-expRecCost)) > 0.0001 {
+		t.Fatalf("expected monthly savings of %f; got %f", expCurrentCost-expRecCost, web.MonthlySavings)
+	}
+
+	// OOMKilled containers must not have their RAM request lowered
+	oomRec := byController["oom"]
+	if !oomRec.OOMKilled {
+		t.Fatalf("expected \"oom\" to be flagged as OOMKilled")
+	}
+	if oomRec.RecommendedRAMBytesRequest != 1.0*gib {
+		t.Fatalf("expected OOMKilled RAM request to stay at %f; got %f", 1.0*gib, oomRec.RecommendedRAMBytesRequest)
+	}
+
+	scaledRec := byController["scaled"]
+	if len(scaledRec.HPAs) != 1 || scaledRec.HPAs[0] != "scaled-hpa" {
+		t.Fatalf("expected \"scaled\" to be flagged with HPA \"scaled-hpa\"; got %v", scaledRec.HPAs)
+	}
+	if len(web.HPAs) != 0 {
+		t.Fatalf("expected \"web\" to have no HPAs; got %v", web.HPAs)
+	}
+
+	if recs := ComputeRequestSizingRecommendations(nil, nil, nil, nil, nil, opts); len(recs) != 0 {
+		t.Fatalf("expected no recommendations for nil set; got %d", len(recs))
+	}
+}
+
+func TestRequestSizingHandlerInvalidStep(t *testing.T) {
+	a := &Accesses{}
+
+	// 30d at 1m is 43,200 samples per series
+	for _, step := range []string{"0s", "-5m", "1m"} {
+		w := httptest.NewRecorder()
+		a.RequestSizingHandler(w, httptest.NewRequest(http.MethodGet, "/savings/requestSizing?window=30d&step="+step, nil), nil)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("expected status %d for step %s; got %d", http.StatusBadRequest, step, w.Code)
+		}
+	}
+}
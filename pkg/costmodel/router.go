@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"net/http"
+	"os"
 	"reflect"
 	"strconv"
 	"strings"
@@ -20,9 +21,12 @@ import (
 
 	sentry "github.com/getsentry/sentry-go"
 
+	"github.com/kubecost/cost-model/pkg/auth"
 	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/cloud/pricing"
 	"github.com/kubecost/cost-model/pkg/clustercache"
 	cm "github.com/kubecost/cost-model/pkg/clustermanager"
+	"github.com/kubecost/cost-model/pkg/costmodel/budget"
 	"github.com/kubecost/cost-model/pkg/costmodel/clusters"
 	"github.com/kubecost/cost-model/pkg/env"
 	"github.com/kubecost/cost-model/pkg/errors"
@@ -40,8 +44,10 @@ import (
 	"github.com/patrickmn/go-cache"
 
 	"k8s.io/client-go/kubernetes"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
@@ -78,12 +84,35 @@ type Accesses struct {
 	ClusterCostsCache *cache.Cache
 	CacheExpiration   map[time.Duration]time.Duration
 	AggAPI            Aggregator
+	BudgetStore       *budget.BudgetStore
+	BudgetEvaluator   *budget.Evaluator
+	CurrencyConverter *CurrencyConverter
+	MetricChecker     *prom.MetricChecker
+	// AuthMiddleware authenticates API requests, if authentication is enabled
+	AuthMiddleware *auth.Middleware
 	// SettingsCache stores current state of app settings
 	SettingsCache *cache.Cache
 	// settingsSubscribers tracks channels through which changes to different
 	// settings will be published in a pub/sub model
 	settingsSubscribers map[string][]chan string
 	settingsMutex       sync.Mutex
+	// pricingDownload records the outcome of the latest pricing download
+	pricingDownload pricingDownloadStatus
+	// pricingRefresh ensures that only one pricing download runs at a time
+	pricingRefresh pricingRefresher
+	// PricingChanges emits material changes to the pricing data of the
+	// provider each time it is downloaded
+	PricingChanges *PricingChangeNotifier
+	// EnvConfigReloader applies changes to the ConfigMap backing the
+	// environment, if enabled
+	EnvConfigReloader *EnvConfigReloader
+	// EnvValidation is the report of the validation of the environment at
+	// startup
+	EnvValidation *EnvValidationReport
+	// RuntimeSettings are the overrides of settings changed through the API
+	RuntimeSettings *RuntimeSettings
+	// ClusterProfile is the report of the cluster profile resolved at startup
+	ClusterProfile *ClusterProfileReport
 }
 
 // GetPrometheusClient decides whether the default Prometheus client or the Thanos client
@@ -148,6 +177,12 @@ type Response struct {
 	Data    interface{} `json:"data"`
 	Message string      `json:"message,omitempty"`
 	Warning string      `json:"warning,omitempty"`
+	// Currency describes the conversion of costs in Data to a display
+	// currency, if one was requested
+	Currency *CurrencyConversion `json:"currency,omitempty"`
+	// Coverage describes the data from which allocations in Data were
+	// computed, if they were
+	Coverage []*AllocationCoverage `json:"coverage,omitempty"`
 }
 
 // FilterFunc is a filter that returns true iff the given CostData should be filtered out, and the environment that was used as the filter criteria, if it was an aggregate
@@ -317,6 +352,46 @@ func WrapDataWithWarning(data interface{}, err error, warning string) []byte {
 	return resp
 }
 
+// WrapDataWithCurrency wraps the given data, whose costs have been converted
+// by the given CurrencyConversion, annotating the response with the conversion.
+// A nil conversion is omitted.
+func WrapDataWithCurrency(data interface{}, err error, conversion *CurrencyConversion) []byte {
+	var resp []byte
+
+	if err != nil {
+		klog.V(1).Infof("Error returned to client: %s", err.Error())
+		resp, _ = json.Marshal(&Response{
+			Code:    http.StatusInternalServerError,
+			Status:  "error",
+			Message: err.Error(),
+			Data:    data,
+		})
+	} else {
+		resp, _ = json.Marshal(&Response{
+			Code:     http.StatusOK,
+			Status:   "success",
+			Data:     data,
+			Currency: conversion,
+		})
+	}
+
+	return resp
+}
+
+// WrapDataWithCoverage is WrapDataWithCurrency for allocations, along with the
+// coverage of the data from which they were computed.
+func WrapDataWithCoverage(data interface{}, conversion *CurrencyConversion, coverage []*AllocationCoverage) []byte {
+	resp, _ := json.Marshal(&Response{
+		Code:     http.StatusOK,
+		Status:   "success",
+		Data:     data,
+		Currency: conversion,
+		Coverage: coverage,
+	})
+
+	return resp
+}
+
 func WrapDataWithMessageAndWarning(data interface{}, err error, message, warning string) []byte {
 	var resp []byte
 
@@ -347,7 +422,7 @@ func (a *Accesses) RefreshPricingData(w http.ResponseWriter, r *http.Request, ps
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
-	err := a.CloudProvider.DownloadPricingData()
+	err := a.downloadPricingData()
 
 	w.Write(WrapData(nil, err))
 }
@@ -621,7 +696,7 @@ func (a *Accesses) UpdateSpotInfoConfigs(w http.ResponseWriter, r *http.Request,
 		return
 	}
 	w.Write(WrapData(data, err))
-	err = a.CloudProvider.DownloadPricingData()
+	err = a.downloadPricingData()
 	if err != nil {
 		klog.V(1).Infof("Error redownloading data on config update: %s", err.Error())
 	}
@@ -655,7 +730,21 @@ func (a *Accesses) UpdateBigQueryInfoConfigs(w http.ResponseWriter, r *http.Requ
 func (a *Accesses) UpdateConfigByKey(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	data, err := a.CloudProvider.UpdateConfig(r.Body, "")
+	updateType := ""
+	switch r.URL.Query().Get("patchType") {
+	case "":
+	case "merge":
+		if _, ok := a.CloudProvider.(*cloud.CustomProvider); !ok {
+			w.Write(WrapData(nil, fmt.Errorf("patchType merge is only supported by the custom provider")))
+			return
+		}
+		updateType = cloud.MergePatchUpdateType
+	default:
+		w.Write(WrapData(nil, fmt.Errorf("unsupported patchType: %s", r.URL.Query().Get("patchType"))))
+		return
+	}
+
+	data, err := a.CloudProvider.UpdateConfig(r.Body, updateType)
 	if err != nil {
 		w.Write(WrapData(data, err))
 		return
@@ -695,6 +784,55 @@ func (a *Accesses) GetClusterInfoMap(w http.ResponseWriter, r *http.Request, ps
 	w.Write(WrapData(data, nil))
 }
 
+// WatchClusterInfoMap streams the changes to the cluster map as server-sent events, each
+// a JSON encoded ClusterMapEvent, until the client disconnects. The optional "since"
+// parameter, an RFC3339 time, e.g. of the last event received by a reconnecting client,
+// catches the client up with the current entries if the map has changed since.
+func (a *Accesses) WatchClusterInfoMap(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	var since time.Time
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		var err error
+		since, err = time.Parse(time.RFC3339, sinceStr)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			WriteError(w, BadRequest(fmt.Sprintf("error parsing since (%s): %s", sinceStr, err)))
+			return
+		}
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		w.Header().Set("Content-Type", "application/json")
+		WriteError(w, InternalServerError("streaming is not supported"))
+		return
+	}
+
+	events, err := clusters.NewClusterMapWatcher(a.ClusterMap).Watch(r.Context(), since)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		WriteError(w, InternalServerError(fmt.Sprintf("error watching cluster map: %s", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for event := range events {
+		data, err := json.Marshal(event)
+		if err != nil {
+			log.Warningf("Failed to encode cluster map event: %s", err)
+			continue
+		}
+		fmt.Fprintf(w, "event: change\ndata: %s\n\n", data)
+		flusher.Flush()
+	}
+}
+
 func (a *Accesses) GetServiceAccountStatus(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -911,6 +1049,25 @@ func (a *Accesses) GetPrometheusMetrics(w http.ResponseWriter, _ *http.Request,
 	w.Write(WrapData(result, nil))
 }
 
+// GetMetricChecks reports whether each metric family required by the cost model
+// is present in Prometheus and, if not, its likely misconfiguration. Reports are
+// cached for a minute.
+func (a *Accesses) GetMetricChecks(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	w.Write(WrapData(a.MetricChecker.Check(), nil))
+}
+
+// GetAllocationCoverage returns the coverage of the data from which the most
+// recently computed allocation windows were computed, most recent first.
+func (a *Accesses) GetAllocationCoverage(w http.ResponseWriter, _ *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+
+	w.Write(WrapData(a.Model.CoverageHistory.Entries(), nil))
+}
+
 // Creates a new ClusterManager instance using a boltdb storage. If that fails,
 // then we fall back to a memory-only storage.
 func newClusterManager() *cm.ClusterManager {
@@ -994,11 +1151,42 @@ func Initialize(additionalConfigWatchers ...ConfigWatchers) *Accesses {
 		}
 	}
 
+	// Validate the environment before it is used, so that misspelled or
+	// malformed settings are reported even if startup fails
+	envValidation := ValidateEnvironment(os.Environ(), env.GetSettings())
+	envValidation.Log()
+
+	// Apply settings changed at runtime by a previous run, before any are read
+	runtimeSettings := NewRuntimeSettings(env.GetConfigPathWithDefault("/models/") + runtimeSettingsFile)
+	if err := runtimeSettings.Load(); err != nil {
+		klog.Warningf("Failed to load runtime settings: %s", err)
+	}
+
 	address := env.GetPrometheusServerEndpoint()
 	if address == "" {
 		klog.Fatalf("No address for prometheus set in $%s. Aborting.", env.PrometheusServerEndpointEnvVar)
 	}
 
+	// Kubernetes API setup
+	var kc *rest.Config
+	if kubeconfig := env.GetKubeConfigPath(); kubeconfig != "" {
+		kc, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
+	} else {
+		kc, err = rest.InClusterConfig()
+	}
+
+	if err != nil {
+		panic(err.Error())
+	}
+	kubeClientset, err := kubernetes.NewForConfig(kc)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	// Credentials may reference Secrets, so the client is required before any
+	// credentials are read
+	env.SetSecretReader(newSecretReader(kubeClientset))
+
 	queryConcurrency := env.GetMaxQueryConcurrency()
 	klog.Infof("Prometheus/Thanos Client Max Concurrency set to %d", queryConcurrency)
 
@@ -1057,31 +1245,32 @@ func Initialize(additionalConfigWatchers ...ConfigWatchers) *Accesses {
 		klog.V(1).Info("Success: retrieved the 'up' query against prometheus at: " + address)
 	}
 
-	// Kubernetes API setup
-	var kc *rest.Config
-	if kubeconfig := env.GetKubeConfigPath(); kubeconfig != "" {
-		kc, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-	} else {
-		kc, err = rest.InClusterConfig()
-	}
-
-	if err != nil {
-		panic(err.Error())
-	}
-	kubeClientset, err := kubernetes.NewForConfig(kc)
-	if err != nil {
-		panic(err.Error())
-	}
-
 	// Create Kubernetes Cluster Cache + Watchers
 	k8sCache := clustercache.NewKubernetesClusterCache(kubeClientset)
 	k8sCache.Run()
 
+	// Watch the ConfigMap backing the environment, if configured, so that its
+	// settings are applied without a restart
+	var envConfigReloader *EnvConfigReloader
+	if name := env.GetEnvConfigMapName(); name != "" {
+		envConfigReloader = NewEnvConfigReloader(name)
+		additionalConfigWatchers = append(additionalConfigWatchers, ConfigWatchers{
+			ConfigmapName: name,
+			WatchFunc:     envConfigReloader.WatchFunc,
+		})
+	}
+
 	cloudProviderKey := env.GetCloudProviderAPIKey()
 	cloudProvider, err := cloud.NewProvider(k8sCache, cloudProviderKey)
 	if err != nil {
 		panic(err.Error())
 	}
+	pricing.InstallBlendedPricing(cloudProvider)
+
+	// Apply the defaults of the cluster profile before the settings they
+	// change are read
+	clusterProfileReport := applyClusterProfile(cloudProvider)
+	clusterProfileReport.Log()
 
 	watchConfigFunc := func(c interface{}) {
 		conf := c.(*v1.ConfigMap)
@@ -1148,7 +1337,10 @@ func Initialize(additionalConfigWatchers ...ConfigWatchers) *Accesses {
 		thanosAddress := thanos.QueryURL()
 
 		if thanosAddress != "" {
-			thanosCli, _ := thanos.NewThanosClient(thanosAddress, timeout, keepAlive, queryConcurrency, env.GetQueryLoggingFile())
+			thanosCli, err := thanos.NewThanosClient(thanosAddress, timeout, keepAlive, queryConcurrency, env.GetQueryLoggingFile())
+			if err != nil {
+				klog.Fatalf("Failed to create thanos client, Error: %v", err)
+			}
 
 			_, err = prom.Validate(thanosCli)
 			if err != nil {
@@ -1168,10 +1360,38 @@ func Initialize(additionalConfigWatchers ...ConfigWatchers) *Accesses {
 	// Initialize ClusterMap for maintaining ClusterInfo by ClusterID
 	var clusterMap clusters.ClusterMap
 	localCIProvider := NewLocalClusterInfoProvider(kubeClientset, cloudProvider)
+	clusterMapOpts := &clusters.ClusterMapOpts{
+		ClusterInfoMetricName: env.GetClusterInfoMetricName(),
+		FieldMapping:          env.GetClusterInfoFieldMapping(),
+		HTTPSDEndpoint:        env.GetClusterMapHTTPSDEndpoint(),
+		EtcdEndpoints:         env.GetClusterMapEtcdEndpoints(),
+		EtcdPrefix:            env.GetClusterMapEtcdPrefix(),
+	}
+	if env.GetPrometheusTLSCertFile() != "" {
+		// The HTTP SD and etcd endpoints are reached with the client certificate
+		// presented to Prometheus
+		clusterMapOpts.TLSConfig, err = prom.NewClientTLSConfig()
+		if err != nil {
+			klog.Fatalf("Failed to load Prometheus TLS configuration, Error: %v", err)
+		}
+	}
+	clusterMapRefresh := 5 * time.Minute
 	if thanosClient != nil {
-		clusterMap = clusters.NewClusterMap(thanosClient, localCIProvider, 10*time.Minute)
+		clusterMapRefresh = 10 * time.Minute
+		clusterMap = clusters.NewClusterMap(thanosClient, localCIProvider, env.GetClusterMapRefreshInterval(clusterMapRefresh), clusterMapOpts)
 	} else {
-		clusterMap = clusters.NewClusterMap(promCli, localCIProvider, 5*time.Minute)
+		clusterMap = clusters.NewClusterMap(promCli, localCIProvider, env.GetClusterMapRefreshInterval(clusterMapRefresh), clusterMapOpts)
+	}
+
+	// Emit Kubernetes Events on the kubecost namespace as clusters are added or removed
+	if env.IsClusterMapEventsEnabled() {
+		broadcaster := record.NewBroadcaster()
+		broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClientset.CoreV1().Events("")})
+		clusters.NewClusterWatcher(clusterMap, broadcaster, &v1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Namespace",
+			Name:       kubecostNamespace,
+		})
 	}
 
 	// cache responses from model and aggregation for a default of 10 minutes;
@@ -1218,21 +1438,52 @@ func Initialize(additionalConfigWatchers ...ConfigWatchers) *Accesses {
 		OutOfClusterCache: outOfClusterCache,
 		SettingsCache:     settingsCache,
 		CacheExpiration:   cacheExpiration,
-	}
+		CurrencyConverter: NewCurrencyConverter(cloudProvider),
+		MetricChecker:     prom.NewMetricChecker(promCli, prom.DefaultMetricChecks(), prom.DefaultMetricCheckWindow, ""),
+		EnvConfigReloader: envConfigReloader,
+		EnvValidation:     envValidation,
+		RuntimeSettings:   runtimeSettings,
+		ClusterProfile:    clusterProfileReport,
+		PricingChanges:    NewPricingChangeNotifier(env.GetPricingChangeThresholdPercent(), env.GetPricingChangeWebhookURL()),
+	}
+	a.registerReloadListeners(clusterMapRefresh)
+	registerFeatureFlagMetric()
+
+	// Apply credentials resolved since the clients were created, and rotated
+	// credentials from then on
+	env.RefreshCredentials()
+	go refreshCredentials(credentialRefreshInterval)
+
 	// Use the Accesses instance, itself, as the CostModelAggregator. This is
 	// confusing and unconventional, but necessary so that we can swap it
 	// out for the ETL-adapted version elsewhere.
 	// TODO clean this up once ETL is open-sourced.
 	a.AggAPI = a
 
+	if env.IsAuthEnabled() {
+		authMiddleware, err := newAuthMiddleware()
+		if err != nil {
+			klog.Fatalf("Failed to initialize API authentication: %s", err)
+		}
+		a.AuthMiddleware = authMiddleware
+		klog.Infof("Init: API authentication enabled")
+	}
+
 	// Initialize mechanism for subscribing to settings changes
 	a.InitializeSettingsPubSub()
 
-	err = a.CloudProvider.DownloadPricingData()
+	err = a.downloadPricingData()
 	if err != nil {
 		klog.V(1).Info("Failed to download pricing data: " + err.Error())
 	}
 
+	a.validatePricing()
+
+	go a.refreshPricingData()
+
+	// Log the effective configuration, with secrets redacted, for support
+	DumpConfig(a.CloudProvider).Log()
+
 	// Warm the aggregate cache unless explicitly set to false
 	if env.IsCacheWarmingEnabled() {
 		log.Infof("Init: AggregateCostModel cache warming enabled")
@@ -1243,15 +1494,30 @@ func Initialize(additionalConfigWatchers ...ConfigWatchers) *Accesses {
 
 	a.MetricsEmitter.Start()
 
+	if env.IsAllocationCostMetricsEnabled() {
+		log.Infof("Init: allocation cost metrics enabled")
+		NewAllocationCostRecorder(a.CloudProvider, a.Model).Start()
+	}
+
+	a.initBudgets()
+
 	managerEndpoints := cm.NewClusterManagerEndpoints(a.ClusterManager)
 
 	a.Router.GET("/costDataModel", a.CostDataModel)
 	a.Router.GET("/costDataModelRange", a.CostDataModelRange)
 	a.Router.GET("/aggregatedCostModel", a.AggregateCostModelHandler)
 	a.Router.GET("/allocation/compute", a.ComputeAllocationHandler)
+	a.Router.GET("/allocation/compare", a.CompareAllocationsHandler)
+	a.Router.GET("/allocation/trends", a.CostTrendsHandler)
+	a.Router.GET("/config/env", a.EnvConfigHandler)
+	a.Router.GET("/config/dump", a.GetConfigDump)
+	a.Router.GET("/settings", a.GetRuntimeSettings)
+	a.Router.GET("/flags", a.GetFeatureFlags)
+	a.Router.POST("/settings", a.UpdateRuntimeSettings)
 	a.Router.GET("/outOfClusterCosts", a.OutOfClusterCostsWithCache)
 	a.Router.GET("/allNodePricing", a.GetAllNodePricing)
 	a.Router.POST("/refreshPricing", a.RefreshPricingData)
+	a.Router.POST("/pricing/refresh", a.RefreshPricing)
 	a.Router.GET("/clusterCostsOverTime", a.ClusterCostsOverTime)
 	a.Router.GET("/clusterCosts", a.ClusterCosts)
 	a.Router.GET("/clusterCostsFromCache", a.ClusterCostsFromCacheHandler)
@@ -1259,9 +1525,38 @@ func Initialize(additionalConfigWatchers ...ConfigWatchers) *Accesses {
 	a.Router.GET("/managementPlatform", a.ManagementPlatform)
 	a.Router.GET("/clusterInfo", a.ClusterInfo)
 	a.Router.GET("/clusterInfoMap", a.GetClusterInfoMap)
+	a.Router.GET("/clusterInfoMap/watch", a.WatchClusterInfoMap)
 	a.Router.GET("/serviceAccountStatus", a.GetServiceAccountStatus)
 	a.Router.GET("/pricingSourceStatus", a.GetPricingSourceStatus)
 	a.Router.GET("/pricingSourceCounts", a.GetPricingSourceCounts)
+	a.Router.GET("/readyz", a.ReadinessHandler)
+
+	// savings
+	a.Router.GET("/savings/abandonedWorkloads", a.AbandonedWorkloadsHandler)
+	a.Router.GET("/savings/requestSizing", a.RequestSizingHandler)
+	a.Router.GET("/savings/clusterSizing", a.ClusterSizingHandler)
+	a.Router.GET("/savings/spotReadiness", a.SpotReadinessHandler)
+	a.Router.GET("/savings/nodeSchedule", a.ScheduleSavingsHandler)
+	a.Router.GET("/savings/storage", a.StorageSavingsHandler)
+
+	// node utilization
+	a.Router.GET("/nodeUtilization", a.NodeUtilizationHandler)
+
+	// assets
+	a.Router.GET("/assets", a.AssetsHandler)
+
+	// what-if
+	a.Router.POST("/whatIf", a.WhatIfHandler)
+
+	// budgets
+	a.Router.GET("/budgets", a.GetBudgetsHandler)
+	a.Router.PUT("/budgets", a.PutBudgetHandler)
+	a.Router.GET("/budgets/:id", a.GetBudgetHandler)
+	a.Router.DELETE("/budgets/:id", a.DeleteBudgetHandler)
+	a.Router.GET("/budgetStatus", a.BudgetStatusHandler)
+
+	// forecasting
+	a.Router.GET("/forecast/monthEnd", a.ForecastHandler)
 
 	// prom query proxies
 	a.Router.GET("/prometheusQuery", a.PrometheusQuery)
@@ -1272,6 +1567,10 @@ func Initialize(additionalConfigWatchers ...ConfigWatchers) *Accesses {
 	// diagnostics
 	a.Router.GET("/diagnostics/requestQueue", a.GetPrometheusQueueState)
 	a.Router.GET("/diagnostics/prometheusMetrics", a.GetPrometheusMetrics)
+	a.Router.GET("/diagnostics/metricChecks", a.GetMetricChecks)
+	a.Router.GET("/diagnostics/allocationCoverage", a.GetAllocationCoverage)
+	a.Router.GET("/diagnostics/env", a.GetEnvValidation)
+	a.Router.GET("/diagnostics/clusterProfile", a.GetClusterProfile)
 
 	// cluster manager endpoints
 	a.Router.GET("/clusters", managerEndpoints.GetAllClusters)
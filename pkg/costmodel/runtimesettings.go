@@ -0,0 +1,292 @@
+package costmodel
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/util/atomicfile"
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+// runtimeSettingsFile is the file, in the config path, in which the runtime
+// settings are persisted
+const runtimeSettingsFile = "runtime-settings.json"
+
+// runtimeSettingValidators are the settings which may be changed at runtime,
+// and the validator of the value of each.
+var runtimeSettingValidators = map[string]func(value string) error{
+	env.ThanosOffsetEnvVar:                     validatePositiveDuration(time.Second),
+	env.ThanosMaxSourceResEnvVar:               validateThanosResolution,
+	env.MaxQueryConcurrencyEnvVar:              validatePositiveInt,
+	env.ClusterMapRefreshIntervalMinutesEnvVar: validatePositiveDuration(time.Minute),
+	env.EmitPodAnnotationsMetricEnvVar:         validateBool,
+	env.EmitNamespaceAnnotationsMetricEnvVar:   validateBool,
+	env.PodAnnotationsMetricFlag.EnvVar:        validateBool,
+	env.NamespaceAnnotationsMetricFlag.EnvVar:  validateBool,
+	env.EmitIngressMetricsEnvVar:               validateBool,
+}
+
+// RuntimeSetting is the state of a setting which may be changed at runtime.
+type RuntimeSetting struct {
+	// Value is the value in effect
+	Value string `json:"value"`
+
+	// Overridden is true if Value is set at runtime, rather than by the
+	// environment
+	Overridden bool `json:"overridden"`
+
+	// RequiresRestart is true if changes to the setting take effect on the
+	// next restart
+	RequiresRestart bool `json:"requiresRestart"`
+}
+
+// RuntimeSettings are overrides of settings which are changed at runtime,
+// e.g. by an operator through the API, and persisted in the config path so
+// that they survive restarts. Overrides take precedence over the
+// environment, and are applied via the env reload listeners.
+type RuntimeSettings struct {
+	lock sync.Mutex
+	path string
+}
+
+// NewRuntimeSettings creates RuntimeSettings persisted at the given path.
+func NewRuntimeSettings(path string) *RuntimeSettings {
+	return &RuntimeSettings{
+		path: path,
+	}
+}
+
+// Load applies the overrides persisted by a previous run. A missing file is
+// not an error, as no settings have been changed.
+func (rs *RuntimeSettings) Load() error {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+
+	var persisted map[string]string
+	err := atomicfile.ReadJSON(rs.path, &persisted)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error reading runtime settings file '%s': %s", rs.path, err)
+	}
+
+	// Persisted settings are validated again, in case the whitelist or
+	// validators have changed since they were set
+	values := map[string]*string{}
+	for key, value := range persisted {
+		if err := validateRuntimeSetting(key, value); err != nil {
+			log.Warningf("Ignoring persisted runtime setting: %s", err)
+			continue
+		}
+		v := value
+		values[key] = &v
+	}
+
+	changed := env.SetOverrides(values)
+	if len(changed) > 0 {
+		log.Infof("Applied persisted runtime settings: %s", strings.Join(changed, ", "))
+	}
+
+	return nil
+}
+
+// Update validates and applies the given changes, persists them, and logs the
+// change for audit, attributed to actor. A nil value removes the override,
+// so that the setting falls back to the environment. No change is applied if
+// any is invalid.
+func (rs *RuntimeSettings) Update(changes map[string]*string, actor string) ([]string, error) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+
+	err := ValidateRuntimeSettingChanges(changes)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := env.GetOverrides()
+
+	persisted := make(map[string]string, len(previous))
+	for key, value := range previous {
+		persisted[key] = value
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(persisted, key)
+		} else {
+			persisted[key] = *value
+		}
+	}
+
+	err = atomicfile.WriteJSON(rs.path, persisted, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("error writing runtime settings file '%s': %s", rs.path, err)
+	}
+
+	changed := env.SetOverrides(changes)
+	for _, key := range changed {
+		from, to := "<unset>", "<unset>"
+		if value, ok := previous[key]; ok {
+			from = strconv.Quote(value)
+		}
+		if value := changes[key]; value != nil {
+			to = strconv.Quote(*value)
+		}
+		log.Infof("Runtime setting %s changed from %s to %s by %s at %s", key, from, to, actor, time.Now().UTC().Format(time.RFC3339))
+	}
+
+	return changed, nil
+}
+
+// Settings returns the state of each setting which may be changed at runtime
+func (rs *RuntimeSettings) Settings() map[string]*RuntimeSetting {
+	overrides := env.GetOverrides()
+
+	settings := make(map[string]*RuntimeSetting, len(runtimeSettingValidators))
+	for key := range runtimeSettingValidators {
+		_, overridden := overrides[key]
+		settings[key] = &RuntimeSetting{
+			Value:           env.Get(key, ""),
+			Overridden:      overridden,
+			RequiresRestart: env.RequiresRestart(key),
+		}
+	}
+	return settings
+}
+
+// ValidateRuntimeSettingChanges returns an error describing each change which
+// is to a setting which may not be changed at runtime, or to an invalid value.
+func ValidateRuntimeSettingChanges(changes map[string]*string) error {
+	errs := []string{}
+	for key, value := range changes {
+		if value == nil {
+			if _, ok := runtimeSettingValidators[key]; !ok {
+				errs = append(errs, fmt.Sprintf("%s cannot be changed at runtime", key))
+			}
+			continue
+		}
+		if err := validateRuntimeSetting(key, *value); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		sort.Strings(errs)
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// validateRuntimeSetting returns an error if the setting may not be changed at
+// runtime, or if the value is invalid
+func validateRuntimeSetting(key, value string) error {
+	validate, ok := runtimeSettingValidators[key]
+	if !ok {
+		return fmt.Errorf("%s cannot be changed at runtime", key)
+	}
+	if err := validate(value); err != nil {
+		return fmt.Errorf("invalid value for %s: %s", key, err)
+	}
+	return nil
+}
+
+func validateBool(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return fmt.Errorf("'%s' is not a boolean", value)
+	}
+	return nil
+}
+
+func validatePositiveInt(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("'%s' is not an integer", value)
+	}
+	if n <= 0 {
+		return fmt.Errorf("'%s' is not positive", value)
+	}
+	return nil
+}
+
+// validatePositiveDuration returns a validator of positive durations, in
+// which a bare number is interpreted in the given unit
+func validatePositiveDuration(bareUnit time.Duration) func(string) error {
+	return func(value string) error {
+		d, err := env.ParseDuration(value, bareUnit)
+		if err != nil {
+			return err
+		}
+		if d <= 0 {
+			return fmt.Errorf("'%s' is not positive", value)
+		}
+		return nil
+	}
+}
+
+// validateThanosResolution validates a Thanos max source resolution, which is
+// "raw", "5m", or "1h"
+func validateThanosResolution(value string) error {
+	switch value {
+	case "raw", "0s", "5m", "1h":
+		return nil
+	}
+	return fmt.Errorf("'%s' is not one of raw, 5m, 1h", value)
+}
+
+// requestActor describes the client of the request, for audit logs
+func requestActor(r *http.Request) string {
+	actor := r.RemoteAddr
+	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+		actor = fmt.Sprintf("%s (forwarded for %s)", actor, forwarded)
+	}
+	if ua := r.UserAgent(); ua != "" {
+		actor = fmt.Sprintf("%s [%s]", actor, ua)
+	}
+	return actor
+}
+
+// GetRuntimeSettings returns the state of each setting which may be changed
+// at runtime.
+func (a *Accesses) GetRuntimeSettings(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	w.Write(WrapData(a.RuntimeSettings.Settings(), nil))
+}
+
+// UpdateRuntimeSettings applies the changes to settings in the request body,
+// a JSON object of setting to value, in which null removes the override of
+// the setting. No change is applied if any is invalid.
+func (a *Accesses) UpdateRuntimeSettings(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var changes map[string]*string
+	err := json.NewDecoder(r.Body).Decode(&changes)
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("invalid settings: %s", err)))
+		return
+	}
+
+	err = ValidateRuntimeSettingChanges(changes)
+	if err != nil {
+		WriteError(w, BadRequest(err.Error()))
+		return
+	}
+
+	_, err = a.RuntimeSettings.Update(changes, requestActor(r))
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	w.Write(WrapData(a.RuntimeSettings.Settings(), nil))
+}
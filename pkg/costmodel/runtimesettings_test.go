@@ -0,0 +1,142 @@
+package costmodel
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubecost/cost-model/pkg/env"
+)
+
+func newTestRuntimeSettings(t *testing.T) (*RuntimeSettings, string) {
+	dir, err := ioutil.TempDir("", "runtime-settings")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+
+		// Remove any overrides set by the test
+		cleared := map[string]*string{}
+		for key := range env.GetOverrides() {
+			cleared[key] = nil
+		}
+		env.SetOverrides(cleared)
+	})
+
+	path := filepath.Join(dir, runtimeSettingsFile)
+	return NewRuntimeSettings(path), path
+}
+
+func TestRuntimeSettings_RejectedKeys(t *testing.T) {
+	rs, path := newTestRuntimeSettings(t)
+
+	cases := map[string]map[string]*string{
+		"not whitelisted": {
+			env.PrometheusServerEndpointEnvVar: toStringPtr("http://other:9090"),
+		},
+		"not whitelisted removal": {
+			env.PrometheusServerEndpointEnvVar: nil,
+		},
+		"invalid value": {
+			env.MaxQueryConcurrencyEnvVar: toStringPtr("-1"),
+		},
+		"valid with invalid": {
+			env.ThanosOffsetEnvVar:  toStringPtr("2h"),
+			env.DBBasicAuthPassword: toStringPtr("secret"),
+		},
+	}
+
+	for name, changes := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := rs.Update(changes, "test")
+			if err == nil {
+				t.Fatalf("expected changes to be rejected")
+			}
+
+			if len(env.GetOverrides()) != 0 {
+				t.Fatalf("expected no overrides; got %v", env.GetOverrides())
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Fatalf("expected nothing to be persisted")
+			}
+		})
+	}
+}
+
+func TestRuntimeSettings_PersistedAcrossRestart(t *testing.T) {
+	rs, path := newTestRuntimeSettings(t)
+
+	os.Setenv(env.ThanosOffsetEnvVar, "3h")
+	defer os.Unsetenv(env.ThanosOffsetEnvVar)
+
+	var notified []string
+	env.RegisterReloadListener(env.ThanosOffsetEnvVar, func(key, value string) {
+		notified = append(notified, value)
+	})
+
+	changed, err := rs.Update(map[string]*string{
+		env.ThanosOffsetEnvVar:        toStringPtr("90m"),
+		env.MaxQueryConcurrencyEnvVar: toStringPtr("10"),
+	}, "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(changed) != 2 {
+		t.Fatalf("expected 2 changed settings; got %v", changed)
+	}
+	if env.GetThanosOffset() != "90m" {
+		t.Fatalf("expected override to take precedence over the environment; got %s", env.GetThanosOffset())
+	}
+	if len(notified) != 1 || notified[0] != "90m" {
+		t.Fatalf("expected listener to be notified of '90m'; got %v", notified)
+	}
+
+	settings := rs.Settings()
+	if s := settings[env.MaxQueryConcurrencyEnvVar]; !s.Overridden || s.Value != "10" || !s.RequiresRestart {
+		t.Fatalf("unexpected state of %s: %+v", env.MaxQueryConcurrencyEnvVar, s)
+	}
+
+	// Simulate a restart by clearing the overrides and loading a new instance
+	env.SetOverrides(map[string]*string{
+		env.ThanosOffsetEnvVar:        nil,
+		env.MaxQueryConcurrencyEnvVar: nil,
+	})
+	if env.GetThanosOffset() != "3h" {
+		t.Fatalf("expected environment value after overrides cleared; got %s", env.GetThanosOffset())
+	}
+
+	err = NewRuntimeSettings(path).Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if env.GetThanosOffset() != "90m" {
+		t.Fatalf("expected persisted override after restart; got %s", env.GetThanosOffset())
+	}
+	if env.GetMaxQueryConcurrency() != 10 {
+		t.Fatalf("expected persisted override after restart; got %d", env.GetMaxQueryConcurrency())
+	}
+
+	// Removing an override falls back to the environment, and is persisted
+	_, err = rs.Update(map[string]*string{env.ThanosOffsetEnvVar: nil}, "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if env.GetThanosOffset() != "3h" {
+		t.Fatalf("expected environment value after override removed; got %s", env.GetThanosOffset())
+	}
+
+	env.SetOverrides(map[string]*string{env.MaxQueryConcurrencyEnvVar: nil})
+	err = NewRuntimeSettings(path).Load()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	overrides := env.GetOverrides()
+	if _, ok := overrides[env.ThanosOffsetEnvVar]; ok {
+		t.Fatalf("expected removed override not to be persisted; got %v", overrides)
+	}
+	if overrides[env.MaxQueryConcurrencyEnvVar] != "10" {
+		t.Fatalf("expected remaining override to be persisted; got %v", overrides)
+	}
+}
@@ -0,0 +1,399 @@
+package costmodel
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/clustercache"
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/util/fileutil"
+	"github.com/kubecost/cost-model/pkg/util/httputil"
+	"github.com/kubecost/cost-model/pkg/util/json"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// SpotReadinessWeightsFile is the name of the file, in the config path, from
+// which spot readiness weights are loaded.
+const SpotReadinessWeightsFile = "spot-readiness.json"
+
+// Spot readiness signal names
+const (
+	SpotSignalReplicas       = "replicas"
+	SpotSignalControllerKind = "controllerKind"
+	SpotSignalDisruption     = "podDisruptionBudget"
+	SpotSignalLocalStorage   = "localStorage"
+	SpotSignalRestarts       = "restartTolerance"
+)
+
+// SpotReadinessWeights are the relative weights of each signal in a
+// controller's spot readiness score.
+type SpotReadinessWeights struct {
+	Replicas            float64 `json:"replicas"`
+	ControllerKind      float64 `json:"controllerKind"`
+	PodDisruptionBudget float64 `json:"podDisruptionBudget"`
+	LocalStorage        float64 `json:"localStorage"`
+	RestartTolerance    float64 `json:"restartTolerance"`
+}
+
+// DefaultSpotReadinessWeights returns the default SpotReadinessWeights.
+func DefaultSpotReadinessWeights() *SpotReadinessWeights {
+	return &SpotReadinessWeights{
+		Replicas:            0.30,
+		ControllerKind:      0.25,
+		PodDisruptionBudget: 0.15,
+		LocalStorage:        0.20,
+		RestartTolerance:    0.10,
+	}
+}
+
+// LoadSpotReadinessWeights returns the weights configured in the config path,
+// falling back to the defaults for a missing file, or any missing weight.
+func LoadSpotReadinessWeights() *SpotReadinessWeights {
+	weights := DefaultSpotReadinessWeights()
+
+	path := env.GetConfigPathWithDefault("/models/") + SpotReadinessWeightsFile
+	exists, err := fileutil.FileExists(path)
+	if err != nil || !exists {
+		return weights
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Warningf("SpotReadiness: failed to read %s: %s", path, err)
+		return weights
+	}
+
+	if err := json.Unmarshal(data, weights); err != nil {
+		log.Warningf("SpotReadiness: failed to parse %s: %s", path, err)
+		return DefaultSpotReadinessWeights()
+	}
+
+	return weights
+}
+
+// SpotReadinessSignals are the observed properties of a controller which
+// determine whether it can safely run on spot nodes.
+type SpotReadinessSignals struct {
+	Namespace        string
+	ControllerKind   string
+	Controller       string
+	Replicas         int
+	HasPDB           bool
+	UsesLocalStorage bool
+	Restarts         int
+	FailingPods      int
+	CPUCoreRequests  float64
+	RAMBytesRequests float64
+}
+
+// SpotReadiness is the spot readiness score of a controller, along with the
+// contribution of each signal and an explanation of each signal which lowered
+// the score.
+type SpotReadiness struct {
+	Namespace      string             `json:"namespace"`
+	ControllerKind string             `json:"controllerKind"`
+	Controller     string             `json:"controller"`
+	Replicas       int                `json:"replicas"`
+	Score          float64            `json:"score"`
+	Signals        map[string]float64 `json:"signals"`
+	Penalties      []string           `json:"penalties"`
+	MonthlySavings float64            `json:"monthlySavings"`
+}
+
+// SpotPrices are the on-demand and spot prices per CPU core-hour and RAM
+// GiB-hour.
+type SpotPrices struct {
+	CPU     float64
+	RAM     float64
+	SpotCPU float64
+	SpotRAM float64
+}
+
+// spotPricesFromConfig parses the on-demand and spot prices from the
+// provider's custom pricing.
+func spotPricesFromConfig(cfg *cloud.CustomPricing) *SpotPrices {
+	parse := func(s string) float64 {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0.0
+		}
+		return f
+	}
+
+	return &SpotPrices{
+		CPU:     parse(cfg.CPU),
+		RAM:     parse(cfg.RAM),
+		SpotCPU: parse(cfg.SpotCPU),
+		SpotRAM: parse(cfg.SpotRAM),
+	}
+}
+
+// ScoreSpotReadiness scores the given controller on a scale of 0 to 100, where
+// 100 is a controller which can safely run on spot nodes.
+func ScoreSpotReadiness(sig *SpotReadinessSignals, weights *SpotReadinessWeights, prices *SpotPrices) *SpotReadiness {
+	if weights == nil {
+		weights = DefaultSpotReadinessWeights()
+	}
+
+	sr := &SpotReadiness{
+		Namespace:      sig.Namespace,
+		ControllerKind: sig.ControllerKind,
+		Controller:     sig.Controller,
+		Replicas:       sig.Replicas,
+		Signals:        map[string]float64{},
+		Penalties:      []string{},
+	}
+
+	// Each signal is scored from 0.0 (unsafe) to 1.0 (safe)
+	switch {
+	case sig.Replicas >= 3:
+		sr.Signals[SpotSignalReplicas] = 1.0
+	case sig.Replicas == 2:
+		sr.Signals[SpotSignalReplicas] = 0.5
+		sr.Penalties = append(sr.Penalties, "only 2 replicas; losing a node halves capacity")
+	default:
+		sr.Signals[SpotSignalReplicas] = 0.0
+		sr.Penalties = append(sr.Penalties, fmt.Sprintf("%d replica(s); a spot interruption causes an outage", sig.Replicas))
+	}
+
+	switch sig.ControllerKind {
+	case "deployment", "job":
+		sr.Signals[SpotSignalControllerKind] = 1.0
+	case "statefulset":
+		sr.Signals[SpotSignalControllerKind] = 0.0
+		sr.Penalties = append(sr.Penalties, "statefulset pods have stable identities and are slow to reschedule")
+	default:
+		sr.Signals[SpotSignalControllerKind] = 0.5
+		sr.Penalties = append(sr.Penalties, fmt.Sprintf("controller kind %q has unknown interruption behavior", sig.ControllerKind))
+	}
+
+	if sig.HasPDB {
+		sr.Signals[SpotSignalDisruption] = 1.0
+	} else {
+		sr.Signals[SpotSignalDisruption] = 0.0
+		sr.Penalties = append(sr.Penalties, "no PodDisruptionBudget limits concurrent evictions")
+	}
+
+	if sig.UsesLocalStorage {
+		sr.Signals[SpotSignalLocalStorage] = 0.0
+		sr.Penalties = append(sr.Penalties, "uses node-local storage which is lost when the node is reclaimed")
+	} else {
+		sr.Signals[SpotSignalLocalStorage] = 1.0
+	}
+
+	switch {
+	case sig.FailingPods > 0:
+		sr.Signals[SpotSignalRestarts] = 0.0
+		sr.Penalties = append(sr.Penalties, fmt.Sprintf("%d pod(s) are not ready after restarting", sig.FailingPods))
+	case sig.Restarts > 0:
+		sr.Signals[SpotSignalRestarts] = 1.0
+	default:
+		// Without any restarts, there's no evidence either way
+		sr.Signals[SpotSignalRestarts] = 0.5
+		sr.Penalties = append(sr.Penalties, "no restart history to demonstrate restart tolerance")
+	}
+
+	totalWeight := weights.Replicas + weights.ControllerKind + weights.PodDisruptionBudget + weights.LocalStorage + weights.RestartTolerance
+	if totalWeight > 0 {
+		score := weights.Replicas*sr.Signals[SpotSignalReplicas] +
+			weights.ControllerKind*sr.Signals[SpotSignalControllerKind] +
+			weights.PodDisruptionBudget*sr.Signals[SpotSignalDisruption] +
+			weights.LocalStorage*sr.Signals[SpotSignalLocalStorage] +
+			weights.RestartTolerance*sr.Signals[SpotSignalRestarts]
+		sr.Score = 100.0 * score / totalWeight
+	}
+
+	if prices != nil {
+		ramGiB := sig.RAMBytesRequests / 1024.0 / 1024.0 / 1024.0
+		hourly := sig.CPUCoreRequests*(prices.CPU-prices.SpotCPU) + ramGiB*(prices.RAM-prices.SpotRAM)
+		sr.MonthlySavings = hourly * timeutil.HoursPerMonth
+	}
+
+	return sr
+}
+
+// podUsesLocalStorage returns true if the pod mounts a hostPath volume, or a
+// claim bound to a local or hostPath persistent volume.
+func podUsesLocalStorage(pod *v1.Pod, pvcs map[string]*v1.PersistentVolumeClaim, pvs map[string]*v1.PersistentVolume) bool {
+	for _, vol := range pod.Spec.Volumes {
+		if vol.HostPath != nil {
+			return true
+		}
+
+		if vol.PersistentVolumeClaim == nil {
+			continue
+		}
+
+		pvc, ok := pvcs[pod.Namespace+"/"+vol.PersistentVolumeClaim.ClaimName]
+		if !ok {
+			continue
+		}
+
+		pv, ok := pvs[pvc.Spec.VolumeName]
+		if ok && (pv.Spec.Local != nil || pv.Spec.HostPath != nil) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// podIsReady returns true if the pod's Ready condition is true.
+func podIsReady(pod *v1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == v1.PodReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// spotReadinessSignalsFor collects the signals of the pods matching the given
+// selector in the given namespace.
+func spotReadinessSignalsFor(sig *SpotReadinessSignals, selector *metav1.LabelSelector, pods []*v1.Pod, pdbs []labels.Selector, pvcs map[string]*v1.PersistentVolumeClaim, pvs map[string]*v1.PersistentVolume) {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		log.Warningf("SpotReadiness: failed to convert selector for %s/%s: %s", sig.Namespace, sig.Controller, err)
+		return
+	}
+
+	for _, pod := range pods {
+		if pod.Namespace != sig.Namespace || !s.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+
+		for _, pdb := range pdbs {
+			if pdb.Matches(labels.Set(pod.Labels)) {
+				sig.HasPDB = true
+			}
+		}
+
+		if podUsesLocalStorage(pod, pvcs, pvs) {
+			sig.UsesLocalStorage = true
+		}
+
+		restarts := 0
+		for _, cs := range pod.Status.ContainerStatuses {
+			restarts += int(cs.RestartCount)
+		}
+		sig.Restarts += restarts
+		if restarts > 0 && !podIsReady(pod) {
+			sig.FailingPods++
+		}
+
+		for _, c := range pod.Spec.Containers {
+			sig.CPUCoreRequests += float64(c.Resources.Requests.Cpu().MilliValue()) / 1000
+			sig.RAMBytesRequests += float64(c.Resources.Requests.Memory().Value())
+		}
+	}
+}
+
+// ComputeSpotReadinessSignals collects the spot readiness signals of each
+// deployment and statefulset in the cluster cache.
+func ComputeSpotReadinessSignals(cache clustercache.ClusterCache) []*SpotReadinessSignals {
+	pods := cache.GetAllPods()
+
+	pvcs := map[string]*v1.PersistentVolumeClaim{}
+	for _, pvc := range cache.GetAllPersistentVolumeClaims() {
+		pvcs[pvc.Namespace+"/"+pvc.Name] = pvc
+	}
+
+	pvs := map[string]*v1.PersistentVolume{}
+	for _, pv := range cache.GetAllPersistentVolumes() {
+		pvs[pv.Name] = pv
+	}
+
+	pdbsByNamespace := map[string][]labels.Selector{}
+	for _, pdb := range cache.GetAllPodDisruptionBudgets() {
+		s, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+		if err != nil {
+			log.Warningf("SpotReadiness: failed to convert selector for PodDisruptionBudget %s/%s: %s", pdb.Namespace, pdb.Name, err)
+			continue
+		}
+		pdbsByNamespace[pdb.Namespace] = append(pdbsByNamespace[pdb.Namespace], s)
+	}
+
+	signals := []*SpotReadinessSignals{}
+
+	for _, deployment := range cache.GetAllDeployments() {
+		sig := &SpotReadinessSignals{
+			Namespace:      deployment.Namespace,
+			ControllerKind: "deployment",
+			Controller:     deployment.Name,
+			Replicas:       int(deployment.Status.Replicas),
+		}
+		spotReadinessSignalsFor(sig, deployment.Spec.Selector, pods, pdbsByNamespace[sig.Namespace], pvcs, pvs)
+		signals = append(signals, sig)
+	}
+
+	for _, statefulset := range cache.GetAllStatefulSets() {
+		sig := &SpotReadinessSignals{
+			Namespace:      statefulset.Namespace,
+			ControllerKind: "statefulset",
+			Controller:     statefulset.Name,
+			Replicas:       int(statefulset.Status.Replicas),
+		}
+		spotReadinessSignalsFor(sig, statefulset.Spec.Selector, pods, pdbsByNamespace[sig.Namespace], pvcs, pvs)
+		signals = append(signals, sig)
+	}
+
+	return signals
+}
+
+// SpotReadinessHandler scores each deployment and statefulset on whether it
+// can safely run on spot nodes.
+func (a *Accesses) SpotReadinessHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	qp := httputil.NewQueryParams(r.URL.Query())
+
+	conversion, err := a.currencyConversion(qp)
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'currency' parameter: %s", err)))
+		return
+	}
+
+	weights := LoadSpotReadinessWeights()
+	weights.Replicas = qp.GetFloat64("replicasWeight", weights.Replicas)
+	weights.ControllerKind = qp.GetFloat64("controllerKindWeight", weights.ControllerKind)
+	weights.PodDisruptionBudget = qp.GetFloat64("pdbWeight", weights.PodDisruptionBudget)
+	weights.LocalStorage = qp.GetFloat64("localStorageWeight", weights.LocalStorage)
+	weights.RestartTolerance = qp.GetFloat64("restartWeight", weights.RestartTolerance)
+
+	if weights.Replicas < 0 || weights.ControllerKind < 0 || weights.PodDisruptionBudget < 0 || weights.LocalStorage < 0 || weights.RestartTolerance < 0 {
+		WriteError(w, BadRequest("weights must be non-negative"))
+		return
+	}
+
+	cfg, err := a.CloudProvider.GetConfig()
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+	prices := spotPricesFromConfig(cfg)
+
+	results := []*SpotReadiness{}
+	for _, sig := range ComputeSpotReadinessSignals(a.Model.Cache) {
+		results = append(results, ScoreSpotReadiness(sig, weights, prices))
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].MonthlySavings > results[j].MonthlySavings
+		}
+		return results[i].Score > results[j].Score
+	})
+
+	writeWithCurrency(w, results, conversion)
+}
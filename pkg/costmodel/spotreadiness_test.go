@@ -0,0 +1,110 @@
+package costmodel
+
+import (
+	"math"
+	"testing"
+
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestScoreSpotReadiness(t *testing.T) {
+	weights := DefaultSpotReadinessWeights()
+	prices := &SpotPrices{CPU: 0.04, RAM: 0.005, SpotCPU: 0.01, SpotRAM: 0.001}
+
+	stateful := ScoreSpotReadiness(&SpotReadinessSignals{
+		Namespace:        "db",
+		ControllerKind:   "statefulset",
+		Controller:       "postgres",
+		Replicas:         1,
+		UsesLocalStorage: true,
+		CPUCoreRequests:  2.0,
+		RAMBytesRequests: 8.0 * 1024.0 * 1024.0 * 1024.0,
+	}, weights, prices)
+
+	stateless := ScoreSpotReadiness(&SpotReadinessSignals{
+		Namespace:        "web",
+		ControllerKind:   "deployment",
+		Controller:       "frontend",
+		Replicas:         10,
+		HasPDB:           true,
+		Restarts:         4,
+		CPUCoreRequests:  5.0,
+		RAMBytesRequests: 10.0 * 1024.0 * 1024.0 * 1024.0,
+	}, weights, prices)
+
+	// Only the unknown restart tolerance contributes to the stateful score
+	expStateful := 100.0 * weights.RestartTolerance * 0.5
+	if math.Abs(stateful.Score-expStateful) > 0.0001 {
+		t.Fatalf("expected stateful score of %f; got %f", expStateful, stateful.Score)
+	}
+	if stateless.Score != 100.0 {
+		t.Fatalf("expected stateless score of 100; got %f", stateless.Score)
+	}
+
+	for _, signal := range []string{SpotSignalReplicas, SpotSignalControllerKind, SpotSignalDisruption, SpotSignalLocalStorage} {
+		if stateful.Signals[signal] != 0.0 {
+			t.Fatalf("expected stateful signal %s to be 0.0; got %f", signal, stateful.Signals[signal])
+		}
+	}
+	if len(stateful.Penalties) != 5 {
+		t.Fatalf("expected 5 penalties for stateful workload; got %d: %v", len(stateful.Penalties), stateful.Penalties)
+	}
+	if len(stateless.Penalties) != 0 {
+		t.Fatalf("expected no penalties for stateless workload; got %v", stateless.Penalties)
+	}
+
+	expSavings := (5.0*(0.04-0.01) + 10.0*(0.005-0.001)) * timeutil.HoursPerMonth
+	if math.Abs(stateless.MonthlySavings-expSavings) > 0.0001 {
+		t.Fatalf("expected monthly savings of %f; got %f", expSavings, stateless.MonthlySavings)
+	}
+
+	// Weights are tunable: ignoring everything but the replica count scores
+	// the stateless workload 100 and the stateful workload 0
+	replicasOnly := &SpotReadinessWeights{Replicas: 1.0}
+	if s := ScoreSpotReadiness(&SpotReadinessSignals{ControllerKind: "statefulset", Replicas: 1}, replicasOnly, nil); s.Score != 0.0 {
+		t.Fatalf("expected score of 0 with replicas-only weights; got %f", s.Score)
+	}
+	if s := ScoreSpotReadiness(&SpotReadinessSignals{ControllerKind: "statefulset", Replicas: 10}, replicasOnly, nil); s.Score != 100.0 {
+		t.Fatalf("expected score of 100 with replicas-only weights; got %f", s.Score)
+	}
+}
+
+func TestPodUsesLocalStorage(t *testing.T) {
+	pvcs := map[string]*v1.PersistentVolumeClaim{
+		"ns1/local-claim":  {Spec: v1.PersistentVolumeClaimSpec{VolumeName: "local-pv"}},
+		"ns1/remote-claim": {Spec: v1.PersistentVolumeClaimSpec{VolumeName: "remote-pv"}},
+	}
+	pvs := map[string]*v1.PersistentVolume{
+		"local-pv": {Spec: v1.PersistentVolumeSpec{PersistentVolumeSource: v1.PersistentVolumeSource{Local: &v1.LocalVolumeSource{Path: "/mnt/disks/ssd1"}}}},
+		"remote-pv": {Spec: v1.PersistentVolumeSpec{PersistentVolumeSource: v1.PersistentVolumeSource{
+			GCEPersistentDisk: &v1.GCEPersistentDiskVolumeSource{PDName: "disk"},
+		}}},
+	}
+
+	newPod := func(vol v1.VolumeSource) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "ns1"},
+			Spec:       v1.PodSpec{Volumes: []v1.Volume{{Name: "data", VolumeSource: vol}}},
+		}
+	}
+
+	cases := []struct {
+		name     string
+		pod      *v1.Pod
+		expected bool
+	}{
+		{"local pv", newPod(v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "local-claim"}}), true},
+		{"remote pv", newPod(v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "remote-claim"}}), false},
+		{"host path", newPod(v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/var/data"}}), true},
+		{"empty dir", newPod(v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}), false},
+	}
+
+	for _, c := range cases {
+		if actual := podUsesLocalStorage(c.pod, pvcs, pvs); actual != c.expected {
+			t.Fatalf("%s: expected %t; got %t", c.name, c.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package costmodel
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/julienschmidt/httprouter"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/prom"
+	"github.com/kubecost/cost-model/pkg/util"
+	"github.com/kubecost/cost-model/pkg/util/httputil"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+)
+
+const (
+	// DefaultStorageUtilizationThreshold is the fraction of its capacity below
+	// which the peak usage of a bound volume is considered over-provisioned.
+	DefaultStorageUtilizationThreshold = 0.5
+
+	// DefaultStorageHeadroom is the fraction of its peak usage added to the
+	// recommended size of an over-provisioned volume.
+	DefaultStorageHeadroom = 0.2
+
+	// minStorageRecommendationBytes is the smallest size recommended for a
+	// volume
+	minStorageRecommendationBytes = 1024.0 * 1024.0 * 1024.0
+
+	queryFmtPVCUsedBytes = `max(max_over_time(kubelet_volume_stats_used_bytes[%s]%s)) by (persistentvolumeclaim, namespace, %s)`
+)
+
+// The reasons for which a volume is listed as a storage saving
+const (
+	StorageSavingsUnclaimed     = "unclaimed"
+	StorageSavingsUnmounted     = "unmounted"
+	StorageSavingsUnderutilized = "underutilized"
+)
+
+// StorageSavingsOptions configures the threshold and headroom used to find
+// over-provisioned volumes, and the namespaces whose volumes are considered.
+type StorageSavingsOptions struct {
+	UtilizationThreshold float64
+	Headroom             float64
+	Namespaces           []string
+}
+
+// DefaultStorageSavingsOptions returns StorageSavingsOptions with default
+// values set.
+func DefaultStorageSavingsOptions() *StorageSavingsOptions {
+	return &StorageSavingsOptions{
+		UtilizationThreshold: DefaultStorageUtilizationThreshold,
+		Headroom:             DefaultStorageHeadroom,
+		Namespaces:           []string{},
+	}
+}
+
+// includesNamespace returns true if no namespaces are configured, or the
+// given namespace is one of them.
+func (opts *StorageSavingsOptions) includesNamespace(namespace string) bool {
+	if len(opts.Namespaces) == 0 {
+		return true
+	}
+
+	for _, ns := range opts.Namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+
+	return false
+}
+
+// StorageSavingsVolume is a persistent volume which costs more than it needs
+// to, because it is unclaimed, unmounted, or larger than its usage.
+type StorageSavingsVolume struct {
+	Volume        string  `json:"volume"`
+	Namespace     string  `json:"namespace,omitempty"`
+	Claim         string  `json:"claim,omitempty"`
+	StorageClass  string  `json:"storageClass"`
+	Phase         string  `json:"phase"`
+	Reason        string  `json:"reason"`
+	CapacityBytes float64 `json:"capacityBytes"`
+	MonthlyCost   float64 `json:"monthlyCost"`
+
+	// UsedBytes is the peak usage of the volume over the window, and is only
+	// set for volumes whose usage was reported by the kubelet
+	UsedBytes   *float64 `json:"usedBytes,omitempty"`
+	Utilization *float64 `json:"utilization,omitempty"`
+
+	// RecommendedBytes is the size to which an over-provisioned volume may be
+	// reduced. Otherwise, the volume may be removed.
+	RecommendedBytes float64 `json:"recommendedBytes,omitempty"`
+	MonthlySavings   float64 `json:"monthlySavings"`
+
+	// AllowsExpansion is false if the storage class of the volume does not
+	// allow volumes to be expanded, and Note explains how that affects
+	// resizing the volume
+	AllowsExpansion bool   `json:"allowsExpansion"`
+	Note            string `json:"note,omitempty"`
+}
+
+// StorageSavings lists the volumes which are unclaimed, bound but not mounted
+// by any pod, or bound and under-utilized, with the monthly savings of
+// removing or resizing each.
+type StorageSavings struct {
+	Unclaimed           []*StorageSavingsVolume `json:"unclaimed"`
+	Unmounted           []*StorageSavingsVolume `json:"unmounted"`
+	Underutilized       []*StorageSavingsVolume `json:"underutilized"`
+	TotalMonthlySavings float64                 `json:"totalMonthlySavings"`
+}
+
+// mountedClaims returns the set of claims, keyed by namespace/name, which
+// are mounted by a pod which has not terminated, so are attached to a node or
+// about to be.
+func mountedClaims(pods []*v1.Pod) map[string]bool {
+	mounted := map[string]bool{}
+
+	for _, pod := range pods {
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+
+		for _, vol := range pod.Spec.Volumes {
+			if vol.PersistentVolumeClaim != nil {
+				mounted[pod.Namespace+"/"+vol.PersistentVolumeClaim.ClaimName] = true
+			}
+		}
+	}
+
+	return mounted
+}
+
+// FindStorageSavings finds the cluster's volumes which are unclaimed,
+// unmounted, or under-utilized, given the peak used bytes of each claim,
+// keyed by namespace/name, and prices them with the provider.
+func (cm *CostModel) FindStorageSavings(cp cloud.Provider, usedBytes map[string]float64, opts *StorageSavingsOptions) (*StorageSavings, error) {
+	// Pull a region from the first node, as addPVData does
+	var defaultRegion string
+	if nodeList := cm.Cache.GetAllNodes(); len(nodeList) > 0 {
+		defaultRegion, _ = util.GetRegion(nodeList[0].Labels)
+	}
+
+	storageClassParams := map[string]map[string]string{}
+	allowsExpansion := map[string]bool{}
+	for _, sc := range cm.Cache.GetAllStorageClasses() {
+		storageClassParams[sc.Name] = sc.Parameters
+		allowsExpansion[sc.Name] = sc.AllowVolumeExpansion != nil && *sc.AllowVolumeExpansion
+	}
+
+	claims := map[string]*v1.PersistentVolumeClaim{}
+	for _, pvc := range cm.Cache.GetAllPersistentVolumeClaims() {
+		claims[pvc.Namespace+"/"+pvc.Name] = pvc
+	}
+
+	mounted := mountedClaims(cm.Cache.GetAllPods())
+
+	savings := &StorageSavings{
+		Unclaimed:     []*StorageSavingsVolume{},
+		Unmounted:     []*StorageSavingsVolume{},
+		Underutilized: []*StorageSavingsVolume{},
+	}
+
+	for _, kpv := range cm.Cache.GetAllPersistentVolumes() {
+		vol := &StorageSavingsVolume{
+			Volume:          kpv.Name,
+			StorageClass:    kpv.Spec.StorageClassName,
+			Phase:           string(kpv.Status.Phase),
+			AllowsExpansion: allowsExpansion[kpv.Spec.StorageClassName],
+		}
+
+		var claimKey string
+		if claim := kpv.Spec.ClaimRef; claim != nil {
+			vol.Namespace = claim.Namespace
+			vol.Claim = claim.Name
+			claimKey = claim.Namespace + "/" + claim.Name
+		}
+
+		if !opts.includesNamespace(vol.Namespace) {
+			continue
+		}
+
+		// A volume is bound if its claim exists and is bound to it; the claim
+		// of a Released volume has been deleted
+		pvc, bound := claims[claimKey]
+		bound = bound && kpv.Status.Phase == v1.VolumeBound && pvc.Spec.VolumeName == kpv.Name
+
+		switch {
+		case !bound:
+			vol.Reason = StorageSavingsUnclaimed
+		case !mounted[claimKey]:
+			vol.Reason = StorageSavingsUnmounted
+		default:
+			vol.Reason = StorageSavingsUnderutilized
+		}
+
+		// An under-utilized volume is only listed if its usage is known
+		used, hasUsage := usedBytes[claimKey]
+		if vol.Reason == StorageSavingsUnderutilized && !hasUsage {
+			continue
+		}
+
+		if capacity, ok := kpv.Spec.Capacity[v1.ResourceStorage]; ok {
+			vol.CapacityBytes = float64(capacity.Value())
+		}
+
+		if vol.Reason == StorageSavingsUnderutilized {
+			if vol.CapacityBytes <= 0 || used/vol.CapacityBytes >= opts.UtilizationThreshold {
+				continue
+			}
+		}
+
+		region := defaultRegion
+		if r, ok := util.GetRegion(kpv.Labels); ok {
+			region = r
+		}
+		pv := &cloud.PV{
+			Class:      kpv.Spec.StorageClassName,
+			Region:     region,
+			Parameters: storageClassParams[kpv.Spec.StorageClassName],
+		}
+		if _, err := getPVCost(pv, kpv, cp, region); err != nil {
+			return nil, err
+		}
+
+		// PV prices are per GiB-hour
+		gibMonthlyCost := parseNodeFloat(pv.Cost) * timeutil.HoursPerMonth
+		vol.MonthlyCost = gibMonthlyCost * vol.CapacityBytes / 1024 / 1024 / 1024
+
+		if hasUsage {
+			utilization := 0.0
+			if vol.CapacityBytes > 0 {
+				utilization = used / vol.CapacityBytes
+			}
+			vol.UsedBytes = &used
+			vol.Utilization = &utilization
+		}
+
+		switch vol.Reason {
+		case StorageSavingsUnclaimed:
+			vol.MonthlySavings = vol.MonthlyCost
+			savings.Unclaimed = append(savings.Unclaimed, vol)
+		case StorageSavingsUnmounted:
+			vol.MonthlySavings = vol.MonthlyCost
+			savings.Unmounted = append(savings.Unmounted, vol)
+		case StorageSavingsUnderutilized:
+			recommendStorageSize(vol, used, opts.Headroom, gibMonthlyCost)
+			if vol.MonthlySavings <= 0 {
+				continue
+			}
+			savings.Underutilized = append(savings.Underutilized, vol)
+		}
+
+		savings.TotalMonthlySavings += vol.MonthlySavings
+	}
+
+	for _, vols := range [][]*StorageSavingsVolume{savings.Unclaimed, savings.Unmounted, savings.Underutilized} {
+		sort.SliceStable(vols, func(i, j int) bool {
+			if vols[i].MonthlySavings == vols[j].MonthlySavings {
+				return vols[i].Volume < vols[j].Volume
+			}
+			return vols[i].MonthlySavings > vols[j].MonthlySavings
+		})
+	}
+
+	return savings, nil
+}
+
+// recommendStorageSize sets the recommended size of the volume to its peak
+// usage plus headroom, rounded up to the GiB, and the savings of resizing it.
+// Volumes cannot be shrunk in place, so resizing a volume means migrating its
+// data to a new, smaller volume.
+func recommendStorageSize(vol *StorageSavingsVolume, used, headroom, gibMonthlyCost float64) {
+	recommended := math.Max(used*(1.0+headroom), minStorageRecommendationBytes)
+	recommended = math.Ceil(recommended/minStorageRecommendationBytes) * minStorageRecommendationBytes
+	if recommended >= vol.CapacityBytes {
+		return
+	}
+
+	vol.RecommendedBytes = recommended
+	vol.MonthlySavings = gibMonthlyCost * (vol.CapacityBytes - recommended) / 1024 / 1024 / 1024
+
+	if vol.AllowsExpansion {
+		vol.Note = "volumes cannot be shrunk in place; migrate the data to a new volume of the recommended size"
+	} else {
+		vol.Note = "volumes cannot be shrunk in place, and the storage class does not allow expansion; a new volume of the recommended size cannot be grown if usage increases"
+	}
+}
+
+// resToPVCUsedBytes returns the used bytes of each claim, keyed by
+// namespace/name
+func resToPVCUsedBytes(results []*prom.QueryResult) map[string]float64 {
+	usedBytes := map[string]float64{}
+
+	for _, res := range results {
+		namespace, err := res.GetString("namespace")
+		if err != nil {
+			log.DedupedWarningf(10, "StorageSavings: usage result missing field: %s", err)
+			continue
+		}
+		claim, err := res.GetString("persistentvolumeclaim")
+		if err != nil {
+			log.DedupedWarningf(10, "StorageSavings: usage result missing field: %s", err)
+			continue
+		}
+
+		if len(res.Values) > 0 {
+			key := namespace + "/" + claim
+			usedBytes[key] = math.Max(usedBytes[key], res.Values[0].Value)
+		}
+	}
+
+	return usedBytes
+}
+
+// ComputeStorageSavings queries the peak usage of each claim over the window,
+// and finds the cluster's unclaimed, unmounted, and under-utilized volumes.
+func (cm *CostModel) ComputeStorageSavings(cp cloud.Provider, window kubecost.Window, opts *StorageSavingsOptions) (*StorageSavings, error) {
+	durStr, offStr, err := window.DurationOffsetForPrometheus()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := prom.NewNamedContext(cm.PrometheusClient, prom.AllocationContextName)
+
+	queryUsed := fmt.Sprintf(queryFmtPVCUsedBytes, durStr, offStr, env.GetPromClusterLabel())
+	resUsed, _ := ctx.Query(queryUsed).Await()
+
+	if ctx.HasErrors() {
+		for _, err := range ctx.Errors() {
+			log.Errorf("CostModel.ComputeStorageSavings: %s", err)
+		}
+		return nil, ctx.ErrorCollection()
+	}
+
+	return cm.FindStorageSavings(cp, resToPVCUsedBytes(resUsed), opts)
+}
+
+// StorageSavingsHandler lists the volumes which are unclaimed, unmounted, or
+// under-utilized over the given window, with the savings of removing or
+// resizing each.
+func (a *Accesses) StorageSavingsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	qp := httputil.NewQueryParams(r.URL.Query())
+
+	conversion, err := a.currencyConversion(qp)
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'currency' parameter: %s", err)))
+		return
+	}
+
+	window, err := kubecost.ParseWindowWithOffset(qp.Get("window", "7d"), env.GetParsedUTCOffset())
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'window' parameter: %s", err)))
+		return
+	}
+	if window.IsOpen() || window.IsNegative() {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'window' parameter: %s", window)))
+		return
+	}
+
+	opts := DefaultStorageSavingsOptions()
+	opts.UtilizationThreshold = qp.GetFloat64("utilizationThreshold", opts.UtilizationThreshold)
+	opts.Headroom = qp.GetFloat64("headroom", opts.Headroom)
+	opts.Namespaces = qp.GetList("namespaces", ",")
+
+	if opts.UtilizationThreshold < 0.0 || opts.UtilizationThreshold > 1.0 {
+		WriteError(w, BadRequest("utilizationThreshold must be between 0.0 and 1.0"))
+		return
+	}
+	if opts.Headroom < 0.0 {
+		WriteError(w, BadRequest("headroom must be non-negative"))
+		return
+	}
+
+	savings, err := a.Model.ComputeStorageSavings(a.CloudProvider, window, opts)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	writeWithCurrency(w, savings, conversion)
+}
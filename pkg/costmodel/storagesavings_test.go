@@ -0,0 +1,176 @@
+package costmodel
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	stv1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/clustercache"
+	"github.com/kubecost/cost-model/pkg/util"
+)
+
+type storageSavingsClusterCache struct {
+	clustercache.ClusterCache
+	pods           []*v1.Pod
+	pvcs           []*v1.PersistentVolumeClaim
+	pvs            []*v1.PersistentVolume
+	storageClasses []*stv1.StorageClass
+}
+
+func (c *storageSavingsClusterCache) GetAllNodes() []*v1.Node { return nil }
+func (c *storageSavingsClusterCache) GetAllPods() []*v1.Pod   { return c.pods }
+func (c *storageSavingsClusterCache) GetAllPersistentVolumeClaims() []*v1.PersistentVolumeClaim {
+	return c.pvcs
+}
+func (c *storageSavingsClusterCache) GetAllPersistentVolumes() []*v1.PersistentVolume { return c.pvs }
+func (c *storageSavingsClusterCache) GetAllStorageClasses() []*stv1.StorageClass {
+	return c.storageClasses
+}
+
+func newStorageSavingsTestPV(name, class, size string, phase v1.PersistentVolumePhase, namespace, claim string) *v1.PersistentVolume {
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: v1.PersistentVolumeSpec{
+			StorageClassName: class,
+			Capacity:         v1.ResourceList{v1.ResourceStorage: resource.MustParse(size)},
+		},
+		Status: v1.PersistentVolumeStatus{Phase: phase},
+	}
+	if claim != "" {
+		pv.Spec.ClaimRef = &v1.ObjectReference{Namespace: namespace, Name: claim}
+	}
+	return pv
+}
+
+func newStorageSavingsTestPVC(namespace, name, volume string) *v1.PersistentVolumeClaim {
+	return &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       v1.PersistentVolumeClaimSpec{VolumeName: volume},
+		Status:     v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound},
+	}
+}
+
+func newStorageSavingsTestPod(namespace, name string, phase v1.PodPhase, claims ...string) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Status:     v1.PodStatus{Phase: phase},
+	}
+	for _, claim := range claims {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, v1.Volume{
+			Name: claim,
+			VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: claim},
+			},
+		})
+	}
+	return pod
+}
+
+func TestFindStorageSavings(t *testing.T) {
+	expandable := true
+
+	cache := &storageSavingsClusterCache{
+		pvs: []*v1.PersistentVolume{
+			// The claim of a Released volume has been deleted
+			newStorageSavingsTestPV("released", "ssd", "100Gi", v1.VolumeReleased, "db", "old-data"),
+			newStorageSavingsTestPV("available", "ssd", "50Gi", v1.VolumeAvailable, "", ""),
+			// Bound, but only mounted by a completed job
+			newStorageSavingsTestPV("unmounted", "ssd", "20Gi", v1.VolumeBound, "etl", "scratch"),
+			// 10Gi used of 500Gi, in a class which does not allow expansion
+			newStorageSavingsTestPV("oversized", "ssd", "500Gi", v1.VolumeBound, "db", "data"),
+			// 10Gi used of 100Gi, in a class which allows expansion
+			newStorageSavingsTestPV("expandable", "standard", "100Gi", v1.VolumeBound, "web", "uploads"),
+			// 80Gi used of 100Gi
+			newStorageSavingsTestPV("utilized", "ssd", "100Gi", v1.VolumeBound, "web", "cache"),
+			// Mounted, but its usage is unknown
+			newStorageSavingsTestPV("unknown", "ssd", "100Gi", v1.VolumeBound, "web", "logs"),
+		},
+		pvcs: []*v1.PersistentVolumeClaim{
+			newStorageSavingsTestPVC("etl", "scratch", "unmounted"),
+			newStorageSavingsTestPVC("db", "data", "oversized"),
+			newStorageSavingsTestPVC("web", "uploads", "expandable"),
+			newStorageSavingsTestPVC("web", "cache", "utilized"),
+			newStorageSavingsTestPVC("web", "logs", "unknown"),
+		},
+		pods: []*v1.Pod{
+			newStorageSavingsTestPod("etl", "job-abcde", v1.PodSucceeded, "scratch"),
+			newStorageSavingsTestPod("db", "postgres-0", v1.PodRunning, "data"),
+			newStorageSavingsTestPod("web", "frontend-abcde", v1.PodRunning, "uploads", "cache", "logs"),
+		},
+		storageClasses: []*stv1.StorageClass{
+			{ObjectMeta: metav1.ObjectMeta{Name: "ssd"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "standard"}, AllowVolumeExpansion: &expandable},
+		},
+	}
+
+	// 0.0002/GiB-hr is 0.146/GiB-month for ssd, and the default
+	// 0.00005/GiB-hr is 0.0365/GiB-month for standard
+	provider := &assetsProvider{
+		pvs: map[string]*cloud.PV{
+			"ssd": {Cost: "0.0002"},
+		},
+	}
+
+	gib := 1024.0 * 1024.0 * 1024.0
+	usedBytes := map[string]float64{
+		"db/data":     10 * gib,
+		"web/uploads": 10 * gib,
+		"web/cache":   80 * gib,
+	}
+
+	cm := &CostModel{Cache: cache}
+
+	savings, err := cm.FindStorageSavings(provider, usedBytes, DefaultStorageSavingsOptions())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(savings.Unclaimed) != 2 || savings.Unclaimed[0].Volume != "released" || savings.Unclaimed[1].Volume != "available" {
+		t.Fatalf("expected the released and available volumes to be unclaimed; got %+v", savings.Unclaimed)
+	}
+	if released := savings.Unclaimed[0]; released.Phase != "Released" || released.Namespace != "db" || !util.IsApproximately(released.MonthlySavings, 14.6) {
+		t.Fatalf("expected the released volume to save 14.6 per month; got %+v", released)
+	}
+
+	if len(savings.Unmounted) != 1 || savings.Unmounted[0].Volume != "unmounted" || !util.IsApproximately(savings.Unmounted[0].MonthlySavings, 2.92) {
+		t.Fatalf("expected the volume mounted by a completed job to be unmounted, saving 2.92 per month; got %+v", savings.Unmounted)
+	}
+
+	if len(savings.Underutilized) != 2 {
+		t.Fatalf("expected 2 under-utilized volumes; got %d", len(savings.Underutilized))
+	}
+
+	// 10Gi plus 20% headroom rounds up to 12Gi
+	oversized := savings.Underutilized[0]
+	if oversized.Volume != "oversized" || oversized.RecommendedBytes != 12*gib || !util.IsApproximately(oversized.MonthlySavings, 0.146*488) {
+		t.Fatalf("expected the oversized volume to be resized to 12Gi, saving %f per month; got %+v", 0.146*488, oversized)
+	}
+	if oversized.AllowsExpansion || oversized.Note == "" || !util.IsApproximately(*oversized.Utilization, 0.02) {
+		t.Fatalf("expected the oversized volume to be annotated as not allowing expansion; got %+v", oversized)
+	}
+
+	expandableVol := savings.Underutilized[1]
+	if expandableVol.Volume != "expandable" || !expandableVol.AllowsExpansion || !util.IsApproximately(expandableVol.MonthlySavings, 0.0365*88) {
+		t.Fatalf("expected the expandable volume to save %f per month; got %+v", 0.0365*88, expandableVol)
+	}
+
+	if !util.IsApproximately(savings.TotalMonthlySavings, 14.6+7.3+2.92+0.146*488+0.0365*88) {
+		t.Fatalf("expected total savings of every volume; got %f", savings.TotalMonthlySavings)
+	}
+
+	// Filtering by namespace excludes the volumes of other namespaces,
+	// including unclaimed volumes which were never bound
+	opts := DefaultStorageSavingsOptions()
+	opts.Namespaces = []string{"db"}
+	savings, err = cm.FindStorageSavings(provider, usedBytes, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(savings.Unclaimed) != 1 || len(savings.Unmounted) != 0 || len(savings.Underutilized) != 1 {
+		t.Fatalf("expected only the db volumes; got %d unclaimed, %d unmounted, %d under-utilized", len(savings.Unclaimed), len(savings.Unmounted), len(savings.Underutilized))
+	}
+}
@@ -0,0 +1,186 @@
+package costmodel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/kubecost"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/util/httputil"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+	"github.com/kubecost/cost-model/pkg/util/workerpool"
+)
+
+const (
+	// DefaultCostTrendDays is the default number of days in a cost trend
+	DefaultCostTrendDays = 30
+
+	// MaxCostTrendDays is the maximum number of days in a cost trend
+	MaxCostTrendDays = 90
+
+	// costTrendConcurrency is the number of days whose allocations are
+	// computed concurrently
+	costTrendConcurrency = 4
+)
+
+// CostTrendReport is a compact daily cost series per aggregated key, e.g. for
+// rendering sparklines. Every series has one total per day in Days, which are
+// the dates of the days in the report's timezone. Days for which allocations
+// could not be computed are listed in MissingDays, and have a total of zero.
+type CostTrendReport struct {
+	Aggregate   []string             `json:"aggregate"`
+	Timezone    string               `json:"timezone"`
+	Days        []string             `json:"days"`
+	MissingDays []string             `json:"missingDays"`
+	Series      map[string][]float64 `json:"series"`
+}
+
+// costTrendDays returns the start of each of the given number of complete
+// days in the location which precede the day containing now, oldest first.
+// Days are aligned to midnight in the location, so they may be shorter or
+// longer than 24 hours across daylight saving time transitions.
+func costTrendDays(now time.Time, days int, loc *time.Location) []time.Time {
+	today := timeutil.StartOfDay(now, loc)
+	window := timeutil.NewWindow(today.AddDate(0, 0, -days), today)
+
+	starts := []time.Time{}
+	for _, day := range window.SplitDays(loc) {
+		starts = append(starts, day.Start)
+	}
+
+	return starts
+}
+
+// NewCostTrendReport builds the series of each key from the totals of each
+// day, keyed by the start of the day. Keys which are absent on a day, and days
+// which are absent entirely, have a total of zero.
+func NewCostTrendReport(days []time.Time, totals map[time.Time]map[string]float64) *CostTrendReport {
+	report := &CostTrendReport{
+		Days:        make([]string, len(days)),
+		MissingDays: []string{},
+		Series:      map[string][]float64{},
+	}
+
+	for i, day := range days {
+		report.Days[i] = day.Format("2006-01-02")
+
+		dayTotals, ok := totals[day]
+		if !ok {
+			report.MissingDays = append(report.MissingDays, report.Days[i])
+			continue
+		}
+
+		for key, cost := range dayTotals {
+			if _, ok := report.Series[key]; !ok {
+				report.Series[key] = make([]float64, len(days))
+			}
+			report.Series[key][i] = cost
+		}
+	}
+
+	return report
+}
+
+// ComputeDailyCostTotals computes the total cost of each aggregated key on
+// each of the given days, keyed by the start of the day. Only the totals are
+// retained, so that memory is bounded by the number of keys, rather than the
+// number of allocations. Days whose allocations could not be computed are
+// omitted.
+func (cm *CostModel) ComputeDailyCostTotals(days []time.Time, aggregateBy []string, resolution time.Duration) map[time.Time]map[string]float64 {
+	tasks := make([]func(context.Context) (map[string]float64, error), len(days))
+	for i, day := range days {
+		start := day
+		tasks[i] = func(ctx context.Context) (map[string]float64, error) {
+			end := start.AddDate(0, 0, 1)
+
+			as, err := cm.ComputeAllocation(start.UTC(), end.UTC(), resolution)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compute allocations for %s: %s", start.Format("2006-01-02"), err)
+			}
+
+			err = as.AggregateBy(aggregateBy, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to aggregate allocations for %s: %s", start.Format("2006-01-02"), err)
+			}
+
+			dayTotals := map[string]float64{}
+			as.Each(func(name string, alloc *kubecost.Allocation) {
+				dayTotals[name] += alloc.TotalCost()
+			})
+			return dayTotals, nil
+		}
+	}
+
+	results, err := workerpool.Map(context.Background(), tasks, workerpool.Options{
+		Concurrency: costTrendConcurrency,
+		Mode:        workerpool.CollectAll,
+	})
+	if errs, ok := err.(workerpool.Errors); ok {
+		for _, te := range errs {
+			log.Warningf("CostTrends: %s", te.Err)
+		}
+	}
+
+	totals := map[time.Time]map[string]float64{}
+	for i, dayTotals := range results {
+		if dayTotals != nil {
+			totals[days[i]] = dayTotals
+		}
+	}
+
+	return totals
+}
+
+// CostTrendsHandler returns the daily cost of each aggregated key over the
+// given number of days, e.g. aggregate=namespace&days=30&tz=America/New_York
+func (a *Accesses) CostTrendsHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	qp := httputil.NewQueryParams(r.URL.Query())
+
+	aggregateBy, err := ParseAggregationProperties(qp, "aggregate")
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'aggregate' parameter: %s", err)))
+		return
+	}
+	if len(aggregateBy) == 0 {
+		WriteError(w, BadRequest("Parameter 'aggregate' is required"))
+		return
+	}
+
+	days := qp.GetInt("days", DefaultCostTrendDays)
+	if days < 1 || days > MaxCostTrendDays {
+		WriteError(w, BadRequest(fmt.Sprintf("Parameter 'days' must be between 1 and %d", MaxCostTrendDays)))
+		return
+	}
+
+	// Timezone is an optional IANA timezone name, e.g. America/New_York, in
+	// which days begin at midnight. Defaults to the configured UTC offset.
+	loc := time.UTC
+	if offset := env.GetParsedUTCOffset(); offset != 0 {
+		loc = time.FixedZone(env.GetUTCOffset(), int(offset.Seconds()))
+	}
+	if tz := qp.Get("tz", ""); tz != "" {
+		loc, err = time.LoadLocation(tz)
+		if err != nil {
+			WriteError(w, BadRequest(fmt.Sprintf("Invalid 'tz' parameter: %s", err)))
+			return
+		}
+	}
+
+	resolution := qp.GetDuration("resolution", env.GetETLResolution())
+
+	starts := costTrendDays(time.Now(), days, loc)
+	totals := a.Model.ComputeDailyCostTotals(starts, aggregateBy, resolution)
+
+	report := NewCostTrendReport(starts, totals)
+	report.Aggregate = aggregateBy
+	report.Timezone = loc.String()
+
+	w.Write(WrapData(report, nil))
+}
@@ -0,0 +1,123 @@
+package costmodel
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCostTrendDays(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("failed to load location: %s", err)
+	}
+
+	cases := []struct {
+		name     string
+		now      time.Time
+		days     int
+		loc      *time.Location
+		expected []string
+		hours    []float64
+	}{
+		{
+			name:     "UTC",
+			now:      time.Date(2021, time.March, 16, 3, 0, 0, 0, time.UTC),
+			days:     3,
+			loc:      time.UTC,
+			expected: []string{"2021-03-13T00:00:00Z", "2021-03-14T00:00:00Z", "2021-03-15T00:00:00Z"},
+			hours:    []float64{24, 24, 24},
+		},
+		{
+			// 03:00 UTC is still the previous day at UTC-5
+			name:     "fixed offset",
+			now:      time.Date(2021, time.March, 16, 3, 0, 0, 0, time.UTC),
+			days:     2,
+			loc:      time.FixedZone("-05:00", -5*60*60),
+			expected: []string{"2021-03-13T05:00:00Z", "2021-03-14T05:00:00Z"},
+			hours:    []float64{24, 24},
+		},
+		{
+			// Daylight saving time begins on March 14th, which is 23 hours long
+			name:     "daylight saving time",
+			now:      time.Date(2021, time.March, 16, 12, 0, 0, 0, time.UTC),
+			days:     3,
+			loc:      newYork,
+			expected: []string{"2021-03-13T05:00:00Z", "2021-03-14T05:00:00Z", "2021-03-15T04:00:00Z"},
+			hours:    []float64{24, 23, 24},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			starts := costTrendDays(c.now, c.days, c.loc)
+			if len(starts) != len(c.expected) {
+				t.Fatalf("expected %d days; got %d", len(c.expected), len(starts))
+			}
+
+			for i, start := range starts {
+				if s := start.UTC().Format(time.RFC3339); s != c.expected[i] {
+					t.Errorf("day %d: expected start %s; got %s", i, c.expected[i], s)
+				}
+				if h := start.AddDate(0, 0, 1).Sub(start).Hours(); h != c.hours[i] {
+					t.Errorf("day %d: expected %.0f hours; got %.0f", i, c.hours[i], h)
+				}
+			}
+		})
+	}
+}
+
+func TestNewCostTrendReport(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("failed to load location: %s", err)
+	}
+
+	days := costTrendDays(time.Date(2021, time.March, 17, 0, 0, 0, 0, time.UTC), 4, newYork)
+
+	totals := map[time.Time]map[string]float64{
+		days[0]: {"kubecost": 1.0, "default": 2.0},
+		// days[1] could not be computed
+		days[2]: {"kubecost": 1.5},
+		days[3]: {"kubecost": 2.0, "default": 0.5, "added": 3.0},
+	}
+
+	report := NewCostTrendReport(days, totals)
+
+	expectedDays := []string{"2021-03-12", "2021-03-13", "2021-03-14", "2021-03-15"}
+	if len(report.Days) != len(expectedDays) {
+		t.Fatalf("expected days %v; got %v", expectedDays, report.Days)
+	}
+	for i := range expectedDays {
+		if report.Days[i] != expectedDays[i] {
+			t.Fatalf("expected days %v; got %v", expectedDays, report.Days)
+		}
+	}
+
+	if len(report.MissingDays) != 1 || report.MissingDays[0] != "2021-03-13" {
+		t.Errorf("expected missing days [2021-03-13]; got %v", report.MissingDays)
+	}
+
+	expected := map[string][]float64{
+		"kubecost": {1.0, 0.0, 1.5, 2.0},
+		"default":  {2.0, 0.0, 0.0, 0.5},
+		"added":    {0.0, 0.0, 0.0, 3.0},
+	}
+	if len(report.Series) != len(expected) {
+		t.Fatalf("expected %d series; got %d", len(expected), len(report.Series))
+	}
+	for key, exp := range expected {
+		series, ok := report.Series[key]
+		if !ok {
+			t.Fatalf("expected series for %s", key)
+		}
+		if len(series) != len(exp) {
+			t.Fatalf("%s: expected %v; got %v", key, exp, series)
+		}
+		for i := range exp {
+			if series[i] != exp[i] {
+				t.Errorf("%s: expected %v; got %v", key, exp, series)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,343 @@
+package costmodel
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kubecost/cost-model/pkg/cloud"
+	"github.com/kubecost/cost-model/pkg/util/httputil"
+	"github.com/kubecost/cost-model/pkg/util/json"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+)
+
+// maxWhatIfBodyBytes is the largest manifest accepted by WhatIfHandler
+const maxWhatIfBodyBytes = 1024 * 1024
+
+// WhatIfWorkload is a workload which is not yet running, described by the
+// requests of each of its replicas and the nodes it may be scheduled on.
+type WhatIfWorkload struct {
+	Kind         string                 `json:"kind"`
+	Namespace    string                 `json:"namespace,omitempty"`
+	Name         string                 `json:"name,omitempty"`
+	Replicas     int                    `json:"replicas"`
+	Requests     ClusterSizingResources `json:"requestsPerReplica"`
+	NodeSelector map[string]string      `json:"nodeSelector,omitempty"`
+}
+
+// WhatIfNodeType is a node type on which a workload may be priced, with the
+// labels which its nodeSelector is matched against.
+type WhatIfNodeType struct {
+	*ClusterSizingNodeType
+	Labels map[string]string
+	Spot   bool
+}
+
+// WhatIfPricing is the cost of a workload on the cheapest node type which
+// satisfies its requests and constraints.
+type WhatIfPricing struct {
+	NodeType       string  `json:"nodeType"`
+	NodeHourlyCost float64 `json:"nodeHourlyCost"`
+	HourlyCost     float64 `json:"hourlyCost"`
+	MonthlyCost    float64 `json:"monthlyCost"`
+}
+
+// WhatIfEstimate is the estimated cost of a workload on on-demand nodes and,
+// if a spot node type satisfies it, on spot nodes, along with each of its
+// constraints which no node type satisfies.
+type WhatIfEstimate struct {
+	Workload    *WhatIfWorkload `json:"workload"`
+	OnDemand    *WhatIfPricing  `json:"onDemand,omitempty"`
+	Spot        *WhatIfPricing  `json:"spot,omitempty"`
+	Unsatisfied []string        `json:"unsatisfied"`
+}
+
+// ParseWhatIfWorkload parses a Deployment, Pod, or bare pod spec, as JSON or
+// YAML, into the workload which it would run.
+func ParseWhatIfWorkload(data []byte) (*WhatIfWorkload, error) {
+	data, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest: %s", err)
+	}
+
+	var meta struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %s", err)
+	}
+
+	workload := &WhatIfWorkload{Replicas: 1}
+	var spec v1.PodSpec
+
+	switch meta.Kind {
+	case "Deployment":
+		deployment := &appsv1.Deployment{}
+		if err := json.Unmarshal(data, deployment); err != nil {
+			return nil, fmt.Errorf("invalid Deployment: %s", err)
+		}
+		workload.Kind = "deployment"
+		workload.Namespace = deployment.Namespace
+		workload.Name = deployment.Name
+		if deployment.Spec.Replicas != nil {
+			workload.Replicas = int(*deployment.Spec.Replicas)
+		}
+		spec = deployment.Spec.Template.Spec
+	case "Pod":
+		pod := &v1.Pod{}
+		if err := json.Unmarshal(data, pod); err != nil {
+			return nil, fmt.Errorf("invalid Pod: %s", err)
+		}
+		workload.Kind = "pod"
+		workload.Namespace = pod.Namespace
+		workload.Name = pod.Name
+		spec = pod.Spec
+	case "":
+		if err := json.Unmarshal(data, &spec); err != nil {
+			return nil, fmt.Errorf("invalid pod spec: %s", err)
+		}
+		workload.Kind = "pod"
+	default:
+		return nil, fmt.Errorf("unsupported kind '%s': expected a Deployment, Pod, or pod spec", meta.Kind)
+	}
+
+	if len(spec.Containers) == 0 {
+		return nil, fmt.Errorf("manifest has no containers")
+	}
+
+	workload.Requests = podSpecRequests(spec)
+	workload.NodeSelector = spec.NodeSelector
+
+	return workload, nil
+}
+
+// podSpecRequests returns the resources the scheduler reserves for a pod of
+// the spec: the greater of the sum of its containers' requests and the
+// largest request of its init containers, plus its overhead. A container
+// which only sets a limit requests its limit.
+func podSpecRequests(spec v1.PodSpec) ClusterSizingResources {
+	total := ClusterSizingResources{Pods: 1}
+	for _, c := range spec.Containers {
+		r := containerRequests(c)
+		total.CPUCores += r.CPUCores
+		total.RAMBytes += r.RAMBytes
+		total.GPUs += r.GPUs
+	}
+
+	for _, c := range spec.InitContainers {
+		r := containerRequests(c)
+		total.CPUCores = math.Max(total.CPUCores, r.CPUCores)
+		total.RAMBytes = math.Max(total.RAMBytes, r.RAMBytes)
+		total.GPUs = math.Max(total.GPUs, r.GPUs)
+	}
+
+	if cpu, ok := spec.Overhead[v1.ResourceCPU]; ok {
+		total.CPUCores += float64(cpu.MilliValue()) / 1000
+	}
+	if ram, ok := spec.Overhead[v1.ResourceMemory]; ok {
+		total.RAMBytes += float64(ram.Value())
+	}
+
+	return total
+}
+
+// containerRequests returns the CPU, RAM, and GPUs requested by a container.
+// GPUs are any extended resource named "<vendor>/gpu", e.g. nvidia.com/gpu.
+func containerRequests(c v1.Container) ClusterSizingResources {
+	r := ClusterSizingResources{}
+
+	resources := v1.ResourceList{}
+	for name, q := range c.Resources.Limits {
+		resources[name] = q
+	}
+	for name, q := range c.Resources.Requests {
+		resources[name] = q
+	}
+
+	for name, q := range resources {
+		switch {
+		case name == v1.ResourceCPU:
+			r.CPUCores = float64(q.MilliValue()) / 1000
+		case name == v1.ResourceMemory:
+			r.RAMBytes = float64(q.Value())
+		case strings.HasSuffix(string(name), "/gpu"):
+			r.GPUs += float64(q.Value())
+		}
+	}
+
+	return r
+}
+
+// matchesNodeSelector returns true if the labels have every label of the
+// selector
+func matchesNodeSelector(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if l, ok := labels[k]; !ok || l != v {
+			return false
+		}
+	}
+	return true
+}
+
+// EstimateWorkloadCost prices the workload on the cheapest on-demand and spot
+// node types of the catalog which satisfy its requests and nodeSelector. Each
+// replica costs the share of its node's hourly cost of the resource it
+// requests the greatest fraction of, as the remainder of the node cannot be
+// filled by that resource. Constraints which no node type satisfies are listed
+// as unsatisfied.
+func EstimateWorkloadCost(workload *WhatIfWorkload, catalog []*WhatIfNodeType) *WhatIfEstimate {
+	estimate := &WhatIfEstimate{
+		Workload:    workload,
+		Unsatisfied: []string{},
+	}
+
+	req := workload.Requests
+
+	var selected []*WhatIfNodeType
+	for _, nt := range catalog {
+		if matchesNodeSelector(nt.Labels, workload.NodeSelector) {
+			selected = append(selected, nt)
+		}
+	}
+
+	if len(selected) == 0 {
+		var selector []string
+		for k, v := range workload.NodeSelector {
+			selector = append(selector, k+"="+v)
+		}
+		sort.Strings(selector)
+		estimate.Unsatisfied = append(estimate.Unsatisfied, fmt.Sprintf("nodeSelector %s matches no node type", strings.Join(selector, ",")))
+		return estimate
+	}
+
+	hasGPUs, hasCPU, hasRAM := false, false, false
+	for _, nt := range selected {
+		hasGPUs = hasGPUs || nt.GPUs >= req.GPUs
+		hasCPU = hasCPU || nt.CPUCores >= req.CPUCores
+		hasRAM = hasRAM || nt.RAMBytes >= req.RAMBytes
+
+		if nt.GPUs < req.GPUs || nt.CPUCores < req.CPUCores || nt.RAMBytes < req.RAMBytes {
+			continue
+		}
+
+		share := 0.0
+		if nt.CPUCores > 0 {
+			share = math.Max(share, req.CPUCores/nt.CPUCores)
+		}
+		if nt.RAMBytes > 0 {
+			share = math.Max(share, req.RAMBytes/nt.RAMBytes)
+		}
+		if nt.GPUs > 0 {
+			share = math.Max(share, req.GPUs/nt.GPUs)
+		}
+
+		hourly := share * nt.HourlyCost * float64(workload.Replicas)
+		pricing := &WhatIfPricing{
+			NodeType:       nt.Name,
+			NodeHourlyCost: nt.HourlyCost,
+			HourlyCost:     hourly,
+			MonthlyCost:    hourly * timeutil.HoursPerMonth,
+		}
+
+		current := &estimate.OnDemand
+		if nt.Spot {
+			current = &estimate.Spot
+		}
+		if *current == nil || pricing.HourlyCost < (*current).HourlyCost {
+			*current = pricing
+		}
+	}
+
+	if !hasGPUs {
+		estimate.Unsatisfied = append(estimate.Unsatisfied, fmt.Sprintf("no node type has %g GPUs", req.GPUs))
+	}
+	if !hasCPU {
+		estimate.Unsatisfied = append(estimate.Unsatisfied, fmt.Sprintf("no node type has %g CPU cores", req.CPUCores))
+	}
+	if !hasRAM {
+		estimate.Unsatisfied = append(estimate.Unsatisfied, fmt.Sprintf("no node type has %g bytes of RAM", req.RAMBytes))
+	}
+	if hasGPUs && hasCPU && hasRAM && estimate.OnDemand == nil && estimate.Spot == nil {
+		estimate.Unsatisfied = append(estimate.Unsatisfied, "no single node type has enough CPU, RAM, and GPUs")
+	}
+
+	return estimate
+}
+
+// whatIfCatalog returns the node types of the cluster's current nodes, priced
+// by the provider, with the labels of the nodes.
+func (cm *CostModel) whatIfCatalog(cp cloud.Provider) ([]*WhatIfNodeType, error) {
+	nodes, err := cm.GetNodeCost(cp)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]map[string]string{}
+	for _, n := range cm.Cache.GetAllNodes() {
+		labels[n.GetName()] = n.GetLabels()
+	}
+
+	var catalog []*WhatIfNodeType
+	for name, node := range nodes {
+		nt := clusterSizingNodeType(node.InstanceType, node, 0)
+		if nt.Name == "" {
+			nt.Name = name
+		}
+		catalog = append(catalog, &WhatIfNodeType{
+			ClusterSizingNodeType: nt,
+			Labels:                labels[name],
+			Spot:                  node.IsSpot(),
+		})
+	}
+
+	// Ties between node types are broken by name, so that estimates are
+	// deterministic
+	sort.Slice(catalog, func(i, j int) bool {
+		return catalog[i].Name < catalog[j].Name
+	})
+
+	return catalog, nil
+}
+
+// WhatIfHandler estimates the hourly and monthly cost of the Deployment, Pod,
+// or pod spec in the request body, as JSON or YAML, on the node types
+// currently priced by the provider.
+func (a *Accesses) WhatIfHandler(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+
+	qp := httputil.NewQueryParams(r.URL.Query())
+
+	conversion, err := a.currencyConversion(qp)
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Invalid 'currency' parameter: %s", err)))
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWhatIfBodyBytes))
+	if err != nil {
+		WriteError(w, BadRequest(fmt.Sprintf("Failed to read manifest: %s", err)))
+		return
+	}
+
+	workload, err := ParseWhatIfWorkload(body)
+	if err != nil {
+		WriteError(w, BadRequest(err.Error()))
+		return
+	}
+
+	catalog, err := a.Model.whatIfCatalog(a.CloudProvider)
+	if err != nil {
+		WriteError(w, InternalServerError(err.Error()))
+		return
+	}
+
+	writeWithCurrency(w, EstimateWorkloadCost(workload, catalog), conversion)
+}
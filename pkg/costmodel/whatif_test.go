@@ -0,0 +1,153 @@
+package costmodel
+
+import (
+	"testing"
+
+	"github.com/kubecost/cost-model/pkg/util"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+)
+
+const whatIfGiB = 1024.0 * 1024.0 * 1024.0
+
+func whatIfTestCatalog() []*WhatIfNodeType {
+	return []*WhatIfNodeType{
+		{
+			ClusterSizingNodeType: &ClusterSizingNodeType{Name: "m5.large", CPUCores: 2, RAMBytes: 8 * whatIfGiB, HourlyCost: 0.096},
+			Labels:                map[string]string{"pool": "general"},
+		},
+		{
+			ClusterSizingNodeType: &ClusterSizingNodeType{Name: "m5.large", CPUCores: 2, RAMBytes: 8 * whatIfGiB, HourlyCost: 0.035},
+			Labels:                map[string]string{"pool": "general-spot"},
+			Spot:                  true,
+		},
+		{
+			ClusterSizingNodeType: &ClusterSizingNodeType{Name: "m5.xlarge", CPUCores: 4, RAMBytes: 16 * whatIfGiB, HourlyCost: 0.2},
+			Labels:                map[string]string{"pool": "general"},
+		},
+		{
+			ClusterSizingNodeType: &ClusterSizingNodeType{Name: "p3.2xlarge", CPUCores: 8, RAMBytes: 61 * whatIfGiB, GPUs: 1, HourlyCost: 3.06},
+			Labels:                map[string]string{"pool": "gpu", "accelerator": "v100"},
+		},
+	}
+}
+
+func TestParseWhatIfWorkload(t *testing.T) {
+	manifest := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: frontend
+  namespace: web
+spec:
+  replicas: 3
+  template:
+    spec:
+      nodeSelector:
+        pool: general
+      initContainers:
+      - name: migrate
+        resources:
+          requests:
+            cpu: "2"
+      containers:
+      - name: app
+        resources:
+          requests:
+            cpu: 500m
+            memory: 1Gi
+      - name: sidecar
+        resources:
+          limits:
+            cpu: 100m
+            memory: 128Mi
+`
+
+	workload, err := ParseWhatIfWorkload([]byte(manifest))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if workload.Kind != "deployment" || workload.Namespace != "web" || workload.Name != "frontend" || workload.Replicas != 3 {
+		t.Fatalf("unexpected workload %+v", workload)
+	}
+	// The init container requests more CPU than the containers, and the
+	// sidecar requests its limits
+	if workload.Requests.CPUCores != 2 || workload.Requests.RAMBytes != 1.125*whatIfGiB || workload.NodeSelector["pool"] != "general" {
+		t.Fatalf("unexpected requests %+v", workload.Requests)
+	}
+
+	// A bare pod spec, as JSON, runs a single replica
+	workload, err = ParseWhatIfWorkload([]byte(`{"containers": [{"name": "train", "resources": {"limits": {"nvidia.com/gpu": 1}}}]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if workload.Kind != "pod" || workload.Replicas != 1 || workload.Requests.GPUs != 1 {
+		t.Fatalf("unexpected workload %+v", workload)
+	}
+
+	for _, manifest := range []string{`kind: Service`, `{"containers": []}`, `[unclosed`} {
+		if _, err := ParseWhatIfWorkload([]byte(manifest)); err == nil {
+			t.Fatalf("expected an error parsing %s", manifest)
+		}
+	}
+}
+
+func TestEstimateWorkloadCost(t *testing.T) {
+	catalog := whatIfTestCatalog()
+
+	// A stateless service of 3 replicas, each requesting 0.5 cores and 1GiB,
+	// costs a quarter of an m5.large per replica
+	service := &WhatIfWorkload{
+		Kind:     "deployment",
+		Replicas: 3,
+		Requests: ClusterSizingResources{CPUCores: 0.5, RAMBytes: 1 * whatIfGiB, Pods: 1},
+	}
+	estimate := EstimateWorkloadCost(service, catalog)
+	if len(estimate.Unsatisfied) != 0 {
+		t.Fatalf("expected every constraint to be satisfied; got %v", estimate.Unsatisfied)
+	}
+	if estimate.OnDemand == nil || estimate.OnDemand.NodeType != "m5.large" || !util.IsApproximately(estimate.OnDemand.HourlyCost, 0.25*0.096*3) {
+		t.Fatalf("expected the service to cost %f/hr on-demand on m5.large; got %+v", 0.25*0.096*3, estimate.OnDemand)
+	}
+	if !util.IsApproximately(estimate.OnDemand.MonthlyCost, estimate.OnDemand.HourlyCost*timeutil.HoursPerMonth) {
+		t.Fatalf("expected the monthly cost to be the hourly cost for a month; got %f", estimate.OnDemand.MonthlyCost)
+	}
+	if estimate.Spot == nil || !util.IsApproximately(estimate.Spot.HourlyCost, 0.25*0.035*3) {
+		t.Fatalf("expected the service to cost %f/hr on spot; got %+v", 0.25*0.035*3, estimate.Spot)
+	}
+
+	// A GPU workload can only run on the GPU node type, for which there is
+	// no spot price
+	training := &WhatIfWorkload{
+		Kind:     "pod",
+		Replicas: 1,
+		Requests: ClusterSizingResources{CPUCores: 4, RAMBytes: 16 * whatIfGiB, GPUs: 1, Pods: 1},
+	}
+	estimate = EstimateWorkloadCost(training, catalog)
+	if len(estimate.Unsatisfied) != 0 || estimate.Spot != nil {
+		t.Fatalf("expected only an on-demand estimate; got %+v", estimate)
+	}
+	if estimate.OnDemand == nil || estimate.OnDemand.NodeType != "p3.2xlarge" || !util.IsApproximately(estimate.OnDemand.HourlyCost, 3.06) {
+		t.Fatalf("expected the GPU workload to take the whole p3.2xlarge; got %+v", estimate.OnDemand)
+	}
+
+	// 2 GPUs are more than any node type has
+	training.Requests.GPUs = 2
+	estimate = EstimateWorkloadCost(training, catalog)
+	if estimate.OnDemand != nil || len(estimate.Unsatisfied) != 1 || estimate.Unsatisfied[0] != "no node type has 2 GPUs" {
+		t.Fatalf("expected the GPU request to be unsatisfied; got %+v", estimate)
+	}
+
+	// A nodeSelector which matches nothing cannot be priced
+	service.NodeSelector = map[string]string{"pool": "arm64", "zone": "a"}
+	estimate = EstimateWorkloadCost(service, catalog)
+	if estimate.OnDemand != nil || estimate.Spot != nil || len(estimate.Unsatisfied) != 1 || estimate.Unsatisfied[0] != "nodeSelector pool=arm64,zone=a matches no node type" {
+		t.Fatalf("expected the nodeSelector to be unsatisfied; got %+v", estimate)
+	}
+
+	// A nodeSelector restricts the node types considered
+	service.NodeSelector = map[string]string{"pool": "general"}
+	estimate = EstimateWorkloadCost(service, catalog)
+	if estimate.OnDemand == nil || estimate.OnDemand.NodeType != "m5.large" || estimate.Spot != nil {
+		t.Fatalf("expected only the general on-demand pool; got %+v", estimate)
+	}
+}
@@ -1,8 +1,10 @@
 package env
 
 import (
+	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kubecost/cost-model/pkg/log"
@@ -40,6 +42,14 @@ const (
 
 	EmitKsmV1MetricsEnvVar = "EMIT_KSM_V1_METRICS"
 
+	EmitIngressMetricsEnvVar = "EMIT_INGRESS_METRICS"
+
+	EmitAdmissionWebhookMetricsEnvVar = "EMIT_ADMISSION_WEBHOOK_METRICS"
+
+	EmitStorageClassMetricsEnvVar = "EMIT_STORAGE_CLASS_METRICS"
+
+	NodeLabelAllowlistEnvVar = "NODE_LABEL_ALLOWLIST"
+
 	ThanosEnabledEnvVar      = "THANOS_ENABLED"
 	ThanosQueryUrlEnvVar     = "THANOS_QUERY_URL"
 	ThanosOffsetEnvVar       = "THANOS_QUERY_OFFSET"
@@ -60,6 +70,10 @@ const (
 
 	InsecureSkipVerify = "INSECURE_SKIP_VERIFY"
 
+	PrometheusTLSCertFileEnvVar = "PROMETHEUS_TLS_CERT_FILE"
+	PrometheusTLSKeyFileEnvVar  = "PROMETHEUS_TLS_KEY_FILE"
+	PrometheusTLSCAFileEnvVar   = "PROMETHEUS_TLS_CA_FILE"
+
 	KubeConfigPathEnvVar = "KUBECONFIG_PATH"
 
 	UTCOffsetEnvVar = "UTC_OFFSET"
@@ -71,6 +85,45 @@ const (
 	LegacyExternalAPIDisabledVar = "LEGACY_EXTERNAL_API_DISABLED"
 
 	PromClusterIDLabelEnvVar = "PROM_CLUSTER_ID_LABEL"
+
+	ClusterInfoMetricNameEnvVar    = "CLUSTER_INFO_METRIC_NAME"
+	ClusterInfoFieldMappingEnvVar  = "CLUSTER_INFO_FIELD_MAPPING"
+	ClusterMapHTTPSDEndpointEnvVar = "CLUSTER_MAP_HTTP_SD_ENDPOINT"
+	ClusterMapEtcdEndpointsEnvVar  = "CLUSTER_MAP_ETCD_ENDPOINTS"
+	ClusterMapEtcdPrefixEnvVar     = "CLUSTER_MAP_ETCD_PREFIX"
+	ClusterMapEventsEnabledEnvVar  = "CLUSTER_MAP_EVENTS_ENABLED"
+
+	ClusterMapRefreshIntervalMinutesEnvVar = "CLUSTER_MAP_REFRESH_INTERVAL_MINUTES"
+
+	BudgetEvaluationIntervalMinutesEnvVar = "BUDGET_EVALUATION_INTERVAL_MINUTES"
+
+	PricingRefreshIntervalMinutesEnvVar = "PRICING_REFRESH_INTERVAL_MINUTES"
+
+	PricingOfflineEnabledEnvVar = "PRICING_OFFLINE_ENABLED"
+	StaticPricingPathEnvVar     = "STATIC_PRICING_PATH"
+
+	PricingChangeThresholdPercentEnvVar = "PRICING_CHANGE_THRESHOLD_PERCENT"
+	PricingChangeWebhookURLEnvVar       = "PRICING_CHANGE_WEBHOOK_URL"
+
+	AuthEnabledEnvVar      = "AUTH_ENABLED"
+	AuthStaticTokensEnvVar = "AUTH_STATIC_TOKENS"
+	AuthJWKSURLEnvVar      = "AUTH_JWKS_URL"
+	AuthJWTRoleClaimEnvVar = "AUTH_JWT_ROLE_CLAIM"
+	AuthJWTIssuerEnvVar    = "AUTH_JWT_ISSUER"
+	AuthJWTAudienceEnvVar  = "AUTH_JWT_AUDIENCE"
+
+	AllocationCostMetricsEnabledEnvVar            = "ALLOCATION_COST_METRICS_ENABLED"
+	AllocationCostMetricsIntervalMinutesEnvVar    = "ALLOCATION_COST_METRICS_INTERVAL_MINUTES"
+	AllocationCostMetricsTopNEnvVar               = "ALLOCATION_COST_METRICS_TOP_N"
+	AllocationCostMetricsTopKEnvVar               = "ALLOCATION_COST_METRICS_TOP_K"
+	AllocationCostMetricsControllersEnabledEnvVar = "ALLOCATION_COST_METRICS_CONTROLLERS_ENABLED"
+
+	AllocationCoverageGapThresholdEnvVar = "ALLOCATION_COVERAGE_GAP_THRESHOLD"
+
+	ReadinessExcludedChecksEnvVar             = "READINESS_EXCLUDED_CHECKS"
+	ReadinessClusterMapToleranceMinutesEnvVar = "READINESS_CLUSTER_MAP_TOLERANCE_MINUTES"
+
+	EnvConfigMapNameEnvVar = "ENV_CONFIGMAP_NAME"
 )
 
 // GetAWSAccessKeyID returns the environment variable value for AWSAccessKeyIDEnvVar which represents
@@ -82,31 +135,63 @@ func GetAppVersion() string {
 // IsEmitNamespaceAnnotationsMetric returns true if cost-model is configured to emit the kube_namespace_annotations metric
 // containing the namespace annotations
 func IsEmitNamespaceAnnotationsMetric() bool {
-	return GetBool(EmitNamespaceAnnotationsMetricEnvVar, false)
+	return NamespaceAnnotationsMetricFlag.Enabled()
 }
 
 // IsEmitPodAnnotationsMetric returns true if cost-model is configured to emit the kube_pod_annotations metric containing
 // pod annotations.
 func IsEmitPodAnnotationsMetric() bool {
-	return GetBool(EmitPodAnnotationsMetricEnvVar, false)
+	return PodAnnotationsMetricFlag.Enabled()
 }
 
 // IsEmitKsmV1Metrics returns true if cost-model is configured to emit all necessary KSM v1
 // metrics that were removed in KSM v2
 func IsEmitKsmV1Metrics() bool {
-	return GetBool(EmitKsmV1MetricsEnvVar, true)
+	return KsmV1MetricsFlag.Enabled()
+}
+
+// IsEmitIngressMetrics returns true if cost-model is configured to emit the kube_ingress_path metric, which
+// attributes ingress paths to backend services. Defaults to false.
+func IsEmitIngressMetrics() bool {
+	return IngressMetricsFlag.Enabled()
+}
+
+// IsEmitAdmissionWebhookMetrics returns true if cost-model is configured to emit the latency and failures of the
+// cluster's admission webhooks, which are read from the Kubernetes API server's metrics. Defaults to false.
+func IsEmitAdmissionWebhookMetrics() bool {
+	return GetBool(EmitAdmissionWebhookMetricsEnvVar, false)
+}
+
+// IsEmitStorageClassMetrics returns true if cost-model is configured to emit the kube_storageclass_info and
+// kube_storageclass_parameter metrics, which describe the storage classes whose parameters determine the cost
+// of their volumes. Defaults to false.
+func IsEmitStorageClassMetrics() bool {
+	return GetBool(EmitStorageClassMetricsEnvVar, false)
+}
+
+// GetNodeLabelAllowlist returns the node labels emitted by the kube_node_labels metric, parsed from a
+// comma-separated list; e.g. "node.kubernetes.io/instance-type,topology.kubernetes.io/zone". If empty,
+// all node labels are emitted.
+func GetNodeLabelAllowlist() []string {
+	var labels []string
+	for _, label := range strings.Split(Get(NodeLabelAllowlistEnvVar, ""), ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+	return labels
 }
 
 // GetAWSAccessKeyID returns the environment variable value for AWSAccessKeyIDEnvVar which represents
 // the AWS access key for authentication
 func GetAWSAccessKeyID() string {
-	return Get(AWSAccessKeyIDEnvVar, "")
+	return getCredential(AWSAccessKeyIDEnvVar)
 }
 
 // GetAWSAccessKeySecret returns the environment variable value for AWSAccessKeySecretEnvVar which represents
 // the AWS access key secret for authentication
 func GetAWSAccessKeySecret() string {
-	return Get(AWSAccessKeySecretEnvVar, "")
+	return getCredential(AWSAccessKeySecretEnvVar)
 }
 
 // GetAWSClusterID returns the environment variable value for AWSClusterIDEnvVar which represents
@@ -116,7 +201,7 @@ func GetAWSClusterID() string {
 }
 
 func GetAzureStorageAccessKey() string {
-	return Get(AzureStorageAccessKeyEnvVar, "")
+	return getCredential(AzureStorageAccessKeyEnvVar)
 }
 
 func GetAzureStorageAccountName() string {
@@ -155,6 +240,25 @@ func GetInsecureSkipVerify() bool {
 	return GetBool(InsecureSkipVerify, false)
 }
 
+// GetPrometheusTLSCertFile returns the path of the PEM encoded client certificate presented
+// to Prometheus and Thanos, for deployments which require mutual TLS. It must be set along
+// with PrometheusTLSKeyFileEnvVar.
+func GetPrometheusTLSCertFile() string {
+	return Get(PrometheusTLSCertFileEnvVar, "")
+}
+
+// GetPrometheusTLSKeyFile returns the path of the PEM encoded private key of the client
+// certificate presented to Prometheus and Thanos.
+func GetPrometheusTLSKeyFile() string {
+	return Get(PrometheusTLSKeyFileEnvVar, "")
+}
+
+// GetPrometheusTLSCAFile returns the path of the PEM encoded CA certificates used to verify
+// Prometheus and Thanos. If empty, the system roots are used.
+func GetPrometheusTLSCAFile() string {
+	return Get(PrometheusTLSCAFileEnvVar, "")
+}
+
 // IsRemoteEnabled returns the environment variable value for RemoteEnabledEnvVar which represents whether
 // or not remote write is enabled for prometheus for use with SQL backed persistent storage.
 func IsRemoteEnabled() bool {
@@ -284,30 +388,29 @@ func GetQueryLoggingFile() string {
 }
 
 func GetDBBasicAuthUsername() string {
-	return Get(DBBasicAuthUsername, "")
+	return getCredential(DBBasicAuthUsername)
 }
 
 func GetDBBasicAuthUserPassword() string {
-	return Get(DBBasicAuthPassword, "")
-
+	return getCredential(DBBasicAuthPassword)
 }
 
 func GetDBBearerToken() string {
-	return Get(DBBearerToken, "")
+	return getCredential(DBBearerToken)
 }
 
 // GetMultiClusterBasicAuthUsername returns the environemnt variable value for MultiClusterBasicAuthUsername
 func GetMultiClusterBasicAuthUsername() string {
-	return Get(MultiClusterBasicAuthUsername, "")
+	return getCredential(MultiClusterBasicAuthUsername)
 }
 
 // GetMultiClusterBasicAuthPassword returns the environemnt variable value for MultiClusterBasicAuthPassword
 func GetMultiClusterBasicAuthPassword() string {
-	return Get(MultiClusterBasicAuthPassword, "")
+	return getCredential(MultiClusterBasicAuthPassword)
 }
 
 func GetMultiClusterBearerToken() string {
-	return Get(MultiClusterBearerToken, "")
+	return getCredential(MultiClusterBearerToken)
 }
 
 // GetKubeConfigPath returns the environment variable value for KubeConfigPathEnvVar
@@ -349,8 +452,10 @@ func GetParsedUTCOffset() time.Duration {
 	return offset
 }
 
+// IsCacheWarmingEnabled returns true if the aggregation caches are warmed in the background,
+// which defaults to true.
 func IsCacheWarmingEnabled() bool {
-	return GetBool(CacheWarmingEnabledEnvVar, true)
+	return CacheWarmingFlag.Enabled()
 }
 
 func IsETLEnabled() bool {
@@ -362,9 +467,8 @@ func IsETLEnabled() bool {
 // timeout for large windows; e.g. if a 24h query is expected to timeout, but
 // a 6h query is expected to complete in 1m, then 6h could be a good value.
 func GetETLMaxBatchDuration() time.Duration {
-	// Default to 6h
-	hrs := time.Duration(GetInt64(ETLMaxBatchHours, 6))
-	return hrs * time.Hour
+	// Default to 6h; a bare number is in hours
+	return getInterval(ETLMaxBatchHours, 6*time.Hour, time.Hour)
 }
 
 // GetETLResolution determines the resolution of ETL queries. The smaller the
@@ -378,9 +482,8 @@ func GetETLResolution() time.Duration {
 	}
 
 	// Thanos is enabled, so use the configured ETL resolution, or default to
-	// 5m (i.e. 300s)
-	secs := time.Duration(GetInt64(ETLResolutionSeconds, 300))
-	return secs * time.Second
+	// 5m (i.e. 300s); a bare number is in seconds
+	return getInterval(ETLResolutionSeconds, 300*time.Second, time.Second)
 }
 
 func LegacyExternalCostsAPIDisabled() bool {
@@ -391,3 +494,231 @@ func LegacyExternalCostsAPIDisabled() bool {
 func GetPromClusterLabel() string {
 	return Get(PromClusterIDLabelEnvVar, "cluster_id")
 }
+
+// GetClusterInfoMetricName returns the name of the metric used to load cluster info for the
+// cluster map, which defaults to kubecost_cluster_info.
+func GetClusterInfoMetricName() string {
+	return Get(ClusterInfoMetricNameEnvVar, "kubecost_cluster_info")
+}
+
+// GetClusterInfoFieldMapping returns the labels of the cluster info metric from which cluster
+// info fields are loaded, parsed from a comma-separated list of field=label pairs; e.g.
+// "id=cluster_id,name=cluster_name". Malformed pairs are ignored.
+func GetClusterInfoFieldMapping() map[string]string {
+	mapping := map[string]string{}
+	for _, pair := range strings.Split(Get(ClusterInfoFieldMappingEnvVar, ""), ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		field, label := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		if field != "" && label != "" {
+			mapping[field] = label
+		}
+	}
+	return mapping
+}
+
+// GetClusterMapHTTPSDEndpoint returns the URL of an optional Prometheus HTTP SD endpoint
+// listing clusters to add to the cluster map.
+func GetClusterMapHTTPSDEndpoint() string {
+	return Get(ClusterMapHTTPSDEndpointEnvVar, "")
+}
+
+// GetClusterMapEtcdEndpoints returns the URLs of optional etcd endpoints in which the
+// cluster map is persisted, parsed from a comma-separated list.
+func GetClusterMapEtcdEndpoints() []string {
+	var endpoints []string
+	for _, endpoint := range strings.Split(Get(ClusterMapEtcdEndpointsEnvVar, ""), ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints
+}
+
+// GetClusterMapEtcdPrefix returns the prefix of the etcd keys of the cluster map. If
+// empty, the cluster map's default is used.
+func GetClusterMapEtcdPrefix() string {
+	return Get(ClusterMapEtcdPrefixEnvVar, "")
+}
+
+// IsClusterMapEventsEnabled returns true if Kubernetes Events are emitted when clusters
+// are added to or removed from the cluster map, which defaults to false.
+func IsClusterMapEventsEnabled() bool {
+	return GetBool(ClusterMapEventsEnabledEnvVar, false)
+}
+
+// GetBudgetEvaluationInterval returns how often budgets are evaluated against
+// month-to-date spend, which defaults to 60 minutes. A bare number is in minutes.
+func GetBudgetEvaluationInterval() time.Duration {
+	return getInterval(BudgetEvaluationIntervalMinutesEnvVar, 60*time.Minute, time.Minute)
+}
+
+// IsAuthEnabled returns true if requests to the API must be authenticated by
+// a bearer token, which defaults to false.
+func IsAuthEnabled() bool {
+	return GetBool(AuthEnabledEnvVar, false)
+}
+
+// GetAuthStaticTokens returns the bearer tokens accepted by the API and their
+// roles, formatted as a comma-separated list of token:role pairs.
+func GetAuthStaticTokens() string {
+	return Get(AuthStaticTokensEnvVar, "")
+}
+
+// GetAuthJWKSURL returns the URL of the JWKS used to verify JWT bearer tokens.
+func GetAuthJWKSURL() string {
+	return Get(AuthJWKSURLEnvVar, "")
+}
+
+// GetAuthJWTRoleClaim returns the JWT claim from which the role of a token is
+// read, which defaults to "role".
+func GetAuthJWTRoleClaim() string {
+	return Get(AuthJWTRoleClaimEnvVar, "role")
+}
+
+// GetAuthJWTIssuer returns the issuer which JWT bearer tokens must have in
+// their iss claim. If empty, the issuer is not checked.
+func GetAuthJWTIssuer() string {
+	return Get(AuthJWTIssuerEnvVar, "")
+}
+
+// GetAuthJWTAudience returns the audience which JWT bearer tokens must have in
+// their aud claim. If empty, the audience is not checked.
+func GetAuthJWTAudience() string {
+	return Get(AuthJWTAudienceEnvVar, "")
+}
+
+// IsAllocationCostMetricsEnabled returns true if the hourly cost of the last
+// full hour's allocations should be emitted as metrics, which defaults to false.
+func IsAllocationCostMetricsEnabled() bool {
+	return GetBool(AllocationCostMetricsEnabledEnvVar, false)
+}
+
+// GetAllocationCostMetricsInterval returns how often allocation cost metrics
+// are recomputed, which defaults to 15 minutes. A bare number is in minutes.
+func GetAllocationCostMetricsInterval() time.Duration {
+	return getInterval(AllocationCostMetricsIntervalMinutesEnvVar, 15*time.Minute, time.Minute)
+}
+
+// GetAllocationCostMetricsTopN returns the maximum number of series emitted
+// per allocation cost metric, beyond which the least costly allocations are
+// summed into a single series, which defaults to 50.
+func GetAllocationCostMetricsTopN() int {
+	return GetInt(AllocationCostMetricsTopNEnvVar, 50)
+}
+
+// GetAllocationCostMetricsTopK returns the number of namespaces and of
+// workloads emitted by the top-K cost metrics, which defaults to 10.
+func GetAllocationCostMetricsTopK() int {
+	return GetInt(AllocationCostMetricsTopKEnvVar, 10)
+}
+
+// GetAllocationCoverageGapThreshold returns the fraction of a pod's expected
+// samples which must be missing for its allocations to be reported as having
+// gaps, which defaults to 0.1.
+func GetAllocationCoverageGapThreshold() float64 {
+	return GetFloat64(AllocationCoverageGapThresholdEnvVar, 0.1)
+}
+
+// IsAllocationCostMetricsControllersEnabled returns true if allocation cost
+// metrics should also be emitted per controller, which defaults to false.
+func IsAllocationCostMetricsControllersEnabled() bool {
+	return AllocationCostMetricsControllersFlag.Enabled()
+}
+
+// GetReadinessExcludedChecks returns the names of the readiness checks which
+// should not be run, parsed from a comma-separated list; e.g. "prometheus,pricing"
+func GetReadinessExcludedChecks() []string {
+	var checks []string
+	for _, check := range strings.Split(Get(ReadinessExcludedChecksEnvVar, ""), ",") {
+		if check = strings.TrimSpace(check); check != "" {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// GetReadinessClusterMapTolerance returns how long after its last refresh an
+// empty cluster map is still considered ready, which defaults to 15 minutes. A
+// bare number is in minutes.
+func GetReadinessClusterMapTolerance() time.Duration {
+	return GetDurationWithUnit(ReadinessClusterMapToleranceMinutesEnvVar, 15*time.Minute, time.Minute)
+}
+
+// GetClusterMapRefreshInterval returns how often the cluster map is refreshed,
+// which defaults to the given interval. A bare number is in minutes.
+func GetClusterMapRefreshInterval(defaultInterval time.Duration) time.Duration {
+	return getInterval(ClusterMapRefreshIntervalMinutesEnvVar, defaultInterval, time.Minute)
+}
+
+// PricingProviders are the names of the providers whose pricing refresh interval
+// may be set individually
+var PricingProviders = []string{"aws", "azure", "gcp", "csv", "custom"}
+
+// GetPricingRefreshInterval returns the interval at which the pricing data of
+// the given provider, e.g. "aws", is downloaded again. It is read from
+// PRICING_REFRESH_INTERVAL_MINUTES_<PROVIDER> if set, and otherwise from
+// PRICING_REFRESH_INTERVAL_MINUTES, which defaults to 24 hours.
+func GetPricingRefreshInterval(provider string) time.Duration {
+	defaultInterval := getInterval(PricingRefreshIntervalMinutesEnvVar, 24*time.Hour, time.Minute)
+
+	key := pricingRefreshIntervalKey(provider)
+	if Get(key, "") == "" {
+		return defaultInterval
+	}
+	return getInterval(key, defaultInterval, time.Minute)
+}
+
+// pricingRefreshIntervalKey returns the setting of the pricing refresh interval
+// of the given provider
+func pricingRefreshIntervalKey(provider string) string {
+	return PricingRefreshIntervalMinutesEnvVar + "_" + strings.ToUpper(provider)
+}
+
+// IsPricingOfflineEnabled returns true if pricing data is loaded from static price
+// files, rather than downloaded from the providers' pricing APIs, e.g. in air-gapped
+// clusters, which defaults to false.
+func IsPricingOfflineEnabled() bool {
+	return GetBool(PricingOfflineEnabledEnvVar, false)
+}
+
+// GetStaticPricingPath returns the directory of the static price files loaded in
+// offline mode, which defaults to static-pricing/ in the config path.
+func GetStaticPricingPath() string {
+	return Get(StaticPricingPathEnvVar, GetConfigPathWithDefault("/models/")+"static-pricing/")
+}
+
+// GetPricingChangeThresholdPercent returns the percentage by which the hourly cost
+// of a node type must change between downloads of pricing data to be reported as
+// a pricing change, which defaults to 5.
+func GetPricingChangeThresholdPercent() float64 {
+	return GetFloat64(PricingChangeThresholdPercentEnvVar, 5.0)
+}
+
+// GetPricingChangeWebhookURL returns the URL to which pricing changes are posted,
+// if any.
+func GetPricingChangeWebhookURL() string {
+	return Get(PricingChangeWebhookURLEnvVar, "")
+}
+
+// getInterval parses the duration of a periodic task, which must be positive,
+// as GetDurationWithUnit. A non-positive duration is logged, and the default is
+// returned.
+func getInterval(key string, defaultInterval time.Duration, bareUnit time.Duration) time.Duration {
+	interval := GetDurationWithUnit(key, defaultInterval, bareUnit)
+	if interval <= 0 {
+		warnParseFailure(key, Get(key, ""), defaultInterval, fmt.Errorf("interval must be positive"))
+		return defaultInterval
+	}
+	return interval
+}
+
+// GetEnvConfigMapName returns the name of the ConfigMap backing the environment,
+// which is watched so that changes to it are applied without a restart. If empty,
+// which is the default, changes require a restart.
+func GetEnvConfigMapName() string {
+	return Get(EnvConfigMapNameEnvVar, "")
+}
@@ -0,0 +1,243 @@
+package env
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Credentials may be supplied indirectly, so that they are not visible in the pod spec,
+// by setting the credential's environment variable name with one of these suffixes. The
+// value of a credential is resolved in order of precedence from:
+//
+//  1. <NAME>_FILE, the path of a file containing the value, e.g. a mounted Secret
+//  2. <NAME>_SECRET, a reference to a key of a Secret, as "name/key" in the kubecost
+//     namespace, or "namespace/name/key"
+//  3. <NAME>, the value itself
+//
+// Trailing newlines are trimmed from values read from files and Secrets. If a file or
+// Secret is referenced but cannot be read, the credential is empty and the error is
+// reported by GetCredentialErrors, rather than falling back to a lower precedence source.
+const (
+	CredentialFileSuffix   = "_FILE"
+	CredentialSecretSuffix = "_SECRET"
+)
+
+// credentialSettings are the settings which may be supplied indirectly as credentials
+var credentialSettings = []string{
+	DBBasicAuthUsername,
+	DBBasicAuthPassword,
+	DBBearerToken,
+	MultiClusterBasicAuthUsername,
+	MultiClusterBasicAuthPassword,
+	MultiClusterBearerToken,
+	AWSAccessKeyIDEnvVar,
+	AWSAccessKeySecretEnvVar,
+	AzureStorageAccessKeyEnvVar,
+}
+
+// SecretReader reads the value of the key of the named Secret
+type SecretReader func(namespace, name, key string) (string, error)
+
+var (
+	credentialLock sync.RWMutex
+	secretReader   SecretReader
+
+	// credentialValues are the resolved values of credentials, and credentialErrors
+	// are the errors resolving them
+	credentialValues = map[string]string{}
+	credentialErrors = map[string]error{}
+)
+
+// SetSecretReader sets the reader used to resolve credentials which reference Secrets.
+// Until it is set, such credentials fail to resolve.
+func SetSecretReader(reader SecretReader) {
+	credentialLock.Lock()
+	defer credentialLock.Unlock()
+
+	secretReader = reader
+}
+
+// getCredential returns the resolved value of the credential, resolving it if it has
+// not been resolved since startup or the last refresh. The credential is resolved
+// without holding the lock, so that readers are not blocked on reading a Secret.
+func getCredential(key string) string {
+	credentialLock.RLock()
+	value, ok := credentialValues[key]
+	reader := secretReader
+	credentialLock.RUnlock()
+	if ok {
+		return value
+	}
+
+	value, err := resolveCredential(key, reader)
+
+	credentialLock.Lock()
+	defer credentialLock.Unlock()
+
+	// A concurrent resolution or refresh may have stored the value first
+	if stored, ok := credentialValues[key]; ok {
+		return stored
+	}
+	credentialValues[key] = value
+	setCredentialError(key, err)
+
+	return value
+}
+
+// isCredential returns true if the setting may be supplied indirectly as a credential
+func isCredential(key string) bool {
+	for _, credential := range credentialSettings {
+		if key == credential {
+			return true
+		}
+	}
+	return false
+}
+
+// effectiveValue returns the value of the setting in effect, which is resolved if the
+// setting is a credential
+func effectiveValue(key string) string {
+	if isCredential(key) {
+		return getCredential(key)
+	}
+	return Get(key, "")
+}
+
+// RefreshCredentials resolves each credential again, e.g. to pick up rotated files and
+// Secrets, and notifies the reload listeners of each credential whose value changed.
+func RefreshCredentials() []string {
+	credentialLock.RLock()
+	reader := secretReader
+	credentialLock.RUnlock()
+
+	values := make(map[string]string, len(credentialSettings))
+	errs := make(map[string]error, len(credentialSettings))
+	for _, key := range credentialSettings {
+		values[key], errs[key] = resolveCredential(key, reader)
+	}
+
+	credentialLock.Lock()
+
+	changed := []string{}
+	for _, key := range credentialSettings {
+		setCredentialError(key, errs[key])
+
+		if previous, ok := credentialValues[key]; !ok || previous != values[key] {
+			changed = append(changed, key)
+			credentialValues[key] = values[key]
+		}
+	}
+
+	credentialLock.Unlock()
+
+	notifyReloadListeners(changed)
+
+	return changed
+}
+
+// withCredentialChanges clears the resolved value of each credential whose environment
+// variable, file, or Secret reference is among the changed settings, so that it is
+// resolved again, and returns the changed settings with those credentials added.
+func withCredentialChanges(changed []string) []string {
+	credentialLock.Lock()
+	defer credentialLock.Unlock()
+
+	seen := make(map[string]bool, len(changed))
+	for _, key := range changed {
+		seen[key] = true
+	}
+
+	keys := append([]string{}, changed...)
+	for _, credential := range credentialSettings {
+		if !seen[credential] && !seen[credential+CredentialFileSuffix] && !seen[credential+CredentialSecretSuffix] {
+			continue
+		}
+
+		delete(credentialValues, credential)
+		delete(credentialErrors, credential)
+		if !seen[credential] {
+			keys = append(keys, credential)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// GetCredentialErrors returns the error resolving each credential whose file or Secret
+// could not be read, by the name of the credential, sorted.
+func GetCredentialErrors() []string {
+	credentialLock.RLock()
+	defer credentialLock.RUnlock()
+
+	errs := []string{}
+	for key, err := range credentialErrors {
+		errs = append(errs, fmt.Sprintf("%s: %s", key, err))
+	}
+	sort.Strings(errs)
+
+	return errs
+}
+
+// setCredentialError records the error resolving the credential, if any. The lock must
+// be held.
+func setCredentialError(key string, err error) {
+	if err != nil {
+		credentialErrors[key] = err
+	} else {
+		delete(credentialErrors, key)
+	}
+}
+
+// resolveCredential returns the value of the credential from its file, Secret, read
+// with the given reader, or environment variable, in that order of precedence.
+func resolveCredential(key string, reader SecretReader) (string, error) {
+	if path := Get(key+CredentialFileSuffix, ""); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s%s: %s", key, CredentialFileSuffix, err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	if ref := Get(key+CredentialSecretSuffix, ""); ref != "" {
+		namespace, name, secretKey, err := parseSecretRef(ref)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s%s: %s", key, CredentialSecretSuffix, err)
+		}
+		if reader == nil {
+			return "", fmt.Errorf("cannot read %s%s: Secrets are not available", key, CredentialSecretSuffix)
+		}
+
+		value, err := reader(namespace, name, secretKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s%s: %s", key, CredentialSecretSuffix, err)
+		}
+		return strings.TrimRight(value, "\r\n"), nil
+	}
+
+	return Get(key, ""), nil
+}
+
+// parseSecretRef parses a reference to a key of a Secret, as "name/key" in the kubecost
+// namespace, or "namespace/name/key".
+func parseSecretRef(ref string) (namespace, name, key string, err error) {
+	split := strings.Split(ref, "/")
+	switch len(split) {
+	case 2:
+		namespace, name, key = GetKubecostNamespace(), split[0], split[1]
+	case 3:
+		namespace, name, key = split[0], split[1], split[2]
+	default:
+		return "", "", "", fmt.Errorf("'%s' is not of the form name/key or namespace/name/key", ref)
+	}
+
+	if namespace == "" || name == "" || key == "" {
+		return "", "", "", fmt.Errorf("'%s' is not of the form name/key or namespace/name/key", ref)
+	}
+
+	return namespace, name, key, nil
+}
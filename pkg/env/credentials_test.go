@@ -0,0 +1,185 @@
+package env
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// setCredentialEnv sets the credential's environment variable, file, and Secret
+// reference, unsetting those which are empty, and returns a func to unset them all.
+func setCredentialEnv(key, value, file, secret string) func() {
+	set := func(k, v string) {
+		if v == "" {
+			os.Unsetenv(k)
+		} else {
+			os.Setenv(k, v)
+		}
+	}
+	set(key, value)
+	set(key+CredentialFileSuffix, file)
+	set(key+CredentialSecretSuffix, secret)
+
+	withCredentialChanges([]string{key})
+
+	return func() {
+		os.Unsetenv(key)
+		os.Unsetenv(key + CredentialFileSuffix)
+		os.Unsetenv(key + CredentialSecretSuffix)
+		withCredentialChanges([]string{key})
+	}
+}
+
+func TestGetCredentialPrecedence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "password")
+	err = ioutil.WriteFile(file, []byte("from-file\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write credential file: %s", err)
+	}
+
+	SetSecretReader(func(namespace, name, key string) (string, error) {
+		if namespace == "kubecost" && name == "prometheus" && key == "password" {
+			return "from-secret\r\n", nil
+		}
+		return "", fmt.Errorf("secret %s/%s not found", namespace, name)
+	})
+	defer SetSecretReader(nil)
+
+	cases := []struct {
+		name     string
+		value    string
+		file     string
+		secret   string
+		expected string
+		err      string
+	}{
+		{"env only", "from-env", "", "", "from-env", ""},
+		{"secret over env", "from-env", "", "kubecost/prometheus/password", "from-secret", ""},
+		{"file over secret", "from-env", file, "kubecost/prometheus/password", "from-file", ""},
+		{"missing file", "from-env", filepath.Join(dir, "missing"), "", "", DBBasicAuthPassword + CredentialFileSuffix},
+		{"missing secret", "from-env", "", "kubecost/other/password", "", DBBasicAuthPassword + CredentialSecretSuffix},
+		{"invalid secret reference", "from-env", "", "password", "", "not of the form"},
+	}
+
+	for _, c := range cases {
+		unset := setCredentialEnv(DBBasicAuthPassword, c.value, c.file, c.secret)
+
+		if value := GetDBBasicAuthUserPassword(); value != c.expected {
+			t.Errorf("%s: expected '%s'; got '%s'", c.name, c.expected, value)
+		}
+
+		errs := GetCredentialErrors()
+		if c.err == "" && len(errs) != 0 {
+			t.Errorf("%s: expected no errors; got %v", c.name, errs)
+		}
+		if c.err != "" && (len(errs) != 1 || !strings.Contains(errs[0], c.err)) {
+			t.Errorf("%s: expected error containing '%s'; got %v", c.name, c.err, errs)
+		}
+
+		unset()
+	}
+
+	if errs := GetCredentialErrors(); len(errs) != 0 {
+		t.Errorf("expected errors to be cleared once references are removed; got %v", errs)
+	}
+}
+
+func TestRefreshCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "token")
+	err = ioutil.WriteFile(file, []byte("first\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write credential file: %s", err)
+	}
+
+	unset := setCredentialEnv(DBBearerToken, "", file, "")
+	defer unset()
+
+	var notified []string
+	RegisterReloadListener(DBBearerToken, func(key, value string) {
+		notified = append(notified, value)
+	})
+
+	if token := GetDBBearerToken(); token != "first" {
+		t.Fatalf("expected 'first'; got '%s'", token)
+	}
+
+	// Unchanged credentials are not notified
+	RefreshCredentials()
+	if len(notified) != 0 {
+		t.Fatalf("expected no notifications; got %v", notified)
+	}
+
+	// Rotation
+	err = ioutil.WriteFile(file, []byte("second\n"), 0600)
+	if err != nil {
+		t.Fatalf("failed to write credential file: %s", err)
+	}
+	RefreshCredentials()
+
+	if token := GetDBBearerToken(); token != "second" {
+		t.Fatalf("expected rotated 'second'; got '%s'", token)
+	}
+	if len(notified) != 1 || notified[0] != "second" {
+		t.Fatalf("expected listener to be notified of 'second'; got %v", notified)
+	}
+
+	// Changing the reference by reload applies the new reference
+	os.Setenv(DBBearerToken, "from-env")
+	Reload(map[string]string{DBBearerToken + CredentialFileSuffix: ""})
+	defer Reload(map[string]string{})
+
+	if token := GetDBBearerToken(); token != "from-env" {
+		t.Fatalf("expected 'from-env' after file reference removed; got '%s'", token)
+	}
+	if len(notified) != 2 || notified[1] != "from-env" {
+		t.Fatalf("expected listener to be notified of 'from-env'; got %v", notified)
+	}
+}
+
+func TestGetCredentialDoesNotBlockReaders(t *testing.T) {
+	reading := make(chan struct{})
+	release := make(chan struct{})
+	SetSecretReader(func(namespace, name, key string) (string, error) {
+		close(reading)
+		<-release
+		return "from-secret", nil
+	})
+	defer SetSecretReader(nil)
+
+	unset := setCredentialEnv(DBBearerToken, "", "", "prometheus/token")
+	defer unset()
+
+	done := make(chan string)
+	go func() { done <- GetDBBearerToken() }()
+
+	// Other readers of credentials are not blocked while the Secret is read
+	<-reading
+	errsCh := make(chan []string)
+	go func() { errsCh <- GetCredentialErrors() }()
+	select {
+	case <-errsCh:
+	case <-time.After(time.Second):
+		t.Fatalf("expected credential readers not to block on reading a Secret")
+	}
+
+	close(release)
+	if token := <-done; token != "from-secret" {
+		t.Fatalf("expected 'from-secret'; got '%s'", token)
+	}
+}
@@ -13,9 +13,20 @@ import (
 // envMap contains Getter and Setter implementations for environment variables
 type envMap struct{}
 
-// Get returns the value for the provided environment variable
+// Get returns the value for the provided environment variable, or its override, if
+// it has one. If neither is set, the default of the applied cluster profile, if any,
+// is returned.
 func (em *envMap) Get(key string) string {
-	return os.Getenv(key)
+	if value, ok := lookupOverride(key); ok {
+		return value
+	}
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	if value, ok := lookupProfileDefault(key); ok {
+		return value
+	}
+	return ""
 }
 
 // Set sets the value for the provided key and returns true if successful. Otherwise,
@@ -0,0 +1,130 @@
+package env
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FeatureFlagEnvVarPrefix is the prefix of the environment variables of feature flags,
+// e.g. KUBECOST_FEATURE_IDLE_V2.
+const FeatureFlagEnvVarPrefix = "KUBECOST_FEATURE_"
+
+// FeatureFlag gates an optional or experimental behavior, which is enabled by setting
+// its environment variable to true. Flags migrated from existing settings fall back to
+// the setting's environment variable, LegacyEnvVar, if their own is unset.
+type FeatureFlag struct {
+	Name         string `json:"name"`
+	EnvVar       string `json:"envVar"`
+	LegacyEnvVar string `json:"legacyEnvVar,omitempty"`
+	Default      bool   `json:"default"`
+	Description  string `json:"description"`
+}
+
+// Enabled returns true if the flag's environment variable is true or, if it is unset,
+// its legacy environment variable is true. If both are unset, it returns true if the
+// flag is enabled by default.
+func (ff *FeatureFlag) Enabled() bool {
+	if ff.LegacyEnvVar != "" && Get(ff.EnvVar, "") == "" {
+		return GetBool(ff.LegacyEnvVar, ff.Default)
+	}
+
+	return GetBool(ff.EnvVar, ff.Default)
+}
+
+var (
+	featureFlagLock sync.RWMutex
+	featureFlags    = map[string]*FeatureFlag{}
+)
+
+// Feature flags migrated from existing settings, which remain enabled by the settings'
+// environment variables
+var (
+	CacheWarmingFlag = registerMigratedFeatureFlag("cache_warming", CacheWarmingEnabledEnvVar, true,
+		"Warm the aggregation caches in the background on startup.")
+
+	PodAnnotationsMetricFlag = registerMigratedFeatureFlag("pod_annotations_metric", EmitPodAnnotationsMetricEnvVar, false,
+		"Emit the kube_pod_annotations metric, containing pod annotations.")
+
+	NamespaceAnnotationsMetricFlag = registerMigratedFeatureFlag("namespace_annotations_metric", EmitNamespaceAnnotationsMetricEnvVar, false,
+		"Emit the kube_namespace_annotations metric, containing namespace annotations.")
+
+	KsmV1MetricsFlag = registerMigratedFeatureFlag("ksm_v1_metrics", EmitKsmV1MetricsEnvVar, true,
+		"Emit the KSM v1 metrics which were removed in KSM v2 and are required by cost-model.")
+)
+
+// Feature flags with their own environment variables
+var (
+	IngressMetricsFlag = registerFeatureFlag("ingress_metrics", EmitIngressMetricsEnvVar, "", false,
+		"Emit the kube_ingress_path metric, attributing ingress paths to backend services.")
+
+	AllocationCostMetricsControllersFlag = registerFeatureFlag("allocation_cost_metrics_controllers", AllocationCostMetricsControllersEnabledEnvVar, "", false,
+		"Emit allocation cost metrics per controller, as well as per namespace.")
+)
+
+// RegisterFeatureFlag registers a feature flag with the given name, default, and
+// description, which is enabled by the environment variable KUBECOST_FEATURE_<NAME>. It
+// panics if a flag of the same name is already registered, so should be called when
+// packages are initialized.
+func RegisterFeatureFlag(name string, defaultValue bool, description string) *FeatureFlag {
+	return registerFeatureFlag(name, FeatureFlagEnvVarPrefix+strings.ToUpper(name), "", defaultValue, description)
+}
+
+// registerMigratedFeatureFlag registers a feature flag migrated from the existing setting
+// of the given environment variable. The flag is enabled by KUBECOST_FEATURE_<NAME>, and
+// falls back to the setting if that is unset.
+func registerMigratedFeatureFlag(name, legacyEnvVar string, defaultValue bool, description string) *FeatureFlag {
+	return registerFeatureFlag(name, FeatureFlagEnvVarPrefix+strings.ToUpper(name), legacyEnvVar, defaultValue, description)
+}
+
+// registerFeatureFlag registers a feature flag enabled by the given environment variable,
+// or the legacy environment variable if set, both of which are recognized as settings when
+// the environment is validated.
+func registerFeatureFlag(name, envVar, legacyEnvVar string, defaultValue bool, description string) *FeatureFlag {
+	featureFlagLock.Lock()
+	defer featureFlagLock.Unlock()
+
+	if _, ok := featureFlags[name]; ok {
+		panic(fmt.Sprintf("feature flag %s is already registered", name))
+	}
+
+	flag := &FeatureFlag{
+		Name:         name,
+		EnvVar:       envVar,
+		LegacyEnvVar: legacyEnvVar,
+		Default:      defaultValue,
+		Description:  description,
+	}
+	featureFlags[name] = flag
+	settings[envVar] = BoolSetting
+	if legacyEnvVar != "" {
+		settings[legacyEnvVar] = BoolSetting
+	}
+
+	return flag
+}
+
+// IsFeatureEnabled returns true if the named feature flag is enabled. Unknown flags are
+// not enabled.
+func IsFeatureEnabled(name string) bool {
+	featureFlagLock.RLock()
+	flag, ok := featureFlags[name]
+	featureFlagLock.RUnlock()
+
+	return ok && flag.Enabled()
+}
+
+// GetFeatureFlags returns all of the registered feature flags, sorted by name.
+func GetFeatureFlags() []*FeatureFlag {
+	featureFlagLock.RLock()
+	defer featureFlagLock.RUnlock()
+
+	flags := make([]*FeatureFlag, 0, len(featureFlags))
+	for _, flag := range featureFlags {
+		flags = append(flags, flag)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+
+	return flags
+}
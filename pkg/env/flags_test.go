@@ -0,0 +1,105 @@
+package env
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	flag := RegisterFeatureFlag("test_experiment", false, "A test experiment.")
+	defer func() {
+		featureFlagLock.Lock()
+		delete(featureFlags, flag.Name)
+		featureFlagLock.Unlock()
+	}()
+
+	if flag.EnvVar != "KUBECOST_FEATURE_TEST_EXPERIMENT" {
+		t.Errorf("expected env var KUBECOST_FEATURE_TEST_EXPERIMENT; got %s", flag.EnvVar)
+	}
+	if _, ok := GetSettings()[flag.EnvVar]; !ok {
+		t.Errorf("expected %s to be a recognized setting", flag.EnvVar)
+	}
+
+	if IsFeatureEnabled("test_experiment") {
+		t.Errorf("expected flag to be disabled by default")
+	}
+	os.Setenv(flag.EnvVar, "true")
+	defer os.Unsetenv(flag.EnvVar)
+	if !IsFeatureEnabled("test_experiment") || !flag.Enabled() {
+		t.Errorf("expected flag to be enabled")
+	}
+
+	if IsFeatureEnabled("not_registered") {
+		t.Errorf("expected unknown flag to be disabled")
+	}
+
+	found := false
+	for _, f := range GetFeatureFlags() {
+		if f.Name == "test_experiment" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected flag to be listed")
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("expected duplicate registration to panic")
+			}
+		}()
+		RegisterFeatureFlag("test_experiment", true, "A duplicate.")
+	}()
+}
+
+func TestMigratedFeatureFlags(t *testing.T) {
+	// Migrated flags keep their defaults, and are enabled by the legacy environment
+	// variables if their own are unset
+	if !IsCacheWarmingEnabled() {
+		t.Errorf("expected cache warming to be enabled by default")
+	}
+	if CacheWarmingFlag.EnvVar != "KUBECOST_FEATURE_CACHE_WARMING" || CacheWarmingFlag.LegacyEnvVar != CacheWarmingEnabledEnvVar {
+		t.Errorf("unexpected cache warming env vars: %+v", CacheWarmingFlag)
+	}
+	os.Setenv(CacheWarmingEnabledEnvVar, "false")
+	defer os.Unsetenv(CacheWarmingEnabledEnvVar)
+	if IsCacheWarmingEnabled() || IsFeatureEnabled(CacheWarmingFlag.Name) {
+		t.Errorf("expected cache warming to be disabled by %s", CacheWarmingEnabledEnvVar)
+	}
+
+	// The flag's own environment variable takes precedence
+	os.Setenv(CacheWarmingFlag.EnvVar, "true")
+	defer os.Unsetenv(CacheWarmingFlag.EnvVar)
+	if !IsCacheWarmingEnabled() {
+		t.Errorf("expected cache warming to be enabled by %s", CacheWarmingFlag.EnvVar)
+	}
+
+	if IsEmitPodAnnotationsMetric() || !IsEmitKsmV1Metrics() {
+		t.Errorf("expected pod annotations to be disabled and KSM v1 metrics enabled by default")
+	}
+	os.Setenv(EmitPodAnnotationsMetricEnvVar, "true")
+	defer os.Unsetenv(EmitPodAnnotationsMetricEnvVar)
+	os.Setenv(KsmV1MetricsFlag.EnvVar, "false")
+	defer os.Unsetenv(KsmV1MetricsFlag.EnvVar)
+	if !IsEmitPodAnnotationsMetric() {
+		t.Errorf("expected pod annotations to be enabled by %s", EmitPodAnnotationsMetricEnvVar)
+	}
+	if IsEmitKsmV1Metrics() {
+		t.Errorf("expected KSM v1 metrics to be disabled by %s", KsmV1MetricsFlag.EnvVar)
+	}
+	for _, envVar := range []string{PodAnnotationsMetricFlag.EnvVar, EmitPodAnnotationsMetricEnvVar} {
+		if _, ok := GetSettings()[envVar]; !ok {
+			t.Errorf("expected %s to be a recognized setting", envVar)
+		}
+	}
+
+	if IsEmitIngressMetrics() {
+		t.Errorf("expected ingress metrics to be disabled by default")
+	}
+	os.Setenv(EmitIngressMetricsEnvVar, "true")
+	defer os.Unsetenv(EmitIngressMetricsEnvVar)
+	if !IsEmitIngressMetrics() {
+		t.Errorf("expected ingress metrics to be enabled by %s", EmitIngressMetricsEnvVar)
+	}
+}
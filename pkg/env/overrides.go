@@ -0,0 +1,68 @@
+package env
+
+import (
+	"sort"
+	"sync"
+)
+
+var (
+	overrideLock sync.RWMutex
+
+	// overrides are values of settings which take precedence over the
+	// environment, e.g. those changed at runtime through the settings API
+	overrides = map[string]string{}
+)
+
+// lookupOverride returns the override of the setting, if there is one
+func lookupOverride(key string) (string, bool) {
+	overrideLock.RLock()
+	defer overrideLock.RUnlock()
+
+	value, ok := overrides[key]
+	return value, ok
+}
+
+// SetOverrides sets the overrides of the given settings, which take precedence
+// over the environment, and notifies the reload listeners of each setting
+// whose override changed. A nil value removes the override, so that the
+// setting falls back to the environment.
+func SetOverrides(values map[string]*string) []string {
+	overrideLock.Lock()
+
+	changed := []string{}
+	for key, value := range values {
+		current, ok := overrides[key]
+		if value == nil {
+			if !ok {
+				continue
+			}
+			delete(overrides, key)
+		} else {
+			if ok && current == *value {
+				continue
+			}
+			overrides[key] = *value
+		}
+		changed = append(changed, key)
+	}
+	sort.Strings(changed)
+
+	overrideLock.Unlock()
+
+	notifyReloadListeners(withCredentialChanges(changed))
+
+	return changed
+}
+
+// GetOverrides returns the overrides of settings, which take precedence over
+// the environment.
+func GetOverrides() map[string]string {
+	overrideLock.RLock()
+	defer overrideLock.RUnlock()
+
+	o := make(map[string]string, len(overrides))
+	for key, value := range overrides {
+		o[key] = value
+	}
+	return o
+}
@@ -0,0 +1,128 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// The cluster profiles, by which the defaults of settings are tuned for the
+// cluster's purpose
+const (
+	DevelopmentClusterProfile = "development"
+	ProductionClusterProfile  = "production"
+)
+
+// clusterProfileDefaults are the defaults of settings for each cluster profile,
+// which take effect unless the setting is explicitly set. Development clusters
+// query at a coarser resolution and refresh less often, to cut the load on
+// Prometheus. The defaults of settings are tuned for production.
+var clusterProfileDefaults = map[string]map[string]string{
+	DevelopmentClusterProfile: {
+		ETLResolutionSeconds:                       "600",
+		ClusterMapRefreshIntervalMinutesEnvVar:     "30",
+		AllocationCostMetricsIntervalMinutesEnvVar: "60",
+		PricingRefreshIntervalMinutesEnvVar:        "2880",
+		CacheWarmingEnabledEnvVar:                  "false",
+	},
+	ProductionClusterProfile: {},
+}
+
+var (
+	profileLock sync.RWMutex
+
+	// profileDefaults are the defaults of settings of the applied cluster
+	// profile, which apply when the setting is not set in the environment
+	profileDefaults = map[string]string{}
+)
+
+// lookupProfileDefault returns the default of the setting of the applied cluster
+// profile, if there is one
+func lookupProfileDefault(key string) (string, bool) {
+	profileLock.RLock()
+	defer profileLock.RUnlock()
+
+	value, ok := profileDefaults[key]
+	return value, ok
+}
+
+// NormalizeClusterProfile returns the cluster profile of which the given name,
+// e.g. "dev" or "Production", is an alias, and false if it is not a known profile.
+func NormalizeClusterProfile(profile string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(profile)) {
+	case "dev", DevelopmentClusterProfile:
+		return DevelopmentClusterProfile, true
+	case "prod", ProductionClusterProfile:
+		return ProductionClusterProfile, true
+	}
+	return profile, false
+}
+
+// ApplyClusterProfile applies the defaults of settings of the given cluster
+// profile, replacing those of any profile applied before, and notifies the
+// reload listeners of each setting whose value changed as a result. It returns
+// the defaults which took effect, and the settings whose defaults did not
+// because they are explicitly set, sorted. An unknown profile is an error, and
+// applies nothing.
+func ApplyClusterProfile(profile string) (map[string]string, []string, error) {
+	normalized, ok := NormalizeClusterProfile(profile)
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown cluster profile '%s'", profile)
+	}
+	defaults := clusterProfileDefaults[normalized]
+
+	profileLock.Lock()
+
+	changed := []string{}
+	for key := range profileDefaults {
+		if _, ok := defaults[key]; !ok {
+			changed = append(changed, key)
+		}
+	}
+	for key, value := range defaults {
+		if current, ok := profileDefaults[key]; !ok || current != value {
+			changed = append(changed, key)
+		}
+	}
+
+	profileDefaults = make(map[string]string, len(defaults))
+	for key, value := range defaults {
+		profileDefaults[key] = value
+	}
+
+	profileLock.Unlock()
+
+	applied := map[string]string{}
+	overridden := []string{}
+	for key, value := range defaults {
+		if isExplicitlySet(key) {
+			overridden = append(overridden, key)
+		} else {
+			applied[key] = value
+		}
+	}
+	sort.Strings(overridden)
+
+	// Settings which are explicitly set are unchanged by their defaults
+	notify := []string{}
+	for _, key := range changed {
+		if !isExplicitlySet(key) {
+			notify = append(notify, key)
+		}
+	}
+	sort.Strings(notify)
+	notifyReloadListeners(withCredentialChanges(notify))
+
+	return applied, overridden, nil
+}
+
+// isExplicitlySet returns true if the setting is overridden, or set in the
+// environment, rather than defaulted
+func isExplicitlySet(key string) bool {
+	if _, ok := lookupOverride(key); ok {
+		return true
+	}
+	return os.Getenv(key) != ""
+}
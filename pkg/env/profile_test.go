@@ -0,0 +1,105 @@
+package env
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// resetClusterProfile removes the defaults of any applied cluster profile
+func resetClusterProfile() {
+	profileLock.Lock()
+	profileDefaults = map[string]string{}
+	profileLock.Unlock()
+}
+
+func TestApplyClusterProfile(t *testing.T) {
+	defer resetClusterProfile()
+
+	cases := map[string]struct {
+		profile     string
+		applied     int
+		refresh     time.Duration
+		cacheWarmed bool
+	}{
+		"development": {"development", len(clusterProfileDefaults[DevelopmentClusterProfile]), 30 * time.Minute, false},
+		"dev alias":   {"Dev", len(clusterProfileDefaults[DevelopmentClusterProfile]), 30 * time.Minute, false},
+		"production":  {"production", 0, 5 * time.Minute, true},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			resetClusterProfile()
+
+			applied, overridden, err := ApplyClusterProfile(c.profile)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(applied) != c.applied || len(overridden) != 0 {
+				t.Fatalf("expected %d applied and no overridden defaults; got %v and %v", c.applied, applied, overridden)
+			}
+
+			if refresh := GetClusterMapRefreshInterval(5 * time.Minute); refresh != c.refresh {
+				t.Errorf("expected cluster map refresh of %s; got %s", c.refresh, refresh)
+			}
+			if IsCacheWarmingEnabled() != c.cacheWarmed {
+				t.Errorf("expected cache warming enabled to be %t", c.cacheWarmed)
+			}
+		})
+	}
+
+	// Applying another profile replaces the defaults of the previous one
+	ApplyClusterProfile(DevelopmentClusterProfile)
+	ApplyClusterProfile(ProductionClusterProfile)
+	if refresh := GetClusterMapRefreshInterval(5 * time.Minute); refresh != 5*time.Minute {
+		t.Errorf("expected development defaults to be replaced; got %s", refresh)
+	}
+
+	if _, _, err := ApplyClusterProfile("staging"); err == nil {
+		t.Errorf("expected unknown profile to be an error")
+	}
+}
+
+func TestApplyClusterProfile_OverrideWins(t *testing.T) {
+	defer resetClusterProfile()
+
+	os.Setenv(ClusterMapRefreshIntervalMinutesEnvVar, "10")
+	defer os.Unsetenv(ClusterMapRefreshIntervalMinutesEnvVar)
+
+	enabled := "true"
+	SetOverrides(map[string]*string{CacheWarmingEnabledEnvVar: &enabled})
+	defer SetOverrides(map[string]*string{CacheWarmingEnabledEnvVar: nil})
+
+	var notified []string
+	RegisterReloadListener(AllocationCostMetricsIntervalMinutesEnvVar, func(key, value string) {
+		notified = append(notified, value)
+	})
+
+	applied, overridden, err := ApplyClusterProfile(DevelopmentClusterProfile)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(overridden) != 2 || overridden[0] != CacheWarmingEnabledEnvVar || overridden[1] != ClusterMapRefreshIntervalMinutesEnvVar {
+		t.Fatalf("expected explicitly set settings to be overridden; got %v", overridden)
+	}
+	if _, ok := applied[ClusterMapRefreshIntervalMinutesEnvVar]; ok {
+		t.Fatalf("expected explicitly set setting not to be applied; got %v", applied)
+	}
+	if applied[AllocationCostMetricsIntervalMinutesEnvVar] != "60" {
+		t.Fatalf("expected default of unset setting to be applied; got %v", applied)
+	}
+
+	if refresh := GetClusterMapRefreshInterval(5 * time.Minute); refresh != 10*time.Minute {
+		t.Errorf("expected environment to win over the profile; got %s", refresh)
+	}
+	if !IsCacheWarmingEnabled() {
+		t.Errorf("expected runtime override to win over the profile")
+	}
+	if interval := GetAllocationCostMetricsInterval(); interval != time.Hour {
+		t.Errorf("expected profile default of 1h; got %s", interval)
+	}
+	if len(notified) != 1 || notified[0] != "60" {
+		t.Errorf("expected listener to be notified of '60'; got %v", notified)
+	}
+}
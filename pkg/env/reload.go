@@ -0,0 +1,197 @@
+package env
+
+import (
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/kubecost/cost-model/pkg/log"
+)
+
+// ReloadListener is called with the new value of a setting when a reload
+// changes it. The value is empty if the setting was removed.
+type ReloadListener func(key string, value string)
+
+// ReloadResult describes the settings changed by a reload.
+type ReloadResult struct {
+	// Changed are the settings which were applied, in sorted order
+	Changed []string `json:"changed"`
+
+	// RequiresRestart are the changed settings which were not applied, because
+	// they are only read at startup, in sorted order
+	RequiresRestart []string `json:"requiresRestart"`
+}
+
+var (
+	reloadLock      sync.Mutex
+	reloadListeners = map[string][]ReloadListener{}
+	reloadCount     int64
+
+	// reloadedValues are the settings most recently applied by a reload
+	reloadedValues = map[string]string{}
+
+	// startupValues are the values which settings had before they were first
+	// changed by a reload, restored when a setting is removed. A nil value
+	// means that the setting was not set.
+	startupValues = map[string]*string{}
+)
+
+// restartRequiredSettings are the settings which are only read at startup,
+// and so cannot be changed by a reload.
+var restartRequiredSettings = map[string]bool{
+	PrometheusServerEndpointEnvVar:  true,
+	MaxQueryConcurrencyEnvVar:       true,
+	KubecostNamespaceEnvVar:         true,
+	KubeConfigPathEnvVar:            true,
+	ThanosEnabledEnvVar:             true,
+	ThanosQueryUrlEnvVar:            true,
+	ClusterInfoMetricNameEnvVar:     true,
+	ClusterInfoFieldMappingEnvVar:   true,
+	KubecostMetricsPodEnabledEnvVar: true,
+	EmitKsmV1MetricsEnvVar:          true,
+	KsmV1MetricsFlag.EnvVar:         true,
+	EmitIngressMetricsEnvVar:        true,
+	PrometheusTLSCertFileEnvVar:     true,
+	PrometheusTLSKeyFileEnvVar:      true,
+	PrometheusTLSCAFileEnvVar:       true,
+	EnvConfigMapNameEnvVar:          true,
+}
+
+// RequiresRestart returns true if the setting is only read at startup, and so
+// cannot be changed by a reload.
+func RequiresRestart(key string) bool {
+	return restartRequiredSettings[key]
+}
+
+// RegisterReloadListener registers a listener which is called with the new
+// value of the setting each time a reload changes it. Listeners are called
+// after every change of the reload has been applied.
+func RegisterReloadListener(key string, listener ReloadListener) {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	reloadListeners[key] = append(reloadListeners[key], listener)
+}
+
+// Reload applies the given settings, e.g. the data of the ConfigMap backing
+// the environment, and notifies the listeners of each changed setting.
+// Settings applied by a previous reload which are absent from values are
+// restored to their values at startup. Settings which require a restart are
+// not applied.
+func Reload(values map[string]string) *ReloadResult {
+	reloadLock.Lock()
+
+	result := &ReloadResult{
+		Changed:         []string{},
+		RequiresRestart: []string{},
+	}
+
+	// The new value of each setting which differs from the environment,
+	// including those removed since the previous reload
+	updates := map[string]*string{}
+	for key, value := range values {
+		if current, ok := os.LookupEnv(key); !ok || current != value {
+			v := value
+			updates[key] = &v
+		}
+	}
+	for key := range reloadedValues {
+		if _, ok := values[key]; !ok {
+			updates[key] = startupValues[key]
+		}
+	}
+
+	keys := make([]string, 0, len(updates))
+	for key := range updates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if RequiresRestart(key) {
+			result.RequiresRestart = append(result.RequiresRestart, key)
+			continue
+		}
+
+		if _, ok := startupValues[key]; !ok {
+			if current, ok := os.LookupEnv(key); ok {
+				startupValues[key] = &current
+			} else {
+				startupValues[key] = nil
+			}
+		}
+
+		value := updates[key]
+		if value == nil {
+			os.Unsetenv(key)
+			delete(reloadedValues, key)
+		} else {
+			os.Setenv(key, *value)
+			reloadedValues[key] = *value
+		}
+
+		result.Changed = append(result.Changed, key)
+	}
+
+	reloadCount++
+
+	reloadLock.Unlock()
+
+	// Logging is configured from the environment at startup, so it
+	// is configured again if its settings changed
+	for _, key := range result.Changed {
+		if log.IsConfigKey(key) {
+			log.Configure()
+			break
+		}
+	}
+
+	notifyReloadListeners(withCredentialChanges(result.Changed))
+
+	return result
+}
+
+// notifyReloadListeners calls the listeners of each of the given settings with
+// its value in effect. Listeners are called without the lock held, so that
+// they may read settings and register listeners.
+func notifyReloadListeners(keys []string) {
+	type notification struct {
+		listener ReloadListener
+		key      string
+		value    string
+	}
+
+	reloadLock.Lock()
+	var notifications []notification
+	for _, key := range keys {
+		for _, listener := range reloadListeners[key] {
+			notifications = append(notifications, notification{listener, key, effectiveValue(key)})
+		}
+	}
+	reloadLock.Unlock()
+
+	for _, n := range notifications {
+		n.listener(n.key, n.value)
+	}
+}
+
+// GetReloadCount returns the number of reloads applied since startup
+func GetReloadCount() int64 {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	return reloadCount
+}
+
+// GetReloadedSettings returns the settings most recently applied by a reload,
+// which override the environment at startup.
+func GetReloadedSettings() map[string]string {
+	reloadLock.Lock()
+	defer reloadLock.Unlock()
+
+	settings := make(map[string]string, len(reloadedValues))
+	for key, value := range reloadedValues {
+		settings[key] = value
+	}
+	return settings
+}
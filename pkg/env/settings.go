@@ -0,0 +1,151 @@
+package env
+
+// SettingKind is the format of the value of a setting
+type SettingKind string
+
+const (
+	StringSetting   SettingKind = "string"
+	BoolSetting     SettingKind = "bool"
+	IntSetting      SettingKind = "int"
+	FloatSetting    SettingKind = "float"
+	DurationSetting SettingKind = "duration"
+	URLSetting      SettingKind = "url"
+)
+
+// settings are all of the settings read by the env package, and the format of
+// their values. New settings should be added here, so that they are
+// recognized when the environment is validated. Feature flags are added when
+// they are registered.
+var settings = map[string]SettingKind{
+	AppVersionEnvVar: StringSetting,
+
+	AWSAccessKeyIDEnvVar:     StringSetting,
+	AWSAccessKeySecretEnvVar: StringSetting,
+	AWSClusterIDEnvVar:       StringSetting,
+
+	AzureStorageAccessKeyEnvVar:     StringSetting,
+	AzureStorageAccountNameEnvVar:   StringSetting,
+	AzureStorageContainerNameEnvVar: StringSetting,
+
+	KubecostNamespaceEnvVar:        StringSetting,
+	ClusterIDEnvVar:                StringSetting,
+	ClusterProfileEnvVar:           StringSetting,
+	PrometheusServerEndpointEnvVar: URLSetting,
+	MaxQueryConcurrencyEnvVar:      IntSetting,
+	QueryLoggingFileEnvVar:         StringSetting,
+	RemoteEnabledEnvVar:            BoolSetting,
+	RemotePWEnvVar:                 StringSetting,
+	SQLAddressEnvVar:               StringSetting,
+	UseCSVProviderEnvVar:           BoolSetting,
+	CSVRegionEnvVar:                StringSetting,
+	CSVEndpointEnvVar:              StringSetting,
+	CSVPathEnvVar:                  StringSetting,
+	ConfigPathEnvVar:               StringSetting,
+	CloudProviderAPIKeyEnvVar:      StringSetting,
+
+	EmitPodAnnotationsMetricEnvVar:       BoolSetting,
+	EmitNamespaceAnnotationsMetricEnvVar: BoolSetting,
+	EmitKsmV1MetricsEnvVar:               BoolSetting,
+	EmitAdmissionWebhookMetricsEnvVar:    BoolSetting,
+	EmitStorageClassMetricsEnvVar:        BoolSetting,
+	NodeLabelAllowlistEnvVar:             StringSetting,
+
+	ThanosEnabledEnvVar:      BoolSetting,
+	ThanosQueryUrlEnvVar:     URLSetting,
+	ThanosOffsetEnvVar:       DurationSetting,
+	ThanosMaxSourceResEnvVar: StringSetting,
+
+	LogCollectionEnabledEnvVar:    BoolSetting,
+	ProductAnalyticsEnabledEnvVar: BoolSetting,
+	ErrorReportingEnabledEnvVar:   BoolSetting,
+	ValuesReportingEnabledEnvVar:  BoolSetting,
+
+	DBBasicAuthUsername: StringSetting,
+	DBBasicAuthPassword: StringSetting,
+	DBBearerToken:       StringSetting,
+
+	MultiClusterBasicAuthUsername: StringSetting,
+	MultiClusterBasicAuthPassword: StringSetting,
+	MultiClusterBearerToken:       StringSetting,
+
+	InsecureSkipVerify: BoolSetting,
+
+	PrometheusTLSCertFileEnvVar: StringSetting,
+	PrometheusTLSKeyFileEnvVar:  StringSetting,
+	PrometheusTLSCAFileEnvVar:   StringSetting,
+
+	KubeConfigPathEnvVar: StringSetting,
+
+	UTCOffsetEnvVar: StringSetting,
+
+	ETLEnabledEnvVar:             BoolSetting,
+	ETLMaxBatchHours:             DurationSetting,
+	ETLResolutionSeconds:         DurationSetting,
+	LegacyExternalAPIDisabledVar: BoolSetting,
+
+	PromClusterIDLabelEnvVar: StringSetting,
+
+	ClusterInfoMetricNameEnvVar:    StringSetting,
+	ClusterInfoFieldMappingEnvVar:  StringSetting,
+	ClusterMapHTTPSDEndpointEnvVar: URLSetting,
+	ClusterMapEtcdEndpointsEnvVar:  StringSetting,
+	ClusterMapEtcdPrefixEnvVar:     StringSetting,
+	ClusterMapEventsEnabledEnvVar:  BoolSetting,
+
+	ClusterMapRefreshIntervalMinutesEnvVar: DurationSetting,
+
+	BudgetEvaluationIntervalMinutesEnvVar: DurationSetting,
+
+	PricingRefreshIntervalMinutesEnvVar: DurationSetting,
+
+	PricingOfflineEnabledEnvVar: BoolSetting,
+	StaticPricingPathEnvVar:     StringSetting,
+
+	PricingChangeThresholdPercentEnvVar: FloatSetting,
+	PricingChangeWebhookURLEnvVar:       URLSetting,
+
+	AuthEnabledEnvVar:      BoolSetting,
+	AuthStaticTokensEnvVar: StringSetting,
+	AuthJWKSURLEnvVar:      URLSetting,
+	AuthJWTRoleClaimEnvVar: StringSetting,
+	AuthJWTIssuerEnvVar:    StringSetting,
+	AuthJWTAudienceEnvVar:  StringSetting,
+
+	AllocationCostMetricsEnabledEnvVar:         BoolSetting,
+	AllocationCostMetricsIntervalMinutesEnvVar: DurationSetting,
+	AllocationCostMetricsTopNEnvVar:            IntSetting,
+	AllocationCostMetricsTopKEnvVar:            IntSetting,
+
+	AllocationCoverageGapThresholdEnvVar: FloatSetting,
+
+	ReadinessExcludedChecksEnvVar:             StringSetting,
+	ReadinessClusterMapToleranceMinutesEnvVar: DurationSetting,
+
+	EnvConfigMapNameEnvVar: StringSetting,
+
+	KubecostMetricsPodEnabledEnvVar: BoolSetting,
+	KubecostMetricsPodPortEnvVar:    IntSetting,
+}
+
+func init() {
+	// Credentials may also be supplied by file or Secret reference
+	for _, key := range credentialSettings {
+		settings[key+CredentialFileSuffix] = StringSetting
+		settings[key+CredentialSecretSuffix] = StringSetting
+	}
+
+	// The pricing refresh interval may be set for each provider
+	for _, provider := range PricingProviders {
+		settings[pricingRefreshIntervalKey(provider)] = DurationSetting
+	}
+}
+
+// GetSettings returns all of the settings read by the env package, and the
+// format of their values.
+func GetSettings() map[string]SettingKind {
+	s := make(map[string]SettingKind, len(settings))
+	for key, kind := range settings {
+		s[key] = kind
+	}
+	return s
+}
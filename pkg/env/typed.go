@@ -0,0 +1,117 @@
+package env
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// parseWarnings records the settings and values for which a parse failure has
+// been logged, so that each is only logged once
+var parseWarnings = struct {
+	sync.Mutex
+	logged map[string]bool
+}{logged: map[string]bool{}}
+
+// warnParseFailure logs a warning that the value of the setting failed to
+// parse, once per setting and value.
+func warnParseFailure(key, value string, defaultValue interface{}, err error) {
+	parseWarnings.Lock()
+	defer parseWarnings.Unlock()
+
+	k := key + "=" + value
+	if parseWarnings.logged[k] {
+		return
+	}
+	parseWarnings.logged[k] = true
+
+	log.Warningf("Invalid value for %s: %s; using default %v", key, err, defaultValue)
+}
+
+// ParseDuration parses a duration in Go duration syntax, e.g. "1h30m", or in
+// Prometheus duration syntax, e.g. "2d". A bare number, e.g. "90", is
+// interpreted in the given unit, for backward compatibility with settings
+// whose names specify their unit, e.g. ETL_RESOLUTION_SECONDS.
+func ParseDuration(value string, bareUnit time.Duration) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return 0, fmt.Errorf("'%s' is not a duration, e.g. \"10m\" or \"2h\"", value)
+		}
+		return time.Duration(n * float64(bareUnit)), nil
+	}
+
+	if d, err := time.ParseDuration(value); err == nil {
+		return d, nil
+	}
+
+	if d, err := timeutil.ParseDuration(value); err == nil {
+		return d, nil
+	}
+
+	return 0, fmt.Errorf("'%s' is not a duration, e.g. \"10m\" or \"2h\"", value)
+}
+
+// GetDuration parses a time.Duration from the environment variable key parameter, in Go
+// duration syntax, e.g. "10m", or as a bare number of seconds, e.g. "600". If the
+// environment variable is empty, the defaultValue parameter is returned. If it fails to
+// parse, a warning is logged and the defaultValue parameter is returned.
+func GetDuration(key string, defaultValue time.Duration) time.Duration {
+	return GetDurationWithUnit(key, defaultValue, time.Second)
+}
+
+// GetDurationWithUnit parses a time.Duration from the environment variable key parameter,
+// as GetDuration, except that a bare number is interpreted in the given unit; e.g. for
+// settings whose names specify their unit, such as CLUSTER_MAP_REFRESH_INTERVAL_MINUTES.
+func GetDurationWithUnit(key string, defaultValue time.Duration, bareUnit time.Duration) time.Duration {
+	value := Get(key, "")
+	if value == "" {
+		return defaultValue
+	}
+
+	d, err := ParseDuration(value, bareUnit)
+	if err != nil {
+		warnParseFailure(key, value, defaultValue, err)
+		return defaultValue
+	}
+
+	return d
+}
+
+// GetQuantity parses a resource.Quantity from the environment variable key parameter,
+// e.g. "500Mi" or "2G". If the environment variable is empty, the defaultValue parameter
+// is returned. If it fails to parse, a warning is logged and the defaultValue parameter
+// is returned.
+func GetQuantity(key string, defaultValue resource.Quantity) resource.Quantity {
+	value := Get(key, "")
+	if value == "" {
+		return defaultValue
+	}
+
+	q, err := resource.ParseQuantity(strings.TrimSpace(value))
+	if err != nil {
+		warnParseFailure(key, value, defaultValue.String(), err)
+		return defaultValue
+	}
+
+	return q
+}
+
+// GetBytes parses a number of bytes from the environment variable key parameter, as a
+// resource.Quantity, e.g. "500Mi" or "2G", or a bare number of bytes. Fractional bytes
+// are rounded up. If the environment variable is empty, the defaultValue parameter is
+// returned. If it fails to parse, a warning is logged and the defaultValue parameter is
+// returned.
+func GetBytes(key string, defaultValue int64) int64 {
+	q := GetQuantity(key, *resource.NewQuantity(defaultValue, resource.BinarySI))
+	return q.Value()
+}
@@ -0,0 +1,120 @@
+package env
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const testTypedKey = "TEST_ENV_TYPED_SETTING"
+
+func TestGetDuration(t *testing.T) {
+	defer os.Unsetenv(testTypedKey)
+
+	cases := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{"unset", "", 5 * time.Minute},
+		{"go duration", "10m", 10 * time.Minute},
+		{"compound go duration", "1h30m", 90 * time.Minute},
+		{"fractional go duration", "1.5h", 90 * time.Minute},
+		{"sub-second go duration", "250ms", 250 * time.Millisecond},
+		{"prometheus days", "2d", 48 * time.Hour},
+		{"bare seconds", "600", 10 * time.Minute},
+		{"fractional bare seconds", "1.5", 1500 * time.Millisecond},
+		{"surrounding space", " 2h ", 2 * time.Hour},
+		{"zero", "0", 0},
+		{"invalid", "ten minutes", 5 * time.Minute},
+		{"invalid unit", "10x", 5 * time.Minute},
+		{"not a number", "NaN", 5 * time.Minute},
+	}
+
+	for _, c := range cases {
+		os.Setenv(testTypedKey, c.value)
+		if d := GetDuration(testTypedKey, 5*time.Minute); d != c.expected {
+			t.Errorf("%s: expected %s for '%s'; got %s", c.name, c.expected, c.value, d)
+		}
+	}
+}
+
+func TestGetDurationWithUnit(t *testing.T) {
+	defer os.Unsetenv(testTypedKey)
+
+	cases := []struct {
+		name     string
+		value    string
+		unit     time.Duration
+		expected time.Duration
+	}{
+		{"bare minutes", "15", time.Minute, 15 * time.Minute},
+		{"bare hours", "6", time.Hour, 6 * time.Hour},
+		{"go duration ignores unit", "90s", time.Minute, 90 * time.Second},
+		{"invalid", "fifteen", time.Minute, time.Hour},
+	}
+
+	for _, c := range cases {
+		os.Setenv(testTypedKey, c.value)
+		if d := GetDurationWithUnit(testTypedKey, time.Hour, c.unit); d != c.expected {
+			t.Errorf("%s: expected %s for '%s'; got %s", c.name, c.expected, c.value, d)
+		}
+	}
+}
+
+func TestGetInterval(t *testing.T) {
+	defer os.Unsetenv(testTypedKey)
+
+	cases := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"30", 30 * time.Minute},
+		{"2h", 2 * time.Hour},
+		{"0", time.Hour},
+		{"-5m", time.Hour},
+		{"soon", time.Hour},
+	}
+
+	for _, c := range cases {
+		os.Setenv(testTypedKey, c.value)
+		if d := getInterval(testTypedKey, time.Hour, time.Minute); d != c.expected {
+			t.Errorf("expected %s for '%s'; got %s", c.expected, c.value, d)
+		}
+	}
+}
+
+func TestGetQuantity(t *testing.T) {
+	defer os.Unsetenv(testTypedKey)
+
+	def := resource.MustParse("100Mi")
+
+	cases := []struct {
+		name     string
+		value    string
+		expected int64
+	}{
+		{"unset", "", 100 * 1024 * 1024},
+		{"binary suffix", "500Mi", 500 * 1024 * 1024},
+		{"decimal suffix", "2G", 2 * 1000 * 1000 * 1000},
+		{"bare bytes", "4096", 4096},
+		{"exponent", "1e3", 1000},
+		{"invalid", "lots", 100 * 1024 * 1024},
+		{"invalid suffix", "5MB", 100 * 1024 * 1024},
+	}
+
+	for _, c := range cases {
+		os.Setenv(testTypedKey, c.value)
+
+		q := GetQuantity(testTypedKey, def)
+		if q.Value() != c.expected {
+			t.Errorf("%s: expected quantity %d for '%s'; got %d", c.name, c.expected, c.value, q.Value())
+		}
+
+		if b := GetBytes(testTypedKey, 100*1024*1024); b != c.expected {
+			t.Errorf("%s: expected %d bytes for '%s'; got %d", c.name, c.expected, c.value, b)
+		}
+	}
+}
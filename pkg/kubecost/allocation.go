@@ -3,6 +3,7 @@ package kubecost
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"sort"
 	"strings"
 	"sync"
@@ -438,6 +439,11 @@ func (a *Allocation) RAMEfficiency() float64 {
 
 // TotalEfficiency is the cost-weighted average of CPU and RAM efficiency. If
 // there is no cost at all, then efficiency is zero.
+//
+// Because request and usage averages are accumulated as core-minutes and
+// byte-minutes when Allocations are added, the efficiency of an aggregated
+// Allocation is weighted by each constituent's requests over the time it ran,
+// rather than being a simple average of the constituents' efficiencies.
 func (a *Allocation) TotalEfficiency() float64 {
 	if a.RAMTotalCost()+a.CPUTotalCost() > 0 {
 		ramCostEff := a.RAMEfficiency() * a.RAMTotalCost()
@@ -448,6 +454,35 @@ func (a *Allocation) TotalEfficiency() float64 {
 	return 0.0
 }
 
+// requestEfficiencies returns the CPU, RAM, and total efficiency of the
+// Allocation as reported by the API. Efficiency is undefined for a resource
+// with no request, so it is returned as NaN (which is encoded as null) and the
+// total efficiency is weighted over the remaining resources only.
+func (a *Allocation) requestEfficiencies() (cpu, ram, total float64) {
+	cpu, ram = math.NaN(), math.NaN()
+
+	weightedEff, weight := 0.0, 0.0
+	if a.CPUCoreRequestAverage > 0 {
+		cpu = a.CPUEfficiency()
+		weightedEff += cpu * a.CPUTotalCost()
+		weight += a.CPUTotalCost()
+	}
+	if a.RAMBytesRequestAverage > 0 {
+		ram = a.RAMEfficiency()
+		weightedEff += ram * a.RAMTotalCost()
+		weight += a.RAMTotalCost()
+	}
+
+	if math.IsNaN(cpu) && math.IsNaN(ram) {
+		return cpu, ram, math.NaN()
+	}
+	if weight <= 0 {
+		return cpu, ram, 0.0
+	}
+
+	return cpu, ram, weightedEff / weight
+}
+
 // CPUCores converts the Allocation's CPUCoreHours into average CPUCores
 func (a *Allocation) CPUCores() float64 {
 	if a.Minutes() <= 0.0 {
@@ -492,6 +527,8 @@ func (a *Allocation) ResetAdjustments() {
 
 // MarshalJSON implements json.Marshaler interface
 func (a *Allocation) MarshalJSON() ([]byte, error) {
+	cpuEfficiency, ramEfficiency, totalEfficiency := a.requestEfficiencies()
+
 	buffer := bytes.NewBufferString("{")
 	jsonEncodeString(buffer, "name", a.Name, ",")
 	jsonEncode(buffer, "properties", a.Properties, ",")
@@ -505,7 +542,7 @@ func (a *Allocation) MarshalJSON() ([]byte, error) {
 	jsonEncodeFloat64(buffer, "cpuCoreHours", a.CPUCoreHours, ",")
 	jsonEncodeFloat64(buffer, "cpuCost", a.CPUCost, ",")
 	jsonEncodeFloat64(buffer, "cpuCostAdjustment", a.CPUCostAdjustment, ",")
-	jsonEncodeFloat64(buffer, "cpuEfficiency", a.CPUEfficiency(), ",")
+	jsonEncodeFloat64(buffer, "cpuEfficiency", cpuEfficiency, ",")
 	jsonEncodeFloat64(buffer, "gpuCount", a.GPUs(), ",")
 	jsonEncodeFloat64(buffer, "gpuHours", a.GPUHours, ",")
 	jsonEncodeFloat64(buffer, "gpuCost", a.GPUCost, ",")
@@ -527,11 +564,11 @@ func (a *Allocation) MarshalJSON() ([]byte, error) {
 	jsonEncodeFloat64(buffer, "ramByteHours", a.RAMByteHours, ",")
 	jsonEncodeFloat64(buffer, "ramCost", a.RAMCost, ",")
 	jsonEncodeFloat64(buffer, "ramCostAdjustment", a.RAMCostAdjustment, ",")
-	jsonEncodeFloat64(buffer, "ramEfficiency", a.RAMEfficiency(), ",")
+	jsonEncodeFloat64(buffer, "ramEfficiency", ramEfficiency, ",")
 	jsonEncodeFloat64(buffer, "sharedCost", a.SharedCost, ",")
 	jsonEncodeFloat64(buffer, "externalCost", a.ExternalCost, ",")
 	jsonEncodeFloat64(buffer, "totalCost", a.TotalCost(), ",")
-	jsonEncodeFloat64(buffer, "totalEfficiency", a.TotalEfficiency(), ",")
+	jsonEncodeFloat64(buffer, "totalEfficiency", totalEfficiency, ",")
 	jsonEncode(buffer, "rawAllocationOnly", a.RawAllocationOnly, "")
 	buffer.WriteString("}")
 	return buffer.Bytes(), nil
@@ -1527,7 +1564,7 @@ func (a *Allocation) generateKey(aggregateBy []string, labelConfig *LabelConfig)
 				controllerKind = UnallocatedSuffix
 			}
 			names = append(names, controllerKind)
-		case agg == AllocationDaemonSetProp || agg == AllocationStatefulSetProp || agg == AllocationDeploymentProp || agg == AllocationJobProp:
+		case agg == AllocationDaemonSetProp || agg == AllocationStatefulSetProp || agg == AllocationDeploymentProp || agg == AllocationJobProp || agg == AllocationCronJobProp:
 			controller := a.Properties.Controller
 			if agg != a.Properties.ControllerKind || controller == "" {
 				// The allocation does not have the specified controller kind
@@ -379,6 +379,93 @@ func TestAllocation_AddDifferentController(t *testing.T) {
 
 }
 
+func TestAllocation_Efficiency(t *testing.T) {
+	gib := 1024.0 * 1024.0 * 1024.0
+
+	s1 := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	e1 := time.Date(2021, time.January, 1, 12, 0, 0, 0, time.UTC)
+	a1 := &Allocation{
+		Window:                 NewWindow(&s1, &e1),
+		Start:                  s1,
+		End:                    e1,
+		Properties:             &AllocationProperties{},
+		CPUCoreRequestAverage:  2.0,
+		CPUCoreUsageAverage:    1.0,
+		CPUCost:                2.0,
+		RAMBytesRequestAverage: 4.0 * gib,
+		RAMBytesUsageAverage:   2.0 * gib,
+		RAMCost:                1.0,
+	}
+
+	// a2 only runs for a quarter of the day and has no RAM request
+	s2 := time.Date(2021, time.January, 1, 12, 0, 0, 0, time.UTC)
+	e2 := time.Date(2021, time.January, 1, 18, 0, 0, 0, time.UTC)
+	a2 := &Allocation{
+		Window:                NewWindow(&s2, &e2),
+		Start:                 s2,
+		End:                   e2,
+		Properties:            &AllocationProperties{},
+		CPUCoreRequestAverage: 1.0,
+		CPUCoreUsageAverage:   1.0,
+		CPUCost:               0.5,
+		RAMBytesUsageAverage:  1.0 * gib,
+	}
+
+	act, err := a1.Add(a2)
+	if err != nil {
+		t.Fatalf("Allocation.Add: unexpected error: %s", err)
+	}
+
+	// Efficiencies are weighted by request over time, not averaged:
+	// CPU efficiency = (1.0*720 + 1.0*360)/(2.0*720 + 1.0*360) = 0.600
+	// RAM efficiency = (2.0*720 + 1.0*360)/(4.0*720 + 0.0*360) = 0.625
+	// Total efficiency = (0.600*2.5 + 0.625*1.0)/(3.5) = 0.6071429
+	if !util.IsApproximately(0.600, act.CPUEfficiency()) {
+		t.Fatalf("Allocation.CPUEfficiency: expected %f; actual %f", 0.600, act.CPUEfficiency())
+	}
+	if !util.IsApproximately(0.625, act.RAMEfficiency()) {
+		t.Fatalf("Allocation.RAMEfficiency: expected %f; actual %f", 0.625, act.RAMEfficiency())
+	}
+	if !util.IsApproximately(0.6071429, act.TotalEfficiency()) {
+		t.Fatalf("Allocation.TotalEfficiency: expected %f; actual %f", 0.6071429, act.TotalEfficiency())
+	}
+
+	decode := func(alloc *Allocation) map[string]interface{} {
+		data, err := json.Marshal(alloc)
+		if err != nil {
+			t.Fatalf("Allocation.MarshalJSON: unexpected error: %s", err)
+		}
+		m := map[string]interface{}{}
+		if err := json.Unmarshal(data, &m); err != nil {
+			t.Fatalf("Allocation.MarshalJSON: unexpected error: %s", err)
+		}
+		return m
+	}
+
+	m := decode(act)
+	if eff, ok := m["totalEfficiency"].(float64); !ok || !util.IsApproximately(0.6071429, eff) {
+		t.Fatalf("Allocation.MarshalJSON: expected totalEfficiency %f; actual %v", 0.6071429, m["totalEfficiency"])
+	}
+
+	// Without a RAM request, RAM efficiency is null and the total efficiency
+	// is the CPU efficiency alone.
+	m = decode(a2)
+	if m["ramEfficiency"] != nil {
+		t.Fatalf("Allocation.MarshalJSON: expected ramEfficiency null; actual %v", m["ramEfficiency"])
+	}
+	if eff, ok := m["totalEfficiency"].(float64); !ok || !util.IsApproximately(1.0, eff) {
+		t.Fatalf("Allocation.MarshalJSON: expected totalEfficiency %f; actual %v", 1.0, m["totalEfficiency"])
+	}
+
+	// Without any requests, all efficiencies are null
+	m = decode(&Allocation{Window: NewWindow(&s1, &e1), Start: s1, End: e1, Properties: &AllocationProperties{}, CPUCoreUsageAverage: 1.0, CPUCost: 1.0})
+	for _, key := range []string{"cpuEfficiency", "ramEfficiency", "totalEfficiency"} {
+		if m[key] != nil {
+			t.Fatalf("Allocation.MarshalJSON: expected %s null; actual %v", key, m[key])
+		}
+	}
+}
+
 func TestAllocation_MarshalJSON(t *testing.T) {
 	start := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
 	end := time.Date(2021, time.January, 2, 0, 0, 0, 0, time.UTC)
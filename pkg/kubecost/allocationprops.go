@@ -25,6 +25,7 @@ const (
 	AllocationStatefulSetProp    string = "statefulset"
 	AllocationDaemonSetProp      string = "daemonset"
 	AllocationJobProp            string = "job"
+	AllocationCronJobProp        string = "cronjob"
 	AllocationDepartmentProp     string = "department"
 	AllocationEnvironmentProp    string = "environment"
 	AllocationOwnerProp          string = "owner"
@@ -64,6 +65,8 @@ func ParseProperty(text string) (string, error) {
 		return AllocationStatefulSetProp, nil
 	case "job":
 		return AllocationJobProp, nil
+	case "cronjob":
+		return AllocationCronJobProp, nil
 	case "department":
 		return AllocationDepartmentProp, nil
 	case "environment":
@@ -320,3 +323,46 @@ func (p *AllocationProperties) String() string {
 
 	return fmt.Sprintf("{%s}", strings.Join(strs, "; "))
 }
+
+// AllocationScope limits a set of allocations to those of a cluster, namespace,
+// and/or set of labels. An empty scope includes all allocations. Label names may
+// be given as they are in Kubernetes, e.g. "app.kubernetes.io/name", and are
+// sanitized to match the label names of AllocationProperties.
+type AllocationScope struct {
+	Cluster   string            `json:"cluster,omitempty"`
+	Namespace string            `json:"namespace,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// Matches returns true if the allocation is within the scope. Idle allocations
+// are only within scopes which are not limited by namespace or labels.
+func (s *AllocationScope) Matches(alloc *Allocation) bool {
+	props := alloc.Properties
+	if props == nil {
+		return false
+	}
+
+	if s.Cluster != "" && props.Cluster != s.Cluster {
+		return false
+	}
+
+	if s.Namespace == "" && len(s.Labels) == 0 {
+		return true
+	}
+
+	if alloc.IsIdle() {
+		return false
+	}
+
+	if s.Namespace != "" && props.Namespace != s.Namespace {
+		return false
+	}
+
+	for name, value := range s.Labels {
+		if v, ok := props.Labels[prom.SanitizeLabelName(name)]; !ok || v != value {
+			return false
+		}
+	}
+
+	return true
+}
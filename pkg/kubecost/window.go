@@ -23,20 +23,14 @@ const (
 // in the given time's timezone.
 // e.g. 2020-01-01T12:37:48-0700, 24h = 2020-01-01T00:00:00-0700
 func RoundBack(t time.Time, resolution time.Duration) time.Time {
-	_, offSec := t.Zone()
-	return t.Add(time.Duration(offSec) * time.Second).Truncate(resolution).Add(-time.Duration(offSec) * time.Second)
+	return timeutil.AlignBack(t, resolution)
 }
 
 // RoundForward rounds the given time forward to a multiple of the given resolution
 // in the given time's timezone.
 // e.g. 2020-01-01T12:37:48-0700, 24h = 2020-01-02T00:00:00-0700
 func RoundForward(t time.Time, resolution time.Duration) time.Time {
-	back := RoundBack(t, resolution)
-	if back.Equal(t) {
-		// The given time is exactly a multiple of the given resolution
-		return t
-	}
-	return back.Add(resolution)
+	return timeutil.AlignForward(t, resolution)
 }
 
 // Window defines a period of time with a start and an end. If either start or
@@ -112,182 +106,15 @@ func ParseWindowWithOffset(window string, offset time.Duration) (Window, error)
 }
 
 // parseWindow generalizes the parsing of window strings, relative to a given
-// moment in time, defined as "now".
+// moment in time, defined as "now". See timeutil.ParseWindow for the accepted
+// formats.
 func parseWindow(window string, now time.Time) (Window, error) {
-	// compute UTC offset in terms of minutes
-	offHr := now.UTC().Hour() - now.Hour()
-	offMin := (now.UTC().Minute() - now.Minute()) + (offHr * 60)
-	offset := time.Duration(offMin) * time.Minute
-
-	if window == "today" {
-		start := now
-		start = start.Truncate(time.Hour * 24)
-		start = start.Add(offset)
-
-		end := start.Add(time.Hour * 24)
-
-		return NewWindow(&start, &end), nil
-	}
-
-	if window == "yesterday" {
-		start := now
-		start = start.Truncate(time.Hour * 24)
-		start = start.Add(offset)
-		start = start.Add(time.Hour * -24)
-
-		end := start.Add(time.Hour * 24)
-
-		return NewWindow(&start, &end), nil
-	}
-
-	if window == "week" {
-		// now
-		start := now
-		// 00:00 today, accounting for timezone offset
-		start = start.Truncate(time.Hour * 24)
-		start = start.Add(offset)
-		// 00:00 Sunday of the current week
-		start = start.Add(-24 * time.Hour * time.Duration(start.Weekday()))
-
-		end := now
-
-		return NewWindow(&start, &end), nil
-	}
-
-	if window == "lastweek" {
-		// now
-		start := now
-		// 00:00 today, accounting for timezone offset
-		start = start.Truncate(time.Hour * 24)
-		start = start.Add(offset)
-		// 00:00 Sunday of last week
-		start = start.Add(-24 * time.Hour * time.Duration(start.Weekday()+7))
-
-		end := start.Add(7 * 24 * time.Hour)
-
-		return NewWindow(&start, &end), nil
-	}
-
-	if window == "month" {
-		// now
-		start := now
-		// 00:00 today, accounting for timezone offset
-		start = start.Truncate(time.Hour * 24)
-		start = start.Add(offset)
-		// 00:00 1st of this month
-		start = start.Add(-24 * time.Hour * time.Duration(start.Day()-1))
-
-		end := now
-
-		return NewWindow(&start, &end), nil
-	}
-
-	if window == "month" {
-		// now
-		start := now
-		// 00:00 today, accounting for timezone offset
-		start = start.Truncate(time.Hour * 24)
-		start = start.Add(offset)
-		// 00:00 1st of this month
-		start = start.Add(-24 * time.Hour * time.Duration(start.Day()-1))
-
-		end := now
-
-		return NewWindow(&start, &end), nil
-	}
-
-	if window == "lastmonth" {
-		// now
-		end := now
-		// 00:00 today, accounting for timezone offset
-		end = end.Truncate(time.Hour * 24)
-		end = end.Add(offset)
-		// 00:00 1st of this month
-		end = end.Add(-24 * time.Hour * time.Duration(end.Day()-1))
-
-		// 00:00 last day of last month
-		start := end.Add(-24 * time.Hour)
-		// 00:00 1st of last month
-		start = start.Add(-24 * time.Hour * time.Duration(start.Day()-1))
-
-		return NewWindow(&start, &end), nil
-	}
-
-	// Match duration strings; e.g. "45m", "24h", "7d"
-	regex := regexp.MustCompile(`^(\d+)(m|h|d)$`)
-	match := regex.FindStringSubmatch(window)
-	if match != nil {
-		dur := time.Minute
-		if match[2] == "h" {
-			dur = time.Hour
-		}
-		if match[2] == "d" {
-			dur = 24 * time.Hour
-		}
-
-		num, _ := strconv.ParseInt(match[1], 10, 64)
-
-		end := now
-		start := end.Add(-time.Duration(num) * dur)
-
-		return NewWindow(&start, &end), nil
-	}
-
-	// Match duration strings with offset; e.g. "45m offset 15m", etc.
-	regex = regexp.MustCompile(`^(\d+)(m|h|d) offset (\d+)(m|h|d)$`)
-	match = regex.FindStringSubmatch(window)
-	if match != nil {
-		end := now
-
-		offUnit := time.Minute
-		if match[4] == "h" {
-			offUnit = time.Hour
-		}
-		if match[4] == "d" {
-			offUnit = 24 * time.Hour
-		}
-
-		offNum, _ := strconv.ParseInt(match[3], 10, 64)
-
-		end = end.Add(-time.Duration(offNum) * offUnit)
-
-		durUnit := time.Minute
-		if match[2] == "h" {
-			durUnit = time.Hour
-		}
-		if match[2] == "d" {
-			durUnit = 24 * time.Hour
-		}
-
-		durNum, _ := strconv.ParseInt(match[1], 10, 64)
-
-		start := end.Add(-time.Duration(durNum) * durUnit)
-
-		return NewWindow(&start, &end), nil
-	}
-
-	// Match timestamp pairs, e.g. "1586822400,1586908800" or "1586822400-1586908800"
-	regex = regexp.MustCompile(`^(\d+)[,|-](\d+)$`)
-	match = regex.FindStringSubmatch(window)
-	if match != nil {
-		s, _ := strconv.ParseInt(match[1], 10, 64)
-		e, _ := strconv.ParseInt(match[2], 10, 64)
-		start := time.Unix(s, 0)
-		end := time.Unix(e, 0)
-		return NewWindow(&start, &end), nil
-	}
-
-	// Match RFC3339 pairs, e.g. "2020-04-01T00:00:00Z,2020-04-03T00:00:00Z"
-	rfc3339 := `\d\d\d\d-\d\d-\d\dT\d\d:\d\d:\d\dZ`
-	regex = regexp.MustCompile(fmt.Sprintf(`(%s),(%s)`, rfc3339, rfc3339))
-	match = regex.FindStringSubmatch(window)
-	if match != nil {
-		start, _ := time.Parse(time.RFC3339, match[1])
-		end, _ := time.Parse(time.RFC3339, match[2])
-		return NewWindow(&start, &end), nil
+	w, err := timeutil.ParseWindow(window, now)
+	if err != nil {
+		return Window{nil, nil}, err
 	}
 
-	return Window{nil, nil}, fmt.Errorf("illegal window: %s", window)
+	return NewClosedWindow(w.Start, w.End), nil
 }
 
 // ApproximatelyEqual returns true if the start and end times of the two windows,
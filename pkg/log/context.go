@@ -0,0 +1,45 @@
+package log
+
+import "context"
+
+// RequestIDField is the name of the field which holds the request ID of the
+// logs of a Logger returned by Ctx
+const RequestIDField = "requestID"
+
+type contextKey int
+
+const requestIDKey contextKey = iota
+
+// ContextWithRequestID returns a copy of the context which carries the ID of
+// the request on whose behalf work is done, so that it can be included in logs
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestIDFromContext returns the request ID carried by the context, if any
+func RequestIDFromContext(ctx context.Context) (string, bool) {
+	if ctx == nil {
+		return "", false
+	}
+
+	requestID, ok := ctx.Value(requestIDKey).(string)
+	return requestID, ok && requestID != ""
+}
+
+// Ctx returns a Logger which includes the request ID carried by the context
+// in its logs; e.g. log.Ctx(ctx).Warningf(...)
+func Ctx(ctx context.Context) *Logger {
+	return root.Ctx(ctx)
+}
+
+// Ctx returns a copy of the Logger which includes the request ID carried by
+// the context in its logs. If the context carries no request ID, the Logger
+// is returned unchanged.
+func (l *Logger) Ctx(ctx context.Context) *Logger {
+	requestID, ok := RequestIDFromContext(ctx)
+	if !ok {
+		return l
+	}
+
+	return l.WithFields(Fields{RequestIDField: requestID})
+}
@@ -0,0 +1,42 @@
+package log
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCtx(t *testing.T) {
+	buf := withConfig(t, "LOG_FORMAT=json", "LOG_LEVEL=info")
+
+	ctx := ContextWithRequestID(context.Background(), "req-1")
+	if id, ok := RequestIDFromContext(ctx); !ok || id != "req-1" {
+		t.Fatalf("expected request ID req-1; got '%s'", id)
+	}
+
+	Ctx(ctx).Warningf("root warning")
+	WithModule("prom").WithFields(Fields{"query": "up"}).Ctx(ctx).Errorf("prom error")
+	Ctx(context.Background()).Infof("no request")
+
+	logs := parseLines(t, buf)
+	if len(logs) != 3 {
+		t.Fatalf("expected 3 logs; got %d", len(logs))
+	}
+	if logs[0].Fields[RequestIDField] != "req-1" {
+		t.Fatalf("expected the request ID field; got %v", logs[0].Fields)
+	}
+	if logs[1].Module != "prom" || logs[1].Fields[RequestIDField] != "req-1" || logs[1].Fields["query"] != "up" {
+		t.Fatalf("expected the prom log to keep its fields and add the request ID; got %s %v", logs[1].Module, logs[1].Fields)
+	}
+	if _, ok := logs[2].Fields[RequestIDField]; ok {
+		t.Fatalf("expected no request ID field without a request; got %v", logs[2].Fields)
+	}
+}
+
+func TestRequestIDFromNilContext(t *testing.T) {
+	if _, ok := RequestIDFromContext(nil); ok {
+		t.Fatalf("expected no request ID from a nil context")
+	}
+	if l := root.Ctx(nil); l != root {
+		t.Fatalf("expected the Logger to be unchanged for a nil context")
+	}
+}
@@ -1,11 +1,6 @@
 package log
 
-import (
-	"fmt"
-	"time"
-
-	"k8s.io/klog"
-)
+import "time"
 
 // TODO for deduped functions, if timeLogged > logTypeLimit, should we log once
 // every... 100 (?) times so we don't lose track entirely?
@@ -14,56 +9,35 @@ import (
 var ctr = newCounter()
 
 func Errorf(format string, a ...interface{}) {
-	klog.Errorf(fmt.Sprintf("[Error] %s", format), a...)
+	root.Errorf(format, a...)
 }
 
 func DedupedErrorf(logTypeLimit int, format string, a ...interface{}) {
-	timesLogged := ctr.increment(format)
-
-	if timesLogged < logTypeLimit {
-		Errorf(format, a...)
-	} else if timesLogged == logTypeLimit {
-		Errorf(format, a...)
-		Infof("%s logged %d times: suppressing future logs", format, logTypeLimit)
-	}
+	root.DedupedErrorf(logTypeLimit, format, a...)
 }
 
 func Warningf(format string, a ...interface{}) {
-	klog.V(2).Infof(fmt.Sprintf("[Warning] %s", format), a...)
+	root.Warningf(format, a...)
 }
 
 func DedupedWarningf(logTypeLimit int, format string, a ...interface{}) {
-	timesLogged := ctr.increment(format)
-
-	if timesLogged < logTypeLimit {
-		Warningf(format, a...)
-	} else if timesLogged == logTypeLimit {
-		Warningf(format, a...)
-		Infof("%s logged %d times: suppressing future logs", format, logTypeLimit)
-	}
+	root.DedupedWarningf(logTypeLimit, format, a...)
 }
 
 func Infof(format string, a ...interface{}) {
-	klog.V(3).Infof(fmt.Sprintf("[Info] %s", format), a...)
+	root.Infof(format, a...)
 }
 
 func DedupedInfof(logTypeLimit int, format string, a ...interface{}) {
-	timesLogged := ctr.increment(format)
-
-	if timesLogged < logTypeLimit {
-		Infof(format, a...)
-	} else if timesLogged == logTypeLimit {
-		Infof(format, a...)
-		Infof("%s logged %d times: suppressing future logs", format, logTypeLimit)
-	}
+	root.DedupedInfof(logTypeLimit, format, a...)
 }
 
 func Profilef(format string, a ...interface{}) {
-	klog.V(3).Infof(fmt.Sprintf("[Profiler] %s", format), a...)
+	root.logf(InfoLevel, "Profiler", format, a...)
 }
 
 func Debugf(format string, a ...interface{}) {
-	klog.V(5).Infof(fmt.Sprintf("[Debug] %s", format), a...)
+	root.Debugf(format, a...)
 }
 
 func Profile(start time.Time, name string) {
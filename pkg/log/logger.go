@@ -0,0 +1,405 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog"
+)
+
+const (
+	// FormatEnvVar selects the output format: "text", the default, which is
+	// written by klog, or "json", which writes an object per line
+	FormatEnvVar = "LOG_FORMAT"
+
+	// LevelEnvVar sets the minimum level of the logs written, e.g. "debug".
+	// If it is not set, the level is determined by the klog verbosity.
+	LevelEnvVar = "LOG_LEVEL"
+
+	// ModuleLevelEnvVarPrefix prefixes the upper-cased name of a module to
+	// set the minimum level of its logs, overriding LevelEnvVar; e.g.
+	// LOG_LEVEL_PROM=debug.
+	ModuleLevelEnvVarPrefix = "LOG_LEVEL_"
+)
+
+// The output formats
+const (
+	TextFormat = "text"
+	JSONFormat = "json"
+)
+
+// Level is the severity of a log
+type Level int
+
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarningLevel
+	ErrorLevel
+)
+
+// String returns the lower-case name of the level
+func (l Level) String() string {
+	switch l {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarningLevel:
+		return "warning"
+	case ErrorLevel:
+		return "error"
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// ParseLevel parses the case-insensitive name of a level. "warn" is accepted
+// for WarningLevel.
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return DebugLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "warning", "warn":
+		return WarningLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	}
+	return InfoLevel, fmt.Errorf("invalid log level '%s'", s)
+}
+
+// klogEnabled returns true if the klog verbosity enables the level, which is
+// how levels are filtered unless configured
+func (l Level) klogEnabled() bool {
+	switch l {
+	case DebugLevel:
+		return bool(klog.V(5))
+	case InfoLevel:
+		return bool(klog.V(3))
+	case WarningLevel:
+		return bool(klog.V(2))
+	}
+	return true
+}
+
+// Fields are the structured context of a log
+type Fields map[string]interface{}
+
+// config is the logging configuration read from the environment
+type config struct {
+	format string
+
+	// level is the minimum level of all modules without their own, or nil if
+	// the klog verbosity determines it
+	level *Level
+
+	// moduleLevels are the minimum levels of modules, by normalized name
+	moduleLevels map[string]Level
+}
+
+var (
+	configLock sync.RWMutex
+	cfg        = parseConfig(os.Environ())
+
+	// output is where logs are written in the JSON format
+	outputLock sync.Mutex
+	output     io.Writer = os.Stderr
+)
+
+// Configure reads the logging configuration from the environment again, so
+// that changes to FormatEnvVar, LevelEnvVar, and the module levels are applied
+func Configure() {
+	c := parseConfig(os.Environ())
+
+	configLock.Lock()
+	cfg = c
+	configLock.Unlock()
+}
+
+// currentConfig returns the logging configuration in effect
+func currentConfig() *config {
+	configLock.RLock()
+	defer configLock.RUnlock()
+
+	return cfg
+}
+
+// IsConfigKey returns true if the environment variable configures logging
+func IsConfigKey(key string) bool {
+	return key == FormatEnvVar || key == LevelEnvVar || strings.HasPrefix(key, ModuleLevelEnvVarPrefix)
+}
+
+// parseConfig parses the logging configuration from the environment, as
+// returned by os.Environ. Invalid levels are ignored.
+func parseConfig(environ []string) *config {
+	c := &config{
+		format:       TextFormat,
+		moduleLevels: map[string]Level{},
+	}
+
+	for _, kv := range environ {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			continue
+		}
+
+		switch {
+		case key == FormatEnvVar:
+			if strings.EqualFold(value, JSONFormat) {
+				c.format = JSONFormat
+			}
+		case key == LevelEnvVar:
+			if level, err := ParseLevel(value); err == nil {
+				c.level = &level
+			}
+		case strings.HasPrefix(key, ModuleLevelEnvVarPrefix):
+			if level, err := ParseLevel(value); err == nil {
+				c.moduleLevels[normalizeModule(strings.TrimPrefix(key, ModuleLevelEnvVarPrefix))] = level
+			}
+		}
+	}
+
+	return c
+}
+
+// normalizeModule returns the name of the module as it appears in its
+// environment variable: upper-cased, with characters other than letters and
+// digits replaced by underscores
+func normalizeModule(module string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, module)
+}
+
+// enabled returns true if logs of the level are written for the module
+func (c *config) enabled(module string, level Level) bool {
+	if module != "" {
+		if min, ok := c.moduleLevels[normalizeModule(module)]; ok {
+			return level >= min
+		}
+	}
+	if c.level != nil {
+		return level >= *c.level
+	}
+	return level.klogEnabled()
+}
+
+// Logger writes logs with a module and structured fields. The package-level
+// functions, such as Infof, use a Logger without either.
+type Logger struct {
+	module string
+	fields Fields
+}
+
+// root is the Logger of the package-level functions
+var root = &Logger{}
+
+// WithModule returns a Logger for the module, whose level can be set by its
+// own environment variable; e.g. LOG_LEVEL_PROM for "prom"
+func WithModule(module string) *Logger {
+	return root.WithModule(module)
+}
+
+// WithFields returns a Logger which adds the fields to its logs
+func WithFields(fields Fields) *Logger {
+	return root.WithFields(fields)
+}
+
+// WithModule returns a copy of the Logger for the module
+func (l *Logger) WithModule(module string) *Logger {
+	return &Logger{
+		module: module,
+		fields: l.fields,
+	}
+}
+
+// WithFields returns a copy of the Logger which adds the fields to its logs,
+// replacing any fields of the same names
+func (l *Logger) WithFields(fields Fields) *Logger {
+	merged := make(Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+
+	return &Logger{
+		module: l.module,
+		fields: merged,
+	}
+}
+
+// Enabled returns true if logs of the level are written for the module of
+// the Logger, so that expensive logs can be skipped
+func (l *Logger) Enabled(level Level) bool {
+	return currentConfig().enabled(l.module, level)
+}
+
+func (l *Logger) Errorf(format string, a ...interface{}) {
+	l.logf(ErrorLevel, "Error", format, a...)
+}
+
+func (l *Logger) Warningf(format string, a ...interface{}) {
+	l.logf(WarningLevel, "Warning", format, a...)
+}
+
+func (l *Logger) Infof(format string, a ...interface{}) {
+	l.logf(InfoLevel, "Info", format, a...)
+}
+
+func (l *Logger) Debugf(format string, a ...interface{}) {
+	l.logf(DebugLevel, "Debug", format, a...)
+}
+
+func (l *Logger) DedupedErrorf(logTypeLimit int, format string, a ...interface{}) {
+	l.dedupedf(l.Errorf, logTypeLimit, format, a...)
+}
+
+func (l *Logger) DedupedWarningf(logTypeLimit int, format string, a ...interface{}) {
+	l.dedupedf(l.Warningf, logTypeLimit, format, a...)
+}
+
+func (l *Logger) DedupedInfof(logTypeLimit int, format string, a ...interface{}) {
+	l.dedupedf(l.Infof, logTypeLimit, format, a...)
+}
+
+// dedupedf logs with logf until the format has been logged logTypeLimit
+// times, after which it is suppressed
+func (l *Logger) dedupedf(logf func(string, ...interface{}), logTypeLimit int, format string, a ...interface{}) {
+	timesLogged := ctr.increment(format)
+
+	if timesLogged < logTypeLimit {
+		logf(format, a...)
+	} else if timesLogged == logTypeLimit {
+		logf(format, a...)
+		l.Infof("%s logged %d times: suppressing future logs", format, logTypeLimit)
+	}
+}
+
+// logf writes the log if its level is enabled for the module. In the text
+// format, the label prefixes the message, as in "[Info] message".
+func (l *Logger) logf(level Level, label string, format string, a ...interface{}) {
+	c := currentConfig()
+	if !c.enabled(l.module, level) {
+		return
+	}
+
+	l.output(c, level, label, fmt.Sprintf(format, a...))
+}
+
+// write writes the formatted message if its level is enabled for the module
+func (l *Logger) write(level Level, label string, msg string) {
+	c := currentConfig()
+	if !c.enabled(l.module, level) {
+		return
+	}
+
+	l.output(c, level, label, msg)
+}
+
+// output writes the message in the configured format
+func (l *Logger) output(c *config, level Level, label string, msg string) {
+	if c.format == JSONFormat {
+		l.writeJSON(level, msg)
+		return
+	}
+
+	line := l.text(label, msg)
+	if level == ErrorLevel {
+		klog.Error(line)
+	} else {
+		klog.Info(line)
+	}
+}
+
+// text returns the log in the text format: the label, the module, the
+// message, and the fields sorted by name; e.g.
+// "[Warning] [prom] fetching query failed query=up"
+func (l *Logger) text(label string, msg string) string {
+	var sb strings.Builder
+
+	sb.WriteString("[" + label + "] ")
+	if l.module != "" {
+		sb.WriteString("[" + l.module + "] ")
+	}
+	sb.WriteString(msg)
+
+	names := make([]string, 0, len(l.fields))
+	for name := range l.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		sb.WriteString(fmt.Sprintf(" %s=%v", name, l.fields[name]))
+	}
+
+	return sb.String()
+}
+
+// jsonLog is a log in the JSON format
+type jsonLog struct {
+	Timestamp string `json:"timestamp"`
+	Level     string `json:"level"`
+	Module    string `json:"module,omitempty"`
+	Message   string `json:"message"`
+	Fields    Fields `json:"fields,omitempty"`
+}
+
+// writeJSON writes the log to the output as a line of JSON
+func (l *Logger) writeJSON(level Level, msg string) {
+	entry := jsonLog{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Level:     level.String(),
+		Module:    l.module,
+		Message:   msg,
+		Fields:    jsonFields(l.fields, false),
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		// A field cannot be encoded, e.g. a channel, so they are formatted
+		entry.Fields = jsonFields(l.fields, true)
+		data, _ = json.Marshal(entry)
+	}
+
+	outputLock.Lock()
+	defer outputLock.Unlock()
+
+	output.Write(append(data, '\n'))
+}
+
+// jsonFields returns the fields to encode, with errors replaced by their
+// messages, which would otherwise be encoded as empty objects. If format is
+// true, all values are formatted as strings.
+func jsonFields(fields Fields, format bool) Fields {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	encoded := make(Fields, len(fields))
+	for k, v := range fields {
+		if err, ok := v.(error); ok {
+			encoded[k] = err.Error()
+		} else if format {
+			encoded[k] = fmt.Sprintf("%v", v)
+		} else {
+			encoded[k] = v
+		}
+	}
+	return encoded
+}
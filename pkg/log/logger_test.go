@@ -0,0 +1,187 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withConfig configures logging from the environment for the duration of the
+// test, returning the buffer to which JSON logs are written
+func withConfig(t *testing.T, environ ...string) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+
+	configLock.Lock()
+	prevCfg := cfg
+	cfg = parseConfig(environ)
+	configLock.Unlock()
+
+	outputLock.Lock()
+	prevOutput := output
+	output = buf
+	outputLock.Unlock()
+
+	t.Cleanup(func() {
+		configLock.Lock()
+		cfg = prevCfg
+		configLock.Unlock()
+
+		outputLock.Lock()
+		output = prevOutput
+		outputLock.Unlock()
+	})
+
+	return buf
+}
+
+// parseLines decodes each line of JSON written to the buffer
+func parseLines(t *testing.T, buf *bytes.Buffer) []jsonLog {
+	var logs []jsonLog
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var l jsonLog
+		if err := json.Unmarshal([]byte(line), &l); err != nil {
+			t.Fatalf("failed to parse log line %q: %s", line, err)
+		}
+		logs = append(logs, l)
+	}
+	return logs
+}
+
+func TestJSONFormat(t *testing.T) {
+	buf := withConfig(t, "LOG_FORMAT=json", "LOG_LEVEL=info")
+
+	WithModule("prom").WithFields(Fields{"query": "up", "attempt": 2}).Warningf("fetching query: %s", "timeout")
+
+	logs := parseLines(t, buf)
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 log; got %d", len(logs))
+	}
+
+	l := logs[0]
+	if l.Level != "warning" {
+		t.Fatalf("expected level warning; got %s", l.Level)
+	}
+	if l.Module != "prom" {
+		t.Fatalf("expected module prom; got %s", l.Module)
+	}
+	if l.Message != "fetching query: timeout" {
+		t.Fatalf("expected message 'fetching query: timeout'; got '%s'", l.Message)
+	}
+	if l.Fields["query"] != "up" || l.Fields["attempt"] != float64(2) {
+		t.Fatalf("expected fields query=up attempt=2; got %v", l.Fields)
+	}
+	if _, err := time.Parse(time.RFC3339Nano, l.Timestamp); err != nil {
+		t.Fatalf("expected an RFC 3339 timestamp; got '%s'", l.Timestamp)
+	}
+}
+
+func TestJSONFormatErrorField(t *testing.T) {
+	buf := withConfig(t, "LOG_FORMAT=json", "LOG_LEVEL=info")
+
+	WithFields(Fields{"err": errors.New("boom"), "ch": make(chan int)}).Errorf("failed")
+
+	logs := parseLines(t, buf)
+	if len(logs) != 1 {
+		t.Fatalf("expected 1 log; got %d", len(logs))
+	}
+	if logs[0].Fields["err"] != "boom" {
+		t.Fatalf("expected the error field to be its message; got %v", logs[0].Fields["err"])
+	}
+	if _, ok := logs[0].Fields["ch"].(string); !ok {
+		t.Fatalf("expected the unencodable field to be formatted; got %v", logs[0].Fields["ch"])
+	}
+}
+
+func TestModuleLevels(t *testing.T) {
+	buf := withConfig(t, "LOG_FORMAT=json", "LOG_LEVEL=info", "LOG_LEVEL_PROM=debug", "LOG_LEVEL_CLOUD=error")
+
+	WithModule("prom").Debugf("prom debug")
+	WithModule("clusters").Debugf("clusters debug")
+	WithModule("clusters").Infof("clusters info")
+	WithModule("cloud").Warningf("cloud warning")
+	WithModule("cloud").Errorf("cloud error")
+	Debugf("root debug")
+	Infof("root info")
+
+	var messages []string
+	for _, l := range parseLines(t, buf) {
+		messages = append(messages, l.Message)
+	}
+
+	expected := []string{"prom debug", "clusters info", "cloud error", "root info"}
+	if strings.Join(messages, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected logs %v; got %v", expected, messages)
+	}
+}
+
+func TestModuleLevelNormalization(t *testing.T) {
+	buf := withConfig(t, "LOG_FORMAT=json", "LOG_LEVEL=error", "LOG_LEVEL_CLUSTER_MAP=debug")
+
+	WithModule("cluster-map").Debugf("cluster map debug")
+
+	if logs := parseLines(t, buf); len(logs) != 1 {
+		t.Fatalf("expected 1 log; got %d", len(logs))
+	}
+}
+
+func TestWithFieldsMerges(t *testing.T) {
+	base := WithModule("prom").WithFields(Fields{"a": 1, "b": 2})
+	derived := base.WithFields(Fields{"b": 3, "c": 4})
+
+	if len(base.fields) != 2 || base.fields["b"] != 2 {
+		t.Fatalf("expected the base fields to be unchanged; got %v", base.fields)
+	}
+	if derived.module != "prom" || derived.fields["a"] != 1 || derived.fields["b"] != 3 || derived.fields["c"] != 4 {
+		t.Fatalf("expected merged fields a=1 b=3 c=4 in module prom; got %v in %s", derived.fields, derived.module)
+	}
+}
+
+func TestTextFormat(t *testing.T) {
+	l := WithModule("prom").WithFields(Fields{"query": "up", "attempt": 2})
+
+	text := l.text("Warning", "fetching query")
+	if text != "[Warning] [prom] fetching query attempt=2 query=up" {
+		t.Fatalf("unexpected text: %s", text)
+	}
+
+	text = root.text("Info", "message")
+	if text != "[Info] message" {
+		t.Fatalf("unexpected text: %s", text)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]Level{
+		"debug":   DebugLevel,
+		"INFO":    InfoLevel,
+		"warn":    WarningLevel,
+		"Warning": WarningLevel,
+		" error ": ErrorLevel,
+	}
+	for s, expected := range cases {
+		level, err := ParseLevel(s)
+		if err != nil {
+			t.Fatalf("unexpected error parsing '%s': %s", s, err)
+		}
+		if level != expected {
+			t.Fatalf("expected '%s' to parse as %s; got %s", s, expected, level)
+		}
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Fatalf("expected an error parsing 'verbose'")
+	}
+}
+
+func TestInvalidLevelIgnored(t *testing.T) {
+	c := parseConfig([]string{"LOG_LEVEL=verbose", "LOG_LEVEL_PROM=loud", "LOG_FORMAT=xml"})
+	if c.level != nil || len(c.moduleLevels) != 0 || c.format != TextFormat {
+		t.Fatalf("expected invalid settings to be ignored; got %+v", c)
+	}
+}
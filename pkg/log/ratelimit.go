@@ -0,0 +1,247 @@
+package log
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// RateLimitInterval is the interval at which the repeats of a rate limited
+	// log are summarized
+	RateLimitInterval = time.Minute
+
+	// maxRateLimitKeys is the number of keys whose repeats are tracked, beyond
+	// which the least recently logged key is summarized and forgotten
+	maxRateLimitKeys = 1024
+)
+
+// rateLimitEntry tracks the repeats of a key within its current interval
+type rateLimitEntry struct {
+	key string
+
+	// start is when the interval began, with the first occurrence of the key
+	start time.Time
+
+	// repeats is the number of occurrences suppressed since start
+	repeats int
+
+	// logger, level, label, and msg are those of the latest occurrence, with
+	// which the repeats are summarized
+	logger *Logger
+	level  Level
+	label  string
+	msg    string
+}
+
+// summary returns the log summarizing the repeats of the entry
+func (e *rateLimitEntry) summary(interval time.Duration) rateLimitSummary {
+	return rateLimitSummary{
+		logger: e.logger,
+		level:  e.level,
+		label:  e.label,
+		msg:    fmt.Sprintf("%s (repeated %d times in the last %s)", e.msg, e.repeats, intervalString(interval)),
+	}
+}
+
+// intervalString returns the interval as it appears in a summary; e.g.
+// "minute" or "30s"
+func intervalString(interval time.Duration) string {
+	switch interval {
+	case time.Minute:
+		return "minute"
+	case time.Hour:
+		return "hour"
+	}
+	return interval.String()
+}
+
+// rateLimitSummary is a summary to log once the limiter is unlocked
+type rateLimitSummary struct {
+	logger *Logger
+	level  Level
+	label  string
+	msg    string
+}
+
+// rateLimiter logs the first occurrence of a key immediately, then suppresses
+// its repeats, summarizing them once per interval. The keys are tracked in an
+// LRU list, so that its memory is bounded.
+type rateLimiter struct {
+	lock     sync.Mutex
+	interval time.Duration
+	maxKeys  int
+	entries  map[string]*list.Element
+	lru      *list.List
+	now      func() time.Time
+}
+
+// newRateLimiter creates a rateLimiter tracking at most maxKeys keys
+func newRateLimiter(interval time.Duration, maxKeys int) *rateLimiter {
+	return &rateLimiter{
+		interval: interval,
+		maxKeys:  maxKeys,
+		entries:  map[string]*list.Element{},
+		lru:      list.New(),
+		now:      time.Now,
+	}
+}
+
+// limiter rate limits the logs of all Loggers
+var limiter = newRateLimiter(RateLimitInterval, maxRateLimitKeys)
+
+// limiterFlush starts the flushing of limiter when it is first used
+var limiterFlush sync.Once
+
+// logf logs the message unless the key has already been logged in its current
+// interval, in which case it is counted as a repeat
+func (rl *rateLimiter) logf(l *Logger, level Level, label string, key string, msg string) {
+	rl.lock.Lock()
+
+	now := rl.now()
+
+	var summaries []rateLimitSummary
+	first := false
+
+	if el, ok := rl.entries[key]; ok {
+		e := el.Value.(*rateLimitEntry)
+		rl.lru.MoveToFront(el)
+
+		if now.Sub(e.start) >= rl.interval {
+			// The interval ended before it was flushed, so this occurrence
+			// begins the next one
+			if e.repeats > 0 {
+				summaries = append(summaries, e.summary(rl.interval))
+			}
+			e.start = now
+			e.repeats = 0
+			first = true
+		} else {
+			e.repeats++
+		}
+		e.logger, e.level, e.label, e.msg = l, level, label, msg
+	} else {
+		rl.entries[key] = rl.lru.PushFront(&rateLimitEntry{
+			key:    key,
+			start:  now,
+			logger: l,
+			level:  level,
+			label:  label,
+			msg:    msg,
+		})
+		first = true
+
+		for rl.lru.Len() > rl.maxKeys {
+			summaries = append(summaries, rl.evict(rl.lru.Back())...)
+		}
+	}
+
+	rl.lock.Unlock()
+
+	for _, s := range summaries {
+		s.logger.write(s.level, s.label, s.msg)
+	}
+	if first {
+		l.write(level, label, msg)
+	}
+}
+
+// flush summarizes the repeats of the keys whose intervals have ended at now,
+// and forgets the keys which were not repeated, so that their next occurrence
+// is logged immediately
+func (rl *rateLimiter) flush() {
+	rl.lock.Lock()
+
+	now := rl.now()
+
+	var summaries []rateLimitSummary
+	for el := rl.lru.Back(); el != nil; {
+		prev := el.Prev()
+		e := el.Value.(*rateLimitEntry)
+
+		if now.Sub(e.start) >= rl.interval {
+			if e.repeats > 0 {
+				summaries = append(summaries, e.summary(rl.interval))
+				e.start = now
+				e.repeats = 0
+			} else {
+				rl.evict(el)
+			}
+		}
+
+		el = prev
+	}
+
+	rl.lock.Unlock()
+
+	for _, s := range summaries {
+		s.logger.write(s.level, s.label, s.msg)
+	}
+}
+
+// evict forgets the key of the element, returning the summary of its repeats,
+// if any. The lock must be held.
+func (rl *rateLimiter) evict(el *list.Element) []rateLimitSummary {
+	e := el.Value.(*rateLimitEntry)
+	rl.lru.Remove(el)
+	delete(rl.entries, e.key)
+
+	if e.repeats == 0 {
+		return nil
+	}
+	return []rateLimitSummary{e.summary(rl.interval)}
+}
+
+// run flushes the limiter at its interval
+func (rl *rateLimiter) run() {
+	ticker := time.NewTicker(rl.interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		rl.flush()
+	}
+}
+
+func RateLimitedErrorf(key string, format string, a ...interface{}) {
+	root.RateLimitedErrorf(key, format, a...)
+}
+
+func RateLimitedWarningf(key string, format string, a ...interface{}) {
+	root.RateLimitedWarningf(key, format, a...)
+}
+
+func RateLimitedInfof(key string, format string, a ...interface{}) {
+	root.RateLimitedInfof(key, format, a...)
+}
+
+// RateLimitedErrorf logs the first occurrence of the key immediately, then
+// summarizes its repeats once per RateLimitInterval; e.g. "... (repeated 12
+// times in the last minute)". Keys are distinct per module.
+func (l *Logger) RateLimitedErrorf(key string, format string, a ...interface{}) {
+	l.rateLimitedf(ErrorLevel, "Error", key, format, a...)
+}
+
+// RateLimitedWarningf is RateLimitedErrorf for warnings
+func (l *Logger) RateLimitedWarningf(key string, format string, a ...interface{}) {
+	l.rateLimitedf(WarningLevel, "Warning", key, format, a...)
+}
+
+// RateLimitedInfof is RateLimitedErrorf for info logs
+func (l *Logger) RateLimitedInfof(key string, format string, a ...interface{}) {
+	l.rateLimitedf(InfoLevel, "Info", key, format, a...)
+}
+
+// rateLimitedf logs through the limiter, if the level is enabled, so that
+// disabled logs are not tracked
+func (l *Logger) rateLimitedf(level Level, label string, key string, format string, a ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	limiterFlush.Do(func() {
+		go limiter.run()
+	})
+
+	limiter.logf(l, level, label, l.module+"/"+key, fmt.Sprintf(format, a...))
+}
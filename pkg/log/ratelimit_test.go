@@ -0,0 +1,166 @@
+package log
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// newTestRateLimiter creates a rateLimiter whose clock is advanced by the
+// returned function
+func newTestRateLimiter(maxKeys int) (*rateLimiter, func(time.Duration)) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	rl := newRateLimiter(time.Minute, maxKeys)
+	rl.now = func() time.Time { return now }
+	return rl, func(d time.Duration) { now = now.Add(d) }
+}
+
+// messages returns the messages of the logs
+func messages(logs []jsonLog) []string {
+	msgs := make([]string, len(logs))
+	for i, l := range logs {
+		msgs[i] = l.Message
+	}
+	return msgs
+}
+
+func expectMessages(t *testing.T, actual []string, expected ...string) {
+	t.Helper()
+
+	if len(actual) != len(expected) {
+		t.Fatalf("expected logs %q; got %q", expected, actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Fatalf("expected logs %q; got %q", expected, actual)
+		}
+	}
+}
+
+func TestRateLimiterSummarizesRepeats(t *testing.T) {
+	buf := withConfig(t, "LOG_FORMAT=json", "LOG_LEVEL=info")
+	rl, advance := newTestRateLimiter(10)
+	l := WithModule("clusters")
+
+	for i := 0; i < 5; i++ {
+		rl.logf(l, WarningLevel, "Warning", "missing-id", fmt.Sprintf("missing id %d", i))
+		advance(time.Second)
+	}
+	expectMessages(t, messages(parseLines(t, buf)), "missing id 0")
+
+	// The repeats are summarized once the interval ends
+	advance(time.Minute)
+	rl.flush()
+
+	logs := parseLines(t, buf)
+	expectMessages(t, messages(logs), "missing id 0", "missing id 4 (repeated 4 times in the last minute)")
+	if logs[1].Level != "warning" || logs[1].Module != "clusters" {
+		t.Fatalf("expected the summary to be a clusters warning; got a %s %s", logs[1].Module, logs[1].Level)
+	}
+
+	// Repeats in the next interval are counted from zero
+	buf.Reset()
+	rl.logf(l, WarningLevel, "Warning", "missing-id", "missing id 5")
+	rl.logf(l, WarningLevel, "Warning", "missing-id", "missing id 6")
+	advance(time.Minute)
+	rl.flush()
+
+	expectMessages(t, messages(parseLines(t, buf)), "missing id 6 (repeated 2 times in the last minute)")
+
+	// A key which was not repeated in an interval is forgotten, so that its
+	// next occurrence is logged immediately
+	buf.Reset()
+	advance(time.Minute)
+	rl.flush()
+	rl.logf(l, WarningLevel, "Warning", "missing-id", "missing id 7")
+
+	expectMessages(t, messages(parseLines(t, buf)), "missing id 7")
+}
+
+func TestRateLimiterSummarizesOnNextOccurrence(t *testing.T) {
+	buf := withConfig(t, "LOG_FORMAT=json", "LOG_LEVEL=info")
+	rl, advance := newTestRateLimiter(10)
+
+	rl.logf(root, WarningLevel, "Warning", "key", "a")
+	rl.logf(root, WarningLevel, "Warning", "key", "b")
+	rl.logf(root, WarningLevel, "Warning", "key", "c")
+
+	// The interval ends without a flush, so the next occurrence summarizes
+	// the repeats and is logged
+	advance(time.Minute)
+	rl.logf(root, WarningLevel, "Warning", "key", "d")
+
+	expectMessages(t, messages(parseLines(t, buf)), "a", "c (repeated 2 times in the last minute)", "d")
+}
+
+func TestRateLimiterDistinctKeys(t *testing.T) {
+	buf := withConfig(t, "LOG_FORMAT=json", "LOG_LEVEL=info")
+	rl, advance := newTestRateLimiter(10)
+
+	for i := 0; i < 3; i++ {
+		rl.logf(root, WarningLevel, "Warning", "a", "a")
+		rl.logf(root, WarningLevel, "Warning", "b", "b")
+	}
+	expectMessages(t, messages(parseLines(t, buf)), "a", "b")
+
+	buf.Reset()
+	advance(time.Minute)
+	rl.flush()
+
+	// Summaries are logged from the least recently logged key
+	expectMessages(t, messages(parseLines(t, buf)), "a (repeated 2 times in the last minute)", "b (repeated 2 times in the last minute)")
+}
+
+func TestRateLimiterModulesDistinct(t *testing.T) {
+	buf := withConfig(t, "LOG_FORMAT=json", "LOG_LEVEL=info")
+
+	prev := limiter
+	limiter, _ = newTestRateLimiter(10)
+	t.Cleanup(func() { limiter = prev })
+
+	WithModule("prom").RateLimitedWarningf("key", "prom")
+	WithModule("cloud").RateLimitedWarningf("key", "cloud")
+	WithModule("prom").RateLimitedWarningf("key", "prom")
+
+	expectMessages(t, messages(parseLines(t, buf)), "prom", "cloud")
+}
+
+func TestRateLimiterBounded(t *testing.T) {
+	buf := withConfig(t, "LOG_FORMAT=json", "LOG_LEVEL=info")
+	rl, _ := newTestRateLimiter(2)
+
+	rl.logf(root, WarningLevel, "Warning", "a", "a")
+	rl.logf(root, WarningLevel, "Warning", "a", "a")
+	rl.logf(root, WarningLevel, "Warning", "b", "b")
+	rl.logf(root, WarningLevel, "Warning", "c", "c")
+
+	if rl.lru.Len() != 2 || len(rl.entries) != 2 {
+		t.Fatalf("expected 2 keys to be tracked; got %d", rl.lru.Len())
+	}
+	if _, ok := rl.entries["a"]; ok {
+		t.Fatalf("expected the least recently logged key to be evicted")
+	}
+
+	// The evicted key's repeats are summarized, and its next occurrence is
+	// logged immediately
+	rl.logf(root, WarningLevel, "Warning", "a", "a")
+
+	expectMessages(t, messages(parseLines(t, buf)), "a", "b", "a (repeated 1 times in the last minute)", "c", "a")
+}
+
+func TestRateLimitedDisabledLevel(t *testing.T) {
+	buf := withConfig(t, "LOG_FORMAT=json", "LOG_LEVEL=error")
+
+	prev := limiter
+	limiter, _ = newTestRateLimiter(10)
+	t.Cleanup(func() { limiter = prev })
+
+	RateLimitedWarningf("key", "suppressed")
+
+	if buf.Len() != 0 {
+		t.Fatalf("expected no logs; got %s", buf.String())
+	}
+	if limiter.lru.Len() != 0 {
+		t.Fatalf("expected disabled logs not to be tracked")
+	}
+}
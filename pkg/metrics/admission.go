@@ -0,0 +1,436 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/clustercache"
+	"github.com/kubecost/cost-model/pkg/log"
+
+	"github.com/prometheus/client_golang/prometheus"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ValidatingWebhookType is the type of the webhooks of a ValidatingWebhookConfiguration
+	ValidatingWebhookType = "validating"
+
+	// MutatingWebhookType is the type of the webhooks of a MutatingWebhookConfiguration
+	MutatingWebhookType = "mutating"
+
+	// DefaultAdmissionWebhookTimeoutSeconds is the timeout of webhooks which do
+	// not configure one.
+	DefaultAdmissionWebhookTimeoutSeconds int32 = 10
+
+	// admissionWebhookScrapeTimeout is the timeout of requesting the metrics of
+	// the API server
+	admissionWebhookScrapeTimeout = 10 * time.Second
+)
+
+// The API server metrics from which webhook latency and failures are read
+const (
+	apiServerWebhookDurationMetric  = "apiserver_admission_webhook_admission_duration_seconds"
+	apiServerWebhookRejectionMetric = "apiserver_admission_webhook_rejection_count"
+	apiServerWebhookFailOpenMetric  = "apiserver_admission_webhook_fail_open_count"
+)
+
+//--------------------------------------------------------------------------
+//  KubeAdmissionWebhookCollector
+//--------------------------------------------------------------------------
+
+// admissionWebhook is a webhook of a webhook configuration
+type admissionWebhook struct {
+	name           string
+	webhookType    string
+	configuration  string
+	failurePolicy  string
+	timeoutSeconds int32
+}
+
+// labels returns the label values of the webhook's metrics
+func (aw admissionWebhook) labels() []string {
+	return []string{aw.name, aw.webhookType, aw.configuration, aw.failurePolicy}
+}
+
+// admissionWebhookKey identifies a webhook in the metrics of the API server,
+// which labels mutating webhooks with the type "admit".
+type admissionWebhookKey struct {
+	name        string
+	webhookType string
+}
+
+// admissionWebhookLatency is the latency histogram of a webhook
+type admissionWebhookLatency struct {
+	buckets map[float64]uint64
+	sum     float64
+	count   uint64
+}
+
+// KubeAdmissionWebhookCollector is a prometheus collector that emits the
+// configured timeout, latency, and failures of each webhook of the cluster's
+// ValidatingWebhookConfigurations and MutatingWebhookConfigurations. Latency
+// and failures are read from the metrics of the API server, which calls the
+// webhooks, so they are not emitted if those metrics cannot be read.
+type KubeAdmissionWebhookCollector struct {
+	lock sync.Mutex
+
+	// webhooks are the webhooks of each configuration, by type and name
+	webhooks map[string][]admissionWebhook
+
+	// scrape returns the metrics of the API server in the Prometheus text format
+	scrape       func() ([]byte, error)
+	scrapeFailed bool
+}
+
+// NewKubeAdmissionWebhookCollector creates a new KubeAdmissionWebhookCollector
+// which watches the webhook configurations of the given client until stopCh is
+// closed.
+func NewKubeAdmissionWebhookCollector(client kubernetes.Interface, stopCh chan struct{}) *KubeAdmissionWebhookCollector {
+	ac := newKubeAdmissionWebhookCollector(func() ([]byte, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), admissionWebhookScrapeTimeout)
+		defer cancel()
+
+		return client.CoreV1().RESTClient().Get().AbsPath("/metrics").DoRaw(ctx)
+	})
+
+	restClient := client.AdmissionregistrationV1().RESTClient()
+
+	validating := clustercache.NewCachingWatcher(
+		restClient,
+		"validatingwebhookconfigurations",
+		&admissionregistrationv1.ValidatingWebhookConfiguration{},
+		"",
+		fields.Everything(),
+	)
+	validating.SetUpdateHandler(func(obj interface{}) {
+		if config, ok := obj.(*admissionregistrationv1.ValidatingWebhookConfiguration); ok {
+			webhooks := make([]admissionWebhook, 0, len(config.Webhooks))
+			for _, wh := range config.Webhooks {
+				webhooks = append(webhooks, newAdmissionWebhook(wh.Name, ValidatingWebhookType, config.Name, wh.FailurePolicy, wh.TimeoutSeconds))
+			}
+			ac.setConfiguration(ValidatingWebhookType, config.Name, webhooks)
+		}
+	})
+	validating.SetRemovedHandler(func(key interface{}) {
+		if k, ok := key.(string); ok {
+			ac.removeConfiguration(ValidatingWebhookType, k)
+		}
+	})
+	go validating.Run(1, stopCh)
+
+	mutating := clustercache.NewCachingWatcher(
+		restClient,
+		"mutatingwebhookconfigurations",
+		&admissionregistrationv1.MutatingWebhookConfiguration{},
+		"",
+		fields.Everything(),
+	)
+	mutating.SetUpdateHandler(func(obj interface{}) {
+		if config, ok := obj.(*admissionregistrationv1.MutatingWebhookConfiguration); ok {
+			webhooks := make([]admissionWebhook, 0, len(config.Webhooks))
+			for _, wh := range config.Webhooks {
+				webhooks = append(webhooks, newAdmissionWebhook(wh.Name, MutatingWebhookType, config.Name, wh.FailurePolicy, wh.TimeoutSeconds))
+			}
+			ac.setConfiguration(MutatingWebhookType, config.Name, webhooks)
+		}
+	})
+	mutating.SetRemovedHandler(func(key interface{}) {
+		if k, ok := key.(string); ok {
+			ac.removeConfiguration(MutatingWebhookType, k)
+		}
+	})
+	go mutating.Run(1, stopCh)
+
+	return ac
+}
+
+// newKubeAdmissionWebhookCollector creates a new KubeAdmissionWebhookCollector
+// without a watch, which reads the metrics of the API server using scrape.
+func newKubeAdmissionWebhookCollector(scrape func() ([]byte, error)) *KubeAdmissionWebhookCollector {
+	return &KubeAdmissionWebhookCollector{
+		webhooks: make(map[string][]admissionWebhook),
+		scrape:   scrape,
+	}
+}
+
+// newAdmissionWebhook creates an admissionWebhook, applying the API server's
+// defaults to the failure policy and timeout if they are not set.
+func newAdmissionWebhook(name, webhookType, configuration string, failurePolicy *admissionregistrationv1.FailurePolicyType, timeoutSeconds *int32) admissionWebhook {
+	aw := admissionWebhook{
+		name:           name,
+		webhookType:    webhookType,
+		configuration:  configuration,
+		failurePolicy:  string(admissionregistrationv1.Fail),
+		timeoutSeconds: DefaultAdmissionWebhookTimeoutSeconds,
+	}
+	if failurePolicy != nil {
+		aw.failurePolicy = string(*failurePolicy)
+	}
+	if timeoutSeconds != nil {
+		aw.timeoutSeconds = *timeoutSeconds
+	}
+	return aw
+}
+
+// setConfiguration sets the webhooks of the configuration of the given type
+// and name
+func (ac *KubeAdmissionWebhookCollector) setConfiguration(webhookType, name string, webhooks []admissionWebhook) {
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+
+	ac.webhooks[webhookType+"/"+name] = webhooks
+}
+
+// removeConfiguration stops tracking the webhooks of the configuration of the
+// given type and name, which has been removed
+func (ac *KubeAdmissionWebhookCollector) removeConfiguration(webhookType, name string) {
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+
+	delete(ac.webhooks, webhookType+"/"+name)
+}
+
+// configuredWebhooks returns the webhooks of all configurations
+func (ac *KubeAdmissionWebhookCollector) configuredWebhooks() []admissionWebhook {
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+
+	var webhooks []admissionWebhook
+	for _, whs := range ac.webhooks {
+		webhooks = append(webhooks, whs...)
+	}
+	return webhooks
+}
+
+// scrapeAPIServer reads the latency and failures of webhooks from the metrics
+// of the API server. Failures are counted by webhook and error type. Only the
+// first of consecutive failures to read the metrics is logged, as they are
+// likely to persist, e.g. if access to them is forbidden.
+func (ac *KubeAdmissionWebhookCollector) scrapeAPIServer() (map[admissionWebhookKey]*admissionWebhookLatency, map[admissionWebhookKey]map[string]float64, bool) {
+	data, err := ac.scrape()
+
+	ac.lock.Lock()
+	defer ac.lock.Unlock()
+
+	if err != nil {
+		if !ac.scrapeFailed {
+			log.Warningf("Failed to read admission webhook metrics from the API server: %s", err)
+		}
+		ac.scrapeFailed = true
+		return nil, nil, false
+	}
+	if ac.scrapeFailed {
+		log.Infof("Reading admission webhook metrics from the API server again")
+	}
+	ac.scrapeFailed = false
+
+	latencies, failures := parseAdmissionWebhookMetrics(data)
+	return latencies, failures, true
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (ac *KubeAdmissionWebhookCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- admissionWebhookTimeoutDesc
+	ch <- admissionWebhookDurationDesc
+	ch <- admissionWebhookFailuresDesc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (ac *KubeAdmissionWebhookCollector) Collect(ch chan<- prometheus.Metric) {
+	webhooks := ac.configuredWebhooks()
+	if len(webhooks) == 0 {
+		return
+	}
+
+	for _, wh := range webhooks {
+		ch <- prometheus.MustNewConstMetric(admissionWebhookTimeoutDesc, prometheus.GaugeValue, float64(wh.timeoutSeconds), wh.labels()...)
+	}
+
+	latencies, failures, ok := ac.scrapeAPIServer()
+	if !ok {
+		return
+	}
+
+	for _, wh := range webhooks {
+		key := admissionWebhookKey{name: wh.name, webhookType: apiServerWebhookType(wh.webhookType)}
+
+		if latency, ok := latencies[key]; ok {
+			ch <- prometheus.MustNewConstHistogram(admissionWebhookDurationDesc, latency.count, latency.sum, latency.buckets, wh.labels()...)
+		}
+
+		for errorType, count := range failures[key] {
+			ch <- prometheus.MustNewConstMetric(admissionWebhookFailuresDesc, prometheus.CounterValue, count, append(wh.labels(), errorType)...)
+		}
+	}
+}
+
+// apiServerWebhookType returns the type by which the API server labels the
+// metrics of webhooks of the given type
+func apiServerWebhookType(webhookType string) string {
+	if webhookType == MutatingWebhookType {
+		return "admit"
+	}
+	return webhookType
+}
+
+// parseAdmissionWebhookMetrics parses the latency and failures of webhooks from
+// the API server metrics in the Prometheus text format. Latency is summed over
+// operations, and failures are rejections due to errors calling the webhook,
+// by error type, and calls whose errors were ignored by the failure policy,
+// with the error type "fail_open".
+func parseAdmissionWebhookMetrics(data []byte) (map[admissionWebhookKey]*admissionWebhookLatency, map[admissionWebhookKey]map[string]float64) {
+	latencies := make(map[admissionWebhookKey]*admissionWebhookLatency)
+	failures := make(map[admissionWebhookKey]map[string]float64)
+
+	latency := func(key admissionWebhookKey) *admissionWebhookLatency {
+		if _, ok := latencies[key]; !ok {
+			latencies[key] = &admissionWebhookLatency{buckets: make(map[float64]uint64)}
+		}
+		return latencies[key]
+	}
+	fail := func(key admissionWebhookKey, errorType string, count float64) {
+		if _, ok := failures[key]; !ok {
+			failures[key] = make(map[string]float64)
+		}
+		failures[key][errorType] += count
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		name, labels, value, ok := parsePrometheusSample(scanner.Text())
+		if !ok || !strings.HasPrefix(name, "apiserver_admission_webhook_") {
+			continue
+		}
+
+		key := admissionWebhookKey{name: labels["name"], webhookType: labels["type"]}
+
+		switch name {
+		case apiServerWebhookDurationMetric + "_bucket":
+			// The +Inf bucket is the count
+			bound, err := strconv.ParseFloat(labels["le"], 64)
+			if err != nil || math.IsInf(bound, 1) {
+				continue
+			}
+			latency(key).buckets[bound] += uint64(value)
+		case apiServerWebhookDurationMetric + "_sum":
+			latency(key).sum += value
+		case apiServerWebhookDurationMetric + "_count":
+			latency(key).count += uint64(value)
+		case apiServerWebhookRejectionMetric:
+			// Rejections without an error were denied by the webhook
+			if errorType := labels["error_type"]; errorType != "" && errorType != "no_error" {
+				fail(key, errorType, value)
+			}
+		case apiServerWebhookFailOpenMetric:
+			fail(key, "fail_open", value)
+		}
+	}
+
+	return latencies, failures
+}
+
+// parsePrometheusSample parses a sample of the Prometheus text format; e.g.
+// `name{label="value"} 1.5`. Comments, blank lines, and malformed lines are
+// not samples.
+func parsePrometheusSample(line string) (string, map[string]string, float64, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' {
+		return "", nil, 0, false
+	}
+
+	i := strings.IndexAny(line, "{ \t")
+	if i <= 0 {
+		return "", nil, 0, false
+	}
+	name, rest := line[:i], line[i:]
+
+	labels := make(map[string]string)
+	if rest[0] == '{' {
+		rest = rest[1:]
+		for {
+			rest = strings.TrimLeft(rest, " ,")
+			if rest == "" {
+				return "", nil, 0, false
+			}
+			if rest[0] == '}' {
+				rest = rest[1:]
+				break
+			}
+
+			eq := strings.IndexByte(rest, '=')
+			if eq < 0 || eq+1 >= len(rest) || rest[eq+1] != '"' {
+				return "", nil, 0, false
+			}
+			label := strings.TrimSpace(rest[:eq])
+			rest = rest[eq+2:]
+
+			var value strings.Builder
+			closed := false
+			for j := 0; j < len(rest) && !closed; j++ {
+				switch {
+				case rest[j] == '\\' && j+1 < len(rest):
+					j++
+					if rest[j] == 'n' {
+						value.WriteByte('\n')
+					} else {
+						value.WriteByte(rest[j])
+					}
+				case rest[j] == '"':
+					rest = rest[j+1:]
+					closed = true
+				default:
+					value.WriteByte(rest[j])
+				}
+			}
+			if !closed {
+				return "", nil, 0, false
+			}
+			labels[label] = value.String()
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", nil, 0, false
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, 0, false
+	}
+
+	return name, labels, value, true
+}
+
+// admissionWebhookTimeoutDesc is the descriptor of kubecost_admission_webhook_timeout_seconds
+var admissionWebhookTimeoutDesc = prometheus.NewDesc(
+	"kubecost_admission_webhook_timeout_seconds",
+	"kubecost_admission_webhook_timeout_seconds Timeout configured for calls to the admission webhook",
+	[]string{"webhook", "type", "configuration", "failure_policy"},
+	nil,
+)
+
+// admissionWebhookDurationDesc is the descriptor of kubecost_admission_webhook_duration_seconds
+var admissionWebhookDurationDesc = prometheus.NewDesc(
+	"kubecost_admission_webhook_duration_seconds",
+	"kubecost_admission_webhook_duration_seconds Latency of calls by the API server to the admission webhook",
+	[]string{"webhook", "type", "configuration", "failure_policy"},
+	nil,
+)
+
+// admissionWebhookFailuresDesc is the descriptor of kubecost_admission_webhook_failures_total
+var admissionWebhookFailuresDesc = prometheus.NewDesc(
+	"kubecost_admission_webhook_failures_total",
+	"kubecost_admission_webhook_failures_total Number of calls by the API server to the admission webhook which failed, by error type",
+	[]string{"webhook", "type", "configuration", "failure_policy", "error_type"},
+	nil,
+)
@@ -116,6 +116,8 @@ type KubeDeploymentCollector struct {
 func (kdc KubeDeploymentCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- prometheus.NewDesc("kube_deployment_spec_replicas", "Number of desired pods for a deployment.", []string{}, nil)
 	ch <- prometheus.NewDesc("kube_deployment_status_replicas_available", "The number of available replicas per deployment.", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_deployment_metadata_generation", "Sequence number representing a specific generation of the desired state.", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_deployment_status_observed_generation", "The generation observed by the deployment controller.", []string{}, nil)
 
 }
 
@@ -143,6 +145,20 @@ func (kdc KubeDeploymentCollector) Collect(ch chan<- prometheus.Metric) {
 			deploymentName,
 			deploymentNS,
 			deployment.Status.AvailableReplicas)
+
+		// Generation, which differs from the observed generation while a
+		// rollout is stalled
+		ch <- newKubeDeploymentMetadataGenerationMetric(
+			"kube_deployment_metadata_generation",
+			deploymentName,
+			deploymentNS,
+			deployment.Generation)
+
+		ch <- newKubeDeploymentStatusObservedGenerationMetric(
+			"kube_deployment_status_observed_generation",
+			deploymentName,
+			deploymentNS,
+			deployment.Status.ObservedGeneration)
 	}
 }
 
@@ -253,3 +269,112 @@ func (kdr KubeDeploymentStatusAvailableReplicasMetric) Write(m *dto.Metric) erro
 
 	return nil
 }
+
+//--------------------------------------------------------------------------
+//  KubeDeploymentMetadataGenerationMetric
+//--------------------------------------------------------------------------
+
+// KubeDeploymentMetadataGenerationMetric is a prometheus.Metric used to encode the generation of a deployment
+type KubeDeploymentMetadataGenerationMetric struct {
+	fqName     string
+	help       string
+	deployment string
+	namespace  string
+	generation float64
+}
+
+// Creates a new KubeDeploymentMetadataGenerationMetric, implementation of prometheus.Metric
+func newKubeDeploymentMetadataGenerationMetric(fqname, deployment, namespace string, generation int64) KubeDeploymentMetadataGenerationMetric {
+	return KubeDeploymentMetadataGenerationMetric{
+		fqName:     fqname,
+		help:       "kube_deployment_metadata_generation Sequence number representing a specific generation of the desired state.",
+		deployment: deployment,
+		namespace:  namespace,
+		generation: float64(generation),
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kdg KubeDeploymentMetadataGenerationMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"deployment": kdg.deployment,
+		"namespace":  kdg.namespace,
+	}
+	return prometheus.NewDesc(kdg.fqName, kdg.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (kdg KubeDeploymentMetadataGenerationMetric) Write(m *dto.Metric) error {
+	m.Gauge = &dto.Gauge{
+		Value: &kdg.generation,
+	}
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &kdg.namespace,
+		},
+		{
+			Name:  toStringPtr("deployment"),
+			Value: &kdg.deployment,
+		},
+	}
+
+	return nil
+}
+
+//--------------------------------------------------------------------------
+//  KubeDeploymentStatusObservedGenerationMetric
+//--------------------------------------------------------------------------
+
+// KubeDeploymentStatusObservedGenerationMetric is a prometheus.Metric used to encode the generation observed
+// by the deployment controller
+type KubeDeploymentStatusObservedGenerationMetric struct {
+	fqName             string
+	help               string
+	deployment         string
+	namespace          string
+	observedGeneration float64
+}
+
+// Creates a new KubeDeploymentStatusObservedGenerationMetric, implementation of prometheus.Metric
+func newKubeDeploymentStatusObservedGenerationMetric(fqname, deployment, namespace string, observedGeneration int64) KubeDeploymentStatusObservedGenerationMetric {
+	return KubeDeploymentStatusObservedGenerationMetric{
+		fqName:             fqname,
+		help:               "kube_deployment_status_observed_generation The generation observed by the deployment controller.",
+		deployment:         deployment,
+		namespace:          namespace,
+		observedGeneration: float64(observedGeneration),
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kdog KubeDeploymentStatusObservedGenerationMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"deployment": kdog.deployment,
+		"namespace":  kdog.namespace,
+	}
+	return prometheus.NewDesc(kdog.fqName, kdog.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (kdog KubeDeploymentStatusObservedGenerationMetric) Write(m *dto.Metric) error {
+	m.Gauge = &dto.Gauge{
+		Value: &kdog.observedGeneration,
+	}
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &kdog.namespace,
+		},
+		{
+			Name:  toStringPtr("deployment"),
+			Value: &kdog.deployment,
+		},
+	}
+
+	return nil
+}
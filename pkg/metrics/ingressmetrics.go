@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"github.com/kubecost/cost-model/pkg/clustercache"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+//--------------------------------------------------------------------------
+//  KubeIngressCollector
+//--------------------------------------------------------------------------
+
+// KubeIngressCollector is a prometheus collector that emits ingress metrics,
+// attributing each HTTP path of an ingress to its backend service.
+type KubeIngressCollector struct {
+	KubeClusterCache clustercache.ClusterCache
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (kic KubeIngressCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- prometheus.NewDesc("kube_ingress_path", "Ingress host and path information.", []string{}, nil)
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (kic KubeIngressCollector) Collect(ch chan<- prometheus.Metric) {
+	ingresses := kic.KubeClusterCache.GetAllIngresses()
+	for _, ingress := range ingresses {
+		for _, p := range getIngressPaths(ingress) {
+			ch <- newKubeIngressPathMetric("kube_ingress_path", ingress.Name, ingress.Namespace, p.host, p.path, p.service)
+		}
+	}
+}
+
+// ingressPath is an HTTP path of an ingress and the service to which it routes
+type ingressPath struct {
+	host    string
+	path    string
+	service string
+}
+
+// getIngressPaths returns the unique HTTP paths of the ingress. An ingress
+// with a default backend, which serves requests matching no rule, has a path
+// with an empty host and path. Paths routing to a resource rather than a
+// service are skipped.
+func getIngressPaths(ingress *networkingv1.Ingress) []ingressPath {
+	var paths []ingressPath
+	seen := map[ingressPath]bool{}
+
+	add := func(p ingressPath) {
+		if !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+
+	if backend := ingress.Spec.DefaultBackend; backend != nil && backend.Service != nil {
+		add(ingressPath{service: backend.Service.Name})
+	}
+
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for _, path := range rule.HTTP.Paths {
+			if path.Backend.Service == nil {
+				continue
+			}
+
+			add(ingressPath{
+				host:    rule.Host,
+				path:    path.Path,
+				service: path.Backend.Service.Name,
+			})
+		}
+	}
+
+	return paths
+}
+
+//--------------------------------------------------------------------------
+//  KubeIngressPathMetric
+//--------------------------------------------------------------------------
+
+// KubeIngressPathMetric is a prometheus.Metric used to encode an ingress path
+// and the service to which it routes
+type KubeIngressPathMetric struct {
+	fqName         string
+	help           string
+	ingress        string
+	namespace      string
+	host           string
+	path           string
+	backendService string
+}
+
+// Creates a new KubeIngressPathMetric, implementation of prometheus.Metric
+func newKubeIngressPathMetric(fqname, ingress, namespace, host, path, backendService string) KubeIngressPathMetric {
+	return KubeIngressPathMetric{
+		fqName:         fqname,
+		help:           "kube_ingress_path Ingress host and path information.",
+		ingress:        ingress,
+		namespace:      namespace,
+		host:           host,
+		path:           path,
+		backendService: backendService,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kip KubeIngressPathMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"namespace":       kip.namespace,
+		"ingress":         kip.ingress,
+		"host":            kip.host,
+		"path":            kip.path,
+		"backend_service": kip.backendService,
+	}
+	return prometheus.NewDesc(kip.fqName, kip.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (kip KubeIngressPathMetric) Write(m *dto.Metric) error {
+	v := float64(1.0)
+	m.Gauge = &dto.Gauge{
+		Value: &v,
+	}
+
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &kip.namespace,
+		},
+		{
+			Name:  toStringPtr("ingress"),
+			Value: &kip.ingress,
+		},
+		{
+			Name:  toStringPtr("host"),
+			Value: &kip.host,
+		},
+		{
+			Name:  toStringPtr("path"),
+			Value: &kip.path,
+		},
+		{
+			Name:  toStringPtr("backend_service"),
+			Value: &kip.backendService,
+		},
+	}
+	return nil
+}
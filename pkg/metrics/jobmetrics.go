@@ -23,7 +23,11 @@ type KubeJobCollector struct {
 // Describe sends the super-set of all possible descriptors of metrics
 // collected by this Collector.
 func (kjc KubeJobCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- prometheus.NewDesc("kube_job_status_active", "The number of actively running pods.", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_job_status_succeeded", "The number of pods which reached Phase Succeeded.", []string{}, nil)
 	ch <- prometheus.NewDesc("kube_job_status_failed", "The number of pods which reached Phase Failed and the reason for failure.", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_job_owner", "Information about the Job's owner.", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_job_spec_active_deadline_seconds", "The duration in seconds relative to the startTime that the job may be active before the system tries to terminate it, or -1 if unbounded.", []string{}, nil)
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
@@ -33,11 +37,28 @@ func (kjc KubeJobCollector) Collect(ch chan<- prometheus.Metric) {
 		jobName := job.GetName()
 		jobNS := job.GetNamespace()
 
-		if job.Status.Failed == 0 {
-			ch <- newKubeJobStatusFailedMetric(jobName, jobNS, "kube_job_status_failed", "", 0)
-		} else {
+		for _, owner := range job.OwnerReferences {
+			ch <- newKubeOwnerMetric("kube_job_owner", "job_name", jobNS, jobName, owner.Name, owner.Kind, owner.Controller != nil && *owner.Controller)
+		}
+
+		// Jobs without a deadline are reported as -1, so that alerts can fire on them
+		activeDeadline := float64(-1)
+		if job.Spec.ActiveDeadlineSeconds != nil {
+			activeDeadline = float64(*job.Spec.ActiveDeadlineSeconds)
+		}
+		ch <- newKubeJobSpecActiveDeadlineSecondsMetric(jobName, jobNS, "kube_job_spec_active_deadline_seconds", activeDeadline)
+
+		ch <- newKubeJobStatusActiveMetric(jobName, jobNS, "kube_job_status_active", float64(job.Status.Active))
+		ch <- newKubeJobStatusSucceededMetric(jobName, jobNS, "kube_job_status_succeeded", float64(job.Status.Succeeded))
+
+		// Pods which failed before the job itself failed, e.g. while it is
+		// retrying within its backoff limit, are reported without a reason
+		jobFailed := false
+		if job.Status.Failed > 0 {
 			for _, condition := range job.Status.Conditions {
 				if condition.Type == batchv1.JobFailed {
+					jobFailed = true
+
 					reasonKnown := false
 					for _, reason := range jobFailureReasons {
 						reasonKnown = reasonKnown || failureReason(&condition, reason)
@@ -52,9 +73,120 @@ func (kjc KubeJobCollector) Collect(ch chan<- prometheus.Metric) {
 				}
 			}
 		}
+		if !jobFailed {
+			ch <- newKubeJobStatusFailedMetric(jobName, jobNS, "kube_job_status_failed", "", float64(job.Status.Failed))
+		}
 	}
 }
 
+//--------------------------------------------------------------------------
+//  KubeJobStatusActiveMetric
+//--------------------------------------------------------------------------
+
+// KubeJobStatusActiveMetric is a prometheus.Metric used to encode the number
+// of actively running pods of a job
+type KubeJobStatusActiveMetric struct {
+	fqName    string
+	help      string
+	job       string
+	namespace string
+	value     float64
+}
+
+// Creates a new KubeJobStatusActiveMetric, implementation of prometheus.Metric
+func newKubeJobStatusActiveMetric(job, namespace, fqName string, value float64) KubeJobStatusActiveMetric {
+	return KubeJobStatusActiveMetric{
+		fqName:    fqName,
+		help:      "kube_job_status_active The number of actively running pods",
+		job:       job,
+		namespace: namespace,
+		value:     value,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kjsa KubeJobStatusActiveMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"job_name":  kjsa.job,
+		"namespace": kjsa.namespace,
+	}
+	return prometheus.NewDesc(kjsa.fqName, kjsa.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (kjsa KubeJobStatusActiveMetric) Write(m *dto.Metric) error {
+	m.Gauge = &dto.Gauge{
+		Value: &kjsa.value,
+	}
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("job_name"),
+			Value: &kjsa.job,
+		},
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &kjsa.namespace,
+		},
+	}
+	return nil
+}
+
+//--------------------------------------------------------------------------
+//  KubeJobStatusSucceededMetric
+//--------------------------------------------------------------------------
+
+// KubeJobStatusSucceededMetric is a prometheus.Metric used to encode the
+// number of pods of a job which succeeded
+type KubeJobStatusSucceededMetric struct {
+	fqName    string
+	help      string
+	job       string
+	namespace string
+	value     float64
+}
+
+// Creates a new KubeJobStatusSucceededMetric, implementation of prometheus.Metric
+func newKubeJobStatusSucceededMetric(job, namespace, fqName string, value float64) KubeJobStatusSucceededMetric {
+	return KubeJobStatusSucceededMetric{
+		fqName:    fqName,
+		help:      "kube_job_status_succeeded The number of pods which reached Phase Succeeded",
+		job:       job,
+		namespace: namespace,
+		value:     value,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kjss KubeJobStatusSucceededMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"job_name":  kjss.job,
+		"namespace": kjss.namespace,
+	}
+	return prometheus.NewDesc(kjss.fqName, kjss.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (kjss KubeJobStatusSucceededMetric) Write(m *dto.Metric) error {
+	m.Gauge = &dto.Gauge{
+		Value: &kjss.value,
+	}
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("job_name"),
+			Value: &kjss.job,
+		},
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &kjss.namespace,
+		},
+	}
+	return nil
+}
+
 //--------------------------------------------------------------------------
 //  KubeJobStatusFailedMetric
 //--------------------------------------------------------------------------
@@ -114,3 +246,57 @@ func (kjsf KubeJobStatusFailedMetric) Write(m *dto.Metric) error {
 	}
 	return nil
 }
+
+//--------------------------------------------------------------------------
+//  KubeJobSpecActiveDeadlineSecondsMetric
+//--------------------------------------------------------------------------
+
+// KubeJobSpecActiveDeadlineSecondsMetric is a prometheus.Metric used to encode
+// the active deadline of a job, or -1 if the job has none
+type KubeJobSpecActiveDeadlineSecondsMetric struct {
+	fqName    string
+	help      string
+	job       string
+	namespace string
+	value     float64
+}
+
+// Creates a new KubeJobSpecActiveDeadlineSecondsMetric, implementation of prometheus.Metric
+func newKubeJobSpecActiveDeadlineSecondsMetric(job, namespace, fqName string, value float64) KubeJobSpecActiveDeadlineSecondsMetric {
+	return KubeJobSpecActiveDeadlineSecondsMetric{
+		fqName:    fqName,
+		help:      "kube_job_spec_active_deadline_seconds Active deadline of the job in seconds, or -1 if unbounded",
+		job:       job,
+		namespace: namespace,
+		value:     value,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kjad KubeJobSpecActiveDeadlineSecondsMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"job_name":  kjad.job,
+		"namespace": kjad.namespace,
+	}
+	return prometheus.NewDesc(kjad.fqName, kjad.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (kjad KubeJobSpecActiveDeadlineSecondsMetric) Write(m *dto.Metric) error {
+	m.Gauge = &dto.Gauge{
+		Value: &kjad.value,
+	}
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("job_name"),
+			Value: &kjad.job,
+		},
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &kjad.namespace,
+		},
+	}
+	return nil
+}
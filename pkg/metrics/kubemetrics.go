@@ -22,12 +22,56 @@ import (
 // initializer
 var kubeMetricInit sync.Once
 
+// kubeMetricsCache is the cluster cache from which kubernetes metrics are
+// emitted, once initialized
+var kubeMetricsCache clustercache.ClusterCache
+
+// optionalKubeMetrics gates the collectors which may be enabled or disabled
+// after initialization
+var optionalKubeMetrics = &kubeMetricsGate{
+	registered: map[string]prometheus.Collector{},
+}
+
+// kubeMetricsGate registers and unregisters optional collectors by name
+type kubeMetricsGate struct {
+	lock       sync.Mutex
+	registered map[string]prometheus.Collector
+}
+
+// set registers the collector if enabled and not yet registered, or
+// unregisters it if disabled and registered.
+func (kmg *kubeMetricsGate) set(name string, enabled bool, collector prometheus.Collector) {
+	kmg.lock.Lock()
+	defer kmg.lock.Unlock()
+
+	registered, ok := kmg.registered[name]
+	if enabled && !ok {
+		prometheus.MustRegister(collector)
+		kmg.registered[name] = collector
+	} else if !enabled && ok {
+		prometheus.Unregister(registered)
+		delete(kmg.registered, name)
+	}
+}
+
 // KubeMetricsOpts represents our Kubernetes metrics emission options.
 type KubeMetricsOpts struct {
 	EmitKubecostControllerMetrics bool
 	EmitNamespaceAnnotations      bool
 	EmitPodAnnotations            bool
 	EmitKubeStateMetrics          bool
+	EmitIngressMetrics            bool
+	EmitAdmissionWebhookMetrics   bool
+	EmitStorageClassMetrics       bool
+
+	// LabelAllowlist restricts the node labels emitted by kube_node_labels to
+	// those named, by either their Kubernetes or sanitized name. If empty, all
+	// labels are emitted.
+	LabelAllowlist []string
+
+	// NodePrices price the kubecost controller cost estimate metrics. If nil,
+	// cost estimate metrics are not emitted.
+	NodePrices *NodePrices
 }
 
 // DefaultKubeMetricsOpts returns KubeMetricsOpts with default values set
@@ -37,6 +81,9 @@ func DefaultKubeMetricsOpts() *KubeMetricsOpts {
 		EmitNamespaceAnnotations:      false,
 		EmitPodAnnotations:            false,
 		EmitKubeStateMetrics:          true,
+		EmitIngressMetrics:            false,
+		EmitAdmissionWebhookMetrics:   false,
+		EmitStorageClassMetrics:       false,
 	}
 }
 
@@ -56,24 +103,16 @@ func InitKubeMetrics(clusterCache clustercache.ClusterCache, opts *KubeMetricsOp
 			})
 			prometheus.MustRegister(KubecostStatefulsetCollector{
 				KubeClusterCache: clusterCache,
+				NodePrices:       opts.NodePrices,
 			})
-		}
-
-		if opts.EmitPodAnnotations {
-			prometheus.MustRegister(KubecostPodCollector{
-				KubeClusterCache: clusterCache,
-			})
-		}
-
-		if opts.EmitNamespaceAnnotations {
-			prometheus.MustRegister(KubecostNamespaceCollector{
-				KubeClusterCache: clusterCache,
-			})
+			// OOM kill events are watched for the lifetime of the process
+			prometheus.MustRegister(NewKubeOOMKillCollector(clusterCache, make(chan struct{})))
 		}
 
 		if opts.EmitKubeStateMetrics {
 			prometheus.MustRegister(KubeNodeCollector{
 				KubeClusterCache: clusterCache,
+				LabelAllowlist:   opts.LabelAllowlist,
 			})
 			prometheus.MustRegister(KubeNamespaceCollector{
 				KubeClusterCache: clusterCache,
@@ -93,7 +132,50 @@ func InitKubeMetrics(clusterCache clustercache.ClusterCache, opts *KubeMetricsOp
 			prometheus.MustRegister(KubeJobCollector{
 				KubeClusterCache: clusterCache,
 			})
+			prometheus.MustRegister(KubeReplicaSetCollector{
+				KubeClusterCache: clusterCache,
+			})
+		}
+
+		if opts.EmitAdmissionWebhookMetrics {
+			// Webhook configurations are watched for the lifetime of the process
+			prometheus.MustRegister(NewKubeAdmissionWebhookCollector(clusterCache.GetClient(), make(chan struct{})))
+		}
+
+		if opts.EmitStorageClassMetrics {
+			prometheus.MustRegister(KubeStorageClassCollector{
+				KubeClusterCache: clusterCache,
+			})
 		}
+
+		kubeMetricsCache = clusterCache
+		setOptionalKubeMetrics(clusterCache, opts)
+	})
+}
+
+// UpdateKubeMetricsOpts applies the options which may change after
+// initialization: EmitPodAnnotations and EmitNamespaceAnnotations. The
+// remaining options require a restart to change. It has no effect if
+// kubernetes metrics have not been initialized.
+func UpdateKubeMetricsOpts(opts *KubeMetricsOpts) {
+	if opts == nil || kubeMetricsCache == nil {
+		return
+	}
+
+	setOptionalKubeMetrics(kubeMetricsCache, opts)
+}
+
+// setOptionalKubeMetrics registers or unregisters each of the optional
+// collectors according to the options
+func setOptionalKubeMetrics(clusterCache clustercache.ClusterCache, opts *KubeMetricsOpts) {
+	optionalKubeMetrics.set("podAnnotations", opts.EmitPodAnnotations, KubecostPodCollector{
+		KubeClusterCache: clusterCache,
+	})
+	optionalKubeMetrics.set("namespaceAnnotations", opts.EmitNamespaceAnnotations, KubecostNamespaceCollector{
+		KubeClusterCache: clusterCache,
+	})
+	optionalKubeMetrics.set("ingress", opts.EmitIngressMetrics, KubeIngressCollector{
+		KubeClusterCache: clusterCache,
 	})
 }
 
@@ -6,6 +6,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
+	v1 "k8s.io/api/core/v1"
 )
 
 //--------------------------------------------------------------------------
@@ -106,6 +107,7 @@ type KubeNamespaceCollector struct {
 // collected by this Collector.
 func (nsac KubeNamespaceCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- prometheus.NewDesc("kube_namespace_labels", "namespace labels", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_namespace_status_phase", "kubernetes namespace status phase.", []string{}, nil)
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
@@ -119,6 +121,13 @@ func (nsac KubeNamespaceCollector) Collect(ch chan<- prometheus.Metric) {
 			m := newNamespaceAnnotationsMetric("kube_namespace_labels", nsName, labels, values)
 			ch <- m
 		}
+
+		// A namespace without a phase has not been reconciled, so is Active
+		phase := namespace.Status.Phase
+		if phase == "" {
+			phase = v1.NamespaceActive
+		}
+		ch <- newKubeNamespaceStatusPhaseMetric("kube_namespace_status_phase", nsName, string(phase))
 	}
 }
 
@@ -176,3 +185,60 @@ func (nam KubeNamespaceLabelsMetric) Write(m *dto.Metric) error {
 	m.Label = labels
 	return nil
 }
+
+//--------------------------------------------------------------------------
+//  KubeNamespaceStatusPhaseMetric
+//--------------------------------------------------------------------------
+
+// KubeNamespaceStatusPhaseMetric is a prometheus.Metric used to encode the
+// phase of a namespace, which is 1 while the namespace is Terminating, so that
+// namespaces stuck terminating with resources which still cost can be alerted on
+type KubeNamespaceStatusPhaseMetric struct {
+	fqName    string
+	help      string
+	namespace string
+	phase     string
+}
+
+// Creates a new KubeNamespaceStatusPhaseMetric, implementation of prometheus.Metric
+func newKubeNamespaceStatusPhaseMetric(fqname, namespace, phase string) KubeNamespaceStatusPhaseMetric {
+	return KubeNamespaceStatusPhaseMetric{
+		fqName:    fqname,
+		help:      "kube_namespace_status_phase kubernetes namespace status phase.",
+		namespace: namespace,
+		phase:     phase,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (nsp KubeNamespaceStatusPhaseMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"namespace": nsp.namespace,
+		"phase":     nsp.phase,
+	}
+	return prometheus.NewDesc(nsp.fqName, nsp.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data transmission object.
+func (nsp KubeNamespaceStatusPhaseMetric) Write(m *dto.Metric) error {
+	v := float64(0)
+	if nsp.phase == string(v1.NamespaceTerminating) {
+		v = 1
+	}
+	m.Gauge = &dto.Gauge{
+		Value: &v,
+	}
+
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &nsp.namespace,
+		},
+		{
+			Name:  toStringPtr("phase"),
+			Value: &nsp.phase,
+		},
+	}
+	return nil
+}
@@ -22,6 +22,10 @@ var (
 // KubeNodeCollector is a prometheus collector that generates node sourced metrics.
 type KubeNodeCollector struct {
 	KubeClusterCache clustercache.ClusterCache
+
+	// LabelAllowlist restricts the labels emitted by kube_node_labels. See
+	// KubeMetricsOpts.
+	LabelAllowlist []string
 }
 
 // Describe sends the super-set of all possible descriptors of metrics
@@ -35,6 +39,8 @@ func (nsac KubeNodeCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- prometheus.NewDesc("kube_node_status_allocatable_memory_bytes", "The allocatable memory in bytes.", []string{}, nil)
 	ch <- prometheus.NewDesc("kube_node_labels", "all labels for each node prefixed with label_", []string{}, nil)
 	ch <- prometheus.NewDesc("kube_node_status_condition", "The condition of a cluster node.", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_node_created", "Unix creation timestamp.", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_node_info", "Information about a cluster node.", []string{}, nil)
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
@@ -88,7 +94,7 @@ func (nsac KubeNodeCollector) Collect(ch chan<- prometheus.Metric) {
 		}
 
 		// node labels
-		labelNames, labelValues := prom.KubePrependQualifierToLabels(node.GetLabels(), "label_")
+		labelNames, labelValues := prom.KubePrependQualifierToLabels(allowedLabels(node.GetLabels(), nsac.LabelAllowlist), "label_")
 		ch <- newKubeNodeLabelsMetric(nodeName, "kube_node_labels", labelNames, labelValues)
 
 		// kube_node_status_condition
@@ -101,6 +107,14 @@ func (nsac KubeNodeCollector) Collect(ch chan<- prometheus.Metric) {
 			}
 		}
 
+		// kube_node_created
+		if !node.CreationTimestamp.IsZero() {
+			ch <- newKubeNodeCreatedMetric("kube_node_created", nodeName, float64(node.CreationTimestamp.Unix()))
+		}
+
+		// kube_node_info
+		ch <- newKubeNodeInfoMetric("kube_node_info", nodeName, node.Status.NodeInfo, node.Spec.ProviderID, node.Spec.PodCIDR)
+
 	}
 }
 
@@ -256,6 +270,28 @@ func (nam KubeNodeStatusCapacityCPUCoresMetric) Write(m *dto.Metric) error {
 	return nil
 }
 
+// allowedLabels returns the labels named by the allowlist, by either their
+// Kubernetes or sanitized name, or all labels if the allowlist is empty.
+func allowedLabels(labels map[string]string, allowlist []string) map[string]string {
+	if len(allowlist) == 0 {
+		return labels
+	}
+
+	allowed := make(map[string]bool, len(allowlist))
+	for _, name := range allowlist {
+		allowed[name] = true
+		allowed[prom.SanitizeLabelName(name)] = true
+	}
+
+	filtered := make(map[string]string)
+	for k, v := range labels {
+		if allowed[k] || allowed[prom.SanitizeLabelName(k)] {
+			filtered[k] = v
+		}
+	}
+	return filtered
+}
+
 //--------------------------------------------------------------------------
 //  KubeNodeLabelsCollector
 //--------------------------------------------------------------------------
@@ -556,3 +592,143 @@ func (kpcrr KubeNodeStatusAllocatableMemoryBytesMetric) Write(m *dto.Metric) err
 	}
 	return nil
 }
+
+//--------------------------------------------------------------------------
+//  KubeNodeCreatedMetric
+//--------------------------------------------------------------------------
+
+// KubeNodeCreatedMetric is a prometheus.Metric used to encode the Unix
+// creation timestamp of a node, from which node age can be computed.
+type KubeNodeCreatedMetric struct {
+	fqName string
+	help   string
+	node   string
+	value  float64
+}
+
+// Creates a new KubeNodeCreatedMetric, implementation of prometheus.Metric
+func newKubeNodeCreatedMetric(fqname, node string, value float64) KubeNodeCreatedMetric {
+	return KubeNodeCreatedMetric{
+		fqName: fqname,
+		help:   "kube_node_created Unix creation timestamp",
+		node:   node,
+		value:  value,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (nam KubeNodeCreatedMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{"node": nam.node}
+	return prometheus.NewDesc(nam.fqName, nam.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (nam KubeNodeCreatedMetric) Write(m *dto.Metric) error {
+	m.Gauge = &dto.Gauge{
+		Value: &nam.value,
+	}
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("node"),
+			Value: &nam.node,
+		},
+	}
+	return nil
+}
+
+//--------------------------------------------------------------------------
+//  KubeNodeInfoMetric
+//--------------------------------------------------------------------------
+
+// KubeNodeInfoMetric is a prometheus.Metric used to encode information about a
+// node, e.g. its kubelet version, which determines the features it supports.
+type KubeNodeInfoMetric struct {
+	fqName                  string
+	help                    string
+	node                    string
+	kernelVersion           string
+	osImage                 string
+	containerRuntimeVersion string
+	kubeletVersion          string
+	kubeProxyVersion        string
+	providerID              string
+	podCIDR                 string
+}
+
+// Creates a new KubeNodeInfoMetric, implementation of prometheus.Metric
+func newKubeNodeInfoMetric(fqname, node string, info v1.NodeSystemInfo, providerID, podCIDR string) KubeNodeInfoMetric {
+	return KubeNodeInfoMetric{
+		fqName:                  fqname,
+		help:                    "kube_node_info Information about a cluster node",
+		node:                    node,
+		kernelVersion:           info.KernelVersion,
+		osImage:                 info.OSImage,
+		containerRuntimeVersion: info.ContainerRuntimeVersion,
+		kubeletVersion:          info.KubeletVersion,
+		kubeProxyVersion:        info.KubeProxyVersion,
+		providerID:              providerID,
+		podCIDR:                 podCIDR,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (nam KubeNodeInfoMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"node":                      nam.node,
+		"kernel_version":            nam.kernelVersion,
+		"os_image":                  nam.osImage,
+		"container_runtime_version": nam.containerRuntimeVersion,
+		"kubelet_version":           nam.kubeletVersion,
+		"kubeproxy_version":         nam.kubeProxyVersion,
+		"provider_id":               nam.providerID,
+		"pod_cidr":                  nam.podCIDR,
+	}
+	return prometheus.NewDesc(nam.fqName, nam.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (nam KubeNodeInfoMetric) Write(m *dto.Metric) error {
+	v := float64(1.0)
+	m.Gauge = &dto.Gauge{
+		Value: &v,
+	}
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("node"),
+			Value: &nam.node,
+		},
+		{
+			Name:  toStringPtr("kernel_version"),
+			Value: &nam.kernelVersion,
+		},
+		{
+			Name:  toStringPtr("os_image"),
+			Value: &nam.osImage,
+		},
+		{
+			Name:  toStringPtr("container_runtime_version"),
+			Value: &nam.containerRuntimeVersion,
+		},
+		{
+			Name:  toStringPtr("kubelet_version"),
+			Value: &nam.kubeletVersion,
+		},
+		{
+			Name:  toStringPtr("kubeproxy_version"),
+			Value: &nam.kubeProxyVersion,
+		},
+		{
+			Name:  toStringPtr("provider_id"),
+			Value: &nam.providerID,
+		},
+		{
+			Name:  toStringPtr("pod_cidr"),
+			Value: &nam.podCIDR,
+		},
+	}
+	return nil
+}
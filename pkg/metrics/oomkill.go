@@ -0,0 +1,184 @@
+package metrics
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/kubecost/cost-model/pkg/clustercache"
+
+	"github.com/prometheus/client_golang/prometheus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/tools/cache"
+)
+
+// OOMKillingReason is the reason of events reporting that a process was killed
+// by the kernel's out-of-memory killer.
+const OOMKillingReason = "OOMKilling"
+
+// containerFieldPathRegex matches the container name of an event's involved
+// object field path; e.g. "spec.containers{app}"
+var containerFieldPathRegex = regexp.MustCompile(`^spec\.(?:initContainers|containers|ephemeralContainers)\{(.+)\}$`)
+
+//--------------------------------------------------------------------------
+//  KubeOOMKillCollector
+//--------------------------------------------------------------------------
+
+// oomKillKey identifies the container an OOM kill is attributed to
+type oomKillKey struct {
+	namespace string
+	pod       string
+	container string
+}
+
+// KubeOOMKillCollector is a prometheus collector that counts OOM kills reported
+// by events, by the namespace, pod, and container of each event's involved
+// object. Counts are held in memory, so they reset when the collector restarts,
+// and the counts of pods which no longer exist are dropped on collection.
+type KubeOOMKillCollector struct {
+	lock   sync.Mutex
+	counts map[oomKillKey]float64
+
+	// pods returns the pods which currently exist
+	pods func() []*v1.Pod
+
+	// seen is the count of each event, by key, already counted. Events are
+	// updated with a new count when the same kill is reported again.
+	seen map[string]int32
+}
+
+// NewKubeOOMKillCollector creates a new KubeOOMKillCollector which watches the
+// OOM kill events of the cluster cache's client until stopCh is closed.
+func NewKubeOOMKillCollector(clusterCache clustercache.ClusterCache, stopCh chan struct{}) *KubeOOMKillCollector {
+	oc := newKubeOOMKillCollector(clusterCache.GetAllPods)
+
+	watch := clustercache.NewCachingWatcher(
+		clusterCache.GetClient().CoreV1().RESTClient(),
+		"events",
+		&v1.Event{},
+		"",
+		fields.OneTermEqualSelector("reason", OOMKillingReason),
+	)
+	watch.SetUpdateHandler(func(obj interface{}) {
+		if event, ok := obj.(*v1.Event); ok {
+			oc.observe(event)
+		}
+	})
+	watch.SetRemovedHandler(func(key interface{}) {
+		if k, ok := key.(string); ok {
+			oc.forget(k)
+		}
+	})
+	go watch.Run(1, stopCh)
+
+	return oc
+}
+
+// newKubeOOMKillCollector creates a new KubeOOMKillCollector without a watch,
+// which keeps the counts of the pods returned by pods
+func newKubeOOMKillCollector(pods func() []*v1.Pod) *KubeOOMKillCollector {
+	return &KubeOOMKillCollector{
+		counts: make(map[oomKillKey]float64),
+		pods:   pods,
+		seen:   make(map[string]int32),
+	}
+}
+
+// observe counts the OOM kills of the given event which have not already been
+// counted.
+func (oc *KubeOOMKillCollector) observe(event *v1.Event) {
+	if event.Reason != OOMKillingReason {
+		return
+	}
+
+	key, err := cache.MetaNamespaceKeyFunc(event)
+	if err != nil {
+		return
+	}
+
+	// Events which do not track a count represent a single occurrence
+	count := event.Count
+	if count < 1 {
+		count = 1
+	}
+
+	oc.lock.Lock()
+	defer oc.lock.Unlock()
+
+	seen := oc.seen[key]
+	if count <= seen {
+		return
+	}
+	oc.seen[key] = count
+
+	container := ""
+	if match := containerFieldPathRegex.FindStringSubmatch(event.InvolvedObject.FieldPath); match != nil {
+		container = match[1]
+	}
+
+	pod := ""
+	if event.InvolvedObject.Kind == "Pod" {
+		pod = event.InvolvedObject.Name
+	}
+
+	oc.counts[oomKillKey{
+		namespace: event.InvolvedObject.Namespace,
+		pod:       pod,
+		container: container,
+	}] += float64(count - seen)
+}
+
+// forget stops tracking the count of the event with the given key, which has
+// been removed. Its OOM kills remain counted.
+func (oc *KubeOOMKillCollector) forget(key string) {
+	oc.lock.Lock()
+	defer oc.lock.Unlock()
+
+	delete(oc.seen, key)
+}
+
+// prune drops the counts of pods which no longer exist, so that the series of
+// deleted pods are not emitted forever. Counts which are not attributed to a pod
+// are kept. The lock must be held.
+func (oc *KubeOOMKillCollector) prune() {
+	if oc.pods == nil {
+		return
+	}
+
+	existing := map[string]bool{}
+	for _, pod := range oc.pods() {
+		existing[pod.Namespace+"/"+pod.Name] = true
+	}
+
+	for key := range oc.counts {
+		if key.pod != "" && !existing[key.namespace+"/"+key.pod] {
+			delete(oc.counts, key)
+		}
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (oc *KubeOOMKillCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- oomKillTotalDesc
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (oc *KubeOOMKillCollector) Collect(ch chan<- prometheus.Metric) {
+	oc.lock.Lock()
+	defer oc.lock.Unlock()
+
+	oc.prune()
+
+	for key, count := range oc.counts {
+		ch <- prometheus.MustNewConstMetric(oomKillTotalDesc, prometheus.CounterValue, count, key.namespace, key.pod, key.container)
+	}
+}
+
+// oomKillTotalDesc is the descriptor of kubecost_oom_kill_total
+var oomKillTotalDesc = prometheus.NewDesc(
+	"kubecost_oom_kill_total",
+	"kubecost_oom_kill_total Number of OOM kills reported by events since the collector started",
+	[]string{"namespace", "pod", "container"},
+	nil,
+)
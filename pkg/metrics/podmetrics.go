@@ -2,6 +2,7 @@ package metrics
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/kubecost/cost-model/pkg/clustercache"
 	"github.com/kubecost/cost-model/pkg/log"
@@ -56,13 +57,18 @@ func (kpmc KubePodCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- prometheus.NewDesc("kube_pod_labels", "All labels for each pod prefixed with label_", []string{}, nil)
 	ch <- prometheus.NewDesc("kube_pod_owner", "Information about the Pod's owner", []string{}, nil)
 	ch <- prometheus.NewDesc("kube_pod_container_status_running", "Describes whether the container is currently in running state", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_pod_init_container_status_running", "Describes whether the init container is currently in running state", []string{}, nil)
 	ch <- prometheus.NewDesc("kube_pod_container_status_terminated_reason", "Describes the reason the container is currently in terminated state.", []string{}, nil)
 	ch <- prometheus.NewDesc("kube_pod_container_status_restarts_total", "The number of container restarts per container.", []string{}, nil)
 	ch <- prometheus.NewDesc("kube_pod_container_resource_requests", "The number of requested resource by a container", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_pod_ephemeral_storage_request_bytes", "The ephemeral storage requested by a container in bytes.", []string{}, nil)
 	ch <- prometheus.NewDesc("kube_pod_container_resource_limits", "The number of requested limit resource by a container.", []string{}, nil)
 	ch <- prometheus.NewDesc("kube_pod_container_resource_limits_cpu_cores", "The number of requested limit cpu core resource by a container.", []string{}, nil)
 	ch <- prometheus.NewDesc("kube_pod_container_resource_limits_memory_bytes", "The number of requested limit memory resource by a container.", []string{}, nil)
 	ch <- prometheus.NewDesc("kube_pod_status_phase", "The pods current phase.", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_pod_affinity_required", "The number of required affinity terms constraining the pod's scheduling.", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_pod_anti_affinity_required", "The number of required anti-affinity terms constraining the pod's scheduling.", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_pod_resource_request_vs_limit_ratio", "The ratio of a container's resource request to its limit.", []string{}, nil)
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
@@ -97,6 +103,11 @@ func (kpmc KubePodCollector) Collect(ch chan<- prometheus.Metric) {
 		labelNames, labelValues := prom.KubePrependQualifierToLabels(pod.GetLabels(), "label_")
 		ch <- newKubePodLabelsMetric("kube_pod_labels", podNS, podName, podUID, labelNames, labelValues)
 
+		// Scheduling Constraints
+		affinityTerms, antiAffinityTerms := requiredAffinityTerms(pod)
+		ch <- newKubePodAffinityRequiredMetric("kube_pod_affinity_required", podNS, podName, podUID, float64(affinityTerms))
+		ch <- newKubePodAffinityRequiredMetric("kube_pod_anti_affinity_required", podNS, podName, podUID, float64(antiAffinityTerms))
+
 		// Owner References
 		for _, owner := range pod.OwnerReferences {
 			ch <- newKubePodOwnerMetric("kube_pod_owner", podNS, podName, owner.Name, owner.Kind, owner.Controller != nil)
@@ -120,6 +131,13 @@ func (kpmc KubePodCollector) Collect(ch chan<- prometheus.Metric) {
 			}
 		}
 
+		// Init Container Status
+		for _, status := range pod.Status.InitContainerStatuses {
+			if status.State.Running != nil {
+				ch <- newKubePodContainerStatusRunningMetric("kube_pod_init_container_status_running", podNS, podName, podUID, status.Name)
+			}
+		}
+
 		for _, container := range pod.Spec.Containers {
 			// Requests
 			for resourceName, quantity := range container.Resources.Requests {
@@ -141,6 +159,15 @@ func (kpmc KubePodCollector) Collect(ch chan<- prometheus.Metric) {
 					resource,
 					unit,
 					value)
+
+				if resourceName == v1.ResourceEphemeralStorage {
+					ch <- newKubePodEphemeralStorageRequestBytesMetric(
+						"kube_pod_ephemeral_storage_request_bytes",
+						podNS,
+						podName,
+						container.Name,
+						value)
+				}
 			}
 
 			// Limits
@@ -186,8 +213,72 @@ func (kpmc KubePodCollector) Collect(ch chan<- prometheus.Metric) {
 					unit,
 					value)
 			}
+
+			// Request to Limit Ratios
+			for _, resourceName := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+				ratio, ok := requestLimitRatio(container.Resources, resourceName)
+				if !ok {
+					continue
+				}
+
+				ch <- newKubePodResourceRequestVsLimitRatioMetric(
+					"kube_pod_resource_request_vs_limit_ratio",
+					podNS,
+					podName,
+					podUID,
+					container.Name,
+					node,
+					string(resourceName),
+					ratio)
+			}
+		}
+	}
+}
+
+// requestLimitRatio returns the ratio of the container's request of the resource to its
+// limit, and false if it neither requests nor limits the resource. A limit of zero, or no
+// limit, is unbounded, so the ratio is +Inf, unless the request is also zero.
+func requestLimitRatio(resources v1.ResourceRequirements, resourceName v1.ResourceName) (float64, bool) {
+	request, hasRequest := resources.Requests[resourceName]
+	limit, hasLimit := resources.Limits[resourceName]
+	if !hasRequest && !hasLimit {
+		return 0, false
+	}
+
+	// Compare in milli-units, so that fractional CPU requests are not rounded
+	req := float64(request.MilliValue())
+	lim := float64(limit.MilliValue())
+
+	if lim <= 0 {
+		if req <= 0 {
+			return 0, true
 		}
+		return math.Inf(1), true
 	}
+
+	return req / lim, true
+}
+
+// requiredAffinityTerms returns the number of required (hard) affinity and anti-affinity
+// terms on the pod. Required node affinity selector terms and pod affinity terms both
+// count as affinity terms.
+func requiredAffinityTerms(pod *v1.Pod) (affinity int, antiAffinity int) {
+	a := pod.Spec.Affinity
+	if a == nil {
+		return 0, 0
+	}
+
+	if a.NodeAffinity != nil && a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		affinity += len(a.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms)
+	}
+	if a.PodAffinity != nil {
+		affinity += len(a.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+	}
+	if a.PodAntiAffinity != nil {
+		antiAffinity += len(a.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution)
+	}
+
+	return affinity, antiAffinity
 }
 
 //--------------------------------------------------------------------------
@@ -562,7 +653,7 @@ type KubePodContainerStatusRunningMetric struct {
 func newKubePodContainerStatusRunningMetric(fqname, namespace, pod, uid, container string) KubePodContainerStatusRunningMetric {
 	return KubePodContainerStatusRunningMetric{
 		fqName:    fqname,
-		help:      "kube_pod_container_status_running pods container status",
+		help:      fqname + " pods container status",
 		pod:       pod,
 		namespace: namespace,
 		uid:       uid,
@@ -941,6 +1032,68 @@ func (kpcrr KubePodContainerResourceLimitsMemoryBytesMetric) Write(m *dto.Metric
 	return nil
 }
 
+//--------------------------------------------------------------------------
+//  KubePodEphemeralStorageRequestBytesMetric
+//--------------------------------------------------------------------------
+
+// KubePodEphemeralStorageRequestBytesMetric is a prometheus.Metric used to encode
+// the ephemeral storage requested by a container
+type KubePodEphemeralStorageRequestBytesMetric struct {
+	fqName    string
+	help      string
+	pod       string
+	namespace string
+	container string
+	value     float64
+}
+
+// Creates a new KubePodEphemeralStorageRequestBytesMetric, implementation of prometheus.Metric
+func newKubePodEphemeralStorageRequestBytesMetric(fqname, namespace, pod, container string, value float64) KubePodEphemeralStorageRequestBytesMetric {
+	return KubePodEphemeralStorageRequestBytesMetric{
+		fqName:    fqname,
+		help:      "kube_pod_ephemeral_storage_request_bytes pods container ephemeral storage request bytes",
+		pod:       pod,
+		namespace: namespace,
+		container: container,
+		value:     value,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kpesr KubePodEphemeralStorageRequestBytesMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"namespace": kpesr.namespace,
+		"pod":       kpesr.pod,
+		"container": kpesr.container,
+	}
+	return prometheus.NewDesc(kpesr.fqName, kpesr.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (kpesr KubePodEphemeralStorageRequestBytesMetric) Write(m *dto.Metric) error {
+	m.Gauge = &dto.Gauge{
+		Value: &kpesr.value,
+	}
+
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &kpesr.namespace,
+		},
+		{
+			Name:  toStringPtr("pod"),
+			Value: &kpesr.pod,
+		},
+		{
+			Name:  toStringPtr("container"),
+			Value: &kpesr.container,
+		},
+	}
+	return nil
+}
+
 //--------------------------------------------------------------------------
 //  KubePodOwnerMetric
 //--------------------------------------------------------------------------
@@ -1014,3 +1167,146 @@ func (kpo KubePodOwnerMetric) Write(m *dto.Metric) error {
 	}
 	return nil
 }
+
+//--------------------------------------------------------------------------
+//  KubePodAffinityRequiredMetric
+//--------------------------------------------------------------------------
+
+// KubePodAffinityRequiredMetric is a prometheus.Metric emitting the number of required
+// affinity or anti-affinity terms on a pod.
+type KubePodAffinityRequiredMetric struct {
+	fqName    string
+	help      string
+	namespace string
+	pod       string
+	uid       string
+	value     float64
+}
+
+// Creates a new KubePodAffinityRequiredMetric, implementation of prometheus.Metric
+func newKubePodAffinityRequiredMetric(fqname, namespace, pod, uid string, value float64) KubePodAffinityRequiredMetric {
+	return KubePodAffinityRequiredMetric{
+		fqName:    fqname,
+		help:      fqname + " The number of required scheduling terms on the pod",
+		namespace: namespace,
+		pod:       pod,
+		uid:       uid,
+		value:     value,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kpar KubePodAffinityRequiredMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"namespace": kpar.namespace,
+		"pod":       kpar.pod,
+		"uid":       kpar.uid,
+	}
+	return prometheus.NewDesc(kpar.fqName, kpar.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data transmission object.
+func (kpar KubePodAffinityRequiredMetric) Write(m *dto.Metric) error {
+	m.Gauge = &dto.Gauge{
+		Value: &kpar.value,
+	}
+
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &kpar.namespace,
+		},
+		{
+			Name:  toStringPtr("pod"),
+			Value: &kpar.pod,
+		},
+		{
+			Name:  toStringPtr("uid"),
+			Value: &kpar.uid,
+		},
+	}
+	return nil
+}
+
+//--------------------------------------------------------------------------
+//  KubePodResourceRequestVsLimitRatioMetric
+//--------------------------------------------------------------------------
+
+// KubePodResourceRequestVsLimitRatioMetric is a prometheus.Metric emitting the ratio of a
+// container's resource request to its limit.
+type KubePodResourceRequestVsLimitRatioMetric struct {
+	fqName    string
+	help      string
+	namespace string
+	pod       string
+	uid       string
+	container string
+	node      string
+	resource  string
+	value     float64
+}
+
+// Creates a new KubePodResourceRequestVsLimitRatioMetric, implementation of prometheus.Metric
+func newKubePodResourceRequestVsLimitRatioMetric(fqname, namespace, pod, uid, container, node, resource string, value float64) KubePodResourceRequestVsLimitRatioMetric {
+	return KubePodResourceRequestVsLimitRatioMetric{
+		fqName:    fqname,
+		help:      fqname + " The ratio of a container's resource request to its limit",
+		namespace: namespace,
+		pod:       pod,
+		uid:       uid,
+		container: container,
+		node:      node,
+		resource:  resource,
+		value:     value,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kprr KubePodResourceRequestVsLimitRatioMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"namespace": kprr.namespace,
+		"pod":       kprr.pod,
+		"uid":       kprr.uid,
+		"container": kprr.container,
+		"node":      kprr.node,
+		"resource":  kprr.resource,
+	}
+	return prometheus.NewDesc(kprr.fqName, kprr.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data transmission object.
+func (kprr KubePodResourceRequestVsLimitRatioMetric) Write(m *dto.Metric) error {
+	m.Gauge = &dto.Gauge{
+		Value: &kprr.value,
+	}
+
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &kprr.namespace,
+		},
+		{
+			Name:  toStringPtr("pod"),
+			Value: &kprr.pod,
+		},
+		{
+			Name:  toStringPtr("uid"),
+			Value: &kprr.uid,
+		},
+		{
+			Name:  toStringPtr("container"),
+			Value: &kprr.container,
+		},
+		{
+			Name:  toStringPtr("node"),
+			Value: &kprr.node,
+		},
+		{
+			Name:  toStringPtr("resource"),
+			Value: &kprr.resource,
+		},
+	}
+	return nil
+}
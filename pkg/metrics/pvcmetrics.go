@@ -20,6 +20,7 @@ type KubePVCCollector struct {
 func (kpvc KubePVCCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- prometheus.NewDesc("kube_persistentvolumeclaim_resource_requests_storage_bytes", "The pvc storage resource requests in bytes", []string{}, nil)
 	ch <- prometheus.NewDesc("kube_persistentvolumeclaim_info", "The pvc storage resource requests in bytes", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_persistentvolumeclaim_bound", "Whether the pvc is bound to a persistent volume (1) or not (0)", []string{}, nil)
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
@@ -34,6 +35,9 @@ func (kpvc KubePVCCollector) Collect(ch chan<- prometheus.Metric) {
 		if storage, ok := pvc.Spec.Resources.Requests[v1.ResourceStorage]; ok {
 			ch <- newKubePVCResourceRequestsStorageBytesMetric("kube_persistentvolumeclaim_resource_requests_storage_bytes", pvc.Name, pvc.Namespace, float64(storage.Value()))
 		}
+
+		// Unbound pvcs hold storage requests which are not being used
+		ch <- newKubePVCBoundMetric("kube_persistentvolumeclaim_bound", pvc.Name, pvc.Namespace, boolFloat64(pvc.Status.Phase == v1.ClaimBound))
 	}
 }
 
@@ -91,6 +95,61 @@ func (kpvcrr KubePVCResourceRequestsStorageBytesMetric) Write(m *dto.Metric) err
 	return nil
 }
 
+//--------------------------------------------------------------------------
+//  KubePVCBoundMetric
+//--------------------------------------------------------------------------
+
+// KubePVCBoundMetric is a prometheus.Metric used to encode whether a pvc is
+// bound (1) or not (0)
+type KubePVCBoundMetric struct {
+	fqName    string
+	help      string
+	namespace string
+	pvc       string
+	value     float64
+}
+
+// Creates a new KubePVCBoundMetric, implementation of prometheus.Metric
+func newKubePVCBoundMetric(fqname, pvc, namespace string, value float64) KubePVCBoundMetric {
+	return KubePVCBoundMetric{
+		fqName:    fqname,
+		help:      "kube_persistentvolumeclaim_bound whether the pvc is bound (1) or not (0)",
+		pvc:       pvc,
+		namespace: namespace,
+		value:     value,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kpvcb KubePVCBoundMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"persistentvolumeclaim": kpvcb.pvc,
+		"namespace":             kpvcb.namespace,
+	}
+	return prometheus.NewDesc(kpvcb.fqName, kpvcb.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (kpvcb KubePVCBoundMetric) Write(m *dto.Metric) error {
+	m.Gauge = &dto.Gauge{
+		Value: &kpvcb.value,
+	}
+
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("persistentvolumeclaim"),
+			Value: &kpvcb.pvc,
+		},
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &kpvcb.namespace,
+		},
+	}
+	return nil
+}
+
 //--------------------------------------------------------------------------
 //  KubePVCInfoMetric
 //--------------------------------------------------------------------------
@@ -0,0 +1,113 @@
+package metrics
+
+import (
+	"fmt"
+
+	"github.com/kubecost/cost-model/pkg/clustercache"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+//--------------------------------------------------------------------------
+//  KubeReplicaSetCollector
+//--------------------------------------------------------------------------
+
+// KubeReplicaSetCollector is a prometheus collector that generates replicaset sourced metrics.
+type KubeReplicaSetCollector struct {
+	KubeClusterCache clustercache.ClusterCache
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (krsc KubeReplicaSetCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- prometheus.NewDesc("kube_replicaset_owner", "Information about the ReplicaSet's owner.", []string{}, nil)
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (krsc KubeReplicaSetCollector) Collect(ch chan<- prometheus.Metric) {
+	replicaSets := krsc.KubeClusterCache.GetAllReplicaSets()
+	for _, rs := range replicaSets {
+		for _, owner := range rs.OwnerReferences {
+			ch <- newKubeOwnerMetric("kube_replicaset_owner", "replicaset", rs.GetNamespace(), rs.GetName(), owner.Name, owner.Kind, owner.Controller != nil && *owner.Controller)
+		}
+	}
+}
+
+//--------------------------------------------------------------------------
+//  KubeOwnerMetric
+//--------------------------------------------------------------------------
+
+// KubeOwnerMetric is a prometheus.Metric emitting the owner of a namespaced resource,
+// such as a ReplicaSet or a Job.
+type KubeOwnerMetric struct {
+	fqName            string
+	help              string
+	resourceLabel     string
+	namespace         string
+	resource          string
+	ownerIsController bool
+	ownerName         string
+	ownerKind         string
+}
+
+// Creates a new KubeOwnerMetric, implementation of prometheus.Metric. The resource name
+// is emitted using the provided resourceLabel, e.g. "replicaset" or "job_name".
+func newKubeOwnerMetric(fqname, resourceLabel, namespace, resource, ownerName, ownerKind string, ownerIsController bool) KubeOwnerMetric {
+	return KubeOwnerMetric{
+		fqName:            fqname,
+		help:              fqname + " Information about the owner",
+		resourceLabel:     resourceLabel,
+		namespace:         namespace,
+		resource:          resource,
+		ownerName:         ownerName,
+		ownerKind:         ownerKind,
+		ownerIsController: ownerIsController,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kom KubeOwnerMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"namespace":           kom.namespace,
+		kom.resourceLabel:     kom.resource,
+		"owner_name":          kom.ownerName,
+		"owner_kind":          kom.ownerKind,
+		"owner_is_controller": fmt.Sprintf("%t", kom.ownerIsController),
+	}
+	return prometheus.NewDesc(kom.fqName, kom.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (kom KubeOwnerMetric) Write(m *dto.Metric) error {
+	v := float64(1.0)
+	m.Gauge = &dto.Gauge{
+		Value: &v,
+	}
+
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &kom.namespace,
+		},
+		{
+			Name:  toStringPtr(kom.resourceLabel),
+			Value: &kom.resource,
+		},
+		{
+			Name:  toStringPtr("owner_name"),
+			Value: &kom.ownerName,
+		},
+		{
+			Name:  toStringPtr("owner_kind"),
+			Value: &kom.ownerKind,
+		},
+		{
+			Name:  toStringPtr("owner_is_controller"),
+			Value: toStringPtr(fmt.Sprintf("%t", kom.ownerIsController)),
+		},
+	}
+	return nil
+}
@@ -21,6 +21,7 @@ type KubecostServiceCollector struct {
 // collected by this Collector.
 func (sc KubecostServiceCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- prometheus.NewDesc("service_selector_labels", "service selector labels", []string{}, nil)
+	ch <- prometheus.NewDesc("kubecost_service_port_count", "Number of ports exposed by a service", []string{}, nil)
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
@@ -35,6 +36,8 @@ func (sc KubecostServiceCollector) Collect(ch chan<- prometheus.Metric) {
 			m := newServiceSelectorLabelsMetric(serviceName, serviceNS, "service_selector_labels", labels, values)
 			ch <- m
 		}
+
+		ch <- newServicePortCountMetric(serviceName, serviceNS, "kubecost_service_port_count", float64(len(svc.Spec.Ports)))
 	}
 }
 
@@ -99,3 +102,57 @@ func (s ServiceSelectorLabelsMetric) Write(m *dto.Metric) error {
 	m.Label = labels
 	return nil
 }
+
+//--------------------------------------------------------------------------
+//  ServicePortCountMetric
+//--------------------------------------------------------------------------
+
+// ServicePortCountMetric is a prometheus.Metric used to encode the number of
+// ports exposed by a service
+type ServicePortCountMetric struct {
+	fqName      string
+	help        string
+	serviceName string
+	namespace   string
+	value       float64
+}
+
+// Creates a new ServicePortCountMetric, implementation of prometheus.Metric
+func newServicePortCountMetric(name, namespace, fqname string, value float64) ServicePortCountMetric {
+	return ServicePortCountMetric{
+		fqName:      fqname,
+		help:        "kubecost_service_port_count Number of ports exposed by a service",
+		serviceName: name,
+		namespace:   namespace,
+		value:       value,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (s ServicePortCountMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"service":   s.serviceName,
+		"namespace": s.namespace,
+	}
+	return prometheus.NewDesc(s.fqName, s.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (s ServicePortCountMetric) Write(m *dto.Metric) error {
+	m.Gauge = &dto.Gauge{
+		Value: &s.value,
+	}
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &s.namespace,
+		},
+		{
+			Name:  toStringPtr("service"),
+			Value: &s.serviceName,
+		},
+	}
+	return nil
+}
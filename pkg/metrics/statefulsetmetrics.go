@@ -1,13 +1,78 @@
 package metrics
 
 import (
+	"sync"
+
 	"github.com/kubecost/cost-model/pkg/clustercache"
 	"github.com/kubecost/cost-model/pkg/prom"
 
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
 )
 
+//--------------------------------------------------------------------------
+//  NodePrices
+//--------------------------------------------------------------------------
+
+// NodeResourcePrices are the hourly prices of a CPU and of a GiB of RAM on a
+// node
+type NodeResourcePrices struct {
+	CPU float64
+	RAM float64
+}
+
+// NodePrices are the resource prices of each node, by name, with which the
+// kubecost controller cost estimates are priced. They are set as nodes are
+// priced by the cost model, rather than as metrics are collected, so that
+// collection does not price every node on every scrape.
+type NodePrices struct {
+	lock     sync.RWMutex
+	prices   map[string]NodeResourcePrices
+	defaults NodeResourcePrices
+	set      bool
+}
+
+// NewNodePrices returns NodePrices without any prices, with which no cost
+// estimates are emitted until they are Set.
+func NewNodePrices() *NodePrices {
+	return &NodePrices{
+		prices: map[string]NodeResourcePrices{},
+	}
+}
+
+// Set replaces the prices of each node, and the default prices of pods which
+// are not scheduled on a priced node.
+func (np *NodePrices) Set(prices map[string]NodeResourcePrices, defaults NodeResourcePrices) {
+	np.lock.Lock()
+	defer np.lock.Unlock()
+
+	np.prices = prices
+	np.defaults = defaults
+	np.set = true
+}
+
+// IsSet returns true if the prices have been set
+func (np *NodePrices) IsSet() bool {
+	np.lock.RLock()
+	defer np.lock.RUnlock()
+
+	return np.set
+}
+
+// For returns the prices of the node with the given name, or the default
+// prices if the node is not priced.
+func (np *NodePrices) For(node string) NodeResourcePrices {
+	np.lock.RLock()
+	defer np.lock.RUnlock()
+
+	if prices, ok := np.prices[node]; ok {
+		return prices
+	}
+	return np.defaults
+}
+
 //--------------------------------------------------------------------------
 //  KubecostStatefulsetCollector
 //--------------------------------------------------------------------------
@@ -15,12 +80,17 @@ import (
 // StatefulsetCollector is a prometheus collector that generates StatefulsetMetrics
 type KubecostStatefulsetCollector struct {
 	KubeClusterCache clustercache.ClusterCache
+	NodePrices       *NodePrices
 }
 
 // Describe sends the super-set of all possible descriptors of metrics
 // collected by this Collector.
 func (sc KubecostStatefulsetCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- prometheus.NewDesc("statefulSet_match_labels", "statfulSet match labels", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_statefulset_update_strategy_type", "kube_statefulset_update_strategy_type Update strategy type of a StatefulSet", []string{}, nil)
+	if sc.NodePrices != nil {
+		ch <- prometheus.NewDesc("kubecost_statefulset_cost_estimate", "kubecost_statefulset_cost_estimate Hourly cost estimate of a StatefulSet based on container requests", []string{}, nil)
+	}
 }
 
 // Collect is called by the Prometheus registry when collecting metrics.
@@ -35,7 +105,67 @@ func (sc KubecostStatefulsetCollector) Collect(ch chan<- prometheus.Metric) {
 			m := newStatefulsetMatchLabelsMetric(statefulsetName, statefulsetNS, "statefulSet_match_labels", labels, values)
 			ch <- m
 		}
+
+		// An unset update strategy type defaults to RollingUpdate
+		strategy := statefulset.Spec.UpdateStrategy.Type
+		if strategy == "" {
+			strategy = appsv1.RollingUpdateStatefulSetStrategyType
+		}
+		ch <- newStatefulsetUpdateStrategyMetric(statefulsetName, statefulsetNS, "kube_statefulset_update_strategy_type", string(strategy))
+	}
+
+	// Until the nodes are first priced, the cost cannot be estimated
+	if sc.NodePrices == nil || !sc.NodePrices.IsSet() {
+		return
+	}
+
+	costs := sc.statefulsetCostEstimates()
+	for _, statefulset := range ds {
+		statefulsetName := statefulset.GetName()
+		statefulsetNS := statefulset.GetNamespace()
+
+		cost := costs[statefulsetNS+"/"+statefulsetName]
+		ch <- newStatefulsetCostEstimateMetric(statefulsetName, statefulsetNS, "kubecost_statefulset_cost_estimate", cost)
+	}
+}
+
+// statefulsetCostEstimates returns the hourly cost of the container requests of
+// each StatefulSet's pods, keyed by "namespace/name", priced using the node each
+// pod is scheduled on.
+func (sc KubecostStatefulsetCollector) statefulsetCostEstimates() map[string]float64 {
+	costs := make(map[string]float64)
+
+	for _, pod := range sc.KubeClusterCache.GetAllPods() {
+		owner := statefulsetOwner(pod)
+		if owner == "" {
+			continue
+		}
+
+		prices := sc.NodePrices.For(pod.Spec.NodeName)
+
+		var cost float64
+		for _, container := range pod.Spec.Containers {
+			cpuCores := float64(container.Resources.Requests.Cpu().MilliValue()) / 1000
+			ramGiB := float64(container.Resources.Requests.Memory().Value()) / 1024 / 1024 / 1024
+
+			cost += cpuCores*prices.CPU + ramGiB*prices.RAM
+		}
+
+		costs[pod.GetNamespace()+"/"+owner] += cost
 	}
+
+	return costs
+}
+
+// statefulsetOwner returns the name of the StatefulSet which owns the pod, or
+// an empty string if the pod is not owned by a StatefulSet.
+func statefulsetOwner(pod *v1.Pod) string {
+	for _, ref := range pod.GetOwnerReferences() {
+		if ref.Kind == "StatefulSet" {
+			return ref.Name
+		}
+	}
+	return ""
 }
 
 //--------------------------------------------------------------------------
@@ -99,3 +229,117 @@ func (s StatefulsetMatchLabelsMetric) Write(m *dto.Metric) error {
 	m.Label = labels
 	return nil
 }
+
+//--------------------------------------------------------------------------
+//  StatefulsetCostEstimateMetric
+//--------------------------------------------------------------------------
+
+// StatefulsetCostEstimateMetric is a prometheus.Metric used to encode the
+// hourly cost estimate of a statefulset
+type StatefulsetCostEstimateMetric struct {
+	fqName          string
+	help            string
+	statefulsetName string
+	namespace       string
+	value           float64
+}
+
+// Creates a new StatefulsetCostEstimateMetric, implementation of prometheus.Metric
+func newStatefulsetCostEstimateMetric(name, namespace, fqname string, value float64) StatefulsetCostEstimateMetric {
+	return StatefulsetCostEstimateMetric{
+		fqName:          fqname,
+		help:            "kubecost_statefulset_cost_estimate Hourly cost estimate of a StatefulSet based on container requests",
+		statefulsetName: name,
+		namespace:       namespace,
+		value:           value,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (s StatefulsetCostEstimateMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"statefulset": s.statefulsetName,
+		"namespace":   s.namespace,
+	}
+	return prometheus.NewDesc(s.fqName, s.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (s StatefulsetCostEstimateMetric) Write(m *dto.Metric) error {
+	m.Gauge = &dto.Gauge{
+		Value: &s.value,
+	}
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &s.namespace,
+		},
+		{
+			Name:  toStringPtr("statefulset"),
+			Value: &s.statefulsetName,
+		},
+	}
+	return nil
+}
+
+//--------------------------------------------------------------------------
+//  StatefulsetUpdateStrategyMetric
+//--------------------------------------------------------------------------
+
+// StatefulsetUpdateStrategyMetric is a prometheus.Metric used to encode the
+// update strategy type of a statefulset, e.g. RollingUpdate or OnDelete
+type StatefulsetUpdateStrategyMetric struct {
+	fqName          string
+	help            string
+	statefulsetName string
+	namespace       string
+	strategyType    string
+}
+
+// Creates a new StatefulsetUpdateStrategyMetric, implementation of prometheus.Metric
+func newStatefulsetUpdateStrategyMetric(name, namespace, fqname, strategyType string) StatefulsetUpdateStrategyMetric {
+	return StatefulsetUpdateStrategyMetric{
+		fqName:          fqname,
+		help:            "kube_statefulset_update_strategy_type Update strategy type of a StatefulSet",
+		statefulsetName: name,
+		namespace:       namespace,
+		strategyType:    strategyType,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (s StatefulsetUpdateStrategyMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"statefulset": s.statefulsetName,
+		"namespace":   s.namespace,
+		"type":        s.strategyType,
+	}
+	return prometheus.NewDesc(s.fqName, s.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (s StatefulsetUpdateStrategyMetric) Write(m *dto.Metric) error {
+	v := float64(1)
+	m.Gauge = &dto.Gauge{
+		Value: &v,
+	}
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &s.namespace,
+		},
+		{
+			Name:  toStringPtr("statefulset"),
+			Value: &s.statefulsetName,
+		},
+		{
+			Name:  toStringPtr("type"),
+			Value: &s.strategyType,
+		},
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+package metrics
+
+import (
+	"sort"
+
+	"github.com/kubecost/cost-model/pkg/clustercache"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	v1 "k8s.io/api/core/v1"
+	stv1 "k8s.io/api/storage/v1"
+)
+
+//--------------------------------------------------------------------------
+//  KubeStorageClassCollector
+//--------------------------------------------------------------------------
+
+// KubeStorageClassCollector is a prometheus collector that emits storage class
+// metrics, including the parameters which determine the cost of its volumes;
+// e.g. the type of disk.
+type KubeStorageClassCollector struct {
+	KubeClusterCache clustercache.ClusterCache
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (ksc KubeStorageClassCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- prometheus.NewDesc("kube_storageclass_info", "Information about storageclass.", []string{}, nil)
+	ch <- prometheus.NewDesc("kube_storageclass_parameter", "A parameter of a storageclass.", []string{}, nil)
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (ksc KubeStorageClassCollector) Collect(ch chan<- prometheus.Metric) {
+	storageClasses := ksc.KubeClusterCache.GetAllStorageClasses()
+	for _, sc := range storageClasses {
+		// A storage class without a reclaim policy or binding mode has the
+		// defaults of the API server
+		reclaimPolicy := string(v1.PersistentVolumeReclaimDelete)
+		if sc.ReclaimPolicy != nil {
+			reclaimPolicy = string(*sc.ReclaimPolicy)
+		}
+		bindingMode := string(stv1.VolumeBindingImmediate)
+		if sc.VolumeBindingMode != nil {
+			bindingMode = string(*sc.VolumeBindingMode)
+		}
+
+		ch <- newKubeStorageClassInfoMetric("kube_storageclass_info", sc.Name, sc.Provisioner, reclaimPolicy, bindingMode)
+
+		keys := make([]string, 0, len(sc.Parameters))
+		for key := range sc.Parameters {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			ch <- newKubeStorageClassParameterMetric("kube_storageclass_parameter", sc.Name, key, sc.Parameters[key])
+		}
+	}
+}
+
+//--------------------------------------------------------------------------
+//  KubeStorageClassInfoMetric
+//--------------------------------------------------------------------------
+
+// KubeStorageClassInfoMetric is a prometheus.Metric used to encode the
+// provisioner and policies of a storage class
+type KubeStorageClassInfoMetric struct {
+	fqName            string
+	help              string
+	storageClass      string
+	provisioner       string
+	reclaimPolicy     string
+	volumeBindingMode string
+}
+
+// Creates a new KubeStorageClassInfoMetric, implementation of prometheus.Metric
+func newKubeStorageClassInfoMetric(fqname, storageClass, provisioner, reclaimPolicy, volumeBindingMode string) KubeStorageClassInfoMetric {
+	return KubeStorageClassInfoMetric{
+		fqName:            fqname,
+		help:              "kube_storageclass_info Information about storageclass.",
+		storageClass:      storageClass,
+		provisioner:       provisioner,
+		reclaimPolicy:     reclaimPolicy,
+		volumeBindingMode: volumeBindingMode,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (ksci KubeStorageClassInfoMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"storageclass":        ksci.storageClass,
+		"provisioner":         ksci.provisioner,
+		"reclaim_policy":      ksci.reclaimPolicy,
+		"volume_binding_mode": ksci.volumeBindingMode,
+	}
+	return prometheus.NewDesc(ksci.fqName, ksci.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (ksci KubeStorageClassInfoMetric) Write(m *dto.Metric) error {
+	v := float64(1.0)
+	m.Gauge = &dto.Gauge{
+		Value: &v,
+	}
+
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("storageclass"),
+			Value: &ksci.storageClass,
+		},
+		{
+			Name:  toStringPtr("provisioner"),
+			Value: &ksci.provisioner,
+		},
+		{
+			Name:  toStringPtr("reclaim_policy"),
+			Value: &ksci.reclaimPolicy,
+		},
+		{
+			Name:  toStringPtr("volume_binding_mode"),
+			Value: &ksci.volumeBindingMode,
+		},
+	}
+	return nil
+}
+
+//--------------------------------------------------------------------------
+//  KubeStorageClassParameterMetric
+//--------------------------------------------------------------------------
+
+// KubeStorageClassParameterMetric is a prometheus.Metric used to encode a
+// parameter of a storage class, as a key-value pair
+type KubeStorageClassParameterMetric struct {
+	fqName       string
+	help         string
+	storageClass string
+	parameter    string
+	value        string
+}
+
+// Creates a new KubeStorageClassParameterMetric, implementation of prometheus.Metric
+func newKubeStorageClassParameterMetric(fqname, storageClass, parameter, value string) KubeStorageClassParameterMetric {
+	return KubeStorageClassParameterMetric{
+		fqName:       fqname,
+		help:         "kube_storageclass_parameter A parameter of a storageclass.",
+		storageClass: storageClass,
+		parameter:    parameter,
+		value:        value,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (kscp KubeStorageClassParameterMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"storageclass": kscp.storageClass,
+		"parameter":    kscp.parameter,
+		"value":        kscp.value,
+	}
+	return prometheus.NewDesc(kscp.fqName, kscp.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (kscp KubeStorageClassParameterMetric) Write(m *dto.Metric) error {
+	v := float64(1.0)
+	m.Gauge = &dto.Gauge{
+		Value: &v,
+	}
+
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("storageclass"),
+			Value: &kscp.storageClass,
+		},
+		{
+			Name:  toStringPtr("parameter"),
+			Value: &kscp.parameter,
+		},
+		{
+			Name:  toStringPtr("value"),
+			Value: &kscp.value,
+		},
+	}
+	return nil
+}
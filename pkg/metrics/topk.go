@@ -0,0 +1,220 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+//--------------------------------------------------------------------------
+//  TopKCollector
+//--------------------------------------------------------------------------
+
+// TopKWorkload identifies a workload by its namespace and controller
+type TopKWorkload struct {
+	Namespace      string
+	ControllerKind string
+	Controller     string
+}
+
+// topKCost is a cost along with the label values of its series
+type topKCost struct {
+	labelValues []string
+	cost        float64
+}
+
+// TopKCollector is a prometheus collector that emits the costs of the K most
+// costly namespaces and workloads, as computed on the refresh cycle of the
+// cost data, so that dashboards can rank them without querying raw metrics.
+// Costs are set with Update, and the series of namespaces and workloads which
+// drop out of the top K are no longer emitted.
+type TopKCollector struct {
+	// K is the number of namespaces and of workloads emitted
+	K int
+
+	lock       sync.RWMutex
+	namespaces []topKCost
+	workloads  []topKCost
+}
+
+// NewTopKCollector creates a TopKCollector which emits the k most costly
+// namespaces and workloads. A non-positive k emits all of them.
+func NewTopKCollector(k int) *TopKCollector {
+	return &TopKCollector{K: k}
+}
+
+// Update replaces the costs emitted with the top K of the given costs of
+// namespaces and workloads
+func (tkc *TopKCollector) Update(namespaceCosts map[string]float64, workloadCosts map[TopKWorkload]float64) {
+	namespaces := make([]topKCost, 0, len(namespaceCosts))
+	for namespace, cost := range namespaceCosts {
+		namespaces = append(namespaces, topKCost{labelValues: []string{namespace}, cost: cost})
+	}
+
+	workloads := make([]topKCost, 0, len(workloadCosts))
+	for workload, cost := range workloadCosts {
+		workloads = append(workloads, topKCost{
+			labelValues: []string{workload.Namespace, workload.ControllerKind, workload.Controller},
+			cost:        cost,
+		})
+	}
+
+	tkc.lock.Lock()
+	defer tkc.lock.Unlock()
+
+	tkc.namespaces = topK(namespaces, tkc.K)
+	tkc.workloads = topK(workloads, tkc.K)
+}
+
+// topK returns the k most costly of the given costs. Ties are broken by label
+// values so that the result is stable. A non-positive k returns all costs.
+func topK(costs []topKCost, k int) []topKCost {
+	sort.Slice(costs, func(i, j int) bool {
+		if costs[i].cost != costs[j].cost {
+			return costs[i].cost > costs[j].cost
+		}
+		for l := range costs[i].labelValues {
+			if costs[i].labelValues[l] != costs[j].labelValues[l] {
+				return costs[i].labelValues[l] < costs[j].labelValues[l]
+			}
+		}
+		return false
+	})
+
+	if k > 0 && len(costs) > k {
+		costs = costs[:k]
+	}
+	return costs
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (tkc *TopKCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- prometheus.NewDesc("kubecost_topk_namespace_cost", "Hourly cost of the most costly namespaces", []string{}, nil)
+	ch <- prometheus.NewDesc("kubecost_topk_workload_cost", "Hourly cost of the most costly workloads", []string{}, nil)
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (tkc *TopKCollector) Collect(ch chan<- prometheus.Metric) {
+	tkc.lock.RLock()
+	defer tkc.lock.RUnlock()
+
+	for _, c := range tkc.namespaces {
+		ch <- newTopKNamespaceCostMetric("kubecost_topk_namespace_cost", c.labelValues[0], c.cost)
+	}
+	for _, c := range tkc.workloads {
+		ch <- newTopKWorkloadCostMetric("kubecost_topk_workload_cost", c.labelValues[0], c.labelValues[1], c.labelValues[2], c.cost)
+	}
+}
+
+//--------------------------------------------------------------------------
+//  TopKNamespaceCostMetric
+//--------------------------------------------------------------------------
+
+// TopKNamespaceCostMetric is a prometheus.Metric used to encode the hourly
+// cost of one of the most costly namespaces
+type TopKNamespaceCostMetric struct {
+	fqName    string
+	help      string
+	namespace string
+	cost      float64
+}
+
+// Creates a new TopKNamespaceCostMetric, implementation of prometheus.Metric
+func newTopKNamespaceCostMetric(fqname, namespace string, cost float64) TopKNamespaceCostMetric {
+	return TopKNamespaceCostMetric{
+		fqName:    fqname,
+		help:      "kubecost_topk_namespace_cost Hourly cost of the most costly namespaces",
+		namespace: namespace,
+		cost:      cost,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (tknc TopKNamespaceCostMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"namespace": tknc.namespace,
+	}
+	return prometheus.NewDesc(tknc.fqName, tknc.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (tknc TopKNamespaceCostMetric) Write(m *dto.Metric) error {
+	m.Gauge = &dto.Gauge{
+		Value: &tknc.cost,
+	}
+
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &tknc.namespace,
+		},
+	}
+	return nil
+}
+
+//--------------------------------------------------------------------------
+//  TopKWorkloadCostMetric
+//--------------------------------------------------------------------------
+
+// TopKWorkloadCostMetric is a prometheus.Metric used to encode the hourly
+// cost of one of the most costly workloads
+type TopKWorkloadCostMetric struct {
+	fqName         string
+	help           string
+	namespace      string
+	controllerKind string
+	controller     string
+	cost           float64
+}
+
+// Creates a new TopKWorkloadCostMetric, implementation of prometheus.Metric
+func newTopKWorkloadCostMetric(fqname, namespace, controllerKind, controller string, cost float64) TopKWorkloadCostMetric {
+	return TopKWorkloadCostMetric{
+		fqName:         fqname,
+		help:           "kubecost_topk_workload_cost Hourly cost of the most costly workloads",
+		namespace:      namespace,
+		controllerKind: controllerKind,
+		controller:     controller,
+		cost:           cost,
+	}
+}
+
+// Desc returns the descriptor for the Metric. This method idempotently
+// returns the same descriptor throughout the lifetime of the Metric.
+func (tkwc TopKWorkloadCostMetric) Desc() *prometheus.Desc {
+	l := prometheus.Labels{
+		"namespace":       tkwc.namespace,
+		"controller_kind": tkwc.controllerKind,
+		"controller":      tkwc.controller,
+	}
+	return prometheus.NewDesc(tkwc.fqName, tkwc.help, []string{}, l)
+}
+
+// Write encodes the Metric into a "Metric" Protocol Buffer data
+// transmission object.
+func (tkwc TopKWorkloadCostMetric) Write(m *dto.Metric) error {
+	m.Gauge = &dto.Gauge{
+		Value: &tkwc.cost,
+	}
+
+	m.Label = []*dto.LabelPair{
+		{
+			Name:  toStringPtr("controller"),
+			Value: &tkwc.controller,
+		},
+		{
+			Name:  toStringPtr("controller_kind"),
+			Value: &tkwc.controllerKind,
+		},
+		{
+			Name:  toStringPtr("namespace"),
+			Value: &tkwc.namespace,
+		},
+	}
+	return nil
+}
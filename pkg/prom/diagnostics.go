@@ -63,7 +63,11 @@ func LogPrometheusClientState(client prometheus.Client) {
 		outbound := rc.TotalOutboundRequests()
 		total := queued + outbound
 
-		log.Infof("Outbound Requests: %d, Queued Requests: %d, Total Requests: %d", outbound, queued, total)
+		promLog.WithFields(log.Fields{
+			"outbound": outbound,
+			"queued":   queued,
+			"total":    total,
+		}).Infof("Outbound Requests: %d, Queued Requests: %d, Total Requests: %d", outbound, queued, total)
 	}
 }
 
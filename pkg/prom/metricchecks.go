@@ -0,0 +1,175 @@
+package prom
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/util/timeutil"
+	prometheus "github.com/prometheus/client_golang/api"
+)
+
+const (
+	// DefaultMetricCheckWindow is the recent window over which metric checks
+	// count samples
+	DefaultMetricCheckWindow time.Duration = 10 * time.Minute
+
+	// DefaultMetricCheckCacheDuration is how long the results of metric checks
+	// are reused before the checks are run again
+	DefaultMetricCheckCacheDuration time.Duration = time.Minute
+)
+
+// MetricCheck is a metric family required by the cost model, and the likely
+// misconfiguration when no samples of it exist.
+type MetricCheck struct {
+	ID               string
+	Selector         string
+	Misconfiguration string
+}
+
+// DefaultMetricChecks returns the canonical set of metric families which must
+// be present for the cost model to produce accurate data.
+func DefaultMetricChecks() []*MetricCheck {
+	return []*MetricCheck{
+		{
+			ID:               "cadvisor",
+			Selector:         "container_cpu_usage_seconds_total",
+			Misconfiguration: "cAdvisor metrics are not scraped; check that Prometheus has a kubelet/cAdvisor scrape job",
+		},
+		{
+			ID:               "resourceRequests",
+			Selector:         "kube_pod_container_resource_requests",
+			Misconfiguration: "container resource requests are not scraped; check that the cost-model is scraped and $" + env.KsmV1MetricsFlag.EnvVar + " (or $" + env.EmitKsmV1MetricsEnvVar + ") is not disabled, or that kube-state-metrics is scraped",
+		},
+		{
+			ID:               "nodeExporter",
+			Selector:         "node_cpu_seconds_total",
+			Misconfiguration: "node-exporter metrics are not scraped; check that node-exporter is deployed and has a scrape job",
+		},
+		{
+			ID:               "clusterInfo",
+			Selector:         env.GetClusterInfoMetricName(),
+			Misconfiguration: "cluster info is not scraped; check that the cost-model's /metrics endpoint is scraped and $" + env.ClusterInfoMetricNameEnvVar + " matches the emitted metric",
+		},
+		{
+			ID:               "network",
+			Selector:         "kubecost_pod_network_egress_bytes_total",
+			Misconfiguration: "network costs metrics are not scraped; check that the network costs daemonset is enabled and has a scrape job",
+		},
+	}
+}
+
+// MetricCheckResult is the outcome of a MetricCheck: whether the metric is
+// present, how many samples of it exist in the checked window and, if it is
+// absent, the likely misconfiguration.
+type MetricCheckResult struct {
+	ID               string `json:"id"`
+	Selector         string `json:"selector"`
+	Present          bool   `json:"present"`
+	SampleCount      int    `json:"sampleCount"`
+	Misconfiguration string `json:"misconfiguration,omitempty"`
+	Error            string `json:"error,omitempty"`
+}
+
+// MetricCheckReport contains the results of a run of metric checks.
+type MetricCheckReport struct {
+	Window    string               `json:"window"`
+	Timestamp time.Time            `json:"timestamp"`
+	Results   []*MetricCheckResult `json:"results"`
+}
+
+// MetricChecker runs a set of metric checks against Prometheus, caching the
+// report so that repeated requests do not repeatedly query Prometheus.
+type MetricChecker struct {
+	client        prometheus.Client
+	checks        []*MetricCheck
+	window        time.Duration
+	offset        string
+	cacheDuration time.Duration
+
+	lock   sync.Mutex
+	report *MetricCheckReport
+	now    func() time.Time
+}
+
+// NewMetricChecker creates a new MetricChecker which runs the given checks
+// over the given window, at the given query offset; e.g. " offset 3h" for
+// Thanos.
+func NewMetricChecker(client prometheus.Client, checks []*MetricCheck, window time.Duration, offset string) *MetricChecker {
+	return &MetricChecker{
+		client:        client,
+		checks:        checks,
+		window:        window,
+		offset:        offset,
+		cacheDuration: DefaultMetricCheckCacheDuration,
+		now:           time.Now,
+	}
+}
+
+// Check returns the report of the most recent run of the checks, running them
+// if the report is older than the cache duration.
+func (mc *MetricChecker) Check() *MetricCheckReport {
+	mc.lock.Lock()
+	defer mc.lock.Unlock()
+
+	now := mc.now()
+	if mc.report != nil && now.Sub(mc.report.Timestamp) < mc.cacheDuration {
+		return mc.report
+	}
+
+	mc.report = mc.run(now)
+	return mc.report
+}
+
+// run queries the sample count of each check's metric concurrently.
+func (mc *MetricChecker) run(now time.Time) *MetricCheckReport {
+	ctx := NewNamedContext(mc.client, DiagnosticContextName)
+	window := timeutil.DurationString(mc.window)
+
+	queries := make([]string, len(mc.checks))
+	for i, check := range mc.checks {
+		queries[i] = metricCheckQuery(check.Selector, window, mc.offset)
+	}
+	resChs := ctx.QueryAll(queries...)
+
+	report := &MetricCheckReport{
+		Window:    window,
+		Timestamp: now,
+		Results:   make([]*MetricCheckResult, len(mc.checks)),
+	}
+
+	for i, check := range mc.checks {
+		result := &MetricCheckResult{
+			ID:       check.ID,
+			Selector: check.Selector,
+		}
+		report.Results[i] = result
+
+		qrs, err := resChs[i].Await()
+		if err != nil {
+			result.Error = err.Error()
+			continue
+		}
+
+		for _, qr := range qrs {
+			for _, v := range qr.Values {
+				result.SampleCount += int(v.Value)
+			}
+		}
+
+		result.Present = result.SampleCount > 0
+		if !result.Present {
+			result.Misconfiguration = check.Misconfiguration
+		}
+	}
+
+	return report
+}
+
+// metricCheckQuery returns a query for the number of samples of the given
+// metric selector over the given window, which returns no results if the
+// metric is absent.
+func metricCheckQuery(selector, window, offset string) string {
+	return fmt.Sprintf("sum(count_over_time(%s[%s]%s))", selector, window, offset)
+}
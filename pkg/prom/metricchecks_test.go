@@ -0,0 +1,154 @@
+package prom
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	prometheus "github.com/prometheus/client_golang/api"
+)
+
+// newMetricCheckTestServer starts a fake Prometheus which answers sample count
+// queries with the given count for each metric, and with no results for any
+// other metric. The returned counter is incremented by each query.
+func newMetricCheckTestServer(t *testing.T, counts map[string]int) (prometheus.Client, *int32, func()) {
+	var queries int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&queries, 1)
+
+		query := r.URL.Query().Get("query")
+
+		result := "[]"
+		for metric, count := range counts {
+			if strings.Contains(query, "("+metric+"[") {
+				result = fmt.Sprintf(`[{"metric":{},"value":[1609459200,"%d"]}]`, count)
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":%s}}`, result)
+	}))
+
+	client, err := prometheus.NewClient(prometheus.Config{Address: server.URL})
+	if err != nil {
+		server.Close()
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+
+	return client, &queries, server.Close
+}
+
+func TestMetricChecker_Check(t *testing.T) {
+	client, _, closeFn := newMetricCheckTestServer(t, map[string]int{
+		"container_cpu_usage_seconds_total": 120,
+		"node_cpu_seconds_total":            40,
+	})
+	defer closeFn()
+
+	checks := []*MetricCheck{
+		{ID: "cadvisor", Selector: "container_cpu_usage_seconds_total", Misconfiguration: "no cadvisor"},
+		{ID: "nodeExporter", Selector: "node_cpu_seconds_total", Misconfiguration: "no node-exporter"},
+		{ID: "network", Selector: "kubecost_pod_network_egress_bytes_total", Misconfiguration: "no network daemonset"},
+	}
+
+	report := NewMetricChecker(client, checks, 10*time.Minute, "").Check()
+
+	if report.Window != "10m" {
+		t.Errorf("expected window 10m; got %s", report.Window)
+	}
+	if len(report.Results) != len(checks) {
+		t.Fatalf("expected %d results; got %d", len(checks), len(report.Results))
+	}
+
+	expected := []struct {
+		id               string
+		present          bool
+		sampleCount      int
+		misconfiguration string
+	}{
+		{"cadvisor", true, 120, ""},
+		{"nodeExporter", true, 40, ""},
+		{"network", false, 0, "no network daemonset"},
+	}
+
+	for i, exp := range expected {
+		result := report.Results[i]
+		if result.ID != exp.id {
+			t.Errorf("result %d: expected ID %s; got %s", i, exp.id, result.ID)
+		}
+		if result.Error != "" {
+			t.Errorf("%s: unexpected error: %s", exp.id, result.Error)
+		}
+		if result.Present != exp.present {
+			t.Errorf("%s: expected present %t; got %t", exp.id, exp.present, result.Present)
+		}
+		if result.SampleCount != exp.sampleCount {
+			t.Errorf("%s: expected sample count %d; got %d", exp.id, exp.sampleCount, result.SampleCount)
+		}
+		if result.Misconfiguration != exp.misconfiguration {
+			t.Errorf("%s: expected misconfiguration %q; got %q", exp.id, exp.misconfiguration, result.Misconfiguration)
+		}
+	}
+}
+
+func TestMetricChecker_CheckCached(t *testing.T) {
+	client, queries, closeFn := newMetricCheckTestServer(t, map[string]int{
+		"container_cpu_usage_seconds_total": 1,
+	})
+	defer closeFn()
+
+	checks := []*MetricCheck{
+		{ID: "cadvisor", Selector: "container_cpu_usage_seconds_total"},
+	}
+
+	now := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	mc := NewMetricChecker(client, checks, 10*time.Minute, "")
+	mc.now = func() time.Time { return now }
+
+	first := mc.Check()
+	now = now.Add(30 * time.Second)
+	second := mc.Check()
+
+	if first != second {
+		t.Errorf("expected cached report within cache duration")
+	}
+	if n := atomic.LoadInt32(queries); n != 1 {
+		t.Errorf("expected 1 query; got %d", n)
+	}
+
+	now = now.Add(DefaultMetricCheckCacheDuration)
+	third := mc.Check()
+
+	if third == second {
+		t.Errorf("expected new report after cache duration")
+	}
+	if n := atomic.LoadInt32(queries); n != 2 {
+		t.Errorf("expected 2 queries; got %d", n)
+	}
+}
+
+func TestMetricCheckQuery(t *testing.T) {
+	query := metricCheckQuery("node_cpu_seconds_total", "10m", " offset 3h")
+	expected := "sum(count_over_time(node_cpu_seconds_total[10m] offset 3h))"
+	if query != expected {
+		t.Errorf("expected %s; got %s", expected, query)
+	}
+}
+
+func TestDefaultMetricChecks(t *testing.T) {
+	ids := map[string]bool{}
+	for _, check := range DefaultMetricChecks() {
+		if check.ID == "" || check.Selector == "" || check.Misconfiguration == "" {
+			t.Errorf("expected check to have an ID, selector, and misconfiguration: %+v", check)
+		}
+		if ids[check.ID] {
+			t.Errorf("duplicate check ID: %s", check.ID)
+		}
+		ids[check.ID] = true
+	}
+}
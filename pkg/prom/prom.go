@@ -3,10 +3,14 @@ package prom
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/kubecost/cost-model/pkg/collections"
@@ -21,6 +25,10 @@ import (
 	prometheus "github.com/prometheus/client_golang/api"
 )
 
+// promLog writes the logs of Prometheus queries, whose level can be set with
+// LOG_LEVEL_PROM
+var promLog = log.WithModule("prom")
+
 //--------------------------------------------------------------------------
 //  QueryParamsDecorator
 //--------------------------------------------------------------------------
@@ -66,6 +74,7 @@ type RateLimitedPrometheusClient struct {
 	id         string
 	client     prometheus.Client
 	auth       *ClientAuth
+	authLock   sync.RWMutex
 	queue      collections.BlockingQueue
 	decorator  QueryParamsDecorator
 	outbound   *atomic.AtomicInt32
@@ -213,9 +222,20 @@ func (rlpc *RateLimitedPrometheusClient) worker() {
 	}
 }
 
+// SetAuth replaces the authentication applied to subsequent requests, e.g.
+// when credentials are rotated.
+func (rlpc *RateLimitedPrometheusClient) SetAuth(auth *ClientAuth) {
+	rlpc.authLock.Lock()
+	defer rlpc.authLock.Unlock()
+
+	rlpc.auth = auth
+}
+
 // Rate limit and passthrough to prometheus client API
 func (rlpc *RateLimitedPrometheusClient) Do(ctx context.Context, req *http.Request) (*http.Response, []byte, prometheus.Warnings, error) {
+	rlpc.authLock.RLock()
 	rlpc.auth.Apply(req)
+	rlpc.authLock.RUnlock()
 
 	respChan := make(chan *workResponse)
 	defer close(respChan)
@@ -246,7 +266,10 @@ func (rlpc *RateLimitedPrometheusClient) Do(ctx context.Context, req *http.Reque
 //--------------------------------------------------------------------------
 
 func NewPrometheusClient(address string, timeout, keepAlive time.Duration, queryConcurrency int, queryLogFile string) (prometheus.Client, error) {
-	tlsConfig := &tls.Config{InsecureSkipVerify: env.GetInsecureSkipVerify()}
+	tlsConfig, err := NewClientTLSConfig()
+	if err != nil {
+		return nil, err
+	}
 
 	// may be necessary for long prometheus queries. TODO: make this configurable
 	pc := prometheus.Config{
@@ -271,6 +294,41 @@ func NewPrometheusClient(address string, timeout, keepAlive time.Duration, query
 	return NewRateLimitedClient(PrometheusClientID, pc, queryConcurrency, auth, nil, queryLogFile)
 }
 
+// NewClientTLSConfig returns the TLS configuration of the clients of Prometheus and Thanos.
+// It presents a client certificate if one is configured, for deployments which require
+// mutual TLS, and verifies the server with the configured CA certificates, if any.
+func NewClientTLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: env.GetInsecureSkipVerify()}
+
+	certFile, keyFile := env.GetPrometheusTLSCertFile(), env.GetPrometheusTLSKeyFile()
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, fmt.Errorf("%s and %s must both be set for mutual TLS", env.PrometheusTLSCertFileEnvVar, env.PrometheusTLSKeyFileEnvVar)
+		}
+
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %s", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if caFile := env.GetPrometheusTLSCAFile(); caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA certificates: %s", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no CA certificates found in %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return tlsConfig, nil
+}
+
 // LogQueryRequest logs the query that was send to prom/thanos with the time in queue and total time after being sent
 func LogQueryRequest(l *golog.Logger, req *http.Request, queueTime time.Duration, sendTime time.Duration) {
 	if l == nil {
@@ -279,5 +337,10 @@ func LogQueryRequest(l *golog.Logger, req *http.Request, queueTime time.Duration
 	qp := httputil.NewQueryParams(req.URL.Query())
 	query := qp.Get("query", "<Unknown>")
 
+	if requestID, ok := log.RequestIDFromContext(req.Context()); ok {
+		l.Printf("[Queue: %fs, Outbound: %fs][Request: %s][Query: %s]\n", queueTime.Seconds(), sendTime.Seconds(), requestID, query)
+		return
+	}
+
 	l.Printf("[Queue: %fs, Outbound: %fs][Query: %s]\n", queueTime.Seconds(), sendTime.Seconds(), query)
 }
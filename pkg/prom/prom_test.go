@@ -0,0 +1,42 @@
+package prom
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kubecost/cost-model/pkg/env"
+)
+
+func TestNewClientTLSConfig(t *testing.T) {
+	tlsConfig, err := NewClientTLSConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(tlsConfig.Certificates) != 0 || tlsConfig.RootCAs != nil {
+		t.Errorf("expected no client certificate or CA certificates by default")
+	}
+
+	os.Setenv(env.PrometheusTLSCertFileEnvVar, "/tmp/client.crt")
+	_, err = NewClientTLSConfig()
+	os.Unsetenv(env.PrometheusTLSCertFileEnvVar)
+	if err == nil {
+		t.Errorf("expected an error for a client certificate without a key")
+	}
+
+	dir, err := ioutil.TempDir("", "prom-tls")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	caFile := filepath.Join(dir, "ca.crt")
+	ioutil.WriteFile(caFile, []byte("not a certificate"), 0600)
+
+	os.Setenv(env.PrometheusTLSCAFileEnvVar, caFile)
+	defer os.Unsetenv(env.PrometheusTLSCAFileEnvVar)
+	if _, err := NewClientTLSConfig(); err == nil {
+		t.Errorf("expected an error for a CA file without certificates")
+	}
+}
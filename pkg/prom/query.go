@@ -27,6 +27,10 @@ type Context struct {
 	Client         prometheus.Client
 	name           string
 	errorCollector *QueryErrorCollector
+
+	// requestCtx is the context of the request on whose behalf queries are
+	// made, if any, whose request ID is included in the logs of the queries
+	requestCtx context.Context
 }
 
 // NewContext creates a new Promethues querying context from the given client
@@ -47,6 +51,32 @@ func NewNamedContext(client prometheus.Client, name string) *Context {
 	return ctx
 }
 
+// WithContext sets the context of the request on whose behalf the queries are
+// made, so that their logs, and the query logs of the client, include its
+// request ID, and returns the Context.
+func (ctx *Context) WithContext(requestCtx context.Context) *Context {
+	ctx.requestCtx = requestCtx
+	return ctx
+}
+
+// logger returns the Logger of the queries, which includes the request ID of
+// the Context, if any
+func (ctx *Context) logger() *log.Logger {
+	return promLog.Ctx(ctx.requestCtx)
+}
+
+// withRequestID returns the request with the request ID of the Context, if any,
+// carried by its context and set on its RequestIDHeader
+func (ctx *Context) withRequestID(req *http.Request) *http.Request {
+	requestID, ok := log.RequestIDFromContext(ctx.requestCtx)
+	if !ok {
+		return req
+	}
+
+	req.Header.Set(httputil.RequestIDHeader, requestID)
+	return req.WithContext(log.ContextWithRequestID(req.Context(), requestID))
+}
+
 // Warnings returns the warnings collected from the Context's ErrorCollector
 func (ctx *Context) Warnings() []*QueryWarning {
 	return ctx.errorCollector.Warnings()
@@ -158,6 +188,9 @@ func runQuery(query string, ctx *Context, resCh QueryResultsChan, profileLabel s
 
 	// report all warnings, request, and parse errors (nils will be ignored)
 	ctx.errorCollector.Report(query, warnings, requestError, results.Error)
+	if requestError != nil {
+		ctx.logger().WithFields(log.Fields{"query": query}).Debugf("Query failed: %s", requestError)
+	}
 
 	if profileLabel != "" {
 		log.Profile(startQuery, profileLabel)
@@ -183,6 +216,7 @@ func (ctx *Context) RawQuery(query string) ([]byte, error) {
 		req = httputil.SetName(req, ctx.name)
 	}
 	req = httputil.SetQuery(req, query)
+	req = ctx.withRequestID(req)
 
 	// Note that the warnings return value from client.Do() is always nil using this
 	// version of the prometheus client library. We parse the warnings out of the response
@@ -227,7 +261,7 @@ func (ctx *Context) query(query string) (interface{}, prometheus.Warnings, error
 			return nil, warnings, CommErrorf("Error: %s, Body: %s, Query: %s", w, body, query)
 		}
 
-		log.Warningf("fetching query '%s': %s", query, w)
+		ctx.logger().WithFields(log.Fields{"query": query}).RateLimitedWarningf(w, "fetching query '%s': %s", query, w)
 	}
 
 	return toReturn, warnings, nil
@@ -279,6 +313,9 @@ func runQueryRange(query string, start, end time.Time, step time.Duration, ctx *
 
 	// report all warnings, request, and parse errors (nils will be ignored)
 	ctx.errorCollector.Report(query, warnings, requestError, results.Error)
+	if requestError != nil {
+		ctx.logger().WithFields(log.Fields{"query": query}).Debugf("Query failed: %s", requestError)
+	}
 
 	if profileLabel != "" {
 		log.Profile(startQuery, profileLabel)
@@ -307,6 +344,7 @@ func (ctx *Context) RawQueryRange(query string, start, end time.Time, step time.
 		req = httputil.SetName(req, ctx.name)
 	}
 	req = httputil.SetQuery(req, query)
+	req = ctx.withRequestID(req)
 
 	// Note that the warnings return value from client.Do() is always nil using this
 	// version of the prometheus client library. We parse the warnings out of the response
@@ -351,7 +389,7 @@ func (ctx *Context) queryRange(query string, start, end time.Time, step time.Dur
 			return nil, warnings, CommErrorf("Error: %s, Body: %s, Query: %s", w, body, query)
 		}
 
-		log.Warningf("fetching query '%s': %s", query, w)
+		ctx.logger().WithFields(log.Fields{"query": query}).RateLimitedWarningf(w, "fetching query '%s': %s", query, w)
 	}
 
 	return toReturn, warnings, nil
@@ -1,6 +1,20 @@
 package prom
 
-import "testing"
+import (
+	"bytes"
+	"context"
+	"fmt"
+	golog "log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/util/httputil"
+	prometheus "github.com/prometheus/client_golang/api"
+)
 
 func TestWarningsFrom(t *testing.T) {
 	var results interface{}
@@ -25,3 +39,73 @@ func TestWarningsFrom(t *testing.T) {
 		t.Errorf("Unexpected second warning: %s", warnings[1])
 	}
 }
+
+// queryLoggingClient is a fake Prometheus client which writes each query to a
+// query log, as RateLimitedPrometheusClient does, and records the request ID
+// header sent with it
+type queryLoggingClient struct {
+	prometheus.Client
+
+	lock      sync.Mutex
+	log       bytes.Buffer
+	headerIDs []string
+}
+
+func (c *queryLoggingClient) Do(ctx context.Context, req *http.Request) (*http.Response, []byte, prometheus.Warnings, error) {
+	c.lock.Lock()
+	LogQueryRequest(golog.New(&c.log, "", 0), req, 0, 0)
+	c.headerIDs = append(c.headerIDs, req.Header.Get(httputil.RequestIDHeader))
+	c.lock.Unlock()
+
+	return c.Client.Do(ctx, req)
+}
+
+func TestRequestIDPropagatesToQueryLog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"status":"success","data":{"resultType":"vector","result":[]}}`)
+	}))
+	defer server.Close()
+
+	promClient, err := prometheus.NewClient(prometheus.Config{Address: server.URL})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %s", err)
+	}
+	client := &queryLoggingClient{Client: promClient}
+
+	handler := httputil.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := NewNamedContext(client, AllocationContextName).WithContext(r.Context())
+
+		for _, resCh := range ctx.QueryAll("up", "kube_pod_info") {
+			resCh.Await()
+		}
+		ctx.QueryRangeSync("up", time.Unix(0, 0), time.Unix(3600, 0), time.Minute)
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/allocation/compute", nil)
+	r.Header.Set(httputil.RequestIDHeader, "req-123")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if id := w.Header().Get(httputil.RequestIDHeader); id != "req-123" {
+		t.Fatalf("expected response request ID req-123; got '%s'", id)
+	}
+
+	client.lock.Lock()
+	defer client.lock.Unlock()
+
+	lines := strings.Split(strings.TrimSpace(client.log.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 logged queries; got %q", lines)
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "[Request: req-123]") {
+			t.Errorf("expected logged query to include the request ID; got %s", line)
+		}
+	}
+	for _, id := range client.headerIDs {
+		if id != "req-123" {
+			t.Errorf("expected query to send request ID header req-123; got '%s'", id)
+		}
+	}
+}
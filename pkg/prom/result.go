@@ -6,7 +6,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/kubecost/cost-model/pkg/log"
 	"github.com/kubecost/cost-model/pkg/util"
 )
 
@@ -176,7 +175,7 @@ func NewQueryResults(query string, queryResult interface{}) *QueryResults {
 				return qrs
 			}
 			if warn != nil {
-				log.DedupedWarningf(5, "%s\nQuery: %s\nLabels: %s", warn.Message(), query, labelsForMetric(metricMap))
+				promLog.RateLimitedWarningf(warn.Message(), "%s\nQuery: %s\nLabels: %s", warn.Message(), query, labelsForMetric(metricMap))
 			}
 
 			vectors = append(vectors, v)
@@ -198,7 +197,7 @@ func NewQueryResults(query string, queryResult interface{}) *QueryResults {
 					if labelString == "" {
 						labelString = labelsForMetric(metricMap)
 					}
-					log.DedupedWarningf(5, "%s\nQuery: %s\nLabels: %s", warn.Message(), query, labelString)
+					promLog.RateLimitedWarningf(warn.Message(), "%s\nQuery: %s\nLabels: %s", warn.Message(), query, labelString)
 				}
 
 				vectors = append(vectors, v)
@@ -264,7 +263,7 @@ func (qr *QueryResult) GetLabels() map[string]string {
 		label := strings.TrimPrefix(k, "label_")
 		value, ok := v.(string)
 		if !ok {
-			log.Warningf("Failed to parse label value for label: '%s'", label)
+			promLog.RateLimitedWarningf("parse-label", "Failed to parse label value for label: '%s'", label)
 			continue
 		}
 
@@ -287,7 +286,7 @@ func (qr *QueryResult) GetAnnotations() map[string]string {
 		annotations := strings.TrimPrefix(k, "annotation_")
 		value, ok := v.(string)
 		if !ok {
-			log.Warningf("Failed to parse label value for label: '%s'", annotations)
+			promLog.RateLimitedWarningf("parse-annotation", "Failed to parse label value for label: '%s'", annotations)
 			continue
 		}
 
@@ -1,13 +1,26 @@
 package prom
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/kubecost/cost-model/pkg/env"
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/util/retry"
 
 	prometheus "github.com/prometheus/client_golang/api"
 )
 
+const (
+	// ValidateRetries is the number of attempts of the validation query
+	ValidateRetries uint = 3
+
+	// ValidateRetryDelay is the delay after the first failed validation query,
+	// which doubles after each attempt
+	ValidateRetryDelay = time.Second
+)
+
 var (
 	prometheusValidateQuery string = "up"
 	thanosValidateQuery     string = fmt.Sprintf("up offset %s", env.GetThanosOffset())
@@ -33,7 +46,21 @@ func Validate(cli prometheus.Client) (*PrometheusMetadata, error) {
 func validate(cli prometheus.Client, q string) (*PrometheusMetadata, error) {
 	ctx := NewContext(cli)
 
-	resUp, _, err := ctx.QuerySync(q)
+	// Retry the query, so that a briefly unavailable Prometheus isn't reported as
+	// not running
+	resUp, err := retry.Do(context.Background(), func() ([]*QueryResult, error) {
+		r, _, e := ctx.QuerySync(q)
+		return r, e
+	}, retry.Options{
+		Attempts: ValidateRetries,
+		Backoff: retry.Backoff{
+			Initial:    ValidateRetryDelay,
+			Multiplier: 2,
+		},
+		OnRetry: func(attempt uint, err error, delay time.Duration) {
+			promLog.WithFields(log.Fields{"attempt": attempt, "error": err}).Debugf("Failed to validate Prometheus (attempt %d of %d): %s; retrying in %s", attempt, ValidateRetries, err, delay)
+		},
+	})
 	if err != nil {
 		return &PrometheusMetadata{
 			Running:            false,
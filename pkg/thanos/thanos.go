@@ -1,7 +1,6 @@
 package thanos
 
 import (
-	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
@@ -42,16 +41,47 @@ func QueryURL() string {
 
 // Offset returns the duration string for the query offset that should be applied to thanos
 func Offset() string {
+	lock.Lock()
+	defer lock.Unlock()
+
 	return offset
 }
 
+// SetOffset sets the duration string for the query offset that should be applied to thanos,
+// e.g. when the setting is changed at runtime.
+func SetOffset(o string) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	offset = o
+	offsetDuration = nil
+	queryOffset = fmt.Sprintf(" offset %s", offset)
+}
+
+// MaxSourceResolution returns the max source resolution applied to thanos queries
+func MaxSourceResolution() string {
+	lock.Lock()
+	defer lock.Unlock()
+
+	return maxSourceRes
+}
+
+// SetMaxSourceResolution sets the max source resolution applied to thanos queries, e.g.
+// when the setting is changed at runtime.
+func SetMaxSourceResolution(res string) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	maxSourceRes = res
+}
+
 // OffsetDuration returns the Offset as a parsed duration
 func OffsetDuration() time.Duration {
 	lock.Lock()
 	defer lock.Unlock()
 
 	if offsetDuration == nil {
-		d, err := time.ParseDuration(offset)
+		d, err := env.ParseDuration(offset, time.Second)
 		if err != nil {
 			d = 0
 		}
@@ -64,11 +94,17 @@ func OffsetDuration() time.Duration {
 
 // QueryOffset returns a string in the format: " offset %s" substituting in the Offset() string.
 func QueryOffset() string {
+	lock.Lock()
+	defer lock.Unlock()
+
 	return queryOffset
 }
 
 func NewThanosClient(address string, timeout, keepAlive time.Duration, queryConcurrency int, queryLogFile string) (prometheus.Client, error) {
-	tlsConfig := &tls.Config{InsecureSkipVerify: env.GetInsecureSkipVerify()}
+	tlsConfig, err := prom.NewClientTLSConfig()
+	if err != nil {
+		return nil, err
+	}
 
 	tc := prometheus.Config{
 		Address: address,
@@ -92,7 +128,7 @@ func NewThanosClient(address string, timeout, keepAlive time.Duration, queryConc
 	// max source resolution decorator
 	maxSourceDecorator := func(path string, queryParams url.Values) url.Values {
 		if strings.Contains(path, "query") {
-			queryParams.Set(MaxSourceResulution, maxSourceRes)
+			queryParams.Set(MaxSourceResulution, MaxSourceResolution())
 		}
 		return queryParams
 	}
@@ -0,0 +1,219 @@
+// Package atomicfile writes files so that a crash never leaves them partially
+// written, and reads them back falling back to the previous version if they
+// are found corrupt anyway; e.g. if they were written by an older release.
+package atomicfile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kubecost/cost-model/pkg/log"
+	"github.com/kubecost/cost-model/pkg/util/json"
+)
+
+// BackupSuffix is appended to the path of a file to name the backup of its
+// previous version
+const BackupSuffix = ".bak"
+
+// tempInfix separates the name of a file from the random suffix of the
+// temporary files written in its place
+const tempInfix = ".tmp-"
+
+// Options configures how a file is written
+type Options struct {
+	// Backup keeps the version of the file being replaced at its path with
+	// BackupSuffix, for readers to fall back to.
+	Backup bool
+
+	// Validate, if set, must accept the version of the file being replaced
+	// for it to be kept as the backup, so that a corrupt file never replaces
+	// a good backup.
+	Validate func(data []byte) error
+
+	// SyncDir fsyncs the file's directory after the file is replaced, so that
+	// the replacement itself survives a crash.
+	SyncDir bool
+}
+
+// DefaultOptions returns the Options used by WriteFile: the previous version
+// is backed up and the directory is synced.
+func DefaultOptions() Options {
+	return Options{
+		Backup:  true,
+		SyncDir: true,
+	}
+}
+
+// WriteFile writes data to the file at path with DefaultOptions. Readers of
+// the path see either the previous contents or data, never a mix of both.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	return WriteFileWithOptions(path, data, perm, DefaultOptions())
+}
+
+// WriteJSON marshals v and writes it to the file at path with DefaultOptions,
+// only backing up the previous version if it is valid JSON.
+func WriteJSON(path string, v interface{}, perm os.FileMode) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	opts := DefaultOptions()
+	opts.Validate = validateJSON
+	return WriteFileWithOptions(path, data, perm, opts)
+}
+
+// WriteFileWithOptions writes data to a temporary file in the same directory
+// as path, fsyncs it, and renames it over path. Temporary files left behind by
+// a crash of a previous write are removed first, so writes of the same path
+// must not be concurrent.
+func WriteFileWithOptions(path string, data []byte, perm os.FileMode, opts Options) (err error) {
+	dir, name := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	removeTempFiles(dir, name)
+
+	f, err := ioutil.TempFile(dir, "."+name+tempInfix+"*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary file for %s: %s", path, err)
+	}
+	tmp := f.Name()
+	defer func() {
+		if err != nil {
+			os.Remove(tmp)
+		}
+	}()
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error writing temporary file for %s: %s", path, err)
+	}
+
+	if opts.Backup {
+		if err = backup(path, perm, opts.Validate); err != nil {
+			return fmt.Errorf("error backing up %s: %s", path, err)
+		}
+	}
+
+	err = rename(tmp, path)
+	if err != nil {
+		return fmt.Errorf("error replacing %s: %s", path, err)
+	}
+
+	if opts.SyncDir {
+		if err = syncDir(dir); err != nil {
+			return fmt.Errorf("error syncing directory of %s: %s", path, err)
+		}
+	}
+
+	return nil
+}
+
+// backup atomically copies the file at path, if it exists and is accepted by
+// validate, to its backup path
+func backup(path string, perm os.FileMode, validate func([]byte) error) error {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if validate != nil {
+		if err := validate(data); err != nil {
+			log.Warningf("Not backing up invalid file %s: %s", path, err)
+			return nil
+		}
+	}
+
+	return WriteFileWithOptions(path+BackupSuffix, data, perm, Options{})
+}
+
+// removeTempFiles removes the temporary files of the file with the given name
+// in dir
+func removeTempFiles(dir, name string) {
+	matches, err := filepath.Glob(filepath.Join(dir, "."+escapeGlob(name)+tempInfix+"*"))
+	if err != nil {
+		return
+	}
+
+	for _, match := range matches {
+		log.Debugf("Removing temporary file %s left by an interrupted write", match)
+		os.Remove(match)
+	}
+}
+
+// escapeGlob escapes the characters of s which filepath.Match treats as
+// patterns
+func escapeGlob(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[\`, r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// ReadFile reads the file at path, checking its contents with validate. If the
+// file cannot be read or is invalid, its backup is read instead, if the backup
+// is valid. A missing file is not replaced by its backup, as it may have been
+// removed deliberately, so os.IsNotExist holds for the returned error.
+func ReadFile(path string, validate func(data []byte) error) ([]byte, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil && validate != nil {
+		err = validate(data)
+	}
+	if err == nil {
+		return data, nil
+	}
+
+	backupData, backupErr := ioutil.ReadFile(path + BackupSuffix)
+	if backupErr == nil && validate != nil {
+		backupErr = validate(backupData)
+	}
+	if backupErr != nil {
+		return nil, err
+	}
+
+	log.Warningf("Reading backup of %s, which could not be read: %s", path, err)
+	return backupData, nil
+}
+
+// ReadJSON unmarshals the file at path into v, falling back to its backup as
+// ReadFile does if the file is not valid JSON.
+func ReadJSON(path string, v interface{}) error {
+	data, err := ReadFile(path, validateJSON)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// validateJSON returns an error if data is not valid JSON
+func validateJSON(data []byte) error {
+	if !json.Valid(data) {
+		return fmt.Errorf("invalid JSON")
+	}
+	return nil
+}
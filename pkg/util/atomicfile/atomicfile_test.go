@@ -0,0 +1,141 @@
+package atomicfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type config struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "atomicfile")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %s", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestWriteReadJSON(t *testing.T) {
+	path := filepath.Join(tempDir(t), "config.json")
+
+	if err := WriteJSON(path, config{"a", 1}, 0644); err != nil {
+		t.Fatalf("unexpected error writing: %s", err)
+	}
+	if _, err := os.Stat(path + BackupSuffix); !os.IsNotExist(err) {
+		t.Fatalf("expected no backup of a new file")
+	}
+
+	if err := WriteJSON(path, config{"b", 2}, 0644); err != nil {
+		t.Fatalf("unexpected error writing: %s", err)
+	}
+
+	var c config
+	if err := ReadJSON(path, &c); err != nil {
+		t.Fatalf("unexpected error reading: %s", err)
+	}
+	if c.Name != "b" || c.Value != 2 {
+		t.Fatalf("expected the latest version; got %+v", c)
+	}
+
+	var backup config
+	if err := ReadJSON(path+BackupSuffix, &backup); err != nil || backup.Name != "a" {
+		t.Fatalf("expected the previous version to be backed up; got %+v, %v", backup, err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Fatalf("expected mode 0644; got %s", info.Mode().Perm())
+	}
+}
+
+func TestReadJSONFallsBackToBackup(t *testing.T) {
+	path := filepath.Join(tempDir(t), "config.json")
+
+	WriteJSON(path, config{"good", 1}, 0644)
+	WriteJSON(path, config{"newer", 2}, 0644)
+
+	// Truncated by a write which wasn't atomic
+	ioutil.WriteFile(path, []byte(`{"name":"tru`), 0644)
+
+	var c config
+	if err := ReadJSON(path, &c); err != nil {
+		t.Fatalf("expected the backup to be read: %s", err)
+	}
+	if c.Name != "good" {
+		t.Fatalf("expected the backup; got %+v", c)
+	}
+
+	// The corrupt file must not replace the good backup
+	if err := WriteJSON(path, config{"fixed", 3}, 0644); err != nil {
+		t.Fatalf("unexpected error writing: %s", err)
+	}
+	var backup config
+	if err := ReadJSON(path+BackupSuffix, &backup); err != nil || backup.Name != "good" {
+		t.Fatalf("expected the good backup to be kept; got %+v, %v", backup, err)
+	}
+}
+
+func TestReadJSONErrors(t *testing.T) {
+	path := filepath.Join(tempDir(t), "config.json")
+
+	var c config
+	if err := ReadJSON(path, &c); !os.IsNotExist(err) {
+		t.Fatalf("expected a missing file to be reported as not existing; got %v", err)
+	}
+
+	// A missing file is not replaced by its backup
+	ioutil.WriteFile(path+BackupSuffix, []byte(`{"name":"backup"}`), 0644)
+	if err := ReadJSON(path, &c); !os.IsNotExist(err) {
+		t.Fatalf("expected a missing file not to fall back to its backup; got %v", err)
+	}
+
+	ioutil.WriteFile(path, []byte(`{`), 0644)
+	ioutil.WriteFile(path+BackupSuffix, []byte(`}`), 0644)
+	if err := ReadJSON(path, &c); err == nil {
+		t.Fatalf("expected an error when the file and its backup are invalid")
+	}
+}
+
+func TestCrashBeforeRename(t *testing.T) {
+	dir := tempDir(t)
+	path := filepath.Join(dir, "config.json")
+
+	WriteJSON(path, config{"original", 1}, 0644)
+
+	// Simulate a crash after the temporary file was partially written, but
+	// before it was renamed over the file
+	stray := filepath.Join(dir, ".config.json"+tempInfix+"123456")
+	if err := ioutil.WriteFile(stray, []byte(`{"name":"upd`), 0600); err != nil {
+		t.Fatalf("failed to write stray temp file: %s", err)
+	}
+
+	var c config
+	if err := ReadJSON(path, &c); err != nil || c.Name != "original" {
+		t.Fatalf("expected the original file to be intact; got %+v, %v", c, err)
+	}
+
+	if err := WriteJSON(path, config{"updated", 2}, 0644); err != nil {
+		t.Fatalf("unexpected error writing: %s", err)
+	}
+	if _, err := os.Stat(stray); !os.IsNotExist(err) {
+		t.Fatalf("expected the stray temp file to be removed by the next write")
+	}
+
+	entries, _ := ioutil.ReadDir(dir)
+	if len(entries) != 2 {
+		names := []string{}
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Fatalf("expected only the file and its backup; got %v", names)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+// +build !windows
+
+package atomicfile
+
+import "os"
+
+// rename renames oldpath to newpath, replacing it atomically
+func rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+// syncDir fsyncs the directory, persisting the renames of its entries
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -0,0 +1,33 @@
+//go:build windows
+// +build windows
+
+package atomicfile
+
+import (
+	"os"
+	"time"
+)
+
+// renameRetries is the number of times a rename is retried when the file being
+// replaced is briefly held open by another process; e.g. a virus scanner.
+const renameRetries = 5
+
+// rename renames oldpath to newpath. On Windows, os.Rename replaces newpath
+// with MoveFileEx, which fails while newpath is open without sharing delete
+// access, so the rename is retried.
+func rename(oldpath, newpath string) error {
+	var err error
+	for i := 0; i < renameRetries; i++ {
+		if err = os.Rename(oldpath, newpath); err == nil || !os.IsPermission(err) {
+			return err
+		}
+		time.Sleep(time.Duration(i+1) * 10 * time.Millisecond)
+	}
+	return err
+}
+
+// syncDir is a no-op, as directories cannot be opened for syncing on Windows,
+// where MoveFileEx is not guaranteed to be durable.
+func syncDir(dir string) error {
+	return nil
+}
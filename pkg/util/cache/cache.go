@@ -0,0 +1,356 @@
+// Package cache provides a concurrency-safe LRU cache whose entries expire
+// after a TTL.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// NoExpiration is the TTL of entries which never expire
+const NoExpiration time.Duration = -1
+
+// EvictionReason is the reason an entry was removed from a cache
+type EvictionReason string
+
+const (
+	// EvictedCapacity entries were the least recently used when the cache was
+	// over its maximum entries or bytes
+	EvictedCapacity EvictionReason = "capacity"
+
+	// EvictedExpired entries were older than their TTL
+	EvictedExpired EvictionReason = "expired"
+
+	// EvictedDeleted entries were deleted by Delete or Purge
+	EvictedDeleted EvictionReason = "deleted"
+)
+
+// Hooks receive the metrics of a cache; e.g. PrometheusHooks. They are called
+// while the cache is locked, so they must be fast and must not use the cache.
+type Hooks interface {
+	// Hit is called when Get finds an entry
+	Hit()
+
+	// Miss is called when Get does not find an entry, or finds it expired
+	Miss()
+
+	// Evict is called when an entry is removed, other than by being replaced
+	Evict(reason EvictionReason)
+
+	// Size is called with the number and total size of the entries whenever
+	// they change
+	Size(entries int, bytes int64)
+}
+
+// Options configures a cache. The zero value is an unbounded cache whose
+// entries never expire.
+type Options[K comparable, V any] struct {
+	// MaxEntries is the maximum number of entries, or unbounded if 0
+	MaxEntries int
+
+	// MaxBytes is the maximum total size of the entries, as given by SizeOf,
+	// or unbounded if 0. Values larger than MaxBytes are not cached.
+	MaxBytes int64
+
+	// SizeOf returns the size of a value, in bytes. If nil, values have no
+	// size, so MaxBytes has no effect.
+	SizeOf func(value V) int64
+
+	// DefaultTTL is the TTL of entries set without one, or NoExpiration
+	DefaultTTL time.Duration
+
+	// CleanupInterval is the interval at which expired entries are evicted
+	// in the background, until Close is called. If 0, they are only evicted
+	// when found expired, or by DeleteExpired. Expired entries are never
+	// returned, regardless.
+	CleanupInterval time.Duration
+
+	// OnEvict, if set, is called with each entry removed other than by being
+	// replaced, after the cache is unlocked.
+	OnEvict func(key K, value V, reason EvictionReason)
+
+	// Hooks, if set, receive the metrics of the cache
+	Hooks Hooks
+}
+
+// entry is an element of the LRU list
+type entry[K comparable, V any] struct {
+	key       K
+	value     V
+	size      int64
+	expiresAt time.Time
+}
+
+// expired returns true if the entry has a TTL which has passed at now
+func (e *entry[K, V]) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// eviction is an entry removed while the cache was locked, whose OnEvict
+// callback is pending
+type eviction[K comparable, V any] struct {
+	entry  *entry[K, V]
+	reason EvictionReason
+}
+
+// Cache is a concurrency-safe LRU cache whose entries expire after a TTL.
+// Create one with New.
+type Cache[K comparable, V any] struct {
+	lock    sync.Mutex
+	opts    Options[K, V]
+	entries map[K]*list.Element
+	lru     *list.List // front is most recently used
+	bytes   int64
+	now     func() time.Time
+	stop    chan struct{}
+	closed  sync.Once
+}
+
+// New creates a new Cache configured by the options
+func New[K comparable, V any](opts Options[K, V]) *Cache[K, V] {
+	if opts.DefaultTTL == 0 {
+		opts.DefaultTTL = NoExpiration
+	}
+
+	c := &Cache[K, V]{
+		opts:    opts,
+		entries: make(map[K]*list.Element),
+		lru:     list.New(),
+		now:     time.Now,
+		stop:    make(chan struct{}),
+	}
+
+	if opts.CleanupInterval > 0 {
+		go c.cleanup(opts.CleanupInterval)
+	}
+
+	return c
+}
+
+// Get returns the value of the key, and true if it is cached and has not
+// expired, marking it most recently used.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	var evicted []eviction[K, V]
+	defer func() { c.notify(evicted) }()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var zero V
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.miss()
+		return zero, false
+	}
+
+	e := elem.Value.(*entry[K, V])
+	if e.expired(c.now()) {
+		evicted = append(evicted, c.remove(elem, EvictedExpired))
+		c.miss()
+		return zero, false
+	}
+
+	c.lru.MoveToFront(elem)
+	if c.opts.Hooks != nil {
+		c.opts.Hooks.Hit()
+	}
+	return e.value, true
+}
+
+// Set caches the value of the key with the default TTL
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.opts.DefaultTTL)
+}
+
+// SetWithTTL caches the value of the key, expiring after the TTL unless it is
+// NoExpiration, and marks it most recently used. Any previous value of the key
+// is replaced. Least recently used entries are evicted while the cache is
+// over its maximum entries or bytes.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	var evicted []eviction[K, V]
+	defer func() { c.notify(evicted) }()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	var size int64
+	if c.opts.SizeOf != nil {
+		size = c.opts.SizeOf(value)
+	}
+
+	// Replacing the value removes the previous one without evicting it
+	if elem, ok := c.entries[key]; ok {
+		c.unlink(elem)
+	}
+
+	if c.opts.MaxBytes > 0 && size > c.opts.MaxBytes {
+		c.resized()
+		return
+	}
+
+	e := &entry[K, V]{key: key, value: value, size: size}
+	if ttl > 0 {
+		e.expiresAt = c.now().Add(ttl)
+	}
+	c.entries[key] = c.lru.PushFront(e)
+	c.bytes += size
+
+	for c.overCapacity() {
+		evicted = append(evicted, c.remove(c.lru.Back(), EvictedCapacity))
+	}
+
+	c.resized()
+}
+
+// Delete removes the key, returning true if it was cached
+func (c *Cache[K, V]) Delete(key K) bool {
+	var evicted []eviction[K, V]
+	defer func() { c.notify(evicted) }()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+
+	evicted = append(evicted, c.remove(elem, EvictedDeleted))
+	return true
+}
+
+// Purge removes all entries
+func (c *Cache[K, V]) Purge() {
+	var evicted []eviction[K, V]
+	defer func() { c.notify(evicted) }()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	for elem := c.lru.Back(); elem != nil; elem = c.lru.Back() {
+		evicted = append(evicted, c.remove(elem, EvictedDeleted))
+	}
+}
+
+// DeleteExpired evicts all expired entries, returning the number evicted
+func (c *Cache[K, V]) DeleteExpired() int {
+	var evicted []eviction[K, V]
+	defer func() { c.notify(evicted) }()
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	for elem := c.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if elem.Value.(*entry[K, V]).expired(now) {
+			evicted = append(evicted, c.remove(elem, EvictedExpired))
+		}
+		elem = prev
+	}
+
+	return len(evicted)
+}
+
+// Len returns the number of entries, including any expired entries not yet
+// evicted
+func (c *Cache[K, V]) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.lru.Len()
+}
+
+// Bytes returns the total size of the entries, including any expired entries
+// not yet evicted
+func (c *Cache[K, V]) Bytes() int64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.bytes
+}
+
+// Close stops the background eviction of expired entries. The cache remains
+// usable.
+func (c *Cache[K, V]) Close() {
+	c.closed.Do(func() {
+		close(c.stop)
+	})
+}
+
+// cleanup evicts expired entries at the interval until the cache is closed
+func (c *Cache[K, V]) cleanup(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.DeleteExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
+
+// overCapacity returns true if the cache has more entries or bytes than its
+// maximums. The caller must hold the lock.
+func (c *Cache[K, V]) overCapacity() bool {
+	if c.lru.Len() == 0 {
+		return false
+	}
+	if c.opts.MaxEntries > 0 && c.lru.Len() > c.opts.MaxEntries {
+		return true
+	}
+	return c.opts.MaxBytes > 0 && c.bytes > c.opts.MaxBytes
+}
+
+// remove removes the element, reporting the eviction to the hooks and
+// returning it to be passed to OnEvict. The caller must hold the lock.
+func (c *Cache[K, V]) remove(elem *list.Element, reason EvictionReason) eviction[K, V] {
+	e := c.unlink(elem)
+
+	if c.opts.Hooks != nil {
+		c.opts.Hooks.Evict(reason)
+	}
+	c.resized()
+
+	return eviction[K, V]{entry: e, reason: reason}
+}
+
+// unlink removes the element from the list and map. The caller must hold the
+// lock.
+func (c *Cache[K, V]) unlink(elem *list.Element) *entry[K, V] {
+	e := c.lru.Remove(elem).(*entry[K, V])
+	delete(c.entries, e.key)
+	c.bytes -= e.size
+	return e
+}
+
+// miss reports a miss to the hooks. The caller must hold the lock.
+func (c *Cache[K, V]) miss() {
+	if c.opts.Hooks != nil {
+		c.opts.Hooks.Miss()
+	}
+}
+
+// resized reports the size of the cache to the hooks. The caller must hold
+// the lock.
+func (c *Cache[K, V]) resized() {
+	if c.opts.Hooks != nil {
+		c.opts.Hooks.Size(c.lru.Len(), c.bytes)
+	}
+}
+
+// notify calls OnEvict with each eviction. The caller must not hold the lock.
+func (c *Cache[K, V]) notify(evicted []eviction[K, V]) {
+	if c.opts.OnEvict == nil {
+		return
+	}
+
+	for _, ev := range evicted {
+		c.opts.OnEvict(ev.entry.key, ev.entry.value, ev.reason)
+	}
+}
@@ -0,0 +1,293 @@
+package cache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a controllable clock for testing expiration
+type fakeClock struct {
+	lock sync.Mutex
+	t    time.Time
+}
+
+func (fc *fakeClock) now() time.Time {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+	return fc.t
+}
+
+func (fc *fakeClock) advance(d time.Duration) {
+	fc.lock.Lock()
+	defer fc.lock.Unlock()
+	fc.t = fc.t.Add(d)
+}
+
+func newTestCache[K comparable, V any](opts Options[K, V]) (*Cache[K, V], *fakeClock) {
+	clock := &fakeClock{t: time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)}
+	c := New(opts)
+	c.now = clock.now
+	return c, clock
+}
+
+// countingHooks counts the calls of each hook
+type countingHooks struct {
+	hits, misses int
+	evictions    map[EvictionReason]int
+	entries      int
+	bytes        int64
+}
+
+func (ch *countingHooks) Hit()  { ch.hits++ }
+func (ch *countingHooks) Miss() { ch.misses++ }
+func (ch *countingHooks) Evict(reason EvictionReason) {
+	ch.evictions[reason]++
+}
+func (ch *countingHooks) Size(entries int, bytes int64) {
+	ch.entries, ch.bytes = entries, bytes
+}
+
+func TestCacheLRUEviction(t *testing.T) {
+	var evicted []string
+	c, _ := newTestCache(Options[string, int]{
+		MaxEntries: 2,
+		OnEvict: func(key string, value int, reason EvictionReason) {
+			evicted = append(evicted, fmt.Sprintf("%s=%d:%s", key, value, reason))
+		},
+	})
+
+	c.Set("a", 1)
+	c.Set("b", 2)
+
+	// a is now more recently used than b
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("expected a=1; got %d, %t", v, ok)
+	}
+
+	c.Set("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Fatalf("expected the least recently used entry to be evicted")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("expected the recently used entry to be kept")
+	}
+	if c.Len() != 2 {
+		t.Fatalf("expected 2 entries; got %d", c.Len())
+	}
+
+	// Replacing a value is not an eviction
+	c.Set("c", 4)
+	if !c.Delete("c") || c.Delete("c") {
+		t.Fatalf("expected Delete to report whether the key was cached")
+	}
+
+	expected := []string{"b=2:capacity", "c=4:deleted"}
+	if fmt.Sprint(evicted) != fmt.Sprint(expected) {
+		t.Fatalf("expected evictions %v; got %v", expected, evicted)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	hooks := &countingHooks{evictions: map[EvictionReason]int{}}
+	c, clock := newTestCache(Options[string, string]{
+		DefaultTTL: time.Minute,
+		Hooks:      hooks,
+	})
+
+	c.Set("default", "x")
+	c.SetWithTTL("short", "y", time.Second)
+	c.SetWithTTL("forever", "z", NoExpiration)
+
+	clock.advance(time.Second)
+
+	// Expired entries are never returned, though they have not been evicted
+	if _, ok := c.Get("short"); ok {
+		t.Fatalf("expected the entry to expire at its TTL")
+	}
+	if _, ok := c.Get("default"); !ok {
+		t.Fatalf("expected the entry with the default TTL not to have expired")
+	}
+
+	clock.advance(time.Hour)
+
+	if _, ok := c.Get("default"); ok {
+		t.Fatalf("expected the entry to expire at the default TTL")
+	}
+	if _, ok := c.Get("forever"); !ok {
+		t.Fatalf("expected the entry without expiration to be kept")
+	}
+
+	if hooks.hits != 2 || hooks.misses != 2 || hooks.evictions[EvictedExpired] != 2 || hooks.entries != 1 {
+		t.Fatalf("unexpected hooks: %+v", hooks)
+	}
+}
+
+func TestCacheDeleteExpired(t *testing.T) {
+	c, clock := newTestCache(Options[int, int]{DefaultTTL: time.Minute})
+
+	for i := 0; i < 10; i++ {
+		if i%2 == 0 {
+			c.Set(i, i)
+		} else {
+			c.SetWithTTL(i, i, time.Hour)
+		}
+	}
+
+	clock.advance(time.Minute)
+	if n := c.DeleteExpired(); n != 5 {
+		t.Fatalf("expected 5 expired entries; got %d", n)
+	}
+	if c.Len() != 5 {
+		t.Fatalf("expected 5 entries; got %d", c.Len())
+	}
+}
+
+func TestCacheBytes(t *testing.T) {
+	hooks := &countingHooks{evictions: map[EvictionReason]int{}}
+	c, _ := newTestCache(Options[string, []byte]{
+		MaxBytes: 10,
+		SizeOf:   func(v []byte) int64 { return int64(len(v)) },
+		Hooks:    hooks,
+	})
+
+	c.Set("a", make([]byte, 4))
+	c.Set("b", make([]byte, 4))
+	if c.Bytes() != 8 {
+		t.Fatalf("expected 8 bytes; got %d", c.Bytes())
+	}
+
+	c.Set("c", make([]byte, 4))
+	if _, ok := c.Get("a"); ok || c.Bytes() != 8 {
+		t.Fatalf("expected the least recently used entry to be evicted to fit; %d bytes", c.Bytes())
+	}
+
+	// Replacing a value accounts for its new size
+	c.Set("b", make([]byte, 1))
+	if c.Bytes() != 5 || hooks.bytes != 5 {
+		t.Fatalf("expected 5 bytes; got %d", c.Bytes())
+	}
+
+	// Values larger than the cache are not cached
+	c.Set("huge", make([]byte, 11))
+	if _, ok := c.Get("huge"); ok || c.Len() != 2 {
+		t.Fatalf("expected a value larger than the cache not to be cached")
+	}
+}
+
+func TestCachePurge(t *testing.T) {
+	evicted := 0
+	c, _ := newTestCache(Options[int, int]{
+		OnEvict: func(key, value int, reason EvictionReason) {
+			if reason == EvictedDeleted {
+				evicted++
+			}
+		},
+	})
+
+	for i := 0; i < 5; i++ {
+		c.Set(i, i)
+	}
+	c.Purge()
+
+	if c.Len() != 0 || c.Bytes() != 0 || evicted != 5 {
+		t.Fatalf("expected all entries to be removed; %d remain, %d evicted", c.Len(), evicted)
+	}
+}
+
+func TestCacheOnEvictMayUseCache(t *testing.T) {
+	var c *Cache[int, int]
+	c = New(Options[int, int]{
+		MaxEntries: 1,
+		OnEvict: func(key, value int, reason EvictionReason) {
+			// Callbacks are called after the cache is unlocked
+			c.Get(key)
+		},
+	})
+
+	c.Set(1, 1)
+	c.Set(2, 2)
+}
+
+func TestCacheCleanupInterval(t *testing.T) {
+	c := New(Options[int, int]{
+		DefaultTTL:      time.Millisecond,
+		CleanupInterval: time.Millisecond,
+	})
+	defer c.Close()
+
+	c.Set(1, 1)
+
+	deadline := time.Now().Add(time.Second)
+	for c.Len() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the expired entry to be evicted in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	c.Close()
+}
+
+func TestCacheConcurrency(t *testing.T) {
+	c := New(Options[int, int]{
+		MaxEntries: 50,
+		MaxBytes:   400,
+		SizeOf:     func(v int) int64 { return 8 },
+		DefaultTTL: time.Millisecond,
+	})
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := (w*1000 + i) % 100
+				switch i % 4 {
+				case 0:
+					c.Set(key, i)
+				case 1:
+					c.SetWithTTL(key, i, NoExpiration)
+				case 2:
+					if v, ok := c.Get(key); ok && v < 0 {
+						t.Errorf("unexpected value %d", v)
+					}
+				case 3:
+					c.Delete(key)
+				}
+			}
+			c.DeleteExpired()
+		}(w)
+	}
+	wg.Wait()
+
+	if c.Len() > 50 || c.Bytes() > 400 || c.Bytes() != int64(c.Len())*8 {
+		t.Fatalf("expected the cache to remain within its bounds; %d entries, %d bytes", c.Len(), c.Bytes())
+	}
+}
+
+func BenchmarkCacheGetWarm(b *testing.B) {
+	const entries = 10000
+
+	c := New(Options[string, int]{
+		MaxEntries: entries,
+		DefaultTTL: time.Hour,
+	})
+
+	keys := make([]string, entries)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key-%d", i)
+		c.Set(keys[i], i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, ok := c.Get(keys[i%entries]); !ok {
+			b.Fatal("expected a hit")
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusHooks are Hooks which export the metrics of a cache to Prometheus,
+// labelled by the name of the cache. Register them as a prometheus.Collector.
+type PrometheusHooks struct {
+	hits      prometheus.Counter
+	misses    prometheus.Counter
+	evictions *prometheus.CounterVec
+	entries   prometheus.Gauge
+	bytes     prometheus.Gauge
+}
+
+// NewPrometheusHooks creates new PrometheusHooks for the cache with the given
+// name
+func NewPrometheusHooks(name string) *PrometheusHooks {
+	labels := prometheus.Labels{"cache": name}
+
+	return &PrometheusHooks{
+		hits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "kubecost_cache_hits_total",
+			Help:        "kubecost_cache_hits_total Number of cache lookups which found an entry",
+			ConstLabels: labels,
+		}),
+		misses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name:        "kubecost_cache_misses_total",
+			Help:        "kubecost_cache_misses_total Number of cache lookups which found no entry, or an expired entry",
+			ConstLabels: labels,
+		}),
+		evictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "kubecost_cache_evictions_total",
+			Help:        "kubecost_cache_evictions_total Number of entries removed from the cache, by reason",
+			ConstLabels: labels,
+		}, []string{"reason"}),
+		entries: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "kubecost_cache_entries",
+			Help:        "kubecost_cache_entries Number of entries in the cache",
+			ConstLabels: labels,
+		}),
+		bytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name:        "kubecost_cache_bytes",
+			Help:        "kubecost_cache_bytes Total size of the entries in the cache",
+			ConstLabels: labels,
+		}),
+	}
+}
+
+// Hit counts a hit
+func (ph *PrometheusHooks) Hit() {
+	ph.hits.Inc()
+}
+
+// Miss counts a miss
+func (ph *PrometheusHooks) Miss() {
+	ph.misses.Inc()
+}
+
+// Evict counts an eviction for the reason
+func (ph *PrometheusHooks) Evict(reason EvictionReason) {
+	ph.evictions.WithLabelValues(string(reason)).Inc()
+}
+
+// Size sets the number and total size of the entries
+func (ph *PrometheusHooks) Size(entries int, bytes int64) {
+	ph.entries.Set(float64(entries))
+	ph.bytes.Set(float64(bytes))
+}
+
+// Describe sends the super-set of all possible descriptors of metrics
+// collected by this Collector.
+func (ph *PrometheusHooks) Describe(ch chan<- *prometheus.Desc) {
+	ph.hits.Describe(ch)
+	ph.misses.Describe(ch)
+	ph.evictions.Describe(ch)
+	ph.entries.Describe(ch)
+	ph.bytes.Describe(ch)
+}
+
+// Collect is called by the Prometheus registry when collecting metrics.
+func (ph *PrometheusHooks) Collect(ch chan<- prometheus.Metric) {
+	ph.hits.Collect(ch)
+	ph.misses.Collect(ch)
+	ph.evictions.Collect(ch)
+	ph.entries.Collect(ch)
+	ph.bytes.Collect(ch)
+}
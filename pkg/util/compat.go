@@ -35,3 +35,26 @@ func GetOperatingSystem(labels map[string]string) (string, bool) {
 		return "", false
 	}
 }
+
+// nodePoolLabels are the labels which name a node's pool, or group, on each
+// managed Kubernetes offering, in order of precedence
+var nodePoolLabels = []string{
+	"cloud.google.com/gke-nodepool",
+	"eks.amazonaws.com/nodegroup",
+	"alpha.eksctl.io/nodegroup-name",
+	"kubernetes.azure.com/agentpool",
+	"agentpool",
+	"karpenter.sh/provisioner-name",
+	"node.kubernetes.io/pool",
+}
+
+// GetNodePool returns the name of the node's pool, normalized across providers,
+// e.g. from cloud.google.com/gke-nodepool on GKE or eks.amazonaws.com/nodegroup on EKS
+func GetNodePool(labels map[string]string) (string, bool) {
+	for _, label := range nodePoolLabels {
+		if pool, ok := labels[label]; ok && pool != "" {
+			return pool, true
+		}
+	}
+	return "", false
+}
@@ -2,11 +2,14 @@ package httputil
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
 
+	"github.com/kubecost/cost-model/pkg/log"
 	"github.com/kubecost/cost-model/pkg/util/mapper"
 )
 
@@ -86,6 +89,58 @@ func SetQuery(r *http.Request, query string) *http.Request {
 	return r.WithContext(ctx)
 }
 
+//--------------------------------------------------------------------------
+//  Request IDs
+//--------------------------------------------------------------------------
+
+// RequestIDHeader is the header which carries the ID of a request, which is
+// honored if set on an incoming request and returned on its response
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength is the maximum length of an incoming request ID which is
+// honored, beyond which an ID is generated instead
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware assigns each request an ID, which is carried by the
+// request's context, so that it is included in the logs of log.Ctx, and set
+// on the response's RequestIDHeader. The ID of the incoming RequestIDHeader is
+// used if it is valid; otherwise, one is generated.
+func RequestIDMiddleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, rq *http.Request) {
+		requestID := rq.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = NewRequestID()
+		}
+
+		rw.Header().Set(RequestIDHeader, requestID)
+		handler.ServeHTTP(rw, rq.WithContext(log.ContextWithRequestID(rq.Context(), requestID)))
+	})
+}
+
+// NewRequestID generates a random request ID of 32 hex characters
+func NewRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		log.Warningf("Failed to generate request ID: %s", err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// validRequestID returns true if the request ID is non-empty, no longer than
+// maxRequestIDLength, and only printable ASCII without spaces, so that it
+// cannot forge log lines or headers
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
 //--------------------------------------------------------------------------
 //  Package Funcs
 //--------------------------------------------------------------------------
@@ -115,3 +170,22 @@ func HeaderString(h http.Header) string {
 
 	return sb.String()
 }
+
+// AcceptsGzip returns true if the request's Accept-Encoding allows a gzip
+// encoded response.
+func AcceptsGzip(r *http.Request) bool {
+	for _, header := range r.Header.Values("Accept-Encoding") {
+		for _, encoding := range strings.Split(header, ",") {
+			// e.g. "gzip;q=0.8"; a zero quality rejects the encoding
+			parts := strings.Split(strings.TrimSpace(encoding), ";")
+			if !strings.EqualFold(strings.TrimSpace(parts[0]), "gzip") {
+				continue
+			}
+			if len(parts) > 1 && strings.TrimSpace(parts[1]) == "q=0" {
+				return false
+			}
+			return true
+		}
+	}
+	return false
+}
@@ -2,7 +2,11 @@ package httputil
 
 import (
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/kubecost/cost-model/pkg/log"
 )
 
 func TestHeaderString(t *testing.T) {
@@ -44,3 +48,59 @@ func TestNilHeader(t *testing.T) {
 
 	t.Logf("Result: %s\n", s)
 }
+
+func TestAcceptsGzip(t *testing.T) {
+	cases := map[string]bool{
+		"":                    false,
+		"gzip":                true,
+		"deflate, GZIP;q=0.8": true,
+		"br, gzip;q=0":        false,
+		"identity":            false,
+	}
+
+	for header, expected := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			r.Header.Set("Accept-Encoding", header)
+		}
+		if AcceptsGzip(r) != expected {
+			t.Errorf("expected AcceptsGzip(%q) to be %t", header, expected)
+		}
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen, _ = log.RequestIDFromContext(r.Context())
+	}))
+
+	cases := map[string]bool{
+		"abc-123":                true,
+		"":                       false,
+		"has space":              false,
+		"line\nbreak":            false,
+		strings.Repeat("a", 129): false,
+	}
+
+	for incoming, honored := range cases {
+		seen = ""
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		if incoming != "" {
+			r.Header.Set(RequestIDHeader, incoming)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		returned := w.Header().Get(RequestIDHeader)
+		if returned == "" || returned != seen {
+			t.Fatalf("expected the response header %q to match the context request ID %q", returned, seen)
+		}
+		if honored && returned != incoming {
+			t.Errorf("expected request ID %q to be honored; got %q", incoming, returned)
+		}
+		if !honored && returned == incoming {
+			t.Errorf("expected request ID %q to be replaced", incoming)
+		}
+	}
+}
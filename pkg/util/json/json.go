@@ -8,6 +8,7 @@ import (
 
 var Marshal = jsoniter.ConfigCompatibleWithStandardLibrary.Marshal
 var Unmarshal = jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal
+var Valid = jsoniter.ConfigCompatibleWithStandardLibrary.Valid
 
 type Marshaler json.Marshaler
 
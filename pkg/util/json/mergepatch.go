@@ -0,0 +1,31 @@
+package json
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) to a decoded JSON document
+// and returns the result. Objects in the patch are merged recursively into the
+// target: null values remove the corresponding member, and all other values
+// replace it. A patch which is not an object replaces the target entirely. The
+// target is not modified.
+func MergePatch(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	result := map[string]interface{}{}
+	if targetObj, ok := target.(map[string]interface{}); ok {
+		for k, v := range targetObj {
+			result[k] = v
+		}
+	}
+
+	for k, v := range patchObj {
+		if v == nil {
+			delete(result, k)
+			continue
+		}
+
+		result[k] = MergePatch(result[k], v)
+	}
+
+	return result
+}
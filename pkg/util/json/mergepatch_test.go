@@ -0,0 +1,49 @@
+package json
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Test cases from RFC 7396, Appendix A
+func TestMergePatch(t *testing.T) {
+	cases := []struct {
+		target   string
+		patch    string
+		expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, c := range cases {
+		var target, patch, expected interface{}
+		if err := Unmarshal([]byte(c.target), &target); err != nil {
+			t.Fatalf("failed to decode target %s: %s", c.target, err)
+		}
+		if err := Unmarshal([]byte(c.patch), &patch); err != nil {
+			t.Fatalf("failed to decode patch %s: %s", c.patch, err)
+		}
+		if err := Unmarshal([]byte(c.expected), &expected); err != nil {
+			t.Fatalf("failed to decode expected %s: %s", c.expected, err)
+		}
+
+		actual := MergePatch(target, patch)
+		if !reflect.DeepEqual(actual, expected) {
+			t.Fatalf("MergePatch(%s, %s): expected %v; got %v", c.target, c.patch, expected, actual)
+		}
+	}
+}
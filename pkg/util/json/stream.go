@@ -0,0 +1,230 @@
+package json
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// streamBufferSize is the size of the buffer of a ResponseStream. Output is
+// only sent once the buffer fills, so an error before then can still be
+// reported with an error status.
+const streamBufferSize = 32 * 1024
+
+// ArrayEncoder encodes a JSON array to a writer one item at a time, so that
+// the array is never held in memory as a whole.
+type ArrayEncoder struct {
+	w      io.Writer
+	count  int
+	closed bool
+}
+
+// NewArrayEncoder creates an ArrayEncoder writing to w. Nothing is written
+// until the first item is encoded, or the encoder is closed.
+func NewArrayEncoder(w io.Writer) *ArrayEncoder {
+	return &ArrayEncoder{w: w}
+}
+
+// Encode appends the JSON encoding of the item to the array
+func (ae *ArrayEncoder) Encode(item interface{}) error {
+	if ae.closed {
+		return fmt.Errorf("cannot encode to a closed array")
+	}
+
+	data, err := Marshal(item)
+	if err != nil {
+		return err
+	}
+
+	delim := ","
+	if ae.count == 0 {
+		delim = "["
+	}
+	if _, err := io.WriteString(ae.w, delim); err != nil {
+		return err
+	}
+	if _, err := ae.w.Write(data); err != nil {
+		return err
+	}
+
+	ae.count++
+	return nil
+}
+
+// Count returns the number of items encoded
+func (ae *ArrayEncoder) Count() int {
+	return ae.count
+}
+
+// Close ends the array, writing an empty array if no items were encoded. It
+// is safe to call more than once.
+func (ae *ArrayEncoder) Close() error {
+	if ae.closed {
+		return nil
+	}
+	ae.closed = true
+
+	end := "]"
+	if ae.count == 0 {
+		end = "[]"
+	}
+	_, err := io.WriteString(ae.w, end)
+	return err
+}
+
+// StreamArray writes a JSON array of the items passed to encode by each, one at
+// a time. The first error returned by encode or each is returned, in which case
+// the array is left open, so that the caller can decide how to end the output.
+func StreamArray(w io.Writer, each func(encode func(item interface{}) error) error) error {
+	ae := NewArrayEncoder(w)
+
+	err := each(ae.Encode)
+	if err != nil {
+		return err
+	}
+
+	return ae.Close()
+}
+
+// StreamArrayFromChannel writes a JSON array of the items received from the
+// channel, until it is closed. If an item fails to encode, the channel is
+// drained, so that its sender isn't blocked, and the error is returned.
+func StreamArrayFromChannel(w io.Writer, items <-chan interface{}) error {
+	return StreamArray(w, func(encode func(item interface{}) error) error {
+		for item := range items {
+			if err := encode(item); err != nil {
+				for range items {
+				}
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// StreamError is the object written in place of, or at the end of, streamed
+// output when an error occurs while streaming.
+type StreamError struct {
+	Error string `json:"error"`
+}
+
+// StreamOptions configures a ResponseStream.
+type StreamOptions struct {
+	// Gzip compresses the response, and sets its Content-Encoding. It should
+	// only be set if the client accepts gzip encoding.
+	Gzip bool
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
+
+// ResponseStream streams a response, buffered and optionally gzipped. Until
+// its buffer first fills, nothing is sent, not even the headers, so that an
+// error can still be reported with an error status by Abort.
+type ResponseStream struct {
+	rw   http.ResponseWriter
+	sent *countingWriter
+	gz   *gzip.Writer
+	buf  *bufio.Writer
+}
+
+// NewResponseStream creates a ResponseStream writing to the http.ResponseWriter.
+// The Content-Type, and any other headers, should be set before the first write.
+func NewResponseStream(w http.ResponseWriter, opts StreamOptions) *ResponseStream {
+	rs := &ResponseStream{
+		rw:   w,
+		sent: &countingWriter{w: w},
+	}
+
+	var out io.Writer = rs.sent
+	if opts.Gzip {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Add("Vary", "Accept-Encoding")
+		rs.gz = gzip.NewWriter(out)
+		out = rs.gz
+	}
+	rs.buf = bufio.NewWriterSize(out, streamBufferSize)
+
+	return rs
+}
+
+// Write buffers the bytes, sending the buffer once it fills
+func (rs *ResponseStream) Write(p []byte) (int, error) {
+	return rs.buf.Write(p)
+}
+
+// Sent returns true if any output has been sent, after which the status of the
+// response can no longer be changed.
+func (rs *ResponseStream) Sent() bool {
+	return rs.sent.n > 0
+}
+
+// Close sends any buffered output and ends the gzip stream, if any
+func (rs *ResponseStream) Close() error {
+	if err := rs.buf.Flush(); err != nil {
+		return err
+	}
+	if rs.gz != nil {
+		return rs.gz.Close()
+	}
+	return nil
+}
+
+// Abort ends the stream after an error. If no output has been sent, it is
+// discarded and a StreamError is written instead, with status 500, and true is
+// returned. Otherwise, false is returned, and the caller should truncate the
+// output, e.g. with a StreamError trailer, and Close the stream.
+func (rs *ResponseStream) Abort(err error) bool {
+	if rs.Sent() {
+		return false
+	}
+
+	// Discard the buffered output, and the gzip stream, which hasn't started
+	rs.buf.Reset(rs.sent)
+	rs.rw.Header().Del("Content-Encoding")
+
+	data, _ := Marshal(&StreamError{Error: err.Error()})
+	rs.rw.Header().Set("Content-Type", "application/json")
+	rs.rw.WriteHeader(http.StatusInternalServerError)
+	rs.rw.Write(data)
+
+	return true
+}
+
+// WriteArrayResponse streams the items passed to encode by each as a JSON array
+// response. If each returns an error before any output is sent, the response is
+// a StreamError with status 500. If output has been sent, the array is
+// truncated, ending with a StreamError, and the error is returned, so that the
+// caller can log it.
+func WriteArrayResponse(w http.ResponseWriter, opts StreamOptions, each func(encode func(item interface{}) error) error) error {
+	w.Header().Set("Content-Type", "application/json")
+
+	rs := NewResponseStream(w, opts)
+	ae := NewArrayEncoder(rs)
+
+	err := each(ae.Encode)
+	if err != nil {
+		if !rs.Abort(err) {
+			ae.Encode(&StreamError{Error: err.Error()})
+			ae.Close()
+			rs.Close()
+		}
+		return err
+	}
+
+	if err := ae.Close(); err != nil {
+		return err
+	}
+	return rs.Close()
+}
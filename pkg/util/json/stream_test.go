@@ -0,0 +1,218 @@
+package json
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+type streamItem struct {
+	Name string  `json:"name"`
+	Cost float64 `json:"cost"`
+}
+
+func TestStreamArray(t *testing.T) {
+	cases := map[string]struct {
+		items    []interface{}
+		expected string
+	}{
+		"empty":    {nil, `[]`},
+		"one":      {[]interface{}{streamItem{"a", 1}}, `[{"name":"a","cost":1}]`},
+		"multiple": {[]interface{}{1, "two", nil}, `[1,"two",null]`},
+	}
+
+	for name, c := range cases {
+		var buf bytes.Buffer
+		err := StreamArray(&buf, func(encode func(item interface{}) error) error {
+			for _, item := range c.items {
+				if err := encode(item); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %s", name, err)
+		}
+		if buf.String() != c.expected {
+			t.Errorf("%s: expected %s; got %s", name, c.expected, buf.String())
+		}
+	}
+}
+
+func TestStreamArrayFromChannel(t *testing.T) {
+	items := make(chan interface{})
+	go func() {
+		defer close(items)
+		for i := 0; i < 3; i++ {
+			items <- i
+		}
+	}()
+
+	var buf bytes.Buffer
+	if err := StreamArrayFromChannel(&buf, items); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if buf.String() != `[0,1,2]` {
+		t.Fatalf("expected [0,1,2]; got %s", buf.String())
+	}
+}
+
+func TestWriteArrayResponse(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := WriteArrayResponse(rec, StreamOptions{}, func(encode func(item interface{}) error) error {
+		return encode(streamItem{"a", 1})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if rec.Code != http.StatusOK || rec.Body.String() != `[{"name":"a","cost":1}]` {
+		t.Fatalf("expected a 200 array response; got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestWriteArrayResponseGzip(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := WriteArrayResponse(rec, StreamOptions{Gzip: true}, func(encode func(item interface{}) error) error {
+		return encode("a")
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if rec.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip Content-Encoding; got %q", rec.Header().Get("Content-Encoding"))
+	}
+
+	gr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("failed to read gzip response: %s", err)
+	}
+	body, _ := ioutil.ReadAll(gr)
+	if string(body) != `["a"]` {
+		t.Fatalf("expected [\"a\"]; got %s", body)
+	}
+}
+
+func TestWriteArrayResponseErrorBeforeSent(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := WriteArrayResponse(rec, StreamOptions{Gzip: true}, func(encode func(item interface{}) error) error {
+		encode("buffered")
+		return fmt.Errorf("query failed")
+	})
+	if err == nil {
+		t.Fatalf("expected the error to be returned")
+	}
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected status 500; got %d", rec.Code)
+	}
+	if rec.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("expected the error not to be gzipped")
+	}
+	if rec.Body.String() != `{"error":"query failed"}` {
+		t.Fatalf("expected only the error; got %s", rec.Body.String())
+	}
+}
+
+func TestWriteArrayResponseErrorAfterSent(t *testing.T) {
+	rec := httptest.NewRecorder()
+	large := strings.Repeat("x", streamBufferSize)
+
+	err := WriteArrayResponse(rec, StreamOptions{}, func(encode func(item interface{}) error) error {
+		encode(large)
+		encode(large)
+		return fmt.Errorf("query failed")
+	})
+	if err == nil {
+		t.Fatalf("expected the error to be returned")
+	}
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected the status already sent; got %d", rec.Code)
+	}
+
+	var items []interface{}
+	if err := Unmarshal(rec.Body.Bytes(), &items); err != nil {
+		t.Fatalf("expected the truncated array to be valid JSON: %s", err)
+	}
+	if len(items) != 3 || fmt.Sprint(items[2]) != "map[error:query failed]" {
+		t.Fatalf("expected the array to end with the error; got %v", items[len(items)-1])
+	}
+}
+
+// benchmarkItems is the number of items of the benchmark payloads
+const benchmarkItems = 100000
+
+// peakHeap samples the heap in use, keeping the maximum
+type peakHeap struct {
+	stats runtime.MemStats
+	peak  uint64
+}
+
+func (ph *peakHeap) sample() {
+	runtime.ReadMemStats(&ph.stats)
+	if ph.stats.HeapAlloc > ph.peak {
+		ph.peak = ph.stats.HeapAlloc
+	}
+}
+
+// BenchmarkMarshalArray marshals the payload in one call, as a baseline for
+// BenchmarkStreamArray. Both report the peak heap in use.
+func BenchmarkMarshalArray(b *testing.B) {
+	b.ReportAllocs()
+	ph := &peakHeap{}
+
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+
+		items := make([]streamItem, benchmarkItems)
+		for j := range items {
+			items[j] = streamItem{Name: fmt.Sprintf("item-%d", j), Cost: float64(j)}
+		}
+
+		data, err := Marshal(items)
+		if err != nil {
+			b.Fatal(err)
+		}
+		ph.sample()
+
+		io.Discard.Write(data)
+	}
+
+	b.ReportMetric(float64(ph.peak), "peak-heap-B")
+}
+
+// BenchmarkStreamArray streams the payload of BenchmarkMarshalArray, creating
+// each item as it is encoded.
+func BenchmarkStreamArray(b *testing.B) {
+	b.ReportAllocs()
+	ph := &peakHeap{}
+
+	for i := 0; i < b.N; i++ {
+		runtime.GC()
+
+		err := StreamArray(io.Discard, func(encode func(item interface{}) error) error {
+			for j := 0; j < benchmarkItems; j++ {
+				if err := encode(streamItem{Name: fmt.Sprintf("item-%d", j), Cost: float64(j)}); err != nil {
+					return err
+				}
+				if j%10000 == 0 {
+					ph.sample()
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.ReportMetric(float64(ph.peak), "peak-heap-B")
+}
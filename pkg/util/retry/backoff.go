@@ -0,0 +1,170 @@
+package retry
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Clock tells the time and waits, so that retries can be tested without real
+// sleeps.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+
+	// After returns a channel which receives the time once the duration has
+	// elapsed
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the Clock of the system time
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// jitterRand returns a random number in [0, 1) to jitter delays
+var jitterRand = rand.Float64 // #nosec No need for a cryptographic strength random here
+
+// Backoff configures the delays between attempts, which start at Initial, and
+// are multiplied by Multiplier after each attempt, up to Max.
+type Backoff struct {
+	// Initial is the delay after the first attempt
+	Initial time.Duration
+
+	// Max caps the delay, if positive
+	Max time.Duration
+
+	// Multiplier scales the delay after each attempt. Values less than 1 are
+	// treated as 1, i.e. a constant delay.
+	Multiplier float64
+
+	// Jitter randomizes each delay by up to the fraction, in either direction,
+	// e.g. 0.2 for delays within 20% of the backoff, so that callers retrying
+	// at the same time spread out. It is clamped to [0, 1].
+	Jitter float64
+}
+
+// Delay returns the delay after the given attempt, counted from 0, without
+// jitter.
+func (b Backoff) Delay(attempt uint) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(b.Initial) * math.Pow(multiplier, float64(attempt))
+	if b.Max > 0 && delay > float64(b.Max) {
+		return b.Max
+	}
+	if delay > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// jittered returns the delay after the given attempt, randomized by Jitter
+func (b Backoff) jittered(attempt uint) time.Duration {
+	delay := b.Delay(attempt)
+
+	jitter := math.Max(0, math.Min(1, b.Jitter))
+	if jitter == 0 || delay <= 0 {
+		return delay
+	}
+
+	// Uniform in [delay*(1-jitter), delay*(1+jitter))
+	return time.Duration(float64(delay) * (1 - jitter + 2*jitter*jitterRand()))
+}
+
+// Options configures RetryWithOptions.
+type Options struct {
+	// Attempts is the maximum number of attempts. Zero is unlimited, in which
+	// case Deadline or the context should bound the retries.
+	Attempts uint
+
+	// Backoff configures the delays between attempts
+	Backoff Backoff
+
+	// Deadline bounds the total time spent retrying, if positive. No attempt
+	// is made after the deadline, and no delay which would end after it is
+	// started.
+	Deadline time.Duration
+
+	// RetryIf returns true if the error of an attempt should be retried. If
+	// nil, every error is retried.
+	RetryIf func(err error) bool
+
+	// OnRetry, if set, is called after each failed attempt which will be
+	// retried, with the attempt, counted from 1, its error, and the delay
+	// before the next attempt, e.g. to log it.
+	OnRetry func(attempt uint, err error, delay time.Duration)
+
+	// Clock is used to wait between attempts. It defaults to the system clock.
+	Clock Clock
+}
+
+// RetryWithOptions runs f until it returns without an error, an error which
+// RetryIf rejects, or the attempts or deadline are exhausted, waiting between
+// attempts according to the Backoff. The result and error of the last attempt
+// are returned. If the context is done before an attempt, or while waiting,
+// RetryCancellationErr is returned.
+func RetryWithOptions(ctx context.Context, f func() (interface{}, error), opts Options) (interface{}, error) {
+	result, _, err := run(ctx, f, opts)
+	return result, err
+}
+
+// run implements RetryWithOptions and Do, returning the result and error of the
+// last attempt along with the number of attempts made.
+func run[T any](ctx context.Context, f func() (T, error), opts Options) (T, uint, error) {
+	clock := opts.Clock
+	if clock == nil {
+		clock = realClock{}
+	}
+
+	var deadline time.Time
+	if opts.Deadline > 0 {
+		deadline = clock.Now().Add(opts.Deadline)
+	}
+
+	var zero, result T
+	var err error
+	var attempts uint
+
+	for attempts = 0; opts.Attempts == 0 || attempts < opts.Attempts; {
+		if ctx.Err() != nil {
+			return zero, attempts, RetryCancellationErr
+		}
+
+		result, err = f()
+		attempts++
+		if err == nil {
+			return result, attempts, nil
+		}
+		if opts.RetryIf != nil && !opts.RetryIf(err) {
+			return result, attempts, err
+		}
+
+		// No wait after the last attempt
+		if opts.Attempts != 0 && attempts >= opts.Attempts {
+			break
+		}
+
+		delay := opts.Backoff.jittered(attempts - 1)
+		if !deadline.IsZero() && clock.Now().Add(delay).After(deadline) {
+			break
+		}
+
+		if opts.OnRetry != nil {
+			opts.OnRetry(attempts, err, delay)
+		}
+
+		select {
+		case <-ctx.Done():
+			return zero, attempts, RetryCancellationErr
+		case <-clock.After(delay):
+		}
+	}
+
+	return result, attempts, err
+}
@@ -0,0 +1,185 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock whose time advances only when it is waited on, and
+// which records each wait
+type fakeClock struct {
+	now    time.Time
+	delays []time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (fc *fakeClock) Now() time.Time {
+	return fc.now
+}
+
+func (fc *fakeClock) After(d time.Duration) <-chan time.Time {
+	fc.delays = append(fc.delays, d)
+	fc.now = fc.now.Add(d)
+
+	ch := make(chan time.Time, 1)
+	ch <- fc.now
+	return ch
+}
+
+// failing returns a func which fails until the given attempt, counted from 1,
+// and counts its calls
+func failing(succeedOn int, calls *int) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		*calls++
+		if *calls == succeedOn {
+			return *calls, nil
+		}
+		return nil, fmt.Errorf("failed: %d", *calls)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 10 * time.Second, Multiplier: 2}
+
+	expected := []time.Duration{1, 2, 4, 8, 10, 10}
+	for attempt, e := range expected {
+		if d := b.Delay(uint(attempt)); d != e*time.Second {
+			t.Errorf("attempt %d: expected delay %s; got %s", attempt, e*time.Second, d)
+		}
+	}
+
+	constant := Backoff{Initial: time.Second}
+	if d := constant.Delay(5); d != time.Second {
+		t.Errorf("expected a constant delay without a multiplier; got %s", d)
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	b := Backoff{Initial: 10 * time.Second, Multiplier: 1, Jitter: 0.2}
+
+	for i := 0; i < 100; i++ {
+		if d := b.jittered(0); d < 8*time.Second || d >= 12*time.Second {
+			t.Fatalf("expected delay within 20%% of 10s; got %s", d)
+		}
+	}
+}
+
+func TestRetryWithOptionsDelays(t *testing.T) {
+	clock := newFakeClock()
+	calls := 0
+
+	var retried []uint
+	result, err := RetryWithOptions(context.Background(), failing(4, &calls), Options{
+		Attempts: 6,
+		Backoff:  Backoff{Initial: time.Second, Max: 3 * time.Second, Multiplier: 2},
+		OnRetry: func(attempt uint, err error, delay time.Duration) {
+			retried = append(retried, attempt)
+		},
+		Clock: clock,
+	})
+	if err != nil || result != 4 {
+		t.Fatalf("expected success on the 4th attempt; got %v, %v", result, err)
+	}
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	if fmt.Sprint(clock.delays) != fmt.Sprint(expected) {
+		t.Fatalf("expected delays %v; got %v", expected, clock.delays)
+	}
+	if fmt.Sprint(retried) != fmt.Sprint([]uint{1, 2, 3}) {
+		t.Fatalf("expected OnRetry after attempts 1-3; got %v", retried)
+	}
+}
+
+func TestRetryWithOptionsExhausted(t *testing.T) {
+	clock := newFakeClock()
+	calls := 0
+
+	_, err := RetryWithOptions(context.Background(), failing(-1, &calls), Options{
+		Attempts: 3,
+		Backoff:  Backoff{Initial: time.Second},
+		Clock:    clock,
+	})
+	if err == nil || err.Error() != "failed: 3" {
+		t.Fatalf("expected the error of the last attempt; got %v", err)
+	}
+
+	// No wait after the last attempt
+	if calls != 3 || len(clock.delays) != 2 {
+		t.Fatalf("expected 3 attempts and 2 delays; got %d and %v", calls, clock.delays)
+	}
+}
+
+func TestRetryWithOptionsDeadline(t *testing.T) {
+	clock := newFakeClock()
+	calls := 0
+
+	_, err := RetryWithOptions(context.Background(), failing(-1, &calls), Options{
+		Backoff:  Backoff{Initial: time.Second, Multiplier: 2},
+		Deadline: 10 * time.Second,
+		Clock:    clock,
+	})
+	if err == nil {
+		t.Fatalf("expected an error once the deadline is reached")
+	}
+
+	// 1s + 2s + 4s = 7s; waiting another 8s would pass the deadline
+	if calls != 4 {
+		t.Fatalf("expected 4 attempts before the deadline; got %d", calls)
+	}
+	if elapsed := clock.delays; fmt.Sprint(elapsed) != fmt.Sprint([]time.Duration{time.Second, 2 * time.Second, 4 * time.Second}) {
+		t.Fatalf("unexpected delays: %v", elapsed)
+	}
+}
+
+func TestRetryWithOptionsRetryIf(t *testing.T) {
+	clock := newFakeClock()
+	permanent := errors.New("permanent")
+
+	calls := 0
+	_, err := RetryWithOptions(context.Background(), func() (interface{}, error) {
+		calls++
+		if calls == 2 {
+			return nil, permanent
+		}
+		return nil, errors.New("transient")
+	}, Options{
+		Attempts: 5,
+		Backoff:  Backoff{Initial: time.Second},
+		RetryIf:  func(err error) bool { return err != permanent },
+		Clock:    clock,
+	})
+
+	if err != permanent {
+		t.Fatalf("expected the permanent error; got %v", err)
+	}
+	if calls != 2 || len(clock.delays) != 1 {
+		t.Fatalf("expected retries to stop at the permanent error; got %d attempts and %v", calls, clock.delays)
+	}
+}
+
+func TestRetryWithOptionsCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+
+	_, err := RetryWithOptions(ctx, failing(-1, &calls), Options{
+		Attempts: 5,
+		Backoff:  Backoff{Initial: time.Second},
+		OnRetry: func(attempt uint, err error, delay time.Duration) {
+			cancel()
+		},
+		Clock: newFakeClock(),
+	})
+
+	if !IsRetryCancelledError(err) {
+		t.Fatalf("expected a cancellation error; got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected no attempts after cancellation; got %d", calls)
+	}
+}
@@ -0,0 +1,43 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+)
+
+// RetryError is the error returned by Do once retries are exhausted, or an
+// error is not retried. It records the number of attempts made and wraps the
+// error of the last attempt, so that errors.Is and errors.As see through it.
+type RetryError struct {
+	Attempts uint
+	Err      error
+}
+
+// Error returns the error of the last attempt, prefixed by the attempt count
+func (re *RetryError) Error() string {
+	return fmt.Sprintf("failed after %d attempt(s): %s", re.Attempts, re.Err)
+}
+
+// Unwrap returns the error of the last attempt
+func (re *RetryError) Unwrap() error {
+	return re.Err
+}
+
+// Do runs f as RetryWithOptions does, but returns the result of f as its
+// concrete type, rather than an interface{} to be asserted. On failure, the
+// zero value of T is returned with a *RetryError wrapping the error of the last
+// attempt. If the context is done before an attempt, or while waiting,
+// RetryCancellationErr is returned unwrapped.
+func Do[T any](ctx context.Context, f func() (T, error), opts Options) (T, error) {
+	result, attempts, err := run(ctx, f, opts)
+	if err == nil {
+		return result, nil
+	}
+
+	var zero T
+	if err == RetryCancellationErr {
+		return zero, err
+	}
+
+	return zero, &RetryError{Attempts: attempts, Err: err}
+}
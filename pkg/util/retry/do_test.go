@@ -0,0 +1,98 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+func TestDoSucceedsAfterAttempts(t *testing.T) {
+	clock := newFakeClock()
+
+	calls := 0
+	result, err := Do(context.Background(), func() ([]string, error) {
+		calls++
+		if calls < 3 {
+			return nil, errUnavailable
+		}
+		return []string{"a", "b"}, nil
+	}, Options{
+		Attempts: 5,
+		Backoff:  Backoff{Initial: time.Second},
+		Clock:    clock,
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if calls != 3 || fmt.Sprint(result) != "[a b]" {
+		t.Fatalf("expected [a b] on the 3rd attempt; got %v on attempt %d", result, calls)
+	}
+	if len(clock.delays) != 2 {
+		t.Fatalf("expected 2 delays; got %v", clock.delays)
+	}
+}
+
+func TestDoExhausted(t *testing.T) {
+	calls := 0
+	result, err := Do(context.Background(), func() (int, error) {
+		calls++
+		return calls, fmt.Errorf("attempt %d: %w", calls, errUnavailable)
+	}, Options{
+		Attempts: 3,
+		Backoff:  Backoff{Initial: time.Second},
+		Clock:    newFakeClock(),
+	})
+
+	if result != 0 {
+		t.Fatalf("expected the zero value on failure; got %d", result)
+	}
+
+	var re *RetryError
+	if !errors.As(err, &re) {
+		t.Fatalf("expected a *RetryError; got %v", err)
+	}
+	if re.Attempts != 3 || re.Err.Error() != "attempt 3: unavailable" {
+		t.Fatalf("expected the error of the 3rd attempt; got %d attempts and %s", re.Attempts, re.Err)
+	}
+	if !errors.Is(err, errUnavailable) {
+		t.Fatalf("expected the underlying error to be preserved; got %s", err)
+	}
+	if err.Error() != "failed after 3 attempt(s): attempt 3: unavailable" {
+		t.Fatalf("unexpected error message: %s", err)
+	}
+}
+
+func TestDoNotRetried(t *testing.T) {
+	calls := 0
+	_, err := Do(context.Background(), func() (*Obj, error) {
+		calls++
+		return &Obj{Name: "partial"}, errUnavailable
+	}, Options{
+		Attempts: 3,
+		RetryIf:  func(err error) bool { return false },
+		Clock:    newFakeClock(),
+	})
+
+	var re *RetryError
+	if !errors.As(err, &re) || re.Attempts != 1 || calls != 1 {
+		t.Fatalf("expected a single attempt; got %d and %v", calls, err)
+	}
+}
+
+func TestDoCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result, err := Do(ctx, func() (string, error) {
+		return "unexpected", nil
+	}, Options{Attempts: 3, Clock: newFakeClock()})
+
+	if !IsRetryCancelledError(err) || result != "" {
+		t.Fatalf("expected a cancellation error and the zero value; got %q, %v", result, err)
+	}
+}
@@ -3,7 +3,6 @@ package retry
 import (
 	"context"
 	"fmt"
-	"math/rand"
 	"time"
 )
 
@@ -16,29 +15,19 @@ func IsRetryCancelledError(err error) bool {
 }
 
 // Retry will run the f func until we receive a non error result up to the provided attempts or a cancellation.
+// The delay grows by a quarter, on average, after each attempt. It is a wrapper of RetryWithOptions, which
+// supports other backoffs, deadlines, and retrying only some errors.
 func Retry(ctx context.Context, f func() (interface{}, error), attempts uint, delay time.Duration) (interface{}, error) {
-	var result interface{}
-	var err error
-
-	d := delay
-	for r := attempts; r > 0; r-- {
-		select {
-		case <-ctx.Done():
-			return nil, RetryCancellationErr
-		default:
-		}
-
-		result, err = f()
-
-		if err == nil {
-			break
-		}
-
-		time.Sleep(d)
-
-		jitter := time.Duration(rand.Int63n(int64(d))) // #nosec No need for a cryptographic strength random here
-		d = d + jitter/2
+	if attempts == 0 {
+		return nil, nil
 	}
 
-	return result, err
+	return RetryWithOptions(ctx, f, Options{
+		Attempts: attempts,
+		Backoff: Backoff{
+			Initial:    delay,
+			Multiplier: 1.25,
+			Jitter:     0.2,
+		},
+	})
 }
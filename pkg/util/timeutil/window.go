@@ -0,0 +1,257 @@
+package timeutil
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	durationWindowRegex       = regexp.MustCompile(`^(\d+)(m|h|d)$`)
+	durationOffsetWindowRegex = regexp.MustCompile(`^(\d+)(m|h|d) offset (\d+)(m|h|d)$`)
+	timestampWindowRegex      = regexp.MustCompile(`^(\d+)[,|-](\d+)$`)
+	rfc3339WindowRegex        = regexp.MustCompile(`(\d\d\d\d-\d\d-\d\dT\d\d:\d\d:\d\dZ),(\d\d\d\d-\d\d-\d\dT\d\d:\d\d:\d\dZ)`)
+)
+
+// Window is the half-open interval of time [Start, End): it contains Start, but
+// not End, so that consecutive windows, e.g. the days of a month, share their
+// boundaries without overlapping.
+type Window struct {
+	Start time.Time
+	End   time.Time
+}
+
+// NewWindow creates a Window from start, inclusive, to end, exclusive
+func NewWindow(start, end time.Time) Window {
+	return Window{Start: start, End: end}
+}
+
+// ParseWindow parses the window string relative to now, whose location is the
+// timezone in which named windows begin. It accepts:
+// - named windows: "today", "yesterday", "week", "lastweek", "month", "lastmonth"
+// - durations ending now: "45m", "24h", "7d", etc.
+// - durations with an offset from now: "24h offset 14h", etc.
+// - unix timestamp ranges: "1586822400,1586908800", etc.
+// - RFC3339 ranges: "2020-04-01T00:00:00Z,2020-04-03T00:00:00Z", etc.
+//
+// Named windows are aligned to midnight in the location, so days may be 23 or
+// 25 hours long across daylight saving time transitions. Weeks begin on Sunday.
+// Durations are absolute, e.g. "2d" is always 48 hours.
+func ParseWindow(window string, now time.Time) (Window, error) {
+	loc := now.Location()
+
+	switch window {
+	case "today":
+		start := StartOfDay(now, loc)
+		return NewWindow(start, start.AddDate(0, 0, 1)), nil
+	case "yesterday":
+		end := StartOfDay(now, loc)
+		return NewWindow(end.AddDate(0, 0, -1), end), nil
+	case "week":
+		return NewWindow(StartOfWeek(now, loc), now), nil
+	case "lastweek":
+		end := StartOfWeek(now, loc)
+		return NewWindow(end.AddDate(0, 0, -7), end), nil
+	case "month":
+		return NewWindow(StartOfMonth(now, loc), now), nil
+	case "lastmonth":
+		end := StartOfMonth(now, loc)
+		return NewWindow(end.AddDate(0, -1, 0), end), nil
+	}
+
+	// e.g. "45m", "24h", "7d"
+	if match := durationWindowRegex.FindStringSubmatch(window); match != nil {
+		dur := windowDuration(match[1], match[2])
+		return NewWindow(now.Add(-dur), now), nil
+	}
+
+	// e.g. "24h offset 14h"
+	if match := durationOffsetWindowRegex.FindStringSubmatch(window); match != nil {
+		end := now.Add(-windowDuration(match[3], match[4]))
+		return NewWindow(end.Add(-windowDuration(match[1], match[2])), end), nil
+	}
+
+	// e.g. "1586822400,1586908800" or "1586822400-1586908800"
+	if match := timestampWindowRegex.FindStringSubmatch(window); match != nil {
+		s, _ := strconv.ParseInt(match[1], 10, 64)
+		e, _ := strconv.ParseInt(match[2], 10, 64)
+		return NewWindow(time.Unix(s, 0).In(loc), time.Unix(e, 0).In(loc)), nil
+	}
+
+	// e.g. "2020-04-01T00:00:00Z,2020-04-03T00:00:00Z"
+	if match := rfc3339WindowRegex.FindStringSubmatch(window); match != nil {
+		start, _ := time.Parse(time.RFC3339, match[1])
+		end, _ := time.Parse(time.RFC3339, match[2])
+		return NewWindow(start, end), nil
+	}
+
+	return Window{}, fmt.Errorf("illegal window: %s", window)
+}
+
+// windowDuration returns the duration of the number of units, which are m, h,
+// or d, where a day is 24 hours
+func windowDuration(num, unit string) time.Duration {
+	n, _ := strconv.ParseInt(num, 10, 64)
+
+	switch unit {
+	case "h":
+		return time.Duration(n) * time.Hour
+	case "d":
+		return time.Duration(n) * 24 * time.Hour
+	}
+	return time.Duration(n) * time.Minute
+}
+
+// Contains returns true if t is in the window: at or after Start, and before End
+func (w Window) Contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// Duration returns the duration of the window, which is negative if it ends
+// before it starts
+func (w Window) Duration() time.Duration {
+	return w.End.Sub(w.Start)
+}
+
+// IsEmpty returns true if the window contains no time
+func (w Window) IsEmpty() bool {
+	return !w.End.After(w.Start)
+}
+
+// Overlaps returns true if the windows have any time in common. Windows which
+// only share a boundary, e.g. consecutive days, do not overlap.
+func (w Window) Overlaps(that Window) bool {
+	_, ok := w.Overlap(that)
+	return ok
+}
+
+// Overlap returns the time the windows have in common, and true if it is not
+// empty
+func (w Window) Overlap(that Window) (Window, bool) {
+	overlap := w
+	if that.Start.After(overlap.Start) {
+		overlap.Start = that.Start
+	}
+	if that.End.Before(overlap.End) {
+		overlap.End = that.End
+	}
+
+	if overlap.IsEmpty() {
+		return Window{}, false
+	}
+	return overlap, true
+}
+
+// Align returns the window expanded to the resolution, with Start aligned back
+// and End aligned forward by AlignBack and AlignForward.
+func (w Window) Align(resolution time.Duration) Window {
+	return NewWindow(AlignBack(w.Start, resolution), AlignForward(w.End, resolution))
+}
+
+// String returns the window as a half-open interval of RFC3339 times
+func (w Window) String() string {
+	return fmt.Sprintf("[%s, %s)", w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339))
+}
+
+// SplitDays splits the window at each midnight in the location. The first and
+// last days are partial if the window doesn't begin or end at midnight, and
+// days are 23 or 25 hours long across daylight saving time transitions.
+func (w Window) SplitDays(loc *time.Location) []Window {
+	return w.split(StartOfDay(w.Start, loc), func(t time.Time) time.Time {
+		return t.AddDate(0, 0, 1)
+	})
+}
+
+// SplitWeeks splits the window at midnight on each Sunday in the location. The
+// first and last weeks are partial if the window doesn't begin or end then.
+func (w Window) SplitWeeks(loc *time.Location) []Window {
+	return w.split(StartOfWeek(w.Start, loc), func(t time.Time) time.Time {
+		return t.AddDate(0, 0, 7)
+	})
+}
+
+// SplitMonths splits the window at midnight on the first of each month in the
+// location. The first and last months are partial if the window doesn't begin
+// or end then.
+func (w Window) SplitMonths(loc *time.Location) []Window {
+	return w.split(StartOfMonth(w.Start, loc), func(t time.Time) time.Time {
+		return t.AddDate(0, 1, 0)
+	})
+}
+
+// split splits the window into the periods beginning at first, which is at or
+// before Start, and each subsequent boundary given by next, clipped to the
+// window. An empty window has no periods.
+func (w Window) split(first time.Time, next func(time.Time) time.Time) []Window {
+	periods := []Window{}
+
+	for start := first; start.Before(w.End); start = next(start) {
+		if period, ok := NewWindow(start, next(start)).Overlap(w); ok {
+			periods = append(periods, period)
+		}
+	}
+
+	return periods
+}
+
+// StartOfDay returns midnight, in the location, of the day containing t. In
+// the rare locations whose daylight saving time transitions skip midnight, it
+// is normalized as by time.Date.
+func StartOfDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
+
+// StartOfWeek returns midnight, in the location, of the Sunday beginning the
+// week containing t.
+func StartOfWeek(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day()-int(t.Weekday()), 0, 0, 0, 0, loc)
+}
+
+// StartOfMonth returns midnight, in the location, of the first of the month
+// containing t.
+func StartOfMonth(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+}
+
+// AlignBack rounds t back to a multiple of the resolution in t's location;
+// e.g. 2020-01-01T12:37:48-07:00 at 1h is 2020-01-01T12:00:00-07:00. A
+// resolution which divides a day is aligned from midnight, so that alignment
+// is unaffected by daylight saving time transitions earlier in the day, and a
+// resolution of a day aligns to midnight. Other resolutions are aligned by
+// the UTC offset of t.
+func AlignBack(t time.Time, resolution time.Duration) time.Time {
+	if resolution <= 0 {
+		return t
+	}
+
+	day := 24 * time.Hour
+	if resolution == day {
+		return StartOfDay(t, t.Location())
+	}
+	if resolution < day && day%resolution == 0 {
+		midnight := StartOfDay(t, t.Location())
+		return midnight.Add(t.Sub(midnight).Truncate(resolution))
+	}
+
+	_, offSec := t.Zone()
+	offset := time.Duration(offSec) * time.Second
+	return t.Add(offset).Truncate(resolution).Add(-offset)
+}
+
+// AlignForward rounds t forward to a multiple of the resolution in t's
+// location, as AlignBack rounds back. A time already aligned is unchanged.
+func AlignForward(t time.Time, resolution time.Duration) time.Time {
+	back := AlignBack(t, resolution)
+	if back.Equal(t) {
+		return t
+	}
+
+	if resolution == 24*time.Hour {
+		return back.AddDate(0, 0, 1)
+	}
+	return back.Add(resolution)
+}
@@ -0,0 +1,284 @@
+package timeutil
+
+import (
+	"testing"
+	"time"
+)
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("failed to load location %s: %s", name, err)
+	}
+	return loc
+}
+
+func windowStrings(windows []Window) []string {
+	strs := make([]string, len(windows))
+	for i, w := range windows {
+		strs[i] = NewWindow(w.Start.UTC(), w.End.UTC()).String()
+	}
+	return strs
+}
+
+func TestWindowHalfOpen(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+	w := NewWindow(start, end)
+
+	if !w.Contains(start) {
+		t.Errorf("expected window to contain its start")
+	}
+	if w.Contains(end) {
+		t.Errorf("expected window not to contain its end")
+	}
+	if !w.Contains(end.Add(-time.Nanosecond)) {
+		t.Errorf("expected window to contain the moment before its end")
+	}
+
+	next := NewWindow(end, end.Add(24*time.Hour))
+	if w.Overlaps(next) {
+		t.Errorf("expected consecutive windows not to overlap")
+	}
+
+	overlap, ok := w.Overlap(NewWindow(start.Add(12*time.Hour), end.Add(12*time.Hour)))
+	if !ok || overlap.Duration() != 12*time.Hour || !overlap.Start.Equal(start.Add(12*time.Hour)) {
+		t.Errorf("expected 12h overlap; got %s", overlap)
+	}
+
+	if !NewWindow(start, start).IsEmpty() || !NewWindow(end, start).IsEmpty() || w.IsEmpty() {
+		t.Errorf("expected only zero and negative windows to be empty")
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	cases := []struct {
+		name     string
+		window   string
+		now      time.Time
+		expected string
+	}{
+		{
+			name:     "today",
+			window:   "today",
+			now:      time.Date(2021, time.March, 16, 3, 0, 0, 0, time.UTC),
+			expected: "[2021-03-16T00:00:00Z, 2021-03-17T00:00:00Z)",
+		},
+		{
+			// Daylight saving time begins on March 14th, which is 23 hours long
+			name:     "today across spring forward",
+			window:   "today",
+			now:      time.Date(2021, time.March, 14, 12, 0, 0, 0, newYork),
+			expected: "[2021-03-14T05:00:00Z, 2021-03-15T04:00:00Z)",
+		},
+		{
+			// Daylight saving time ends on November 7th, which is 25 hours long
+			name:     "yesterday across fall back",
+			window:   "yesterday",
+			now:      time.Date(2021, time.November, 8, 12, 0, 0, 0, newYork),
+			expected: "[2021-11-07T04:00:00Z, 2021-11-08T05:00:00Z)",
+		},
+		{
+			// March 16th, 2021 is a Tuesday
+			name:     "week",
+			window:   "week",
+			now:      time.Date(2021, time.March, 16, 12, 0, 0, 0, newYork),
+			expected: "[2021-03-14T05:00:00Z, 2021-03-16T16:00:00Z)",
+		},
+		{
+			name:     "lastweek",
+			window:   "lastweek",
+			now:      time.Date(2021, time.March, 16, 12, 0, 0, 0, newYork),
+			expected: "[2021-03-07T05:00:00Z, 2021-03-14T05:00:00Z)",
+		},
+		{
+			name:     "month",
+			window:   "month",
+			now:      time.Date(2021, time.March, 16, 12, 0, 0, 0, newYork),
+			expected: "[2021-03-01T05:00:00Z, 2021-03-16T16:00:00Z)",
+		},
+		{
+			name:     "lastmonth",
+			window:   "lastmonth",
+			now:      time.Date(2021, time.April, 2, 12, 0, 0, 0, newYork),
+			expected: "[2021-03-01T05:00:00Z, 2021-04-01T04:00:00Z)",
+		},
+		{
+			// Durations are absolute, regardless of daylight saving time
+			name:     "duration",
+			window:   "2d",
+			now:      time.Date(2021, time.March, 15, 12, 0, 0, 0, newYork),
+			expected: "[2021-03-13T16:00:00Z, 2021-03-15T16:00:00Z)",
+		},
+		{
+			name:     "duration with offset",
+			window:   "24h offset 14h",
+			now:      time.Date(2021, time.March, 16, 14, 0, 0, 0, time.UTC),
+			expected: "[2021-03-15T00:00:00Z, 2021-03-16T00:00:00Z)",
+		},
+		{
+			name:     "timestamps",
+			window:   "1583712000,1583884800",
+			now:      time.Date(2021, time.March, 16, 0, 0, 0, 0, newYork),
+			expected: "[2020-03-09T00:00:00Z, 2020-03-11T00:00:00Z)",
+		},
+		{
+			name:     "RFC3339",
+			window:   "2020-04-08T00:00:00Z,2020-04-12T00:00:00Z",
+			now:      time.Date(2021, time.March, 16, 0, 0, 0, 0, time.UTC),
+			expected: "[2020-04-08T00:00:00Z, 2020-04-12T00:00:00Z)",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w, err := ParseWindow(c.window, c.now)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if s := windowStrings([]Window{w})[0]; s != c.expected {
+				t.Fatalf("expected %s; got %s", c.expected, s)
+			}
+		})
+	}
+
+	if _, err := ParseWindow("fortnight", time.Now()); err == nil {
+		t.Fatalf("expected error parsing illegal window")
+	}
+}
+
+func TestWindowSplitDays(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+
+	// From noon on March 13th to noon on March 15th, across the 23 hour day
+	w := NewWindow(time.Date(2021, time.March, 13, 12, 0, 0, 0, newYork), time.Date(2021, time.March, 15, 12, 0, 0, 0, newYork))
+	days := w.SplitDays(newYork)
+
+	expected := []string{
+		"[2021-03-13T17:00:00Z, 2021-03-14T05:00:00Z)",
+		"[2021-03-14T05:00:00Z, 2021-03-15T04:00:00Z)",
+		"[2021-03-15T04:00:00Z, 2021-03-15T16:00:00Z)",
+	}
+	actual := windowStrings(days)
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d days; got %v", len(expected), actual)
+	}
+	for i := range expected {
+		if actual[i] != expected[i] {
+			t.Errorf("day %d: expected %s; got %s", i, expected[i], actual[i])
+		}
+	}
+	if days[1].Duration() != 23*time.Hour {
+		t.Errorf("expected the day daylight saving time begins to be 23h; got %s", days[1].Duration())
+	}
+
+	// The same window split in UTC has whole 24 hour days
+	for _, day := range w.SplitDays(time.UTC)[1:2] {
+		if day.Duration() != 24*time.Hour {
+			t.Errorf("expected whole UTC days to be 24h; got %s", day.Duration())
+		}
+	}
+
+	// A window ending at midnight has no empty trailing day
+	whole := NewWindow(time.Date(2021, time.November, 6, 0, 0, 0, 0, newYork), time.Date(2021, time.November, 8, 0, 0, 0, 0, newYork))
+	if fallBack := whole.SplitDays(newYork); len(fallBack) != 2 || fallBack[1].Duration() != 25*time.Hour {
+		t.Errorf("expected 2 days, the second 25h; got %v", windowStrings(fallBack))
+	}
+
+	if empty := NewWindow(w.Start, w.Start).SplitDays(newYork); len(empty) != 0 {
+		t.Errorf("expected an empty window to have no days; got %v", windowStrings(empty))
+	}
+}
+
+func TestWindowSplitWeeksAndMonths(t *testing.T) {
+	w := NewWindow(time.Date(2021, time.January, 27, 0, 0, 0, 0, time.UTC), time.Date(2021, time.March, 10, 0, 0, 0, 0, time.UTC))
+
+	// January 31st and February 28th, 2021 are Sundays
+	weeks := windowStrings(w.SplitWeeks(time.UTC))
+	if len(weeks) != 7 || weeks[0] != "[2021-01-27T00:00:00Z, 2021-01-31T00:00:00Z)" || weeks[6] != "[2021-03-07T00:00:00Z, 2021-03-10T00:00:00Z)" {
+		t.Errorf("unexpected weeks: %v", weeks)
+	}
+
+	months := windowStrings(w.SplitMonths(time.UTC))
+	expected := []string{
+		"[2021-01-27T00:00:00Z, 2021-02-01T00:00:00Z)",
+		"[2021-02-01T00:00:00Z, 2021-03-01T00:00:00Z)",
+		"[2021-03-01T00:00:00Z, 2021-03-10T00:00:00Z)",
+	}
+	if len(months) != len(expected) {
+		t.Fatalf("expected %d months; got %v", len(expected), months)
+	}
+	for i := range expected {
+		if months[i] != expected[i] {
+			t.Errorf("month %d: expected %s; got %s", i, expected[i], months[i])
+		}
+	}
+}
+
+func TestAlign(t *testing.T) {
+	newYork := mustLoadLocation(t, "America/New_York")
+	boulder := time.FixedZone("Boulder", -7*60*60)
+
+	cases := []struct {
+		name       string
+		t          time.Time
+		resolution time.Duration
+		back       time.Time
+		forward    time.Time
+	}{
+		{
+			name:       "hour",
+			t:          time.Date(2020, time.January, 1, 12, 37, 48, 0, boulder),
+			resolution: time.Hour,
+			back:       time.Date(2020, time.January, 1, 12, 0, 0, 0, boulder),
+			forward:    time.Date(2020, time.January, 1, 13, 0, 0, 0, boulder),
+		},
+		{
+			name:       "day in fixed zone",
+			t:          time.Date(2020, time.January, 1, 12, 37, 48, 0, boulder),
+			resolution: 24 * time.Hour,
+			back:       time.Date(2020, time.January, 1, 0, 0, 0, 0, boulder),
+			forward:    time.Date(2020, time.January, 2, 0, 0, 0, 0, boulder),
+		},
+		{
+			// Aligning by the UTC offset at noon would give 23:00 the day before
+			name:       "day after spring forward",
+			t:          time.Date(2021, time.March, 14, 12, 0, 0, 0, newYork),
+			resolution: 24 * time.Hour,
+			back:       time.Date(2021, time.March, 14, 0, 0, 0, 0, newYork),
+			forward:    time.Date(2021, time.March, 15, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:       "six hours after fall back",
+			t:          time.Date(2021, time.November, 7, 13, 30, 0, 0, newYork),
+			resolution: 6 * time.Hour,
+			back:       time.Date(2021, time.November, 7, 11, 0, 0, 0, newYork),
+			forward:    time.Date(2021, time.November, 7, 17, 0, 0, 0, newYork),
+		},
+		{
+			name:       "already aligned",
+			t:          time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC),
+			resolution: time.Hour,
+			back:       time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC),
+			forward:    time.Date(2020, time.January, 1, 12, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if back := AlignBack(c.t, c.resolution); !back.Equal(c.back) {
+				t.Errorf("AlignBack: expected %s; got %s", c.back, back)
+			}
+			if forward := AlignForward(c.t, c.resolution); !forward.Equal(c.forward) {
+				t.Errorf("AlignForward: expected %s; got %s", c.forward, forward)
+			}
+		})
+	}
+
+	w := NewWindow(time.Date(2020, time.January, 1, 12, 37, 0, 0, time.UTC), time.Date(2020, time.January, 1, 14, 5, 0, 0, time.UTC)).Align(time.Hour)
+	if w.String() != "[2020-01-01T12:00:00Z, 2020-01-01T15:00:00Z)" {
+		t.Errorf("expected window to be expanded to whole hours; got %s", w)
+	}
+}
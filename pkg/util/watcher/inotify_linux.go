@@ -0,0 +1,77 @@
+//go:build linux
+// +build linux
+
+package watcher
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+// inotifyMask selects the events of the entries of a directory which may
+// change their contents, and those of the directory itself being removed
+const inotifyMask = syscall.IN_CREATE | syscall.IN_DELETE | syscall.IN_MODIFY |
+	syscall.IN_CLOSE_WRITE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO |
+	syscall.IN_ATTRIB | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+// watchNative watches the directory with inotify, calling onEvent with the name
+// of each entry which has an event, or with an empty name if the event queue
+// overflowed. onLost is called if the directory can no longer be watched.
+// Closing the returned Closer stops watching.
+func watchNative(dir string, onEvent func(name string), onLost func(err error)) (io.Closer, error) {
+	// The descriptor is non-blocking so that reads use the runtime poller, and
+	// are interrupted when the file is closed
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, fmt.Errorf("inotify_init1: %w", err)
+	}
+
+	if _, err := syscall.InotifyAddWatch(fd, dir, inotifyMask); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("inotify_add_watch %s: %w", dir, err)
+	}
+
+	file := os.NewFile(uintptr(fd), "inotify")
+	go readInotify(file, onEvent, onLost)
+
+	return file, nil
+}
+
+// readInotify reads events from the inotify file until it is closed
+func readInotify(file *os.File, onEvent func(name string), onLost func(err error)) {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+
+	for {
+		n, err := file.Read(buf)
+		if err != nil {
+			if !errors.Is(err, os.ErrClosed) {
+				onLost(err)
+			}
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(event.Len)
+			offset = nameEnd
+
+			if event.Mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF|syscall.IN_IGNORED) != 0 {
+				onLost(errors.New("watched directory was removed"))
+				return
+			}
+
+			if event.Mask&syscall.IN_Q_OVERFLOW != 0 {
+				onEvent("")
+				continue
+			}
+
+			onEvent(strings.TrimRight(string(buf[nameStart:nameEnd]), "\x00"))
+		}
+	}
+}
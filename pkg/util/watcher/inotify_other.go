@@ -0,0 +1,15 @@
+//go:build !linux
+// +build !linux
+
+package watcher
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// watchNative is unsupported outside of Linux, so watchers poll
+func watchNative(dir string, onEvent func(name string), onLost func(err error)) (io.Closer, error) {
+	return nil, fmt.Errorf("inotify is not supported on %s", runtime.GOOS)
+}
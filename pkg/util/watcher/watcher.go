@@ -0,0 +1,323 @@
+// Package watcher calls back when the contents of a file or directory change,
+// e.g. a config file mounted from a ConfigMap.
+package watcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kubecost/cost-model/pkg/log"
+)
+
+// DefaultDebounce is how long a watcher waits for further events by default
+const DefaultDebounce = 100 * time.Millisecond
+
+// DefaultPollInterval is the interval at which a watcher polls by default
+const DefaultPollInterval = 10 * time.Second
+
+// Options configures a watcher. The zero value uses inotify, falling back to
+// polling, with the default debounce and poll interval.
+type Options struct {
+	// Debounce is how long the watcher waits after an event for further events
+	// before checking the contents, so that a burst of events, such as those of
+	// a ConfigMap update, results in a single callback.
+	Debounce time.Duration
+
+	// PollInterval is the interval at which the contents are checked when
+	// polling
+	PollInterval time.Duration
+
+	// Poll, if true, polls rather than using inotify
+	Poll bool
+}
+
+// Watcher calls back when the contents of a file or directory change. The
+// contents are hashed, so that events which do not change them, such as a file
+// being rewritten with the same data, do not result in a callback. Create one
+// with New.
+//
+// The parent directory of a file is watched, rather than the file itself, so
+// that the file can be replaced. This handles the volumes of ConfigMaps and
+// Secrets, which are updated by atomically swapping the ..data symlink that the
+// files link through.
+type Watcher struct {
+	path     string
+	isDir    bool
+	onChange func()
+	opts     Options
+
+	// hash is the hash of the contents when last checked. It is only accessed
+	// by the run goroutine.
+	hash string
+
+	events    chan struct{}
+	polls     chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	lock    sync.Mutex
+	native  io.Closer
+	polling bool
+}
+
+// New watches the file or directory at path, calling onChange when its
+// contents change. Only the files directly in a directory are watched. The
+// path must exist when the watcher is created. If inotify is unavailable, the
+// path is polled.
+func New(path string, onChange func(), opts Options) (*Watcher, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+
+	w := &Watcher{
+		path:     path,
+		isDir:    info.IsDir(),
+		onChange: onChange,
+		opts:     opts,
+		events:   make(chan struct{}, 1),
+		polls:    make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+
+	w.hash, err = hashContents(path, w.isDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Poll {
+		w.poll()
+	} else {
+		dir := path
+		if !w.isDir {
+			dir = filepath.Dir(path)
+		}
+
+		native, err := watchNative(dir, w.onEvent, w.onLost)
+		if err != nil {
+			log.Warningf("Failed to watch %s with inotify, polling every %s: %s", path, opts.PollInterval, err)
+			w.poll()
+		} else {
+			w.native = native
+		}
+	}
+
+	go w.run()
+
+	return w, nil
+}
+
+// Polling returns true if the path is polled rather than watched with inotify
+func (w *Watcher) Polling() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.polling
+}
+
+// Close stops watching. onChange is not called after Close returns, unless it
+// is already running. Close may be called from onChange.
+func (w *Watcher) Close() {
+	w.closeOnce.Do(func() {
+		close(w.done)
+
+		w.lock.Lock()
+		defer w.lock.Unlock()
+
+		if w.native != nil {
+			w.native.Close()
+			w.native = nil
+		}
+	})
+}
+
+// notify schedules a check of the contents, without blocking if one is
+// already scheduled
+func (w *Watcher) notify() {
+	select {
+	case w.events <- struct{}{}:
+	default:
+	}
+}
+
+// onEvent is called with the name of each entry of the watched directory
+// which has an event. The name is empty if events were dropped.
+func (w *Watcher) onEvent(name string) {
+	// The events of a file's siblings are ignored, except those of the
+	// entries prefixed by "..", which include the ..data symlink of a
+	// ConfigMap volume
+	if !w.isDir && name != "" && name != filepath.Base(w.path) && !strings.HasPrefix(name, "..") {
+		return
+	}
+
+	w.notify()
+}
+
+// onLost is called if the directory can no longer be watched with inotify,
+// e.g. because it was removed, in which case the path is polled instead
+func (w *Watcher) onLost(err error) {
+	select {
+	case <-w.done:
+		return
+	default:
+	}
+
+	log.Warningf("Stopped watching %s with inotify, polling every %s: %s", w.path, w.opts.PollInterval, err)
+
+	w.lock.Lock()
+	if w.native != nil {
+		w.native.Close()
+		w.native = nil
+	}
+	w.lock.Unlock()
+
+	w.poll()
+	w.notify()
+}
+
+// poll checks the contents at the poll interval until the watcher is closed
+func (w *Watcher) poll() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.polling {
+		return
+	}
+	w.polling = true
+
+	go func() {
+		ticker := time.NewTicker(w.opts.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-ticker.C:
+				select {
+				case w.polls <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+}
+
+// run checks the contents once events have stopped for the debounce duration,
+// and at each poll, until the watcher is closed
+func (w *Watcher) run() {
+	var debounced <-chan time.Time
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-w.events:
+			debounced = time.After(w.opts.Debounce)
+		case <-debounced:
+			debounced = nil
+			w.check()
+		case <-w.polls:
+			w.check()
+		}
+	}
+}
+
+// check calls onChange if the hash of the contents has changed
+func (w *Watcher) check() {
+	hash, err := hashContents(w.path, w.isDir)
+	if err != nil {
+		// The path may be briefly missing while it is replaced, in which case
+		// its replacement is checked on its next event
+		log.Debugf("Failed to check %s for changes: %s", w.path, err)
+		return
+	}
+
+	if hash == w.hash {
+		return
+	}
+	w.hash = hash
+
+	select {
+	case <-w.done:
+		return
+	default:
+	}
+
+	w.onChange()
+}
+
+// hashContents returns the hash of the file at path, following symlinks, or of
+// the names and contents of the files directly in the directory at path. The
+// entries of a directory prefixed by "..", which are the internals of a
+// ConfigMap volume, are skipped.
+func hashContents(path string, isDir bool) (string, error) {
+	h := sha256.New()
+
+	if !isDir {
+		if err := hashFile(h, path); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(h.Sum(nil)), nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "..") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		file := filepath.Join(path, name)
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		if info.IsDir() {
+			continue
+		}
+
+		io.WriteString(h, name)
+		h.Write([]byte{0})
+		if err := hashFile(h, file); err != nil {
+			return "", err
+		}
+		h.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashFile writes the contents of the file at path to w
+func hashFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(w, f)
+	return err
+}
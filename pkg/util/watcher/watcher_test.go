@@ -0,0 +1,212 @@
+package watcher
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testDebounce = 20 * time.Millisecond
+
+// newConfigMapDir creates a directory laid out like a ConfigMap volume, whose
+// files link through the ..data symlink to the timestamped directory holding
+// the data
+func newConfigMapDir(t *testing.T, data map[string]string) string {
+	dir := t.TempDir()
+	writeConfigMapData(t, dir, "..2026_01_01_00_00_00.000000001", data)
+	if err := os.Symlink("..2026_01_01_00_00_00.000000001", filepath.Join(dir, "..data")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for key := range data {
+		if err := os.Symlink(filepath.Join("..data", key), filepath.Join(dir, key)); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+	return dir
+}
+
+// writeConfigMapData writes the data to the timestamped directory
+func writeConfigMapData(t *testing.T, dir, timestamped string, data map[string]string) {
+	if err := os.Mkdir(filepath.Join(dir, timestamped), 0755); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for key, value := range data {
+		if err := os.WriteFile(filepath.Join(dir, timestamped, key), []byte(value), 0644); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+}
+
+// swapConfigMapData updates the ConfigMap volume as the kubelet does, writing
+// the data to a new timestamped directory, atomically replacing the ..data
+// symlink with one to it, and removing the old directory
+func swapConfigMapData(t *testing.T, dir, timestamped string, data map[string]string) {
+	old, err := os.Readlink(filepath.Join(dir, "..data"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	writeConfigMapData(t, dir, timestamped, data)
+	if err := os.Symlink(timestamped, filepath.Join(dir, "..data_tmp")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, old)); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+// newTestWatcher watches the path, returning a channel which receives on each
+// callback
+func newTestWatcher(t *testing.T, path string, opts Options) (*Watcher, chan struct{}) {
+	changes := make(chan struct{}, 10)
+	if opts.Debounce == 0 {
+		opts.Debounce = testDebounce
+	}
+
+	w, err := New(path, func() { changes <- struct{}{} }, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	t.Cleanup(w.Close)
+
+	return w, changes
+}
+
+// expectChanges fails unless exactly n callbacks are received
+func expectChanges(t *testing.T, changes chan struct{}, n int) {
+	t.Helper()
+
+	for i := 0; i < n; i++ {
+		select {
+		case <-changes:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("expected %d change(s); got %d", n, i)
+		}
+	}
+
+	select {
+	case <-changes:
+		t.Fatalf("expected %d change(s); got more", n)
+	case <-time.After(10 * testDebounce):
+	}
+}
+
+func TestWatchFileSymlinkSwap(t *testing.T) {
+	dir := newConfigMapDir(t, map[string]string{"pricing.json": `{"cpu": "1"}`})
+	path := filepath.Join(dir, "pricing.json")
+
+	w, changes := newTestWatcher(t, path, Options{})
+	if w.Polling() {
+		t.Skip("inotify is unavailable")
+	}
+
+	swapConfigMapData(t, dir, "..2026_01_01_00_01_00.000000001", map[string]string{"pricing.json": `{"cpu": "2"}`})
+	expectChanges(t, changes, 1)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(data) != `{"cpu": "2"}` {
+		t.Fatalf("expected the swapped data; got %s", data)
+	}
+}
+
+func TestWatchDirectorySymlinkSwap(t *testing.T) {
+	dir := newConfigMapDir(t, map[string]string{"a": "1", "b": "2"})
+
+	_, changes := newTestWatcher(t, dir, Options{})
+
+	// A swap which only changes the timestamped directory is not a change
+	swapConfigMapData(t, dir, "..2026_01_01_00_01_00.000000001", map[string]string{"a": "1", "b": "2"})
+	expectChanges(t, changes, 0)
+
+	swapConfigMapData(t, dir, "..2026_01_01_00_02_00.000000001", map[string]string{"a": "1", "b": "3"})
+	expectChanges(t, changes, 1)
+}
+
+func TestWatchDebounce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("0"), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, changes := newTestWatcher(t, path, Options{Debounce: 100 * time.Millisecond})
+
+	for i := 1; i <= 10; i++ {
+		if err := os.WriteFile(path, []byte{byte('0' + i)}, 0644); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	expectChanges(t, changes, 1)
+}
+
+func TestWatchUnchangedContents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("same"), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, changes := newTestWatcher(t, path, Options{})
+
+	if err := os.WriteFile(path, []byte("same"), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectChanges(t, changes, 0)
+}
+
+func TestWatchIgnoresSiblings(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte("1"), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_, changes := newTestWatcher(t, path, Options{})
+
+	if err := os.WriteFile(filepath.Join(dir, "other.json"), []byte("1"), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectChanges(t, changes, 0)
+}
+
+func TestWatchPolling(t *testing.T) {
+	dir := newConfigMapDir(t, map[string]string{"pricing.json": "1"})
+	path := filepath.Join(dir, "pricing.json")
+
+	w, changes := newTestWatcher(t, path, Options{Poll: true, PollInterval: 10 * time.Millisecond})
+	if !w.Polling() {
+		t.Fatalf("expected the watcher to poll")
+	}
+
+	swapConfigMapData(t, dir, "..2026_01_01_00_01_00.000000001", map[string]string{"pricing.json": "2"})
+	expectChanges(t, changes, 1)
+}
+
+func TestWatchClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte("1"), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	w, changes := newTestWatcher(t, path, Options{})
+	w.Close()
+
+	if err := os.WriteFile(path, []byte("2"), 0644); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectChanges(t, changes, 0)
+}
+
+func TestNewMissingPath(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing.json"), func() {}, Options{})
+	if !os.IsNotExist(err) {
+		t.Fatalf("expected a not exist error; got %v", err)
+	}
+}
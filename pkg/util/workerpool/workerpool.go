@@ -0,0 +1,223 @@
+// Package workerpool runs tasks with bounded concurrency, collecting their
+// results in order.
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// Mode determines how a pool handles the failure of a task
+type Mode int
+
+const (
+	// FailFast cancels the remaining tasks when a task fails, returning its error
+	FailFast Mode = iota
+
+	// CollectAll runs every task, returning the errors of all that failed
+	CollectAll
+)
+
+// Options configures how tasks are run
+type Options struct {
+	// Concurrency is the maximum number of tasks run at once. If less than 1,
+	// every task is run at once.
+	Concurrency int
+
+	// Mode determines how the failure of a task is handled
+	Mode Mode
+}
+
+// TaskError is the error of the task at Index
+type TaskError struct {
+	Index int
+	Err   error
+}
+
+// Error returns the error of the task, prefixed by its index
+func (te *TaskError) Error() string {
+	return fmt.Sprintf("task %d: %s", te.Index, te.Err)
+}
+
+// Unwrap returns the error of the task
+func (te *TaskError) Unwrap() error {
+	return te.Err
+}
+
+// PanicError is the error of a task which panicked
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+// Error returns the value the task panicked with
+func (pe *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", pe.Value)
+}
+
+// Errors are the errors of the tasks which failed in CollectAll mode, in the
+// order of the tasks
+type Errors []*TaskError
+
+// Error returns the errors of all failed tasks
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d task(s) failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Is returns true if the error of any failed task matches target, so that
+// errors.Is can be used with Errors
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error of the failed tasks matching target, so that
+// errors.As can be used with Errors
+func (e Errors) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run runs the tasks, at most concurrency at once, in FailFast mode. It
+// returns once all started tasks have returned.
+func Run(ctx context.Context, concurrency int, tasks []func(ctx context.Context) error) error {
+	return RunWithOptions(ctx, tasks, Options{
+		Concurrency: concurrency,
+		Mode:        FailFast,
+	})
+}
+
+// RunWithOptions runs the tasks as configured by the options. See Map.
+func RunWithOptions(ctx context.Context, tasks []func(ctx context.Context) error, opts Options) error {
+	wrapped := make([]func(context.Context) (struct{}, error), len(tasks))
+	for i, task := range tasks {
+		task := task
+		wrapped[i] = func(ctx context.Context) (struct{}, error) {
+			return struct{}{}, task(ctx)
+		}
+	}
+
+	_, err := Map(ctx, wrapped, opts)
+	return err
+}
+
+// Map runs the tasks as configured by the options, returning their results in
+// the order of the tasks, and returns once all started tasks have returned.
+// Tasks are started in order. A task which panics fails with a PanicError.
+//
+// In FailFast mode, the context passed to tasks is cancelled when a task
+// fails, tasks not yet started are not run, and the first *TaskError is
+// returned. In CollectAll mode, every task is run and the errors of those
+// which failed are returned as Errors. In either mode, tasks not yet started
+// when ctx is done are not run, and fail with the error of ctx.
+//
+// The results of failed tasks are those they returned, or the zero value if
+// they did not run or panicked.
+func Map[T any](ctx context.Context, tasks []func(ctx context.Context) (T, error), opts Options) ([]T, error) {
+	results := make([]T, len(tasks))
+	if len(tasks) == 0 {
+		return results, nil
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency < 1 || concurrency > len(tasks) {
+		concurrency = len(tasks)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	errs := make([]*TaskError, len(tasks))
+	var firstErr *TaskError
+	var once sync.Once
+
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for i := range indices {
+				// The dispatcher may send a task as ctx is done
+				if ctx.Err() != nil {
+					errs[i] = &TaskError{Index: i, Err: ctx.Err()}
+					continue
+				}
+
+				result, err := runTask(ctx, tasks[i])
+				results[i] = result
+				if err == nil {
+					continue
+				}
+
+				errs[i] = &TaskError{Index: i, Err: err}
+				if opts.Mode == FailFast {
+					once.Do(func() {
+						firstErr = errs[i]
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	for i := range tasks {
+		if ctx.Err() == nil {
+			select {
+			case indices <- i:
+				continue
+			case <-ctx.Done():
+			}
+		}
+		errs[i] = &TaskError{Index: i, Err: ctx.Err()}
+	}
+	close(indices)
+	wg.Wait()
+
+	if firstErr != nil {
+		return results, firstErr
+	}
+
+	var failed Errors
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	if len(failed) == 0 {
+		return results, nil
+	}
+	if opts.Mode == FailFast {
+		return results, failed[0]
+	}
+	return results, failed
+}
+
+// runTask runs the task, recovering a panic as a PanicError
+func runTask[T any](ctx context.Context, task func(context.Context) (T, error)) (result T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var zero T
+			result, err = zero, &PanicError{Value: r, Stack: debug.Stack()}
+		}
+	}()
+
+	return task(ctx)
+}
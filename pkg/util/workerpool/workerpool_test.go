@@ -0,0 +1,193 @@
+package workerpool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMapOrdering(t *testing.T) {
+	tasks := make([]func(context.Context) (int, error), 20)
+	for i := range tasks {
+		i := i
+		tasks[i] = func(ctx context.Context) (int, error) {
+			// Later tasks finish first
+			time.Sleep(time.Duration(len(tasks)-i) * time.Millisecond)
+			return i * i, nil
+		}
+	}
+
+	results, err := Map(context.Background(), tasks, Options{Concurrency: 5})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for i, result := range results {
+		if result != i*i {
+			t.Fatalf("expected result %d to be %d; got %d", i, i*i, result)
+		}
+	}
+}
+
+func TestRunConcurrencyBound(t *testing.T) {
+	var running, peak int32
+
+	tasks := make([]func(context.Context) error, 12)
+	for i := range tasks {
+		tasks[i] = func(ctx context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		}
+	}
+
+	if err := Run(context.Background(), 3, tasks); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if peak > 3 {
+		t.Fatalf("expected at most 3 tasks at once; got %d", peak)
+	}
+}
+
+func TestRunFailFast(t *testing.T) {
+	var ran int32
+	failure := fmt.Errorf("failed")
+
+	tasks := make([]func(context.Context) error, 10)
+	for i := range tasks {
+		i := i
+		tasks[i] = func(ctx context.Context) error {
+			atomic.AddInt32(&ran, 1)
+			if i == 1 {
+				return failure
+			}
+			// Running tasks are cancelled by the failure
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+				return nil
+			}
+		}
+	}
+
+	err := Run(context.Background(), 2, tasks)
+
+	var te *TaskError
+	if !errors.As(err, &te) || te.Index != 1 || !errors.Is(err, failure) {
+		t.Fatalf("expected the error of task 1; got %v", err)
+	}
+	if ran != 2 {
+		t.Fatalf("expected tasks not yet started not to run; %d ran", ran)
+	}
+}
+
+func TestRunCollectAll(t *testing.T) {
+	var ran int32
+
+	tasks := make([]func(context.Context) error, 6)
+	for i := range tasks {
+		i := i
+		tasks[i] = func(ctx context.Context) error {
+			atomic.AddInt32(&ran, 1)
+			if i%2 == 0 {
+				return fmt.Errorf("task %d failed", i)
+			}
+			return nil
+		}
+	}
+
+	err := RunWithOptions(context.Background(), tasks, Options{Concurrency: 2, Mode: CollectAll})
+	if ran != 6 {
+		t.Fatalf("expected every task to run; %d ran", ran)
+	}
+
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != 3 {
+		t.Fatalf("expected 3 errors; got %v", err)
+	}
+	for i, te := range errs {
+		if te.Index != i*2 {
+			t.Fatalf("expected errors in task order; got %v", err)
+		}
+	}
+}
+
+func TestMapCancelledMidRun(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+
+	tasks := make([]func(context.Context) (int, error), 5)
+	for i := range tasks {
+		i := i
+		tasks[i] = func(ctx context.Context) (int, error) {
+			if i == 0 {
+				close(started)
+			}
+			<-ctx.Done()
+			return i + 1, ctx.Err()
+		}
+	}
+
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	results, err := Map(ctx, tasks, Options{Concurrency: 1, Mode: CollectAll})
+
+	errs, ok := err.(Errors)
+	if !ok || len(errs) != len(tasks) {
+		t.Fatalf("expected every task to fail; got %v", err)
+	}
+	for _, te := range errs {
+		if !errors.Is(te, context.Canceled) {
+			t.Fatalf("expected tasks to fail with the cancellation; got %v", te)
+		}
+	}
+	if results[0] != 1 || results[4] != 0 {
+		t.Fatalf("expected only the result of the task which ran; got %v", results)
+	}
+}
+
+func TestMapPanic(t *testing.T) {
+	tasks := []func(context.Context) (string, error){
+		func(ctx context.Context) (string, error) {
+			return "ok", nil
+		},
+		func(ctx context.Context) (string, error) {
+			var m map[string]string
+			m["boom"] = "boom"
+			return "unreachable", nil
+		},
+	}
+
+	results, err := Map(context.Background(), tasks, Options{Mode: CollectAll})
+
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected a panic error; got %v", err)
+	}
+	if len(pe.Stack) == 0 {
+		t.Fatalf("expected the panic's stack")
+	}
+	if results[0] != "ok" || results[1] != "" {
+		t.Fatalf("expected the panicking task to have no result; got %v", results)
+	}
+}
+
+func TestMapEmpty(t *testing.T) {
+	results, err := Map(context.Background(), []func(context.Context) (int, error){}, Options{})
+	if err != nil || len(results) != 0 {
+		t.Fatalf("expected no results or error; got %v, %v", results, err)
+	}
+}